@@ -4,19 +4,94 @@ import (
 	"clank/config"
 	"clank/internal/api/routes"
 	"clank/internal/db"
+	"clank/internal/limits"
+	"clank/internal/llm/sequential"
+	"clank/internal/logging"
+	"clank/internal/models"
+	"context"
+	"errors"
 	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// defaultShutdownTimeout bounds request draining when no timeout is configured
+const defaultShutdownTimeout = 30 * time.Second
+
 func main() {
 	cfg := config.LoadConfig()
+	logging.Setup(os.Stderr, cfg.Server.LogFormat, cfg.Server.LogLevel)
+
+	entityTypes := cfg.Graph.EntityTypes
+	if len(entityTypes) == 0 {
+		entityTypes = models.DefaultEntityTypes
+	}
+	models.SetEntityTaxonomy(models.NewEntityTaxonomy(entityTypes, cfg.Graph.UnknownEntityType))
+	if err := db.SetEventRoleEdges(cfg.Graph.EventRoleEdges); err != nil {
+		log.Fatalf("Invalid graph.event_role_edges in config/config.yaml: %v", err)
+	}
+	if err := sequential.ValidateStageModels(cfg.LLM.StageModels); err != nil {
+		log.Fatalf("Invalid llm.stage_models in config/config.yaml: %v", err)
+	}
+	limits.Configure(cfg.Limits.MaxConcurrentBrowsers, cfg.Limits.MaxConcurrentLLM, cfg.Limits.MaxQueued)
 
 	// Initialize Neo4j connection
-	if err := db.InitDB(cfg.Neo4j.URI, cfg.Neo4j.Username, cfg.Neo4j.Password); err != nil {
-		log.Fatalf("Failed to initialize Neo4j: %v", err)
+	if err := db.InitDB(cfg.Neo4j); err != nil {
+		log.Fatalf("Cannot reach Neo4j at %s; check that it is running and that neo4j.uri, neo4j.username and neo4j.password in config/config.yaml are correct: %v", cfg.Neo4j.URI, err)
 	}
 	defer db.CloseDB()
 
-	r := routes.SetupRouter()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	db.StartHealthCheck(ctx, cfg.Neo4j.HealthCheckInterval)
+
+	ln, err := net.Listen("tcp", cfg.Server.Address)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", cfg.Server.Address, err)
+	}
+
+	timeout := cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	srv := &http.Server{Handler: routes.SetupRouter(ctx)}
 	log.Printf("Server running on %s", cfg.Server.Address)
-	r.Run(cfg.Server.Address)
+	if err := serve(ctx, srv, ln, timeout); err != nil {
+		log.Printf("Server stopped with error: %v", err)
+	}
+	// The deferred CloseDB runs only after in-flight requests have drained
+}
+
+// serve runs srv on ln until ctx is cancelled, then stops accepting connections and
+// waits up to timeout for in-flight requests to finish.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, draining in-flight requests (timeout %s)...", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"fmt"
+
+	"clank/internal/models"
+)
+
+// confidenceThresholds are the minimum confidences for extracted items to be
+// persisted; zero keeps everything
+type confidenceThresholds struct {
+	entity       float64
+	relationship float64
+}
+
+// withOverrides replaces the thresholds a request sets, rejecting values outside 0..1
+func (t confidenceThresholds) withOverrides(entity, relationship *float64) (confidenceThresholds, error) {
+	if entity != nil {
+		if *entity < 0 || *entity > 1 {
+			return t, fmt.Errorf("minEntityConfidence must be between 0 and 1")
+		}
+		t.entity = *entity
+	}
+	if relationship != nil {
+		if *relationship < 0 || *relationship > 1 {
+			return t, fmt.Errorf("minRelationshipConfidence must be between 0 and 1")
+		}
+		t.relationship = *relationship
+	}
+	return t, nil
+}
+
+// apply returns a copy of result without entities and relationships below the
+// thresholds, recording each skipped item as a warning. Relationships to a skipped
+// entity are skipped too so nothing points at a node that was never written.
+// result itself is left untouched since it may be cached and reused.
+func (t confidenceThresholds) apply(result *models.ExtractionResult) *models.ExtractionResult {
+	if result == nil || (t.entity <= 0 && t.relationship <= 0) {
+		return result
+	}
+
+	filtered := *result
+	filtered.Entities = make([]models.ExtractedEntity, 0, len(result.Entities))
+	filtered.Relationships = make([]models.ExtractedRelationship, 0, len(result.Relationships))
+	filtered.Warnings = append([]string(nil), result.Warnings...)
+
+	skipped := make(map[string]bool)
+	for _, entity := range result.Entities {
+		if entity.Confidence < t.entity {
+			skipped[entity.ID] = true
+			filtered.Warnings = append(filtered.Warnings, fmt.Sprintf(
+				"skipped %s %q: confidence %.2f is below %.2f", entity.Type, entity.Name, entity.Confidence, t.entity))
+			continue
+		}
+		filtered.Entities = append(filtered.Entities, entity)
+	}
+
+	for _, rel := range result.Relationships {
+		switch {
+		case rel.Confidence < t.relationship:
+			filtered.Warnings = append(filtered.Warnings, fmt.Sprintf(
+				"skipped %s relationship %s -> %s: confidence %.2f is below %.2f", rel.Type, rel.FromID, rel.ToID, rel.Confidence, t.relationship))
+		case skipped[rel.FromID] || skipped[rel.ToID]:
+			filtered.Warnings = append(filtered.Warnings, fmt.Sprintf(
+				"skipped %s relationship %s -> %s: it references a skipped entity", rel.Type, rel.FromID, rel.ToID))
+		default:
+			filtered.Relationships = append(filtered.Relationships, rel)
+		}
+	}
+	return &filtered
+}
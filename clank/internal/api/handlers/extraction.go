@@ -2,15 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 	"net/url"
 	"time"
 
 	"clank/config"
 	"clank/internal/db"
+	"clank/internal/limits"
 	"clank/internal/llm"
 	"clank/internal/llm/sequential"
+	"clank/internal/logging"
 	"clank/internal/models"
 	browser "clank/internal/tools/browser"
 	"clank/pkg/extraction"
@@ -28,19 +30,28 @@ type ExtractionHandler struct {
 // NewExtractionHandler creates a new extraction handler with sequential analysis
 func NewExtractionHandler(cfg *config.Config) *ExtractionHandler {
 	llmClient := llm.NewClient(cfg)
+	controller, _ := newAnalysisController(llmClient)
+	controller.SetWebhook(sequential.NewWebhook(cfg.Webhook.Secret, cfg.Webhook.MaxAttempts, cfg.Webhook.Timeout, cfg.Webhook.AllowedHosts))
 	return &ExtractionHandler{
 		scraper:            browser.NewArticleScraper(),
 		processor:          extraction.NewContentProcessor(),
 		llm:                llmClient,
 		db:                 db.NewArticleStore(),
-		analysisController: sequential.NewAnalysisController(llmClient),
+		analysisController: controller,
 	}
 }
 
+// isHTTPURL reports whether s is an absolute http or https URL
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 // ExtractionRequest represents the request to extract information from a URL
 type ExtractionRequest struct {
-	URL   string `json:"url"`
-	Depth int    `json:"depth,omitempty"` // Analysis depth (2-10)
+	URL         string `json:"url"`
+	Depth       int    `json:"depth,omitempty"`       // Analysis depth (2-10)
+	CallbackURL string `json:"callbackUrl,omitempty"` // Receives the session summary when the analysis ends
 }
 
 // ExtractionResponse represents the complete extraction response
@@ -53,22 +64,23 @@ type ExtractionResponse struct {
 
 // HandleURLExtraction processes a URL for article extraction with sequential analysis
 func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.Request) {
-	log.Printf("[Extraction] Received request from %s", r.RemoteAddr)
+	logger := logging.For(r.Context(), "extraction")
+	logger.Info("Received request", "remote_addr", r.RemoteAddr)
 
 	if r.Method != http.MethodPost {
-		log.Printf("[Extraction] Invalid method %s from %s", r.Method, r.RemoteAddr)
+		logger.Warn("Invalid method", "method", r.Method, "remote_addr", r.RemoteAddr)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var req ExtractionRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("[Extraction] Failed to decode request body: %v", err)
+		logger.Warn("Failed to decode request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("[Extraction] Processing URL: %s", req.URL)
+	logger.Info("Processing URL", "url", req.URL)
 
 	// Validate URL
 	if req.URL == "" {
@@ -81,6 +93,11 @@ func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	if req.CallbackURL != "" && !isHTTPURL(req.CallbackURL) {
+		http.Error(w, "callbackUrl must be an http or https URL", http.StatusBadRequest)
+		return
+	}
+
 	// Validate depth (default to 3 if not specified)
 	if req.Depth == 0 {
 		req.Depth = 3
@@ -94,32 +111,32 @@ func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.R
 	ctx := r.Context()
 
 	// Initialize scraper if needed
-	log.Println("[Extraction] Initializing scraper...")
 	if err := h.scraper.Initialize(); err != nil {
-		log.Printf("[Extraction] Scraper initialization failed: %v", err)
+		logger.Error("Scraper initialization failed", "error", err)
 		http.Error(w, "Failed to initialize scraper: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Scrape the article
-	log.Printf("[Extraction] Starting article scraping for URL: %s", req.URL)
-	article, err := h.scraper.ScrapeArticle(req.URL)
+	article, err := h.scraper.ScrapeArticle(r.Context(), req.URL)
 	if err != nil {
-		log.Printf("[Extraction] Article scraping failed: %v", err)
+		logger.Error("Article scraping failed", "url", req.URL, "error", err)
+		if errors.Is(err, limits.ErrBusy) {
+			http.Error(w, "Too many extractions in progress, try again later", http.StatusTooManyRequests)
+			return
+		}
 		http.Error(w, "Failed to scrape article: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("[Extraction] Successfully scraped article, length: %d characters", len(article.Content))
 
 	// Process the content
-	log.Println("[Extraction] Starting article processing...")
-	result, err := h.processor.ProcessArticle(article.Content)
+	result, err := h.processor.ProcessSiteArticle(articleDomain(article), article.Content)
 	if err != nil {
-		log.Printf("[Extraction] Article processing failed: %v", err)
+		logger.Error("Article processing failed", "error", err)
 		http.Error(w, "Failed to process article: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Println("[Extraction] Article processing completed successfully")
+	logger.Debug("Article processed", "characters", len(result.Content))
 
 	// Update article with processed content
 	article.Content = result.Content
@@ -131,13 +148,13 @@ func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.R
 	}
 
 	// Save the article
-	log.Println("[Extraction] Saving article to database...")
+	graphLogger := logging.For(ctx, "graph")
 	if err := h.db.SaveArticle(article); err != nil {
-		log.Printf("[Extraction] Failed to save article: %v", err)
+		graphLogger.Error("Failed to save article", "article_id", article.ID, "error", err)
 		http.Error(w, "Failed to save article: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
-	log.Printf("[Extraction] Article saved successfully with ID: %s", article.ID)
+	graphLogger.Info("Article integrated into the graph", "article_id", article.ID)
 
 	// Set timestamps
 	article.ExtractedAt = time.Now()
@@ -145,7 +162,6 @@ func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.R
 	article.UpdatedAt = time.Now()
 
 	// Configure sequential analysis
-	log.Printf("[Extraction] Configuring analysis with depth %d...", req.Depth)
 	config := &sequential.AnalysisConfig{
 		Depth:                req.Depth,
 		MaxStages:            5,
@@ -153,8 +169,9 @@ func (h *ExtractionHandler) HandleURLExtraction(w http.ResponseWriter, r *http.R
 		TimeoutPerStage:      60 * time.Second,
 		EnableCrossReference: true,
 		EnableHypotheses:     true,
+		CallbackURL:          req.CallbackURL,
 	}
-	log.Println("[Extraction] Analysis configuration complete")
+	logger.Info("Starting sequential analysis", "article_id", article.ID, "depth", req.Depth)
 
 	// Start sequential analysis
 	session, err := h.analysisController.StartAnalysis(ctx, article, config)
@@ -231,7 +248,10 @@ func (h *ExtractionHandler) HandleAnalysisTermination(w http.ResponseWriter, r *
 		return
 	}
 
-	if err := h.analysisController.TerminateSession(req.SessionID); err != nil {
+	if err := h.analysisController.TerminateSession(req.SessionID); errors.Is(err, sequential.ErrSessionNotFound) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to terminate session: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -301,6 +321,7 @@ func (h *ExtractionHandler) HandleAnalysisEvidence(w http.ResponseWriter, r *htt
 	response := map[string]interface{}{
 		"sessionId": sessionID,
 		"evidence":  session.Evidence,
+		"chains":    session.EvidenceChains,
 		"count":     len(session.Evidence),
 	}
 
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"sync"
+
+	"clank/internal/models"
+)
+
+// cachedExtraction is a successful extraction and the article it was made for
+type cachedExtraction struct {
+	articleID string
+	result    *models.ExtractionResult
+}
+
+// extractionCache remembers recent successful extractions by content fingerprint
+// so duplicates of saved articles get the full result back rather than one
+// rebuilt from the stored entities. The oldest entry is evicted once maxEntries
+// is reached.
+type extractionCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]cachedExtraction
+	order      []string
+}
+
+// newExtractionCache returns a cache holding up to maxEntries extractions, or nil
+// (caching disabled) when maxEntries is not positive
+func newExtractionCache(maxEntries int) *extractionCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	return &extractionCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]cachedExtraction),
+	}
+}
+
+// get returns the cached extraction for a fingerprint. A nil cache never matches.
+func (c *extractionCache) get(fingerprint string) (cachedExtraction, bool) {
+	if c == nil || fingerprint == "" {
+		return cachedExtraction{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[fingerprint]
+	return entry, ok
+}
+
+// put records a successful extraction. A nil cache ignores it.
+func (c *extractionCache) put(fingerprint, articleID string, result *models.ExtractionResult) {
+	if c == nil || fingerprint == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[fingerprint]; !exists {
+		if len(c.order) >= c.maxEntries {
+			delete(c.entries, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, fingerprint)
+	}
+	c.entries[fingerprint] = cachedExtraction{articleID: articleID, result: result}
+}
@@ -1,17 +1,30 @@
 package handlers
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
 	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"clank/config"
+	"clank/internal/authority"
 	"clank/internal/db"
+	"clank/internal/limits"
 	"clank/internal/llm"
+	llmprompts "clank/internal/llm/prompts"
 	"clank/internal/llm/sequential"
+	"clank/internal/logging"
+	"clank/internal/metrics"
+	"clank/internal/models"
+	"clank/internal/prompts"
 	browser "clank/internal/tools/browser"
 	"clank/pkg/extraction"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // ExtractionGinHandler handles article content extraction requests with sequential analysis for Gin
@@ -20,107 +33,767 @@ type ExtractionGinHandler struct {
 	processor          Processor
 	llm                LLMClient
 	db                 Store
-	analysisController *sequential.AnalysisController
+	extractor          ArticleExtractor
+	analysisController AnalysisStarter
+	sessions           SessionLookup
+	enricher           *llmprompts.ArticleExtractionPrompt
+	enrich             bool
+	authority          EntityAuthority
+	defaultMode        string
+	analysisTimeout    time.Duration
+	lowConfidence      string
+	indicatorFlags     []string
+	stageModels        map[string]string
+	promptCache        bool
+	thresholds         confidenceThresholds
+	reliability        sourceReliability
+	localizer          *responseLocalizer
+	duplicates         *extractionCache // Full extractions of recently saved articles, returned for duplicate submissions
+	retries            *integrationQueue
+	retryInterval      time.Duration // How often StartRetryQueue retries queued integrations
+	pricing            sequential.Pricing
+	promptLoader       *prompts.PromptLoader // The stage and events prompt templates; nil when the built-in prompts are used
 }
 
+// Extraction modes selectable per request
+const (
+	extractionModeFast = "fast" // single-pass entity/relationship extraction
+	extractionModeDeep = "deep" // multi-stage sequential analysis
+)
+
 // NewExtractionGinHandler creates a new extraction handler with sequential analysis for Gin
 func NewExtractionGinHandler(cfg *config.Config) *ExtractionGinHandler {
 	llmClient := llm.NewClient(cfg)
-	return &ExtractionGinHandler{
-		scraper:            browser.NewArticleScraper(),
-		processor:          extraction.NewContentProcessor(),
+	scraper := browser.NewArticleScraper()
+	scraper.SetMaxPageBytes(cfg.Extraction.MaxPageBytes)
+	if err := scraper.SetSiteSelectors(siteRules(cfg)); err != nil {
+		logging.For(context.Background(), "extraction").Warn("Ignoring site selectors", "error", err)
+	}
+	if err := scraper.SetNetworkOptions(networkOptions(cfg)); err != nil {
+		logging.For(context.Background(), "extraction").Warn("Ignoring scraper proxy, headers and cookies", "error", err)
+	}
+	if err := scraper.SetWaitStrategies(cfg.Scraper.WaitStrategies); err != nil {
+		logging.For(context.Background(), "extraction").Warn("Ignoring scraper wait strategies", "error", err)
+	}
+	scraper.SetJSONFields(browser.JSONFields{
+		Title:       cfg.Scraper.JSONFields.Title,
+		Content:     cfg.Scraper.JSONFields.Content,
+		Author:      cfg.Scraper.JSONFields.Author,
+		PublishDate: cfg.Scraper.JSONFields.PublishDate,
+	})
+	sessions, err := browser.NewSessionStore(cfg.Scraper.SessionDir, cfg.Scraper.SessionKey)
+	if err != nil {
+		logging.For(context.Background(), "extraction").Warn("Session profiles disabled", "error", err)
+	}
+	scraper.SetSessionStore(sessions)
+	processor := extraction.NewContentProcessor()
+	processor.SetBoilerplate(boilerplate(cfg))
+	controller, promptLoader := newAnalysisController(llmClient)
+	controller.SetWebhook(sequential.NewWebhook(cfg.Webhook.Secret, cfg.Webhook.MaxAttempts, cfg.Webhook.Timeout, cfg.Webhook.AllowedHosts))
+	h := &ExtractionGinHandler{
+		scraper:            scraper,
+		processor:          processor,
 		llm:                llmClient,
 		db:                 db.NewArticleStore(),
-		analysisController: sequential.NewAnalysisController(llmClient),
+		extractor:          llmClient,
+		analysisController: controller,
+		sessions:           controller,
+		enricher:           llmprompts.NewArticleExtractionPrompt(),
+		enrich:             cfg.Extraction.Enrichment,
+		defaultMode:        cfg.Extraction.DefaultMode,
+		analysisTimeout:    cfg.Extraction.AnalysisTimeout,
+		lowConfidence:      cfg.Extraction.LowConfidence,
+		indicatorFlags:     cfg.Extraction.IndicatorFlags,
+		stageModels:        cfg.LLM.StageModels,
+		promptCache:        cfg.LLM.SessionPromptCache,
+		localizer:          newResponseLocalizer(cfg),
+		duplicates:         newExtractionCache(cfg.Extraction.DuplicateCache),
+		pricing:            sequential.Pricing{PromptPer1K: cfg.LLM.PromptPrice, CompletionPer1K: cfg.LLM.CompletionPrice},
+		promptLoader:       promptLoader,
+		thresholds: confidenceThresholds{
+			entity:       cfg.Graph.MinEntityConfidence,
+			relationship: cfg.Graph.MinRelationshipConfidence,
+		},
+		reliability: newSourceReliability(cfg.Graph.SourceReliability, cfg.Graph.DefaultSourceReliability),
+	}
+	if cfg.Enrichment.Enabled {
+		h.authority = authority.NewClient(cfg.Enrichment.Endpoint, cfg.Enrichment.Language, cfg.Enrichment.Timeout, cfg.Enrichment.CacheTTL)
+	}
+	retries, err := newIntegrationQueue(cfg.Graph.RetryQueueDir, h.db)
+	if err != nil {
+		logging.For(context.Background(), "graph").Warn("Integration retry queue disabled", "error", err)
+	}
+	if retries != nil {
+		h.retries = retries
+		h.retryInterval = cfg.Graph.RetryInterval
 	}
+	return h
 }
 
-// HandleURLExtraction processes a URL for article extraction with sequential analysis
-func (h *ExtractionGinHandler) HandleURLExtraction(c *gin.Context) {
-	var req struct {
-		URL   string `json:"url"`
-		Depth int    `json:"depth,omitempty"` // Analysis depth (2-10)
+// siteRules converts the configured per-domain selectors for the scraper
+func siteRules(cfg *config.Config) extraction.SiteRules {
+	rules := make(extraction.SiteRules, len(cfg.Extraction.SiteSelectors))
+	for domain, selectors := range cfg.Extraction.SiteSelectors {
+		rules[strings.ToLower(domain)] = extraction.SiteSelectors{
+			Content: selectors.Content,
+			Skip:    selectors.Skip,
+		}
 	}
+	return rules
+}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("[Extraction] Invalid request body: %v", err)
-		c.JSON(400, gin.H{"error": "Invalid request body"})
-		return
+// boilerplate converts the configured boilerplate phrases, global and per site,
+// into the content processor's
+func boilerplate(cfg *config.Config) extraction.Boilerplate {
+	b := extraction.Boilerplate{
+		Phrases:    cfg.Extraction.Boilerplate,
+		Sites:      make(map[string][]string),
+		MinRepeats: cfg.Extraction.BoilerplateRepeats,
+	}
+	for domain, selectors := range cfg.Extraction.SiteSelectors {
+		if len(selectors.Boilerplate) > 0 {
+			b.Sites[strings.ToLower(domain)] = selectors.Boilerplate
+		}
+	}
+	return b
+}
+
+// networkOptions converts the scraper config into browser network options
+func networkOptions(cfg *config.Config) browser.NetworkOptions {
+	opts := browser.NetworkOptions{
+		Proxy:   cfg.Scraper.Proxy,
+		Headers: cfg.Scraper.Headers,
 	}
+	for _, cookie := range cfg.Scraper.Cookies {
+		opts.Cookies = append(opts.Cookies, browser.Cookie{
+			Name:   cookie.Name,
+			Value:  cookie.Value,
+			Domain: cookie.Domain,
+			Path:   cookie.Path,
+		})
+	}
+	return opts
+}
 
-	log.Printf("[Extraction] Processing URL: %s", req.URL)
+// stagePromptsDir holds the prompt templates the sequential analysis stages render
+const stagePromptsDir = "./prompts"
 
-	// Validate URL
-	if req.URL == "" {
-		c.JSON(400, gin.H{"error": "URL is required"})
-		return
+// newAnalysisController creates an analysis controller whose stages, like the
+// client's two-phase events pass, render their prompts from stagePromptsDir, and
+// returns the loader they render from. The built-in prompts are kept, and the
+// loader is nil, if it can't be loaded.
+func newAnalysisController(llmClient *llm.Client) (*sequential.AnalysisController, *prompts.PromptLoader) {
+	controller := sequential.NewAnalysisController(llmClient)
+	loader := prompts.NewPromptLoader(stagePromptsDir)
+	if err := loader.LoadPrompts(); err != nil {
+		logging.For(context.Background(), "extraction").Warn("Using built-in stage prompts", "error", err)
+		return controller, nil
 	}
+	controller.SetPromptRenderer(loader)
+	llmClient.SetPromptRenderer(loader)
+	return controller, loader
+}
 
-	if _, err := url.Parse(req.URL); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid URL"})
-		return
+// Prompts returns the loader the extraction prompts are rendered from, or nil
+// when the built-in prompts are used
+func (h *ExtractionGinHandler) Prompts() *prompts.PromptLoader {
+	return h.promptLoader
+}
+
+// extractionOptions are the request fields shared by URL, text and PDF extraction,
+// sent as JSON or, for uploads, as form fields
+type extractionOptions struct {
+	Depth  int      `json:"depth,omitempty" form:"depth"`   // Analysis depth (2-10)
+	Mode   string   `json:"mode,omitempty" form:"mode"`     // "fast" or "deep" (defaults to the configured mode)
+	Force  bool     `json:"force,omitempty" form:"force"`   // Re-analyze even if the same content was already analyzed
+	Stages []string `json:"stages,omitempty" form:"stages"` // Ordered stage names for deep mode (defaults to the full pipeline truncated by depth)
+
+	// Deep mode POSTs the session summary here when the session ends
+	CallbackURL string `json:"callbackUrl,omitempty" form:"callbackUrl"`
+
+	// Deep mode reuses the stages before resumeFrom from this completed session
+	// of the same article, e.g. to deepen an analysis without redoing extraction
+	ResumeSessionID string `json:"resumeSessionId,omitempty" form:"resumeSessionId"`
+	ResumeFrom      string `json:"resumeFrom,omitempty" form:"resumeFrom"`
+
+	// Deep mode ends the session after this long (e.g. "90s"), keeping the stages
+	// that completed; overrides the configured analysis timeout
+	TotalTimeout string `json:"totalTimeout,omitempty" form:"totalTimeout"`
+
+	// Deep mode's handling of a stage below the confidence threshold: "continue",
+	// "stop" or "skip-dependents"; overrides the configured policy
+	LowConfidencePolicy string `json:"lowConfidencePolicy,omitempty" form:"lowConfidencePolicy"`
+
+	// Fast mode extracts events in a second LLM call given the entities already
+	// extracted, linking events to those entities more reliably
+	TwoPhaseEvents bool `json:"twoPhaseEvents,omitempty" form:"twoPhaseEvents"`
+
+	// Override the configured persistence thresholds for this request (0..1)
+	MinEntityConfidence       *float64 `json:"minEntityConfidence,omitempty" form:"minEntityConfidence"`
+	MinRelationshipConfidence *float64 `json:"minRelationshipConfidence,omitempty" form:"minRelationshipConfidence"`
+
+	// Response shape: "verbose" (default), "compact" or "flat", and the optional
+	// sections (content, debug, mentions, evidence) to include
+	Verbosity string   `json:"verbosity,omitempty" form:"verbosity"`
+	Fields    []string `json:"fields,omitempty" form:"fields"`
+}
+
+// extractionRun is a validated extraction request with its defaults filled in
+type extractionRun struct {
+	extractionOptions
+	thresholds confidenceThresholds
+	shape      responseShape
+	resuming   bool
+	integrate  bool          // Save the article and its extraction to the graph
+	stored     bool          // The article is re-extracted from the store, its content already processed
+	timeout    time.Duration // Total budget for deep analysis (0 is unlimited)
+}
+
+// validateOptions checks the shared request fields and fills in their defaults
+func (h *ExtractionGinHandler) validateOptions(opts extractionOptions) (extractionRun, error) {
+	req := extractionRun{extractionOptions: opts, integrate: true}
+	if err := sequential.ValidatePipeline(req.Stages); err != nil {
+		return req, err
+	}
+	thresholds, err := h.thresholds.withOverrides(req.MinEntityConfidence, req.MinRelationshipConfidence)
+	if err != nil {
+		return req, err
+	}
+	req.thresholds = thresholds
+	if req.shape, err = parseResponseShape(req.Verbosity, req.Fields); err != nil {
+		return req, err
 	}
 
+	if req.CallbackURL != "" && !isHTTPURL(req.CallbackURL) {
+		return req, errors.New("callbackUrl must be an http or https URL")
+	}
 	// Validate depth (default to 3 if not specified)
 	if req.Depth == 0 {
 		req.Depth = 3
 	}
 	if req.Depth < 2 || req.Depth > 10 {
-		c.JSON(400, gin.H{"error": "Depth must be between 2 and 10"})
+		return req, errors.New("Depth must be between 2 and 10")
+	}
+
+	if req.Mode == "" {
+		req.Mode = h.defaultMode
+	}
+	if req.Mode == "" {
+		req.Mode = extractionModeFast
+	}
+	if req.Mode != extractionModeFast && req.Mode != extractionModeDeep {
+		return req, errors.New("Mode must be fast or deep")
+	}
+	req.timeout = h.analysisTimeout
+	if req.TotalTimeout != "" {
+		timeout, err := time.ParseDuration(req.TotalTimeout)
+		if err != nil || timeout <= 0 {
+			return req, errors.New(`totalTimeout must be a positive duration, e.g. "90s"`)
+		}
+		req.timeout = timeout
+	}
+	if req.LowConfidencePolicy == "" {
+		req.LowConfidencePolicy = h.lowConfidence
+	}
+	if !sequential.ValidLowConfidencePolicy(req.LowConfidencePolicy) {
+		return req, errors.New("lowConfidencePolicy must be continue, stop or skip-dependents")
+	}
+	req.resuming = req.ResumeSessionID != "" || req.ResumeFrom != ""
+	if req.resuming && req.Mode != extractionModeDeep {
+		return req, errors.New("resumeSessionId and resumeFrom require deep mode")
+	}
+	if req.TwoPhaseEvents && req.Mode != extractionModeFast {
+		return req, errors.New("twoPhaseEvents requires fast mode")
+	}
+	return req, nil
+}
+
+// HandleURLExtraction processes a URL for article extraction. The mode field selects
+// single-pass extraction ("fast") or sequential analysis ("deep"); both return the
+// same response envelope.
+func (h *ExtractionGinHandler) HandleURLExtraction(c *gin.Context) {
+	var body struct {
+		URL string `json:"url"`
+
+		// Sent with this request's page load in addition to the configured headers,
+		// e.g. a Referer or Cookie the site requires
+		Headers map[string]string `json:"headers,omitempty"`
+
+		// Scrape in a saved login session. With login steps, they are run first
+		// and the cookies they leave are saved under this name for later requests.
+		SessionProfile string              `json:"sessionProfile,omitempty"`
+		Login          []browser.LoginStep `json:"login,omitempty"`
+
+		extractionOptions
+	}
+
+	logger := logging.For(c.Request.Context(), "extraction")
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Warn("Invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req, err := h.validateOptions(body.extractionOptions)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := browser.ValidateHeaders(body.Headers); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	ctx := browser.WithRequestHeaders(c.Request.Context(), body.Headers)
+	if body.SessionProfile != "" || len(body.Login) > 0 {
+		session := browser.Session{Profile: body.SessionProfile, Steps: body.Login}
+		if body.SessionProfile == "" {
+			c.JSON(400, gin.H{"error": "login requires a sessionProfile to save the session as"})
+			return
+		}
+		if err := session.Validate(); err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		ctx = browser.WithSession(ctx, session)
+	}
+
+	logger.Info("Processing URL", "url", body.URL)
+
+	// Validate URL
+	if body.URL == "" {
+		c.JSON(400, gin.H{"error": "URL is required"})
+		return
+	}
+
+	if _, err := url.Parse(body.URL); err != nil {
+		c.JSON(400, gin.H{"error": "Invalid URL"})
 		return
 	}
+	defer func() {
+		metrics.Extractions.Inc(req.Mode, metrics.HTTPStatus(c.Writer.Status()))
+	}()
 
 	// Initialize scraper if needed
-	log.Println("[Extraction] Initializing scraper...")
 	if err := h.scraper.Initialize(); err != nil {
-		log.Printf("[Extraction] Scraper initialization failed: %v", err)
+		logger.Error("Scraper initialization failed", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to initialize scraper: " + err.Error()})
 		return
 	}
 
 	// Scrape the article
-	log.Printf("[Extraction] Starting article scraping for URL: %s", req.URL)
-	article, err := h.scraper.ScrapeArticle(req.URL)
+	article, err := h.scraper.ScrapeArticle(ctx, body.URL)
 	if err != nil {
-		log.Printf("[Extraction] Article scraping failed: %v", err)
+		logger.Error("Article scraping failed", "url", body.URL, "error", err)
+		if errors.Is(err, browser.ErrPageTooLarge) {
+			c.JSON(413, gin.H{"error": "Article page is too large: " + err.Error()})
+			return
+		}
+		// A feed lists articles; each item's URL can be submitted instead
+		var feedErr *browser.FeedError
+		if errors.As(err, &feedErr) {
+			c.JSON(422, gin.H{"error": "URL is a feed, not an article; submit its items' URLs", "feed": feedErr.Feed})
+			return
+		}
+		// Consent walls and empty pages would cost an LLM call extracting nothing
+		var unavailableErr *browser.UnavailableError
+		if errors.As(err, &unavailableErr) {
+			c.JSON(422, gin.H{"error": "No article content found at URL", "reason": unavailableErr.Reason})
+			return
+		}
+		if errors.Is(err, browser.ErrUnsupportedContent) {
+			c.JSON(415, gin.H{"error": "Unsupported content: " + err.Error()})
+			return
+		}
+		if errors.Is(err, limits.ErrBusy) {
+			c.JSON(429, gin.H{"error": "Too many extractions in progress, try again later"})
+			return
+		}
+		if errors.Is(err, browser.ErrSessionsDisabled) {
+			c.JSON(400, gin.H{"error": "Session profiles are disabled; set scraper.session_dir"})
+			return
+		}
+		if errors.Is(err, browser.ErrSessionNotFound) {
+			c.JSON(404, gin.H{"error": err.Error() + "; log in with login steps first"})
+			return
+		}
+		if errors.Is(err, browser.ErrLoginFailed) {
+			c.JSON(502, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(500, gin.H{"error": "Failed to scrape article: " + err.Error()})
 		return
 	}
-	log.Printf("[Extraction] Successfully scraped article, length: %d characters", len(article.Content))
 
-	// Process the content
-	log.Println("[Extraction] Starting article processing...")
-	result, err := h.processor.ProcessArticle(article.Content)
+	h.extract(c, req, article)
+}
+
+// minTextContentLength is the shortest text, in characters, accepted for text extraction
+const minTextContentLength = 100
+
+// HandleTextExtraction extracts from article text the client already has, e.g.
+// pasted or taken from a PDF or email, skipping the scraper. It accepts the same
+// options as HandleURLExtraction and returns the same envelope. With integrate set
+// to false (fast mode only) nothing is saved to the graph.
+func (h *ExtractionGinHandler) HandleTextExtraction(c *gin.Context) {
+	var body struct {
+		Title     string `json:"title"`
+		Content   string `json:"content"`             // Plain text or HTML
+		URL       string `json:"url,omitempty"`       // Where the text came from, if anywhere
+		Source    string `json:"source,omitempty"`    // Publisher or origin, e.g. "Court filing"
+		Integrate *bool  `json:"integrate,omitempty"` // Save the article and extraction to the graph (default true)
+
+		extractionOptions
+	}
+
+	logger := logging.For(c.Request.Context(), "extraction")
+	if err := c.ShouldBindJSON(&body); err != nil {
+		logger.Warn("Invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req, err := h.validateOptions(body.extractionOptions)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to process article: " + err.Error()})
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(body.Content)); n < minTextContentLength {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("content must be at least %d characters (got %d)", minTextContentLength, n)})
+		return
+	}
+	if err := req.submitted(body.URL, body.Integrate); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
-	log.Println("[Extraction] Article processing completed successfully")
+	defer func() {
+		metrics.Extractions.Inc(req.Mode, metrics.HTTPStatus(c.Writer.Status()))
+	}()
+
+	article := newSubmittedArticle(body.Title, body.Content, body.URL, body.Source)
+	logger.Info("Processing submitted text", "characters", len(article.Content))
+
+	h.extract(c, req, article)
+}
 
-	// Update article with processed content
-	article.Content = result.Content
-	if result.Title != "" {
-		article.Title = result.Title
+// submitted checks the fields of an article the client submitted instead of
+// having it scraped: where it came from and whether to save it
+func (r *extractionRun) submitted(sourceURL string, integrate *bool) error {
+	if sourceURL != "" && !isHTTPURL(sourceURL) {
+		return errors.New("url must be an http or https URL")
+	}
+	if integrate != nil {
+		r.integrate = *integrate
 	}
-	if result.Metadata != nil {
-		article.Metadata = result.Metadata
+	if !r.integrate && r.Mode == extractionModeDeep {
+		return errors.New("Deep mode analyzes saved articles, so integrate can't be false")
 	}
+	return nil
+}
 
-	// Save the article
-	log.Println("[Extraction] Saving article to database...")
-	if err := h.db.SaveArticle(article); err != nil {
-		log.Printf("[Extraction] Failed to save article: %v", err)
-		c.JSON(500, gin.H{"error": "Failed to save article: " + err.Error()})
-		return
+// newSubmittedArticle builds an article from submitted content. The source falls
+// back to the host of sourceURL.
+func newSubmittedArticle(title, content, sourceURL, source string) *models.Article {
+	now := time.Now()
+	article := &models.Article{
+		ID:          uuid.New().String(),
+		URL:         sourceURL,
+		Title:       strings.TrimSpace(title),
+		Content:     content,
+		Source:      source,
+		ExtractedAt: now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Metadata:    make(map[string]interface{}),
 	}
-	log.Printf("[Extraction] Article saved successfully with ID: %s", article.ID)
+	if article.Source == "" && sourceURL != "" {
+		if parsed, err := url.Parse(sourceURL); err == nil {
+			article.Source = parsed.Host
+		}
+	}
+	return article
+}
+
+// extract processes a scraped or submitted article, extracts from it in the
+// requested mode and writes the response
+func (h *ExtractionGinHandler) extract(c *gin.Context, req extractionRun, article *models.Article) {
+	logger := logging.For(c.Request.Context(), "extraction")
+
+	// Stored articles being re-extracted were processed when first extracted
+	if !req.stored {
+		// Process the content
+		result, err := h.processor.ProcessSiteArticle(articleDomain(article), article.Content)
+		if err != nil {
+			logger.Error("Article processing failed", "error", err)
+			c.JSON(500, gin.H{"error": "Failed to process article: " + err.Error()})
+			return
+		}
+		logger.Debug("Article processed", "characters", len(result.Content))
+
+		// Update article with processed content
+		article.Content = result.Content
+		if result.Title != "" {
+			article.Title = result.Title
+		}
+		// Keep what the scraper or upload recorded alongside the processor's metadata
+		if len(result.Metadata) > 0 && article.Metadata == nil {
+			article.Metadata = make(map[string]interface{})
+		}
+		for key, value := range result.Metadata {
+			article.Metadata[key] = value
+		}
+		article.Language = result.Language
+	}
+
+	// Resubmissions and syndicated copies return the stored analysis unless forced
+	article.ContentHash = extraction.ContentFingerprint(article.Content)
+	if article.ContentHash != "" && req.integrate {
+		existing, err := h.db.FindArticleByContentHash(article.ContentHash)
+		if err != nil {
+			logger.Warn("Content hash lookup failed", "error", err)
+		} else if existing != nil {
+			// Resuming re-analyzes the stored article, which the resumed session belongs to
+			if !req.Force && !req.resuming {
+				h.respondWithExisting(c, existing, article.URL, req.Mode, req.shape)
+				return
+			}
+			// Re-analysis refreshes the stored article instead of duplicating it
+			article.ID = existing.ID
+		}
+	}
+
+	// Optionally enrich the article with an LLM summary before saving
+	if h.enrich {
+		h.enrichArticle(c.Request.Context(), article)
+	}
+	reliability := h.reliability.weight(article)
+	h.reliability.annotate(article, reliability)
 
-	// Return successful response
-	c.JSON(200, gin.H{
+	response := gin.H{
 		"articleId": article.ID,
 		"title":     article.Title,
 		"content":   article.Content,
 		"url":       article.URL,
-		"status":    "success",
-	})
+		"mode":      req.Mode,
+		"debug":     gin.H{"language": article.Language},
+	}
+
+	if req.Mode == extractionModeFast {
+		ctx := c.Request.Context()
+		if req.TwoPhaseEvents {
+			ctx = llm.WithTwoPhaseEvents(ctx)
+		}
+		extracted, err := h.extractor.ProcessArticle(ctx, article)
+		if err != nil {
+			logger.Error("Single-pass extraction failed", "error", err)
+			if errors.Is(err, limits.ErrBusy) {
+				c.JSON(429, gin.H{"error": "Too many extractions in progress, try again later"})
+				return
+			}
+			c.JSON(500, gin.H{"error": "Failed to extract entities: " + err.Error()})
+			return
+		}
+		h.linkEntities(c.Request.Context(), extracted)
+		// The cache keeps the full extraction of saved articles, which respondWithExisting
+		// returns when the content hash lookup matches them
+		if req.integrate {
+			h.duplicates.put(article.ContentHash, article.ID, extracted)
+		}
+		kept := req.thresholds.apply(h.reliability.apply(extracted, reliability))
+		attachExtraction(article, kept)
+		response["extraction"] = kept
+	}
+
+	// Save the article
+	if req.integrate {
+		graphLogger := logging.For(c.Request.Context(), "graph")
+		err := h.db.SaveArticle(article)
+		// While the database is down the extraction is kept for a later retry, so
+		// the article doesn't have to be scraped and extracted again
+		if errors.Is(err, db.ErrUnavailable) && h.retries != nil {
+			if qerr := h.retries.enqueue(article); qerr != nil {
+				graphLogger.Error("Failed to queue article for integration", "article_id", article.ID, "error", qerr)
+			} else {
+				graphLogger.Warn("Database unavailable, article queued for integration", "article_id", article.ID, "error", err)
+				response["integrated"] = false
+				response["queued"] = true
+				err = nil
+			}
+		} else if err == nil {
+			graphLogger.Info("Article integrated into the graph", "article_id", article.ID,
+				"entities", len(article.Entities), "relationships", len(article.Relations))
+		}
+		if err != nil {
+			graphLogger.Error("Failed to save article", "article_id", article.ID, "error", err)
+			c.JSON(500, gin.H{"error": "Failed to save article: " + err.Error()})
+			return
+		}
+	}
+	if !req.integrate {
+		response["integrated"] = false
+	}
+
+	if req.Mode == extractionModeDeep {
+		logger.Info("Starting sequential analysis", "article_id", article.ID, "depth", req.Depth)
+		config := &sequential.AnalysisConfig{
+			Depth:                req.Depth,
+			MaxStages:            5,
+			ConfidenceThreshold:  0.6,
+			TimeoutPerStage:      60 * time.Second,
+			EnableCrossReference: true,
+			EnableHypotheses:     true,
+			IndicatorFlags:       h.indicatorFlags,
+			Stages:               req.Stages,
+			CallbackURL:          req.CallbackURL,
+			ResumeSessionID:      req.ResumeSessionID,
+			ResumeFrom:           req.ResumeFrom,
+			TotalTimeout:         req.timeout,
+			LowConfidencePolicy:  req.LowConfidencePolicy,
+			SourceReliability:    reliability,
+			StageModels:          h.stageModels,
+			PromptCache:          h.promptCache,
+		}
+
+		// The session keeps running after this response is sent
+		session, err := h.analysisController.StartAnalysis(context.WithoutCancel(c.Request.Context()), article, config)
+		if errors.Is(err, sequential.ErrInvalidResume) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to start analysis: " + err.Error()})
+			return
+		}
+		response["sessionId"] = session.ID
+		response["status"] = "started"
+
+		// Remembered so a resubmission of the same content can return this session
+		if article.Metadata == nil {
+			article.Metadata = make(map[string]interface{})
+		}
+		article.Metadata["sessionId"] = session.ID
+		if err := h.db.UpdateArticle(article); err != nil {
+			logger.Warn("Failed to record session on article", "article_id", article.ID, "error", err)
+		}
+	} else {
+		response["status"] = "success"
+	}
+
+	c.JSON(200, h.localizer.localize(req.shape.apply(response)))
+}
+
+// respondWithExisting answers a resubmitted article with its stored analysis and
+// records the submitted URL as another source of the stored article
+func (h *ExtractionGinHandler) respondWithExisting(c *gin.Context, existing *models.Article, submittedURL, mode string, shape responseShape) {
+	logger := logging.For(c.Request.Context(), "extraction")
+	logger.Info("Content already analyzed, returning stored results", "article_id", existing.ID)
+
+	if addSourceURL(existing, submittedURL) {
+		if err := h.db.UpdateArticle(existing); err != nil {
+			logger.Warn("Failed to link source URL", "article_id", existing.ID, "error", err)
+		}
+	}
+
+	response := gin.H{
+		"articleId":   existing.ID,
+		"title":       existing.Title,
+		"content":     existing.Content,
+		"url":         existing.URL,
+		"mode":        mode,
+		"debug":       gin.H{"language": existing.Language},
+		"status":      "duplicate",
+		"duplicateOf": existing.ID,
+	}
+	if sessionID, ok := existing.Metadata["sessionId"].(string); ok {
+		response["sessionId"] = sessionID
+	}
+	if cached, ok := h.duplicates.get(existing.ContentHash); ok {
+		response["extraction"] = cached.result
+	} else if len(existing.Entities) > 0 || len(existing.Relations) > 0 {
+		response["extraction"] = extractionFromArticle(existing)
+	}
+
+	c.JSON(200, h.localizer.localize(shape.apply(response)))
+}
+
+// addSourceURL records url in the article's sourceUrls metadata, reporting whether it was new
+func addSourceURL(article *models.Article, url string) bool {
+	if url == "" || url == article.URL {
+		return false
+	}
+
+	var sources []string
+	switch stored := article.Metadata["sourceUrls"].(type) {
+	case []string:
+		sources = stored
+	case []interface{}:
+		for _, s := range stored {
+			if source, ok := s.(string); ok {
+				sources = append(sources, source)
+			}
+		}
+	}
+	for _, source := range sources {
+		if source == url {
+			return false
+		}
+	}
+
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]interface{})
+	}
+	article.Metadata["sourceUrls"] = append(sources, url)
+	return true
+}
+
+// extractionFromArticle rebuilds an extraction result from a stored article's entities and relationships
+func extractionFromArticle(article *models.Article) *models.ExtractionResult {
+	result := &models.ExtractionResult{}
+	for _, entity := range article.Entities {
+		result.Entities = append(result.Entities, *entity)
+	}
+	for _, rel := range article.Relations {
+		result.Relationships = append(result.Relationships, *rel)
+	}
+	return result
+}
+
+// attachExtraction copies single-pass extraction results onto the article so they are saved with it
+func attachExtraction(article *models.Article, result *models.ExtractionResult) {
+	now := time.Now()
+	for i := range result.Entities {
+		entity := result.Entities[i]
+		entity.ArticleID = article.ID
+		entity.ExtractedAt = now
+		article.Entities = append(article.Entities, &entity)
+	}
+	for i := range result.Relationships {
+		rel := result.Relationships[i]
+		rel.ArticleID = article.ID
+		rel.ExtractedAt = now
+		article.Relations = append(article.Relations, &rel)
+	}
+	article.ExtractedAt = now
+}
+
+// linkEntities links extracted entities to the external authority when
+// enrichment is enabled. Like article enrichment it is best-effort: entities
+// keep whatever links were made before a failure and ingestion continues.
+func (h *ExtractionGinHandler) linkEntities(ctx context.Context, result *models.ExtractionResult) {
+	if h.authority == nil {
+		return
+	}
+	linked, err := h.authority.Enrich(ctx, result)
+	if err != nil {
+		logging.For(ctx, "extraction").Warn("Entity authority lookup failed", "linked", linked, "error", err)
+		return
+	}
+	logging.For(ctx, "extraction").Info("Linked entities to the entity authority", "linked", linked, "entities", len(result.Entities))
+}
+
+// enrichArticle adds summary, topics, sentiment and risk score to the article metadata.
+// Enrichment is best-effort: failures are logged and ingestion continues.
+func (h *ExtractionGinHandler) enrichArticle(ctx context.Context, article *models.Article) {
+	if err := h.enricher.EnrichArticle(ctx, article, llm.NewTextAdapter(h.llm)); err != nil {
+		logging.For(ctx, "extraction").Warn("Article enrichment failed", "error", err)
+		return
+	}
+	logging.For(ctx, "extraction").Debug("Article enriched")
 }
@@ -0,0 +1,988 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clank/config"
+	"clank/internal/api/middleware"
+	"clank/internal/db"
+	"clank/internal/limits"
+	"clank/internal/llm"
+	llmprompts "clank/internal/llm/prompts"
+	"clank/internal/llm/sequential"
+	"clank/internal/logging"
+	"clank/internal/metrics"
+	"clank/internal/models"
+	"clank/internal/testutil"
+	browser "clank/internal/tools/browser"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passthroughProcessor returns the content unchanged
+type passthroughProcessor struct{}
+
+func (passthroughProcessor) ProcessSiteArticle(host, content string) (*models.ProcessingResult, error) {
+	return &models.ProcessingResult{Content: content}, nil
+}
+
+// recordingExtractor is a single-pass extractor that returns a fixed result
+type recordingExtractor struct {
+	calls  int
+	result *models.ExtractionResult
+}
+
+func (e *recordingExtractor) ProcessArticle(ctx context.Context, article *models.Article) (*models.ExtractionResult, error) {
+	e.calls++
+	if e.result == nil {
+		return &models.ExtractionResult{}, nil
+	}
+	return e.result, nil
+}
+
+// recordingAnalyzer starts fake sequential analysis sessions
+type recordingAnalyzer struct {
+	calls  int
+	ctx    context.Context
+	config *sequential.AnalysisConfig
+}
+
+func (a *recordingAnalyzer) StartAnalysis(ctx context.Context, article *models.Article, config *sequential.AnalysisConfig) (*sequential.AnalysisSession, error) {
+	a.calls++
+	a.ctx = ctx
+	a.config = config
+	return &sequential.AnalysisSession{ID: "session-1", ArticleID: article.ID, Status: "running"}, nil
+}
+
+func newTestExtractionGinHandler(llmClient LLMClient, store Store, enrich bool) *ExtractionGinHandler {
+	return &ExtractionGinHandler{
+		scraper:            testutil.NewMockBrowserAutomation(),
+		processor:          passthroughProcessor{},
+		llm:                llmClient,
+		db:                 store,
+		extractor:          &recordingExtractor{},
+		analysisController: &recordingAnalyzer{},
+		enricher:           llmprompts.NewArticleExtractionPrompt(),
+		enrich:             enrich,
+	}
+}
+
+func performExtraction(t *testing.T, h *ExtractionGinHandler, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, http.MethodPost, "/api/extraction", "/api/extraction", h.HandleURLExtraction, body)
+}
+
+func TestExtractionGinHandler_Enrichment(t *testing.T) {
+	enrichment := `{
+		"summary": "Official accepted bribes for contracts",
+		"topics": ["corruption", "procurement"],
+		"sentiment": "negative",
+		"risk_score": 0.8
+	}`
+
+	tests := []struct {
+		name        string
+		enrich      bool
+		llmResponse string
+		llmErr      error
+		check       func(*testing.T, *models.Article)
+	}{
+		{
+			name:        "enrichment enabled stores fields",
+			enrich:      true,
+			llmResponse: enrichment,
+			check: func(t *testing.T, article *models.Article) {
+				assert.Equal(t, "Official accepted bribes for contracts", article.Metadata["summary"])
+				assert.Equal(t, []interface{}{"corruption", "procurement"}, article.Metadata["topics"])
+				assert.Equal(t, "negative", article.Metadata["sentiment"])
+				assert.Equal(t, 0.8, article.Metadata["risk_score"])
+			},
+		},
+		{
+			name:        "enrichment disabled skips llm",
+			enrich:      false,
+			llmResponse: enrichment,
+			check: func(t *testing.T, article *models.Article) {
+				assert.NotContains(t, article.Metadata, "summary")
+			},
+		},
+		{
+			name:   "enrichment failure still saves article",
+			enrich: true,
+			llmErr: assert.AnError,
+			check: func(t *testing.T, article *models.Article) {
+				assert.NotContains(t, article.Metadata, "summary")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockLLM := testutil.NewMockLLMClient()
+			mockLLM.GenerateResponse = tt.llmResponse
+			mockLLM.GenerateError = tt.llmErr
+			store := db.NewMemoryStore()
+
+			h := newTestExtractionGinHandler(mockLLM, store, tt.enrich)
+			rr := performExtraction(t, h, gin.H{"url": "https://example.com/article"})
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			saved := store.Articles[resp["articleId"].(string)]
+			require.NotNil(t, saved)
+			tt.check(t, saved)
+		})
+	}
+}
+
+func TestExtractionGinHandler_Modes(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         gin.H
+		defaultMode  string
+		wantStatus   int
+		wantMode     string
+		wantFast     int
+		wantDeep     int
+		wantEnvelope string
+	}{
+		{name: "fast mode uses single-pass extractor", body: gin.H{"url": "https://example.com/a", "mode": "fast"}, wantStatus: http.StatusOK, wantMode: "fast", wantFast: 1, wantEnvelope: "success"},
+		{name: "deep mode starts sequential analysis", body: gin.H{"url": "https://example.com/a", "mode": "deep", "depth": 4}, wantStatus: http.StatusOK, wantMode: "deep", wantDeep: 1, wantEnvelope: "started"},
+		{name: "configured default mode", body: gin.H{"url": "https://example.com/a"}, defaultMode: "deep", wantStatus: http.StatusOK, wantMode: "deep", wantDeep: 1, wantEnvelope: "started"},
+		{name: "fast when nothing is configured", body: gin.H{"url": "https://example.com/a"}, wantStatus: http.StatusOK, wantMode: "fast", wantFast: 1, wantEnvelope: "success"},
+		{name: "unknown mode", body: gin.H{"url": "https://example.com/a", "mode": "thorough"}, wantStatus: http.StatusBadRequest},
+		{name: "two-phase events in fast mode", body: gin.H{"url": "https://example.com/a", "mode": "fast", "twoPhaseEvents": true}, wantStatus: http.StatusOK, wantMode: "fast", wantFast: 1, wantEnvelope: "success"},
+		{name: "two-phase events need fast mode", body: gin.H{"url": "https://example.com/a", "mode": "deep", "twoPhaseEvents": true}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extractor := &recordingExtractor{result: &models.ExtractionResult{
+				Entities:   []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe"}},
+				Confidence: 0.9,
+			}}
+			analyzer := &recordingAnalyzer{}
+			store := db.NewMemoryStore()
+
+			h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+			h.extractor = extractor
+			h.analysisController = analyzer
+			h.defaultMode = tt.defaultMode
+
+			rr := performExtraction(t, h, tt.body)
+			require.Equal(t, tt.wantStatus, rr.Code)
+			assert.Equal(t, tt.wantFast, extractor.calls)
+			assert.Equal(t, tt.wantDeep, analyzer.calls)
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantMode, resp["mode"])
+			assert.Equal(t, tt.wantEnvelope, resp["status"])
+			for _, key := range []string{"articleId", "title", "content", "url"} {
+				assert.Contains(t, resp, key)
+			}
+
+			saved := store.Articles[resp["articleId"].(string)]
+			require.NotNil(t, saved)
+			if tt.wantMode == "fast" {
+				assert.Contains(t, resp, "extraction")
+				require.Len(t, saved.Entities, 1)
+				assert.Equal(t, saved.ID, saved.Entities[0].ArticleID)
+			} else {
+				assert.Equal(t, "session-1", resp["sessionId"])
+				assert.Empty(t, saved.Entities)
+			}
+		})
+	}
+}
+
+func TestExtractionGinHandler_Localization(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Localization.Locale = "es"
+	cfg.Localization.FieldNames = map[string]string{"title": "titular"}
+
+	extractor := &recordingExtractor{result: &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe"}},
+	}}
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.extractor = extractor
+	h.localizer = newResponseLocalizer(cfg)
+
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, "success", resp["estado"])
+	assert.Contains(t, resp, "titular")
+	assert.NotContains(t, resp, "title")
+	assert.NotContains(t, resp, "status")
+
+	entities := resp["extraccion"].(map[string]interface{})["entidades"].([]interface{})
+	entity := entities[0].(map[string]interface{})
+	assert.Equal(t, "persona", entity["tipo"])
+	assert.Equal(t, "Jane Doe", entity["nombre"])
+
+	// Stored data keeps the internal model
+	saved := store.Articles[resp["idArticulo"].(string)]
+	require.NotNil(t, saved)
+	require.Len(t, saved.Entities, 1)
+	assert.Equal(t, "person", saved.Entities[0].Type)
+	assert.Equal(t, "person", extractor.result.Entities[0].Type)
+}
+
+func TestNewResponseLocalizer_English(t *testing.T) {
+	cfg := &config.Config{}
+	assert.Nil(t, newResponseLocalizer(cfg))
+
+	response := gin.H{"title": "x"}
+	assert.Equal(t, response, newResponseLocalizer(cfg).localize(response))
+}
+
+func TestExtractionGinHandler_SavesToStore(t *testing.T) {
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.extractor = &recordingExtractor{result: &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9},
+			{ID: "e2", Type: "organization", Name: "Acme Corp", Confidence: 0.8},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "EMPLOYED_BY", FromID: "e1", ToID: "e2", Confidence: 0.7},
+		},
+	}}
+
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+	saved, err := store.GetArticleByID(resp["articleId"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", saved.URL)
+	assert.NotEmpty(t, saved.ContentHash)
+	require.Len(t, saved.Entities, 2)
+	assert.Equal(t, "Jane Doe", saved.Entities[0].Name)
+	require.Len(t, saved.Relations, 1)
+	assert.Equal(t, "e2", saved.Relations[0].ToID)
+}
+
+func TestExtractionGinHandler_DuplicateContentReusesExtraction(t *testing.T) {
+	scraper := testutil.NewMockBrowserAutomation()
+	extractor := &recordingExtractor{result: &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe"}},
+	}}
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.scraper = scraper
+	h.extractor = extractor
+	h.duplicates = newExtractionCache(10)
+
+	scraper.ScrapeResponse = "Mayor Jane Doe awarded the contract to her brother's firm."
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/original", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code)
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &first))
+	assert.NotContains(t, first, "duplicateOf")
+
+	// A syndicated copy differing only in case and punctuation
+	scraper.ScrapeResponse = "MAYOR JANE DOE awarded the contract to her brother's firm!"
+	rr = performExtraction(t, h, gin.H{"url": "https://syndicated.example.org/copy", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code)
+	var second map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &second))
+
+	assert.Equal(t, 1, extractor.calls, "duplicate content should not call the extractor again")
+	assert.Equal(t, first["articleId"], second["duplicateOf"])
+	assert.Equal(t, first["articleId"], second["articleId"])
+	assert.Equal(t, "duplicate", second["status"])
+	assert.Contains(t, second, "extraction")
+
+	// The copy's URL is linked to the stored article instead of duplicating it
+	require.Len(t, store.Articles, 1)
+	saved := store.Articles[first["articleId"].(string)]
+	assert.Equal(t, "https://example.com/original", saved.URL)
+	assert.Equal(t, []string{"https://syndicated.example.org/copy"}, saved.Metadata["sourceUrls"])
+
+	// Different content still runs the extractor
+	scraper.ScrapeResponse = "An unrelated article about the harbour authority."
+	rr = performExtraction(t, h, gin.H{"url": "https://example.com/other", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2, extractor.calls)
+
+	// Previews skip the content hash lookup, so they extract even for stored content
+	preview := strings.Repeat("Mayor Jane Doe awarded the contract to her brother's firm. ", 2)
+	rr = performTextExtraction(t, h, gin.H{"title": "Copy", "content": preview, "integrate": false})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var third map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &third))
+	assert.Equal(t, 3, extractor.calls)
+	assert.NotContains(t, third, "duplicateOf")
+}
+
+func TestExtractionGinHandler_ContentHashShortCircuit(t *testing.T) {
+	scraper := testutil.NewMockBrowserAutomation()
+	scraper.ScrapeResponse = "Mayor Jane Doe awarded the contract to her brother's firm."
+	extractor := &recordingExtractor{}
+	analyzer := &recordingAnalyzer{}
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.scraper = scraper
+	h.extractor = extractor
+	h.analysisController = analyzer
+
+	submit := func(body gin.H) map[string]interface{} {
+		rr := performExtraction(t, h, body)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	first := submit(gin.H{"url": "https://example.com/a", "mode": "deep"})
+	require.Equal(t, "started", first["status"])
+	saved := store.Articles[first["articleId"].(string)]
+	require.NotNil(t, saved)
+	assert.NotEmpty(t, saved.ContentHash)
+
+	// The same content again returns the stored article and its session
+	second := submit(gin.H{"url": "https://example.com/a", "mode": "deep"})
+	assert.Equal(t, "duplicate", second["status"])
+	assert.Equal(t, first["articleId"], second["articleId"])
+	assert.Equal(t, first["sessionId"], second["sessionId"])
+	assert.Equal(t, 1, analyzer.calls)
+	assert.NotContains(t, saved.Metadata, "sourceUrls", "resubmitting the same URL adds no source")
+
+	// force bypasses the stored result and re-analyzes the same article
+	forced := submit(gin.H{"url": "https://example.com/a", "mode": "fast", "force": true})
+	assert.Equal(t, "success", forced["status"])
+	assert.Equal(t, first["articleId"], forced["articleId"])
+	assert.Equal(t, 1, extractor.calls)
+	assert.Len(t, store.Articles, 1)
+}
+
+func TestExtractionCache_EvictsOldest(t *testing.T) {
+	assert.Nil(t, newExtractionCache(0))
+	_, ok := (*extractionCache)(nil).get("a")
+	assert.False(t, ok)
+
+	cache := newExtractionCache(2)
+	cache.put("a", "article-a", &models.ExtractionResult{})
+	cache.put("b", "article-b", &models.ExtractionResult{})
+	cache.put("c", "article-c", &models.ExtractionResult{})
+
+	_, ok = cache.get("a")
+	assert.False(t, ok)
+	entry, ok := cache.get("c")
+	require.True(t, ok)
+	assert.Equal(t, "article-c", entry.articleID)
+}
+
+func TestExtractionGinHandler_PageTooLarge(t *testing.T) {
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(nil, store, false)
+	scraper := testutil.NewMockBrowserAutomation()
+	scraper.ScrapeErr = fmt.Errorf("https://example.com/huge: %w", browser.ErrPageTooLarge)
+	h.scraper = scraper
+
+	rr := performExtraction(t, h, map[string]interface{}{"url": "https://example.com/huge"})
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "too large")
+	assert.Empty(t, store.Articles)
+}
+
+func TestExtractionGinHandler_ContentUnavailable(t *testing.T) {
+	store := db.NewMemoryStore()
+	h := newTestExtractionGinHandler(nil, store, false)
+	extractor := &recordingExtractor{}
+	h.extractor = extractor
+	scraper := testutil.NewMockBrowserAutomation()
+	scraper.ScrapeErr = &browser.UnavailableError{URL: "https://example.com/a", Reason: browser.UnavailableConsentWall, Words: 80}
+	h.scraper = scraper
+
+	rr := performExtraction(t, h, map[string]interface{}{"url": "https://example.com/a"})
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, browser.UnavailableConsentWall, body["reason"])
+	assert.Zero(t, extractor.calls, "no LLM call for a page without content")
+	assert.Empty(t, store.Articles)
+}
+
+// headerRecordingScraper records the per-request headers each scrape was given
+type headerRecordingScraper struct {
+	*testutil.MockBrowserAutomation
+	headers map[string]string
+}
+
+func (s *headerRecordingScraper) ScrapeArticle(ctx context.Context, url string) (*models.Article, error) {
+	s.headers = browser.RequestHeaders(ctx)
+	return s.MockBrowserAutomation.ScrapeArticle(ctx, url)
+}
+
+func TestExtractionGinHandler_RequestHeaders(t *testing.T) {
+	scraper := &headerRecordingScraper{MockBrowserAutomation: testutil.NewMockBrowserAutomation()}
+	h := newTestExtractionGinHandler(nil, db.NewMemoryStore(), false)
+	h.scraper = scraper
+
+	rr := performExtraction(t, h, gin.H{
+		"url":     "https://example.com/a",
+		"headers": map[string]string{"Referer": "https://news.example.com/", "Cookie": "paywall=off"},
+	})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, map[string]string{"Referer": "https://news.example.com/", "Cookie": "paywall=off"}, scraper.headers)
+
+	rr = performExtraction(t, h, gin.H{"url": "https://example.com/a", "headers": map[string]string{"Bad Header": "x"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// sessionRecordingScraper records the session each scrape was given
+type sessionRecordingScraper struct {
+	*testutil.MockBrowserAutomation
+	session *browser.Session
+}
+
+func (s *sessionRecordingScraper) ScrapeArticle(ctx context.Context, url string) (*models.Article, error) {
+	if session, ok := browser.RequestSession(ctx); ok {
+		s.session = &session
+	}
+	return s.MockBrowserAutomation.ScrapeArticle(ctx, url)
+}
+
+func TestExtractionGinHandler_SessionProfile(t *testing.T) {
+	scraper := &sessionRecordingScraper{MockBrowserAutomation: testutil.NewMockBrowserAutomation()}
+	h := newTestExtractionGinHandler(nil, db.NewMemoryStore(), false)
+	h.scraper = scraper
+
+	login := []gin.H{
+		{"action": "navigate", "url": "https://courts.example.com/login"},
+		{"action": "fill", "selector": "#password", "value": "hunter2"},
+		{"action": "click", "selector": "button[type=submit]"},
+	}
+	rr := performExtraction(t, h, gin.H{"url": "https://courts.example.com/case/1", "sessionProfile": "court-portal", "login": login})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	require.NotNil(t, scraper.session)
+	assert.Equal(t, "court-portal", scraper.session.Profile)
+	require.Len(t, scraper.session.Steps, 3)
+	assert.Equal(t, browser.LoginStep{Action: "fill", Selector: "#password", Value: "hunter2"}, scraper.session.Steps[1])
+
+	scraper.session = nil
+	rr = performExtraction(t, h, gin.H{"url": "https://example.com/a"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Nil(t, scraper.session, "no session unless one is asked for")
+
+	for name, body := range map[string]gin.H{
+		"login without profile": {"url": "https://example.com/a", "login": login},
+		"unsafe profile name":   {"url": "https://example.com/a", "sessionProfile": "../secrets"},
+		"unknown action":        {"url": "https://example.com/a", "sessionProfile": "p", "login": []gin.H{{"action": "hover", "selector": "a"}}},
+	} {
+		rr = performExtraction(t, h, body)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+	}
+}
+
+func TestExtractionGinHandler_ConfidenceThresholds(t *testing.T) {
+	newHandler := func(store *db.MemoryStore) *ExtractionGinHandler {
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+		h.extractor = &recordingExtractor{result: &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9},
+				{ID: "e2", Type: "organization", Name: "Shell Co", Confidence: 0.3},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{ID: "r1", Type: "EMPLOYED_BY", FromID: "e1", ToID: "e2", Confidence: 0.8},
+			},
+		}}
+		h.thresholds = confidenceThresholds{entity: 0.5}
+		return h
+	}
+	savedEntities := func(store *db.MemoryStore) []string {
+		var names []string
+		for _, article := range store.Articles {
+			for _, entity := range article.Entities {
+				names = append(names, entity.Name)
+			}
+		}
+		return names
+	}
+
+	t.Run("below threshold is skipped with a warning", func(t *testing.T) {
+		store := db.NewMemoryStore()
+		rr := performExtraction(t, newHandler(store), gin.H{"url": "https://example.com/a", "mode": "fast"})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Extraction models.ExtractionResult `json:"extraction"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"Jane Doe"}, savedEntities(store))
+		require.Len(t, resp.Extraction.Entities, 1)
+		assert.Empty(t, resp.Extraction.Relationships, "relationships to a skipped entity are skipped too")
+		require.Len(t, resp.Extraction.Warnings, 2)
+		assert.Contains(t, resp.Extraction.Warnings[0], "Shell Co")
+	})
+
+	t.Run("lowered per request keeps it", func(t *testing.T) {
+		store := db.NewMemoryStore()
+		rr := performExtraction(t, newHandler(store), gin.H{
+			"url": "https://example.com/a", "mode": "fast", "minEntityConfidence": 0.2,
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.ElementsMatch(t, []string{"Jane Doe", "Shell Co"}, savedEntities(store))
+		for _, article := range store.Articles {
+			assert.Len(t, article.Relations, 1)
+		}
+	})
+
+	t.Run("out of range override is rejected", func(t *testing.T) {
+		rr := performExtraction(t, newHandler(db.NewMemoryStore()), gin.H{
+			"url": "https://example.com/a", "mode": "fast", "minRelationshipConfidence": 1.5,
+		})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestExtractionGinHandler_SourceReliability(t *testing.T) {
+	extracted := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9},
+			{ID: "e2", Type: "organization", Name: "Shell Co", Confidence: 0.6},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "EMPLOYED_BY", FromID: "e1", ToID: "e2", Confidence: 0.8},
+		},
+	}
+	reliability := newSourceReliability(map[string]float64{"courts.example.gov": 1, "tabloid.example": 0.4}, 0.7)
+
+	// Each source gets its own handler and store so the content hash doesn't
+	// short-circuit the repeated extraction
+	extractFrom := func(t *testing.T, url string, thresholds confidenceThresholds) *models.Article {
+		t.Helper()
+		store := db.NewMemoryStore()
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+		h.extractor = &recordingExtractor{result: extracted}
+		h.reliability = reliability
+		h.thresholds = thresholds
+		rr := performExtraction(t, h, gin.H{"url": url, "mode": "fast"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.Len(t, store.Articles, 1)
+		for _, article := range store.Articles {
+			return article
+		}
+		return nil
+	}
+
+	court := extractFrom(t, "https://courts.example.gov/filing/1", confidenceThresholds{})
+	tabloid := extractFrom(t, "https://www.tabloid.example/scandal", confidenceThresholds{})
+	require.Len(t, court.Entities, 2)
+	require.Len(t, tabloid.Entities, 2)
+	assert.InDelta(t, 0.9, court.Entities[0].Confidence, 1e-9)
+	assert.InDelta(t, 0.36, tabloid.Entities[0].Confidence, 1e-9)
+	assert.InDelta(t, 0.32, tabloid.Relations[0].Confidence, 1e-9)
+	assert.Equal(t, 0.4, tabloid.Entities[0].Properties[sourceReliabilityProperty])
+	assert.Equal(t, 0.4, tabloid.Relations[0].Properties[sourceReliabilityProperty])
+	assert.Equal(t, 0.4, tabloid.Metadata[sourceReliabilityProperty])
+	assert.InDelta(t, 0.9, extracted.Entities[0].Confidence, 1e-9, "the extraction itself is left untouched")
+	assert.Nil(t, extracted.Entities[0].Properties)
+
+	t.Run("subdomains and unknown domains", func(t *testing.T) {
+		assert.InDelta(t, 0.36, extractFrom(t, "https://news.tabloid.example/a", confidenceThresholds{}).Entities[0].Confidence, 1e-9)
+		assert.InDelta(t, 0.63, extractFrom(t, "https://blog.example.org/a", confidenceThresholds{}).Entities[0].Confidence, 1e-9)
+	})
+
+	t.Run("thresholds apply to weighted confidences", func(t *testing.T) {
+		article := extractFrom(t, "https://tabloid.example/scandal", confidenceThresholds{entity: 0.3})
+		require.Len(t, article.Entities, 1)
+		assert.Equal(t, "Jane Doe", article.Entities[0].Name)
+	})
+
+	t.Run("deep analysis weights evidence", func(t *testing.T) {
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+		analyzer := &recordingAnalyzer{}
+		h.analysisController = analyzer
+		h.reliability = reliability
+		rr := performExtraction(t, h, gin.H{"url": "https://tabloid.example/scandal", "mode": "deep"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, 0.4, analyzer.config.SourceReliability)
+	})
+
+	t.Run("no weights configured", func(t *testing.T) {
+		unweighted := sourceReliability{}
+		assert.Equal(t, 1.0, unweighted.weight(&models.Article{URL: "https://tabloid.example/scandal"}))
+		assert.Same(t, extracted, unweighted.apply(extracted, 1))
+	})
+}
+
+func TestNewSourceReliability_IgnoresInvalidWeights(t *testing.T) {
+	r := newSourceReliability(map[string]float64{"WWW.Example.com": 0.5, "bad.example": 2}, -1)
+	assert.Equal(t, map[string]float64{"example.com": 0.5}, r.weights)
+	assert.Equal(t, 1.0, r.weight(&models.Article{URL: "https://bad.example/a"}))
+	assert.Equal(t, 0.5, r.weight(&models.Article{Source: "example.com"}), "the source is used without a URL")
+}
+
+func TestExtractionGinHandler_Metrics(t *testing.T) {
+	successes := metrics.Extractions.Value("fast", metrics.StatusSuccess)
+	busy := metrics.Extractions.Value("fast", metrics.StatusBusy)
+
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, successes+1, metrics.Extractions.Value("fast", metrics.StatusSuccess))
+
+	scraper := testutil.NewMockBrowserAutomation()
+	scraper.ScrapeErr = fmt.Errorf("waiting for a browser: %w", limits.ErrBusy)
+	h.scraper = scraper
+	rr = performExtraction(t, h, gin.H{"url": "https://example.com/b", "mode": "fast"})
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, busy+1, metrics.Extractions.Value("fast", metrics.StatusBusy))
+
+	exposition := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(exposition, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, exposition.Body.String(), `clank_extractions_total{mode="fast",status="busy"}`)
+}
+
+func TestExtractionGinHandler_ResponseShape(t *testing.T) {
+	newExtractor := func() *recordingExtractor {
+		return &recordingExtractor{result: &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "person", Name: "Jane Doe", Mentions: []models.EntityMention{{Text: "Jane"}}},
+				{ID: "e2", Type: "event", Name: "Contract award"},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{ID: "r1", Type: "participated_in", FromID: "e1", ToID: "e2", Context: "Jane signed the contract"},
+			},
+		}}
+	}
+	extract := func(t *testing.T, body gin.H) (int, map[string]interface{}) {
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+		h.extractor = newExtractor()
+		rr := performExtraction(t, h, body)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return rr.Code, resp
+	}
+
+	t.Run("verbose by default", func(t *testing.T) {
+		code, resp := extract(t, gin.H{"url": "https://example.com/a"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Contains(t, resp, "content")
+		assert.Contains(t, resp, "debug")
+
+		extraction := resp["extraction"].(map[string]interface{})
+		entity := extraction["entities"].([]interface{})[0].(map[string]interface{})
+		assert.Len(t, entity["mentions"], 1)
+		rel := extraction["relationships"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Jane signed the contract", rel["context"])
+	})
+
+	t.Run("compact leaves out optional sections", func(t *testing.T) {
+		code, resp := extract(t, gin.H{"url": "https://example.com/a", "verbosity": "compact"})
+		require.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, resp, "content")
+		assert.NotContains(t, resp, "debug")
+		assert.Equal(t, "success", resp["status"])
+
+		extraction := resp["extraction"].(map[string]interface{})
+		entity := extraction["entities"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Jane Doe", entity["name"])
+		assert.NotContains(t, entity, "mentions")
+		rel := extraction["relationships"].([]interface{})[0].(map[string]interface{})
+		assert.NotContains(t, rel, "context")
+	})
+
+	t.Run("fields select sections", func(t *testing.T) {
+		code, resp := extract(t, gin.H{"url": "https://example.com/a", "fields": []string{"evidence"}})
+		require.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, resp, "content")
+
+		extraction := resp["extraction"].(map[string]interface{})
+		entity := extraction["entities"].([]interface{})[0].(map[string]interface{})
+		assert.NotContains(t, entity, "mentions")
+		rel := extraction["relationships"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Jane signed the contract", rel["context"])
+	})
+
+	t.Run("flat returns only the graph items", func(t *testing.T) {
+		code, resp := extract(t, gin.H{"url": "https://example.com/a", "verbosity": "flat"})
+		require.Equal(t, http.StatusOK, code)
+		assert.ElementsMatch(t, []string{"articleId", "entities", "relationships", "events"}, keysOf(resp))
+
+		entities := resp["entities"].([]interface{})
+		require.Len(t, entities, 1)
+		assert.Equal(t, "Jane Doe", entities[0].(map[string]interface{})["name"])
+		events := resp["events"].([]interface{})
+		require.Len(t, events, 1)
+		assert.Equal(t, "Contract award", events[0].(map[string]interface{})["name"])
+		assert.Len(t, resp["relationships"], 1)
+	})
+
+	t.Run("unknown options are rejected", func(t *testing.T) {
+		code, _ := extract(t, gin.H{"url": "https://example.com/a", "verbosity": "terse"})
+		assert.Equal(t, http.StatusBadRequest, code)
+		code, _ = extract(t, gin.H{"url": "https://example.com/a", "fields": []string{"html"}})
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+}
+
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func performTextExtraction(t *testing.T, h *ExtractionGinHandler, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, http.MethodPost, "/api/extraction/text", "/api/extraction/text", h.HandleTextExtraction, body)
+}
+
+func TestExtractionGinHandler_TextExtraction(t *testing.T) {
+	content := "The city council awarded the bridge contract to Acme Corp weeks after " +
+		"its chief executive donated to Mayor Jane Doe's re-election campaign."
+	newHandler := func() (*ExtractionGinHandler, *db.MemoryStore, *recordingExtractor) {
+		store := db.NewMemoryStore()
+		extractor := &recordingExtractor{result: &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9},
+				{ID: "e2", Type: "organization", Name: "Acme Corp", Confidence: 0.9},
+			},
+		}}
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+		h.extractor = extractor
+		h.scraper = &testutil.MockBrowserAutomation{ScrapeErr: assert.AnError} // Never used
+		return h, store, extractor
+	}
+
+	t.Run("extracts and integrates submitted text", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		rr := performTextExtraction(t, h, gin.H{"title": "Bridge deal", "content": content, "url": "https://news.example.com/bridge"})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, extractor.calls)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "success", resp["status"])
+		assert.Equal(t, "Bridge deal", resp["title"])
+		entities := resp["extraction"].(map[string]interface{})["entities"].([]interface{})
+		assert.Len(t, entities, 2)
+
+		saved := store.Articles[resp["articleId"].(string)]
+		require.NotNil(t, saved)
+		assert.Equal(t, "news.example.com", saved.Source)
+		assert.Len(t, saved.Entities, 2)
+	})
+
+	t.Run("integrate false only extracts", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		rr := performTextExtraction(t, h, gin.H{"title": "Bridge deal", "content": content, "integrate": false})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, extractor.calls)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, false, resp["integrated"])
+		assert.Len(t, resp["extraction"].(map[string]interface{})["entities"], 2)
+		assert.Empty(t, store.Articles)
+	})
+
+	t.Run("rejects invalid submissions", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		for name, body := range map[string]gin.H{
+			"short content":          {"title": "Brief", "content": "Too short to analyze."},
+			"deep without integrate": {"content": content, "mode": "deep", "integrate": false},
+			"non-http url":           {"content": content, "url": "ftp://example.com/a"},
+			"invalid mode":           {"content": content, "mode": "thorough"},
+		} {
+			rr := performTextExtraction(t, h, body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+		assert.Zero(t, extractor.calls)
+		assert.Empty(t, store.Articles)
+	})
+}
+
+func TestExtractionGinHandler_TotalTimeout(t *testing.T) {
+	newHandler := func() (*ExtractionGinHandler, *recordingAnalyzer) {
+		analyzer := &recordingAnalyzer{}
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+		h.analysisController = analyzer
+		h.analysisTimeout = 5 * time.Minute
+		return h, analyzer
+	}
+
+	t.Run("configured budget", func(t *testing.T) {
+		h, analyzer := newHandler()
+		rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "deep"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 5*time.Minute, analyzer.config.TotalTimeout)
+	})
+
+	t.Run("request overrides the budget", func(t *testing.T) {
+		h, analyzer := newHandler()
+		rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "deep", "totalTimeout": "90s"})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 90*time.Second, analyzer.config.TotalTimeout)
+	})
+
+	t.Run("rejects invalid budgets", func(t *testing.T) {
+		h, analyzer := newHandler()
+		for _, timeout := range []string{"soon", "-1m", "0s"} {
+			rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "deep", "totalTimeout": timeout})
+			assert.Equal(t, http.StatusBadRequest, rr.Code, timeout)
+		}
+		assert.Zero(t, analyzer.calls)
+	})
+}
+
+// stubAuthority links entities by name to fixed authority IDs, or fails
+type stubAuthority struct {
+	ids map[string]string
+	err error
+}
+
+func (a stubAuthority) Enrich(ctx context.Context, result *models.ExtractionResult) (int, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+	linked := 0
+	for i := range result.Entities {
+		if id, ok := a.ids[result.Entities[i].Name]; ok {
+			result.Entities[i].Properties = map[string]interface{}{"wikidata_id": id}
+			linked++
+		}
+	}
+	return linked, nil
+}
+
+func TestExtractionGinHandler_EntityAuthority(t *testing.T) {
+	extracted := func() *models.ExtractionResult {
+		return &models.ExtractionResult{Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "person", Name: "Michael Jordan", Confidence: 0.9},
+			{ID: "e2", Type: "person", Name: "Jane Clerk", Confidence: 0.9},
+		}}
+	}
+
+	for _, tt := range []struct {
+		name      string
+		authority EntityAuthority
+		wantID    interface{}
+	}{
+		{"linked", stubAuthority{ids: map[string]string{"Michael Jordan": "Q41421"}}, "Q41421"},
+		{"authority down", stubAuthority{err: assert.AnError}, nil},
+		{"disabled", nil, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := db.NewMemoryStore()
+			h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+			h.extractor = &recordingExtractor{result: extracted()}
+			h.authority = tt.authority
+
+			rr := performExtraction(t, h, gin.H{"url": "https://example.com/article"})
+			require.Equal(t, http.StatusOK, rr.Code, "authority failures don't fail extraction")
+
+			var resp struct {
+				ArticleID  string                  `json:"articleId"`
+				Extraction models.ExtractionResult `json:"extraction"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Len(t, resp.Extraction.Entities, 2)
+			assert.Equal(t, tt.wantID, resp.Extraction.Entities[0].Properties["wikidata_id"])
+			assert.Nil(t, resp.Extraction.Entities[1].Properties["wikidata_id"])
+			require.Contains(t, store.Articles, resp.ArticleID)
+		})
+	}
+}
+
+func TestExtractionGinHandler_LowConfidencePolicy(t *testing.T) {
+	deep := func(t *testing.T, body gin.H) (*httptest.ResponseRecorder, *recordingAnalyzer) {
+		t.Helper()
+		analyzer := &recordingAnalyzer{}
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+		h.analysisController = analyzer
+		h.lowConfidence = sequential.LowConfidenceStop
+		body["url"], body["mode"] = "https://example.com/a", "deep"
+		return performExtraction(t, h, body), analyzer
+	}
+
+	rr, analyzer := deep(t, gin.H{})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, sequential.LowConfidenceStop, analyzer.config.LowConfidencePolicy, "the configured policy")
+
+	rr, analyzer = deep(t, gin.H{"lowConfidencePolicy": "skip-dependents"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, sequential.LowConfidenceSkipDependents, analyzer.config.LowConfidencePolicy)
+
+	rr, analyzer = deep(t, gin.H{"lowConfidencePolicy": "retry"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Zero(t, analyzer.calls)
+}
+
+func TestExtractionGinHandler_CorrelationID(t *testing.T) {
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	extraction := `{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe", "confidence": 0.9}], "relationships": [], "confidence": 0.9}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Content: extraction}}})
+	}))
+	defer server.Close()
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+	h.extractor = llm.NewClient(cfg)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.POST("/api/extraction/text", h.HandleTextExtraction)
+
+	payload, err := json.Marshal(gin.H{"title": "Bridge deal", "content": strings.Repeat("Mayor Jane Doe awarded the bridge contract. ", 5)})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/api/extraction/text", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logging.RequestIDHeader, "req-bridge-42")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, "req-bridge-42", rr.Header().Get(logging.RequestIDHeader))
+
+	components := make(map[string]bool)
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &entry), string(line))
+		assert.Equal(t, "req-bridge-42", entry["request_id"], string(line))
+		if component, ok := entry["component"].(string); ok {
+			components[component] = true
+		}
+	}
+	assert.True(t, components["extraction"], "handler lines carry the ID")
+	assert.True(t, components["llm"], "LLM client lines carry the same ID")
+	assert.True(t, components["graph"], "graph integration lines carry the same ID")
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"clank/internal/logging"
+	"clank/internal/metrics"
+	"clank/pkg/extraction"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// maxPDFUploadBytes caps the size of an uploaded PDF, including the other form fields
+const maxPDFUploadBytes = 32 << 20
+
+// HandlePDFExtraction extracts from the text of an uploaded PDF, e.g. a court
+// filing or audit report. The multipart form carries the PDF as "file" and the
+// fields of HandleTextExtraction other than content; the response is the same
+// envelope. PDFs without a text layer, such as scans, are rejected since they
+// need OCR first.
+func (h *ExtractionGinHandler) HandlePDFExtraction(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPDFUploadBytes)
+	var body struct {
+		Title     string `form:"title"`
+		URL       string `form:"url"`       // Where the PDF was published, if anywhere
+		Source    string `form:"source"`    // Publisher or origin, e.g. "State auditor"
+		Integrate *bool  `form:"integrate"` // Save the article and extraction to the graph (default true)
+
+		extractionOptions
+	}
+
+	if err := c.ShouldBindWith(&body, binding.FormMultipart); err != nil {
+		logging.For(c.Request.Context(), "extraction").Warn("Invalid PDF upload", "error", err)
+		respondUploadError(c, err, "Invalid request body: expected a multipart form")
+		return
+	}
+	req, err := h.validateOptions(body.extractionOptions)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.submitted(body.URL, body.Integrate); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		respondUploadError(c, err, "A PDF file is required")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondUploadError(c, err, "Failed to read the uploaded file")
+		return
+	}
+	defer func() {
+		metrics.Extractions.Inc(req.Mode, metrics.HTTPStatus(c.Writer.Status()))
+	}()
+
+	filename := filepath.Base(header.Filename)
+	doc, err := extraction.ExtractPDFText(data)
+	switch {
+	case errors.Is(err, extraction.ErrPDFInvalid):
+		c.JSON(400, gin.H{"error": fmt.Sprintf("%s is not a PDF", filename)})
+		return
+	case errors.Is(err, extraction.ErrPDFEncrypted):
+		c.JSON(422, gin.H{"error": fmt.Sprintf("%s is encrypted; upload a copy without a password", filename)})
+		return
+	case errors.Is(err, extraction.ErrPDFNoText):
+		c.JSON(422, gin.H{"error": fmt.Sprintf("%s has almost no text (%d pages); it looks scanned or image-only, so run OCR on it and submit the text to /api/extraction/text", filename, doc.Pages)})
+		return
+	case err != nil:
+		c.JSON(500, gin.H{"error": "Failed to read PDF: " + err.Error()})
+		return
+	}
+
+	title := body.Title
+	if strings.TrimSpace(title) == "" {
+		title = doc.Title
+	}
+	if strings.TrimSpace(title) == "" {
+		title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	article := newSubmittedArticle(title, doc.Text, body.URL, body.Source)
+	article.Metadata["sourceFilename"] = filename
+	article.Metadata["pageCount"] = doc.Pages
+	logging.For(c.Request.Context(), "extraction").Info("Processing PDF", "filename", filename, "pages", doc.Pages, "characters", len(article.Content))
+
+	h.extract(c, req, article)
+}
+
+// respondUploadError answers 413 for uploads over maxPDFUploadBytes and 400 with
+// message otherwise
+func respondUploadError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, gin.H{"error": fmt.Sprintf("Upload is larger than %d MB", maxPDFUploadBytes>>20)})
+		return
+	}
+	c.JSON(400, gin.H{"error": message})
+}
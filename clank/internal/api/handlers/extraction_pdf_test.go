@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/internal/db"
+	"clank/internal/models"
+	"clank/internal/testutil"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderTestPDF renders a PDF with one page per entry of pages
+func renderTestPDF(t *testing.T, pages ...[]string) []byte {
+	t.Helper()
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", 12)
+	for _, lines := range pages {
+		pdf.AddPage()
+		if len(lines) == 0 {
+			pdf.Rect(20, 20, 100, 150, "F") // A stand-in for a scanned page
+		}
+		for _, line := range lines {
+			pdf.Cell(0, 8, line)
+			pdf.Ln(8)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, pdf.Output(&buf))
+	return buf.Bytes()
+}
+
+func performPDFExtraction(t *testing.T, h *ExtractionGinHandler, filename string, data []byte, fields map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		require.NoError(t, form.WriteField(name, value))
+	}
+	if data != nil {
+		part, err := form.CreateFormFile("file", filename)
+		require.NoError(t, err)
+		_, err = part.Write(data)
+		require.NoError(t, err)
+	}
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/extraction/pdf", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return serve("/api/extraction/pdf", h.HandlePDFExtraction, req)
+}
+
+func TestExtractionGinHandler_PDFExtraction(t *testing.T) {
+	report := renderTestPDF(t,
+		[]string{
+			"Audit of the 2023 bridge procurement",
+			"The city council awarded the bridge contract to Acme Corp weeks after",
+			"its chief executive donated to Mayor Jane Doe's re-election campaign.",
+		},
+		[]string{"Payments to Acme Corp exceeded the contract value by 40 percent."},
+	)
+	newHandler := func() (*ExtractionGinHandler, *db.MemoryStore, *recordingExtractor) {
+		store := db.NewMemoryStore()
+		extractor := &recordingExtractor{result: &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9},
+				{ID: "e2", Type: "organization", Name: "Acme Corp", Confidence: 0.9},
+			},
+		}}
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+		h.extractor = extractor
+		return h, store, extractor
+	}
+
+	t.Run("extracts from the PDF text", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		rr := performPDFExtraction(t, h, "bridge-audit.pdf", report, map[string]string{"source": "City auditor"})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, extractor.calls)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "bridge-audit", resp["title"])
+		assert.Len(t, resp["extraction"].(map[string]interface{})["entities"], 2)
+
+		saved := store.Articles[resp["articleId"].(string)]
+		require.NotNil(t, saved)
+		assert.Contains(t, saved.Content, "awarded the bridge contract to Acme Corp")
+		assert.Contains(t, saved.Content, "exceeded the contract value by 40 percent")
+		assert.Equal(t, "City auditor", saved.Source)
+		assert.Equal(t, "bridge-audit.pdf", saved.Metadata["sourceFilename"])
+		assert.Equal(t, 2, saved.Metadata["pageCount"])
+	})
+
+	t.Run("form options apply", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		rr := performPDFExtraction(t, h, "bridge-audit.pdf", report, map[string]string{
+			"title":     "Bridge audit",
+			"integrate": "false",
+			"verbosity": "flat",
+		})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, extractor.calls)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.NotContains(t, resp, "title", "flat responses carry only the extraction")
+		assert.Len(t, resp["entities"], 2)
+		assert.Empty(t, store.Articles)
+	})
+
+	t.Run("rejects scanned PDFs", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		rr := performPDFExtraction(t, h, "scan.pdf", renderTestPDF(t, []string{}, []string{"2"}), nil)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), "OCR")
+		assert.Zero(t, extractor.calls)
+		assert.Empty(t, store.Articles)
+	})
+
+	t.Run("rejects invalid uploads", func(t *testing.T) {
+		h, store, extractor := newHandler()
+
+		for name, rr := range map[string]*httptest.ResponseRecorder{
+			"missing file": performPDFExtraction(t, h, "", nil, map[string]string{"title": "Bridge audit"}),
+			"not a PDF":    performPDFExtraction(t, h, "notes.pdf", []byte("Just some notes"), nil),
+			"invalid mode": performPDFExtraction(t, h, "bridge-audit.pdf", report, map[string]string{"mode": "thorough"}),
+		} {
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+		assert.Zero(t, extractor.calls)
+		assert.Empty(t, store.Articles)
+	})
+
+	t.Run("rejects oversized uploads", func(t *testing.T) {
+		h, _, extractor := newHandler()
+
+		rr := performPDFExtraction(t, h, "huge.pdf", make([]byte, maxPDFUploadBytes+1), nil)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, rr.Body.String())
+		assert.Zero(t, extractor.calls)
+	})
+}
@@ -2,6 +2,7 @@ package graph
 
 import (
 	"clank/internal/db"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -39,7 +40,7 @@ func GetCorruptionScoreHandler(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("node not found")
+			return nil, errNodeNotFound
 		}
 
 		record := result.Record()
@@ -51,8 +52,8 @@ func GetCorruptionScoreHandler(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "node not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -103,15 +104,15 @@ func GetEntityConnectionsHandler(c *gin.Context) {
 		}
 
 		if len(connections) == 0 {
-			return nil, fmt.Errorf("no connections found")
+			return nil, errNoConnections
 		}
 
 		return connections, nil
 	})
 
 	if err != nil {
-		if err.Error() == "no connections found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -121,7 +122,8 @@ func GetEntityConnectionsHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// GetTimelineHandler generates a timeline of events
+// GetTimelineHandler generates a timeline of events. Dates normalized during
+// extraction sort chronologically; the rest fall back to their raw text.
 func GetTimelineHandler(c *gin.Context) {
 	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
 		query := `
@@ -131,8 +133,9 @@ func GetTimelineHandler(c *gin.Context) {
 				   type(r) as eventType,
 				   n.name as source,
 				   m.name as target,
-				   r.amount as amount
-			ORDER BY r.date DESC
+				   r.amount as amount,
+				   r.date_precision as precision
+			ORDER BY coalesce(r.date_rfc3339, r.date) DESC
 		`
 
 		result, err := tx.Run(query, nil)
@@ -149,19 +152,20 @@ func GetTimelineHandler(c *gin.Context) {
 				"source":    record.Values[2],
 				"target":    record.Values[3],
 				"amount":    record.Values[4],
+				"precision": record.Values[5],
 			})
 		}
 
 		if len(events) == 0 {
-			return nil, fmt.Errorf("no events found")
+			return nil, errNoEvents
 		}
 
 		return events, nil
 	})
 
 	if err != nil {
-		if err.Error() == "no events found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
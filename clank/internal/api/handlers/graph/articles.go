@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteSourceHandler removes the data a source URL contributed, e.g. DELETE
+// /api/articles?sourceUrl=https://example.com/story. Its articles go, along with
+// entities, relationships and events no other source reports; those other
+// sources corroborate only lose the URL from their source_urls.
+func DeleteSourceHandler(c *gin.Context) {
+	sourceURL := strings.TrimSpace(c.Query("sourceUrl"))
+	if sourceURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sourceUrl parameter is required"})
+		return
+	}
+
+	report, err := db.DeleteSourceURL(sourceURL)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+	if report == (db.SourceDeletionReport{}) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no data found for source URL"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sourceUrl": sourceURL,
+		"report":    report,
+	})
+}
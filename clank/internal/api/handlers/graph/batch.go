@@ -19,6 +19,16 @@ func BatchCreateNodes(c *gin.Context) {
 		return
 	}
 
+	labels := make([]string, len(nodes))
+	for i, node := range nodes {
+		label, err := nodeLabel(node.Type)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("node %d: %v", i, err)})
+			return
+		}
+		labels[i] = label
+	}
+
 	result, err := db.ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
 		// Build UNWIND query
 		query := `
@@ -30,7 +40,7 @@ func BatchCreateNodes(c *gin.Context) {
 
 		// Group nodes by type
 		nodesByType := make(map[string][]map[string]interface{})
-		for _, node := range nodes {
+		for i, node := range nodes {
 			// Add timestamps
 			now := time.Now()
 			if node.Props == nil {
@@ -42,7 +52,7 @@ func BatchCreateNodes(c *gin.Context) {
 			nodeData := map[string]interface{}{
 				"props": node.Props,
 			}
-			nodesByType[node.Type] = append(nodesByType[node.Type], nodeData)
+			nodesByType[labels[i]] = append(nodesByType[labels[i]], nodeData)
 		}
 
 		var createdNodes []models.Node
@@ -0,0 +1,459 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"clank/internal/logging"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	// centralityCacheTTL controls how long computed rankings are reused
+	centralityCacheTTL = 5 * time.Minute
+	// betweennessMaxEdges bounds the subgraph loaded for betweenness
+	betweennessMaxEdges = 5000
+	// betweennessMaxSources bounds the number of BFS sources used for the approximation
+	betweennessMaxSources = 200
+)
+
+// Sources of a centrality ranking
+const (
+	centralitySourceCypher = "cypher" // Degree counted by a Cypher aggregation
+	centralitySourceGDS    = "gds"    // Betweenness computed by the Graph Data Science library
+	centralitySourceApprox = "approximate"
+)
+
+// CentralityScore is an entity ranked by a centrality metric
+type CentralityScore struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Score     float64 `json:"score"`
+	InDegree  int     `json:"inDegree"`
+	OutDegree int     `json:"outDegree"`
+}
+
+type centralityCacheEntry struct {
+	scores    []CentralityScore
+	source    string
+	expiresAt time.Time
+}
+
+var (
+	centralityCache   = make(map[string]centralityCacheEntry)
+	centralityCacheMu sync.Mutex
+)
+
+// GetCentralityHandler returns the most central entities by degree or betweenness.
+// Betweenness is computed by GDS when the server has it, and approximated in Go on
+// a bounded subgraph otherwise; "source" says which. Articles, mentions and entity
+// revisions are not ranked and their edges don't count.
+func GetCentralityHandler(c *gin.Context) {
+	metric := c.DefaultQuery("metric", "degree")
+	if metric != "degree" && metric != "betweenness" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metric must be degree or betweenness"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	cacheKey := fmt.Sprintf("%s:%d", metric, limit)
+	if c.Query("refresh") != "true" {
+		if scores, source, ok := getCachedCentrality(cacheKey); ok {
+			c.JSON(http.StatusOK, gin.H{"metric": metric, "source": source, "entities": scores, "cached": true})
+			return
+		}
+	}
+
+	var scores []CentralityScore
+	source := centralitySourceCypher
+	if metric == "degree" {
+		scores, err = queryDegreeCentrality(limit)
+	} else {
+		scores, source, err = queryBetweennessCentrality(c.Request.Context(), limit)
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	setCachedCentrality(cacheKey, scores, source)
+	c.JSON(http.StatusOK, gin.H{"metric": metric, "source": source, "entities": scores, "cached": false})
+}
+
+// queryDegreeCentrality computes node degree in Cypher
+func queryDegreeCentrality(limit int) ([]CentralityScore, error) {
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return degreeCentrality(tx, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]CentralityScore), nil
+}
+
+// degreeCentrality ranks entities by their total degree, split into incoming and
+// outgoing relationships
+func degreeCentrality(tx neo4j.Transaction, limit int) ([]CentralityScore, error) {
+	query := `
+		MATCH (n)
+		WHERE NOT (n:Article OR n:Mention OR n:EntityRevision)
+		OPTIONAL MATCH (n)<-[in]-(m)
+		WHERE NOT (m:Article OR m:Mention OR m:EntityRevision)
+		WITH n, count(in) as inDegree
+		OPTIONAL MATCH (n)-[out]->(m)
+		WHERE NOT (m:Article OR m:Mention OR m:EntityRevision)
+		WITH n, inDegree, count(out) as outDegree
+		RETURN ID(n) as id, n.name as name, labels(n)[0] as type, inDegree, outDegree
+		ORDER BY inDegree + outDegree DESC
+		LIMIT $limit
+	`
+	result, err := tx.Run(query, map[string]interface{}{"limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []CentralityScore
+	for result.Next() {
+		record := result.Record()
+		inDegree, _ := record.Values[3].(int64)
+		outDegree, _ := record.Values[4].(int64)
+		scores = append(scores, CentralityScore{
+			ID:        fmt.Sprint(record.Values[0]),
+			Name:      stringValue(record.Values[1]),
+			Type:      stringValue(record.Values[2]),
+			Score:     float64(inDegree + outDegree),
+			InDegree:  int(inDegree),
+			OutDegree: int(outDegree),
+		})
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	return rankCentrality(scores, limit), nil
+}
+
+// queryBetweennessCentrality computes betweenness with GDS, falling back to the
+// in-memory approximation when GDS isn't installed. It returns the source used.
+func queryBetweennessCentrality(ctx context.Context, limit int) ([]CentralityScore, string, error) {
+	scores, err := queryGDSBetweenness(ctx, limit)
+	if err == nil {
+		return scores, centralitySourceGDS, nil
+	}
+	if !isGDSUnavailable(err) {
+		return nil, "", err
+	}
+	logging.For(ctx, "graph").Info("GDS unavailable, approximating betweenness", "error", err)
+
+	scores, err = queryApproximateBetweenness(limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return scores, centralitySourceApprox, nil
+}
+
+// queryGDSBetweenness projects the entity graph into a GDS graph named for this
+// call, streams betweenness from it and drops it again. The catalog is shared by
+// all sessions, so concurrent requests can't reuse a name; projecting writes to
+// it and runs in a write transaction.
+func queryGDSBetweenness(ctx context.Context, limit int) ([]CentralityScore, error) {
+	graphName := "clank-centrality-" + uuid.New().String()
+	defer func() {
+		_, err := db.ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+			return nil, dropGDSGraph(tx, graphName)
+		})
+		if err != nil && !isGDSUnavailable(err) {
+			logging.For(ctx, "graph").Warn("Failed to drop GDS graph", "graph", graphName, "error", err)
+		}
+	}()
+
+	if _, err := db.ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return nil, projectGDSGraph(tx, graphName)
+	}); err != nil {
+		return nil, err
+	}
+
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return gdsBetweenness(tx, graphName, limit)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rankCentrality(result.([]CentralityScore), limit), nil
+}
+
+// projectGDSGraph projects the entity graph, undirected, into the GDS catalog as graphName
+func projectGDSGraph(tx neo4j.Transaction, graphName string) error {
+	query := `
+		MATCH (a)-[]->(b)
+		WHERE NOT (a:Article OR a:Mention OR a:EntityRevision)
+		AND NOT (b:Article OR b:Mention OR b:EntityRevision)
+		WITH gds.graph.project($graph, a, b, {}, {undirectedRelationshipTypes: ['*']}) as g
+		RETURN g.graphName
+	`
+	result, err := tx.Run(query, map[string]interface{}{"graph": graphName})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
+
+// gdsBetweenness streams the top betweenness scores of the projected graph graphName
+func gdsBetweenness(tx neo4j.Transaction, graphName string, limit int) ([]CentralityScore, error) {
+	query := `
+		CALL gds.betweenness.stream($graph) YIELD nodeId, score
+		WITH gds.util.asNode(nodeId) as n, score
+		ORDER BY score DESC
+		LIMIT $limit
+		RETURN ID(n), n.name, labels(n)[0], score,
+			size([(n)<--(m) WHERE NOT (m:Article OR m:Mention OR m:EntityRevision) | m]),
+			size([(n)-->(m) WHERE NOT (m:Article OR m:Mention OR m:EntityRevision) | m])
+	`
+	result, err := tx.Run(query, map[string]interface{}{"graph": graphName, "limit": limit})
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []CentralityScore
+	for result.Next() {
+		v := result.Record().Values
+		score, _ := v[3].(float64)
+		inDegree, _ := v[4].(int64)
+		outDegree, _ := v[5].(int64)
+		scores = append(scores, CentralityScore{
+			ID:        fmt.Sprint(v[0]),
+			Name:      stringValue(v[1]),
+			Type:      stringValue(v[2]),
+			Score:     score,
+			InDegree:  int(inDegree),
+			OutDegree: int(outDegree),
+		})
+	}
+	return scores, result.Err()
+}
+
+// dropGDSGraph removes graphName from the GDS catalog; a missing graph isn't an error
+func dropGDSGraph(tx neo4j.Transaction, graphName string) error {
+	result, err := tx.Run(`CALL gds.graph.drop($graph, false) YIELD graphName RETURN graphName`,
+		map[string]interface{}{"graph": graphName})
+	if err != nil {
+		return err
+	}
+	_, err = result.Consume()
+	return err
+}
+
+// queryApproximateBetweenness loads a bounded subgraph and approximates betweenness in Go
+func queryApproximateBetweenness(limit int) ([]CentralityScore, error) {
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		query := `
+			MATCH (a)-[r]->(b)
+			WHERE NOT (a:Article OR a:Mention OR a:EntityRevision)
+			AND NOT (b:Article OR b:Mention OR b:EntityRevision)
+			RETURN ID(a), a.name, labels(a)[0], ID(b), b.name, labels(b)[0]
+			LIMIT $maxEdges
+		`
+		result, err := tx.Run(query, map[string]interface{}{"maxEdges": betweennessMaxEdges})
+		if err != nil {
+			return nil, err
+		}
+
+		g := newCentralityGraph()
+		for result.Next() {
+			v := result.Record().Values
+			from := CentralityScore{ID: fmt.Sprint(v[0]), Name: stringValue(v[1]), Type: stringValue(v[2])}
+			to := CentralityScore{ID: fmt.Sprint(v[3]), Name: stringValue(v[4]), Type: stringValue(v[5])}
+			g.addEdge(from, to)
+		}
+		return g, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	g := result.(*centralityGraph)
+	return rankCentrality(g.betweenness(betweennessMaxSources), limit), nil
+}
+
+// isGDSUnavailable reports whether err means the Graph Data Science procedures
+// and functions aren't installed on the server
+func isGDSUnavailable(err error) bool {
+	var neoErr *neo4j.Neo4jError
+	if !errors.As(err, &neoErr) {
+		return false
+	}
+	switch neoErr.Code {
+	case "Neo.ClientError.Procedure.ProcedureNotFound":
+		return true
+	case "Neo.ClientError.Statement.SyntaxError":
+		return strings.Contains(neoErr.Msg, "gds.")
+	}
+	return false
+}
+
+func getCachedCentrality(key string) ([]CentralityScore, string, bool) {
+	centralityCacheMu.Lock()
+	defer centralityCacheMu.Unlock()
+
+	entry, ok := centralityCache[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, "", false
+	}
+	return entry.scores, entry.source, true
+}
+
+func setCachedCentrality(key string, scores []CentralityScore, source string) {
+	centralityCacheMu.Lock()
+	defer centralityCacheMu.Unlock()
+
+	centralityCache[key] = centralityCacheEntry{
+		scores:    scores,
+		source:    source,
+		expiresAt: time.Now().Add(centralityCacheTTL),
+	}
+}
+
+// rankCentrality sorts scores descending (ties broken by name) and keeps the top limit
+func rankCentrality(scores []CentralityScore, limit int) []CentralityScore {
+	ranked := make([]CentralityScore, len(scores))
+	copy(ranked, scores)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// centralityGraph is an undirected in-memory graph used for betweenness. Edge
+// direction is only kept for the in/out degree reported alongside the scores.
+type centralityGraph struct {
+	nodes     map[string]CentralityScore
+	adjacency map[string]map[string]bool
+	inDegree  map[string]int
+	outDegree map[string]int
+}
+
+func newCentralityGraph() *centralityGraph {
+	return &centralityGraph{
+		nodes:     make(map[string]CentralityScore),
+		adjacency: make(map[string]map[string]bool),
+		inDegree:  make(map[string]int),
+		outDegree: make(map[string]int),
+	}
+}
+
+func (g *centralityGraph) addEdge(from, to CentralityScore) {
+	for _, n := range []CentralityScore{from, to} {
+		if _, ok := g.nodes[n.ID]; !ok {
+			g.nodes[n.ID] = n
+			g.adjacency[n.ID] = make(map[string]bool)
+		}
+	}
+	g.outDegree[from.ID]++
+	g.inDegree[to.ID]++
+	if from.ID == to.ID {
+		return
+	}
+	g.adjacency[from.ID][to.ID] = true
+	g.adjacency[to.ID][from.ID] = true
+}
+
+// sortedIDs returns node IDs in a stable order
+func (g *centralityGraph) sortedIDs() []string {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// betweenness computes betweenness centrality using Brandes' algorithm.
+// When the graph has more than maxSources nodes, an evenly spaced sample of
+// sources is used and the result is scaled up accordingly.
+func (g *centralityGraph) betweenness(maxSources int) []CentralityScore {
+	ids := g.sortedIDs()
+	sources := ids
+	if maxSources > 0 && len(ids) > maxSources {
+		step := float64(len(ids)) / float64(maxSources)
+		sources = make([]string, 0, maxSources)
+		for i := 0; i < maxSources; i++ {
+			sources = append(sources, ids[int(float64(i)*step)])
+		}
+	}
+
+	centrality := make(map[string]float64, len(ids))
+	for _, s := range sources {
+		var stack []string
+		preds := make(map[string][]string)
+		sigma := map[string]float64{s: 1}
+		dist := map[string]int{s: 0}
+		queue := []string{s}
+
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for w := range g.adjacency[v] {
+				if _, seen := dist[w]; !seen {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		delta := make(map[string]float64)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range preds[w] {
+				delta[v] += sigma[v] / sigma[w] * (1 + delta[w])
+			}
+			if w != s {
+				centrality[w] += delta[w]
+			}
+		}
+	}
+
+	// Each undirected shortest path is counted from both endpoints
+	scale := 0.5 * float64(len(ids)) / float64(max(len(sources), 1))
+	scores := make([]CentralityScore, 0, len(ids))
+	for _, id := range ids {
+		node := g.nodes[id]
+		node.Score = centrality[id] * scale
+		node.InDegree = g.inDegree[id]
+		node.OutDegree = g.outDegree[id]
+		scores = append(scores, node)
+	}
+	return scores
+}
+
+// stringValue returns v as a string, or "" when it is nil or not a string
+func stringValue(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCentralityGraph builds a small network:
+//
+//	Alice - Acme Corp - Bob
+//	          |
+//	      City Council - Carol
+func mockCentralityGraph() *centralityGraph {
+	alice := CentralityScore{ID: "1", Name: "Alice", Type: "Person"}
+	bob := CentralityScore{ID: "2", Name: "Bob", Type: "Person"}
+	carol := CentralityScore{ID: "3", Name: "Carol", Type: "Person"}
+	acme := CentralityScore{ID: "4", Name: "Acme Corp", Type: "Company"}
+	council := CentralityScore{ID: "5", Name: "City Council", Type: "Organization"}
+
+	g := newCentralityGraph()
+	g.addEdge(alice, acme)
+	g.addEdge(bob, acme)
+	g.addEdge(acme, council)
+	g.addEdge(council, carol)
+	return g
+}
+
+// degreeTx answers the degree query from its edges, returning one row per node
+// unordered, with the query's in/out degree columns
+type degreeTx struct {
+	neo4j.Transaction
+	nodes []CentralityScore
+	edges [][2]string // from, to
+}
+
+func (tx *degreeTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	var records []*neo4j.Record
+	for _, node := range tx.nodes {
+		var in, out int64
+		for _, edge := range tx.edges {
+			if edge[1] == node.ID {
+				in++
+			}
+			if edge[0] == node.ID {
+				out++
+			}
+		}
+		records = append(records, &neo4j.Record{Values: []interface{}{node.ID, node.Name, node.Type, in, out}})
+	}
+	return &fakeResult{records: records, index: -1}, nil
+}
+
+func TestDegreeCentrality_Star(t *testing.T) {
+	// Two leaves point at the center and the center points at the other two
+	tx := &degreeTx{
+		nodes: []CentralityScore{
+			{ID: "1", Name: "Alice", Type: "Person"},
+			{ID: "2", Name: "Bob", Type: "Person"},
+			{ID: "3", Name: "Carol", Type: "Person"},
+			{ID: "4", Name: "Dave", Type: "Person"},
+			{ID: "5", Name: "Acme Corp", Type: "Company"},
+		},
+		edges: [][2]string{{"1", "5"}, {"2", "5"}, {"5", "3"}, {"5", "4"}},
+	}
+
+	ranked, err := degreeCentrality(tx, 3)
+
+	require.NoError(t, err)
+	require.Len(t, ranked, 3)
+	assert.Equal(t, "Acme Corp", ranked[0].Name)
+	assert.Equal(t, 4.0, ranked[0].Score)
+	assert.Equal(t, 2, ranked[0].InDegree)
+	assert.Equal(t, 2, ranked[0].OutDegree)
+	assert.Equal(t, "Alice", ranked[1].Name)
+	assert.Equal(t, 0, ranked[1].InDegree)
+	assert.Equal(t, 1, ranked[1].OutDegree)
+}
+
+func TestRankCentrality_Degree(t *testing.T) {
+	g := mockCentralityGraph()
+
+	// Mirror the degree rows returned by the Cypher query
+	var scores []CentralityScore
+	for _, id := range g.sortedIDs() {
+		node := g.nodes[id]
+		node.Score = float64(len(g.adjacency[id]))
+		scores = append(scores, node)
+	}
+
+	ranked := rankCentrality(scores, 3)
+
+	require.Len(t, ranked, 3)
+	assert.Equal(t, "Acme Corp", ranked[0].Name)
+	assert.Equal(t, 3.0, ranked[0].Score)
+	assert.Equal(t, "City Council", ranked[1].Name)
+	assert.Equal(t, 2.0, ranked[1].Score)
+	// Ties are broken alphabetically
+	assert.Equal(t, "Alice", ranked[2].Name)
+}
+
+func TestCentralityGraph_Betweenness(t *testing.T) {
+	g := mockCentralityGraph()
+
+	ranked := rankCentrality(g.betweenness(0), 0)
+
+	require.Len(t, ranked, 5)
+	assert.Equal(t, "Acme Corp", ranked[0].Name)
+	assert.InDelta(t, 5.0, ranked[0].Score, 1e-9)
+	assert.Equal(t, "City Council", ranked[1].Name)
+	assert.InDelta(t, 3.0, ranked[1].Score, 1e-9)
+	for _, leaf := range ranked[2:] {
+		assert.Zero(t, leaf.Score, leaf.Name)
+	}
+	// Degrees follow edge direction: Alice -> Acme Corp
+	assert.Equal(t, 2, ranked[0].InDegree)
+	assert.Equal(t, 1, ranked[0].OutDegree)
+}
+
+func TestCentralityCache(t *testing.T) {
+	scores := []CentralityScore{{ID: "1", Name: "Alice", Score: 2}}
+	setCachedCentrality("degree:1", scores, centralitySourceCypher)
+
+	cached, source, ok := getCachedCentrality("degree:1")
+	require.True(t, ok)
+	assert.Equal(t, scores, cached)
+	assert.Equal(t, centralitySourceCypher, source)
+
+	_, _, ok = getCachedCentrality("betweenness:1")
+	assert.False(t, ok)
+}
+
+// gdsTx records the queries and graph names it runs, answering the stream query
+// with records
+type gdsTx struct {
+	neo4j.Transaction
+	records []*neo4j.Record
+	queries []string
+	graphs  []interface{}
+}
+
+func (tx *gdsTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.queries = append(tx.queries, cypher)
+	tx.graphs = append(tx.graphs, params["graph"])
+	if strings.Contains(cypher, "gds.betweenness.stream") {
+		return &fakeResult{records: tx.records, index: -1}, nil
+	}
+	return &fakeResult{index: -1}, nil
+}
+
+func TestGDSBetweenness_UsesNamedGraph(t *testing.T) {
+	tx := &gdsTx{records: []*neo4j.Record{
+		{Values: []interface{}{int64(4), "Acme Corp", "Company", 3.5, int64(2), int64(1)}},
+	}}
+
+	require.NoError(t, projectGDSGraph(tx, "clank-centrality-a"))
+	scores, err := gdsBetweenness(tx, "clank-centrality-a", 10)
+	require.NoError(t, err)
+	require.NoError(t, dropGDSGraph(tx, "clank-centrality-a"))
+
+	require.Len(t, tx.queries, 3)
+	assert.Contains(t, tx.queries[0], "gds.graph.project($graph")
+	assert.Contains(t, tx.queries[2], "gds.graph.drop($graph, false)")
+	assert.Equal(t, []interface{}{"clank-centrality-a", "clank-centrality-a", "clank-centrality-a"}, tx.graphs)
+	assert.Equal(t, []CentralityScore{
+		{ID: "4", Name: "Acme Corp", Type: "Company", Score: 3.5, InDegree: 2, OutDegree: 1},
+	}, scores)
+}
@@ -0,0 +1,210 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	// cycleMaxEdges bounds the ownership subgraph loaded for cycle detection
+	cycleMaxEdges = 10000
+	// cycleMaxLengthLimit is the longest cycle a caller may ask for
+	cycleMaxLengthLimit = 8
+)
+
+// defaultCycleTypes are the relationship types followed when none are requested
+var defaultCycleTypes = []string{"OWNS", "CONTROLLED_BY", "PAID"}
+
+// reversedCycleTypes point from the controlled entity to its controller, so they
+// are followed backwards to keep every edge in the direction of control or money
+var reversedCycleTypes = map[string]bool{"CONTROLLED_BY": true}
+
+// CycleEntity is a member of a suspicious cycle
+type CycleEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// SuspiciousCycle is a closed chain of ownership/control/payment relationships.
+// Relationships[i] links Entities[i] to Entities[i+1], wrapping around to the first entity.
+type SuspiciousCycle struct {
+	Entities      []CycleEntity `json:"entities"`
+	Relationships []string      `json:"relationships"`
+	Length        int           `json:"length"`
+	Flag          string        `json:"flag"`
+}
+
+// cycleEdge is a directed edge in the cycle detection graph
+type cycleEdge struct {
+	to      string
+	relType string
+}
+
+// cycleGraph is a directed in-memory graph used for cycle detection
+type cycleGraph struct {
+	nodes     map[string]CycleEntity
+	adjacency map[string][]cycleEdge
+}
+
+func newCycleGraph() *cycleGraph {
+	return &cycleGraph{
+		nodes:     make(map[string]CycleEntity),
+		adjacency: make(map[string][]cycleEdge),
+	}
+}
+
+// addRelationship adds a relationship, reversing types listed in reversedCycleTypes
+func (g *cycleGraph) addRelationship(from, to CycleEntity, relType string) {
+	g.nodes[from.ID] = from
+	g.nodes[to.ID] = to
+	if reversedCycleTypes[relType] {
+		from, to = to, from
+	}
+	g.adjacency[from.ID] = append(g.adjacency[from.ID], cycleEdge{to: to.ID, relType: relType})
+}
+
+// GetOwnershipCyclesHandler flags circular ownership, control and payment structures
+// (A owns B owns C owns A) of up to maxLength entities
+func GetOwnershipCyclesHandler(c *gin.Context) {
+	maxLength, err := strconv.Atoi(c.DefaultQuery("maxLength", "4"))
+	if err != nil || maxLength < 2 || maxLength > cycleMaxLengthLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("maxLength must be between 2 and %d", cycleMaxLengthLimit)})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit parameter"})
+		return
+	}
+
+	types := defaultCycleTypes
+	if requested := c.Query("types"); requested != "" {
+		types = nil
+		for _, t := range strings.Split(requested, ",") {
+			if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		// Extracted relationships are stored as RELATES_TO with the type as a property
+		query := `
+			MATCH (a)-[r]->(b)
+			WITH a, b, CASE type(r) WHEN 'RELATES_TO' THEN toUpper(r.type) ELSE type(r) END as relType
+			WHERE relType IN $types
+			RETURN coalesce(a.id, toString(ID(a))), a.name, coalesce(a.type, labels(a)[0]),
+			       coalesce(b.id, toString(ID(b))), b.name, coalesce(b.type, labels(b)[0]), relType
+			LIMIT $maxEdges
+		`
+		result, err := tx.Run(query, map[string]interface{}{"types": types, "maxEdges": cycleMaxEdges})
+		if err != nil {
+			return nil, err
+		}
+
+		g := newCycleGraph()
+		for result.Next() {
+			v := result.Record().Values
+			from := CycleEntity{ID: stringValue(v[0]), Name: stringValue(v[1]), Type: stringValue(v[2])}
+			to := CycleEntity{ID: stringValue(v[3]), Name: stringValue(v[4]), Type: stringValue(v[5])}
+			g.addRelationship(from, to, stringValue(v[6]))
+		}
+		return g, nil
+	})
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	cycles := result.(*cycleGraph).findCycles(maxLength, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"cycles":    cycles,
+		"count":     len(cycles),
+		"maxLength": maxLength,
+		"types":     types,
+		"truncated": len(cycles) == limit,
+	})
+}
+
+// findCycles returns the simple directed cycles of 2 to maxLength entities, at most
+// limit of them. Each cycle is reported once, starting from its smallest entity ID.
+func (g *cycleGraph) findCycles(maxLength, limit int) []SuspiciousCycle {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var cycles []SuspiciousCycle
+	seen := make(map[string]bool)
+	path := make([]string, 0, maxLength)
+	rels := make([]string, 0, maxLength)
+	onPath := make(map[string]bool)
+
+	var visit func(start, current string)
+	visit = func(start, current string) {
+		for _, edge := range g.adjacency[current] {
+			if len(cycles) >= limit {
+				return
+			}
+
+			if edge.to == start && len(path) >= 2 {
+				cycle := g.newSuspiciousCycle(path, append(rels, edge.relType))
+				key := strings.Join(path, ">")
+				if !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+				continue
+			}
+
+			// Only extend through IDs greater than the start so each cycle is found from its minimum
+			if edge.to <= start || onPath[edge.to] || len(path) >= maxLength {
+				continue
+			}
+
+			path = append(path, edge.to)
+			rels = append(rels, edge.relType)
+			onPath[edge.to] = true
+			visit(start, edge.to)
+			onPath[edge.to] = false
+			path = path[:len(path)-1]
+			rels = rels[:len(rels)-1]
+		}
+	}
+
+	for _, id := range ids {
+		if len(cycles) >= limit {
+			break
+		}
+		path = append(path[:0], id)
+		rels = rels[:0]
+		onPath[id] = true
+		visit(id, id)
+		onPath[id] = false
+	}
+
+	return cycles
+}
+
+func (g *cycleGraph) newSuspiciousCycle(path, rels []string) SuspiciousCycle {
+	cycle := SuspiciousCycle{
+		Entities:      make([]CycleEntity, len(path)),
+		Relationships: append([]string(nil), rels...),
+		Length:        len(path),
+		Flag:          "circular_structure",
+	}
+	for i, id := range path {
+		cycle.Entities[i] = g.nodes[id]
+	}
+	return cycle
+}
@@ -0,0 +1,78 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockOwnershipGraph builds a 3-node shell company loop plus unrelated ownership:
+//
+//	Alpha Holdings -OWNS-> Beta Ltd -OWNS-> Gamma LLC -OWNS-> Alpha Holdings
+//	Jane Doe -OWNS-> Alpha Holdings
+//	Delta Inc -OWNS-> Epsilon SA
+func mockOwnershipGraph() *cycleGraph {
+	alpha := CycleEntity{ID: "a", Name: "Alpha Holdings", Type: "Company"}
+	beta := CycleEntity{ID: "b", Name: "Beta Ltd", Type: "Company"}
+	gamma := CycleEntity{ID: "c", Name: "Gamma LLC", Type: "Company"}
+	jane := CycleEntity{ID: "j", Name: "Jane Doe", Type: "Person"}
+	delta := CycleEntity{ID: "d", Name: "Delta Inc", Type: "Company"}
+	epsilon := CycleEntity{ID: "e", Name: "Epsilon SA", Type: "Company"}
+
+	g := newCycleGraph()
+	g.addRelationship(alpha, beta, "OWNS")
+	g.addRelationship(beta, gamma, "OWNS")
+	g.addRelationship(gamma, alpha, "OWNS")
+	g.addRelationship(jane, alpha, "OWNS")
+	g.addRelationship(delta, epsilon, "OWNS")
+	return g
+}
+
+func TestFindCycles_OwnershipLoop(t *testing.T) {
+	cycles := mockOwnershipGraph().findCycles(4, 50)
+	require.Len(t, cycles, 1)
+
+	cycle := cycles[0]
+	assert.Equal(t, 3, cycle.Length)
+	assert.Equal(t, "circular_structure", cycle.Flag)
+	assert.Equal(t, []string{"OWNS", "OWNS", "OWNS"}, cycle.Relationships)
+
+	names := make([]string, len(cycle.Entities))
+	for i, e := range cycle.Entities {
+		names[i] = e.Name
+	}
+	assert.Equal(t, []string{"Alpha Holdings", "Beta Ltd", "Gamma LLC"}, names)
+}
+
+func TestFindCycles_MaxLength(t *testing.T) {
+	assert.Empty(t, mockOwnershipGraph().findCycles(2, 50))
+}
+
+func TestFindCycles_ControlledByIsReversed(t *testing.T) {
+	// Alpha owns Beta and Alpha is controlled by Beta: control flows both ways
+	alpha := CycleEntity{ID: "a", Name: "Alpha Holdings"}
+	beta := CycleEntity{ID: "b", Name: "Beta Ltd"}
+
+	g := newCycleGraph()
+	g.addRelationship(alpha, beta, "OWNS")
+	g.addRelationship(alpha, beta, "CONTROLLED_BY")
+
+	cycles := g.findCycles(4, 50)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, []string{"OWNS", "CONTROLLED_BY"}, cycles[0].Relationships)
+
+	// Owning and controlling the same company in the same direction is not a cycle
+	g = newCycleGraph()
+	g.addRelationship(alpha, beta, "OWNS")
+	g.addRelationship(beta, alpha, "CONTROLLED_BY")
+	assert.Empty(t, g.findCycles(4, 50))
+}
+
+func TestFindCycles_Limit(t *testing.T) {
+	g := mockOwnershipGraph()
+	g.addRelationship(CycleEntity{ID: "e"}, CycleEntity{ID: "d"}, "PAID")
+
+	assert.Len(t, g.findCycles(4, 50), 2)
+	assert.Len(t, g.findCycles(4, 1), 1)
+}
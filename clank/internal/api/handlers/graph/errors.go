@@ -2,14 +2,47 @@ package graph
 
 import (
 	"clank/internal/db"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
+// Errors the handlers' queries return, with the message shown to clients
+var (
+	errNodeNotFound         = db.NewError(db.ErrNotFound, "node not found")
+	errRelationshipNotFound = db.NewError(db.ErrNotFound, "relationship not found")
+	errNoConnections        = db.NewError(db.ErrNotFound, "no connections found")
+	errNoEvents             = db.NewError(db.ErrNotFound, "no events found")
+	errNoPath               = db.NewError(db.ErrNotFound, "no path found")
+)
+
+// errorKinds maps each kind of database error to its status and code
+var errorKinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{db.ErrNotFound, http.StatusNotFound, "NOT_FOUND"},
+	{db.ErrValidation, http.StatusBadRequest, "INVALID_REQUEST"},
+	{db.ErrConflict, http.StatusConflict, "CONFLICT"},
+	{db.ErrUnavailable, http.StatusServiceUnavailable, "DATABASE_UNAVAILABLE"},
+}
+
 // handleDBError handles database errors and returns appropriate HTTP responses
 func handleDBError(c *gin.Context, err error) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.kind) {
+			c.JSON(k.status, gin.H{
+				"error": errorMessage(err),
+				"code":  k.code,
+			})
+			return
+		}
+	}
+
 	if !db.IsAvailable() {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
 			"error": "Database service is currently unavailable",
@@ -19,17 +52,12 @@ func handleDBError(c *gin.Context, err error) {
 	}
 
 	// Handle specific Neo4j errors
-	switch err.Error() {
-	case "Neo.ClientError.Statement.TypeError":
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid data type in request",
-			"code":  "INVALID_DATA_TYPE",
-		})
-	case "Neo.ClientError.Statement.ParameterMissing":
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing required parameters",
-			"code":  "MISSING_PARAMETERS",
-		})
+	var neo4jErr *neo4j.Neo4jError
+	code := ""
+	if errors.As(err, &neo4jErr) {
+		code = neo4jErr.Code
+	}
+	switch code {
 	case "Neo.ClientError.Statement.SyntaxError":
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Database query syntax error",
@@ -49,3 +77,13 @@ func handleDBError(c *gin.Context, err error) {
 		})
 	}
 }
+
+// errorMessage returns the client-facing message of a typed database error,
+// without the transaction wrapping around it
+func errorMessage(err error) string {
+	var typed *db.Error
+	if errors.As(err, &typed) {
+		return typed.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dbErrorResponse(t *testing.T, err error) (int, map[string]string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	handleDBError(c, err)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestHandleDBError_NotFound(t *testing.T) {
+	// As ExecuteRead returns it
+	err := fmt.Errorf("read transaction failed: %w", errNodeNotFound)
+
+	status, body := dbErrorResponse(t, err)
+
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "node not found", body["error"])
+	assert.Equal(t, "NOT_FOUND", body["code"])
+}
+
+func TestHandleDBError_Validation(t *testing.T) {
+	err := fmt.Errorf("write transaction failed: %w", db.NewError(db.ErrValidation, "Missing required parameters"))
+
+	status, body := dbErrorResponse(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.Equal(t, "Missing required parameters", body["error"])
+	assert.Equal(t, "INVALID_REQUEST", body["code"])
+}
+
+func TestHandleDBError_Kinds(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{db.ErrDuplicateContent, http.StatusConflict},
+		{fmt.Errorf("read transaction failed: %w", db.ErrCircuitOpen), http.StatusServiceUnavailable},
+	} {
+		status, body := dbErrorResponse(t, tc.err)
+		assert.Equal(t, tc.status, status, tc.err.Error())
+		assert.NotContains(t, body["error"], "transaction failed", "clients see the typed error's message")
+	}
+}
@@ -0,0 +1,174 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// ChainEvent is an event in a temporal/causal chain
+type ChainEvent struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Order int    `json:"order"`
+}
+
+// ChainLink is a BEFORE or CAUSED edge between two events
+type ChainLink struct {
+	FromID string `json:"fromId"`
+	ToID   string `json:"toId"`
+	Type   string `json:"type"`
+}
+
+// GetEventChainHandler returns the ordered chain of events an entity is involved in,
+// following BEFORE and CAUSED edges up to the requested depth
+func GetEventChainHandler(c *gin.Context) {
+	entityID := c.Param("entityId")
+	depth, err := strconv.Atoi(c.DefaultQuery("depth", "5"))
+	if err != nil || depth < 1 || depth > 20 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "depth must be between 1 and 20"})
+		return
+	}
+
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		eventsQuery := `
+			MATCH (n:Entity {id: $entityId})-[:RELATES_TO]-(seed:Entity)
+			WHERE toLower(seed.type) = 'event'
+			MATCH (seed)-[:BEFORE|CAUSED*0..%d]-(ev:Entity)
+			RETURN DISTINCT ev.id as id, ev.name as name
+		`
+		result, err := tx.Run(fmt.Sprintf(eventsQuery, depth), map[string]interface{}{"entityId": entityID})
+		if err != nil {
+			return nil, err
+		}
+
+		var events []ChainEvent
+		var ids []string
+		for result.Next() {
+			record := result.Record()
+			id := stringValue(record.Values[0])
+			events = append(events, ChainEvent{ID: id, Name: stringValue(record.Values[1])})
+			ids = append(ids, id)
+		}
+
+		if len(events) == 0 {
+			return nil, errNoEvents
+		}
+
+		linksQuery := `
+			MATCH (a:Entity)-[r:BEFORE|CAUSED]->(b:Entity)
+			WHERE a.id IN $ids AND b.id IN $ids
+			RETURN a.id, b.id, type(r)
+		`
+		result, err = tx.Run(linksQuery, map[string]interface{}{"ids": ids})
+		if err != nil {
+			return nil, err
+		}
+
+		var links []ChainLink
+		for result.Next() {
+			v := result.Record().Values
+			links = append(links, ChainLink{FromID: stringValue(v[0]), ToID: stringValue(v[1]), Type: stringValue(v[2])})
+		}
+
+		return map[string]interface{}{
+			"entityId": entityID,
+			"events":   orderEventChain(events, links),
+			"links":    links,
+		}, nil
+	})
+
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// orderEventChain orders events so every BEFORE/CAUSED edge points forward.
+// Events with no ordering between them are sorted by name; events on a cycle
+// are appended at the end in name order.
+func orderEventChain(events []ChainEvent, links []ChainLink) []ChainEvent {
+	byID := make(map[string]ChainEvent, len(events))
+	inDegree := make(map[string]int, len(events))
+	next := make(map[string][]string)
+	for _, e := range events {
+		byID[e.ID] = e
+		inDegree[e.ID] = 0
+	}
+	for _, l := range links {
+		if _, ok := byID[l.FromID]; !ok {
+			continue
+		}
+		if _, ok := byID[l.ToID]; !ok {
+			continue
+		}
+		next[l.FromID] = append(next[l.FromID], l.ToID)
+		inDegree[l.ToID]++
+	}
+
+	byName := func(ids []string) {
+		sort.Slice(ids, func(i, j int) bool {
+			if byID[ids[i]].Name != byID[ids[j]].Name {
+				return byID[ids[i]].Name < byID[ids[j]].Name
+			}
+			return ids[i] < ids[j]
+		})
+	}
+
+	var ready []string
+	for id, degree := range inDegree {
+		if degree == 0 {
+			ready = append(ready, id)
+		}
+	}
+	byName(ready)
+
+	ordered := make([]ChainEvent, 0, len(events))
+	placed := make(map[string]bool, len(events))
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		event := byID[id]
+		event.Order = len(ordered) + 1
+		ordered = append(ordered, event)
+		placed[id] = true
+
+		var unlocked []string
+		for _, to := range next[id] {
+			inDegree[to]--
+			if inDegree[to] == 0 {
+				unlocked = append(unlocked, to)
+			}
+		}
+		byName(unlocked)
+		ready = append(ready, unlocked...)
+	}
+
+	// Anything left is part of a cycle
+	var remaining []string
+	for id := range byID {
+		if !placed[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	byName(remaining)
+	for _, id := range remaining {
+		event := byID[id]
+		event.Order = len(ordered) + 1
+		ordered = append(ordered, event)
+	}
+
+	return ordered
+}
@@ -0,0 +1,67 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func eventNames(events []ChainEvent) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Name
+	}
+	return names
+}
+
+func TestOrderEventChain(t *testing.T) {
+	events := []ChainEvent{
+		{ID: "e3", Name: "Contract awarded"},
+		{ID: "e1", Name: "Campaign donation"},
+		{ID: "e4", Name: "Audit opened"},
+		{ID: "e2", Name: "Private meeting"},
+	}
+
+	tests := []struct {
+		name     string
+		links    []ChainLink
+		expected []string
+	}{
+		{
+			name: "linear chain",
+			links: []ChainLink{
+				{FromID: "e1", ToID: "e2", Type: "BEFORE"},
+				{FromID: "e2", ToID: "e3", Type: "CAUSED"},
+				{FromID: "e3", ToID: "e4", Type: "BEFORE"},
+			},
+			expected: []string{"Campaign donation", "Private meeting", "Contract awarded", "Audit opened"},
+		},
+		{
+			name: "branches are ordered by name",
+			links: []ChainLink{
+				{FromID: "e1", ToID: "e3", Type: "CAUSED"},
+				{FromID: "e1", ToID: "e4", Type: "BEFORE"},
+			},
+			expected: []string{"Campaign donation", "Private meeting", "Audit opened", "Contract awarded"},
+		},
+		{
+			name: "cycles are appended",
+			links: []ChainLink{
+				{FromID: "e1", ToID: "e2", Type: "BEFORE"},
+				{FromID: "e3", ToID: "e4", Type: "BEFORE"},
+				{FromID: "e4", ToID: "e3", Type: "BEFORE"},
+			},
+			expected: []string{"Campaign donation", "Private meeting", "Audit opened", "Contract awarded"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered := orderEventChain(events, tt.links)
+			assert.Equal(t, tt.expected, eventNames(ordered))
+			for i, e := range ordered {
+				assert.Equal(t, i+1, e.Order)
+			}
+		})
+	}
+}
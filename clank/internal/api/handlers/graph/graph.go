@@ -3,6 +3,7 @@ package graph
 import (
 	"clank/internal/db"
 	"clank/internal/models"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -37,7 +38,7 @@ func GetShortestPath(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("no path found")
+			return nil, errNoPath
 		}
 
 		record := result.Record()
@@ -72,8 +73,8 @@ func GetShortestPath(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "no path found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -109,7 +110,7 @@ func GetSubgraph(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("node not found")
+			return nil, errNodeNotFound
 		}
 
 		record := result.Record()
@@ -178,8 +179,8 @@ func GetSubgraph(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "node not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
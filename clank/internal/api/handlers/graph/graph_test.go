@@ -146,6 +146,32 @@ func TestCreateNode(t *testing.T) {
 	}
 }
 
+func TestNodeLabel(t *testing.T) {
+	for nodeType, expected := range map[string]string{
+		"Person":     "Person",
+		"individual": "Person",
+		"org":        "Organization",
+		"Company":    "Company",
+		"incident":   "Event",
+	} {
+		label, err := nodeLabel(nodeType)
+		require.NoError(t, err, nodeType)
+		assert.Equal(t, expected, label, nodeType)
+	}
+
+	for _, invalid := range []string{"Persom", "person {name: 'x'}) DETACH DELETE n //", ""} {
+		_, err := nodeLabel(invalid)
+		assert.ErrorIs(t, err, models.ErrUnknownEntityType, invalid)
+	}
+
+	// Multi-word types of a configured taxonomy
+	models.SetEntityTaxonomy(models.NewEntityTaxonomy(map[string][]string{"government_agency": nil}, ""))
+	t.Cleanup(func() { models.SetEntityTaxonomy(nil) })
+	label, err := nodeLabel("Government Agency")
+	require.NoError(t, err)
+	assert.Equal(t, "GovernmentAgency", label)
+}
+
 func TestGetNode(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -251,6 +277,19 @@ func TestCreateRelationship(t *testing.T) {
 			mockSetup:      func(db *testutil.MockDB) {},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "malicious relationship type",
+			requestBody: &models.ExtractedRelationship{
+				Type:   "OWNS]->(to) DETACH DELETE to //",
+				FromID: "entity1",
+				ToID:   "entity2",
+			},
+			mockSetup:      func(db *testutil.MockDB) {},
+			expectedStatus: http.StatusBadRequest,
+			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
+				assert.Contains(t, rr.Body.String(), "unknown relationship type")
+			},
+		},
 	}
 
 	for _, tt := range tests {
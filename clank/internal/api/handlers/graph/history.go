@@ -0,0 +1,25 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetEntityHistoryHandler returns the revisions recorded for an entity, oldest
+// first: which article changed which properties, from what to what
+func GetEntityHistoryHandler(c *gin.Context) {
+	entityID := c.Param("id")
+
+	revisions, err := db.GetEntityHistory(entityID)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entityId":  entityID,
+		"revisions": revisions,
+	})
+}
@@ -0,0 +1,64 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"clank/internal/logging"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportDocuments bounds the documents accepted by one import request
+const maxImportDocuments = 1000
+
+// ImportDocumentsHandler loads articles extracted by an external pipeline. The body
+// is a JSON array of {article, entities, relationships} documents. With
+// ?transactional=true nothing is imported unless every document is; otherwise
+// documents are imported independently in batches of ?batchSize=. Responds 200
+// when every document was imported, 207 when only some were and 422 when none were,
+// with the outcome of each document.
+func ImportDocumentsHandler(c *gin.Context) {
+	transactional, err := strconv.ParseBool(c.DefaultQuery("transactional", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transactional parameter"})
+		return
+	}
+	batchSize, err := strconv.Atoi(c.DefaultQuery("batchSize", strconv.Itoa(db.DefaultImportBatchSize)))
+	if err != nil || batchSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batchSize parameter"})
+		return
+	}
+
+	var docs []db.ImportDocument
+	if err := c.ShouldBindJSON(&docs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(docs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no documents to import"})
+		return
+	}
+	if len(docs) > maxImportDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d documents can be imported at once", maxImportDocuments)})
+		return
+	}
+
+	report := db.ImportDocuments(docs, db.ImportOptions{
+		Transactional: transactional,
+		BatchSize:     batchSize,
+		Progress: func(done, total int) {
+			logging.For(c.Request.Context(), "graph").Info("Import progress", "done", done, "total", total)
+		},
+	})
+
+	status := http.StatusOK
+	switch {
+	case report.Imported == 0:
+		status = http.StatusUnprocessableEntity
+	case report.Failed > 0:
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, report)
+}
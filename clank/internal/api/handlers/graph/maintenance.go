@@ -0,0 +1,33 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeduplicateRelationshipsHandler collapses parallel edges of the same type between
+// the same node pair, left behind by older writes that used CREATE. It is safe to
+// re-run; a second run merges nothing. ?batchSize= sets the groups merged per
+// transaction.
+func DeduplicateRelationshipsHandler(c *gin.Context) {
+	batchSize, err := strconv.Atoi(c.DefaultQuery("batchSize", strconv.Itoa(db.DefaultDedupBatchSize)))
+	if err != nil || batchSize < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batchSize parameter"})
+		return
+	}
+
+	report, err := db.DeduplicateRelationships(batchSize)
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"merged":  report.Removed,
+		"groups":  report.Groups,
+		"batches": report.Batches,
+	})
+}
@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// networkConnections mirrors the collect({node, relationship}) rows for Acme Corp
+func networkConnections() []interface{} {
+	return []interface{}{
+		map[string]interface{}{"node": alice, "relationship": neo4j.Relationship{Id: 10, StartId: 1, EndId: 2, Type: "EMPLOYED_BY", Props: map[string]interface{}{"confidence": 0.9}}},
+		map[string]interface{}{"node": council, "relationship": neo4j.Relationship{Id: 11, StartId: 2, EndId: 3, Type: "PAID", Props: map[string]interface{}{"confidence": 0.4}}},
+		map[string]interface{}{"node": nil, "relationship": nil}, // OPTIONAL MATCH with no neighbors
+	}
+}
+
+func TestBuildNetworkNode_Direction(t *testing.T) {
+	node := buildNetworkNode(acme, networkConnections(), networkFilter{})
+
+	require.Len(t, node.Connections, 2)
+	assert.Equal(t, "EMPLOYED_BY", node.Connections[0].Relationship.Type)
+	assert.Equal(t, "incoming", node.Connections[0].Relationship.Direction, "Alice -> Acme is incoming for Acme")
+	assert.Equal(t, "PAID", node.Connections[1].Relationship.Type)
+	assert.Equal(t, "outgoing", node.Connections[1].Relationship.Direction, "Acme -> Council is outgoing for Acme")
+	assert.Equal(t, "3", node.Connections[1].ID)
+	assert.Equal(t, 2, node.Metadata.ConnectionCount)
+}
+
+func TestBuildNetworkNode_Filters(t *testing.T) {
+	filter, err := parseNetworkFilter("paid", "")
+	require.NoError(t, err)
+	node := buildNetworkNode(acme, networkConnections(), filter)
+	require.Len(t, node.Connections, 1)
+	assert.Equal(t, "PAID", node.Connections[0].Relationship.Type)
+
+	filter, err = parseNetworkFilter("", "0.5")
+	require.NoError(t, err)
+	node = buildNetworkNode(acme, networkConnections(), filter)
+	require.Len(t, node.Connections, 1)
+	assert.Equal(t, "EMPLOYED_BY", node.Connections[0].Relationship.Type)
+
+	filter, err = parseNetworkFilter("OWNS", "")
+	require.NoError(t, err)
+	assert.True(t, filter.active())
+	assert.Empty(t, buildNetworkNode(acme, networkConnections(), filter).Connections)
+}
+
+func TestBuildNetworkNode_FiltersExtractedRelationships(t *testing.T) {
+	connections := append(networkConnections(), map[string]interface{}{
+		"node":         council,
+		"relationship": neo4j.Relationship{Id: 12, StartId: 2, EndId: 3, Type: "RELATES_TO", Props: map[string]interface{}{"type": "payment", "confidence": 0.8}},
+	})
+
+	filter, err := parseNetworkFilter("PAYMENT", "")
+	require.NoError(t, err)
+	node := buildNetworkNode(acme, connections, filter)
+	require.Len(t, node.Connections, 1)
+	assert.Equal(t, "RELATES_TO", node.Connections[0].Relationship.Type)
+
+	filter, err = parseNetworkFilter("RELATES_TO", "")
+	require.NoError(t, err)
+	assert.Empty(t, buildNetworkNode(acme, connections, filter).Connections, "extracted relationships match by their own type")
+}
+
+func TestBuildNetworkNode_Unlabeled(t *testing.T) {
+	node := buildNetworkNode(neo4j.Node{Id: 7}, nil, networkFilter{})
+	assert.Equal(t, "", node.Type)
+	assert.Empty(t, node.Connections)
+}
+
+func TestParseNetworkFilter_Invalid(t *testing.T) {
+	_, err := parseNetworkFilter("", "high")
+	assert.Error(t, err)
+	_, err = parseNetworkFilter("", "1.5")
+	assert.Error(t, err)
+
+	filter, err := parseNetworkFilter("", "")
+	require.NoError(t, err)
+	assert.False(t, filter.active())
+}
@@ -3,8 +3,11 @@ package graph
 import (
 	"clank/internal/db"
 	"clank/internal/models"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -54,6 +57,12 @@ func CreateNode(c *gin.Context) {
 		return
 	}
 
+	label, err := nodeLabel(node.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Add timestamps
 	now := time.Now()
 	if node.Props == nil {
@@ -71,7 +80,7 @@ func CreateNode(c *gin.Context) {
 			"props": node.Props,
 		}
 
-		result, err := tx.Run(fmt.Sprintf(query, node.Type), params)
+		result, err := tx.Run(fmt.Sprintf(query, label), params)
 		if err != nil {
 			return nil, err
 		}
@@ -98,6 +107,24 @@ func CreateNode(c *gin.Context) {
 	c.JSON(http.StatusCreated, result)
 }
 
+// nodeLabel maps a node type onto the entity taxonomy and checks it is safe to use
+// as a label, since labels can't be passed as query parameters. Labels keep the
+// graph's casing ("individual" is Person, "government_agency" GovernmentAgency),
+// as the seed data and the frontend compare against them.
+func nodeLabel(nodeType string) (string, error) {
+	entityType, err := models.NormalizeEntityType(nodeType)
+	if err != nil {
+		return "", err
+	}
+	var label strings.Builder
+	for _, word := range strings.Split(entityType, "_") {
+		if word != "" {
+			label.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return models.SanitizeLabel(label.String())
+}
+
 // GetNode returns a specific node by ID
 func GetNode(c *gin.Context) {
 	id := c.Param("id")
@@ -118,7 +145,7 @@ func GetNode(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("node not found")
+			return nil, errNodeNotFound
 		}
 
 		record := result.Record()
@@ -132,8 +159,8 @@ func GetNode(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "node not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -176,7 +203,7 @@ func UpdateNode(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("node not found")
+			return nil, errNodeNotFound
 		}
 
 		record := result.Record()
@@ -190,8 +217,8 @@ func UpdateNode(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "node not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -226,15 +253,15 @@ func DeleteNode(c *gin.Context) {
 		}
 
 		if summary.Counters().NodesDeleted() == 0 {
-			return nil, fmt.Errorf("node not found")
+			return nil, errNodeNotFound
 		}
 
 		return nil, nil
 	})
 
 	if err != nil {
-		if err.Error() == "node not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -244,123 +271,42 @@ func DeleteNode(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-// SearchNodes searches nodes based on properties
-func SearchNodes(c *gin.Context) {
-	query := c.Query("q")
-	nodeType := c.Query("type")
-
-	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
-		var cypher string
-		params := map[string]interface{}{
-			"query": "(?i).*" + query + ".*", // Case-insensitive regex
-		}
-
-		if nodeType != "" {
-			cypher = `
-				MATCH (n:%s)
-				WHERE any(prop in keys(n) WHERE n[prop] =~ $query)
-				RETURN n
-			`
-			cypher = fmt.Sprintf(cypher, nodeType)
-		} else {
-			cypher = `
-				MATCH (n)
-				WHERE any(prop in keys(n) WHERE n[prop] =~ $query)
-				RETURN n
-			`
-		}
-
-		result, err := tx.Run(cypher, params)
-		if err != nil {
-			return nil, err
-		}
-
-		var nodes []models.Node
-		for result.Next() {
-			record := result.Record()
-			node := record.Values[0].(neo4j.Node)
-
-			nodes = append(nodes, models.Node{
-				ID:    fmt.Sprint(node.Id),
-				Type:  node.Labels[0],
-				Props: node.Props,
-			})
-		}
-
-		return nodes, nil
-	})
-
+// GetNetwork returns the graph network. Article, Mention and EntityRevision
+// housekeeping nodes are left out unless includeHousekeeping=true; types
+// (comma-separated relationship types) and minConfidence restrict the relationships,
+// and with either set only nodes that keep a relationship are returned.
+func GetNetwork(c *gin.Context) {
+	filter, err := parseNetworkFilter(c.Query("types"), c.Query("minConfidence"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	includeHousekeeping := c.Query("includeHousekeeping") == "true"
 
-	c.JSON(http.StatusOK, result)
-}
-
-// GetNetwork returns the entire graph network
-func GetNetwork(c *gin.Context) {
 	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
 		query := `
 			MATCH (n)
+			WHERE $includeHousekeeping OR NOT (n:Article OR n:Mention OR n:EntityRevision)
 			OPTIONAL MATCH (n)-[r]-(m)
+			WHERE $includeHousekeeping OR NOT (m:Article OR m:Mention OR m:EntityRevision)
 			RETURN n, collect({node: m, relationship: r}) as connections
 		`
 
-		result, err := tx.Run(query, nil)
+		result, err := tx.Run(query, map[string]interface{}{"includeHousekeeping": includeHousekeeping})
 		if err != nil {
 			return nil, err
 		}
 
-		var network []models.NodeWithConnections
+		network := []models.NodeWithConnections{}
 		for result.Next() {
 			record := result.Record()
 			node := record.Values[0].(neo4j.Node)
 			connections := record.Values[1].([]interface{})
 
-			nodeWithConn := models.NodeWithConnections{
-				ID:         fmt.Sprint(node.Id),
-				Type:       node.Labels[0],
-				Properties: node.Props,
+			nodeWithConn := buildNetworkNode(node, connections, filter)
+			if filter.active() && len(nodeWithConn.Connections) == 0 {
+				continue
 			}
-
-			for _, conn := range connections {
-				if conn == nil {
-					continue
-				}
-
-				connMap := conn.(map[string]interface{})
-
-				// Skip if either node or relationship is nil
-				if connMap["node"] == nil || connMap["relationship"] == nil {
-					continue
-				}
-
-				connNode, ok := connMap["node"].(neo4j.Node)
-				if !ok {
-					continue
-				}
-
-				rel, ok := connMap["relationship"].(neo4j.Relationship)
-				if !ok {
-					continue
-				}
-
-				connection := models.Connection{
-					ID:         fmt.Sprint(connNode.Id),
-					Type:       connNode.Labels[0],
-					Properties: connNode.Props,
-				}
-				connection.Relationship.Type = rel.Type
-				connection.Relationship.Properties = rel.Props
-				connection.Relationship.Direction = "outgoing"
-				if rel.StartId == node.Id {
-					connection.Relationship.Direction = "incoming"
-				}
-
-				nodeWithConn.Connections = append(nodeWithConn.Connections, connection)
-			}
-
 			network = append(network, nodeWithConn)
 		}
 
@@ -374,3 +320,94 @@ func GetNetwork(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+// networkFilter restricts the relationships GetNetwork returns
+type networkFilter struct {
+	types         map[string]bool // Uppercased relationship types; empty allows all
+	minConfidence float64         // Relationships below this confidence are dropped (0 keeps all)
+}
+
+// parseNetworkFilter parses the comma-separated types and minConfidence query values
+func parseNetworkFilter(types, minConfidence string) (networkFilter, error) {
+	filter := networkFilter{types: make(map[string]bool)}
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			filter.types[t] = true
+		}
+	}
+	if minConfidence != "" {
+		value, err := strconv.ParseFloat(minConfidence, 64)
+		if err != nil || value < 0 || value > 1 {
+			return filter, fmt.Errorf("minConfidence must be a number between 0 and 1")
+		}
+		filter.minConfidence = value
+	}
+	return filter, nil
+}
+
+func (f networkFilter) active() bool {
+	return len(f.types) > 0 || f.minConfidence > 0
+}
+
+// allows reports whether a relationship passes the type and confidence filters.
+// Extracted relationships are stored as RELATES_TO and filtered by their type
+// property. Relationships without a confidence are dropped once a minimum is set.
+func (f networkFilter) allows(rel neo4j.Relationship) bool {
+	relType := rel.Type
+	if t, ok := rel.Props["type"].(string); ok && t != "" && rel.Type == "RELATES_TO" {
+		relType = t
+	}
+	if len(f.types) > 0 && !f.types[strings.ToUpper(relType)] {
+		return false
+	}
+	if f.minConfidence > 0 {
+		var confidence float64
+		switch v := rel.Props["confidence"].(type) {
+		case float64:
+			confidence = v
+		case int64:
+			confidence = float64(v)
+		default:
+			return false
+		}
+		if confidence < f.minConfidence {
+			return false
+		}
+	}
+	return true
+}
+
+// buildNetworkNode converts a node and its collected {node, relationship} pairs into
+// a NodeWithConnections, skipping empty pairs and relationships the filter rejects
+func buildNetworkNode(node neo4j.Node, connections []interface{}, filter networkFilter) models.NodeWithConnections {
+	nodeWithConn := models.NodeWithConnections{
+		ID:          fmt.Sprint(node.Id),
+		Type:        nodeType(node),
+		Properties:  node.Props,
+		Connections: []models.Connection{},
+	}
+
+	for _, conn := range connections {
+		connMap, ok := conn.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		connNode, ok := connMap["node"].(neo4j.Node)
+		if !ok {
+			continue
+		}
+		rel, ok := connMap["relationship"].(neo4j.Relationship)
+		if !ok || !filter.allows(rel) {
+			continue
+		}
+
+		direction := "incoming"
+		if rel.StartId == node.Id {
+			direction = "outgoing"
+		}
+		nodeWithConn.Connections = append(nodeWithConn.Connections, newConnection(connNode, rel, direction))
+	}
+	nodeWithConn.Metadata.ConnectionCount = len(nodeWithConn.Connections)
+
+	return nodeWithConn
+}
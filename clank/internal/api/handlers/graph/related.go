@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	// defaultRelatedMinConfidence is the mention confidence an entity needs, in
+	// both articles, to count as shared
+	defaultRelatedMinConfidence = 0.7
+	// maxRelatedArticles caps the articles one related-articles request returns
+	maxRelatedArticles = 100
+)
+
+var errArticleNotFound = db.NewError(db.ErrNotFound, "article not found")
+
+// SharedEntity is an entity mentioned by both an article and a related one
+type SharedEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// RelatedArticle is an article mentioning some of the same entities as another
+type RelatedArticle struct {
+	ID             string         `json:"id"`
+	Title          string         `json:"title"`
+	URL            string         `json:"url"`
+	Source         string         `json:"source"`
+	PublishDate    *time.Time     `json:"publishDate,omitempty"`
+	SharedCount    int            `json:"sharedCount"`
+	SharedEntities []SharedEntity `json:"sharedEntities"`
+}
+
+// GetRelatedArticlesHandler returns the articles sharing the most high-confidence
+// entities with an article, e.g. /api/articles/42/related?limit=10&minConfidence=0.7
+func GetRelatedArticlesHandler(c *gin.Context) {
+	articleID := c.Param("id")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > maxRelatedArticles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	minConfidence, err := strconv.ParseFloat(c.DefaultQuery("minConfidence", strconv.FormatFloat(defaultRelatedMinConfidence, 'f', -1, 64)), 64)
+	if err != nil || minConfidence < 0 || minConfidence > 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minConfidence must be between 0 and 1"})
+		return
+	}
+
+	related, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return relatedArticles(tx, articleID, minConfidence, limit)
+	})
+	if errors.Is(err, errArticleNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"articleId":     articleID,
+		"minConfidence": minConfidence,
+		"related":       related,
+	})
+}
+
+// relatedArticles finds the articles other than articleID that mention the same
+// entities with at least minConfidence, ranked by how many they share
+func relatedArticles(tx neo4j.Transaction, articleID string, minConfidence float64, limit int) ([]RelatedArticle, error) {
+	query := `
+		MATCH (a:Article {id: $id})
+		OPTIONAL MATCH (a)-[m1:MENTIONS]->(e:Entity)<-[m2:MENTIONS]-(other:Article)
+		WHERE other <> a
+			AND coalesce(m1.confidence, e.confidence, 0) >= $minConfidence
+			AND coalesce(m2.confidence, e.confidence, 0) >= $minConfidence
+		RETURN a.id, other.id, other.title, other.url, other.source, other.publishDate,
+			e.id, e.name, e.type
+	`
+	result, err := tx.Run(query, map[string]interface{}{"id": articleID, "minConfidence": minConfidence})
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	byID := make(map[string]*RelatedArticle)
+	for result.Next() {
+		v := result.Record().Values
+		found = true
+		otherID := stringValue(v[1])
+		if otherID == "" {
+			continue // The article shares no entities
+		}
+
+		article, ok := byID[otherID]
+		if !ok {
+			article = &RelatedArticle{
+				ID:     otherID,
+				Title:  stringValue(v[2]),
+				URL:    stringValue(v[3]),
+				Source: stringValue(v[4]),
+			}
+			if published, ok := v[5].(time.Time); ok {
+				article.PublishDate = &published
+			}
+			byID[otherID] = article
+		}
+		article.SharedEntities = append(article.SharedEntities, SharedEntity{
+			ID:   stringValue(v[6]),
+			Name: stringValue(v[7]),
+			Type: stringValue(v[8]),
+		})
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errArticleNotFound
+	}
+
+	return rankRelatedArticles(byID, limit), nil
+}
+
+// published returns the publish date, or the zero time when it's unknown
+func (a RelatedArticle) published() time.Time {
+	if a.PublishDate == nil {
+		return time.Time{}
+	}
+	return *a.PublishDate
+}
+
+// rankRelatedArticles orders articles by shared entity count, then most recent
+// first (undated last), then title, keeping the first limit
+func rankRelatedArticles(byID map[string]*RelatedArticle, limit int) []RelatedArticle {
+	ranked := make([]RelatedArticle, 0, len(byID))
+	for _, article := range byID {
+		article.SharedCount = len(article.SharedEntities)
+		sort.Slice(article.SharedEntities, func(i, j int) bool {
+			return article.SharedEntities[i].Name < article.SharedEntities[j].Name
+		})
+		ranked = append(ranked, *article)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.SharedCount != b.SharedCount {
+			return a.SharedCount > b.SharedCount
+		}
+		if published, other := a.published(), b.published(); !published.Equal(other) {
+			return published.After(other)
+		}
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID < b.ID
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
@@ -0,0 +1,126 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relatedMention is an article's MENTIONS edge to an entity
+type relatedMention struct {
+	articleID  string
+	entity     SharedEntity
+	confidence float64
+}
+
+// relatedTx answers the related-articles query from its mentions, one row per
+// shared entity and article like the OPTIONAL MATCH
+type relatedTx struct {
+	neo4j.Transaction
+	articles map[string]RelatedArticle
+	mentions []relatedMention
+}
+
+func (tx *relatedTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	id := params["id"].(string)
+	minConfidence := params["minConfidence"].(float64)
+	if _, ok := tx.articles[id]; !ok {
+		return &fakeResult{index: -1}, nil
+	}
+
+	var records []*neo4j.Record
+	for _, source := range tx.mentions {
+		if source.articleID != id || source.confidence < minConfidence {
+			continue
+		}
+		for _, other := range tx.mentions {
+			if other.articleID == id || other.entity.ID != source.entity.ID || other.confidence < minConfidence {
+				continue
+			}
+			article := tx.articles[other.articleID]
+			records = append(records, &neo4j.Record{Values: []interface{}{
+				id, article.ID, article.Title, article.URL, article.Source, *article.PublishDate,
+				other.entity.ID, other.entity.Name, other.entity.Type,
+			}})
+		}
+	}
+	if len(records) == 0 {
+		records = append(records, &neo4j.Record{Values: []interface{}{id, nil, nil, nil, nil, nil, nil, nil, nil}})
+	}
+	return &fakeResult{records: records, index: -1}, nil
+}
+
+func newRelatedTx() *relatedTx {
+	published := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	article := func(id, title string) RelatedArticle {
+		return RelatedArticle{ID: id, Title: title, URL: "https://news.example.com/" + id, Source: "Example News", PublishDate: &published}
+	}
+	mayor := SharedEntity{ID: "e1", Name: "Mayor Smith", Type: "person"}
+	acme := SharedEntity{ID: "e2", Name: "Acme Corp", Type: "organization"}
+	contract := SharedEntity{ID: "e3", Name: "Bridge contract", Type: "event"}
+	council := SharedEntity{ID: "e4", Name: "City Council", Type: "organization"}
+
+	return &relatedTx{
+		articles: map[string]RelatedArticle{
+			"a1": article("a1", "Mayor awards bridge contract"),
+			"a2": article("a2", "Acme donated to mayor's campaign"),
+			"a3": article("a3", "Bridge contract under review"),
+			"a4": article("a4", "Weather report"),
+		},
+		mentions: []relatedMention{
+			{articleID: "a1", entity: mayor, confidence: 0.9},
+			{articleID: "a1", entity: acme, confidence: 0.9},
+			{articleID: "a1", entity: contract, confidence: 0.8},
+			{articleID: "a1", entity: council, confidence: 0.4},
+			// Shares the mayor and Acme
+			{articleID: "a2", entity: mayor, confidence: 0.95},
+			{articleID: "a2", entity: acme, confidence: 0.85},
+			// Shares the contract; the council mention is too uncertain on a1's side
+			{articleID: "a3", entity: contract, confidence: 0.9},
+			{articleID: "a3", entity: council, confidence: 0.9},
+			// Shares nothing
+			{articleID: "a4", entity: SharedEntity{ID: "e9", Name: "Storm", Type: "event"}, confidence: 0.9},
+		},
+	}
+}
+
+func TestRelatedArticles_RankedBySharedEntities(t *testing.T) {
+	related, err := relatedArticles(newRelatedTx(), "a1", defaultRelatedMinConfidence, 10)
+
+	require.NoError(t, err)
+	require.Len(t, related, 2)
+	assert.Equal(t, "a2", related[0].ID)
+	assert.Equal(t, 2, related[0].SharedCount)
+	assert.Equal(t, []SharedEntity{
+		{ID: "e2", Name: "Acme Corp", Type: "organization"},
+		{ID: "e1", Name: "Mayor Smith", Type: "person"},
+	}, related[0].SharedEntities)
+	assert.Equal(t, "Acme donated to mayor's campaign", related[0].Title)
+	assert.Equal(t, "a3", related[1].ID)
+	assert.Equal(t, 1, related[1].SharedCount)
+	for _, article := range related {
+		assert.NotEqual(t, "a1", article.ID, "the source article is excluded")
+		assert.NotEqual(t, "a4", article.ID, "articles sharing nothing are excluded")
+	}
+}
+
+func TestRelatedArticles_LowerThresholdAndLimit(t *testing.T) {
+	related, err := relatedArticles(newRelatedTx(), "a1", 0.3, 1)
+
+	require.NoError(t, err)
+	require.Len(t, related, 1)
+	// a3 now also shares the council, tying with a2; the titles break the tie
+	assert.Equal(t, "a2", related[0].ID)
+}
+
+func TestRelatedArticles_NoneAndMissing(t *testing.T) {
+	related, err := relatedArticles(newRelatedTx(), "a4", defaultRelatedMinConfidence, 10)
+	require.NoError(t, err)
+	assert.Empty(t, related)
+
+	_, err = relatedArticles(newRelatedTx(), "missing", defaultRelatedMinConfidence, 10)
+	assert.ErrorIs(t, err, errArticleNotFound)
+}
@@ -3,6 +3,7 @@ package graph
 import (
 	"clank/internal/db"
 	"clank/internal/models"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -19,6 +20,13 @@ func CreateRelationship(c *gin.Context) {
 		return
 	}
 
+	// The type is interpolated into the query, so only allowlisted types get this far
+	relType, err := models.NormalizeRelationshipType(rel.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Add timestamp to properties
 	if rel.Props == nil {
 		rel.Props = make(map[string]any)
@@ -38,7 +46,7 @@ func CreateRelationship(c *gin.Context) {
 			"props":  rel.Props,
 		}
 
-		result, err := tx.Run(fmt.Sprintf(query, rel.Type), params)
+		result, err := tx.Run(fmt.Sprintf(query, relType), params)
 		if err != nil {
 			return nil, err
 		}
@@ -87,7 +95,7 @@ func GetRelationship(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("relationship not found")
+			return nil, errRelationshipNotFound
 		}
 
 		record := result.Record()
@@ -103,8 +111,8 @@ func GetRelationship(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "relationship not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -147,7 +155,7 @@ func UpdateRelationship(c *gin.Context) {
 		}
 
 		if !result.Next() {
-			return nil, fmt.Errorf("relationship not found")
+			return nil, errRelationshipNotFound
 		}
 
 		record := result.Record()
@@ -163,8 +171,8 @@ func UpdateRelationship(c *gin.Context) {
 	})
 
 	if err != nil {
-		if err.Error() == "relationship not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -199,15 +207,15 @@ func DeleteRelationship(c *gin.Context) {
 		}
 
 		if summary.Counters().RelationshipsDeleted() == 0 {
-			return nil, fmt.Errorf("relationship not found")
+			return nil, errRelationshipNotFound
 		}
 
 		return nil, nil
 	})
 
 	if err != nil {
-		if err.Error() == "relationship not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if errors.Is(err, db.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errorMessage(err)})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -0,0 +1,248 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"clank/internal/logging"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	// snippetRadius is how many bytes of context a snippet keeps around the match
+	snippetRadius = 80
+)
+
+// snippetFields are the properties a snippet is cut from, in order of preference
+var snippetFields = []string{"content", "title", "name"}
+
+// luceneSpecial matches the characters with a meaning in Lucene query syntax
+var luceneSpecial = regexp.MustCompile(`[+\-&|!(){}\[\]^"~*?:\\/]`)
+
+// SearchResult is one ranked search hit
+type SearchResult struct {
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	Score      float64                `json:"score"`
+	Snippet    string                 `json:"snippet"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// SearchNodes searches article titles and content and entity names through the
+// full-text index, returning hits ranked by relevance with a snippet around the
+// match, e.g. /api/search?q=harbour+contract&type=Article&limit=10. When the index
+// is unavailable it falls back to an unranked regex match over every property.
+func SearchNodes(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q parameter is required"})
+		return
+	}
+	nodeType := c.Query("type")
+	if nodeType != "" && !snapshotIdentifier.MatchString(nodeType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid type parameter"})
+		return
+	}
+	limit, err := parseSearchLimit(c.Query("limit"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode := "fulltext"
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return fullTextSearch(tx, query, nodeType, limit)
+	})
+	if db.IsFullTextUnavailable(err) {
+		// A failed statement ends its transaction, so the fallback runs in a new one
+		logging.For(c.Request.Context(), "graph").Info("Full-text index unavailable, using regex search", "error", err)
+		mode = "regex"
+		result, err = db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+			return regexSearch(tx, query, nodeType, limit)
+		})
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":   query,
+		"mode":    mode,
+		"results": result,
+	})
+}
+
+// parseSearchLimit validates the limit parameter, defaulting to defaultSearchLimit
+func parseSearchLimit(value string) (int, error) {
+	if value == "" {
+		return defaultSearchLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("invalid limit parameter")
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	return limit, nil
+}
+
+// fullTextSearch queries the full-text index, best matches first
+func fullTextSearch(tx neo4j.Transaction, query, nodeType string, limit int) ([]SearchResult, error) {
+	terms := searchTerms(query)
+	result, err := tx.Run(`
+		CALL db.index.fulltext.queryNodes($index, $query) YIELD node, score
+		WHERE $type = '' OR $type IN labels(node)
+		RETURN node, score
+		ORDER BY score DESC
+		LIMIT $limit
+	`, map[string]interface{}{
+		"index": db.FullTextIndex,
+		"query": luceneQuery(terms),
+		"type":  nodeType,
+		"limit": limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := []SearchResult{}
+	for result.Next() {
+		record := result.Record()
+		node, ok := record.Values[0].(neo4j.Node)
+		if !ok {
+			continue
+		}
+		score, _ := record.Values[1].(float64)
+		results = append(results, newSearchResult(node, score, terms))
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	// Equal scores come back in arbitrary order; break ties by ID so pages are stable
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// regexSearch matches the query case-insensitively against every property. It
+// can't rank, so every hit scores 0.
+func regexSearch(tx neo4j.Transaction, query, nodeType string, limit int) ([]SearchResult, error) {
+	match := "MATCH (n)"
+	if nodeType != "" {
+		match = fmt.Sprintf("MATCH (n:`%s`)", nodeType)
+	}
+	result, err := tx.Run(match+`
+		WHERE any(prop in keys(n) WHERE n[prop] =~ $query)
+		RETURN n
+		LIMIT $limit
+	`, map[string]interface{}{
+		"query": "(?i).*" + regexp.QuoteMeta(query) + ".*",
+		"limit": limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	terms := []string{query}
+	results := []SearchResult{}
+	for result.Next() {
+		if node, ok := result.Record().Values[0].(neo4j.Node); ok {
+			results = append(results, newSearchResult(node, 0, terms))
+		}
+	}
+	return results, result.Err()
+}
+
+func newSearchResult(node neo4j.Node, score float64, terms []string) SearchResult {
+	return SearchResult{
+		ID:         fmt.Sprint(node.Id),
+		Type:       nodeType(node),
+		Score:      score,
+		Snippet:    searchSnippet(node.Props, terms),
+		Properties: node.Props,
+	}
+}
+
+// searchTerms splits a query into lowercase words, so words like OR and NOT are
+// searched for rather than read as operators
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// luceneQuery escapes each term so user input can't break the query syntax; Lucene
+// ranks documents matching more of the terms higher
+func luceneQuery(terms []string) string {
+	escaped := make([]string, len(terms))
+	for i, term := range terms {
+		escaped[i] = luceneSpecial.ReplaceAllString(term, `\$0`)
+	}
+	return strings.Join(escaped, " ")
+}
+
+// searchSnippet returns the text around the first term found in a snippet field,
+// or the start of the first non-empty one when no term matches
+func searchSnippet(props map[string]interface{}, terms []string) string {
+	fallback := ""
+	for _, field := range snippetFields {
+		text, _ := props[field].(string)
+		if text == "" {
+			continue
+		}
+		if fallback == "" {
+			fallback = cutSnippet(text, 0, 0)
+		}
+		for _, term := range terms {
+			loc := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(term)).FindStringIndex(text)
+			if loc != nil {
+				return cutSnippet(text, loc[0], loc[1])
+			}
+		}
+	}
+	return fallback
+}
+
+// cutSnippet cuts text to snippetRadius bytes either side of [start, end), on rune
+// boundaries, marking cut ends with an ellipsis
+func cutSnippet(text string, start, end int) string {
+	from := start - snippetRadius
+	if from <= 0 {
+		from = 0
+	} else {
+		for from < start && !utf8.RuneStart(text[from]) {
+			from++
+		}
+	}
+	to := end + snippetRadius
+	if to >= len(text) {
+		to = len(text)
+	} else {
+		for to > end && !utf8.RuneStart(text[to]) {
+			to--
+		}
+	}
+
+	snippet := strings.TrimSpace(text[from:to])
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fullTextTx scores its nodes against the Lucene query by counting term
+// occurrences, returning them unordered as an index with tied scores might
+type fullTextTx struct {
+	neo4j.Transaction
+	nodes  []neo4j.Node
+	params map[string]interface{}
+}
+
+func (tx *fullTextTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.params = params
+	terms := strings.Fields(params["query"].(string))
+
+	var records []*neo4j.Record
+	for _, node := range tx.nodes {
+		text := ""
+		for _, field := range snippetFields {
+			value, _ := node.Props[field].(string)
+			text += " " + strings.ToLower(value)
+		}
+		score := 0.0
+		for _, term := range terms {
+			score += float64(strings.Count(text, term))
+		}
+		if score > 0 {
+			records = append(records, &neo4j.Record{Values: []interface{}{node, score}})
+		}
+	}
+	return &fakeResult{records: records, index: -1}, nil
+}
+
+func TestFullTextSearch_RanksMultiWordQuery(t *testing.T) {
+	tx := &fullTextTx{nodes: []neo4j.Node{
+		{Id: 1, Labels: []string{"Article"}, Props: map[string]interface{}{
+			"title": "Council budget", "content": "The council approved the harbour budget.",
+		}},
+		{Id: 2, Labels: []string{"Article"}, Props: map[string]interface{}{
+			"title": "Harbour contract bribery", "content": "A harbour contract was awarded after a bribe; the contract was later voided.",
+		}},
+		{Id: 3, Labels: []string{"Entity"}, Props: map[string]interface{}{"name": "Harbour Authority"}},
+		{Id: 4, Labels: []string{"Article"}, Props: map[string]interface{}{"title": "Weather", "content": "Rain expected."}},
+	}}
+
+	results, err := fullTextSearch(tx, "Harbour Contract", "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "harbour contract", tx.params["query"])
+
+	require.Len(t, results, 3)
+	assert.Equal(t, "2", results[0].ID, "the article matching both terms most often ranks first")
+	assert.Equal(t, []string{"1", "3"}, []string{results[1].ID, results[2].ID}, "ties are ordered by ID")
+	for i := 1; i < len(results); i++ {
+		assert.GreaterOrEqual(t, results[i-1].Score, results[i].Score)
+	}
+	assert.Equal(t, "Article", results[0].Type)
+	assert.Contains(t, results[0].Snippet, "harbour contract")
+}
+
+func TestLuceneQuery_EscapesSyntax(t *testing.T) {
+	assert.Equal(t, `smith \(jr\) or acme\:corp \-\-`, luceneQuery(searchTerms("Smith (Jr) OR acme:corp --")))
+}
+
+func TestSearchSnippet(t *testing.T) {
+	content := strings.Repeat("filler ", 40) + "the Mayor signed the permit " + strings.Repeat("more ", 40)
+	snippet := searchSnippet(map[string]interface{}{"content": content, "title": "Permits"}, []string{"mayor"})
+	assert.True(t, strings.HasPrefix(snippet, "…"))
+	assert.True(t, strings.HasSuffix(snippet, "…"))
+	assert.Contains(t, snippet, "Mayor signed")
+
+	// Without a match the first non-empty field is used
+	assert.Equal(t, "Acme Corp", searchSnippet(map[string]interface{}{"name": "Acme Corp"}, []string{"zzz"}))
+	assert.Equal(t, "", searchSnippet(map[string]interface{}{}, []string{"zzz"}))
+}
+
+func TestParseSearchLimit(t *testing.T) {
+	limit, err := parseSearchLimit("")
+	require.NoError(t, err)
+	assert.Equal(t, defaultSearchLimit, limit)
+
+	limit, err = parseSearchLimit("1000")
+	require.NoError(t, err)
+	assert.Equal(t, maxSearchLimit, limit)
+
+	_, err = parseSearchLimit("0")
+	assert.Error(t, err)
+}
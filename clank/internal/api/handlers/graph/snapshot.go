@@ -0,0 +1,398 @@
+package graph
+
+import (
+	"bufio"
+	"clank/internal/db"
+	"clank/internal/logging"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	snapshotKindNode         = "node"
+	snapshotKindRelationship = "relationship"
+	// snapshotKindError ends a snapshot whose export failed part way
+	snapshotKindError = "error"
+
+	// snapshotMaxLineBytes bounds a single JSON line accepted on import
+	snapshotMaxLineBytes = 4 * 1024 * 1024
+)
+
+// snapshotIdentifier matches labels and relationship types that are safe to interpolate into Cypher
+var snapshotIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SnapshotRecord is one line of a graph snapshot: either a node or a relationship.
+// IDs are the Neo4j internal IDs at export time and are only meaningful within the snapshot.
+type SnapshotRecord struct {
+	Kind       string         `json:"kind"`
+	ID         string         `json:"id"`
+	Labels     []string       `json:"labels,omitempty"`
+	Type       string         `json:"type,omitempty"`
+	FromID     string         `json:"fromId,omitempty"`
+	ToID       string         `json:"toId,omitempty"`
+	Props      map[string]any `json:"properties"`
+	Timestamps []string       `json:"timestamps,omitempty"` // Property keys holding RFC3339 timestamps
+}
+
+// snapshotError is the last line of a snapshot whose export failed after records
+// were sent, so the truncated snapshot is recognized as such and can't be imported
+type snapshotError struct {
+	Kind  string `json:"kind"`
+	Error string `json:"error"`
+}
+
+// GetGraphSnapshotHandler streams the graph as JSON lines, nodes first and then
+// relationships. The optional labels query parameter (comma separated) restricts
+// the snapshot to nodes with any of those labels and the relationships between them.
+// The read isn't retried, as records already sent can't be taken back; an export
+// failing part way ends with an error line.
+func GetGraphSnapshotHandler(c *gin.Context) {
+	var labels []string
+	for _, label := range strings.Split(c.Query("labels"), ",") {
+		if label = strings.TrimSpace(label); label == "" {
+			continue
+		}
+		if !snapshotIdentifier.MatchString(label) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid label: %s", label)})
+			return
+		}
+		labels = append(labels, label)
+	}
+
+	// Headers are only sent once the first record is written, so query errors
+	// that happen before then can still be reported as JSON
+	encoder := json.NewEncoder(c.Writer)
+	written := 0
+	write := func(record SnapshotRecord) error {
+		if written == 0 {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=graph-snapshot-%s.jsonl", time.Now().UTC().Format("20060102T150405Z")))
+			c.Status(http.StatusOK)
+		}
+		written++
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+		if written%500 == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	}
+
+	err := db.ExecuteReadOnce(func(tx neo4j.Transaction) error {
+		return streamSnapshot(tx, labels, write)
+	})
+
+	if err != nil {
+		if written == 0 {
+			handleDBError(c, err)
+			return
+		}
+		logging.For(c.Request.Context(), "graph").Error("Graph snapshot aborted", "records", written, "error", err)
+		encoder.Encode(snapshotError{Kind: snapshotKindError, Error: "snapshot incomplete: export failed"})
+		c.Writer.Flush()
+		return
+	}
+
+	if written == 0 {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+	c.Writer.Flush()
+}
+
+// streamSnapshot reads the matching nodes and relationships and passes each to write
+func streamSnapshot(tx neo4j.Transaction, labels []string, write func(SnapshotRecord) error) error {
+	params := map[string]interface{}{"labels": labels}
+	filter := ""
+	if len(labels) > 0 {
+		filter = "WHERE any(label IN labels(n) WHERE label IN $labels)"
+	}
+
+	result, err := tx.Run(fmt.Sprintf("MATCH (n) %s RETURN n ORDER BY ID(n)", filter), params)
+	if err != nil {
+		return err
+	}
+	for result.Next() {
+		if err := write(nodeSnapshotRecord(result.Record().Values[0].(neo4j.Node))); err != nil {
+			return err
+		}
+	}
+	if err := result.Err(); err != nil {
+		return err
+	}
+
+	if len(labels) > 0 {
+		filter = `WHERE any(label IN labels(a) WHERE label IN $labels)
+			AND any(label IN labels(b) WHERE label IN $labels)`
+	}
+	result, err = tx.Run(fmt.Sprintf("MATCH (a)-[r]->(b) %s RETURN r ORDER BY ID(r)", filter), params)
+	if err != nil {
+		return err
+	}
+	for result.Next() {
+		if err := write(relationshipSnapshotRecord(result.Record().Values[0].(neo4j.Relationship))); err != nil {
+			return err
+		}
+	}
+	return result.Err()
+}
+
+// ImportGraphSnapshotHandler imports the nodes and relationships of a JSON lines
+// snapshot in a single transaction. Nodes with an id property, like entities and
+// articles, are merged into the node already holding that id, as are relationships
+// with one; relationships without are merged by type between their nodes. Other
+// nodes are created. The response maps snapshot node IDs to the graph's.
+func ImportGraphSnapshotHandler(c *gin.Context) {
+	records, err := readSnapshot(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := db.ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return importSnapshot(tx, records)
+	})
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	idMap := result.(map[string]int64)
+	c.JSON(http.StatusCreated, gin.H{
+		"nodes":         len(idMap),
+		"relationships": len(records) - len(idMap),
+		"idMap":         idMap,
+	})
+}
+
+// importSnapshot merges or creates the snapshot records in tx and returns the
+// mapping from snapshot node IDs to the IDs of the nodes they were imported as,
+// so importing a snapshot again doesn't duplicate what it already imported
+func importSnapshot(tx neo4j.Transaction, records []SnapshotRecord) (map[string]int64, error) {
+	idMap := make(map[string]int64)
+	for _, record := range records {
+		if record.Kind != snapshotKindNode {
+			continue
+		}
+
+		props := restoreSnapshotProps(record)
+		query := "CREATE (n%s) SET n = $props RETURN ID(n)"
+		if _, ok := props["id"]; ok {
+			query = "MERGE (n%s {id: $props.id}) SET n = $props RETURN ID(n)"
+		}
+		labels := ""
+		for _, label := range record.Labels {
+			labels += ":`" + label + "`"
+		}
+
+		result, err := tx.Run(fmt.Sprintf(query, labels), map[string]interface{}{"props": props})
+		if err != nil {
+			return nil, err
+		}
+		created, err := result.Single()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create node %s: %w", record.ID, err)
+		}
+		id, ok := created.Values[0].(int64)
+		if !ok {
+			return nil, fmt.Errorf("unexpected id %v for node %s", created.Values[0], record.ID)
+		}
+		idMap[record.ID] = id
+	}
+
+	for _, record := range records {
+		if record.Kind != snapshotKindRelationship {
+			continue
+		}
+
+		props := restoreSnapshotProps(record)
+		identity := ""
+		if _, ok := props["id"]; ok {
+			identity = " {id: $props.id}"
+		}
+		query := `
+			MATCH (a), (b)
+			WHERE ID(a) = $fromId AND ID(b) = $toId
+			MERGE (a)-[r:` + "`" + record.Type + "`" + identity + `]->(b)
+			SET r = $props
+			RETURN ID(r)
+		`
+		params := map[string]interface{}{
+			"fromId": idMap[record.FromID],
+			"toId":   idMap[record.ToID],
+			"props":  props,
+		}
+
+		result, err := tx.Run(query, params)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := result.Single(); err != nil {
+			return nil, fmt.Errorf("failed to create relationship %s: %w", record.ID, err)
+		}
+	}
+
+	return idMap, nil
+}
+
+// readSnapshot parses and validates a JSON lines snapshot. Every relationship
+// must reference nodes present in the snapshot.
+func readSnapshot(r io.Reader) ([]SnapshotRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), snapshotMaxLineBytes)
+
+	var records []SnapshotRecord
+	nodes := make(map[string]bool)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var record SnapshotRecord
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("line %d: invalid JSON: %w", line, err)
+		}
+		if record.Kind == snapshotKindError {
+			return nil, fmt.Errorf("line %d: the snapshot is incomplete, its export failed", line)
+		}
+		if record.ID == "" {
+			return nil, fmt.Errorf("line %d: missing id", line)
+		}
+
+		switch record.Kind {
+		case snapshotKindNode:
+			if nodes[record.ID] {
+				return nil, fmt.Errorf("line %d: duplicate node id %s", line, record.ID)
+			}
+			for _, label := range record.Labels {
+				if !snapshotIdentifier.MatchString(label) {
+					return nil, fmt.Errorf("line %d: invalid label %q", line, label)
+				}
+			}
+			nodes[record.ID] = true
+		case snapshotKindRelationship:
+			if !snapshotIdentifier.MatchString(record.Type) {
+				return nil, fmt.Errorf("line %d: invalid relationship type %q", line, record.Type)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown record kind %q", line, record.Kind)
+		}
+
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	for _, record := range records {
+		if record.Kind != snapshotKindRelationship {
+			continue
+		}
+		if !nodes[record.FromID] || !nodes[record.ToID] {
+			return nil, fmt.Errorf("relationship %s references a node missing from the snapshot", record.ID)
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("snapshot is empty")
+	}
+	return records, nil
+}
+
+func nodeSnapshotRecord(node neo4j.Node) SnapshotRecord {
+	props, timestamps := snapshotProps(node.Props)
+	return SnapshotRecord{
+		Kind:       snapshotKindNode,
+		ID:         fmt.Sprint(node.Id),
+		Labels:     node.Labels,
+		Props:      props,
+		Timestamps: timestamps,
+	}
+}
+
+func relationshipSnapshotRecord(rel neo4j.Relationship) SnapshotRecord {
+	props, timestamps := snapshotProps(rel.Props)
+	return SnapshotRecord{
+		Kind:       snapshotKindRelationship,
+		ID:         fmt.Sprint(rel.Id),
+		Type:       rel.Type,
+		FromID:     fmt.Sprint(rel.StartId),
+		ToID:       fmt.Sprint(rel.EndId),
+		Props:      props,
+		Timestamps: timestamps,
+	}
+}
+
+// snapshotProps copies props, converting time values to RFC3339 strings and
+// returning the converted keys so import can restore them
+func snapshotProps(props map[string]any) (map[string]any, []string) {
+	out := make(map[string]any, len(props))
+	var timestamps []string
+	for key, value := range props {
+		if t, ok := value.(time.Time); ok {
+			out[key] = t.Format(time.RFC3339Nano)
+			timestamps = append(timestamps, key)
+			continue
+		}
+		out[key] = value
+	}
+	sort.Strings(timestamps)
+	return out, timestamps
+}
+
+// restoreSnapshotProps reverses snapshotProps and restores integer properties that
+// JSON would otherwise turn into floats. Values that fail to parse as timestamps
+// are kept as strings.
+func restoreSnapshotProps(record SnapshotRecord) map[string]any {
+	props := make(map[string]any, len(record.Props))
+	for key, value := range record.Props {
+		props[key] = restoreSnapshotValue(value)
+	}
+	for _, key := range record.Timestamps {
+		if s, ok := props[key].(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				props[key] = t
+			}
+		}
+	}
+	return props
+}
+
+func restoreSnapshotValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = restoreSnapshotValue(item)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			out[key] = restoreSnapshotValue(item)
+		}
+		return out
+	}
+	return value
+}
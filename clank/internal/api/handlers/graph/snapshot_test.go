@@ -0,0 +1,197 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fakeIdentifier = regexp.MustCompile("`([^`]+)`")
+
+// fakeGraphTx is an in-memory graph that answers the queries issued by
+// streamSnapshot and importSnapshot
+type fakeGraphTx struct {
+	neo4j.Transaction
+	nodes []neo4j.Node
+	rels  []neo4j.Relationship
+}
+
+func (tx *fakeGraphTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	cypher = strings.TrimSpace(cypher)
+	var records []*neo4j.Record
+
+	switch {
+	case strings.HasPrefix(cypher, "MATCH (n)"):
+		for _, node := range tx.nodes {
+			records = append(records, &neo4j.Record{Values: []interface{}{node}})
+		}
+
+	case strings.HasPrefix(cypher, "MATCH (a)-[r]->(b)"):
+		for _, rel := range tx.rels {
+			records = append(records, &neo4j.Record{Values: []interface{}{rel}})
+		}
+
+	case strings.HasPrefix(cypher, "CREATE (n"), strings.HasPrefix(cypher, "MERGE (n"):
+		props := params["props"].(map[string]any)
+		if strings.HasPrefix(cypher, "MERGE") {
+			if i := slices.IndexFunc(tx.nodes, func(node neo4j.Node) bool { return node.Props["id"] == props["id"] }); i >= 0 {
+				tx.nodes[i].Props = props
+				records = append(records, &neo4j.Record{Values: []interface{}{tx.nodes[i].Id}})
+				break
+			}
+		}
+		node := neo4j.Node{Id: int64(100 + len(tx.nodes)), Props: props}
+		for _, match := range fakeIdentifier.FindAllStringSubmatch(cypher, -1) {
+			node.Labels = append(node.Labels, match[1])
+		}
+		tx.nodes = append(tx.nodes, node)
+		records = append(records, &neo4j.Record{Values: []interface{}{node.Id}})
+
+	case strings.HasPrefix(cypher, "MATCH (a), (b)"):
+		rel := neo4j.Relationship{
+			Id:      int64(500 + len(tx.rels)),
+			StartId: params["fromId"].(int64),
+			EndId:   params["toId"].(int64),
+			Type:    fakeIdentifier.FindStringSubmatch(cypher)[1],
+			Props:   params["props"].(map[string]any),
+		}
+		byID := strings.Contains(cypher, "{id: $props.id}")
+		if i := slices.IndexFunc(tx.rels, func(r neo4j.Relationship) bool {
+			return r.StartId == rel.StartId && r.EndId == rel.EndId && r.Type == rel.Type && (!byID || r.Props["id"] == rel.Props["id"])
+		}); i >= 0 {
+			tx.rels[i].Props = rel.Props
+			records = append(records, &neo4j.Record{Values: []interface{}{tx.rels[i].Id}})
+			break
+		}
+		tx.rels = append(tx.rels, rel)
+		records = append(records, &neo4j.Record{Values: []interface{}{rel.Id}})
+	}
+
+	return &fakeResult{records: records, index: -1}, nil
+}
+
+type fakeResult struct {
+	neo4j.Result
+	records []*neo4j.Record
+	index   int
+}
+
+func (r *fakeResult) Next() bool {
+	r.index++
+	return r.index < len(r.records)
+}
+
+func (r *fakeResult) Record() *neo4j.Record { return r.records[r.index] }
+
+func (r *fakeResult) Err() error { return nil }
+
+func (r *fakeResult) Consume() (neo4j.ResultSummary, error) { return nil, nil }
+
+func (r *fakeResult) Single() (*neo4j.Record, error) {
+	if len(r.records) != 1 {
+		return nil, assert.AnError
+	}
+	return r.records[0], nil
+}
+
+func exportSnapshot(t *testing.T, tx neo4j.Transaction, labels []string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	require.NoError(t, streamSnapshot(tx, labels, func(record SnapshotRecord) error {
+		return encoder.Encode(record)
+	}))
+	return &buf
+}
+
+func TestGraphSnapshot_RoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	source := &fakeGraphTx{
+		nodes: []neo4j.Node{
+			{Id: 1, Labels: []string{"Person"}, Props: map[string]any{"name": "Jane Doe", "created_at": createdAt}},
+			{Id: 2, Labels: []string{"Company", "Entity"}, Props: map[string]any{"name": "Acme Corp", "employees": int64(120), "tags": []any{"shell", "offshore"}}},
+		},
+		rels: []neo4j.Relationship{
+			{Id: 7, StartId: 1, EndId: 2, Type: "OWNS", Props: map[string]any{"share": 0.75}},
+		},
+	}
+
+	snapshot := exportSnapshot(t, source, nil)
+	assert.Equal(t, 3, strings.Count(snapshot.String(), "\n"))
+
+	records, err := readSnapshot(snapshot)
+	require.NoError(t, err)
+
+	target := &fakeGraphTx{}
+	idMap, err := importSnapshot(target, records)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"1": 100, "2": 101}, idMap)
+
+	require.Len(t, target.nodes, 2)
+	assert.Equal(t, []string{"Person"}, target.nodes[0].Labels)
+	assert.Equal(t, createdAt, target.nodes[0].Props["created_at"])
+	assert.Equal(t, []string{"Company", "Entity"}, target.nodes[1].Labels)
+	assert.Equal(t, int64(120), target.nodes[1].Props["employees"])
+	assert.Equal(t, []any{"shell", "offshore"}, target.nodes[1].Props["tags"])
+
+	require.Len(t, target.rels, 1)
+	assert.Equal(t, "OWNS", target.rels[0].Type)
+	assert.Equal(t, int64(100), target.rels[0].StartId)
+	assert.Equal(t, int64(101), target.rels[0].EndId)
+	assert.Equal(t, 0.75, target.rels[0].Props["share"])
+}
+
+func TestGraphSnapshot_ImportMergesOnID(t *testing.T) {
+	source := &fakeGraphTx{
+		nodes: []neo4j.Node{
+			{Id: 1, Labels: []string{"Entity"}, Props: map[string]any{"id": "person-1", "name": "Jane Doe"}},
+			{Id: 2, Labels: []string{"Entity"}, Props: map[string]any{"id": "org-1", "name": "Acme Corp"}},
+			{Id: 3, Labels: []string{"Mention"}, Props: map[string]any{"text": "Jane"}},
+		},
+		rels: []neo4j.Relationship{
+			{Id: 7, StartId: 1, EndId: 2, Type: "RELATES_TO", Props: map[string]any{"id": "rel-1"}},
+			{Id: 8, StartId: 3, EndId: 1, Type: "IN", Props: map[string]any{}},
+		},
+	}
+	records, err := readSnapshot(exportSnapshot(t, source, nil))
+	require.NoError(t, err)
+
+	target := &fakeGraphTx{}
+	first, err := importSnapshot(target, records)
+	require.NoError(t, err)
+	second, err := importSnapshot(target, records)
+	require.NoError(t, err)
+
+	assert.Equal(t, first["1"], second["1"], "nodes with an id are merged")
+	assert.Equal(t, first["2"], second["2"])
+	assert.NotEqual(t, first["3"], second["3"], "nodes without an id are created")
+	assert.Len(t, target.nodes, 4)
+	assert.Len(t, target.rels, 3, "the id-less relationship to the new mention is created, the others merged")
+}
+
+func TestReadSnapshot_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":         "\n",
+		"bad json":      `{"kind": "node"`,
+		"unknown kind":  `{"kind": "edge", "id": "1"}`,
+		"bad label":     `{"kind": "node", "id": "1", "labels": ["Person) DETACH DELETE (m"]}`,
+		"dangling edge": `{"kind": "node", "id": "1", "labels": ["Person"]}` + "\n" + `{"kind": "relationship", "id": "9", "type": "OWNS", "fromId": "1", "toId": "2"}`,
+		"duplicate":     `{"kind": "node", "id": "1"}` + "\n" + `{"kind": "node", "id": "1"}`,
+		"incomplete":    `{"kind": "node", "id": "1"}` + "\n" + `{"kind": "error", "error": "snapshot incomplete: export failed"}`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := readSnapshot(strings.NewReader(input))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package graph
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"clank/internal/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	// statsCacheTTL controls how long computed statistics are reused
+	statsCacheTTL = time.Minute
+	// topSourcesLimit is the number of sources listed in the statistics
+	topSourcesLimit = 10
+)
+
+// The statistics queries. Articles, mentions and entity revisions are
+// bookkeeping and not counted as entities; nodes without a type property or
+// labels are counted as "unknown".
+const (
+	statsArticlesQuery = `
+		MATCH (a:Article)
+		RETURN count(a)
+	`
+	statsEntitiesQuery = `
+		MATCH (n)
+		WHERE NOT (n:Article OR n:Mention OR n:EntityRevision)
+		RETURN coalesce(n.type, head(labels(n)), 'unknown') as type, count(n) as count
+	`
+	statsRelationshipsQuery = `
+		MATCH (a)-[r]->(b)
+		WHERE NOT (a:Article OR a:Mention OR a:EntityRevision)
+		AND NOT (b:Article OR b:Mention OR b:EntityRevision)
+		RETURN CASE type(r) WHEN 'RELATES_TO' THEN coalesce(r.type, type(r)) ELSE type(r) END as type, count(r) as count
+	`
+	statsEventsQuery = `
+		MATCH (n:Entity)
+		WHERE toLower(n.type) = 'event'
+		WITH left(toString(coalesce(n.date_rfc3339, n.date)), 7) as month
+		RETURN month, count(*) as count
+		ORDER BY month
+	`
+	statsSourcesQuery = `
+		MATCH (a:Article)
+		WHERE a.source IS NOT NULL AND a.source <> ''
+		RETURN a.source as source, count(a) as articles
+		ORDER BY articles DESC, source
+		LIMIT $limit
+	`
+)
+
+// GraphStats is an overview of the graph for dashboards
+type GraphStats struct {
+	Articles            int64            `json:"articles"`
+	EntitiesByType      map[string]int64 `json:"entitiesByType"`
+	RelationshipsByType map[string]int64 `json:"relationshipsByType"`
+	EventsByMonth       []MonthCount     `json:"eventsByMonth"` // Undated events are counted under "undated"
+	TopSources          []SourceCount    `json:"topSources"`
+	GeneratedAt         time.Time        `json:"generatedAt"`
+}
+
+// MonthCount is the number of events dated in a month (YYYY-MM)
+type MonthCount struct {
+	Month string `json:"month"`
+	Count int64  `json:"count"`
+}
+
+// SourceCount is the number of articles from a source
+type SourceCount struct {
+	Source   string `json:"source"`
+	Articles int64  `json:"articles"`
+}
+
+var (
+	statsCache   *GraphStats
+	statsCacheMu sync.Mutex
+)
+
+// GetStatsHandler returns article, entity, relationship, event and source counts.
+// The statistics are cached for a minute; ?refresh=true recomputes them.
+func GetStatsHandler(c *gin.Context) {
+	if c.Query("refresh") != "true" {
+		if stats, ok := getCachedStats(); ok {
+			c.JSON(http.StatusOK, gin.H{"stats": stats, "cached": true})
+			return
+		}
+	}
+
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return collectStats(tx)
+	})
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	stats := result.(*GraphStats)
+	setCachedStats(stats)
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "cached": false})
+}
+
+// collectStats runs the statistics queries
+func collectStats(tx neo4j.Transaction) (*GraphStats, error) {
+	stats := &GraphStats{
+		EntitiesByType:      make(map[string]int64),
+		RelationshipsByType: make(map[string]int64),
+		EventsByMonth:       []MonthCount{},
+		TopSources:          []SourceCount{},
+		GeneratedAt:         time.Now(),
+	}
+
+	result, err := tx.Run(statsArticlesQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	record, err := result.Single()
+	if err != nil {
+		return nil, err
+	}
+	stats.Articles = int64Value(record.Values[0])
+
+	if err := countByKey(tx, statsEntitiesQuery, stats.EntitiesByType); err != nil {
+		return nil, err
+	}
+	if err := countByKey(tx, statsRelationshipsQuery, stats.RelationshipsByType); err != nil {
+		return nil, err
+	}
+
+	result, err = tx.Run(statsEventsQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	var undated int64
+	for result.Next() {
+		v := result.Record().Values
+		month := stringValue(v[0])
+		if len(month) != len("2006-01") {
+			undated += int64Value(v[1])
+			continue
+		}
+		stats.EventsByMonth = append(stats.EventsByMonth, MonthCount{Month: month, Count: int64Value(v[1])})
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+	if undated > 0 {
+		stats.EventsByMonth = append(stats.EventsByMonth, MonthCount{Month: "undated", Count: undated})
+	}
+
+	result, err = tx.Run(statsSourcesQuery, map[string]interface{}{"limit": topSourcesLimit})
+	if err != nil {
+		return nil, err
+	}
+	for result.Next() {
+		v := result.Record().Values
+		stats.TopSources = append(stats.TopSources, SourceCount{Source: stringValue(v[0]), Articles: int64Value(v[1])})
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// countByKey adds the (key, count) rows of query to counts
+func countByKey(tx neo4j.Transaction, query string, counts map[string]int64) error {
+	result, err := tx.Run(query, nil)
+	if err != nil {
+		return err
+	}
+	for result.Next() {
+		v := result.Record().Values
+		counts[stringValue(v[0])] += int64Value(v[1])
+	}
+	return result.Err()
+}
+
+func int64Value(v interface{}) int64 {
+	n, _ := v.(int64)
+	return n
+}
+
+func getCachedStats() (*GraphStats, bool) {
+	statsCacheMu.Lock()
+	defer statsCacheMu.Unlock()
+
+	if statsCache == nil || time.Since(statsCache.GeneratedAt) > statsCacheTTL {
+		return nil, false
+	}
+	return statsCache, true
+}
+
+func setCachedStats(stats *GraphStats) {
+	statsCacheMu.Lock()
+	defer statsCacheMu.Unlock()
+	statsCache = stats
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsTx answers each statistics query with its canned rows and records the
+// queries run with their parameters
+type statsTx struct {
+	neo4j.Transaction
+	rows    map[string][][]interface{}
+	queries []string
+	params  []map[string]interface{}
+}
+
+func (tx *statsTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.queries = append(tx.queries, cypher)
+	tx.params = append(tx.params, params)
+
+	var records []*neo4j.Record
+	for _, values := range tx.rows[cypher] {
+		records = append(records, &neo4j.Record{Values: values})
+	}
+	return &fakeResult{records: records, index: -1}, nil
+}
+
+// seededStatsTx answers with the rows the statistics queries return for a small
+// graph: three articles from two sources, people, an organization and events
+func seededStatsTx() *statsTx {
+	return &statsTx{rows: map[string][][]interface{}{
+		statsArticlesQuery: {{int64(3)}},
+		statsEntitiesQuery: {
+			{"person", int64(2)},
+			{"organization", int64(1)},
+			{"event", int64(3)},
+			{"Company", int64(1)},
+			{"unknown", int64(1)},
+		},
+		statsRelationshipsQuery: {
+			{"EMPLOYED_BY", int64(2)},
+			{"involvement", int64(1)},
+			{"BEFORE", int64(1)},
+		},
+		// An event without a date has a null month
+		statsEventsQuery: {
+			{nil, int64(1)},
+			{"2024-03", int64(2)},
+		},
+		statsSourcesQuery: {
+			{"news.example.com", int64(2)},
+			{"courier.example.org", int64(1)},
+		},
+	}}
+}
+
+func TestCollectStats_SeededGraph(t *testing.T) {
+	tx := seededStatsTx()
+	stats, err := collectStats(tx)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(3), stats.Articles)
+	assert.Equal(t, map[string]int64{
+		"person":       2,
+		"organization": 1,
+		"event":        3,
+		"Company":      1,
+		"unknown":      1,
+	}, stats.EntitiesByType)
+	assert.Equal(t, map[string]int64{
+		"EMPLOYED_BY": 2,
+		"involvement": 1,
+		"BEFORE":      1,
+	}, stats.RelationshipsByType)
+	assert.Equal(t, []MonthCount{
+		{Month: "2024-03", Count: 2},
+		{Month: "undated", Count: 1},
+	}, stats.EventsByMonth)
+	assert.Equal(t, []SourceCount{
+		{Source: "news.example.com", Articles: 2},
+		{Source: "courier.example.org", Articles: 1},
+	}, stats.TopSources)
+
+	assert.Equal(t, []string{
+		statsArticlesQuery,
+		statsEntitiesQuery,
+		statsRelationshipsQuery,
+		statsEventsQuery,
+		statsSourcesQuery,
+	}, tx.queries)
+	assert.Equal(t, map[string]interface{}{"limit": topSourcesLimit}, tx.params[4])
+}
+
+func TestCollectStats_EmptyGraph(t *testing.T) {
+	stats, err := collectStats(&statsTx{rows: map[string][][]interface{}{
+		statsArticlesQuery: {{int64(0)}},
+	}})
+	require.NoError(t, err)
+
+	assert.Zero(t, stats.Articles)
+	assert.Empty(t, stats.EntitiesByType)
+	assert.NotNil(t, stats.EventsByMonth, "empty lists encode as [] for dashboards")
+	assert.NotNil(t, stats.TopSources)
+}
+
+func TestStatsCache(t *testing.T) {
+	t.Cleanup(func() { setCachedStats(nil) })
+
+	stats, err := collectStats(seededStatsTx())
+	require.NoError(t, err)
+	setCachedStats(stats)
+
+	cached, ok := getCachedStats()
+	require.True(t, ok)
+	assert.Same(t, stats, cached)
+
+	stats.GeneratedAt = stats.GeneratedAt.Add(-2 * statsCacheTTL)
+	_, ok = getCachedStats()
+	assert.False(t, ok, "expired statistics are recomputed")
+}
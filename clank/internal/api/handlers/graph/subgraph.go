@@ -0,0 +1,219 @@
+package graph
+
+import (
+	"clank/internal/db"
+	"clank/internal/models"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	// maxSubgraphDepth caps how many hops a subgraph request may expand
+	maxSubgraphDepth = 4
+	// maxSubgraphNodes caps the nodes returned by one subgraph request
+	maxSubgraphNodes = 500
+	// maxSubgraphPaths bounds the paths Neo4j returns before nodes are capped
+	maxSubgraphPaths = 5000
+)
+
+var errSubgraphSeedNotFound = db.NewError(db.ErrNotFound, "seed node not found")
+
+// subgraphParams are the validated query parameters of a subgraph request
+type subgraphParams struct {
+	id    string
+	depth int
+	types []string // Uppercased node labels or entity types; empty allows all
+}
+
+// GetEntitySubgraphHandler returns the neighborhood of one node, expanded up to depth
+// hops and optionally restricted to node types, e.g.
+// /api/graph/subgraph?id=42&depth=2&types=PERSON,ORGANIZATION
+func GetEntitySubgraphHandler(c *gin.Context) {
+	params, err := parseSubgraphParams(c.Query("id"), c.DefaultQuery("depth", "1"), c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := db.ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		queryParams := map[string]interface{}{
+			"id":    params.id,
+			"types": params.types,
+			"limit": maxSubgraphPaths,
+		}
+		result, err := tx.Run(subgraphQuery(params.depth, len(params.types) > 0), queryParams)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Next() {
+			return nil, errSubgraphSeedNotFound
+		}
+
+		record := result.Record()
+		seed := record.Values[0].(neo4j.Node)
+		var paths []neo4j.Path
+		for _, p := range record.Values[1].([]interface{}) {
+			if path, ok := p.(neo4j.Path); ok {
+				paths = append(paths, path)
+			}
+		}
+		nodes, truncated := buildSubgraph(seed, paths, maxSubgraphNodes)
+		return gin.H{
+			"seed":      fmt.Sprint(seed.Id),
+			"depth":     params.depth,
+			"nodes":     nodes,
+			"truncated": truncated,
+		}, nil
+	})
+	if errors.Is(err, errSubgraphSeedNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "node not found"})
+		return
+	}
+	if err != nil {
+		handleDBError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parseSubgraphParams validates the seed ID, depth (1..maxSubgraphDepth) and the
+// comma-separated type filter
+func parseSubgraphParams(id, depth, types string) (subgraphParams, error) {
+	params := subgraphParams{id: strings.TrimSpace(id)}
+	if params.id == "" {
+		return params, fmt.Errorf("id parameter is required")
+	}
+
+	d, err := strconv.Atoi(depth)
+	if err != nil || d < 1 {
+		return params, fmt.Errorf("invalid depth parameter")
+	}
+	if d > maxSubgraphDepth {
+		return params, fmt.Errorf("depth must be at most %d", maxSubgraphDepth)
+	}
+	params.depth = d
+
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			params.types = append(params.types, t)
+		}
+	}
+	return params, nil
+}
+
+// subgraphQuery matches the seed by internal ID or id property and collects the
+// variable-length paths around it. With filterTypes, every node past the seed must
+// carry one of $types as a label or entity type, so excluded nodes also stop the
+// expansion through them.
+func subgraphQuery(depth int, filterTypes bool) string {
+	filter := ""
+	if filterTypes {
+		filter = `
+			WHERE all(x IN nodes(path)[1..] WHERE
+				any(l IN labels(x) WHERE toUpper(l) IN $types) OR toUpper(coalesce(x.type, '')) IN $types)`
+	}
+	return fmt.Sprintf(`
+			MATCH (seed)
+			WHERE toString(ID(seed)) = $id OR seed.id = $id
+			WITH seed LIMIT 1
+			OPTIONAL MATCH path = (seed)-[*1..%d]-(m)%s
+			WITH seed, path LIMIT $limit
+			RETURN seed, collect(path) as paths
+		`, depth, filter)
+}
+
+// buildSubgraph turns the seed and its paths into nodes with their connections to
+// other nodes of the subgraph. Nodes are added nearest first; once maxNodes is
+// reached further nodes and the edges to them are dropped and truncated is true.
+func buildSubgraph(seed neo4j.Node, paths []neo4j.Path, maxNodes int) ([]models.NodeWithConnections, bool) {
+	nodes := map[int64]neo4j.Node{seed.Id: seed}
+	order := []int64{seed.Id}
+	truncated := false
+
+	// Shorter paths first so the cap keeps the closest neighbors
+	sort.SliceStable(paths, func(i, j int) bool {
+		return len(paths[i].Relationships) < len(paths[j].Relationships)
+	})
+
+	relationships := make(map[int64]neo4j.Relationship)
+	for _, path := range paths {
+		for _, node := range path.Nodes {
+			if _, ok := nodes[node.Id]; ok {
+				continue
+			}
+			if len(nodes) >= maxNodes {
+				truncated = true
+				continue
+			}
+			nodes[node.Id] = node
+			order = append(order, node.Id)
+		}
+		for _, rel := range path.Relationships {
+			relationships[rel.Id] = rel
+		}
+	}
+
+	relIDs := make([]int64, 0, len(relationships))
+	for id := range relationships {
+		relIDs = append(relIDs, id)
+	}
+	sort.Slice(relIDs, func(i, j int) bool { return relIDs[i] < relIDs[j] })
+
+	byID := make(map[int64]*models.NodeWithConnections, len(order))
+	result := make([]models.NodeWithConnections, len(order))
+	for i, id := range order {
+		result[i] = models.NodeWithConnections{
+			ID:          fmt.Sprint(id),
+			Type:        nodeType(nodes[id]),
+			Properties:  nodes[id].Props,
+			Connections: []models.Connection{},
+		}
+		byID[id] = &result[i]
+	}
+
+	for _, relID := range relIDs {
+		rel := relationships[relID]
+		start, startOK := byID[rel.StartId]
+		end, endOK := byID[rel.EndId]
+		if !startOK || !endOK {
+			continue
+		}
+		start.Connections = append(start.Connections, newConnection(nodes[rel.EndId], rel, "outgoing"))
+		end.Connections = append(end.Connections, newConnection(nodes[rel.StartId], rel, "incoming"))
+	}
+	for i := range result {
+		result[i].Metadata.ConnectionCount = len(result[i].Connections)
+	}
+
+	return result, truncated
+}
+
+// newConnection describes rel, seen from one node, as a connection to other
+func newConnection(other neo4j.Node, rel neo4j.Relationship, direction string) models.Connection {
+	connection := models.Connection{
+		ID:         fmt.Sprint(other.Id),
+		Type:       nodeType(other),
+		Properties: other.Props,
+	}
+	connection.Relationship.ID = fmt.Sprint(rel.Id)
+	connection.Relationship.Type = rel.Type
+	connection.Relationship.Properties = rel.Props
+	connection.Relationship.Direction = direction
+	return connection
+}
+
+// nodeType is a node's first label, or "" for an unlabeled node
+func nodeType(node neo4j.Node) string {
+	if len(node.Labels) == 0 {
+		return ""
+	}
+	return node.Labels[0]
+}
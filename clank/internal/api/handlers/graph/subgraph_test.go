@@ -0,0 +1,106 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subgraphFixture mirrors the paths Neo4j returns around Alice:
+//
+//	Alice -EMPLOYED_BY-> Acme Corp -PAID-> City Council
+//	Alice -ATTENDED-> Contract Signing (Event)
+var (
+	alice   = neo4j.Node{Id: 1, Labels: []string{"Person"}, Props: map[string]interface{}{"name": "Alice"}}
+	acme    = neo4j.Node{Id: 2, Labels: []string{"Organization"}, Props: map[string]interface{}{"name": "Acme Corp"}}
+	council = neo4j.Node{Id: 3, Labels: []string{"Organization"}, Props: map[string]interface{}{"name": "City Council"}}
+	signing = neo4j.Node{Id: 4, Labels: []string{"Event"}, Props: map[string]interface{}{"name": "Contract Signing"}}
+
+	employedBy = neo4j.Relationship{Id: 10, StartId: 1, EndId: 2, Type: "EMPLOYED_BY"}
+	paid       = neo4j.Relationship{Id: 11, StartId: 2, EndId: 3, Type: "PAID"}
+	attended   = neo4j.Relationship{Id: 12, StartId: 1, EndId: 4, Type: "ATTENDED"}
+
+	aliceToAcme    = neo4j.Path{Nodes: []neo4j.Node{alice, acme}, Relationships: []neo4j.Relationship{employedBy}}
+	aliceToSigning = neo4j.Path{Nodes: []neo4j.Node{alice, signing}, Relationships: []neo4j.Relationship{attended}}
+	aliceToCouncil = neo4j.Path{Nodes: []neo4j.Node{alice, acme, council}, Relationships: []neo4j.Relationship{employedBy, paid}}
+)
+
+func subgraphNames(t *testing.T, paths []neo4j.Path, maxNodes int) ([]string, bool) {
+	t.Helper()
+	nodes, truncated := buildSubgraph(alice, paths, maxNodes)
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Properties.(map[string]interface{})["name"].(string))
+	}
+	return names, truncated
+}
+
+func TestBuildSubgraph_DepthOne(t *testing.T) {
+	nodes, truncated := buildSubgraph(alice, []neo4j.Path{aliceToAcme, aliceToSigning}, maxSubgraphNodes)
+
+	assert.False(t, truncated)
+	require.Len(t, nodes, 3)
+	assert.Equal(t, "1", nodes[0].ID)
+	require.Len(t, nodes[0].Connections, 2)
+	assert.Equal(t, "EMPLOYED_BY", nodes[0].Connections[0].Relationship.Type)
+	assert.Equal(t, "outgoing", nodes[0].Connections[0].Relationship.Direction)
+	assert.Equal(t, 2, nodes[0].Metadata.ConnectionCount)
+
+	require.Len(t, nodes[1].Connections, 1)
+	assert.Equal(t, "1", nodes[1].Connections[0].ID)
+	assert.Equal(t, "incoming", nodes[1].Connections[0].Relationship.Direction)
+}
+
+func TestBuildSubgraph_DepthTwo(t *testing.T) {
+	names, truncated := subgraphNames(t, []neo4j.Path{aliceToCouncil, aliceToAcme, aliceToSigning}, maxSubgraphNodes)
+
+	assert.False(t, truncated)
+	assert.Equal(t, []string{"Alice", "Acme Corp", "Contract Signing", "City Council"}, names, "nearest nodes come first")
+
+	nodes, _ := buildSubgraph(alice, []neo4j.Path{aliceToCouncil, aliceToAcme}, maxSubgraphNodes)
+	require.Len(t, nodes[1].Connections, 2, "Acme connects to Alice and the council")
+}
+
+func TestBuildSubgraph_NodeCap(t *testing.T) {
+	names, truncated := subgraphNames(t, []neo4j.Path{aliceToCouncil, aliceToAcme, aliceToSigning}, 2)
+
+	assert.True(t, truncated)
+	assert.Equal(t, []string{"Alice", "Acme Corp"}, names)
+}
+
+func TestSubgraphQuery(t *testing.T) {
+	depthOne := subgraphQuery(1, false)
+	assert.Contains(t, depthOne, "-[*1..1]-")
+	assert.NotContains(t, depthOne, "$types")
+
+	filtered := subgraphQuery(2, true)
+	assert.Contains(t, filtered, "-[*1..2]-")
+	assert.Contains(t, filtered, "all(x IN nodes(path)[1..]")
+	assert.Contains(t, filtered, "IN $types")
+}
+
+func TestParseSubgraphParams(t *testing.T) {
+	params, err := parseSubgraphParams("42", "2", "person, Organization,,")
+	require.NoError(t, err)
+	assert.Equal(t, "42", params.id)
+	assert.Equal(t, 2, params.depth)
+	assert.Equal(t, []string{"PERSON", "ORGANIZATION"}, params.types, "events are excluded by omission")
+
+	params, err = parseSubgraphParams("42", "1", "")
+	require.NoError(t, err)
+	assert.Empty(t, params.types)
+
+	for _, tt := range []struct{ id, depth, wantErr string }{
+		{"", "1", "id parameter is required"},
+		{"42", "zero", "invalid depth"},
+		{"42", "0", "invalid depth"},
+		{"42", "9", "at most"},
+	} {
+		_, err := parseSubgraphParams(tt.id, tt.depth, "")
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), tt.wantErr), err.Error())
+	}
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clank/internal/db"
+	"clank/internal/logging"
+	"clank/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRetryInterval is how often queued integrations are retried when no interval is configured
+const defaultRetryInterval = 30 * time.Second
+
+// queuedIntegration is an article whose graph integration is pending, as stored on disk
+type queuedIntegration struct {
+	Article  *models.Article `json:"article"`
+	QueuedAt time.Time       `json:"queuedAt"`
+	Attempts int             `json:"attempts"`
+}
+
+// IntegrationFlushReport is the outcome of retrying the queued integrations
+type IntegrationFlushReport struct {
+	Integrated []string          `json:"integrated"`        // IDs of the articles saved to the graph
+	Dropped    map[string]string `json:"dropped,omitempty"` // Articles the graph rejected, with the error; they are not retried
+	Remaining  int               `json:"remaining"`         // Articles still queued because the database is unavailable
+	Error      string            `json:"error,omitempty"`   // Why the flush stopped early
+}
+
+// integrationQueue keeps the extraction results of articles that couldn't be
+// integrated because the database was unavailable, one JSON file per article
+// in dir so they survive a restart, and saves them once the database is back
+type integrationQueue struct {
+	mu      sync.Mutex
+	dir     string
+	store   Store
+	pending map[string]*queuedIntegration // article ID -> queued integration
+}
+
+// newIntegrationQueue returns a queue persisted in dir holding the integrations
+// queued there before, or nil (queueing disabled) when dir is empty. Files that
+// can't be read back are logged and left in dir, so one bad file doesn't
+// disable the queue.
+func newIntegrationQueue(dir string, store Store) (*integrationQueue, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create retry queue directory: %w", err)
+	}
+	q := &integrationQueue{dir: dir, store: store, pending: make(map[string]*queuedIntegration)}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retry queue: %w", err)
+	}
+	logger := logging.For(context.Background(), "graph")
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			logger.Error("Skipping unreadable queued integration", "file", file, "error", err)
+			continue
+		}
+		var item queuedIntegration
+		if err := json.Unmarshal(data, &item); err != nil || item.Article == nil || item.Article.ID == "" {
+			logger.Error("Skipping invalid queued integration", "file", file, "error", err)
+			continue
+		}
+		q.pending[item.Article.ID] = &item
+	}
+	return q, nil
+}
+
+// path returns the file an article's queued integration is kept in
+func (q *integrationQueue) path(articleID string) string {
+	return filepath.Join(q.dir, articleID+".json")
+}
+
+// enqueue persists an article for a later integration attempt
+func (q *integrationQueue) enqueue(article *models.Article) error {
+	if strings.ContainsAny(article.ID, `/\`) || article.ID == "" {
+		return fmt.Errorf("article ID %q can't be queued", article.ID)
+	}
+	item := &queuedIntegration{Article: article, QueuedAt: time.Now(), Attempts: 1}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.write(item); err != nil {
+		return err
+	}
+	q.pending[article.ID] = item
+	return nil
+}
+
+// write stores item atomically, so a crash never leaves half a file behind
+func (q *integrationQueue) write(item *queuedIntegration) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode queued integration: %w", err)
+	}
+	tmp := q.path(item.Article.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write queued integration: %w", err)
+	}
+	if err := os.Rename(tmp, q.path(item.Article.ID)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write queued integration: %w", err)
+	}
+	return nil
+}
+
+// depth returns the number of queued integrations. A nil queue is empty.
+func (q *integrationQueue) depth() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// flush saves the queued articles, oldest first. It stops at the first
+// unavailable error, keeping that article and the rest queued; articles the
+// graph rejects for any other reason are dropped.
+func (q *integrationQueue) flush(ctx context.Context) IntegrationFlushReport {
+	report := IntegrationFlushReport{Integrated: []string{}}
+	if q == nil {
+		return report
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	logger := logging.For(ctx, "graph")
+
+	items := make([]*queuedIntegration, 0, len(q.pending))
+	for _, item := range q.pending {
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].QueuedAt.Before(items[j].QueuedAt) })
+
+	for _, item := range items {
+		id := item.Article.ID
+		err := q.store.SaveArticle(item.Article)
+		if errors.Is(err, db.ErrUnavailable) {
+			item.Attempts++
+			if werr := q.write(item); werr != nil {
+				logger.Warn("Failed to update queued integration", "article_id", id, "error", werr)
+			}
+			report.Error = err.Error()
+			break
+		}
+		if err != nil {
+			logger.Error("Dropping queued integration", "article_id", id, "attempts", item.Attempts+1, "error", err)
+			if report.Dropped == nil {
+				report.Dropped = make(map[string]string)
+			}
+			report.Dropped[id] = err.Error()
+		} else {
+			logger.Info("Queued article integrated into the graph", "article_id", id, "attempts", item.Attempts+1)
+			report.Integrated = append(report.Integrated, id)
+		}
+		delete(q.pending, id)
+		if err := os.Remove(q.path(id)); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove queued integration", "article_id", id, "error", err)
+		}
+	}
+	report.Remaining = len(q.pending)
+	return report
+}
+
+// run flushes the queue every interval (0 uses 30s) while available reports
+// the database as up, until ctx ends
+func (q *integrationQueue) run(ctx context.Context, interval time.Duration, available func() bool) {
+	if interval <= 0 {
+		interval = defaultRetryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if q.depth() > 0 && available() {
+				q.flush(ctx)
+			}
+		}
+	}
+}
+
+// StartRetryQueue retries the queued integrations in the background whenever
+// the database is available, until ctx ends
+func (h *ExtractionGinHandler) StartRetryQueue(ctx context.Context) {
+	if h.retries != nil {
+		go h.retries.run(ctx, h.retryInterval, db.IsAvailable)
+	}
+}
+
+// HandleRetryQueue returns the number of articles waiting to be integrated into the graph
+func (h *ExtractionGinHandler) HandleRetryQueue(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": h.retries != nil, "depth": h.retries.depth()})
+}
+
+// HandleRetryQueueFlush retries the queued integrations now
+func (h *ExtractionGinHandler) HandleRetryQueueFlush(c *gin.Context) {
+	if h.retries == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "The integration retry queue is disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, h.retries.flush(c.Request.Context()))
+}
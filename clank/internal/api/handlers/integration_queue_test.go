@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clank/internal/db"
+	"clank/internal/models"
+	"clank/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDatabaseDown = db.NewError(db.ErrUnavailable, "database connection lost")
+
+func TestIntegrationQueue_OutageQueuesAndFlushIntegrates(t *testing.T) {
+	dir := t.TempDir()
+	store := db.NewMemoryStore()
+	store.SaveError = errDatabaseDown
+	queue, err := newIntegrationQueue(dir, store)
+	require.NoError(t, err)
+
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.extractor = &recordingExtractor{result: &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9}},
+	}}
+	h.retries = queue
+
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "fast"})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, false, resp["integrated"])
+	assert.Equal(t, true, resp["queued"])
+	articleID := resp["articleId"].(string)
+	assert.Empty(t, store.Articles)
+	assert.FileExists(t, filepath.Join(dir, articleID+".json"))
+
+	// The queue is reloaded from disk, e.g. after a restart
+	queue, err = newIntegrationQueue(dir, store)
+	require.NoError(t, err)
+	h.retries = queue
+	assert.Equal(t, 1, queue.depth())
+
+	// Still down: nothing is lost
+	report := performQueueRequest(t, h, http.MethodPost, "/api/extraction/retry-queue/flush")
+	assert.Empty(t, report["integrated"])
+	assert.Equal(t, float64(1), report["remaining"])
+
+	store.SaveError = nil
+	report = performQueueRequest(t, h, http.MethodPost, "/api/extraction/retry-queue/flush")
+	assert.Equal(t, []interface{}{articleID}, report["integrated"])
+	assert.Equal(t, float64(0), report["remaining"])
+
+	saved := store.Articles[articleID]
+	require.NotNil(t, saved)
+	require.Len(t, saved.Entities, 1)
+	assert.Equal(t, "Jane Doe", saved.Entities[0].Name)
+	assert.NoFileExists(t, filepath.Join(dir, articleID+".json"))
+
+	depth := performQueueRequest(t, h, http.MethodGet, "/api/extraction/retry-queue")
+	assert.Equal(t, float64(0), depth["depth"])
+}
+
+func TestIntegrationQueue_OtherErrorsAreNotQueued(t *testing.T) {
+	store := db.NewMemoryStore()
+	store.SaveError = errors.New("constraint violated")
+	queue, err := newIntegrationQueue(t.TempDir(), store)
+	require.NoError(t, err)
+
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.retries = queue
+
+	rr := performExtraction(t, h, gin.H{"url": "https://example.com/a", "mode": "fast"})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, 0, queue.depth())
+}
+
+func TestIntegrationQueue_FlushDropsRejectedArticles(t *testing.T) {
+	dir := t.TempDir()
+	store := db.NewMemoryStore()
+	queue, err := newIntegrationQueue(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, queue.enqueue(&models.Article{ID: "a1"}))
+
+	store.SaveError = errors.New("constraint violated")
+	report := queue.flush(t.Context())
+	assert.Equal(t, map[string]string{"a1": "constraint violated"}, report.Dropped)
+	assert.Equal(t, 0, report.Remaining)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestIntegrationQueue_WorkerWaitsForDatabase(t *testing.T) {
+	store := db.NewMemoryStore()
+	queue, err := newIntegrationQueue(t.TempDir(), store)
+	require.NoError(t, err)
+	require.NoError(t, queue.enqueue(&models.Article{ID: "a1"}))
+
+	var up atomic.Bool
+	go queue.run(t.Context(), time.Millisecond, up.Load)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, queue.depth(), "nothing is retried while the database is down")
+
+	up.Store(true)
+	assert.Eventually(t, func() bool { return queue.depth() == 0 }, time.Second, time.Millisecond)
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	assert.Contains(t, store.Articles, "a1")
+}
+
+func TestIntegrationQueue_SkipsCorruptFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := db.NewMemoryStore()
+	queue, err := newIntegrationQueue(dir, store)
+	require.NoError(t, err)
+	require.NoError(t, queue.enqueue(&models.Article{ID: "a1"}))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte(`{"article": `), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "empty.json"), []byte(`{}`), 0o644))
+
+	queue, err = newIntegrationQueue(dir, store)
+	require.NoError(t, err)
+	require.NotNil(t, queue)
+	assert.Equal(t, 1, queue.depth())
+	assert.FileExists(t, filepath.Join(dir, "broken.json"), "corrupt files are kept for inspection")
+}
+
+func TestIntegrationQueue_Disabled(t *testing.T) {
+	queue, err := newIntegrationQueue("", db.NewMemoryStore())
+	require.NoError(t, err)
+	assert.Nil(t, queue)
+
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), db.NewMemoryStore(), false)
+	depth := performQueueRequest(t, h, http.MethodGet, "/api/extraction/retry-queue")
+	assert.Equal(t, false, depth["enabled"])
+	assert.Equal(t, float64(0), depth["depth"])
+
+	rr := perform(t, http.MethodPost, "/flush", "/flush", h.HandleRetryQueueFlush, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func performQueueRequest(t *testing.T, h *ExtractionGinHandler, method, path string) map[string]interface{} {
+	t.Helper()
+	handler := h.HandleRetryQueue
+	if method == http.MethodPost {
+		handler = h.HandleRetryQueueFlush
+	}
+	rr := perform(t, method, path, path, handler, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return body
+}
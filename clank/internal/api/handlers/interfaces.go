@@ -1,21 +1,23 @@
 package handlers
 
 import (
+	"clank/internal/db"
 	"clank/internal/llm"
+	"clank/internal/llm/sequential"
 	"clank/internal/models"
 	"context"
-	"time"
 )
 
 // Scraper defines the interface for article scraping
 type Scraper interface {
 	Initialize() error
-	ScrapeArticle(url string) (*models.Article, error)
+	ScrapeArticle(ctx context.Context, url string) (*models.Article, error)
 }
 
-// Processor defines the interface for content processing
+// Processor defines the interface for content processing. Host selects the
+// publisher's boilerplate rules.
 type Processor interface {
-	ProcessArticle(content string) (*models.ProcessingResult, error)
+	ProcessSiteArticle(host, content string) (*models.ProcessingResult, error)
 }
 
 // LLMClient defines the interface for LLM operations
@@ -24,10 +26,25 @@ type LLMClient interface {
 	GenerateStream(ctx context.Context, messages []llm.Message, respChan chan<- string) error
 }
 
-// Store defines the interface for database operations
-type Store interface {
-	SaveArticle(article *models.Article) error
-	GetArticleByID(id string) (*models.Article, error)
-	GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models.Article, error)
-	UpdateArticle(article *models.Article) error
+// Store defines the interface for database operations; db.MemoryStore implements it for tests
+type Store = db.Store
+
+// ArticleExtractor defines the interface for single-pass entity and relationship extraction
+type ArticleExtractor interface {
+	ProcessArticle(ctx context.Context, article *models.Article) (*models.ExtractionResult, error)
+}
+
+// EntityAuthority defines the interface for linking extracted entities to an external authority
+type EntityAuthority interface {
+	Enrich(ctx context.Context, result *models.ExtractionResult) (int, error)
+}
+
+// AnalysisStarter defines the interface for starting sequential analysis sessions
+type AnalysisStarter interface {
+	StartAnalysis(ctx context.Context, article *models.Article, config *sequential.AnalysisConfig) (*sequential.AnalysisSession, error)
+}
+
+// GraphReader defines the read-only graph queries available to MCP tools
+type GraphReader interface {
+	QueryEntities(query db.EntityQuery) ([]db.EntityNeighborhood, error)
 }
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 
 	"clank/config"
+	"clank/internal/limits"
 	"clank/internal/llm"
+	"clank/internal/logging"
 
 	"github.com/gin-gonic/gin"
 )
@@ -39,12 +41,17 @@ func LLMHandler(c *gin.Context) {
 		return
 	}
 
-	log.Printf("Processing LLM request with %d messages", len(req.Messages))
+	logger := logging.For(c.Request.Context(), "chat")
+	logger.Info("Processing LLM request", "messages", len(req.Messages))
 
 	// Generate response from llama.cpp
 	result, err := client.Generate(c.Request.Context(), req.Messages)
 	if err != nil {
-		log.Printf("Error generating response: %v", err)
+		logger.Error("Error generating response", "error", err)
+		if errors.Is(err, limits.ErrBusy) {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many LLM requests in progress, try again later"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to generate response from llama.cpp",
 			"details": err.Error(),
@@ -76,13 +83,13 @@ func LLMStreamHandler(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		log.Printf("Processing streaming LLM request with %d messages", len(req.Messages))
+		logger := logging.For(c.Request.Context(), "chat")
+		logger.Info("Processing streaming LLM request", "messages", len(req.Messages))
 
 		// Set SSE headers
 		c.Writer.Header().Set("Content-Type", "text/event-stream")
 		c.Writer.Header().Set("Cache-Control", "no-cache")
 		c.Writer.Header().Set("Connection", "keep-alive")
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 
 		// Create response channel
 		responseChan := make(chan string, 100)
@@ -91,7 +98,7 @@ func LLMStreamHandler(cfg *config.Config) gin.HandlerFunc {
 		go func() {
 			defer close(responseChan)
 			if err := client.GenerateStream(c.Request.Context(), req.Messages, responseChan); err != nil {
-				log.Printf("Streaming generation error: %v", err)
+				logger.Error("Streaming generation error", "error", err)
 				responseChan <- fmt.Sprintf(`{"error": "%s"}`, err.Error())
 			}
 		}()
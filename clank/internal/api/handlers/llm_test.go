@@ -2,162 +2,173 @@ package handlers
 
 import (
 	"bytes"
-	"clank/internal/testutil"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 
+	"clank/config"
+	"clank/internal/llm"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestLLMHandler(t *testing.T) {
-	tests := []struct {
-		name           string
-		requestBody    map[string]interface{}
-		setupMocks     func(*testutil.MockLLMClient, *testutil.MockDB)
-		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
-	}{
-		{
-			name: "successful completion request",
-			requestBody: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			setupMocks: func(llm *testutil.MockLLMClient, db *testutil.MockDB) {
-				llm.GenerateResponse = `{"text": "Generated response", "usage": {"total_tokens": 50}}`
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Contains(t, response, "text")
-				assert.Contains(t, response, "usage")
-			},
-		},
-		{
-			name: "missing prompt",
-			requestBody: map[string]interface{}{
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			setupMocks:     func(llm *testutil.MockLLMClient, db *testutil.MockDB) {},
-			expectedStatus: http.StatusBadRequest,
-		},
-		{
-			name: "llm service error",
-			requestBody: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			setupMocks: func(llm *testutil.MockLLMClient, db *testutil.MockDB) {
-				llm.GenerateError = assert.AnError
-			},
-			expectedStatus: http.StatusInternalServerError,
-		},
-		{
-			name: "invalid temperature value",
-			requestBody: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 2.0, // Should be between 0 and 1
-			},
-			setupMocks:     func(llm *testutil.MockLLMClient, db *testutil.MockDB) {},
-			expectedStatus: http.StatusBadRequest,
-		},
+// fakeLlama answers chat completions like llama.cpp: streamed requests with
+// chunks as SSE deltas, the others with reply. A non-zero status fails every
+// request with it; with hang set, requests are answered once the client gives up.
+type fakeLlama struct {
+	reply  string
+	chunks []string
+	status int
+	hang   bool
+
+	mu       sync.Mutex
+	requests []llm.GenerateRequest
+}
+
+func (f *fakeLlama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req llm.GenerateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			r := setupTestRouter()
-			mockLLM := testutil.NewMockLLMClient()
-			mockDB := testutil.NewMockDB()
-			tt.setupMocks(mockLLM, mockDB)
+	if f.hang {
+		<-r.Context().Done()
+		return
+	}
+	if f.status != 0 {
+		http.Error(w, "model not loaded", f.status)
+		return
+	}
+	if !req.Stream {
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: f.reply}}}})
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range f.chunks {
+		delta, _ := json.Marshal(map[string]interface{}{
+			"choices": []map[string]interface{}{{"delta": map[string]string{"content": chunk}}},
+		})
+		fmt.Fprintf(w, "data: %s\n\n", delta)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
 
-			r.POST("/llm/complete", func(c *gin.Context) {
-				c.Set("llm", mockLLM)
-				c.Set("db", mockDB)
-				LLMHandler(c)
-			})
+// lastUserMessage returns the content of the last user message f received
+func (f *fakeLlama) lastUserMessage(t *testing.T) string {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.NotEmpty(t, f.requests)
+	messages := f.requests[len(f.requests)-1].Messages
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
 
-			body, err := json.Marshal(tt.requestBody)
-			require.NoError(t, err)
-			req := httptest.NewRequest(http.MethodPost, "/llm/complete", bytes.NewBuffer(body))
-			req.Header.Set("Content-Type", "application/json")
-			rr := httptest.NewRecorder()
+// newFakeLlamaConfig starts f and returns a config pointing the LLM client at it
+func newFakeLlamaConfig(t *testing.T, f *fakeLlama) *config.Config {
+	t.Helper()
+	server := httptest.NewServer(f)
+	t.Cleanup(func() {
+		server.CloseClientConnections()
+		server.Close()
+	})
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.Model = "test-model"
+	return cfg
+}
 
-			r.ServeHTTP(rr, req)
+func setupTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return gin.New()
+}
 
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			if tt.checkResponse != nil {
-				tt.checkResponse(t, rr)
-			}
-		})
+// perform sends a method request for path to handler, registered on route, with
+// body encoded as JSON unless it is nil
+func perform(t *testing.T, method, route, path string, handler gin.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	return serve(route, handler, req)
+}
+
+// serve sends req to handler, registered on route for the request's method
+func serve(route string, handler gin.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	r := setupTestRouter()
+	r.Handle(req.Method, route, handler)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// parseSSEEvents returns the data of each server-sent event in response
+func parseSSEEvents(response string) []string {
+	var events []string
+	for _, line := range strings.Split(response, "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			events = append(events, strings.TrimSpace(strings.TrimPrefix(line, "data: ")))
+		}
 	}
+	return events
 }
 
-func TestStreamLLMHandler(t *testing.T) {
+func TestLLMStreamHandler(t *testing.T) {
 	tests := []struct {
 		name           string
+		llama          *fakeLlama
 		requestBody    map[string]interface{}
-		setupMocks     func(*testutil.MockLLMClient, *testutil.MockDB)
+		expectedStatus int
 		expectedEvents []string
-		expectError    bool
 	}{
 		{
-			name: "successful streaming request",
+			name:  "successful streaming request",
+			llama: &fakeLlama{chunks: []string{"First chunk", "Second chunk", "Final chunk"}},
 			requestBody: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			setupMocks: func(llm *testutil.MockLLMClient, db *testutil.MockDB) {
-				llm.StreamResponses = []string{
-					"First chunk",
-					"Second chunk",
-					"Final chunk",
-				}
+				"messages": []map[string]string{{"role": "user", "content": "Test prompt"}},
 			},
-			expectedEvents: []string{
-				"First chunk",
-				"Second chunk",
-				"Final chunk",
-			},
-			expectError: false,
+			expectedStatus: http.StatusOK,
+			expectedEvents: []string{"First chunk", "Second chunk", "Final chunk", "[DONE]"},
 		},
 		{
-			name: "stream error handling",
+			name:  "stream error is sent as an event",
+			llama: &fakeLlama{status: http.StatusServiceUnavailable},
 			requestBody: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			setupMocks: func(llm *testutil.MockLLMClient, db *testutil.MockDB) {
-				llm.StreamError = assert.AnError
+				"messages": []map[string]string{{"role": "user", "content": "Test prompt"}},
 			},
-			expectError: true,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing messages",
+			llama:          &fakeLlama{},
+			requestBody:    map[string]interface{}{"prompt": "Test prompt"},
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := setupTestRouter()
-			mockLLM := testutil.NewMockLLMClient()
-			mockDB := testutil.NewMockDB()
-			tt.setupMocks(mockLLM, mockDB)
-
-			r.POST("/llm/stream", func(c *gin.Context) {
-				c.Set("llm", mockLLM)
-				c.Set("db", mockDB)
-				StreamLLMHandler(c)
-			})
+			r.POST("/llm/stream", LLMStreamHandler(newFakeLlamaConfig(t, tt.llama)))
 
 			body, err := json.Marshal(tt.requestBody)
 			require.NoError(t, err)
@@ -168,83 +179,18 @@ func TestStreamLLMHandler(t *testing.T) {
 
 			r.ServeHTTP(rr, req)
 
-			if tt.expectError {
-				assert.Equal(t, http.StatusInternalServerError, rr.Code)
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
 				return
 			}
-
-			assert.Equal(t, http.StatusOK, rr.Code)
-
-			// Parse SSE response
 			events := parseSSEEvents(rr.Body.String())
-			assert.Equal(t, tt.expectedEvents, events)
-		})
-	}
-}
-
-// Helper function to parse SSE events from response
-func parseSSEEvents(response string) []string {
-	var events []string
-	for _, line := range strings.Split(response, "\n") {
-		if strings.HasPrefix(line, "data: ") {
-			event := strings.TrimPrefix(line, "data: ")
-			events = append(events, strings.TrimSpace(event))
-		}
-	}
-	return events
-}
-
-func TestValidateLLMRequest(t *testing.T) {
-	tests := []struct {
-		name    string
-		request map[string]interface{}
-		wantErr bool
-	}{
-		{
-			name: "valid request",
-			request: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			wantErr: false,
-		},
-		{
-			name: "missing prompt",
-			request: map[string]interface{}{
-				"maxTokens":   100,
-				"temperature": 0.7,
-			},
-			wantErr: true,
-		},
-		{
-			name: "invalid temperature",
-			request: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   100,
-				"temperature": 1.5,
-			},
-			wantErr: true,
-		},
-		{
-			name: "invalid maxTokens",
-			request: map[string]interface{}{
-				"prompt":      "Test prompt",
-				"maxTokens":   -1,
-				"temperature": 0.7,
-			},
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := validateLLMRequest(tt.request)
-			if tt.wantErr {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
+			if tt.expectedEvents != nil {
+				assert.Equal(t, tt.expectedEvents, events)
+				return
 			}
+			require.Len(t, events, 2)
+			assert.Contains(t, events[0], "error")
+			assert.Equal(t, "[DONE]", events[1])
 		})
 	}
 }
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	"clank/config"
+)
+
+// localeFieldNames are the built-in translations of extraction response keys
+var localeFieldNames = map[string]map[string]string{
+	"es": {
+		"articleId":     "idArticulo",
+		"title":         "titulo",
+		"content":       "contenido",
+		"status":        "estado",
+		"mode":          "modo",
+		"sessionId":     "idSesion",
+		"extraction":    "extraccion",
+		"entities":      "entidades",
+		"relationships": "relaciones",
+		"name":          "nombre",
+		"type":          "tipo",
+		"properties":    "propiedades",
+		"confidence":    "confianza",
+		"mentions":      "menciones",
+		"text":          "texto",
+		"context":       "contexto",
+		"aliases":       "alias",
+		"fromId":        "idOrigen",
+		"toId":          "idDestino",
+		"extractedAt":   "extraidoEn",
+	},
+	"fr": {
+		"articleId":     "idArticle",
+		"title":         "titre",
+		"content":       "contenu",
+		"status":        "statut",
+		"mode":          "mode",
+		"sessionId":     "idSession",
+		"extraction":    "extraction",
+		"entities":      "entites",
+		"relationships": "relations",
+		"name":          "nom",
+		"type":          "type",
+		"properties":    "proprietes",
+		"confidence":    "confiance",
+		"mentions":      "mentions",
+		"text":          "texte",
+		"context":       "contexte",
+		"aliases":       "alias",
+		"fromId":        "idSource",
+		"toId":          "idCible",
+		"extractedAt":   "extraitLe",
+	},
+}
+
+// localeTypes are the built-in translations of entity and relationship types
+var localeTypes = map[string]map[string]string{
+	"es": {
+		"person":       "persona",
+		"organization": "organizacion",
+		"location":     "ubicacion",
+		"money":        "dinero",
+		"time":         "tiempo",
+		"event":        "evento",
+		"payment":      "pago",
+		"affiliation":  "afiliacion",
+		"ownership":    "propiedad",
+		"involvement":  "participacion",
+	},
+	"fr": {
+		"person":       "personne",
+		"organization": "organisation",
+		"location":     "lieu",
+		"money":        "argent",
+		"time":         "date",
+		"event":        "evenement",
+		"payment":      "paiement",
+		"affiliation":  "affiliation",
+		"ownership":    "propriete",
+		"involvement":  "implication",
+	},
+}
+
+// responseLocalizer renames response keys and type values for display. It works
+// on the encoded response, so the models used internally are never changed.
+type responseLocalizer struct {
+	fieldNames map[string]string
+	types      map[string]string
+}
+
+// newResponseLocalizer builds a localizer for the configured locale, or returns nil
+// when responses should be left in English
+func newResponseLocalizer(cfg *config.Config) *responseLocalizer {
+	locale := strings.ToLower(cfg.Localization.Locale)
+	if (locale == "" || locale == "en") && len(cfg.Localization.FieldNames) == 0 && len(cfg.Localization.Types) == 0 {
+		return nil
+	}
+
+	l := &responseLocalizer{
+		fieldNames: make(map[string]string),
+		types:      make(map[string]string),
+	}
+	for k, v := range localeFieldNames[locale] {
+		l.fieldNames[k] = v
+	}
+	for k, v := range cfg.Localization.FieldNames {
+		l.fieldNames[k] = v
+	}
+	for k, v := range localeTypes[locale] {
+		l.types[k] = v
+	}
+	for k, v := range cfg.Localization.Types {
+		l.types[strings.ToLower(k)] = v
+	}
+	return l
+}
+
+// localize returns response with its keys and type values translated. A nil
+// localizer, or a response that cannot be encoded, is returned unchanged.
+func (l *responseLocalizer) localize(response interface{}) interface{} {
+	if l == nil {
+		return response
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return response
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return response
+	}
+	return l.localizeValue(decoded)
+}
+
+func (l *responseLocalizer) localizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			if key == "type" {
+				if s, ok := item.(string); ok {
+					if translated, ok := l.types[strings.ToLower(s)]; ok {
+						item = translated
+					}
+				}
+			}
+			if translated, ok := l.fieldNames[key]; ok {
+				key = translated
+			}
+			out[key] = l.localizeValue(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = l.localizeValue(item)
+		}
+		return out
+	}
+	return value
+}
@@ -4,12 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
 	"clank/config"
 	"clank/internal/llm"
+	"clank/internal/logging"
 	"clank/internal/models"
 	"clank/internal/prompts"
 
@@ -53,7 +53,7 @@ func NewMCPService(cfg *config.Config) *MCPService {
 
 	// Load all prompts at startup
 	if err := service.promptLoader.LoadPrompts(); err != nil {
-		log.Printf("Error loading prompts: %v", err)
+		logging.For(context.Background(), "mcp").Warn("Error loading prompts", "error", err)
 	}
 
 	// Create MCP implementation for tool calling and context injection
@@ -63,19 +63,21 @@ func NewMCPService(cfg *config.Config) *MCPService {
 	}
 
 	service.server = mcp.NewServer(impl, &mcp.ServerOptions{})
+	registerGraphTools(service.server, neo4jGraph{})
 
 	return service
 }
 
 // ProcessWithMCP processes messages through MCP, injecting system prompts
 func (s *MCPService) ProcessWithMCP(ctx context.Context, messages []llm.Message) ([]llm.Message, error) {
-	log.Printf("Processing %d messages through MCP", len(messages))
+	logger := logging.For(ctx, "mcp")
+	logger.Info("Processing messages through MCP", "messages", len(messages))
 
 	var processed []llm.Message
 
 	// Reload prompts if files have changed
 	if err := s.promptLoader.ReloadIfChanged(); err != nil {
-		log.Printf("Error reloading prompts: %v", err)
+		logger.Warn("Error reloading prompts", "error", err)
 	}
 
 	// Inject system prompt
@@ -89,7 +91,7 @@ func (s *MCPService) ProcessWithMCP(ctx context.Context, messages []llm.Message)
 			Content: sysPrompt.RenderedText,
 		})
 	} else {
-		log.Printf("Failed to render system prompt: %v", err)
+		logger.Warn("Failed to render system prompt", "error", err)
 	}
 
 	// TODO: optionally add prompts for tools or context analysis here based on message content
@@ -119,16 +121,15 @@ func MCPHandlerSSE(c *gin.Context) {
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
 	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-	c.Writer.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
 
-	log.Printf("Starting MCP SSE connection")
+	ctx := c.Request.Context()
+	logger := logging.For(ctx, "mcp")
+	logger.Info("Starting MCP SSE connection")
 
 	transport := mcp.NewSSEServerTransport("mcp-llm-proxy", c.Writer)
 
-	ctx := c.Request.Context()
 	if err := mcpService.server.Run(ctx, transport); err != nil {
-		log.Printf("MCP server error: %v", err)
+		logger.Error("MCP server error", "error", err)
 		c.String(http.StatusInternalServerError, "MCP server error: %v", err)
 		return
 	}
@@ -159,7 +160,7 @@ func MCPChatHandler(cfg *config.Config) gin.HandlerFunc {
 			go func() {
 				defer close(responseChan)
 				if err := mcpService.GenerateWithMCP(c.Request.Context(), request.Messages, responseChan); err != nil {
-					log.Printf("MCP generation error: %v", err)
+					logging.For(c.Request.Context(), "mcp").Error("MCP generation error", "error", err)
 					responseChan <- fmt.Sprintf("data: {\"error\": \"%s\"}\n\n", err.Error())
 				}
 			}()
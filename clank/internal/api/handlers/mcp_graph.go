@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"clank/internal/db"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// GraphQueryToolName is the MCP tool that looks entities up in the graph
+const GraphQueryToolName = "graph_query"
+
+// minGraphQueryName keeps name lookups from matching most of the graph
+const minGraphQueryName = 2
+
+// GraphQueryArgs are the arguments of the graph_query tool. They select
+// entities; there is no way to pass Cypher.
+type GraphQueryArgs struct {
+	Name         string `json:"name,omitempty" jsonschema:"entity name or alias to look up, matched ignoring case as a substring"`
+	ID           string `json:"id,omitempty" jsonschema:"entity ID to look up instead of a name"`
+	Type         string `json:"type,omitempty" jsonschema:"only entities of this type, e.g. person, organization, company"`
+	Relationship string `json:"relationship,omitempty" jsonschema:"only relationships of this type, e.g. payment, employment"`
+	Limit        int    `json:"limit,omitempty" jsonschema:"maximum entities returned (default 5, at most 25)"`
+}
+
+// neo4jGraph reads the graph through the db package
+type neo4jGraph struct{}
+
+func (neo4jGraph) QueryEntities(query db.EntityQuery) ([]db.EntityNeighborhood, error) {
+	return db.QueryEntities(query)
+}
+
+// registerGraphTools adds the graph_query tool, backed by reader, to server
+func registerGraphTools(server *mcp.Server, reader GraphReader) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name: GraphQueryToolName,
+		Description: "Look up entities in the corruption graph by name or ID and return them " +
+			"with their immediate relationships. Read-only.",
+	}, graphQueryTool(reader))
+}
+
+// graphQueryTool returns the graph_query handler. Invalid arguments and database
+// failures are tool errors the model can read, not protocol errors.
+func graphQueryTool(reader GraphReader) mcp.ToolHandlerFor[GraphQueryArgs, any] {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[GraphQueryArgs]) (*mcp.CallToolResultFor[any], error) {
+		args := params.Arguments
+		query := db.EntityQuery{
+			ID:               strings.TrimSpace(args.ID),
+			Name:             strings.TrimSpace(args.Name),
+			Type:             args.Type,
+			RelationshipType: args.Relationship,
+			Limit:            args.Limit,
+		}
+		if query.ID == "" && len([]rune(query.Name)) < minGraphQueryName {
+			return graphToolError(fmt.Sprintf("name must be at least %d characters, or id must be set", minGraphQueryName)), nil
+		}
+		if query.Limit < 0 {
+			return graphToolError("limit must not be negative"), nil
+		}
+
+		entities, err := reader.QueryEntities(query)
+		if err != nil {
+			if errors.Is(err, db.ErrUnavailable) {
+				return graphToolError("graph database unavailable, try again later"), nil
+			}
+			return graphToolError(fmt.Sprintf("graph query failed: %v", err)), nil
+		}
+		return &mcp.CallToolResultFor[any]{
+			Content:           []mcp.Content{&mcp.TextContent{Text: formatEntityNeighborhoods(query, entities)}},
+			StructuredContent: map[string]any{"entities": entities},
+		}, nil
+	}
+}
+
+func graphToolError(message string) *mcp.CallToolResultFor[any] {
+	return &mcp.CallToolResultFor[any]{
+		Content: []mcp.Content{&mcp.TextContent{Text: message}},
+		IsError: true,
+	}
+}
+
+// formatEntityNeighborhoods renders entities one per block, relationships
+// indented below with -> for outgoing and <- for incoming ones
+func formatEntityNeighborhoods(query db.EntityQuery, entities []db.EntityNeighborhood) string {
+	subject := fmt.Sprintf("%q", query.Name)
+	if query.ID != "" {
+		subject = "id " + query.ID
+	}
+	if len(entities) == 0 {
+		return fmt.Sprintf("No entities match %s.", subject)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d entities matching %s:\n", len(entities), subject)
+	for _, entity := range entities {
+		fmt.Fprintf(&b, "\n%s (%s, id: %s)", entity.Name, entity.Type, entity.ID)
+		if len(entity.Aliases) > 0 {
+			fmt.Fprintf(&b, " also known as %s", strings.Join(entity.Aliases, ", "))
+		}
+		b.WriteString("\n")
+		if len(entity.Relationships) == 0 {
+			b.WriteString("  no relationships\n")
+			continue
+		}
+		for _, rel := range entity.Relationships {
+			arrow := "<-"
+			if rel.Outgoing {
+				arrow = "->"
+			}
+			fmt.Fprintf(&b, "  %s %s %s (%s, id: %s)", arrow, rel.Type, rel.OtherName, rel.OtherType, rel.OtherID)
+			if rel.Confidence > 0 {
+				fmt.Fprintf(&b, " confidence %.2f", rel.Confidence)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"clank/internal/db"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeGraphReader struct {
+	entities []db.EntityNeighborhood
+	err      error
+	queries  []db.EntityQuery
+}
+
+func (f *fakeGraphReader) QueryEntities(query db.EntityQuery) ([]db.EntityNeighborhood, error) {
+	f.queries = append(f.queries, query)
+	return f.entities, f.err
+}
+
+// callGraphQuery invokes graph_query through an in-memory MCP client session
+func callGraphQuery(t *testing.T, reader GraphReader, args map[string]any) *mcp.CallToolResult {
+	t.Helper()
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "test-server", Version: "v0.0.1"}, nil)
+	registerGraphTools(server, reader)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport)
+	require.NoError(t, err)
+	defer serverSession.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0.0.1"}, nil)
+	clientSession, err := client.Connect(ctx, clientTransport)
+	require.NoError(t, err)
+	defer clientSession.Close()
+
+	result, err := clientSession.CallTool(ctx, &mcp.CallToolParams{Name: GraphQueryToolName, Arguments: args})
+	require.NoError(t, err)
+	return result
+}
+
+func resultText(t *testing.T, result *mcp.CallToolResult) string {
+	t.Helper()
+	require.Len(t, result.Content, 1)
+	text, ok := result.Content[0].(*mcp.TextContent)
+	require.True(t, ok)
+	return text.Text
+}
+
+func TestGraphQueryTool(t *testing.T) {
+	reader := &fakeGraphReader{entities: []db.EntityNeighborhood{
+		{
+			ID: "e-1", Name: "Acme Corp", Type: "company", Aliases: []string{"Acme"},
+			Relationships: []db.NeighborRelationship{
+				{Type: "payment", Outgoing: true, OtherID: "e-2", OtherName: "Jane Doe", OtherType: "person", Confidence: 0.9},
+				{Type: "ownership", OtherID: "e-3", OtherName: "Harbor Holdings", OtherType: "company"},
+			},
+		},
+		{ID: "e-4", Name: "Acme Foundation", Type: "organization", Relationships: []db.NeighborRelationship{}},
+	}}
+
+	result := callGraphQuery(t, reader, map[string]any{"name": " acme ", "type": "company", "relationship": "payment", "limit": 3})
+
+	assert.False(t, result.IsError)
+	assert.Equal(t, "Found 2 entities matching \"acme\":\n"+
+		"\nAcme Corp (company, id: e-1) also known as Acme\n"+
+		"  -> payment Jane Doe (person, id: e-2) confidence 0.90\n"+
+		"  <- ownership Harbor Holdings (company, id: e-3)\n"+
+		"\nAcme Foundation (organization, id: e-4)\n"+
+		"  no relationships\n", resultText(t, result))
+	require.Len(t, reader.queries, 1)
+	assert.Equal(t, db.EntityQuery{Name: "acme", Type: "company", RelationshipType: "payment", Limit: 3}, reader.queries[0])
+}
+
+func TestGraphQueryTool_ByID(t *testing.T) {
+	reader := &fakeGraphReader{}
+
+	result := callGraphQuery(t, reader, map[string]any{"id": "e-9"})
+
+	assert.False(t, result.IsError)
+	assert.Equal(t, "No entities match id e-9.", resultText(t, result))
+	require.Len(t, reader.queries, 1)
+	assert.Equal(t, "e-9", reader.queries[0].ID)
+}
+
+func TestGraphQueryTool_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+		err  error
+		want string
+	}{
+		{"no name or id", map[string]any{}, nil, "name must be at least 2 characters, or id must be set"},
+		{"name too short", map[string]any{"name": "a"}, nil, "name must be at least 2 characters, or id must be set"},
+		{"negative limit", map[string]any{"name": "acme", "limit": -1}, nil, "limit must not be negative"},
+		{"database unavailable", map[string]any{"name": "acme"},
+			db.NewError(db.ErrUnavailable, "database connection lost"), "graph database unavailable, try again later"},
+		{"query failure", map[string]any{"name": "acme"}, fmt.Errorf("boom"), "graph query failed: boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &fakeGraphReader{err: tt.err}
+
+			result := callGraphQuery(t, reader, tt.args)
+
+			assert.True(t, result.IsError)
+			assert.Equal(t, tt.want, resultText(t, result))
+			if tt.err == nil {
+				assert.Empty(t, reader.queries)
+			}
+		})
+	}
+}
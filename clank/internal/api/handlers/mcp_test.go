@@ -2,26 +2,42 @@ package handlers
 
 import (
 	"bytes"
-	"clank/config"
-	"clank/internal/llm"
-	"clank/internal/testutil"
 	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"clank/internal/llm"
+	"clank/internal/prompts"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestNewMCPService(t *testing.T) {
-	cfg := &config.Config{
-		LLMEndpoint: "http://localhost:8080",
-	}
+const testSystemPrompt = `{
+  "name": "system",
+  "description": "Test system prompt",
+  "template": "You are Clank, a network analysis assistant."
+}`
+
+// newTestMCPService returns a service using f as its LLM and a prompts
+// directory holding only the system prompt
+func newTestMCPService(t *testing.T, f *fakeLlama) *MCPService {
+	t.Helper()
+	service := NewMCPService(newFakeLlamaConfig(t, f))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "system.json"), []byte(testSystemPrompt), 0o644))
+	service.promptLoader = prompts.NewPromptLoader(dir)
+	require.NoError(t, service.promptLoader.LoadPrompts())
+	return service
+}
 
-	service := NewMCPService(cfg)
+func TestNewMCPService(t *testing.T) {
+	service := NewMCPService(newFakeLlamaConfig(t, &fakeLlama{}))
 	assert.NotNil(t, service)
 	assert.NotNil(t, service.llmClient)
 	assert.NotNil(t, service.server)
@@ -32,33 +48,24 @@ func TestMCPService_ProcessWithMCP(t *testing.T) {
 	tests := []struct {
 		name           string
 		messages       []llm.Message
-		setupMocks     func(*testutil.MockLLMClient)
-		expectError    bool
 		checkProcessed func(*testing.T, []llm.Message)
 	}{
 		{
-			name: "successful processing with system prompt",
-			messages: []llm.Message{
-				{Role: "user", Content: "Hello"},
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{"response": "Hello there!"}`
-			},
-			expectError: false,
+			name:     "successful processing with system prompt",
+			messages: []llm.Message{{Role: "user", Content: "Hello"}},
 			checkProcessed: func(t *testing.T, processed []llm.Message) {
-				require.GreaterOrEqual(t, len(processed), 2) // At least system + user message
+				require.Len(t, processed, 2)
 				assert.Equal(t, "system", processed[0].Role)
+				assert.Contains(t, processed[0].Content, "You are Clank")
 				assert.Equal(t, "user", processed[1].Role)
 				assert.Equal(t, "Hello", processed[1].Content)
 			},
 		},
 		{
-			name:        "empty message list",
-			messages:    []llm.Message{},
-			setupMocks:  func(mock *testutil.MockLLMClient) {},
-			expectError: false,
+			name:     "empty message list",
+			messages: []llm.Message{},
 			checkProcessed: func(t *testing.T, processed []llm.Message) {
-				require.GreaterOrEqual(t, len(processed), 1) // At least system prompt
+				require.Len(t, processed, 1)
 				assert.Equal(t, "system", processed[0].Role)
 			},
 		},
@@ -66,26 +73,12 @@ func TestMCPService_ProcessWithMCP(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &config.Config{
-				LLMEndpoint: "http://localhost:8080",
-			}
-			service := NewMCPService(cfg)
-
-			mockLLM := testutil.NewMockLLMClient()
-			tt.setupMocks(mockLLM)
-			service.llmClient = mockLLM
+			service := newTestMCPService(t, &fakeLlama{})
 
 			processed, err := service.ProcessWithMCP(context.Background(), tt.messages)
 
-			if tt.expectError {
-				assert.Error(t, err)
-				return
-			}
-
-			assert.NoError(t, err)
-			if tt.checkProcessed != nil {
-				tt.checkProcessed(t, processed)
-			}
+			require.NoError(t, err)
+			tt.checkProcessed(t, processed)
 		})
 	}
 }
@@ -93,68 +86,42 @@ func TestMCPService_ProcessWithMCP(t *testing.T) {
 func TestMCPService_GenerateWithMCP(t *testing.T) {
 	tests := []struct {
 		name        string
-		messages    []llm.Message
-		setupMocks  func(*testutil.MockLLMClient)
+		llama       *fakeLlama
 		expectError bool
 		expected    []string
 	}{
 		{
-			name: "successful generation",
-			messages: []llm.Message{
-				{Role: "user", Content: "Hello"},
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.StreamResponses = []string{
-					"Hello",
-					" there!",
-				}
-			},
-			expectError: false,
-			expected: []string{
-				"Hello",
-				" there!",
-			},
+			name:     "successful generation",
+			llama:    &fakeLlama{chunks: []string{"Hello", " there!"}},
+			expected: []string{"Hello", " there!"},
 		},
 		{
-			name: "llm error",
-			messages: []llm.Message{
-				{Role: "user", Content: "Hello"},
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.StreamError = assert.AnError
-			},
+			name:        "llm error",
+			llama:       &fakeLlama{status: http.StatusInternalServerError},
 			expectError: true,
-			expected:    nil,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &config.Config{
-				LLMEndpoint: "http://localhost:8080",
-			}
-			service := NewMCPService(cfg)
-
-			mockLLM := testutil.NewMockLLMClient()
-			tt.setupMocks(mockLLM)
-			service.llmClient = mockLLM
+			service := newTestMCPService(t, tt.llama)
 
 			responseChan := make(chan string, 10)
-			err := service.GenerateWithMCP(context.Background(), tt.messages, responseChan)
+			err := service.GenerateWithMCP(context.Background(), []llm.Message{{Role: "user", Content: "Hello"}}, responseChan)
+			close(responseChan)
 
 			if tt.expectError {
 				assert.Error(t, err)
 				return
 			}
-
-			assert.NoError(t, err)
+			require.NoError(t, err)
 
 			var responses []string
 			for response := range responseChan {
 				responses = append(responses, response)
 			}
-
 			assert.Equal(t, tt.expected, responses)
+			assert.Equal(t, "Hello", tt.llama.lastUserMessage(t))
 		})
 	}
 }
@@ -162,71 +129,50 @@ func TestMCPService_GenerateWithMCP(t *testing.T) {
 func TestMCPChatHandler(t *testing.T) {
 	tests := []struct {
 		name           string
+		llama          *fakeLlama
 		request        map[string]interface{}
-		setupMocks     func(*testutil.MockLLMClient)
 		expectedStatus int
 		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
-			name: "successful non-streaming request",
+			name:  "successful non-streaming request",
+			llama: &fakeLlama{reply: "Hello there!"},
 			request: map[string]interface{}{
-				"messages": []map[string]interface{}{
-					{"role": "user", "content": "Hello"},
-				},
-				"stream": false,
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{"response": "Hello there!"}`
+				"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+				"stream":   false,
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Contains(t, response, "response")
+				var response llm.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				require.Len(t, response.Choices, 1)
+				assert.Equal(t, "Hello there!", response.Choices[0].Message.Content)
 			},
 		},
 		{
-			name: "successful streaming request",
+			name:  "successful streaming request",
+			llama: &fakeLlama{chunks: []string{"Hello", " there!"}},
 			request: map[string]interface{}{
-				"messages": []map[string]interface{}{
-					{"role": "user", "content": "Hello"},
-				},
-				"stream": true,
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.StreamResponses = []string{
-					"Hello",
-					" there!",
-				}
+				"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+				"stream":   true,
 			},
 			expectedStatus: http.StatusOK,
 			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				responses := strings.Split(strings.TrimSpace(rr.Body.String()), "\n\n")
-				assert.Greater(t, len(responses), 0)
-				for _, resp := range responses {
-					assert.True(t, strings.HasPrefix(resp, "data: "))
-				}
+				assert.Equal(t, []string{"Hello", "there!"}, parseSSEEvents(rr.Body.String()))
 			},
 		},
 		{
-			name: "invalid request format",
-			request: map[string]interface{}{
-				"invalid": "format",
-			},
-			setupMocks:     func(mock *testutil.MockLLMClient) {},
+			name:           "invalid request format",
+			llama:          &fakeLlama{},
+			request:        map[string]interface{}{"messages": "Hello"},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name: "llm error",
+			name:  "llm error",
+			llama: &fakeLlama{status: http.StatusInternalServerError},
 			request: map[string]interface{}{
-				"messages": []map[string]interface{}{
-					{"role": "user", "content": "Hello"},
-				},
-				"stream": false,
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateError = assert.AnError
+				"messages": []map[string]interface{}{{"role": "user", "content": "Hello"}},
+				"stream":   false,
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -234,16 +180,8 @@ func TestMCPChatHandler(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := &config.Config{
-				LLMEndpoint: "http://localhost:8080",
-			}
-
 			r := setupTestRouter()
-			mockLLM := testutil.NewMockLLMClient()
-			tt.setupMocks(mockLLM)
-
-			handler := MCPChatHandler(cfg)
-			r.POST("/chat", handler)
+			r.POST("/chat", MCPChatHandler(newFakeLlamaConfig(t, tt.llama)))
 
 			body, err := json.Marshal(tt.request)
 			require.NoError(t, err)
@@ -22,6 +22,13 @@ func InitPromptService(promptsDir string) error {
 	return nil
 }
 
+// UsePromptLoader makes the prompt endpoints serve loader, e.g. the one the
+// extraction prompts are rendered from, so the versions they list can be
+// selected per request and rollbacks change what extraction renders
+func UsePromptLoader(loader *prompts.PromptLoader) {
+	promptServiceInstance = prompts.NewServiceForLoader(loader)
+}
+
 // ListPrompts lists all loaded prompts
 func ListPrompts(c *gin.Context) {
 	if promptServiceInstance == nil {
@@ -164,7 +171,7 @@ func ValidatePrompt(c *gin.Context) {
 
 	if err := promptServiceInstance.ValidatePromptArguments(name, req.Arguments); err != nil {
 		if ve, ok := err.(*models.ValidationError); ok {
-			c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": ve.Message, "field": ve.Field, "value": ve.Value})
+			c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": ve.Message, "field": ve.Field, "value": ve.Value, "errors": ve.Errors})
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": err.Error()})
 		}
@@ -173,3 +180,45 @@ func ValidatePrompt(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"valid": true, "message": "Arguments are valid"})
 }
+
+// GetPromptVersions lists the retained versions of a prompt
+func GetPromptVersions(c *gin.Context) {
+	if promptServiceInstance == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Prompt service not initialized"})
+		return
+	}
+
+	name := c.Param("name")
+	versions, err := promptServiceInstance.GetPromptVersions(name)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found", "name": name})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"name": name, "versions": versions, "count": len(versions)})
+}
+
+// RollbackPrompt makes a previously loaded version of a prompt active
+func RollbackPrompt(c *gin.Context) {
+	if promptServiceInstance == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Prompt service not initialized"})
+		return
+	}
+
+	name := c.Param("name")
+
+	var req struct {
+		Version int `json:"version" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	if err := promptServiceInstance.RollbackPrompt(name, req.Version); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Failed to roll back prompt", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt rolled back successfully", "name": name, "version": req.Version})
+}
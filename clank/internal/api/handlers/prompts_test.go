@@ -1,12 +1,10 @@
 package handlers
 
 import (
-	"bytes"
-	"clank/internal/prompts"
-	"clank/internal/testutil"
 	"encoding/json"
 	"net/http"
-	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -14,121 +12,109 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func setupTestRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	r := gin.New()
-	return r
+const testExtractionPrompt = `{
+  "name": "entity_extraction",
+  "description": "Extract entities from text",
+  "arguments": [{"name": "text", "description": "Article text", "required": true}],
+  "template": "Extract entities from {{text}}"
+}`
+
+const testRelationshipPrompt = `{
+  "name": "relationship_analysis",
+  "description": "Analyze relationships",
+  "arguments": [{"name": "context", "description": "Graph context", "required": false}],
+  "template": "Analyze relationships in {{context}}"
+}`
+
+// initTestPromptService points the prompt handlers at a service loading files,
+// given as prompt JSON by file name, until the test ends
+func initTestPromptService(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	previous := promptServiceInstance
+	t.Cleanup(func() { promptServiceInstance = previous })
+	require.NoError(t, InitPromptService(dir))
 }
 
 func TestListPrompts(t *testing.T) {
 	tests := []struct {
-		name           string
-		mockPrompts    map[string]string
-		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+		name  string
+		files map[string]string
+		count int
 	}{
 		{
 			name: "successful prompt listing",
-			mockPrompts: map[string]string{
-				"entity_extraction":     "Extract entities from {{.text}}",
-				"relationship_analysis": "Analyze relationships in {{.context}}",
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Len(t, response["prompts"], 2)
+			files: map[string]string{
+				"entity_extraction.json":     testExtractionPrompt,
+				"relationship_analysis.json": testRelationshipPrompt,
 			},
+			count: 2,
 		},
 		{
-			name:           "empty prompt list",
-			mockPrompts:    map[string]string{},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Empty(t, response["prompts"])
-			},
+			name:  "empty prompt list",
+			files: map[string]string{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := setupTestRouter()
-			mockPromptService := &testutil.MockPromptService{
-				Prompts: tt.mockPrompts,
-			}
-
-			r.GET("/prompts", func(c *gin.Context) {
-				c.Set("promptService", mockPromptService)
-				ListPrompts(c)
-			})
+			initTestPromptService(t, tt.files)
 
-			req := httptest.NewRequest(http.MethodGet, "/prompts", nil)
-			rr := httptest.NewRecorder()
-			r.ServeHTTP(rr, req)
+			rr := perform(t, http.MethodGet, "/prompts", "/prompts", ListPrompts, nil)
 
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			if tt.checkResponse != nil {
-				tt.checkResponse(t, rr)
+			require.Equal(t, http.StatusOK, rr.Code)
+			var response struct {
+				Prompts map[string]interface{} `json:"prompts"`
+				Count   int                    `json:"count"`
 			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Len(t, response.Prompts, tt.count)
+			assert.Equal(t, tt.count, response.Count)
 		})
 	}
 }
 
+func TestPromptHandlers_NotInitialized(t *testing.T) {
+	previous := promptServiceInstance
+	promptServiceInstance = nil
+	t.Cleanup(func() { promptServiceInstance = previous })
+
+	rr := perform(t, http.MethodGet, "/prompts", "/prompts", ListPrompts, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+}
+
 func TestGetPrompt(t *testing.T) {
 	tests := []struct {
 		name           string
 		promptName     string
-		mockPrompts    map[string]string
 		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
-		{
-			name:       "get existing prompt",
-			promptName: "entity_extraction",
-			mockPrompts: map[string]string{
-				"entity_extraction": "Extract entities from {{.text}}",
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response prompts.Prompt
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Equal(t, "entity_extraction", response.Name)
-				assert.Contains(t, response.Template, "Extract entities")
-			},
-		},
-		{
-			name:           "prompt not found",
-			promptName:     "non_existent",
-			mockPrompts:    map[string]string{},
-			expectedStatus: http.StatusNotFound,
-		},
+		{name: "get existing prompt", promptName: "entity_extraction", expectedStatus: http.StatusOK},
+		{name: "prompt not found", promptName: "non_existent", expectedStatus: http.StatusNotFound},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := setupTestRouter()
-			mockPromptService := &testutil.MockPromptService{
-				Prompts: tt.mockPrompts,
-			}
-
-			r.GET("/prompts/:name", func(c *gin.Context) {
-				c.Set("promptService", mockPromptService)
-				GetPrompt(c)
-			})
+			initTestPromptService(t, map[string]string{"entity_extraction.json": testExtractionPrompt})
 
-			req := httptest.NewRequest(http.MethodGet, "/prompts/"+tt.promptName, nil)
-			rr := httptest.NewRecorder()
-			r.ServeHTTP(rr, req)
+			rr := perform(t, http.MethodGet, "/prompts/:name", "/prompts/"+tt.promptName, GetPrompt, nil)
 
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			if tt.checkResponse != nil {
-				tt.checkResponse(t, rr)
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
 			}
+			var response struct {
+				Prompt struct {
+					Name     string `json:"name"`
+					Template string `json:"template"`
+				} `json:"prompt"`
+			}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "entity_extraction", response.Prompt.Name)
+			assert.Contains(t, response.Prompt.Template, "Extract entities")
 		})
 	}
 }
@@ -137,73 +123,43 @@ func TestRenderPrompt(t *testing.T) {
 	tests := []struct {
 		name           string
 		promptName     string
-		requestBody    map[string]interface{}
-		mockPrompts    map[string]string
+		arguments      map[string]interface{}
 		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
-			name:       "successful render",
-			promptName: "entity_extraction",
-			requestBody: map[string]interface{}{
-				"text": "John Doe is CEO of Acme Corp",
-			},
-			mockPrompts: map[string]string{
-				"entity_extraction": "Extract entities from {{.text}}",
-			},
+			name:           "successful render",
+			promptName:     "entity_extraction",
+			arguments:      map[string]interface{}{"text": "John Doe is CEO of Acme Corp"},
 			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.Contains(t, response["rendered"].(string), "John Doe")
-			},
 		},
 		{
-			name:       "missing required variable",
-			promptName: "entity_extraction",
-			requestBody: map[string]interface{}{
-				"wrong_var": "test",
-			},
-			mockPrompts: map[string]string{
-				"entity_extraction": "Extract entities from {{.text}}",
-			},
+			name:           "missing required variable",
+			promptName:     "entity_extraction",
+			arguments:      map[string]interface{}{"wrong_var": "test"},
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
-			name:       "prompt not found",
-			promptName: "non_existent",
-			requestBody: map[string]interface{}{
-				"text": "test",
-			},
-			mockPrompts:    map[string]string{},
-			expectedStatus: http.StatusNotFound,
+			name:           "prompt not found",
+			promptName:     "non_existent",
+			arguments:      map[string]interface{}{"text": "test"},
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := setupTestRouter()
-			mockPromptService := &testutil.MockPromptService{
-				Prompts: tt.mockPrompts,
-			}
-
-			r.POST("/prompts/:name/render", func(c *gin.Context) {
-				c.Set("promptService", mockPromptService)
-				RenderPrompt(c)
-			})
+			initTestPromptService(t, map[string]string{"entity_extraction.json": testExtractionPrompt})
 
-			body, err := json.Marshal(tt.requestBody)
-			require.NoError(t, err)
-			req := httptest.NewRequest(http.MethodPost, "/prompts/"+tt.promptName+"/render", bytes.NewBuffer(body))
-			req.Header.Set("Content-Type", "application/json")
-			rr := httptest.NewRecorder()
-			r.ServeHTTP(rr, req)
+			rr := perform(t, http.MethodPost, "/prompts/:name/render", "/prompts/"+tt.promptName+"/render",
+				RenderPrompt, gin.H{"arguments": tt.arguments})
 
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			if tt.checkResponse != nil {
-				tt.checkResponse(t, rr)
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatus != http.StatusOK {
+				return
 			}
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, "Extract entities from John Doe is CEO of Acme Corp", response["rendered_text"])
 		})
 	}
 }
@@ -211,71 +167,36 @@ func TestRenderPrompt(t *testing.T) {
 func TestValidatePrompt(t *testing.T) {
 	tests := []struct {
 		name           string
-		promptName     string
-		requestBody    map[string]interface{}
-		mockPrompts    map[string]string
+		arguments      map[string]interface{}
 		expectedStatus int
-		checkResponse  func(*testing.T, *httptest.ResponseRecorder)
+		valid          bool
 	}{
 		{
-			name:       "valid arguments",
-			promptName: "entity_extraction",
-			requestBody: map[string]interface{}{
-				"text": "test content",
-			},
-			mockPrompts: map[string]string{
-				"entity_extraction": "Extract entities from {{.text}}",
-			},
+			name:           "valid arguments",
+			arguments:      map[string]interface{}{"text": "test content"},
 			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.True(t, response["valid"].(bool))
-			},
+			valid:          true,
 		},
 		{
-			name:       "missing required argument",
-			promptName: "entity_extraction",
-			requestBody: map[string]interface{}{
-				"wrong_var": "test",
-			},
-			mockPrompts: map[string]string{
-				"entity_extraction": "Extract entities from {{.text}}",
-			},
-			expectedStatus: http.StatusOK,
-			checkResponse: func(t *testing.T, rr *httptest.ResponseRecorder) {
-				var response map[string]interface{}
-				err := json.Unmarshal(rr.Body.Bytes(), &response)
-				require.NoError(t, err)
-				assert.False(t, response["valid"].(bool))
-				assert.Contains(t, response["errors"], "text")
-			},
+			name:           "missing required argument",
+			arguments:      map[string]interface{}{"wrong_var": "test"},
+			expectedStatus: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := setupTestRouter()
-			mockPromptService := &testutil.MockPromptService{
-				Prompts: tt.mockPrompts,
-			}
-
-			r.POST("/prompts/:name/validate", func(c *gin.Context) {
-				c.Set("promptService", mockPromptService)
-				ValidatePrompt(c)
-			})
+			initTestPromptService(t, map[string]string{"entity_extraction.json": testExtractionPrompt})
 
-			body, err := json.Marshal(tt.requestBody)
-			require.NoError(t, err)
-			req := httptest.NewRequest(http.MethodPost, "/prompts/"+tt.promptName+"/validate", bytes.NewBuffer(body))
-			req.Header.Set("Content-Type", "application/json")
-			rr := httptest.NewRecorder()
-			r.ServeHTTP(rr, req)
+			rr := perform(t, http.MethodPost, "/prompts/:name/validate", "/prompts/entity_extraction/validate",
+				ValidatePrompt, gin.H{"arguments": tt.arguments})
 
-			assert.Equal(t, tt.expectedStatus, rr.Code)
-			if tt.checkResponse != nil {
-				tt.checkResponse(t, rr)
+			require.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.valid, response["valid"])
+			if !tt.valid {
+				assert.Equal(t, "text", response["field"])
 			}
 		})
 	}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"clank/internal/db"
+	"clank/internal/llm"
+	"clank/internal/logging"
+	"clank/internal/metrics"
+	"clank/internal/models"
+	"clank/internal/prompts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxExtraPromptLength is the longest extraPrompt accepted, in characters
+const maxExtraPromptLength = 4000
+
+// HandleReextraction runs extraction again on a stored article's content without
+// scraping it, e.g. to compare a prompt change on articles already analyzed.
+// extraPrompt is appended to every prompt of the run, and promptVersions renders
+// the prompt templates it names in a retained version instead of the active
+// one (see GET /api/prompts/:name/versions). It accepts the options of
+// HandleURLExtraction; the stored analysis is always redone, and deep mode starts
+// a new session. With integrate set to false (fast mode only) nothing is saved.
+func (h *ExtractionGinHandler) HandleReextraction(c *gin.Context) {
+	var body struct {
+		ExtraPrompt    string         `json:"extraPrompt,omitempty"`    // Instructions appended to every prompt, e.g. "Only extract payments above $10,000"
+		PromptVersions map[string]int `json:"promptVersions,omitempty"` // Prompt template versions to render by name, e.g. {"surface_extraction": 2}
+		Integrate      *bool          `json:"integrate,omitempty"`      // Save the new extraction to the graph (default true)
+
+		extractionOptions
+	}
+
+	logger := logging.For(c.Request.Context(), "extraction")
+	// Every field is optional, so an empty body re-runs with the defaults
+	if err := c.ShouldBindJSON(&body); err != nil && !errors.Is(err, io.EOF) {
+		logger.Warn("Invalid request body", "error", err)
+		c.JSON(400, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req, err := h.validateOptions(body.extractionOptions)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.submitted("", body.Integrate); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if n := utf8.RuneCountInString(body.ExtraPrompt); n > maxExtraPromptLength {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("extraPrompt must be at most %d characters (got %d)", maxExtraPromptLength, n)})
+		return
+	}
+	if err := h.checkPromptVersions(body.PromptVersions); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	req.Force = true
+	req.stored = true
+
+	stored, err := h.db.GetArticleByID(c.Param("id"))
+	if errors.Is(err, db.ErrNotFound) {
+		c.JSON(404, gin.H{"error": "Article not found"})
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to load article", "article_id", c.Param("id"), "error", err)
+		c.JSON(500, gin.H{"error": "Failed to load article: " + err.Error()})
+		return
+	}
+	if strings.TrimSpace(stored.Content) == "" {
+		c.JSON(422, gin.H{"error": "Stored article has no content to extract from"})
+		return
+	}
+	defer func() {
+		metrics.Extractions.Inc(req.Mode, metrics.HTTPStatus(c.Writer.Status()))
+	}()
+
+	// The run replaces the stored extraction; the stored article is left as is
+	// until the new one is saved
+	article := *stored
+	article.Metadata = maps.Clone(stored.Metadata)
+	article.Entities = nil
+	article.Relations = nil
+
+	if body.ExtraPrompt != "" {
+		c.Request = c.Request.WithContext(llm.WithExtraInstructions(c.Request.Context(), body.ExtraPrompt))
+	}
+	if len(body.PromptVersions) > 0 {
+		c.Request = c.Request.WithContext(prompts.WithVersions(c.Request.Context(), body.PromptVersions))
+	}
+	logger.Info("Re-extracting stored article", "article_id", article.ID, "mode", req.Mode,
+		"extra_prompt", body.ExtraPrompt != "", "prompt_versions", body.PromptVersions)
+
+	h.extract(c, req, &article)
+}
+
+// checkPromptVersions rejects prompt versions the extraction prompts don't
+// retain, naming the first one in prompt name order
+func (h *ExtractionGinHandler) checkPromptVersions(pinned map[string]int) error {
+	if len(pinned) == 0 {
+		return nil
+	}
+	if h.promptLoader == nil {
+		return errors.New("promptVersions can't be used: the prompt templates aren't loaded")
+	}
+	for _, name := range slices.Sorted(maps.Keys(pinned)) {
+		versions, err := h.promptLoader.GetPromptVersions(name)
+		if err != nil {
+			return fmt.Errorf("promptVersions: %w", err)
+		}
+		version := pinned[name]
+		if !slices.ContainsFunc(versions, func(v models.PromptVersion) bool { return v.Version == version }) {
+			return fmt.Errorf("promptVersions: version %d of prompt %s is not retained", version, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"clank/internal/db"
+	"clank/internal/models"
+	"clank/internal/prompts"
+	"clank/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// articleRecordingExtractor records the article it was asked to extract from
+type articleRecordingExtractor struct {
+	recordingExtractor
+	article models.Article
+}
+
+func (e *articleRecordingExtractor) ProcessArticle(ctx context.Context, article *models.Article) (*models.ExtractionResult, error) {
+	e.article = *article
+	return e.recordingExtractor.ProcessArticle(ctx, article)
+}
+
+func performReextraction(t *testing.T, h *ExtractionGinHandler, id string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, http.MethodPost, "/api/articles/:id/reextract", "/api/articles/"+id+"/reextract", h.HandleReextraction, body)
+}
+
+// newVersionedPromptLoader returns a loader that loaded the prompt name once
+// with each of templates, so it retains a version of each
+func newVersionedPromptLoader(t *testing.T, name string, templates ...string) *prompts.PromptLoader {
+	t.Helper()
+	dir := t.TempDir()
+	loader := prompts.NewPromptLoader(dir)
+	path := filepath.Join(dir, name+".json")
+	for _, tmpl := range templates {
+		content, err := json.Marshal(gin.H{"name": name, "description": "test prompt", "template": tmpl})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, content, 0o644))
+		require.NoError(t, loader.LoadPromptFile(path))
+	}
+	return loader
+}
+
+func TestExtractionGinHandler_Reextraction(t *testing.T) {
+	const content = "The city council awarded the bridge contract to Acme Corp weeks after " +
+		"its chief executive donated to Mayor Jane Doe's re-election campaign."
+	newHandler := func() (*ExtractionGinHandler, *db.MemoryStore) {
+		store := db.NewMemoryStore()
+		store.Articles["article-1"] = &models.Article{
+			ID:       "article-1",
+			Title:    "Bridge deal",
+			URL:      "https://news.example.com/bridge",
+			Source:   "news.example.com",
+			Content:  content,
+			Metadata: map[string]interface{}{"sessionId": "session-0"},
+			Entities: []*models.ExtractedEntity{{ID: "old", Name: "Old entity"}},
+		}
+		h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+		h.scraper = &testutil.MockBrowserAutomation{ScrapeErr: assert.AnError} // Never used
+		return h, store
+	}
+
+	t.Run("extracts from the stored content", func(t *testing.T) {
+		h, store := newHandler()
+		extractor := &articleRecordingExtractor{recordingExtractor: recordingExtractor{result: &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9}},
+		}}}
+		h.extractor = extractor
+
+		rr := performReextraction(t, h, "article-1", gin.H{"mode": "fast", "extraPrompt": "Only extract people."})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, extractor.calls)
+		assert.Equal(t, content, extractor.article.Content)
+		assert.Empty(t, extractor.article.Entities, "the previous extraction isn't passed on")
+
+		saved := store.Articles["article-1"]
+		require.Len(t, store.Articles, 1, "the stored article is refreshed, not duplicated")
+		require.Len(t, saved.Entities, 1)
+		assert.Equal(t, "Jane Doe", saved.Entities[0].Name)
+	})
+
+	t.Run("deep mode starts a new session", func(t *testing.T) {
+		h, _ := newHandler()
+		analyzer := &recordingAnalyzer{}
+		h.analysisController = analyzer
+
+		rr := performReextraction(t, h, "article-1", gin.H{"mode": "deep"})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		assert.Equal(t, 1, analyzer.calls)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		assert.Equal(t, "session-1", resp["sessionId"])
+		assert.Equal(t, "article-1", resp["articleId"])
+	})
+
+	t.Run("renders the requested prompt versions", func(t *testing.T) {
+		h, _ := newHandler()
+		analyzer := &recordingAnalyzer{}
+		h.analysisController = analyzer
+		h.promptLoader = newVersionedPromptLoader(t, "surface_extraction", "first", "second")
+
+		rr := performReextraction(t, h, "article-1", gin.H{"mode": "deep", "promptVersions": gin.H{"surface_extraction": 1}})
+
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		require.NotNil(t, analyzer.ctx)
+		assert.Equal(t, 1, prompts.PinnedVersion(analyzer.ctx, "surface_extraction"))
+
+		for name, versions := range map[string]gin.H{
+			"unretained version": {"surface_extraction": 3},
+			"unknown prompt":     {"hypothesis": 1},
+		} {
+			rr := performReextraction(t, h, "article-1", gin.H{"mode": "deep", "promptVersions": versions})
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+		assert.Equal(t, 1, analyzer.calls)
+	})
+
+	t.Run("unknown article", func(t *testing.T) {
+		h, _ := newHandler()
+		rr := performReextraction(t, h, "missing", gin.H{})
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		h, _ := newHandler()
+		extractor := &recordingExtractor{}
+		h.extractor = extractor
+
+		for name, body := range map[string]gin.H{
+			"deep without integrate": {"mode": "deep", "integrate": false},
+			"invalid mode":           {"mode": "thorough"},
+			"long extra prompt":      {"extraPrompt": string(bytes.Repeat([]byte("a"), maxExtraPromptLength+1))},
+			"built-in prompts":       {"promptVersions": gin.H{"surface_extraction": 1}},
+		} {
+			rr := performReextraction(t, h, "article-1", body)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, name)
+		}
+		assert.Zero(t, extractor.calls)
+	})
+}
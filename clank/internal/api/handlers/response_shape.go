@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"clank/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Extraction response verbosities
+const (
+	verbosityVerbose = "verbose" // Every section (the default)
+	verbosityCompact = "compact" // The envelope and extraction without the optional sections
+	verbosityFlat    = "flat"    // Only the article ID and the entities, relationships and events
+)
+
+// Optional sections of an extraction response, selectable with "fields"
+const (
+	fieldContent  = "content"  // The article text
+	fieldDebug    = "debug"    // Detected language and other diagnostics
+	fieldMentions = "mentions" // Where each entity is mentioned in the article
+	fieldEvidence = "evidence" // The quote supporting each relationship
+)
+
+var responseFields = []string{fieldContent, fieldDebug, fieldMentions, fieldEvidence}
+
+// responseShape selects what an extraction response includes
+type responseShape struct {
+	flat    bool
+	include map[string]bool
+}
+
+// parseResponseShape reads the verbosity and fields request options. Fields, when
+// given, replace the optional sections the verbosity would include.
+func parseResponseShape(verbosity string, fields []string) (responseShape, error) {
+	shape := responseShape{include: make(map[string]bool)}
+	switch verbosity {
+	case "", verbosityVerbose:
+		for _, field := range responseFields {
+			shape.include[field] = true
+		}
+	case verbosityCompact:
+	case verbosityFlat:
+		shape.flat = true
+	default:
+		return shape, fmt.Errorf("verbosity must be %s, %s or %s", verbosityVerbose, verbosityCompact, verbosityFlat)
+	}
+
+	if len(fields) > 0 {
+		shape.include = make(map[string]bool)
+		for _, field := range fields {
+			field = strings.ToLower(strings.TrimSpace(field))
+			if !isResponseField(field) {
+				return shape, fmt.Errorf("unknown field %q (expected %s)", field, strings.Join(responseFields, ", "))
+			}
+			shape.include[field] = true
+		}
+	}
+	return shape, nil
+}
+
+func isResponseField(field string) bool {
+	for _, known := range responseFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// entityView is an extracted entity as returned to clients, with mentions only
+// when they were asked for
+type entityView struct {
+	models.ExtractedEntity
+	Mentions []models.EntityMention `json:"mentions,omitempty"`
+}
+
+// relationshipView is an extracted relationship as returned to clients, with its
+// supporting quote only when evidence was asked for
+type relationshipView struct {
+	models.ExtractedRelationship
+	Context string `json:"context,omitempty"`
+}
+
+// extractionView is an extraction result with its entities and relationships
+// trimmed to the response shape
+type extractionView struct {
+	*models.ExtractionResult
+	Entities      []entityView       `json:"entities"`
+	Relationships []relationshipView `json:"relationships"`
+}
+
+// flatResponse is the whole response in flat verbosity. Events are the extracted
+// entities of type event, listed apart from the other entities.
+type flatResponse struct {
+	ArticleID     string             `json:"articleId"`
+	SessionID     string             `json:"sessionId,omitempty"`
+	Entities      []entityView       `json:"entities"`
+	Relationships []relationshipView `json:"relationships"`
+	Events        []entityView       `json:"events"`
+}
+
+// apply trims response to the shape. Sections left out are never marshaled.
+func (s responseShape) apply(response gin.H) interface{} {
+	result, _ := response["extraction"].(*models.ExtractionResult)
+	if s.flat {
+		return s.flatten(response, result)
+	}
+
+	for _, field := range []string{fieldContent, fieldDebug} {
+		if !s.include[field] {
+			delete(response, field)
+		}
+	}
+	if result != nil {
+		response["extraction"] = extractionView{
+			ExtractionResult: result,
+			Entities:         s.entities(result.Entities),
+			Relationships:    s.relationships(result.Relationships),
+		}
+	}
+	return response
+}
+
+func (s responseShape) flatten(response gin.H, result *models.ExtractionResult) flatResponse {
+	flat := flatResponse{
+		Entities:      []entityView{},
+		Relationships: []relationshipView{},
+		Events:        []entityView{},
+	}
+	flat.ArticleID, _ = response["articleId"].(string)
+	flat.SessionID, _ = response["sessionId"].(string)
+	if result == nil {
+		return flat
+	}
+	for _, entity := range s.entities(result.Entities) {
+		if strings.EqualFold(entity.Type, "event") {
+			flat.Events = append(flat.Events, entity)
+		} else {
+			flat.Entities = append(flat.Entities, entity)
+		}
+	}
+	flat.Relationships = s.relationships(result.Relationships)
+	return flat
+}
+
+func (s responseShape) entities(entities []models.ExtractedEntity) []entityView {
+	views := make([]entityView, len(entities))
+	for i, entity := range entities {
+		views[i].ExtractedEntity = entity
+		if s.include[fieldMentions] {
+			views[i].Mentions = entity.Mentions
+		}
+	}
+	return views
+}
+
+func (s responseShape) relationships(relationships []models.ExtractedRelationship) []relationshipView {
+	views := make([]relationshipView, len(relationships))
+	for i, rel := range relationships {
+		views[i].ExtractedRelationship = rel
+		if s.include[fieldEvidence] {
+			views[i].Context = rel.Context
+		}
+	}
+	return views
+}
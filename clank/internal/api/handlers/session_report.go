@@ -0,0 +1,395 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"clank/internal/llm/sequential"
+	"clank/internal/logging"
+	"clank/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+)
+
+// Report formats served by HandleSessionReport
+const (
+	reportFormatMarkdown = "md"
+	reportFormatPDF      = "pdf"
+)
+
+// SessionLookup defines the interface for reading analysis sessions
+type SessionLookup interface {
+	GetSession(sessionID string) (*sequential.AnalysisSession, error)
+}
+
+// sessionReport is the content of a session report, shared by every output format
+type sessionReport struct {
+	Title         string
+	SourceURLs    []string
+	SessionID     string
+	Status        string
+	StartedAt     time.Time
+	CompletedAt   *time.Time
+	Entities      []models.ExtractedEntity
+	Relationships []reportRelationship
+	Insights      []reportInsight
+	Hypotheses    []sequential.Hypothesis
+	Chains        []reportChain
+}
+
+type reportRelationship struct {
+	From, Type, To string
+	Confidence     float64
+	Context        string
+}
+
+type reportInsight struct {
+	Stage string
+	Text  string
+}
+
+type reportChain struct {
+	Claim      string
+	Strength   float64
+	Quotes     []string
+	SourceURLs []string
+}
+
+// HandleSessionReport renders an analysis session as a report for readers outside
+// the tool: GET /api/extraction/report?sessionId=...&format=md|pdf
+func (h *ExtractionGinHandler) HandleSessionReport(c *gin.Context) {
+	sessionID := c.Query("sessionId")
+	if sessionID == "" {
+		c.JSON(400, gin.H{"error": "sessionId is required"})
+		return
+	}
+	format := c.DefaultQuery("format", reportFormatMarkdown)
+	if format != reportFormatMarkdown && format != reportFormatPDF {
+		c.JSON(400, gin.H{"error": "format must be md or pdf"})
+		return
+	}
+	if h.sessions == nil {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+
+	session, err := h.sessions.GetSession(sessionID)
+	if errors.Is(err, sequential.ErrSessionNotFound) {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The article only adds a title and URL; a session outliving it still renders
+	article, err := h.db.GetArticleByID(session.ArticleID)
+	if err != nil {
+		logging.For(c.Request.Context(), "report").Warn("Failed to load article", "article_id", session.ArticleID, "error", err)
+		article = nil
+	}
+	report := buildSessionReport(session, article)
+
+	filename := "analysis-" + session.ID + "." + format
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	if format == reportFormatMarkdown {
+		c.Data(200, "text/markdown; charset=utf-8", []byte(renderReportMarkdown(report)))
+		return
+	}
+
+	pdf, err := renderReportPDF(report)
+	if err != nil {
+		c.Header("Content-Disposition", "")
+		c.JSON(500, gin.H{"error": "Failed to render report: " + err.Error()})
+		return
+	}
+	c.Data(200, "application/pdf", pdf)
+}
+
+// buildSessionReport collects the session's final findings. Entities and
+// relationships found by several stages are listed once with their highest confidence.
+func buildSessionReport(session *sequential.AnalysisSession, article *models.Article) *sessionReport {
+	report := &sessionReport{
+		Title:       "Analysis " + session.ID,
+		SessionID:   session.ID,
+		Status:      session.Status,
+		StartedAt:   session.StartedAt,
+		CompletedAt: session.CompletedAt,
+		Hypotheses:  session.Hypotheses,
+	}
+	if article != nil {
+		if article.Title != "" {
+			report.Title = article.Title
+		}
+		report.SourceURLs = appendUnique(report.SourceURLs, article.URL)
+	}
+
+	entities := make(map[string]int)
+	relationships := make(map[string]int)
+	var rels []models.ExtractedRelationship
+	for _, result := range session.Results {
+		if result == nil {
+			continue
+		}
+		for _, entity := range result.Entities {
+			key := entity.ID
+			if key == "" {
+				key = strings.ToLower(entity.Type + "|" + entity.Name)
+			}
+			if i, ok := entities[key]; ok {
+				if entity.Confidence > report.Entities[i].Confidence {
+					report.Entities[i].Confidence = entity.Confidence
+				}
+				continue
+			}
+			entities[key] = len(report.Entities)
+			report.Entities = append(report.Entities, entity)
+		}
+		for _, rel := range result.Relationships {
+			key := strings.ToUpper(rel.Type) + "|" + rel.FromID + "|" + rel.ToID
+			if i, ok := relationships[key]; ok {
+				if rel.Confidence > rels[i].Confidence {
+					rels[i].Confidence = rel.Confidence
+				}
+				continue
+			}
+			relationships[key] = len(rels)
+			rels = append(rels, rel)
+		}
+	}
+	sort.SliceStable(report.Entities, func(i, j int) bool {
+		return report.Entities[i].Confidence > report.Entities[j].Confidence
+	})
+
+	names := make(map[string]string, len(report.Entities))
+	for _, entity := range report.Entities {
+		names[entity.ID] = entity.Name
+	}
+	entityName := func(id string) string {
+		if name, ok := names[id]; ok && name != "" {
+			return name
+		}
+		return id
+	}
+	for _, rel := range rels {
+		report.Relationships = append(report.Relationships, reportRelationship{
+			From:       entityName(rel.FromID),
+			Type:       rel.Type,
+			To:         entityName(rel.ToID),
+			Confidence: rel.Confidence,
+			Context:    rel.Context,
+		})
+	}
+
+	for _, stage := range session.Stages {
+		for _, insight := range stage.Insights {
+			report.Insights = append(report.Insights, reportInsight{Stage: stage.Name, Text: insight})
+		}
+	}
+
+	evidence := make(map[string]string, len(session.Evidence))
+	for _, e := range session.Evidence {
+		evidence[e.ID] = e.Text
+	}
+	for _, chain := range session.EvidenceChains {
+		rc := reportChain{Claim: chain.Claim, Strength: chain.Strength, SourceURLs: chain.SourceURLs}
+		for _, id := range chain.Evidence {
+			if text, ok := evidence[id]; ok {
+				rc.Quotes = append(rc.Quotes, text)
+			}
+		}
+		report.Chains = append(report.Chains, rc)
+		for _, url := range chain.SourceURLs {
+			report.SourceURLs = appendUnique(report.SourceURLs, url)
+		}
+	}
+
+	return report
+}
+
+// renderReportMarkdown renders the report as a Markdown document
+func renderReportMarkdown(report *sessionReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", report.Title)
+	fmt.Fprintf(&b, "- **Session:** %s\n", report.SessionID)
+	fmt.Fprintf(&b, "- **Status:** %s\n", report.Status)
+	fmt.Fprintf(&b, "- **Started:** %s\n", report.StartedAt.UTC().Format(time.RFC3339))
+	if report.CompletedAt != nil {
+		fmt.Fprintf(&b, "- **Completed:** %s\n", report.CompletedAt.UTC().Format(time.RFC3339))
+	}
+	for _, url := range report.SourceURLs {
+		fmt.Fprintf(&b, "- **Source:** <%s>\n", url)
+	}
+
+	b.WriteString("\n## Entities\n\n")
+	if len(report.Entities) == 0 {
+		b.WriteString("No entities were found.\n")
+	} else {
+		b.WriteString("| Name | Type | Confidence |\n|---|---|---|\n")
+		for _, entity := range report.Entities {
+			fmt.Fprintf(&b, "| %s | %s | %.2f |\n", markdownCell(entity.Name), markdownCell(entity.Type), entity.Confidence)
+		}
+	}
+
+	b.WriteString("\n## Relationships\n\n")
+	if len(report.Relationships) == 0 {
+		b.WriteString("No relationships were found.\n")
+	} else {
+		b.WriteString("| From | Relationship | To | Confidence | Context |\n|---|---|---|---|---|\n")
+		for _, rel := range report.Relationships {
+			fmt.Fprintf(&b, "| %s | %s | %s | %.2f | %s |\n",
+				markdownCell(rel.From), markdownCell(rel.Type), markdownCell(rel.To), rel.Confidence, markdownCell(rel.Context))
+		}
+	}
+
+	b.WriteString("\n## Key Insights\n\n")
+	if len(report.Insights) == 0 {
+		b.WriteString("No insights were recorded.\n")
+	}
+	for _, insight := range report.Insights {
+		fmt.Fprintf(&b, "- %s _(%s)_\n", insight.Text, insight.Stage)
+	}
+
+	b.WriteString("\n## Hypotheses\n\n")
+	if len(report.Hypotheses) == 0 {
+		b.WriteString("No hypotheses were generated.\n")
+	}
+	for _, hypothesis := range report.Hypotheses {
+		fmt.Fprintf(&b, "- %s (confidence %.2f, %s)\n", hypothesis.Description, hypothesis.Confidence, hypothesis.Status)
+	}
+
+	b.WriteString("\n## Evidence Chains\n\n")
+	if len(report.Chains) == 0 {
+		b.WriteString("No evidence chains were built.\n")
+	}
+	for _, chain := range report.Chains {
+		fmt.Fprintf(&b, "### %s\n\nStrength: %.2f\n\n", chain.Claim, chain.Strength)
+		for _, quote := range chain.Quotes {
+			fmt.Fprintf(&b, "> %s\n\n", quote)
+		}
+		for _, url := range chain.SourceURLs {
+			fmt.Fprintf(&b, "- Source: <%s>\n", url)
+		}
+		if len(chain.SourceURLs) > 0 {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String()
+}
+
+// markdownCell keeps a value on one table row and escapes column separators
+func markdownCell(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	return strings.ReplaceAll(value, "|", `\|`)
+}
+
+// renderReportPDF renders the report as a PDF with the same sections as the Markdown
+func renderReportPDF(report *sessionReport) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(report.Title, true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	// The core fonts cover Latin-1; tr maps UTF-8 text onto them
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	heading := func(size float64, text string) {
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "B", size)
+		pdf.MultiCell(0, size*0.5, tr(text), "", "L", false)
+		pdf.Ln(1)
+	}
+	line := func(text string) {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.MultiCell(0, 5, tr(text), "", "L", false)
+	}
+	empty := func(count int, text string) bool {
+		if count == 0 {
+			line(text)
+			return true
+		}
+		return false
+	}
+
+	heading(18, report.Title)
+	line("Session: " + report.SessionID)
+	line("Status: " + report.Status)
+	line("Started: " + report.StartedAt.UTC().Format(time.RFC3339))
+	if report.CompletedAt != nil {
+		line("Completed: " + report.CompletedAt.UTC().Format(time.RFC3339))
+	}
+	for _, url := range report.SourceURLs {
+		line("Source: " + url)
+	}
+
+	heading(14, "Entities")
+	if !empty(len(report.Entities), "No entities were found.") {
+		for _, entity := range report.Entities {
+			line(fmt.Sprintf("- %s (%s), confidence %.2f", entity.Name, entity.Type, entity.Confidence))
+		}
+	}
+
+	heading(14, "Relationships")
+	if !empty(len(report.Relationships), "No relationships were found.") {
+		for _, rel := range report.Relationships {
+			text := fmt.Sprintf("- %s %s %s, confidence %.2f", rel.From, rel.Type, rel.To, rel.Confidence)
+			if rel.Context != "" {
+				text += ": " + rel.Context
+			}
+			line(text)
+		}
+	}
+
+	heading(14, "Key Insights")
+	if !empty(len(report.Insights), "No insights were recorded.") {
+		for _, insight := range report.Insights {
+			line(fmt.Sprintf("- %s (%s)", insight.Text, insight.Stage))
+		}
+	}
+
+	heading(14, "Hypotheses")
+	if !empty(len(report.Hypotheses), "No hypotheses were generated.") {
+		for _, hypothesis := range report.Hypotheses {
+			line(fmt.Sprintf("- %s (confidence %.2f, %s)", hypothesis.Description, hypothesis.Confidence, hypothesis.Status))
+		}
+	}
+
+	heading(14, "Evidence Chains")
+	if !empty(len(report.Chains), "No evidence chains were built.") {
+		for _, chain := range report.Chains {
+			heading(11, fmt.Sprintf("%s (strength %.2f)", chain.Claim, chain.Strength))
+			for _, quote := range chain.Quotes {
+				line(`"` + quote + `"`)
+			}
+			for _, url := range chain.SourceURLs {
+				line("Source: " + url)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// appendUnique appends value unless it is empty or already present
+func appendUnique(values []string, value string) []string {
+	if value == "" {
+		return values
+	}
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"clank/internal/db"
+	"clank/internal/llm/sequential"
+	"clank/internal/models"
+	"clank/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sessionMap is a SessionLookup over fixed sessions
+type sessionMap map[string]*sequential.AnalysisSession
+
+func (m sessionMap) GetSession(sessionID string) (*sequential.AnalysisSession, error) {
+	if session, ok := m[sessionID]; ok {
+		return session, nil
+	}
+	return nil, sequential.ErrSessionNotFound
+}
+
+func completedReportSession() *sequential.AnalysisSession {
+	started := time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)
+	completed := started.Add(3 * time.Minute)
+	return &sequential.AnalysisSession{
+		ID:          "session-42",
+		ArticleID:   "article-1",
+		Status:      "completed",
+		StartedAt:   started,
+		CompletedAt: &completed,
+		Stages: []*sequential.AnalysisStage{
+			{Stage: 1, Name: sequential.StageSurfaceExtraction, Insights: []string{"The mayor's brother owns Acme Paving"}},
+			{Stage: 2, Name: sequential.StageDeepAnalysis, Insights: []string{"The contract skipped the tender process"}},
+		},
+		Results: []*models.ExtractionResult{
+			{
+				Entities: []models.ExtractedEntity{
+					{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.8},
+					{ID: "e2", Type: "organization", Name: "Acme Paving", Confidence: 0.7},
+				},
+				Relationships: []models.ExtractedRelationship{
+					{Type: "AWARDED_CONTRACT", FromID: "e1", ToID: "e2", Confidence: 0.6, Context: "signed the | paving deal"},
+				},
+			},
+			{
+				// A later stage confirms an entity with higher confidence
+				Entities: []models.ExtractedEntity{{ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.95}},
+			},
+		},
+		Hypotheses: []sequential.Hypothesis{
+			{Description: "The award was steered to a relative", Confidence: 0.75, Status: "supported"},
+		},
+		Evidence: []sequential.Evidence{{ID: "ev1", Text: "Doe signed the award the day bids closed"}},
+		EvidenceChains: []*sequential.EvidenceChain{{
+			Claim:      "Contract steered to the mayor's brother",
+			Evidence:   []string{"ev1"},
+			SourceURLs: []string{"https://news.example.com/paving", "https://other.example.org/copy"},
+			Strength:   0.82,
+		}},
+	}
+}
+
+func performReport(t *testing.T, h *ExtractionGinHandler, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, http.MethodGet, "/api/extraction/report", "/api/extraction/report?"+query, h.HandleSessionReport, nil)
+}
+
+func newReportHandler() *ExtractionGinHandler {
+	store := db.NewMemoryStore()
+	store.Articles["article-1"] = &models.Article{ID: "article-1", Title: "Paving contract questions", URL: "https://news.example.com/paving"}
+	h := newTestExtractionGinHandler(testutil.NewMockLLMClient(), store, false)
+	h.sessions = sessionMap{"session-42": completedReportSession()}
+	return h
+}
+
+func TestHandleSessionReport_Markdown(t *testing.T) {
+	rr := performReport(t, newReportHandler(), "sessionId=session-42&format=md")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/markdown; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "analysis-session-42.md")
+
+	report := rr.Body.String()
+	assert.True(t, strings.HasPrefix(report, "# Paving contract questions\n"))
+	for _, section := range []string{"## Entities", "## Relationships", "## Key Insights", "## Hypotheses", "## Evidence Chains"} {
+		assert.Contains(t, report, section)
+	}
+	assert.Contains(t, report, "| Jane Doe | person | 0.95 |")
+	assert.Contains(t, report, "| Acme Paving | organization | 0.70 |")
+	assert.Equal(t, 1, strings.Count(report, "| Jane Doe | person"), "entities found by several stages are listed once")
+	assert.Contains(t, report, `| Jane Doe | AWARDED_CONTRACT | Acme Paving | 0.60 | signed the \| paving deal |`)
+	assert.Contains(t, report, "- The contract skipped the tender process _(deep_analysis)_")
+	assert.Contains(t, report, "- The award was steered to a relative (confidence 0.75, supported)")
+	assert.Contains(t, report, "### Contract steered to the mayor's brother\n\nStrength: 0.82")
+	assert.Contains(t, report, "> Doe signed the award the day bids closed")
+	assert.Contains(t, report, "- **Source:** <https://news.example.com/paving>")
+	assert.Contains(t, report, "- **Source:** <https://other.example.org/copy>")
+}
+
+func TestHandleSessionReport_PDF(t *testing.T) {
+	rr := performReport(t, newReportHandler(), "sessionId=session-42&format=pdf")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "%PDF-"))
+}
+
+func TestHandleSessionReport_Errors(t *testing.T) {
+	h := newReportHandler()
+	assert.Equal(t, http.StatusBadRequest, performReport(t, h, "format=md").Code)
+	assert.Equal(t, http.StatusBadRequest, performReport(t, h, "sessionId=session-42&format=docx").Code)
+	assert.Equal(t, http.StatusNotFound, performReport(t, h, "sessionId=missing").Code)
+}
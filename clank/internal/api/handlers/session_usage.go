@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"errors"
+
+	"clank/internal/llm/sequential"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleSessionUsage returns the tokens an analysis session used per stage and in
+// total, with cost estimates when token prices are configured:
+// GET /api/extraction/usage?sessionId=...
+func (h *ExtractionGinHandler) HandleSessionUsage(c *gin.Context) {
+	sessionID := c.Query("sessionId")
+	if sessionID == "" {
+		c.JSON(400, gin.H{"error": "sessionId is required"})
+		return
+	}
+	if h.sessions == nil {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+
+	session, err := h.sessions.GetSession(sessionID)
+	if errors.Is(err, sequential.ErrSessionNotFound) {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, sequential.SummarizeUsage(session, h.pricing))
+}
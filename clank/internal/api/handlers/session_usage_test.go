@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/internal/llm"
+	"clank/internal/llm/sequential"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func performUsage(t *testing.T, h *ExtractionGinHandler, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	return perform(t, http.MethodGet, "/api/extraction/usage", "/api/extraction/usage?"+query, h.HandleSessionUsage, nil)
+}
+
+func TestHandleSessionUsage(t *testing.T) {
+	session := completedReportSession()
+	session.Stages[0].Usage = &llm.Usage{PromptTokens: 1000, CompletionTokens: 200, TotalTokens: 1200}
+	session.Stages[1].Usage = &llm.Usage{PromptTokens: 2000, CompletionTokens: 400, TotalTokens: 2400}
+	session.Usage = llm.Usage{PromptTokens: 3000, CompletionTokens: 600, TotalTokens: 3600}
+
+	h := newReportHandler()
+	h.sessions = sessionMap{session.ID: session}
+	h.pricing = sequential.Pricing{PromptPer1K: 0.01, CompletionPer1K: 0.03}
+
+	rr := performUsage(t, h, "sessionId="+session.ID)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var summary sequential.UsageSummary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &summary))
+	assert.Equal(t, 3600, summary.Total.TotalTokens)
+	require.Len(t, summary.Stages, 2)
+	assert.Equal(t, 1200, summary.Stages[0].Usage.TotalTokens)
+	require.NotNil(t, summary.EstimatedCost)
+	assert.InDelta(t, 0.03+0.018, *summary.EstimatedCost, 1e-9)
+}
+
+func TestHandleSessionUsage_Errors(t *testing.T) {
+	h := newReportHandler()
+	assert.Equal(t, http.StatusBadRequest, performUsage(t, h, "").Code)
+	assert.Equal(t, http.StatusNotFound, performUsage(t, h, "sessionId=missing").Code)
+}
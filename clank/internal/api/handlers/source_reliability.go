@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"strings"
+
+	"clank/internal/logging"
+	"clank/internal/models"
+)
+
+// sourceReliabilityProperty records on articles, entities and relationships the
+// weight their confidence was scaled by
+const sourceReliabilityProperty = "source_reliability"
+
+// sourceReliability weights what is extracted from an article by how reliable its
+// source is, so a court filing counts for more than a tabloid. The zero value
+// weights every source 1 and changes nothing.
+type sourceReliability struct {
+	weights  map[string]float64 // by lower-case domain
+	fallback float64            // for unlisted domains; 0 means 1
+}
+
+// newSourceReliability builds the weighting from the configured domain weights and
+// the default for other domains. Weights outside 0..1 are ignored.
+func newSourceReliability(weights map[string]float64, fallback float64) sourceReliability {
+	r := sourceReliability{weights: make(map[string]float64, len(weights))}
+	for domain, weight := range weights {
+		if weight < 0 || weight > 1 {
+			logging.For(context.Background(), "extraction").Warn("Ignoring source reliability: it must be between 0 and 1", "domain", domain, "weight", weight)
+			continue
+		}
+		r.weights[strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")] = weight
+	}
+	if fallback < 0 || fallback > 1 {
+		logging.For(context.Background(), "extraction").Warn("Ignoring default source reliability: it must be between 0 and 1", "weight", fallback)
+		fallback = 0
+	}
+	r.fallback = fallback
+	return r
+}
+
+// articleDomain returns the host of the article's URL, or else its source, lower case
+func articleDomain(article *models.Article) string {
+	if parsed, err := url.Parse(article.URL); err == nil && parsed.Hostname() != "" {
+		return strings.ToLower(parsed.Hostname())
+	}
+	return strings.ToLower(article.Source)
+}
+
+// enabled reports whether any source is weighted below 1
+func (r sourceReliability) enabled() bool {
+	return len(r.weights) > 0 || (r.fallback > 0 && r.fallback < 1)
+}
+
+// weight returns the weight of the article's domain, taken from its URL or else
+// its source. A subdomain without a weight of its own uses its parent's, so
+// news.example.com falls back to example.com.
+func (r sourceReliability) weight(article *models.Article) float64 {
+	domain := strings.TrimPrefix(articleDomain(article), "www.")
+	for domain != "" {
+		if weight, ok := r.weights[domain]; ok {
+			return weight
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || !strings.Contains(parent, ".") {
+			break
+		}
+		domain = parent
+	}
+	if r.fallback > 0 {
+		return r.fallback
+	}
+	return 1
+}
+
+// apply returns a copy of result whose entity and relationship confidences are
+// scaled by weight, each recording it as source_reliability. result itself is
+// left untouched since it may be cached and reused for other sources.
+func (r sourceReliability) apply(result *models.ExtractionResult, weight float64) *models.ExtractionResult {
+	if result == nil || !r.enabled() {
+		return result
+	}
+
+	weighted := *result
+	weighted.Entities = make([]models.ExtractedEntity, len(result.Entities))
+	for i, entity := range result.Entities {
+		entity.Confidence *= weight
+		entity.Properties = withProperty(entity.Properties, sourceReliabilityProperty, weight)
+		weighted.Entities[i] = entity
+	}
+	weighted.Relationships = make([]models.ExtractedRelationship, len(result.Relationships))
+	for i, rel := range result.Relationships {
+		rel.Confidence *= weight
+		rel.Properties = withProperty(rel.Properties, sourceReliabilityProperty, weight)
+		weighted.Relationships[i] = rel
+	}
+	return &weighted
+}
+
+// annotate records the weight on the article and scales its enrichment risk
+// score by it, so articles from unreliable sources rank as less risky
+func (r sourceReliability) annotate(article *models.Article, weight float64) {
+	if !r.enabled() {
+		return
+	}
+	if article.Metadata == nil {
+		article.Metadata = make(map[string]interface{})
+	}
+	article.Metadata[sourceReliabilityProperty] = weight
+	if risk, ok := article.Metadata["risk_score"].(float64); ok {
+		article.Metadata["risk_score"] = risk * weight
+	}
+}
+
+// withProperty returns a copy of props with key set to value
+func withProperty(props map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	copied := maps.Clone(props)
+	if copied == nil {
+		copied = make(map[string]interface{}, 1)
+	}
+	copied[key] = value
+	return copied
+}
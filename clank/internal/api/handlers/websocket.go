@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"strings"
 	"sync"
@@ -10,6 +9,7 @@ import (
 
 	"clank/config"
 	"clank/internal/llm"
+	"clank/internal/logging"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -40,13 +40,14 @@ func WebSocketHandler(cfg *config.Config) gin.HandlerFunc {
 	client := llm.NewClient(cfg)
 
 	return func(c *gin.Context) {
+		logger := logging.For(c.Request.Context(), "websocket")
 		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
-			log.Printf("Failed to upgrade connection: %v", err)
+			logger.Error("Failed to upgrade connection", "error", err)
 			return
 		}
 		defer func() {
-			log.Printf("Closing WebSocket connection")
+			logger.Info("Closing WebSocket connection")
 			ws.Close()
 		}()
 
@@ -77,21 +78,21 @@ func WebSocketHandler(cfg *config.Config) gin.HandlerFunc {
 		for {
 			var wsMsg WebSocketMessage
 			if err := ws.ReadJSON(&wsMsg); err != nil {
-				log.Printf("Error reading message: %v", err)
+				logger.Warn("Error reading message", "error", err)
 				break
 			}
 
 			switch wsMsg.Type {
 			case "chat":
 				if err := handleChatMessage(ws, client, &wsMsg, c.Request.Context()); err != nil {
-					log.Printf("Error handling chat message: %v", err)
+					logger.Error("Error handling chat message", "error", err)
 					sendError(ws, "Error processing chat message")
 				}
 			case "ping":
 				response := WebSocketResponse{Type: "pong"}
 				ws.WriteJSON(response)
 			default:
-				log.Printf("Unknown message type: %s", wsMsg.Type)
+				logger.Warn("Unknown message type", "type", wsMsg.Type)
 			}
 		}
 	}
@@ -111,14 +112,16 @@ func handleChatMessage(ws *websocket.Conn, client *llm.Client, wsMsg *WebSocketM
 	msgCtx, cancel := context.WithTimeout(parentCtx, 5*time.Minute)
 	defer cancel()
 
+	// The generation closes the channel once done so the loop below ends
 	go func(msgs []llm.Message) {
+		defer closeResponseChan()
 		defer func() {
 			if r := recover(); r != nil {
-				log.Printf("Recovered from panic in generation goroutine: %v", r)
+				logging.For(msgCtx, "websocket").Error("Recovered from panic in generation goroutine", "panic", r)
 			}
 		}()
 		if err := client.GenerateStream(msgCtx, msgs, responseChan); err != nil {
-			log.Printf("Error generating: %v", err)
+			logging.For(msgCtx, "websocket").Error("Error generating", "error", err)
 			select {
 			case responseChan <- "ERROR: " + err.Error():
 			case <-msgCtx.Done():
@@ -1 +1,101 @@
 package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"clank/config"
+	"clank/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Origin", "Content-Type", "Authorization", IdempotencyKeyHeader, logging.RequestIDHeader}
+)
+
+// defaultContentSecurityPolicy allows nothing to load and the API to be framed
+// nowhere; it only serves JSON, streams and downloads
+const defaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+
+// CORS allows cross-origin requests from the configured origins. Requests from
+// other origins get no CORS headers, so browsers don't expose the response, and
+// their preflights are refused with 403. Requests without an Origin header, such
+// as from curl or other services, pass through untouched.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == "*" {
+			anyOrigin = true
+		}
+		allowed[strings.ToLower(origin)] = true
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !anyOrigin && !allowed[strings.ToLower(origin)] {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if anyOrigin {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		// Lets the frontend read the correlation ID to quote in bug reports
+		c.Header("Access-Control-Expose-Headers", logging.RequestIDHeader)
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// SecurityHeaders sets headers that stop browsers sniffing content types,
+// framing responses or loading anything from them. csp replaces the default
+// Content-Security-Policy when set.
+func SecurityHeaders(csp string) gin.HandlerFunc {
+	if csp == "" {
+		csp = defaultContentSecurityPolicy
+	}
+	return func(c *gin.Context) {
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("X-Frame-Options", "DENY")
+		c.Header("Content-Security-Policy", csp)
+		c.Header("Referrer-Policy", "no-referrer")
+		c.Next()
+	}
+}
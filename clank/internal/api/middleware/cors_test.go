@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clank/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// corsRouter serves GET and POST /api/nodes behind the CORS and security header middleware
+func corsRouter(cfg config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(SecurityHeaders(""), CORS(cfg))
+	r.GET("/api/nodes", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"nodes": []string{}}) })
+	r.POST("/api/nodes", func(c *gin.Context) { c.JSON(http.StatusCreated, gin.H{}) })
+	return r
+}
+
+func corsRequest(r *gin.Engine, method, origin string, preflight bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/nodes", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if preflight {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORS_PermittedOrigin(t *testing.T) {
+	r := corsRouter(config.CORSConfig{AllowedOrigins: []string{"http://localhost:3000"}, MaxAge: 10 * time.Minute})
+
+	rr := corsRequest(r, http.MethodGet, "http://localhost:3000", false)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "http://localhost:3000", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "Origin", rr.Header().Get("Vary"))
+
+	rr = corsRequest(r, http.MethodOptions, "http://localhost:3000", true)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "http://localhost:3000", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Contains(t, rr.Header().Get("Access-Control-Allow-Headers"), IdempotencyKeyHeader)
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORS_RejectedOrigin(t *testing.T) {
+	r := corsRouter(config.CORSConfig{AllowedOrigins: []string{"http://localhost:3000"}})
+
+	rr := corsRequest(r, http.MethodGet, "https://evil.example", false)
+	assert.Equal(t, http.StatusOK, rr.Code, "the browser, not the server, withholds the response")
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	rr = corsRequest(r, http.MethodOptions, "https://evil.example", true)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+
+	// Nothing is allowed by default
+	rr = corsRequest(corsRouter(config.CORSConfig{}), http.MethodOptions, "http://localhost:3000", true)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCORS_AnyOriginAndNoOrigin(t *testing.T) {
+	r := corsRouter(config.CORSConfig{AllowedOrigins: []string{"*"}})
+	rr := corsRequest(r, http.MethodGet, "https://anywhere.example", false)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// Same-origin and non-browser requests carry no Origin
+	rr = corsRequest(r, http.MethodPost, "", false)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	r := corsRouter(config.CORSConfig{})
+
+	for _, rr := range []*httptest.ResponseRecorder{
+		corsRequest(r, http.MethodGet, "", false),
+		corsRequest(r, http.MethodOptions, "https://evil.example", true),
+	} {
+		assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+		assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+		assert.Equal(t, defaultContentSecurityPolicy, rr.Header().Get("Content-Security-Policy"))
+	}
+
+	gin.SetMode(gin.TestMode)
+	custom := gin.New()
+	custom.Use(SecurityHeaders("default-src 'self'"))
+	custom.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	rr := httptest.NewRecorder()
+	custom.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "default-src 'self'", rr.Header().Get("Content-Security-Policy"))
+}
@@ -37,6 +37,12 @@ func isNonDatabaseEndpoint(path string) bool {
 		"/health",
 		"/ws",
 		"/mcp/sse",
+		// Extractions are queued for integration while the database is down
+		"/api/extraction",
+		"/api/extraction/text",
+		"/api/extraction/pdf",
+		"/api/extraction/retry-queue",
+		"/api/extraction/retry-queue/flush",
 	}
 
 	for _, endpoint := range nonDBEndpoints {
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireDatabase_Unavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequireDatabase())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/nodes", ok)
+	r.POST("/api/extraction", ok)
+	r.POST("/api/extraction/text", ok)
+	r.GET("/api/extraction/retry-queue", ok)
+	r.POST("/api/extraction/retry-queue/flush", ok)
+
+	// No database was initialized in this test binary, so it counts as down
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/api/nodes", http.StatusServiceUnavailable},
+		{http.MethodPost, "/api/extraction", http.StatusOK},
+		{http.MethodPost, "/api/extraction/text", http.StatusOK},
+		{http.MethodGet, "/api/extraction/retry-queue", http.StatusOK},
+		{http.MethodPost, "/api/extraction/retry-queue/flush", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, tt.status, rr.Code)
+		})
+	}
+}
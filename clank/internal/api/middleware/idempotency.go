@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader names the request header carrying a client-chosen operation key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// defaultIdempotencyTTL is how long completed operations are replayed when no window is configured
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// defaultIdempotencyMaxBody caps the request body hashed for a key when no limit
+// is configured; it matches the PDF upload limit
+const defaultIdempotencyMaxBody = 32 << 20
+
+// idempotentOperation is a request seen under an idempotency key. done is closed
+// once the first request finishes; its response is replayed until expires, to
+// requests whose body hashes to bodyHash.
+type idempotentOperation struct {
+	done     chan struct{}
+	bodyHash [sha256.Size]byte
+	status   int
+	header   http.Header
+	body     []byte
+	expires  time.Time
+}
+
+// idempotencyStore holds operations by key in memory
+type idempotencyStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxBody    int64 // Largest request body read, in bytes
+	operations map[string]*idempotentOperation
+	now        func() time.Time
+}
+
+// begin returns the operation for key and whether the caller owns it and must run
+// the request. A new operation is recorded with bodyHash.
+func (s *idempotencyStore) begin(key string, bodyHash [sha256.Size]byte) (*idempotentOperation, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, op := range s.operations {
+		if !op.expires.IsZero() && now.After(op.expires) {
+			delete(s.operations, k)
+		}
+	}
+
+	if op, ok := s.operations[key]; ok {
+		return op, false
+	}
+	op := &idempotentOperation{done: make(chan struct{}), bodyHash: bodyHash}
+	s.operations[key] = op
+	return op, true
+}
+
+// finish records a successful response for replay, or forgets a failed one so it can be retried
+func (s *idempotencyStore) finish(key string, op *idempotentOperation, status int, header http.Header, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op.status, op.header, op.body = status, header, body
+	if status >= http.StatusOK && status < http.StatusMultipleChoices {
+		op.expires = s.now().Add(s.ttl)
+	} else {
+		delete(s.operations, key)
+	}
+	close(op.done)
+}
+
+// bodyRecorder copies everything written to the response
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency makes requests carrying an Idempotency-Key header run at most once:
+// a repeat of a completed request replays the first response, and a repeat that
+// arrives while the first is running waits for it. Only successful responses are
+// kept, for ttl (24h when ttl is not positive). Reusing a key with a different
+// request body is rejected with 422. The body is read up front to compare it, so
+// keyed requests with bodies over maxBody bytes (32MB when not positive) are
+// rejected with 413. Requests without the header are unaffected.
+func Idempotency(ttl time.Duration, maxBody int64) gin.HandlerFunc {
+	return idempotency(newIdempotencyStore(ttl, maxBody))
+}
+
+func newIdempotencyStore(ttl time.Duration, maxBody int64) *idempotencyStore {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+	if maxBody <= 0 {
+		maxBody = defaultIdempotencyMaxBody
+	}
+	return &idempotencyStore{
+		ttl:        ttl,
+		maxBody:    maxBody,
+		operations: make(map[string]*idempotentOperation),
+		now:        time.Now,
+	}
+}
+
+func idempotency(store *idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		key = c.Request.Method + " " + c.FullPath() + " " + key
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, store.maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge,
+					gin.H{"error": fmt.Sprintf("Request body is larger than %d bytes", store.maxBody)})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		bodyHash := sha256.Sum256(body)
+
+		for {
+			op, owner := store.begin(key, bodyHash)
+			if owner {
+				recorder := &bodyRecorder{ResponseWriter: c.Writer}
+				c.Writer = recorder
+				defer func() {
+					// Release waiting requests if the handler panics
+					if r := recover(); r != nil {
+						store.finish(key, op, http.StatusInternalServerError, nil, nil)
+						panic(r)
+					}
+				}()
+				c.Next()
+				store.finish(key, op, recorder.Status(), recorder.Header().Clone(), recorder.body.Bytes())
+				return
+			}
+
+			if op.bodyHash != bodyHash {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+					gin.H{"error": "idempotency key was already used with a different request body"})
+				return
+			}
+
+			select {
+			case <-op.done:
+			case <-c.Request.Context().Done():
+				c.AbortWithStatus(http.StatusRequestTimeout)
+				return
+			}
+			// A failed first attempt is forgotten, so this request runs it again
+			if op.expires.IsZero() {
+				continue
+			}
+
+			for name, values := range op.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(op.status, op.header.Get("Content-Type"), op.body)
+			c.Abort()
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRouter serves POST /api/extraction through the idempotency middleware,
+// returning a new integration number on each run
+func countingRouter(store *idempotencyStore, status int, runs *int, mu *sync.Mutex) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/api/extraction", idempotency(store), func(c *gin.Context) {
+		mu.Lock()
+		*runs++
+		run := *runs
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond) // let concurrent retries overlap
+		c.JSON(status, gin.H{"integration": run})
+	})
+	return r
+}
+
+func post(r *gin.Engine, key string) *httptest.ResponseRecorder {
+	return postBody(r, key, `{"url": "https://example.com"}`)
+}
+
+func postBody(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/extraction", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotency_SameKeyRunsOnce(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	r := countingRouter(newIdempotencyStore(time.Hour, 0), http.StatusOK, &runs, &mu)
+
+	first := post(r, "retry-1")
+	second := post(r, "retry-1")
+
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+
+	post(r, "retry-2")
+	post(r, "")
+	assert.Equal(t, 3, runs, "other keys and requests without a key still run")
+}
+
+func TestIdempotency_DifferentBodyIsRejected(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	r := countingRouter(newIdempotencyStore(time.Hour, 0), http.StatusOK, &runs, &mu)
+
+	post(r, "retry-1")
+	rr := postBody(r, "retry-1", `{"url": "https://example.com/other"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Contains(t, rr.Body.String(), "different request body")
+	assert.Equal(t, 1, runs)
+	assert.Equal(t, http.StatusOK, post(r, "retry-1").Code, "the original body still replays")
+}
+
+func TestIdempotency_OversizedBodyIsRejected(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	r := countingRouter(newIdempotencyStore(time.Hour, 64), http.StatusOK, &runs, &mu)
+
+	rr := postBody(r, "upload-1", strings.Repeat("%PDF-1.7 ", 100))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, 0, runs)
+	assert.Equal(t, http.StatusOK, post(r, "upload-2").Code, "bodies within the limit still run")
+}
+
+func TestIdempotency_ConcurrentRetriesWait(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	r := countingRouter(newIdempotencyStore(time.Hour, 0), http.StatusOK, &runs, &mu)
+
+	bodies := make([]string, 5)
+	var wg sync.WaitGroup
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i] = post(r, "flaky").Body.String()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, runs)
+	for _, body := range bodies {
+		assert.Equal(t, bodies[0], body)
+	}
+}
+
+func TestIdempotency_FailuresAreNotReplayed(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	r := countingRouter(newIdempotencyStore(time.Hour, 0), http.StatusInternalServerError, &runs, &mu)
+
+	post(r, "failing")
+	post(r, "failing")
+	assert.Equal(t, 2, runs)
+}
+
+func TestIdempotency_KeysExpire(t *testing.T) {
+	var runs int
+	var mu sync.Mutex
+	now := time.Now()
+	store := newIdempotencyStore(time.Minute, 0)
+	store.now = func() time.Time { return now }
+	r := countingRouter(store, http.StatusOK, &runs, &mu)
+
+	post(r, "expiring")
+	now = now.Add(30 * time.Second)
+	post(r, "expiring")
+	require.Equal(t, 1, runs)
+
+	now = now.Add(time.Minute)
+	post(r, "expiring")
+	assert.Equal(t, 2, runs)
+}
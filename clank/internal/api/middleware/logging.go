@@ -1 +1,20 @@
 package middleware
+
+import (
+	"clank/internal/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID gives every request a correlation ID: the inbound X-Request-ID when it
+// is usable, a generated one otherwise. The ID is echoed in the response header
+// and set on the request context, so every log line written for the request
+// through logging.For carries it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := logging.NewRequestID(c.GetHeader(logging.RequestIDHeader))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(logging.RequestIDHeader, id)
+		c.Next()
+	}
+}
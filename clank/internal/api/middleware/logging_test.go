@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/internal/logging"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID())
+	var seen string
+	r.GET("/", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	t.Run("inbound ID is echoed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(logging.RequestIDHeader, "upstream-7")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		assert.Equal(t, "upstream-7", rr.Header().Get(logging.RequestIDHeader))
+		assert.Equal(t, "upstream-7", seen)
+	})
+
+	t.Run("missing ID is generated", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		generated := rr.Header().Get(logging.RequestIDHeader)
+		assert.NotEmpty(t, generated)
+		assert.Equal(t, generated, seen)
+	})
+}
@@ -1,32 +1,32 @@
 package routes
 
 import (
+	"context"
+
 	"clank/config"
 	"clank/internal/api/handlers"
 	"clank/internal/api/handlers/graph"
 	"clank/internal/api/middleware"
+	"clank/internal/logging"
+	"clank/internal/metrics"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter() *gin.Engine {
+// SetupRouter builds the server's routes; background workers they start stop once ctx ends
+func SetupRouter(ctx context.Context) *gin.Engine {
 	r := gin.Default()
 	cfg := config.LoadConfig()
 
-	// CORS middleware
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
-		c.Next()
-	})
+	// Correlation IDs first, so every later log line for a request carries one
+	r.Use(middleware.RequestID())
+	r.Use(middleware.SecurityHeaders(cfg.Server.ContentSecurityPolicy))
+	r.Use(middleware.CORS(cfg.Server.CORS))
 
 	// Core endpoints
 	r.GET("/health", handlers.HealthHandler)
+	// Prometheus metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Prompt endpoints (file-based prompts + hot-reload).
 	// These do not require the database middleware because they operate on the filesystem / prompt loader.
@@ -44,6 +44,12 @@ func SetupRouter() *gin.Engine {
 		// Validate arguments for a prompt without rendering
 		prompts.POST("/:name/validate", handlers.ValidatePrompt)
 
+		// List retained versions of a prompt
+		prompts.GET("/:name/versions", handlers.GetPromptVersions)
+
+		// Make a previous version of a prompt active
+		prompts.POST("/:name/rollback", handlers.RollbackPrompt)
+
 		// Reload all prompts (hot-reload trigger)
 		prompts.POST("/reload", handlers.ReloadPrompts)
 	}
@@ -97,11 +103,64 @@ func SetupRouter() *gin.Engine {
 			analytics.GET("/entity-connections/:nodeId", graph.GetEntityConnectionsHandler)
 			analytics.GET("/timeline", graph.GetTimelineHandler)
 			analytics.GET("/network-stats", graph.GetNetworkStatsHandler)
+			analytics.GET("/event-chain/:entityId", graph.GetEventChainHandler)
+			analytics.GET("/ownership-cycles", graph.GetOwnershipCyclesHandler)
 		}
 
+		// Dashboard overview: article, entity, relationship, event and source counts
+		api.GET("/stats", graph.GetStatsHandler)
+
+		// Centrality rankings (?metric=degree|betweenness); /graph/central is kept as an alias
+		api.GET("/graph/centrality", graph.GetCentralityHandler)
+		api.GET("/graph/central", graph.GetCentralityHandler)
+
+		// N-hop neighborhood of one node (?id=&depth=&types=PERSON,ORGANIZATION)
+		api.GET("/graph/subgraph", graph.GetEntitySubgraphHandler)
+
+		// Chronological property changes of an entity, one revision per article
+		api.GET("/graph/entities/:id/history", graph.GetEntityHistoryHandler)
+
+		// Point-in-time graph snapshots as JSON lines
+		api.GET("/graph/snapshot", graph.GetGraphSnapshotHandler)
+		api.POST("/graph/snapshot/import", graph.ImportGraphSnapshotHandler)
+
+		// Articles sharing the most high-confidence entities with one (?limit=&minConfidence=)
+		api.GET("/articles/:id/related", graph.GetRelatedArticlesHandler)
+		// Everything only one source reported (?sourceUrl=); corroborated data loses just the URL
+		api.DELETE("/articles", graph.DeleteSourceHandler)
+
+		// Load articles extracted by an external pipeline
+		api.POST("/graph/import", graph.ImportDocumentsHandler)
+
+		// Maintenance jobs for data written by older versions
+		api.POST("/graph/maintenance/dedup-relationships", graph.DeduplicateRelationshipsHandler)
+
 		// Extraction endpoints
 		extractionHandler := handlers.NewExtractionGinHandler(cfg)
-		api.POST("/extraction", extractionHandler.HandleURLExtraction)
+		extractionHandler.StartRetryQueue(ctx)
+		if loader := extractionHandler.Prompts(); loader != nil {
+			handlers.UsePromptLoader(loader)
+			if cfg.LLM.WatchPrompts {
+				if err := loader.Watch(ctx); err != nil {
+					logging.For(ctx, "prompts").Warn("Prompt hot reload disabled", "error", err)
+				}
+			}
+		}
+		// Retried requests with the same Idempotency-Key replay the first result
+		api.POST("/extraction", middleware.Idempotency(cfg.Extraction.IdempotencyTTL, cfg.Extraction.IdempotencyMaxBody), extractionHandler.HandleURLExtraction)
+		// Extraction from submitted text or HTML, without scraping
+		api.POST("/extraction/text", middleware.Idempotency(cfg.Extraction.IdempotencyTTL, cfg.Extraction.IdempotencyMaxBody), extractionHandler.HandleTextExtraction)
+		// Extraction from an uploaded PDF (multipart "file" plus the text extraction fields)
+		api.POST("/extraction/pdf", middleware.Idempotency(cfg.Extraction.IdempotencyTTL, cfg.Extraction.IdempotencyMaxBody), extractionHandler.HandlePDFExtraction)
+		// Extraction rerun on a stored article's content, e.g. with an extraPrompt to compare prompt changes
+		api.POST("/articles/:id/reextract", middleware.Idempotency(cfg.Extraction.IdempotencyTTL, cfg.Extraction.IdempotencyMaxBody), extractionHandler.HandleReextraction)
+		// Shareable report of an analysis session (?sessionId=&format=md|pdf)
+		api.GET("/extraction/report", extractionHandler.HandleSessionReport)
+		// Tokens used by an analysis session, per stage (?sessionId=)
+		api.GET("/extraction/usage", extractionHandler.HandleSessionUsage)
+		// Extractions waiting to be integrated while the database is unavailable
+		api.GET("/extraction/retry-queue", extractionHandler.HandleRetryQueue)
+		api.POST("/extraction/retry-queue/flush", extractionHandler.HandleRetryQueueFlush)
 
 		// Tools endpoint (enhanced with graph context and LLM)
 		api.POST("/run-tool", handlers.ToolHandler)
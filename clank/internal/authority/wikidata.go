@@ -0,0 +1,283 @@
+// Package authority links extracted entities to an external authority, Wikidata
+// by default, so that namesakes can be told apart and one entity's names can be
+// recognized by the authority ID they share.
+package authority
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"clank/internal/models"
+)
+
+const (
+	// DefaultEndpoint is the Wikidata API searched by wbsearchentities
+	DefaultEndpoint = "https://www.wikidata.org/w/api.php"
+
+	defaultLanguage = "en"
+	defaultTimeout  = 5 * time.Second
+	defaultCacheTTL = 24 * time.Hour
+	// unavailableCooldown is how long lookups are skipped after the authority fails,
+	// so an outage costs one timeout rather than one per entity
+	unavailableCooldown = time.Minute
+	// searchLimit is how many candidates are considered per name
+	searchLimit = 7
+	userAgent   = "clank/1.0 (corruption tracker entity enrichment)"
+)
+
+// Properties set on entities linked to the authority
+const (
+	PropertyID          = "wikidata_id"
+	PropertyLabel       = "wikidata_label"
+	PropertyDescription = "wikidata_description"
+)
+
+// ErrUnavailable is returned while the authority is failing or cooling down after a failure
+var ErrUnavailable = errors.New("entity authority unavailable")
+
+// Match is the authority item an entity was linked to
+type Match struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+// typeKeywords are words in an item's description that show it is an entity of
+// the type. Only entities of these types are looked up.
+var typeKeywords = map[string][]string{
+	"person": {"politician", "businessman", "businesswoman", "business executive", "entrepreneur", "official",
+		"minister", "lawyer", "judge", "prosecutor", "diplomat", "journalist", "banker", "economist", "civil servant",
+		"mayor", "governor", "senator", "president", "player", "actor", "singer", "writer", "born"},
+	"organization": {"organization", "organisation", "political party", "non-profit", "nonprofit", "foundation",
+		"association", "trade union", "charity", "ngo"},
+	"company": {"company", "corporation", "firm", "bank", "business", "conglomerate", "manufacturer", "contractor",
+		"enterprise", "subsidiary"},
+	"government": {"agency", "ministry", "department", "government", "authority", "commission", "council", "court",
+		"police", "regulator"},
+	"location": {"city", "town", "village", "country", "state", "province", "region", "capital", "municipality",
+		"county", "district", "island"},
+}
+
+// nonEntityDescriptions mark items that are about a name rather than something named
+var nonEntityDescriptions = []string{"disambiguation page", "family name", "given name", "wikimedia list", "surname"}
+
+func init() {
+	// The LLM often says organization for companies and agencies
+	typeKeywords["organization"] = append(typeKeywords["organization"],
+		append(typeKeywords["company"], typeKeywords["government"]...)...)
+}
+
+// Client looks entities up in a wbsearchentities-compatible API, caching answers,
+// including that a name has no match
+type Client struct {
+	endpoint string
+	language string
+	client   *http.Client
+	ttl      time.Duration
+	now      func() time.Time // replaced in tests
+
+	mu        sync.Mutex
+	cache     map[string]cachedMatch
+	downUntil time.Time
+}
+
+type cachedMatch struct {
+	match   *Match
+	expires time.Time
+}
+
+// NewClient creates an authority client. Empty or zero arguments use Wikidata,
+// English, a 5s timeout and a 24h cache.
+func NewClient(endpoint, language string, timeout, cacheTTL time.Duration) *Client {
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	if language == "" {
+		language = defaultLanguage
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = defaultCacheTTL
+	}
+	return &Client{
+		endpoint: endpoint,
+		language: language,
+		client:   &http.Client{Timeout: timeout},
+		ttl:      cacheTTL,
+		now:      time.Now,
+		cache:    make(map[string]cachedMatch),
+	}
+}
+
+// Lookup returns the item named name that is an entity of entityType, or nil
+// when there is none or the type isn't looked up
+func (c *Client) Lookup(ctx context.Context, name, entityType string) (*Match, error) {
+	entityType = strings.ToLower(strings.TrimSpace(entityType))
+	name = strings.Join(strings.Fields(name), " ")
+	if _, ok := typeKeywords[entityType]; !ok || name == "" {
+		return nil, nil
+	}
+
+	key := entityType + "|" + strings.ToLower(name)
+	c.mu.Lock()
+	now := c.now()
+	if cached, ok := c.cache[key]; ok && now.Before(cached.expires) {
+		c.mu.Unlock()
+		return cached.match, nil
+	}
+	if now.Before(c.downUntil) {
+		c.mu.Unlock()
+		return nil, ErrUnavailable
+	}
+	c.mu.Unlock()
+
+	candidates, err := c.search(ctx, name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			c.downUntil = c.now().Add(unavailableCooldown)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	match := bestMatch(name, entityType, candidates)
+	for k, cached := range c.cache {
+		if !now.Before(cached.expires) {
+			delete(c.cache, k)
+		}
+	}
+	c.cache[key] = cachedMatch{match: match, expires: now.Add(c.ttl)}
+	return match, nil
+}
+
+// Enrich links the result's entities to the authority, setting PropertyID,
+// PropertyLabel and PropertyDescription on each match, and returns how many
+// were linked. It stops at the first failure, keeping the links made so far.
+func (c *Client) Enrich(ctx context.Context, result *models.ExtractionResult) (int, error) {
+	if result == nil {
+		return 0, nil
+	}
+	linked := 0
+	for i := range result.Entities {
+		entity := &result.Entities[i]
+		match, err := c.Lookup(ctx, entity.Name, entity.Type)
+		if err != nil {
+			return linked, err
+		}
+		if match == nil {
+			continue
+		}
+		if entity.Properties == nil {
+			entity.Properties = make(map[string]interface{})
+		}
+		entity.Properties[PropertyID] = match.ID
+		entity.Properties[PropertyLabel] = match.Label
+		entity.Properties[PropertyDescription] = match.Description
+		linked++
+	}
+	return linked, nil
+}
+
+// searchResponse is the part of a wbsearchentities response used
+type searchResponse struct {
+	Search []struct {
+		ID          string `json:"id"`
+		Label       string `json:"label"`
+		Description string `json:"description"`
+		Match       struct {
+			Text string `json:"text"`
+		} `json:"match"`
+	} `json:"search"`
+	Error *struct {
+		Info string `json:"info"`
+	} `json:"error"`
+}
+
+// candidate is a search result with the text it matched, a label or alias
+type candidate struct {
+	Match
+	matched string
+}
+
+// search returns the items whose label or alias matches name, best first
+func (c *Client) search(ctx context.Context, name string) ([]candidate, error) {
+	query := url.Values{
+		"action":   {"wbsearchentities"},
+		"search":   {name},
+		"language": {c.language},
+		"uselang":  {c.language},
+		"type":     {"item"},
+		"limit":    {fmt.Sprint(searchLimit)},
+		"format":   {"json"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("authority returned %s", resp.Status)
+	}
+
+	var body searchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode authority response: %w", err)
+	}
+	if body.Error != nil {
+		return nil, fmt.Errorf("authority error: %s", body.Error.Info)
+	}
+
+	candidates := make([]candidate, 0, len(body.Search))
+	for _, item := range body.Search {
+		candidates = append(candidates, candidate{
+			Match:   Match{ID: item.ID, Label: item.Label, Description: item.Description},
+			matched: item.Match.Text,
+		})
+	}
+	return candidates, nil
+}
+
+// bestMatch returns the first candidate named exactly name, by label or alias,
+// whose description shows it is an entity of entityType. Items without a
+// description can't be told apart from namesakes and are not matched.
+func bestMatch(name, entityType string, candidates []candidate) *Match {
+	for _, c := range candidates {
+		if !strings.EqualFold(c.Label, name) && !strings.EqualFold(c.matched, name) {
+			continue
+		}
+		description := strings.ToLower(c.Description)
+		if description == "" || containsAny(description, nonEntityDescriptions) {
+			continue
+		}
+		if containsAny(description, typeKeywords[entityType]) {
+			match := c.Match
+			return &match
+		}
+	}
+	return nil
+}
+
+func containsAny(s string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(s, word) {
+			return true
+		}
+	}
+	return false
+}
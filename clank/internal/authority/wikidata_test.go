@@ -0,0 +1,147 @@
+package authority
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAuthority answers wbsearchentities queries from a fixed set of results per name
+func stubAuthority(t *testing.T, results map[string][]map[string]interface{}) (*httptest.Server, *int32) {
+	t.Helper()
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		assert.Equal(t, "wbsearchentities", r.URL.Query().Get("action"))
+		search := results[r.URL.Query().Get("search")]
+		if search == nil {
+			search = []map[string]interface{}{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"search": search})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &requests
+}
+
+var jordanResults = map[string][]map[string]interface{}{
+	"Michael Jordan": {
+		{"id": "Q41421", "label": "Michael Jordan", "description": "American basketball player and businessman (born 1963)"},
+		{"id": "Q3308285", "label": "Michael Jordan", "description": "American scientist and professor at Berkeley"},
+		{"id": "Q27069141", "label": "Michael Jordan", "description": "Wikimedia disambiguation page"},
+	},
+	"Jordan": {
+		{"id": "Q27069141", "label": "Jordan", "description": "Wikimedia disambiguation page"},
+		{"id": "Q810", "label": "Jordan", "description": "country in Western Asia"},
+	},
+	"Acme Holdings": {
+		{"id": "Q999", "label": "Acme Holdings Ltd", "description": "company"},
+	},
+}
+
+func TestLookup_Match(t *testing.T) {
+	srv, _ := stubAuthority(t, jordanResults)
+	client := NewClient(srv.URL, "", time.Second, time.Hour)
+
+	match, err := client.Lookup(context.Background(), "Michael  Jordan", "person")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, Match{ID: "Q41421", Label: "Michael Jordan", Description: "American basketball player and businessman (born 1963)"}, *match)
+
+	// The same name as a location is the country, not the disambiguation page
+	match, err = client.Lookup(context.Background(), "Jordan", "location")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "Q810", match.ID)
+}
+
+func TestLookup_NoMatch(t *testing.T) {
+	srv, requests := stubAuthority(t, jordanResults)
+	client := NewClient(srv.URL, "", time.Second, time.Hour)
+
+	for _, tc := range []struct{ name, entityType string }{
+		{"Jordan", "person"},           // only a country and a disambiguation page
+		{"Acme Holdings", "company"},   // the label differs
+		{"Unknown Official", "person"}, // nothing found
+		{"Michael Jordan", "money"},    // not a type that is looked up
+	} {
+		match, err := client.Lookup(context.Background(), tc.name, tc.entityType)
+		require.NoError(t, err)
+		assert.Nil(t, match, tc.name)
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+
+	// No-matches are cached too
+	_, err := client.Lookup(context.Background(), "unknown official", "Person")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(requests))
+}
+
+func TestLookup_CacheExpires(t *testing.T) {
+	srv, requests := stubAuthority(t, jordanResults)
+	client := NewClient(srv.URL, "", time.Second, time.Hour)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	_, err := client.Lookup(context.Background(), "Michael Jordan", "person")
+	require.NoError(t, err)
+	_, err = client.Lookup(context.Background(), "Michael Jordan", "person")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	now = now.Add(2 * time.Hour)
+	_, err = client.Lookup(context.Background(), "Michael Jordan", "person")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestEnrich(t *testing.T) {
+	srv, _ := stubAuthority(t, jordanResults)
+	client := NewClient(srv.URL, "", time.Second, time.Hour)
+	result := &models.ExtractionResult{Entities: []models.ExtractedEntity{
+		{ID: "e1", Type: "person", Name: "Michael Jordan"},
+		{ID: "e2", Type: "person", Name: "Unknown Official", Properties: map[string]interface{}{"role": "clerk"}},
+		{ID: "e3", Type: "money", Name: "$5 million"},
+	}}
+
+	linked, err := client.Enrich(context.Background(), result)
+	require.NoError(t, err)
+	assert.Equal(t, 1, linked)
+	assert.Equal(t, "Q41421", result.Entities[0].Properties[PropertyID])
+	assert.Equal(t, "Michael Jordan", result.Entities[0].Properties[PropertyLabel])
+	assert.Contains(t, result.Entities[0].Properties[PropertyDescription], "basketball")
+	assert.Equal(t, map[string]interface{}{"role": "clerk"}, result.Entities[1].Properties)
+	assert.Nil(t, result.Entities[2].Properties)
+}
+
+func TestEnrich_AuthorityDown(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	client := NewClient(srv.URL, "", time.Second, time.Hour)
+	result := &models.ExtractionResult{Entities: []models.ExtractedEntity{
+		{ID: "e1", Type: "person", Name: "Michael Jordan"},
+		{ID: "e2", Type: "company", Name: "Acme Holdings"},
+	}}
+
+	linked, err := client.Enrich(context.Background(), result)
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Zero(t, linked)
+	assert.Nil(t, result.Entities[0].Properties)
+
+	// Further lookups wait out the cooldown instead of calling the authority
+	_, err = client.Lookup(context.Background(), "Acme Holdings", "company")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
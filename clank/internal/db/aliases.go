@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// entityMatchKeys returns the lowercased name and aliases a person can be matched by
+func entityMatchKeys(entity *models.ExtractedEntity) []string {
+	var keys []string
+	for _, name := range append([]string{entity.Name}, entity.Aliases...) {
+		if key := strings.ToLower(strings.Join(strings.Fields(name), " ")); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// findExistingEntity looks for a stored entity with the same authority ID
+// (wikidata_id), or for a person, one whose name or aliases match the entity's
+// name or aliases and that isn't linked to another authority item. It returns
+// the stored entity's ID, name and aliases, or an empty ID when there is no match.
+func findExistingEntity(tx neo4j.Transaction, entity *models.ExtractedEntity) (id, name string, aliases []string, err error) {
+	authorityID, _ := entity.Properties["wikidata_id"].(string)
+	if authorityID != "" {
+		result, err := tx.Run(`
+			MATCH (e:Entity {wikidata_id: $authorityId})
+			RETURN e.id, e.name, coalesce(e.aliases, [])
+			ORDER BY e.id = $id DESC, e.id
+			LIMIT 1
+		`, map[string]interface{}{"authorityId": authorityID, "id": entity.ID})
+		if err != nil {
+			return "", "", nil, fmt.Errorf("failed to look up entity by authority ID: %w", err)
+		}
+		if id, name, aliases, err = storedEntity(result); id != "" || err != nil {
+			return id, name, aliases, err
+		}
+	}
+
+	if !strings.EqualFold(entity.Type, "person") {
+		return "", "", nil, nil
+	}
+
+	keys := entityMatchKeys(entity)
+	if len(keys) == 0 {
+		return "", "", nil, nil
+	}
+
+	result, err := tx.Run(`
+		MATCH (e:Entity)
+		WHERE toLower(e.type) = 'person'
+		  AND (toLower(e.name) IN $keys OR any(alias IN coalesce(e.aliases, []) WHERE toLower(alias) IN $keys))
+		  AND (e.wikidata_id IS NULL OR $authorityId = '')
+		RETURN e.id, e.name, coalesce(e.aliases, [])
+		ORDER BY e.id = $id DESC, e.id
+		LIMIT 1
+	`, map[string]interface{}{"keys": keys, "id": entity.ID, "authorityId": authorityID})
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to look up existing entity: %w", err)
+	}
+	return storedEntity(result)
+}
+
+// storedEntity reads the ID, name and aliases of the first entity in result
+func storedEntity(result neo4j.Result) (id, name string, aliases []string, err error) {
+	if !result.Next() {
+		return "", "", nil, result.Err()
+	}
+
+	values := result.Record().Values
+	id, _ = values[0].(string)
+	name, _ = values[1].(string)
+	if stored, ok := values[2].([]interface{}); ok {
+		for _, alias := range stored {
+			if s, ok := alias.(string); ok {
+				aliases = append(aliases, s)
+			}
+		}
+	}
+	return id, name, aliases, nil
+}
+
+// resolveEntity points an entity at the stored entity it matches by authority ID,
+// or for a person by name or alias, keeping the stored name and merging aliases.
+// It returns the entity's original ID so relationships can be remapped.
+func resolveEntity(tx neo4j.Transaction, entity *models.ExtractedEntity) (string, error) {
+	originalID := entity.ID
+
+	id, name, aliases, err := findExistingEntity(tx, entity)
+	if err != nil || id == "" {
+		return originalID, err
+	}
+
+	merged := &models.ExtractedEntity{Name: name}
+	merged.AddAliases(aliases...)
+	merged.AddAliases(entity.Name)
+	merged.AddAliases(entity.Aliases...)
+
+	entity.ID = id
+	entity.Name = name
+	entity.Aliases = merged.Aliases
+	return originalID, nil
+}
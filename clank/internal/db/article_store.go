@@ -1,10 +1,13 @@
 package db
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"clank/internal/models"
+	"clank/pkg/extraction"
 
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
@@ -21,13 +24,23 @@ func NewArticleStore() *ArticleStore {
 	}
 }
 
-// SaveArticle stores an article and its extracted entities in Neo4j
+// transaction runs work in a read or write transaction of a new session,
+// retrying transient errors like ExecuteRead and ExecuteWrite
+func (s *ArticleStore) transaction(mode neo4j.AccessMode, work neo4j.TransactionWork) (interface{}, error) {
+	return executeWithRetry(func() (interface{}, error) {
+		session := s.driver.NewSession(neo4j.SessionConfig{AccessMode: mode})
+		defer session.Close()
+
+		if mode == neo4j.AccessModeRead {
+			return session.ReadTransaction(work)
+		}
+		return session.WriteTransaction(work)
+	})
+}
+
 // UpdateArticle updates an existing article in the database
 func (s *ArticleStore) UpdateArticle(article *models.Article) error {
-	session := s.driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
-
-	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+	_, err := s.transaction(neo4j.AccessModeWrite, func(tx neo4j.Transaction) (interface{}, error) {
 		params := map[string]interface{}{
 			"id":          article.ID,
 			"url":         article.URL,
@@ -35,6 +48,7 @@ func (s *ArticleStore) UpdateArticle(article *models.Article) error {
 			"content":     article.Content,
 			"source":      article.Source,
 			"author":      article.Author,
+			"language":    article.Language,
 			"publishDate": article.PublishDate.Format(time.RFC3339),
 			"extractedAt": article.ExtractedAt.Format(time.RFC3339),
 			"metadata":    article.Metadata,
@@ -48,6 +62,7 @@ func (s *ArticleStore) UpdateArticle(article *models.Article) error {
 				content: $content,
 				source: $source,
 				author: $author,
+				language: $language,
 				publishDate: $publishDate,
 				extractedAt: $extractedAt,
 				metadata: $metadata
@@ -68,142 +83,288 @@ func (s *ArticleStore) UpdateArticle(article *models.Article) error {
 	return nil
 }
 
+// SaveArticle stores an article and its extracted entities in Neo4j
 func (s *ArticleStore) SaveArticle(article *models.Article) error {
-	session := s.driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
+	_, err := s.transaction(neo4j.AccessModeWrite, func(tx neo4j.Transaction) (interface{}, error) {
+		return nil, saveArticle(tx, article)
+	})
 
-	_, err := session.WriteTransaction(func(tx neo4j.Transaction) (interface{}, error) {
-		// Create article node
-		params := map[string]interface{}{
-			"id":          article.ID,
-			"url":         article.URL,
-			"title":       article.Title,
-			"content":     article.Content,
-			"source":      article.Source,
-			"author":      article.Author,
-			"publishDate": article.PublishDate.Format(time.RFC3339),
-			"extractedAt": article.ExtractedAt.Format(time.RFC3339),
-			"metadata":    article.Metadata,
+	// Lost connections are ErrUnavailable, so callers can keep the article for a retry
+	return classifyError(err)
+}
+
+// saveArticle writes an article, its entities and relationships in tx, merging
+// entities into those already in the graph
+func saveArticle(tx neo4j.Transaction, article *models.Article) error {
+	if article.ContentHash == "" {
+		article.ContentHash = extraction.ContentFingerprint(article.Content)
+	}
+
+	// Syndicated copies and resubmissions are stored once
+	if err := checkDuplicateContent(tx, article); err != nil {
+		return err
+	}
+
+	// A re-extraction replaces what the article's previous extraction saved
+	if err := clearExtraction(tx, article.ID); err != nil {
+		return err
+	}
+
+	// Create article node
+	params := map[string]interface{}{
+		"id":          article.ID,
+		"url":         article.URL,
+		"title":       article.Title,
+		"content":     article.Content,
+		"source":      article.Source,
+		"author":      article.Author,
+		"language":    article.Language,
+		"contentHash": article.ContentHash,
+		"publishDate": article.PublishDate.Format(time.RFC3339),
+		"extractedAt": article.ExtractedAt.Format(time.RFC3339),
+		"metadata":    article.Metadata,
+	}
+
+	_, err := tx.Run(`
+		MERGE (a:Article {id: $id})
+		SET a += {
+			url: $url,
+			title: $title,
+			content: $content,
+			source: $source,
+			author: $author,
+			language: $language,
+			contentHash: $contentHash,
+			publishDate: datetime($publishDate),
+			extractedAt: datetime($extractedAt),
+			metadata: $metadata
 		}
+	`, params)
 
-		_, err := tx.Run(`
-			MERGE (a:Article {id: $id})
-			SET a += {
-				url: $url,
-				title: $title,
-				content: $content,
-				source: $source,
-				author: $author,
-				publishDate: datetime($publishDate),
-				extractedAt: datetime($extractedAt),
-				metadata: $metadata
-			}
-		`, params)
+	if err != nil {
+		return fmt.Errorf("failed to create article node: %w", err)
+	}
 
+	// Store enrichment fields as top-level properties for quick scanning
+	if enrichment := articleEnrichment(article); len(enrichment) > 0 {
+		_, err := tx.Run(`
+			MATCH (a:Article {id: $id})
+			SET a += $enrichment
+		`, map[string]interface{}{"id": article.ID, "enrichment": enrichment})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create article node: %w", err)
+			return fmt.Errorf("failed to store article enrichment: %w", err)
 		}
+	}
 
-		// Process entities if present
-		if article.Entities != nil {
-			for _, entity := range article.Entities {
-				params := map[string]interface{}{
-					"id":          entity.ID,
-					"type":        entity.Type,
-					"name":        entity.Name,
-					"properties":  entity.Properties,
-					"confidence":  entity.Confidence,
-					"articleId":   article.ID,
-					"extractedAt": entity.ExtractedAt.Format(time.RFC3339),
-				}
+	// Process entities if present
+	resolvedIDs := make(map[string]string)
+	if article.Entities != nil {
+		for _, entity := range article.Entities {
+			// People already in the graph under another name or alias reuse that entity
+			originalID, err := resolveEntity(tx, entity)
+			if err != nil {
+				return err
+			}
+			resolvedIDs[originalID] = entity.ID
 
+			before, err := entityState(tx, entity.ID)
+			if err != nil {
+				return err
+			}
+
+			params := map[string]interface{}{
+				"id":             entity.ID,
+				"type":           entity.Type,
+				"name":           entity.Name,
+				"aliases":        entity.Aliases,
+				"indicators":     entity.Indicators,
+				"indicatorFlags": indicatorProperties(entity.Indicators),
+				"liftedProps":    liftedProperties(entity.Properties),
+				"properties":     entity.Properties,
+				"confidence":     entity.Confidence,
+				"articleId":      article.ID,
+				"sourceUrl":      article.URL,
+				"extractedAt":    entity.ExtractedAt.Format(time.RFC3339),
+			}
+
+			_, err = tx.Run(`
+				MERGE (e:Entity {id: $id})
+				SET e += {
+					type: $type,
+					name: $name,
+					aliases: $aliases,
+					indicators: $indicators,
+					properties: $properties,
+					confidence: $confidence,
+					extractedAt: datetime($extractedAt)
+				}, e += $indicatorFlags, e += $liftedProps, `+sourceURLsSet("e")+`
+				WITH e
+				MATCH (a:Article {id: $articleId})
+				MERGE (a)-[r:MENTIONS]->(e)
+				SET r.confidence = $confidence
+			`, params)
+
+			if err != nil {
+				return fmt.Errorf("failed to create entity node: %w", err)
+			}
+
+			if err := savePropertyConfidence(tx, entity); err != nil {
+				return err
+			}
+
+			// Provenance: which article changed what on this entity
+			if err := saveEntityRevision(tx, entity.ID, article, entityChanges(before, entity)); err != nil {
+				return err
+			}
+
+			// Store entity mentions
+			for _, mention := range entity.Mentions {
+				params["mentionText"] = mention.Text
+				params["mentionContext"] = mention.Context
+				params["startPos"] = mention.Position.Start
+				params["endPos"] = mention.Position.End
+				params["notFound"] = mention.NotFound
+
+				// Neo4j properties can't be maps, so the position is stored as start/end
 				_, err := tx.Run(`
-					MERGE (e:Entity {id: $id})
-					SET e += {
-						type: $type,
-						name: $name,
-						properties: $properties,
-						confidence: $confidence,
-						extractedAt: datetime($extractedAt)
-					}
-					WITH e
-					MATCH (a:Article {id: $articleId})
-					MERGE (a)-[r:MENTIONS]->(e)
-					SET r.confidence = $confidence
+					MATCH (e:Entity {id: $id})
+					CREATE (m:Mention {
+						articleId: $articleId,
+						text: $mentionText,
+						context: $mentionContext,
+						start: $startPos,
+						end: $endPos,
+						notFound: $notFound
+					})-[:IN]->(e)
 				`, params)
-
 				if err != nil {
-					return nil, fmt.Errorf("failed to create entity node: %w", err)
-				}
-
-				// Store entity mentions
-				for _, mention := range entity.Mentions {
-					params["mentionText"] = mention.Text
-					params["mentionContext"] = mention.Context
-					params["startPos"] = mention.Position.Start
-					params["endPos"] = mention.Position.End
-
-					_, err := tx.Run(`
-						MATCH (e:Entity {id: $id})
-						CREATE (m:Mention {
-							text: $mentionText,
-							context: $mentionContext,
-							position: {start: $startPos, end: $endPos}
-						})-[:IN]->(e)
-					`, params)
-					if err != nil {
-						return nil, fmt.Errorf("failed to create mention: %w", err)
-					}
+					return fmt.Errorf("failed to create mention: %w", err)
 				}
 			}
 		}
+	}
 
-		// Process relationships if present
-		if article.Relations != nil {
-			for _, rel := range article.Relations {
-				params := map[string]interface{}{
-					"id":          rel.ID,
-					"type":        rel.Type,
-					"fromId":      rel.FromID,
-					"toId":        rel.ToID,
-					"properties":  rel.Properties,
-					"confidence":  rel.Confidence,
-					"articleId":   article.ID,
-					"extractedAt": rel.ExtractedAt.Format(time.RFC3339),
-				}
+	// Events extracted with the article, whose participants get typed edges
+	events := make(map[string]bool)
+	for _, entity := range article.Entities {
+		if strings.EqualFold(entity.Type, "event") {
+			events[entity.ID] = true
+		}
+	}
 
-				_, err := tx.Run(`
+	// Process relationships if present
+	if article.Relations != nil {
+		for _, rel := range article.Relations {
+			if id, ok := resolvedIDs[rel.FromID]; ok {
+				rel.FromID = id
+			}
+			if id, ok := resolvedIDs[rel.ToID]; ok {
+				rel.ToID = id
+			}
+
+			params := map[string]interface{}{
+				"id":             rel.ID,
+				"type":           rel.Type,
+				"fromId":         rel.FromID,
+				"toId":           rel.ToID,
+				"indicators":     rel.Indicators,
+				"indicatorFlags": indicatorProperties(rel.Indicators),
+				"liftedProps":    liftedProperties(rel.Properties),
+				"properties":     rel.Properties,
+				"confidence":     rel.Confidence,
+				"articleId":      article.ID,
+				"sourceUrl":      article.URL,
+				"extractedAt":    rel.ExtractedAt.Format(time.RFC3339),
+			}
+
+			_, err := tx.Run(`
+				MATCH (from:Entity {id: $fromId}), (to:Entity {id: $toId})
+				MERGE (from)-[r:RELATES_TO {id: $id}]->(to)
+				SET r += {
+					type: $type,
+					indicators: $indicators,
+					properties: $properties,
+					confidence: $confidence,
+					extractedAt: datetime($extractedAt)
+				}, r += $indicatorFlags, r += $liftedProps, `+sourceURLsSet("r")+`
+				WITH r
+				MATCH (a:Article {id: $articleId})
+				MERGE (a)-[:CONTAINS_RELATION]->(r)
+			`, params)
+
+			if err != nil {
+				return fmt.Errorf("failed to create relationship: %w", err)
+			}
+
+			// Event ordering gets a typed edge so event chains can be traversed
+			if fromID, toID, edgeType, ok := temporalEdge(rel); ok {
+				_, err := tx.Run(fmt.Sprintf(`
 					MATCH (from:Entity {id: $fromId}), (to:Entity {id: $toId})
-					MERGE (from)-[r:RELATES_TO {id: $id}]->(to)
-					SET r += {
-						type: $type,
-						properties: $properties,
-						confidence: $confidence,
-						extractedAt: datetime($extractedAt)
-					}
-					WITH r
-					MATCH (a:Article {id: $articleId})
-					MERGE (a)-[:CONTAINS_RELATION]->(r)
-				`, params)
+					MERGE (from)-[t:%s]->(to)
+					SET t.confidence = $confidence, t.articleId = $articleId, %s
+				`, edgeType, sourceURLsSet("t")), map[string]interface{}{
+					"fromId":     fromID,
+					"toId":       toID,
+					"confidence": rel.Confidence,
+					"articleId":  article.ID,
+					"sourceUrl":  article.URL,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to create %s edge: %w", edgeType, err)
+				}
+			}
 
+			// Taking part in an event gets an edge typed by the entity's role
+			if fromID, toID, edgeType, role, ok := involvementEdge(rel, events); ok {
+				var roleParam interface{}
+				if role != "" {
+					roleParam = role
+				}
+				_, err := tx.Run(fmt.Sprintf(`
+					MATCH (from:Entity {id: $fromId}), (to:Entity {id: $toId})
+					MERGE (from)-[t:%s]->(to)
+					SET t.role = $role, t.confidence = $confidence, t.articleId = $articleId, %s
+				`, edgeType, sourceURLsSet("t")), map[string]interface{}{
+					"fromId":     fromID,
+					"toId":       toID,
+					"role":       roleParam,
+					"confidence": rel.Confidence,
+					"articleId":  article.ID,
+					"sourceUrl":  article.URL,
+				})
 				if err != nil {
-					return nil, fmt.Errorf("failed to create relationship: %w", err)
+					return fmt.Errorf("failed to create %s edge: %w", edgeType, err)
 				}
 			}
 		}
+	}
 
-		return nil, nil
-	})
+	return nil
+}
 
-	return err
+// clearExtraction removes the entity mentions and relationships an earlier save
+// of the article linked to it, so saving it again leaves only the new extraction.
+// Entities and relationships themselves stay, as other articles may refer to them.
+func clearExtraction(tx neo4j.Transaction, articleID string) error {
+	params := map[string]interface{}{"id": articleID}
+	if _, err := tx.Run(`
+		MATCH (:Article {id: $id})-[r:MENTIONS|CONTAINS_RELATION]->()
+		DELETE r
+	`, params); err != nil {
+		return fmt.Errorf("failed to remove previous extraction: %w", err)
+	}
+	if _, err := tx.Run(`
+		MATCH (m:Mention {articleId: $id})
+		DETACH DELETE m
+	`, params); err != nil {
+		return fmt.Errorf("failed to remove previous mentions: %w", err)
+	}
+	return nil
 }
 
 // GetArticleByID retrieves an article by its ID
 func (s *ArticleStore) GetArticleByID(id string) (*models.Article, error) {
-	session := s.driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
-
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+	result, err := s.transaction(neo4j.AccessModeRead, func(tx neo4j.Transaction) (interface{}, error) {
 		params := map[string]interface{}{
 			"id": id,
 		}
@@ -221,21 +382,12 @@ func (s *ArticleStore) GetArticleByID(id string) (*models.Article, error) {
 
 		record, err := records.Single()
 		if err != nil {
-			return nil, fmt.Errorf("article not found: %w", err)
+			return nil, &Error{Kind: ErrNotFound, Msg: "article not found", Err: err}
 		}
 
-		articleNode := record.GetByIndex(0).(neo4j.Node)
-		article := &models.Article{
-			ID:          id,
-			URL:         articleNode.Props["url"].(string),
-			Title:       articleNode.Props["title"].(string),
-			Content:     articleNode.Props["content"].(string),
-			Source:      articleNode.Props["source"].(string),
-			Author:      articleNode.Props["author"].(string),
-			PublishDate: parseTime(articleNode.Props["publishDate"].(string)),
-			ExtractedAt: parseTime(articleNode.Props["extractedAt"].(string)),
-			Metadata:    articleNode.Props["metadata"].(map[string]interface{}),
-		}
+		articleNode, _ := record.GetByIndex(0).(neo4j.Node)
+		article := articleFromNode(articleNode)
+		article.ID = id
 
 		return article, nil
 	})
@@ -249,10 +401,7 @@ func (s *ArticleStore) GetArticleByID(id string) (*models.Article, error) {
 
 // GetArticlesByTimeRange retrieves articles within a time range
 func (s *ArticleStore) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models.Article, error) {
-	session := s.driver.NewSession(neo4j.SessionConfig{})
-	defer session.Close()
-
-	result, err := session.ReadTransaction(func(tx neo4j.Transaction) (interface{}, error) {
+	result, err := s.transaction(neo4j.AccessModeRead, func(tx neo4j.Transaction) (interface{}, error) {
 		params := map[string]interface{}{
 			"startTime": startTime.Format(time.RFC3339),
 			"endTime":   endTime.Format(time.RFC3339),
@@ -272,19 +421,8 @@ func (s *ArticleStore) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*
 		var articles []*models.Article
 		for records.Next() {
 			record := records.Record()
-			articleNode := record.GetByIndex(0).(neo4j.Node)
-			article := &models.Article{
-				ID:          articleNode.Props["id"].(string),
-				URL:         articleNode.Props["url"].(string),
-				Title:       articleNode.Props["title"].(string),
-				Content:     articleNode.Props["content"].(string),
-				Source:      articleNode.Props["source"].(string),
-				Author:      articleNode.Props["author"].(string),
-				PublishDate: parseTime(articleNode.Props["publishDate"].(string)),
-				ExtractedAt: parseTime(articleNode.Props["extractedAt"].(string)),
-				Metadata:    articleNode.Props["metadata"].(map[string]interface{}),
-			}
-			articles = append(articles, article)
+			articleNode, _ := record.GetByIndex(0).(neo4j.Node)
+			articles = append(articles, articleFromNode(articleNode))
 		}
 
 		return articles, nil
@@ -297,11 +435,135 @@ func (s *ArticleStore) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*
 	return result.([]*models.Article), nil
 }
 
-// parseTime parses a time string in RFC3339 format
-func parseTime(timeStr string) time.Time {
-	t, err := time.Parse(time.RFC3339, timeStr)
-	if err != nil {
-		return time.Now() // Default to current time on error
+// temporalEdgeTypes maps extracted temporal relationship types to the edge type stored in the graph
+var temporalEdgeTypes = map[string]string{
+	"BEFORE":    "BEFORE",
+	"AFTER":     "BEFORE",
+	"CAUSED":    "CAUSED",
+	"CAUSED_BY": "CAUSED",
+}
+
+// temporalEdge returns the typed edge to store for a temporal relationship.
+// AFTER and CAUSED_BY are reversed so that chains always point forward in time.
+func temporalEdge(rel *models.ExtractedRelationship) (fromID, toID, edgeType string, ok bool) {
+	relType := strings.ToUpper(strings.TrimSpace(rel.Type))
+	edgeType, ok = temporalEdgeTypes[relType]
+	if !ok || rel.FromID == "" || rel.ToID == "" {
+		return "", "", "", false
 	}
-	return t
+	if relType == "AFTER" || relType == "CAUSED_BY" {
+		return rel.ToID, rel.FromID, edgeType, true
+	}
+	return rel.FromID, rel.ToID, edgeType, true
+}
+
+// enrichmentFields are the metadata keys produced by article enrichment, and the
+// source reliability its risk score was weighted by
+var enrichmentFields = []string{"summary", "topics", "sentiment", "risk_score", "source_reliability"}
+
+// articleEnrichment returns the enrichment fields present in the article metadata
+func articleEnrichment(article *models.Article) map[string]interface{} {
+	enrichment := make(map[string]interface{})
+	for _, field := range enrichmentFields {
+		if value, ok := article.Metadata[field]; ok && value != nil {
+			enrichment[field] = value
+		}
+	}
+	return enrichment
+}
+
+// indicatorProperties returns the known corruption indicators as boolean properties,
+// so flagged nodes can be queried directly (e.g. WHERE e.conflict_of_interest)
+func indicatorProperties(indicators []string) map[string]interface{} {
+	known := make(map[string]bool, len(models.CorruptionIndicators))
+	for _, flag := range models.CorruptionIndicators {
+		known[flag] = true
+	}
+
+	flags := make(map[string]interface{})
+	for _, indicator := range indicators {
+		if known[indicator] {
+			flags[indicator] = true
+		}
+	}
+	return flags
+}
+
+// liftedFields are the properties copied to the top level of nodes and relationships
+var liftedFields = []string{"amount", "amount_value", "currency", "date", "date_rfc3339", "date_precision",
+	"wikidata_id", "wikidata_label", "wikidata_description", "source_reliability", "evidence_verified"}
+
+// liftedProperties lifts raw and normalized amounts and dates, authority links and
+// evidence verification out of the properties map, so they can be filtered, summed,
+// sorted and matched directly (e.g. sum(r.amount_value), ORDER BY e.date_rfc3339,
+// {wikidata_id: $id} or WHERE r.evidence_verified)
+func liftedProperties(props map[string]interface{}) map[string]interface{} {
+	lifted := make(map[string]interface{})
+	for _, key := range liftedFields {
+		if value, ok := props[key]; ok {
+			lifted[key] = value
+		}
+	}
+	return lifted
+}
+
+// articleFromNode builds an article from an Article node. Older and partially written
+// nodes may lack properties or store them in another shape, so every field is read
+// with an accessor that falls back to its zero value instead of panicking.
+func articleFromNode(node neo4j.Node) *models.Article {
+	props := node.Props
+	return &models.Article{
+		ID:          stringProp(props, "id"),
+		URL:         stringProp(props, "url"),
+		Title:       stringProp(props, "title"),
+		Content:     stringProp(props, "content"),
+		Source:      stringProp(props, "source"),
+		Author:      stringProp(props, "author"),
+		Language:    stringProp(props, "language"),
+		ContentHash: stringProp(props, "contentHash"),
+		PublishDate: timeProp(props, "publishDate"),
+		ExtractedAt: timeProp(props, "extractedAt"),
+		Metadata:    mapProp(props, "metadata"),
+	}
+}
+
+// stringProp returns a string property, or "" when it is missing or not a string
+func stringProp(props map[string]interface{}, key string) string {
+	s, _ := props[key].(string)
+	return s
+}
+
+// timeProp returns a temporal property stored either as a Neo4j datetime or as an
+// RFC3339 string, or the zero time when it is missing or unparseable
+func timeProp(props map[string]interface{}, key string) time.Time {
+	switch v := props[key].(type) {
+	case time.Time:
+		return v
+	case neo4j.LocalDateTime:
+		return v.Time()
+	case neo4j.Date:
+		return v.Time()
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// mapProp returns a map property, decoding maps stored as JSON strings, or nil when
+// it is missing or holds anything else
+func mapProp(props map[string]interface{}, key string) map[string]interface{} {
+	switch v := props[key].(type) {
+	case map[string]interface{}:
+		return v
+	case string:
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &m); err == nil {
+			return m
+		}
+	}
+	return nil
 }
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// ErrDuplicateContent is returned when saving an article whose content hash
+// belongs to another stored article
+var ErrDuplicateContent error = NewError(ErrConflict, "article content already stored")
+
+// checkDuplicateContent fails with ErrDuplicateContent when another article
+// already stores the article's content hash
+func checkDuplicateContent(tx neo4j.Transaction, article *models.Article) error {
+	if article.ContentHash == "" {
+		return nil
+	}
+
+	result, err := tx.Run(`
+		MATCH (a:Article {contentHash: $contentHash})
+		WHERE a.id <> $id
+		RETURN a.id
+		LIMIT 1
+	`, map[string]interface{}{"contentHash": article.ContentHash, "id": article.ID})
+	if err != nil {
+		return fmt.Errorf("failed to check content hash: %w", err)
+	}
+	if result.Next() {
+		existingID, _ := result.Record().Values[0].(string)
+		return fmt.Errorf("%w: matches article %s", ErrDuplicateContent, existingID)
+	}
+	return result.Err()
+}
+
+// FindArticleByContentHash returns the stored article with the given content
+// hash, including its entities and relationships, or nil when there is none
+func (s *ArticleStore) FindArticleByContentHash(hash string) (*models.Article, error) {
+	result, err := s.transaction(neo4j.AccessModeRead, func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := tx.Run(`
+			MATCH (a:Article {contentHash: $contentHash})
+			OPTIONAL MATCH (a)-[:MENTIONS]->(e:Entity)
+			WITH a, collect(e) as entities
+			OPTIONAL MATCH (a)-[:MENTIONS]->(:Entity)-[r:RELATES_TO]->(:Entity)<-[:MENTIONS]-(a)
+			RETURN a, entities, collect(DISTINCT {rel: r, fromId: startNode(r).id, toId: endNode(r).id}) as relations
+			LIMIT 1
+		`, map[string]interface{}{"contentHash": hash})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query article by content hash: %w", err)
+		}
+		if !records.Next() {
+			return nil, records.Err()
+		}
+		return articleWithExtraction(records.Record()), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+	return result.(*models.Article), nil
+}
+
+// articleWithExtraction builds an article from a record of (article, entities, relations)
+func articleWithExtraction(record *neo4j.Record) *models.Article {
+	node, _ := record.Values[0].(neo4j.Node)
+	article := articleFromNode(node)
+
+	entities, _ := record.Values[1].([]interface{})
+	for _, value := range entities {
+		entityNode, ok := value.(neo4j.Node)
+		if !ok {
+			continue
+		}
+		entity := &models.ExtractedEntity{ArticleID: article.ID}
+		entity.ID, _ = entityNode.Props["id"].(string)
+		entity.Type, _ = entityNode.Props["type"].(string)
+		entity.Name, _ = entityNode.Props["name"].(string)
+		entity.Confidence, _ = entityNode.Props["confidence"].(float64)
+		entity.Properties, _ = entityNode.Props["properties"].(map[string]interface{})
+		article.Entities = append(article.Entities, entity)
+	}
+
+	relations, _ := record.Values[2].([]interface{})
+	for _, value := range relations {
+		row, _ := value.(map[string]interface{})
+		relationship, ok := row["rel"].(neo4j.Relationship)
+		if !ok {
+			continue
+		}
+		rel := &models.ExtractedRelationship{ArticleID: article.ID}
+		rel.ID, _ = relationship.Props["id"].(string)
+		rel.Type, _ = relationship.Props["type"].(string)
+		rel.FromID, _ = row["fromId"].(string)
+		rel.ToID, _ = row["toId"].(string)
+		rel.Confidence, _ = relationship.Props["confidence"].(float64)
+		rel.Properties, _ = relationship.Props["properties"].(map[string]interface{})
+		article.Relations = append(article.Relations, rel)
+	}
+
+	return article
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// DefaultDedupBatchSize is how many groups of parallel edges one write transaction merges
+const DefaultDedupBatchSize = 100
+
+// unionedRelationshipProperties are list properties combined across merged duplicates
+var unionedRelationshipProperties = []string{"evidence", "source_urls"}
+
+// DedupReport summarizes a duplicate-relationship cleanup
+type DedupReport struct {
+	Groups  int `json:"groups"`  // Node pairs that had parallel edges of one type
+	Removed int `json:"removed"` // Duplicate edges deleted after merging into a survivor
+	Batches int `json:"batches"` // Write transactions used
+}
+
+// DeduplicateRelationships collapses parallel edges of the same type (and, for
+// RELATES_TO edges, the same type property) between the same ordered node pair.
+// Each group keeps its oldest edge, which takes the highest confidence, the union of
+// evidence and source_urls, and any property only a duplicate had. Groups are merged
+// batchSize at a time in separate write transactions until none are left, so the job
+// is safe to re-run and an interrupted run only leaves unmerged groups behind.
+func DeduplicateRelationships(batchSize int) (DedupReport, error) {
+	if batchSize <= 0 {
+		batchSize = DefaultDedupBatchSize
+	}
+
+	var report DedupReport
+	for {
+		result, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+			return deduplicateRelationshipBatch(tx, batchSize)
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to deduplicate relationships: %w", err)
+		}
+
+		batch := result.(DedupReport)
+		if batch.Groups == 0 {
+			return report, nil
+		}
+		report.Groups += batch.Groups
+		report.Removed += batch.Removed
+		report.Batches++
+	}
+}
+
+// deduplicateRelationshipBatch merges up to batchSize groups of parallel edges
+func deduplicateRelationshipBatch(tx neo4j.Transaction, batchSize int) (DedupReport, error) {
+	result, err := tx.Run(`
+		MATCH (a)-[r]->(b)
+		WITH a, b, type(r) AS relType, coalesce(r.type, '') AS subtype, collect(r) AS rels
+		WHERE size(rels) > 1
+		WITH rels LIMIT $batchSize
+		RETURN [x IN rels | {id: ID(x), props: properties(x)}] AS rels
+	`, map[string]interface{}{"batchSize": batchSize})
+	if err != nil {
+		return DedupReport{}, fmt.Errorf("failed to find duplicate relationships: %w", err)
+	}
+
+	var groups [][]relationshipProps
+	for result.Next() {
+		rows, _ := result.Record().Values[0].([]interface{})
+		var group []relationshipProps
+		for _, row := range rows {
+			values, ok := row.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			id, _ := values["id"].(int64)
+			props, _ := values["props"].(map[string]interface{})
+			group = append(group, relationshipProps{id: id, props: props})
+		}
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return DedupReport{}, fmt.Errorf("failed to read duplicate relationships: %w", err)
+	}
+
+	var report DedupReport
+	for _, group := range groups {
+		keepID, updates, duplicateIDs := mergeRelationshipGroup(group)
+		_, err := tx.Run(`
+			MATCH ()-[keep]->() WHERE ID(keep) = $keepId
+			SET keep += $updates
+			WITH keep
+			MATCH ()-[dup]->() WHERE ID(dup) IN $duplicateIds
+			DELETE dup
+		`, map[string]interface{}{
+			"keepId":       keepID,
+			"updates":      updates,
+			"duplicateIds": duplicateIDs,
+		})
+		if err != nil {
+			return report, fmt.Errorf("failed to merge duplicate relationships into %d: %w", keepID, err)
+		}
+		report.Groups++
+		report.Removed += len(duplicateIDs)
+	}
+	return report, nil
+}
+
+// relationshipProps is one edge of a duplicate group
+type relationshipProps struct {
+	id    int64
+	props map[string]interface{}
+}
+
+// mergeRelationshipGroup picks the edge with the lowest ID as the survivor and returns
+// the property updates that fold the other edges into it
+func mergeRelationshipGroup(group []relationshipProps) (keepID int64, updates map[string]interface{}, duplicateIDs []int64) {
+	sort.Slice(group, func(i, j int) bool { return group[i].id < group[j].id })
+	keep := group[0]
+	updates = make(map[string]interface{})
+
+	confidence, hasConfidence := numericProperty(keep.props["confidence"])
+	unions := make(map[string][]interface{})
+	for _, name := range unionedRelationshipProperties {
+		unions[name] = appendUniqueValues(nil, keep.props[name])
+	}
+
+	for _, duplicate := range group[1:] {
+		duplicateIDs = append(duplicateIDs, duplicate.id)
+		for key, value := range duplicate.props {
+			if _, ok := keep.props[key]; !ok {
+				if _, set := updates[key]; !set {
+					updates[key] = value
+				}
+			}
+		}
+		if c, ok := numericProperty(duplicate.props["confidence"]); ok && (!hasConfidence || c > confidence) {
+			confidence, hasConfidence = c, true
+		}
+		for _, name := range unionedRelationshipProperties {
+			unions[name] = appendUniqueValues(unions[name], duplicate.props[name])
+		}
+	}
+
+	if hasConfidence {
+		updates["confidence"] = confidence
+	}
+	for name, values := range unions {
+		if len(values) > 0 {
+			updates[name] = values
+		}
+	}
+	return keep.id, updates, duplicateIDs
+}
+
+// appendUniqueValues adds a property value, or each element of a list value, to values
+func appendUniqueValues(values []interface{}, value interface{}) []interface{} {
+	var items []interface{}
+	switch v := value.(type) {
+	case nil:
+		return values
+	case []interface{}:
+		items = v
+	case []string:
+		for _, s := range v {
+			items = append(items, s)
+		}
+	default:
+		items = []interface{}{v}
+	}
+
+	for _, item := range items {
+		seen := false
+		for _, existing := range values {
+			if reflect.DeepEqual(existing, item) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func numericProperty(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
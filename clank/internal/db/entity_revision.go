@@ -0,0 +1,200 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// revisionFields are the entity properties whose changes are recorded in revisions;
+// keys of the properties map are tracked individually as "properties.<key>"
+var revisionFields = []string{"type", "name", "aliases", "indicators", "confidence"}
+
+// PropertyChange is the value of one property before and after a revision
+type PropertyChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// EntityRevision is one append-only record of how an article changed an entity
+type EntityRevision struct {
+	Revision  int                       `json:"revision"` // 1 for the revision that created the entity
+	EntityID  string                    `json:"entityId"`
+	ArticleID string                    `json:"articleId"`
+	SourceURL string                    `json:"sourceUrl,omitempty"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	Changes   map[string]PropertyChange `json:"changes"`
+}
+
+// entityState returns the stored entity's tracked properties, or nil when it
+// doesn't exist yet
+func entityState(tx neo4j.Transaction, id string) (map[string]interface{}, error) {
+	result, err := tx.Run(`
+		MATCH (e:Entity {id: $id})
+		RETURN e
+	`, map[string]interface{}{"id": id})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entity state: %w", err)
+	}
+	if !result.Next() {
+		return nil, result.Err()
+	}
+	node, _ := result.Record().Values[0].(neo4j.Node)
+	if node.Props == nil {
+		return map[string]interface{}{}, nil
+	}
+	return node.Props, nil
+}
+
+// entityChanges diffs the stored properties before an update with the entity
+// being written. Every field of a new entity (before == nil) counts as changed.
+func entityChanges(before map[string]interface{}, entity *models.ExtractedEntity) map[string]PropertyChange {
+	after := map[string]interface{}{
+		"type":       entity.Type,
+		"name":       entity.Name,
+		"aliases":    entity.Aliases,
+		"indicators": entity.Indicators,
+		"confidence": entity.Confidence,
+	}
+
+	changes := make(map[string]PropertyChange)
+	for _, field := range revisionFields {
+		if from, to := before[field], after[field]; !sameRevisionValue(from, to) {
+			changes[field] = PropertyChange{From: from, To: to}
+		}
+	}
+
+	oldProps, _ := before["properties"].(map[string]interface{})
+	for key, to := range entity.Properties {
+		if from := oldProps[key]; !sameRevisionValue(from, to) {
+			changes["properties."+key] = PropertyChange{From: from, To: to}
+		}
+	}
+	for key, from := range oldProps {
+		if _, ok := entity.Properties[key]; !ok {
+			changes["properties."+key] = PropertyChange{From: from}
+		}
+	}
+	return changes
+}
+
+// sameRevisionValue compares a stored value with a new one, treating empty lists
+// as absent and ignoring how the driver typed lists and numbers
+func sameRevisionValue(stored, value interface{}) bool {
+	normalize := func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case []string:
+			if len(v) == 0 {
+				return nil
+			}
+			items := make([]interface{}, len(v))
+			for i, s := range v {
+				items[i] = s
+			}
+			return items
+		case []interface{}:
+			if len(v) == 0 {
+				return nil
+			}
+		case int64:
+			return float64(v)
+		case int:
+			return float64(v)
+		}
+		return v
+	}
+	return reflect.DeepEqual(normalize(stored), normalize(value))
+}
+
+// saveEntityRevision appends a revision recording the changes an article made to
+// an entity. Nothing is written when the article changed nothing.
+func saveEntityRevision(tx neo4j.Transaction, entityID string, article *models.Article, changes map[string]PropertyChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	// Neo4j properties can't hold nested maps, so the diff is stored as JSON
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode entity revision: %w", err)
+	}
+
+	_, err = tx.Run(`
+		MATCH (e:Entity {id: $entityId})
+		OPTIONAL MATCH (e)<-[:REVISION_OF]-(previous:EntityRevision)
+		WITH e, count(previous) AS revisions
+		CREATE (rev:EntityRevision {
+			entityId: $entityId,
+			revision: revisions + 1,
+			articleId: $articleId,
+			sourceUrl: $sourceUrl,
+			changes: $changes,
+			createdAt: datetime($createdAt)
+		})-[:REVISION_OF]->(e)
+	`, map[string]interface{}{
+		"entityId":  entityID,
+		"articleId": article.ID,
+		"sourceUrl": article.URL,
+		"changes":   string(encoded),
+		"createdAt": time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record entity revision: %w", err)
+	}
+	return nil
+}
+
+// GetEntityHistory returns an entity's revisions, oldest first
+func GetEntityHistory(entityID string) ([]EntityRevision, error) {
+	result, err := ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		return entityHistory(tx, entityID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read entity history: %w", err)
+	}
+	return result.([]EntityRevision), nil
+}
+
+func entityHistory(tx neo4j.Transaction, entityID string) ([]EntityRevision, error) {
+	result, err := tx.Run(`
+		MATCH (rev:EntityRevision {entityId: $entityId})
+		RETURN rev
+		ORDER BY rev.revision, rev.createdAt
+	`, map[string]interface{}{"entityId": entityID})
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := []EntityRevision{}
+	for result.Next() {
+		node, ok := result.Record().Values[0].(neo4j.Node)
+		if !ok {
+			continue
+		}
+		revision := EntityRevision{
+			EntityID:  stringProp(node.Props, "entityId"),
+			ArticleID: stringProp(node.Props, "articleId"),
+			SourceURL: stringProp(node.Props, "sourceUrl"),
+			CreatedAt: timeProp(node.Props, "createdAt"),
+		}
+		if n, ok := node.Props["revision"].(int64); ok {
+			revision.Revision = int(n)
+		}
+		if changes := stringProp(node.Props, "changes"); changes != "" {
+			if err := json.Unmarshal([]byte(changes), &revision.Changes); err != nil {
+				return nil, fmt.Errorf("failed to decode revision %d of %s: %w", revision.Revision, entityID, err)
+			}
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// Kinds of database error. Handlers match them with errors.Is to choose a
+// status code rather than comparing messages, which wrapping changes.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrValidation  = errors.New("invalid request")
+	ErrConflict    = errors.New("conflict")
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// Error is an error of one of the kinds above. Msg is what clients are shown;
+// Err, when set, is the underlying cause.
+type Error struct {
+	Kind error
+	Msg  string
+	Err  error
+}
+
+// NewError returns an error of the given kind with msg as its message
+func NewError(kind error, msg string) *Error {
+	return &Error{Kind: kind, Msg: msg}
+}
+
+func (e *Error) Error() string {
+	if e.Msg == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// neo4jErrors maps Neo4j status codes to the kind of error they are
+var neo4jErrors = map[string]*Error{
+	"Neo.ClientError.Schema.ConstraintValidationFailed": NewError(ErrConflict, "Conflicts with existing data"),
+	"Neo.ClientError.Statement.TypeError":               NewError(ErrValidation, "Invalid data type in request"),
+	"Neo.ClientError.Statement.ParameterMissing":        NewError(ErrValidation, "Missing required parameters"),
+	"Neo.ClientError.Statement.ArgumentError":           NewError(ErrValidation, "Invalid argument in request"),
+}
+
+// classifyError gives Neo4j errors with a known status code and lost
+// connections their kind, leaving other errors as they are
+func classifyError(err error) error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return err
+	}
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		if known, ok := neo4jErrors[neo4jErr.Code]; ok {
+			return &Error{Kind: known.Kind, Msg: known.Msg, Err: err}
+		}
+	}
+	if isConnectionError(err) {
+		return &Error{Kind: ErrUnavailable, Msg: "database connection lost", Err: err}
+	}
+	return err
+}
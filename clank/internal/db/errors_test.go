@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	constraint := &neo4j.Neo4jError{Code: "Neo.ClientError.Schema.ConstraintValidationFailed", Msg: "already exists"}
+	err := classifyError(constraint)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.ErrorIs(t, err, constraint, "the cause is kept")
+
+	missing := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.ParameterMissing", Msg: "Expected parameter(s): id"}
+	assert.ErrorIs(t, classifyError(missing), ErrValidation)
+
+	lost := &neo4j.ConnectivityError{}
+	assert.ErrorIs(t, classifyError(lost), ErrUnavailable)
+
+	syntax := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "bad query"}
+	assert.Same(t, error(syntax), classifyError(syntax), "unknown codes are left alone")
+
+	notFound := fmt.Errorf("read transaction failed: %w", NewError(ErrNotFound, "article not found"))
+	assert.Same(t, notFound, classifyError(notFound))
+	assert.True(t, errors.Is(notFound, ErrNotFound))
+	assert.ErrorIs(t, ErrCircuitOpen, ErrUnavailable)
+	assert.ErrorIs(t, ErrDuplicateContent, ErrConflict)
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+
+	"clank/internal/models"
+)
+
+// involvedInEdge is the edge from an entity to an event it took part in when
+// its role is unknown or has no edge type of its own
+const involvedInEdge = "INVOLVED_IN"
+
+// DefaultEventRoleEdges are the edge types of the roles deep analysis assigns
+// to the entities involved in an event
+var DefaultEventRoleEdges = map[string]string{
+	"perpetrator":  "PERPETRATED",
+	"victim":       "VICTIM_OF",
+	"investigator": "INVESTIGATED",
+	"witness":      "WITNESSED",
+	"beneficiary":  "BENEFITED_FROM",
+}
+
+// involvementTypes are the extracted relationship types linking an entity to an event it took part in
+var involvementTypes = map[string]bool{"INVOLVEMENT": true, "INVOLVED_IN": true}
+
+// edgeTypeRegex matches the edge types that can be written into Cypher
+var edgeTypeRegex = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+var eventRoleEdges atomic.Pointer[map[string]string]
+
+// SetEventRoleEdges replaces the edge types stored for event roles, keyed by
+// role; empty restores DefaultEventRoleEdges. Edge types must be upper case
+// identifiers, e.g. PERPETRATED.
+func SetEventRoleEdges(edges map[string]string) error {
+	if len(edges) == 0 {
+		eventRoleEdges.Store(nil)
+		return nil
+	}
+	normalized := make(map[string]string, len(edges))
+	for role, edgeType := range edges {
+		if !edgeTypeRegex.MatchString(edgeType) {
+			return fmt.Errorf("invalid edge type %q for event role %q", edgeType, role)
+		}
+		normalized[roleKey(role)] = edgeType
+	}
+	eventRoleEdges.Store(&normalized)
+	return nil
+}
+
+// roleKey normalizes a role for lookup: lower case with spaces and hyphens as underscores
+func roleKey(role string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(role)))
+}
+
+// involvementEdge returns the typed edge to store for an entity's involvement
+// in an event, pointing from the entity to the event, and the role the entity
+// played ("" when the relationship has none). Roles without an edge type of
+// their own, and missing roles, use INVOLVED_IN.
+func involvementEdge(rel *models.ExtractedRelationship, events map[string]bool) (fromID, toID, edgeType, role string, ok bool) {
+	if !involvementTypes[strings.ToUpper(strings.TrimSpace(rel.Type))] || rel.FromID == "" || rel.ToID == "" {
+		return "", "", "", "", false
+	}
+	fromID, toID = rel.FromID, rel.ToID
+	switch {
+	case events[toID] && !events[fromID]:
+	case events[fromID] && !events[toID]:
+		fromID, toID = toID, fromID
+	default:
+		return "", "", "", "", false
+	}
+
+	role, _ = rel.Properties["role"].(string)
+	role = roleKey(role)
+	edges := DefaultEventRoleEdges
+	if configured := eventRoleEdges.Load(); configured != nil {
+		edges = *configured
+	}
+	edgeType, known := edges[role]
+	if !known {
+		edgeType = involvedInEdge
+	}
+	return fromID, toID, edgeType, role, true
+}
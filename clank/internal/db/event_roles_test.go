@@ -0,0 +1,132 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statementTx records the statements run in it and returns no rows
+type statementTx struct {
+	neo4j.Transaction
+	statements []string
+	params     []map[string]interface{}
+}
+
+func (tx *statementTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.statements = append(tx.statements, cypher)
+	tx.params = append(tx.params, params)
+	return &rowsResult{index: -1}, nil
+}
+
+// edge returns the parameters of the statement merging an edge of edgeType, or nil
+func (tx *statementTx) edge(edgeType string) map[string]interface{} {
+	for i, cypher := range tx.statements {
+		if strings.Contains(cypher, "MERGE (from)-[t:"+edgeType+"]->(to)") {
+			return tx.params[i]
+		}
+	}
+	return nil
+}
+
+func eventArticle(rels ...*models.ExtractedRelationship) *models.Article {
+	return &models.Article{
+		ID:      "a1",
+		URL:     "https://example.com/bribery",
+		Content: "The mayor took a bribe from the contractor.",
+		Entities: []*models.ExtractedEntity{
+			{ID: "mayor", Type: "person", Name: "Mayor Smith"},
+			{ID: "acme", Type: "organization", Name: "Acme Builders"},
+			{ID: "bribe", Type: "event", Name: "Bribe payment"},
+		},
+		Relations: rels,
+	}
+}
+
+func TestSaveArticle_EventRoleEdge(t *testing.T) {
+	tx := &statementTx{}
+	article := eventArticle(&models.ExtractedRelationship{
+		ID: "r1", Type: "involvement", FromID: "mayor", ToID: "bribe", Confidence: 0.8,
+		Properties: map[string]interface{}{"role": "Perpetrator"},
+	})
+
+	require.NoError(t, saveArticle(tx, article))
+
+	edge := tx.edge("PERPETRATED")
+	require.NotNil(t, edge, "a perpetrator gets a PERPETRATED edge")
+	assert.Equal(t, "mayor", edge["fromId"])
+	assert.Equal(t, "bribe", edge["toId"])
+	assert.Equal(t, "perpetrator", edge["role"])
+	assert.Equal(t, 0.8, edge["confidence"])
+	assert.Nil(t, tx.edge(involvedInEdge))
+}
+
+func TestSaveArticle_EventRoleFallback(t *testing.T) {
+	tx := &statementTx{}
+	article := eventArticle(
+		// Unknown role, pointing from the event to the entity
+		&models.ExtractedRelationship{ID: "r1", Type: "INVOLVED_IN", FromID: "bribe", ToID: "acme", Properties: map[string]interface{}{"role": "payer"}},
+		// Not involvement in an event
+		&models.ExtractedRelationship{ID: "r2", Type: "payment", FromID: "acme", ToID: "mayor"},
+	)
+
+	require.NoError(t, saveArticle(tx, article))
+
+	edge := tx.edge(involvedInEdge)
+	require.NotNil(t, edge)
+	assert.Equal(t, "acme", edge["fromId"], "involvement edges point from the entity to the event")
+	assert.Equal(t, "bribe", edge["toId"])
+	assert.Equal(t, "payer", edge["role"], "the role is kept on the fallback edge")
+	for _, cypher := range tx.statements {
+		assert.NotContains(t, cypher, "MERGE (from)-[t:PAYMENT]")
+	}
+}
+
+func TestInvolvementEdge(t *testing.T) {
+	events := map[string]bool{"bribe": true, "arrest": true}
+	involvement := func(from, to string, role interface{}) *models.ExtractedRelationship {
+		rel := &models.ExtractedRelationship{Type: "involvement", FromID: from, ToID: to}
+		if role != nil {
+			rel.Properties = map[string]interface{}{"role": role}
+		}
+		return rel
+	}
+
+	_, _, edgeType, role, ok := involvementEdge(involvement("mayor", "bribe", nil), events)
+	require.True(t, ok)
+	assert.Equal(t, involvedInEdge, edgeType)
+	assert.Empty(t, role)
+
+	_, _, edgeType, _, ok = involvementEdge(involvement("police", "arrest", "investigator"), events)
+	require.True(t, ok)
+	assert.Equal(t, "INVESTIGATED", edgeType)
+
+	_, _, _, _, ok = involvementEdge(involvement("bribe", "arrest", "perpetrator"), events)
+	assert.False(t, ok, "event to event is ordering, not involvement")
+
+	_, _, _, _, ok = involvementEdge(involvement("mayor", "acme", "perpetrator"), events)
+	assert.False(t, ok, "no event involved")
+}
+
+func TestSetEventRoleEdges(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, SetEventRoleEdges(nil)) })
+
+	require.NoError(t, SetEventRoleEdges(map[string]string{"Whistle Blower": "EXPOSED"}))
+	rel := &models.ExtractedRelationship{Type: "involvement", FromID: "clerk", ToID: "bribe", Properties: map[string]interface{}{"role": "whistle-blower"}}
+	_, _, edgeType, role, ok := involvementEdge(rel, map[string]bool{"bribe": true})
+	require.True(t, ok)
+	assert.Equal(t, "EXPOSED", edgeType)
+	assert.Equal(t, "whistle_blower", role)
+
+	// Configured roles replace the built-in ones
+	rel.Properties["role"] = "perpetrator"
+	_, _, edgeType, _, _ = involvementEdge(rel, map[string]bool{"bribe": true})
+	assert.Equal(t, involvedInEdge, edgeType)
+
+	assert.Error(t, SetEventRoleEdges(map[string]string{"victim": "VICTIM OF]->(x) DETACH DELETE x //"}))
+}
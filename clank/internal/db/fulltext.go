@@ -0,0 +1,28 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// FullTextIndex is the full-text index over article titles and content and entity names,
+// created by EnsureSchema
+const FullTextIndex = "article_entity_text"
+
+// IsFullTextUnavailable reports whether err means the full-text index can't be
+// queried, because it doesn't exist (yet) or the server lacks full-text procedures
+func IsFullTextUnavailable(err error) bool {
+	var neoErr *neo4j.Neo4jError
+	if !errors.As(err, &neoErr) {
+		return false
+	}
+	switch neoErr.Code {
+	case "Neo.ClientError.Procedure.ProcedureNotFound":
+		return true
+	case "Neo.ClientError.Procedure.ProcedureCallFailed":
+		return strings.Contains(strings.ToLower(neoErr.Msg), "index")
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+const (
+	defaultEntityQueryLimit = 5
+	maxEntityQueryLimit     = 25
+	// maxNeighborRelationships caps the relationships returned per entity, most
+	// confident first
+	maxNeighborRelationships = 20
+)
+
+// EntityQuery selects entities and their immediate relationships. It is a fixed,
+// parameterized read query; callers can't supply Cypher.
+type EntityQuery struct {
+	ID               string // Entity ID; when set, Name is ignored
+	Name             string // Matched against names and aliases ignoring case, as a substring
+	Type             string // Entity type, e.g. person (empty matches any)
+	RelationshipType string // Only relationships of this type, e.g. payment (empty includes all)
+	Limit            int    // Entities returned (0 uses 5, at most 25)
+}
+
+// EntityNeighborhood is an entity with the relationships linking it to other entities
+type EntityNeighborhood struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Type          string                 `json:"type"`
+	Aliases       []string               `json:"aliases,omitempty"`
+	Relationships []NeighborRelationship `json:"relationships"`
+}
+
+// NeighborRelationship is one relationship of an EntityNeighborhood
+type NeighborRelationship struct {
+	Type       string  `json:"type"`
+	Outgoing   bool    `json:"outgoing"` // From the entity to the other one
+	OtherID    string  `json:"otherId"`
+	OtherName  string  `json:"otherName"`
+	OtherType  string  `json:"otherType"`
+	Confidence float64 `json:"confidence"`
+}
+
+// entityQueryCypher finds entities by ID or by name/alias substring, exact names
+// first, with their most confident relationships
+const entityQueryCypher = `
+	MATCH (e:Entity)
+	WHERE ($id <> '' AND e.id = $id)
+	   OR ($id = '' AND (toLower(e.name) CONTAINS $name
+	       OR any(alias IN coalesce(e.aliases, []) WHERE toLower(alias) CONTAINS $name)))
+	WITH e
+	WHERE $type = '' OR toLower(e.type) = $type
+	WITH e ORDER BY toLower(e.name) = $name DESC, size(e.name), e.id
+	LIMIT $limit
+	OPTIONAL MATCH (e)-[r:RELATES_TO]-(other:Entity)
+	WHERE $relationshipType = '' OR toLower(r.type) = $relationshipType
+	WITH e, r, other ORDER BY coalesce(r.confidence, 0) DESC
+	WITH e, collect(CASE WHEN r IS NULL THEN NULL ELSE {
+		type: r.type, outgoing: startNode(r) = e, otherId: other.id,
+		otherName: other.name, otherType: other.type, confidence: r.confidence
+	} END)[..$maxRelationships] AS relationships
+	RETURN e.id, e.name, e.type, coalesce(e.aliases, []), relationships
+`
+
+// QueryEntities returns the entities matching query with their immediate
+// relationships, in a read transaction
+func QueryEntities(query EntityQuery) ([]EntityNeighborhood, error) {
+	result, err := ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		records, err := tx.Run(entityQueryCypher, entityQueryParams(query))
+		if err != nil {
+			return nil, err
+		}
+		return entityNeighborhoods(records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]EntityNeighborhood), nil
+}
+
+// entityQueryParams normalizes query into the Cypher parameters
+func entityQueryParams(query EntityQuery) map[string]interface{} {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultEntityQueryLimit
+	}
+	limit = min(limit, maxEntityQueryLimit)
+	return map[string]interface{}{
+		"id":               strings.TrimSpace(query.ID),
+		"name":             strings.ToLower(strings.Join(strings.Fields(query.Name), " ")),
+		"type":             strings.ToLower(strings.TrimSpace(query.Type)),
+		"relationshipType": strings.ToLower(strings.TrimSpace(query.RelationshipType)),
+		"limit":            int64(limit),
+		"maxRelationships": int64(maxNeighborRelationships),
+	}
+}
+
+// entityNeighborhoods reads the rows returned by entityQueryCypher
+func entityNeighborhoods(records neo4j.Result) ([]EntityNeighborhood, error) {
+	neighborhoods := make([]EntityNeighborhood, 0)
+	for records.Next() {
+		values := records.Record().Values
+		entity := EntityNeighborhood{Relationships: make([]NeighborRelationship, 0)}
+		entity.ID, _ = values[0].(string)
+		entity.Name, _ = values[1].(string)
+		entity.Type, _ = values[2].(string)
+		if aliases, ok := values[3].([]interface{}); ok {
+			for _, alias := range aliases {
+				if s, ok := alias.(string); ok {
+					entity.Aliases = append(entity.Aliases, s)
+				}
+			}
+		}
+		if relationships, ok := values[4].([]interface{}); ok {
+			for _, value := range relationships {
+				rel, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				neighbor := NeighborRelationship{}
+				neighbor.Type, _ = rel["type"].(string)
+				neighbor.Outgoing, _ = rel["outgoing"].(bool)
+				neighbor.OtherID, _ = rel["otherId"].(string)
+				neighbor.OtherName, _ = rel["otherName"].(string)
+				neighbor.OtherType, _ = rel["otherType"].(string)
+				neighbor.Confidence, _ = rel["confidence"].(float64)
+				entity.Relationships = append(entity.Relationships, neighbor)
+			}
+		}
+		neighborhoods = append(neighborhoods, entity)
+	}
+	return neighborhoods, records.Err()
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityQueryParams(t *testing.T) {
+	params := entityQueryParams(EntityQuery{Name: "  Acme   Corp ", Type: " Company", RelationshipType: "PAYMENT "})
+	assert.Equal(t, "", params["id"])
+	assert.Equal(t, "acme corp", params["name"])
+	assert.Equal(t, "company", params["type"])
+	assert.Equal(t, "payment", params["relationshipType"])
+	assert.Equal(t, int64(defaultEntityQueryLimit), params["limit"])
+
+	params = entityQueryParams(EntityQuery{ID: "e-1", Limit: 1000})
+	assert.Equal(t, "e-1", params["id"])
+	assert.Equal(t, int64(maxEntityQueryLimit), params["limit"])
+}
+
+func TestEntityNeighborhoods(t *testing.T) {
+	result := &rowsResult{index: -1, records: []*neo4j.Record{
+		{Values: []interface{}{"e-1", "Acme Corp", "company", []interface{}{"Acme"}, []interface{}{
+			map[string]interface{}{"type": "payment", "outgoing": true, "otherId": "e-2",
+				"otherName": "Jane Doe", "otherType": "person", "confidence": 0.9},
+			map[string]interface{}{"type": "ownership", "outgoing": false, "otherId": "e-3",
+				"otherName": "Harbor Holdings", "otherType": "company", "confidence": nil},
+		}}},
+		{Values: []interface{}{"e-4", "Acme Foundation", "organization", []interface{}{}, []interface{}{}}},
+	}}
+
+	entities, err := entityNeighborhoods(result)
+	require.NoError(t, err)
+	require.Len(t, entities, 2)
+	assert.Equal(t, EntityNeighborhood{
+		ID: "e-1", Name: "Acme Corp", Type: "company", Aliases: []string{"Acme"},
+		Relationships: []NeighborRelationship{
+			{Type: "payment", Outgoing: true, OtherID: "e-2", OtherName: "Jane Doe", OtherType: "person", Confidence: 0.9},
+			{Type: "ownership", OtherID: "e-3", OtherName: "Harbor Holdings", OtherType: "company"},
+		},
+	}, entities[0])
+	assert.Empty(t, entities[1].Aliases)
+	assert.Empty(t, entities[1].Relationships)
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// DefaultImportBatchSize is how many documents a best-effort import writes per transaction
+const DefaultImportBatchSize = 50
+
+// ImportDocument is one pre-extracted article, e.g. the output of an external
+// extraction pipeline: the article and the entities and relationships found in it
+type ImportDocument struct {
+	Article       models.Article                 `json:"article"`
+	Entities      []models.ExtractedEntity       `json:"entities"`
+	Relationships []models.ExtractedRelationship `json:"relationships"`
+}
+
+// ImportOptions controls how documents are written
+type ImportOptions struct {
+	Transactional bool                  // Write every document in one transaction, or none of them
+	BatchSize     int                   // Documents per transaction in best-effort mode (0 uses 50)
+	Progress      func(done, total int) // Called after each transaction with the documents processed so far
+}
+
+// ImportResult is the outcome of one document
+type ImportResult struct {
+	Index     int    `json:"index"` // Position of the document in the request
+	ArticleID string `json:"articleId,omitempty"`
+	Imported  bool   `json:"imported"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ImportReport is the outcome of an import
+type ImportReport struct {
+	Transactional bool           `json:"transactional"`
+	Imported      int            `json:"imported"`
+	Failed        int            `json:"failed"`
+	Results       []ImportResult `json:"results"`
+}
+
+// writeFunc runs work in a write transaction (ExecuteWrite, replaced in tests)
+type writeFunc func(work func(tx neo4j.Transaction) (interface{}, error)) (interface{}, error)
+
+// ImportDocuments validates pre-extracted documents and saves them the way
+// extracted articles are saved, merging entities into those already in the graph.
+// In transactional mode a single invalid document or failed write imports nothing.
+// Otherwise invalid documents are skipped and the rest are written in batches; when
+// a batch fails, its documents are retried one by one so one bad write doesn't sink
+// the others.
+func ImportDocuments(docs []ImportDocument, opts ImportOptions) *ImportReport {
+	return importDocuments(docs, opts, ExecuteWrite)
+}
+
+func importDocuments(docs []ImportDocument, opts ImportOptions, write writeFunc) *ImportReport {
+	report := &ImportReport{Transactional: opts.Transactional, Results: make([]ImportResult, len(docs))}
+	articles := make([]*models.Article, len(docs))
+	var valid []int
+	for i := range docs {
+		report.Results[i].Index = i
+		article, err := importArticle(&docs[i])
+		if err != nil {
+			report.Results[i].Error = err.Error()
+			continue
+		}
+		articles[i] = article
+		report.Results[i].ArticleID = article.ID
+		valid = append(valid, i)
+	}
+
+	if opts.Transactional {
+		var err error
+		if len(valid) < len(docs) {
+			err = fmt.Errorf("%d of %d documents are invalid", len(docs)-len(valid), len(docs))
+		} else {
+			err = writeArticles(write, articles, valid)
+		}
+		for i := range report.Results {
+			result := &report.Results[i]
+			switch {
+			case err == nil:
+				result.Imported = true
+			case result.Error == "":
+				result.Error = "not imported: " + err.Error()
+			}
+		}
+		if opts.Progress != nil {
+			opts.Progress(len(docs), len(docs))
+		}
+		report.tally()
+		return report
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+	done := len(docs) - len(valid)
+	for start := 0; start < len(valid); start += batchSize {
+		batch := valid[start:min(start+batchSize, len(valid))]
+		if err := writeArticles(write, articles, batch); err == nil {
+			for _, i := range batch {
+				report.Results[i].Imported = true
+			}
+		} else {
+			for _, i := range batch {
+				if err := writeArticles(write, articles, []int{i}); err != nil {
+					report.Results[i].Error = err.Error()
+				} else {
+					report.Results[i].Imported = true
+				}
+			}
+		}
+		done += len(batch)
+		if opts.Progress != nil {
+			opts.Progress(done, len(docs))
+		}
+	}
+	report.tally()
+	return report
+}
+
+// writeArticles saves the articles at the given indexes in one transaction.
+// saveArticle resolves entities onto stored ones and rewrites IDs as it goes, so
+// each attempt saves fresh copies; a rolled back attempt can't leave the articles
+// pointing at entities that were never committed.
+func writeArticles(write writeFunc, articles []*models.Article, indexes []int) error {
+	if len(indexes) == 0 {
+		return nil
+	}
+	_, err := write(func(tx neo4j.Transaction) (interface{}, error) {
+		for _, i := range indexes {
+			if err := saveArticle(tx, cloneArticle(articles[i])); err != nil {
+				return nil, fmt.Errorf("document %d: %w", i, err)
+			}
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// cloneArticle copies article along with the entities and relationships that
+// saving it modifies
+func cloneArticle(article *models.Article) *models.Article {
+	clone := *article
+	clone.Metadata = maps.Clone(article.Metadata)
+	clone.Entities = make([]*models.ExtractedEntity, len(article.Entities))
+	for i, entity := range article.Entities {
+		e := *entity
+		e.Properties = maps.Clone(entity.Properties)
+		e.PropertyConfidence = maps.Clone(entity.PropertyConfidence)
+		e.Mentions = slices.Clone(entity.Mentions)
+		e.Aliases = slices.Clone(entity.Aliases)
+		e.Indicators = slices.Clone(entity.Indicators)
+		clone.Entities[i] = &e
+	}
+	clone.Relations = make([]*models.ExtractedRelationship, len(article.Relations))
+	for i, rel := range article.Relations {
+		r := *rel
+		r.Properties = maps.Clone(rel.Properties)
+		r.Indicators = slices.Clone(rel.Indicators)
+		clone.Relations[i] = &r
+	}
+	return &clone
+}
+
+func (r *ImportReport) tally() {
+	for _, result := range r.Results {
+		if result.Imported {
+			r.Imported++
+		} else {
+			r.Failed++
+		}
+	}
+}
+
+// importArticle checks a document and builds the article to save from it. Entity
+// types are mapped onto the taxonomy, and every relationship must connect two
+// entities of the same document.
+func importArticle(doc *ImportDocument) (*models.Article, error) {
+	article := doc.Article
+	if article.URL == "" {
+		return nil, fmt.Errorf("article url is required")
+	}
+	if article.ID == "" {
+		article.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if article.ExtractedAt.IsZero() {
+		article.ExtractedAt = now
+	}
+	article.Entities = nil
+	article.Relations = nil
+
+	ids := make(map[string]bool, len(doc.Entities))
+	for i := range doc.Entities {
+		entity := doc.Entities[i]
+		if entity.ID == "" || entity.Name == "" {
+			return nil, fmt.Errorf("entity %d: id and name are required", i)
+		}
+		if ids[entity.ID] {
+			return nil, fmt.Errorf("entity %d: duplicate id %q", i, entity.ID)
+		}
+		entityType, err := models.NormalizeEntityType(entity.Type)
+		if err != nil {
+			return nil, fmt.Errorf("entity %q: %w", entity.ID, err)
+		}
+		ids[entity.ID] = true
+		entity.Type = entityType
+		entity.ArticleID = article.ID
+		if entity.ExtractedAt.IsZero() {
+			entity.ExtractedAt = now
+		}
+		article.Entities = append(article.Entities, &entity)
+	}
+
+	for i := range doc.Relationships {
+		rel := doc.Relationships[i]
+		if rel.Type == "" {
+			return nil, fmt.Errorf("relationship %d: type is required", i)
+		}
+		for _, ref := range []string{rel.FromID, rel.ToID} {
+			if !ids[ref] {
+				return nil, fmt.Errorf("relationship %d references unknown entity %q", i, ref)
+			}
+		}
+		if rel.ID == "" {
+			rel.ID = uuid.New().String()
+		}
+		rel.ArticleID = article.ID
+		if rel.ExtractedAt.IsZero() {
+			rel.ExtractedAt = now
+		}
+		article.Relations = append(article.Relations, &rel)
+	}
+	return &article, nil
+}
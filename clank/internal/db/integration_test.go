@@ -0,0 +1,128 @@
+package db
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireNeo4j connects the package to the Neo4j database at NEO4J_TEST_URI,
+// logging in as NEO4J_TEST_USER with NEO4J_TEST_PASSWORD, and empties it. The
+// test is skipped when NEO4J_TEST_URI isn't set. Never point it at real data.
+func requireNeo4j(t *testing.T) {
+	t.Helper()
+	uri := os.Getenv("NEO4J_TEST_URI")
+	if uri == "" {
+		t.Skip("NEO4J_TEST_URI not set")
+	}
+	require.NoError(t, InitDB(config.Neo4jConfig{
+		URI:      uri,
+		Username: os.Getenv("NEO4J_TEST_USER"),
+		Password: os.Getenv("NEO4J_TEST_PASSWORD"),
+	}))
+	t.Cleanup(func() { CloseDB() })
+
+	_, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return tx.Run(`MATCH (n) DETACH DELETE n`, nil)
+	})
+	require.NoError(t, err)
+}
+
+// queryStrings returns the single string column of cypher's rows
+func queryStrings(t *testing.T, cypher string, params map[string]interface{}) []string {
+	t.Helper()
+	values, err := ExecuteRead(func(tx neo4j.Transaction) (interface{}, error) {
+		result, err := tx.Run(cypher, params)
+		if err != nil {
+			return nil, err
+		}
+		var values []string
+		for result.Next() {
+			value, _ := result.Record().Values[0].(string)
+			values = append(values, value)
+		}
+		return values, result.Err()
+	})
+	require.NoError(t, err)
+	return values.([]string)
+}
+
+func TestSaveArticle_ReplacesPreviousExtraction(t *testing.T) {
+	requireNeo4j(t)
+	store := NewArticleStore()
+
+	extracted := func(entities ...*models.ExtractedEntity) *models.Article {
+		return &models.Article{
+			ID:          "contract-article",
+			URL:         "https://example.com/contract",
+			Title:       "Council awards bridge contract",
+			Content:     "The mayor awarded the bridge contract to Acme Builders.",
+			PublishDate: time.Now(),
+			ExtractedAt: time.Now(),
+			Entities:    entities,
+		}
+	}
+	require.NoError(t, store.SaveArticle(extracted(
+		&models.ExtractedEntity{ID: "mayor", Type: "person", Name: "Mayor Smith", Mentions: []models.EntityMention{{Text: "The mayor"}}},
+		&models.ExtractedEntity{ID: "acme", Type: "organization", Name: "Acme Builders", Mentions: []models.EntityMention{{Text: "Acme Builders"}}},
+	)))
+
+	// The re-extraction only finds the contractor
+	require.NoError(t, store.SaveArticle(extracted(
+		&models.ExtractedEntity{ID: "acme", Type: "organization", Name: "Acme Builders", Mentions: []models.EntityMention{{Text: "Acme Builders"}}},
+	)))
+
+	params := map[string]interface{}{"id": "contract-article"}
+	assert.Equal(t, []string{"acme"}, queryStrings(t, `
+		MATCH (:Article {id: $id})-[:MENTIONS]->(e:Entity)
+		RETURN e.id ORDER BY e.id
+	`, params))
+	assert.Equal(t, []string{"Acme Builders"}, queryStrings(t, `
+		MATCH (m:Mention {articleId: $id})
+		RETURN m.text ORDER BY m.text
+	`, params))
+	assert.Equal(t, []string{"acme", "mayor"}, queryStrings(t, `
+		MATCH (e:Entity)
+		RETURN e.id ORDER BY e.id
+	`, nil), "entities stay in the graph for other articles")
+}
+
+func TestDeduplicateRelationships_MergesParallelEdges(t *testing.T) {
+	requireNeo4j(t)
+
+	_, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return tx.Run(`
+			CREATE (a:Entity {id: 'acme'}), (b:Entity {id: 'mayor'})
+			CREATE (a)-[:RELATES_TO {type: 'payment', confidence: 0.6, evidence: ['invoice 17']}]->(b)
+			CREATE (a)-[:RELATES_TO {type: 'payment', confidence: 0.9, evidence: ['bank transfer']}]->(b)
+			CREATE (a)-[:RELATES_TO {type: 'payment', confidence: 0.7, evidence: ['invoice 17'], amount: '$50,000'}]->(b)
+			CREATE (a)-[:RELATES_TO {type: 'employment', confidence: 0.8}]->(b)
+			CREATE (b)-[:RELATES_TO {type: 'payment', confidence: 0.5}]->(a)
+		`, nil)
+	})
+	require.NoError(t, err)
+
+	result, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return deduplicateRelationshipBatch(tx, 10)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DedupReport{Groups: 1, Removed: 2}, result)
+
+	assert.Equal(t, []string{"acme employment", "acme payment", "mayor payment"}, queryStrings(t, `
+		MATCH (a)-[r:RELATES_TO]->()
+		RETURN a.id + ' ' + r.type AS edge ORDER BY edge
+	`, nil), "parallel edges of another type or direction are kept")
+
+	merged := `MATCH (:Entity {id: 'acme'})-[r:RELATES_TO {type: 'payment'}]->() `
+	assert.Equal(t, []string{"invoice 17", "bank transfer"}, queryStrings(t, merged+`UNWIND r.evidence AS e RETURN e`, nil))
+	assert.Equal(t, []string{"0.9"}, queryStrings(t, merged+`RETURN toString(r.confidence)`, nil))
+	assert.Equal(t, []string{"$50,000"}, queryStrings(t, merged+`RETURN r.amount`, nil),
+		"properties only a duplicate had are kept")
+}
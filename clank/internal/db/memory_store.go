@@ -0,0 +1,122 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"clank/internal/models"
+	"clank/pkg/extraction"
+)
+
+// Store is the article persistence the API handlers depend on. ArticleStore
+// implements it on Neo4j and MemoryStore in memory for tests.
+type Store interface {
+	SaveArticle(article *models.Article) error
+	GetArticleByID(id string) (*models.Article, error)
+	GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models.Article, error)
+	UpdateArticle(article *models.Article) error
+	FindArticleByContentHash(hash string) (*models.Article, error)
+}
+
+var (
+	_ Store = (*ArticleStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
+
+// MemoryStore keeps articles in a map, following ArticleStore's semantics:
+// unknown IDs are ErrNotFound, content hashes are unique and updates leave the
+// extracted entities and relationships alone
+type MemoryStore struct {
+	mu        sync.Mutex
+	Articles  map[string]*models.Article // Stored articles by ID; read it once the calls writing it have returned
+	SaveError error                      // Returned by SaveArticle instead of saving, e.g. to simulate an outage
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{Articles: make(map[string]*models.Article)}
+}
+
+// SaveArticle stores an article, replacing one with the same ID
+func (s *MemoryStore) SaveArticle(article *models.Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.SaveError != nil {
+		return s.SaveError
+	}
+	if article.ContentHash == "" {
+		article.ContentHash = extraction.ContentFingerprint(article.Content)
+	}
+	if existing := s.findByContentHash(article.ContentHash); existing != nil && existing.ID != article.ID {
+		return fmt.Errorf("%w: matches article %s", ErrDuplicateContent, existing.ID)
+	}
+	s.Articles[article.ID] = article
+	return nil
+}
+
+// GetArticleByID returns the article with the given ID
+func (s *MemoryStore) GetArticleByID(id string) (*models.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	article, ok := s.Articles[id]
+	if !ok {
+		return nil, NewError(ErrNotFound, "article not found")
+	}
+	return article, nil
+}
+
+// GetArticlesByTimeRange returns the articles published within the range, newest first
+func (s *MemoryStore) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var articles []*models.Article
+	for _, article := range s.Articles {
+		if !article.PublishDate.Before(startTime) && !article.PublishDate.After(endTime) {
+			articles = append(articles, article)
+		}
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].PublishDate.After(articles[j].PublishDate) })
+	return articles, nil
+}
+
+// UpdateArticle replaces the fields of a stored article, keeping its entities,
+// relationships and content hash. Unknown articles are ignored.
+func (s *MemoryStore) UpdateArticle(article *models.Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.Articles[article.ID]
+	if !ok {
+		return nil
+	}
+	updated := *article
+	updated.Entities = stored.Entities
+	updated.Relations = stored.Relations
+	updated.ContentHash = stored.ContentHash
+	s.Articles[article.ID] = &updated
+	return nil
+}
+
+// FindArticleByContentHash returns the article with the given content hash, or nil
+func (s *MemoryStore) FindArticleByContentHash(hash string) (*models.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.findByContentHash(hash), nil
+}
+
+func (s *MemoryStore) findByContentHash(hash string) *models.Article {
+	if hash == "" {
+		return nil
+	}
+	for _, article := range s.Articles {
+		if article.ContentHash == hash {
+			return article
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_SaveAndGet(t *testing.T) {
+	store := NewMemoryStore()
+	article := &models.Article{ID: "a1", Title: "Mayor charged", Content: "The mayor was charged with fraud."}
+	require.NoError(t, store.SaveArticle(article))
+	assert.NotEmpty(t, article.ContentHash, "the content hash is filled in like ArticleStore does")
+
+	got, err := store.GetArticleByID("a1")
+	require.NoError(t, err)
+	assert.Same(t, article, got)
+
+	_, err = store.GetArticleByID("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	found, err := store.FindArticleByContentHash(article.ContentHash)
+	require.NoError(t, err)
+	assert.Same(t, article, found)
+	found, err = store.FindArticleByContentHash("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestMemoryStore_DuplicateContent(t *testing.T) {
+	store := NewMemoryStore()
+	require.NoError(t, store.SaveArticle(&models.Article{ID: "a1", Content: "The mayor was charged with fraud."}))
+
+	err := store.SaveArticle(&models.Article{ID: "a2", Content: "THE MAYOR was charged with fraud!"})
+	assert.ErrorIs(t, err, ErrDuplicateContent)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// Saving the same article again is not a duplicate
+	assert.NoError(t, store.SaveArticle(&models.Article{ID: "a1", Content: "The mayor was charged with fraud."}))
+}
+
+func TestMemoryStore_UpdateKeepsEntities(t *testing.T) {
+	store := NewMemoryStore()
+	entities := []*models.ExtractedEntity{{ID: "e1", Name: "Jane Doe"}}
+	require.NoError(t, store.SaveArticle(&models.Article{ID: "a1", Title: "Draft", Content: "text", Entities: entities}))
+
+	require.NoError(t, store.UpdateArticle(&models.Article{ID: "a1", Title: "Final", Content: "text"}))
+	got, err := store.GetArticleByID("a1")
+	require.NoError(t, err)
+	assert.Equal(t, "Final", got.Title)
+	assert.Equal(t, entities, got.Entities)
+
+	require.NoError(t, store.UpdateArticle(&models.Article{ID: "unknown"}))
+	assert.Len(t, store.Articles, 1, "updates don't create articles")
+}
+
+func TestMemoryStore_GetArticlesByTimeRange(t *testing.T) {
+	store := NewMemoryStore()
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	for i, d := range []int{1, 3, 5, 7} {
+		require.NoError(t, store.SaveArticle(&models.Article{ID: string(rune('a' + i)), Content: string(rune('a' + i)), PublishDate: day(d)}))
+	}
+
+	articles, err := store.GetArticlesByTimeRange(day(3), day(6))
+	require.NoError(t, err)
+	require.Len(t, articles, 2)
+	assert.Equal(t, "c", articles[0].ID, "newest first")
+	assert.Equal(t, "b", articles[1].ID, "the range is inclusive")
+}
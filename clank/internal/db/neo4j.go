@@ -1,11 +1,15 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
+	"clank/config"
+	"clank/internal/logging"
+	"clank/internal/metrics"
+
 	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
 )
 
@@ -13,72 +17,147 @@ var (
 	driver    neo4j.Driver
 	available bool
 	mu        sync.RWMutex
+
+	// newDriver creates the Neo4j driver (replaced in tests)
+	newDriver = neo4j.NewDriver
+	// connectRetryDelay is the wait between connection attempts (replaced in tests)
+	connectRetryDelay = 5 * time.Second
+)
+
+// Driver settings used when the configuration leaves them at zero
+const (
+	defaultMaxConnectionPoolSize        = 50
+	defaultConnectionAcquisitionTimeout = 5 * time.Second
+	defaultMaxConnectionLifetime        = 30 * time.Minute
+	defaultHealthCheckInterval          = 30 * time.Second
 )
 
-// IsAvailable returns true if the Neo4j database is available
+// IsAvailable returns true if the Neo4j database is available and the circuit
+// breaker is not holding requests back
 func IsAvailable() bool {
 	mu.RLock()
 	defer mu.RUnlock()
-	return available && driver != nil
+	return available && driver != nil && breaker.allow()
 }
 
 // SetAvailable sets the availability status of the Neo4j database
 func setAvailable(status bool) {
 	mu.Lock()
 	defer mu.Unlock()
+	if available && !status {
+		logging.For(context.Background(), "db").Warn("Neo4j database is now unavailable")
+	}
 	available = status
-	if !status {
-		log.Printf("Neo4j database is now unavailable")
+}
+
+// driverConfig applies the configured connection pool settings, or their
+// defaults, to the driver configuration. The driver's own transaction retries
+// are turned off, as executeWithRetry already retries transient errors.
+func driverConfig(cfg config.Neo4jConfig) func(*neo4j.Config) {
+	return func(config *neo4j.Config) {
+		config.MaxTransactionRetryTime = 0
+		config.MaxConnectionPoolSize = cfg.MaxConnectionPoolSize
+		if config.MaxConnectionPoolSize <= 0 {
+			config.MaxConnectionPoolSize = defaultMaxConnectionPoolSize
+		}
+		config.ConnectionAcquisitionTimeout = cfg.ConnectionAcquisitionTimeout
+		if config.ConnectionAcquisitionTimeout <= 0 {
+			config.ConnectionAcquisitionTimeout = defaultConnectionAcquisitionTimeout
+		}
+		config.MaxConnectionLifetime = cfg.MaxConnectionLifetime
+		if config.MaxConnectionLifetime <= 0 {
+			config.MaxConnectionLifetime = defaultMaxConnectionLifetime
+		}
+		config.Log = neo4j.ConsoleLogger(neo4j.INFO)
 	}
 }
 
-// InitDB initializes a Neo4j database connection with retry logic
-func InitDB(uri, username, password string) error {
+// InitDB initializes a Neo4j database connection with retry logic. The error
+// wraps the driver's when connectivity can't be verified.
+func InitDB(cfg config.Neo4jConfig) error {
 	var err error
 	maxRetries := 3
-	retryDelay := 5 * time.Second
+	logger := logging.For(context.Background(), "db")
 
 	for i := 0; i < maxRetries; i++ {
-		log.Printf("Attempting to connect to Neo4j (attempt %d/%d)...", i+1, maxRetries)
+		logger.Info("Attempting to connect to Neo4j", "attempt", i+1, "max_attempts", maxRetries)
 
-		driver, err = neo4j.NewDriver(uri, neo4j.BasicAuth(username, password, ""),
-			func(config *neo4j.Config) {
-				config.MaxConnectionLifetime = 30 * time.Minute
-				config.MaxConnectionPoolSize = 50
-				config.Log = neo4j.ConsoleLogger(neo4j.INFO)
-				config.ConnectionAcquisitionTimeout = 5 * time.Second
-			})
+		driver, err = newDriver(cfg.URI, neo4j.BasicAuth(cfg.Username, cfg.Password, ""), driverConfig(cfg))
 
 		if err != nil {
-			log.Printf("Failed to create Neo4j driver: %v", err)
+			logger.Warn("Failed to create Neo4j driver", "attempt", i+1, "error", err)
 			if i < maxRetries-1 {
-				time.Sleep(retryDelay)
+				time.Sleep(connectRetryDelay)
 				continue
 			}
 			setAvailable(false)
-			return fmt.Errorf("failed to create Neo4j driver after %d attempts: %v", maxRetries, err)
+			return fmt.Errorf("failed to create Neo4j driver after %d attempts: %w", maxRetries, err)
 		}
 
 		// Verify connection
 		err = driver.VerifyConnectivity()
 		if err != nil {
-			log.Printf("Failed to connect to Neo4j: %v", err)
+			logger.Warn("Failed to connect to Neo4j", "attempt", i+1, "error", err)
 			if i < maxRetries-1 {
-				time.Sleep(retryDelay)
+				time.Sleep(connectRetryDelay)
 				continue
 			}
 			setAvailable(false)
-			return fmt.Errorf("failed to connect to Neo4j after %d attempts: %v", maxRetries, err)
+			return fmt.Errorf("failed to connect to Neo4j after %d attempts: %w", maxRetries, err)
 		}
 
 		setAvailable(true)
-		log.Printf("Successfully connected to Neo4j database")
+		logger.Info("Successfully connected to Neo4j database", "uri", cfg.URI)
+
+		if err := EnsureSchema(); err != nil {
+			logger.Error("Neo4j schema setup failed", "error", err)
+		}
 		return nil
 	}
 
 	return fmt.Errorf("failed to initialize Neo4j connection")
 }
 
+// StartHealthCheck verifies connectivity every interval (0 uses 30s) until ctx
+// ends, so IsAvailable turns false while the database is unreachable and true
+// again once it answers
+func StartHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkConnectivity(ctx)
+			}
+		}
+	}()
+}
+
+// checkConnectivity verifies the driver's connectivity and records the outcome
+func checkConnectivity(ctx context.Context) {
+	d := GetDriver()
+	if d == nil {
+		return
+	}
+	if err := d.VerifyConnectivity(); err != nil {
+		if isConnected() {
+			logging.For(ctx, "db").Warn("Neo4j connectivity check failed", "error", err)
+		}
+		setAvailable(false)
+		return
+	}
+	if !isConnected() {
+		logging.For(ctx, "db").Info("Neo4j database is available again")
+	}
+	setAvailable(true)
+}
+
 // GetDriver returns the Neo4j driver instance
 func GetDriver() neo4j.Driver {
 	mu.RLock()
@@ -102,61 +181,109 @@ func CloseDB() error {
 	return nil
 }
 
-// withDatabase executes a function with database error handling
+// errNotConnected is returned without a driver or before InitDB succeeds
+var errNotConnected = NewError(ErrUnavailable, "database is not available - ensure Neo4j is running and properly configured")
+
+// withDatabase executes a function with database error handling, retrying
+// transient errors and fast-failing while the circuit breaker is open. Errors
+// are given their kind (see classifyError).
 func withDatabase(f func() (interface{}, error)) (interface{}, error) {
-	if !IsAvailable() {
-		return nil, fmt.Errorf("database is not available - ensure Neo4j is running and properly configured")
+	if GetDriver() == nil || !isConnected() {
+		return nil, errNotConnected
 	}
 
-	result, err := f()
+	result, err := executeWithRetry(f)
 	if err != nil {
-		// Check if error is due to connection issues
-		if neo4j.IsConnectivityError(err) {
-			setAvailable(false)
-			return nil, fmt.Errorf("database connection lost: %v", err)
-		}
-		return nil, err
+		return nil, classifyError(err)
 	}
 
 	return result, nil
 }
 
+// isConnected reports whether InitDB succeeded and the last connectivity check passed
+func isConnected() bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return available
+}
+
 // ExecuteRead executes a read transaction with the given work function
 func ExecuteRead(work func(tx neo4j.Transaction) (interface{}, error)) (interface{}, error) {
-	return withDatabase(func() (interface{}, error) {
+	start := time.Now()
+	result, err := withDatabase(func() (interface{}, error) {
 		session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 		defer session.Close()
 
 		result, err := session.ReadTransaction(work)
 		if err != nil {
-			return nil, fmt.Errorf("read transaction failed: %v", err)
+			return nil, fmt.Errorf("read transaction failed: %w", err)
 		}
 
 		return result, nil
 	})
+	metrics.Neo4jTransactionDuration.ObserveSince(start, "read", metrics.Status(err))
+	return result, err
+}
+
+// ExecuteReadOnce executes a read transaction with the given work function
+// without retrying it, for work that hands out what it reads as it goes (e.g.
+// streams it to a client) and so can't be repeated once it started. Errors are
+// given their kind like ExecuteRead's.
+func ExecuteReadOnce(work func(tx neo4j.Transaction) error) error {
+	if GetDriver() == nil || !isConnected() {
+		return errNotConnected
+	}
+	if !breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	start := time.Now()
+	err := func() error {
+		session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+		defer session.Close()
+
+		tx, err := session.BeginTransaction()
+		if err != nil {
+			return fmt.Errorf("read transaction failed: %w", err)
+		}
+		defer tx.Close()
+		if err := work(tx); err != nil {
+			return fmt.Errorf("read transaction failed: %w", err)
+		}
+		return tx.Commit()
+	}()
+	breaker.record(err)
+	metrics.Neo4jTransactionDuration.ObserveSince(start, "read", metrics.Status(err))
+	if err != nil {
+		return classifyError(err)
+	}
+	return nil
 }
 
 // ExecuteWrite executes a write transaction with the given work function
 func ExecuteWrite(work func(tx neo4j.Transaction) (interface{}, error)) (interface{}, error) {
-	return withDatabase(func() (interface{}, error) {
+	start := time.Now()
+	result, err := withDatabase(func() (interface{}, error) {
 		session := driver.NewSession(neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 		defer session.Close()
 
 		result, err := session.WriteTransaction(work)
 		if err != nil {
-			return nil, fmt.Errorf("write transaction failed: %v", err)
+			return nil, fmt.Errorf("write transaction failed: %w", err)
 		}
 
 		return result, nil
 	})
+	metrics.Neo4jTransactionDuration.ObserveSince(start, "write", metrics.Status(err))
+	return result, err
 }
 
 // TryReconnect attempts to reconnect to the Neo4j database
-func TryReconnect(uri, username, password string) error {
+func TryReconnect(cfg config.Neo4jConfig) error {
 	if IsAvailable() {
 		return nil
 	}
 
-	log.Printf("Attempting to reconnect to Neo4j database...")
-	return InitDB(uri, username, password)
+	logging.For(context.Background(), "db").Info("Attempting to reconnect to Neo4j database")
+	return InitDB(cfg)
 }
@@ -1,9 +1,14 @@
 package db
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"clank/config"
 	"clank/internal/models"
 	"clank/internal/testutil"
 
@@ -12,40 +17,40 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// storedArticle returns a mock article whose fields can all be stored as Neo4j
+// properties, which maps can't
+func storedArticle(url, title, content string) *models.Article {
+	article := testutil.MockArticle(url, title, content)
+	article.Metadata = nil
+	return article
+}
+
 func TestArticleStore_SaveArticle(t *testing.T) {
+	requireNeo4j(t)
+
 	tests := []struct {
 		name        string
 		article     *models.Article
-		mockSetup   func(neo4j.Session)
 		expectError bool
 	}{
 		{
 			name:    "successful save",
-			article: testutil.MockArticle("https://example.com", "Test Article", "Test content"),
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
+			article: storedArticle("https://example.com/a", "Test Article", "Test content"),
 		},
 		{
 			name: "save with entities",
 			article: func() *models.Article {
-				article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
-				article.Entities = []*models.ExtractedEntity{
-					testutil.MockEntity("person", "John Doe"),
-				}
+				article := storedArticle("https://example.com/b", "Test Article", "Other test content")
+				entity := testutil.MockEntity("person", "John Doe")
+				entity.Properties = nil
+				article.Entities = []*models.ExtractedEntity{entity}
 				return article
 			}(),
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a mock Neo4j session
-			// TODO: Implement proper Neo4j mocking
-
 			store := NewArticleStore()
 			err := store.SaveArticle(tt.article)
 
@@ -60,41 +65,36 @@ func TestArticleStore_SaveArticle(t *testing.T) {
 }
 
 func TestArticleStore_GetArticleByID(t *testing.T) {
+	requireNeo4j(t)
+	saved := storedArticle("https://example.com", "Test Article", "Test content")
+	saved.ID = "test-id"
+	require.NoError(t, NewArticleStore().SaveArticle(saved))
+
 	tests := []struct {
 		name            string
 		articleID       string
-		mockSetup       func(neo4j.Session)
 		expectedArticle *models.Article
 		expectError     bool
 	}{
 		{
-			name:      "article exists",
-			articleID: "test-id",
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
-			expectedArticle: testutil.MockArticle("https://example.com", "Test Article", "Test content"),
+			name:            "article exists",
+			articleID:       "test-id",
+			expectedArticle: saved,
 		},
 		{
-			name:      "article not found",
-			articleID: "non-existent",
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
+			name:        "article not found",
+			articleID:   "non-existent",
 			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a mock Neo4j session
-			// TODO: Implement proper Neo4j mocking
-
 			store := NewArticleStore()
 			article, err := store.GetArticleByID(tt.articleID)
 
 			if tt.expectError {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrNotFound)
 				return
 			}
 
@@ -106,40 +106,37 @@ func TestArticleStore_GetArticleByID(t *testing.T) {
 }
 
 func TestArticleStore_GetArticlesByTimeRange(t *testing.T) {
+	requireNeo4j(t)
+	store := NewArticleStore()
+	for i, published := range []time.Time{time.Now().Add(-time.Hour), time.Now().Add(-2 * time.Hour), time.Now().Add(-72 * time.Hour)} {
+		article := storedArticle(fmt.Sprintf("https://example.com/%d", i), "Test Article", fmt.Sprintf("Test content %d", i))
+		article.PublishDate = published
+		require.NoError(t, store.SaveArticle(article))
+	}
+
 	tests := []struct {
 		name          string
 		startTime     time.Time
 		endTime       time.Time
-		mockSetup     func(neo4j.Session)
 		expectedCount int
 		expectError   bool
 	}{
 		{
-			name:      "find articles in range",
-			startTime: time.Now().Add(-24 * time.Hour),
-			endTime:   time.Now(),
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
+			name:          "find articles in range",
+			startTime:     time.Now().Add(-24 * time.Hour),
+			endTime:       time.Now(),
 			expectedCount: 2,
 		},
 		{
-			name:      "no articles in range",
-			startTime: time.Now().Add(-48 * time.Hour),
-			endTime:   time.Now().Add(-24 * time.Hour),
-			mockSetup: func(session neo4j.Session) {
-				// TODO: Set up mock Neo4j session expectations
-			},
+			name:          "no articles in range",
+			startTime:     time.Now().Add(-48 * time.Hour),
+			endTime:       time.Now().Add(-24 * time.Hour),
 			expectedCount: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create a mock Neo4j session
-			// TODO: Implement proper Neo4j mocking
-
-			store := NewArticleStore()
 			articles, err := store.GetArticlesByTimeRange(tt.startTime, tt.endTime)
 
 			if tt.expectError {
@@ -152,3 +149,712 @@ func TestArticleStore_GetArticlesByTimeRange(t *testing.T) {
 		})
 	}
 }
+
+func TestArticleEnrichment(t *testing.T) {
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+	article.Metadata = map[string]interface{}{
+		"summary":    "Short summary",
+		"topics":     []interface{}{"corruption"},
+		"sentiment":  "negative",
+		"risk_score": 0.7,
+		"wordCount":  120,
+	}
+
+	enrichment := articleEnrichment(article)
+
+	assert.Equal(t, map[string]interface{}{
+		"summary":    "Short summary",
+		"topics":     []interface{}{"corruption"},
+		"sentiment":  "negative",
+		"risk_score": 0.7,
+	}, enrichment)
+
+	article.Metadata = nil
+	assert.Empty(t, articleEnrichment(article))
+}
+
+func TestIndicatorProperties(t *testing.T) {
+	flags := indicatorProperties([]string{models.IndicatorConflictOfInterest, models.IndicatorAbuseOfPower, "made_up_flag"})
+
+	assert.Equal(t, map[string]interface{}{
+		"conflict_of_interest": true,
+		"abuse_of_power":       true,
+	}, flags)
+	assert.Empty(t, indicatorProperties(nil))
+}
+
+func TestTemporalEdge(t *testing.T) {
+	tests := []struct {
+		name     string
+		rel      *models.ExtractedRelationship
+		from     string
+		to       string
+		edgeType string
+		ok       bool
+	}{
+		{name: "before", rel: testutil.MockRelationship("e1", "e2", "BEFORE"), from: "e1", to: "e2", edgeType: "BEFORE", ok: true},
+		{name: "after is reversed", rel: testutil.MockRelationship("e2", "e1", "after"), from: "e1", to: "e2", edgeType: "BEFORE", ok: true},
+		{name: "caused", rel: testutil.MockRelationship("e1", "e2", "CAUSED"), from: "e1", to: "e2", edgeType: "CAUSED", ok: true},
+		{name: "caused by is reversed", rel: testutil.MockRelationship("e2", "e1", "CAUSED_BY"), from: "e1", to: "e2", edgeType: "CAUSED", ok: true},
+		{name: "non temporal", rel: testutil.MockRelationship("e1", "e2", "payment")},
+		{name: "missing endpoint", rel: testutil.MockRelationship("", "e2", "BEFORE")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, edgeType, ok := temporalEdge(tt.rel)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+			assert.Equal(t, tt.edgeType, edgeType)
+		})
+	}
+}
+
+// personRowsTx answers findExistingEntity queries from in-memory entity rows
+type personRowsTx struct {
+	neo4j.Transaction
+	rows []map[string]interface{}
+}
+
+func (tx *personRowsTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	if authorityID, ok := params["authorityId"]; ok && params["keys"] == nil {
+		result := &rowsResult{index: -1}
+		for _, row := range tx.rows {
+			if row["wikidata_id"] == authorityID {
+				result.records = append(result.records, &neo4j.Record{Values: []interface{}{row["id"], row["name"], row["aliases"]}})
+			}
+		}
+		return result, nil
+	}
+
+	keys := make(map[string]bool)
+	for _, key := range params["keys"].([]string) {
+		keys[key] = true
+	}
+
+	result := &rowsResult{index: -1}
+	for _, row := range tx.rows {
+		match := keys[strings.ToLower(row["name"].(string))]
+		for _, alias := range row["aliases"].([]interface{}) {
+			match = match || keys[strings.ToLower(alias.(string))]
+		}
+		// Namesakes linked to different authority items are different entities
+		if linked, ok := row["wikidata_id"]; ok && params["authorityId"] != "" && params["authorityId"] != linked {
+			match = false
+		}
+		if match {
+			result.records = append(result.records, &neo4j.Record{Values: []interface{}{row["id"], row["name"], row["aliases"]}})
+		}
+	}
+	return result, nil
+}
+
+type rowsResult struct {
+	neo4j.Result
+	records []*neo4j.Record
+	index   int
+}
+
+func (r *rowsResult) Next() bool {
+	r.index++
+	return r.index < len(r.records)
+}
+
+func (r *rowsResult) Record() *neo4j.Record { return r.records[r.index] }
+
+func (r *rowsResult) Err() error { return nil }
+
+func TestResolveEntity_MatchesByAlias(t *testing.T) {
+	tx := &personRowsTx{rows: []map[string]interface{}{
+		{"id": "person-1", "name": "Robert Smith", "aliases": []interface{}{"Senator Smith"}},
+	}}
+
+	// A later article mentions him only by a stored alias
+	entity := testutil.MockEntity("PERSON", "Senator  Smith")
+	entity.ID = "article-2-person"
+	entity.Aliases = []string{"Bob"}
+
+	originalID, err := resolveEntity(tx, entity)
+	require.NoError(t, err)
+	assert.Equal(t, "article-2-person", originalID)
+	assert.Equal(t, "person-1", entity.ID)
+	assert.Equal(t, "Robert Smith", entity.Name)
+	assert.Equal(t, []string{"Senator Smith", "Bob"}, entity.Aliases)
+
+	// The merged alias now matches too
+	later := testutil.MockEntity("person", "bob")
+	_, err = resolveEntity(&personRowsTx{rows: []map[string]interface{}{
+		{"id": entity.ID, "name": entity.Name, "aliases": []interface{}{"Senator Smith", "Bob"}},
+	}}, later)
+	require.NoError(t, err)
+	assert.Equal(t, "person-1", later.ID)
+}
+
+func TestResolveEntity_NoMatch(t *testing.T) {
+	tx := &personRowsTx{rows: []map[string]interface{}{
+		{"id": "person-1", "name": "Robert Smith", "aliases": []interface{}{"Senator Smith"}},
+	}}
+
+	person := testutil.MockEntity("person", "Jane Doe")
+	id := person.ID
+	_, err := resolveEntity(tx, person)
+	require.NoError(t, err)
+	assert.Equal(t, id, person.ID)
+
+	// Only people are matched by alias
+	company := testutil.MockEntity("organization", "Senator Smith")
+	id = company.ID
+	_, err = resolveEntity(tx, company)
+	require.NoError(t, err)
+	assert.Equal(t, id, company.ID)
+}
+
+func TestResolveEntity_MatchesByAuthorityID(t *testing.T) {
+	tx := &personRowsTx{rows: []map[string]interface{}{
+		{"id": "org-1", "name": "Department of Justice", "aliases": []interface{}{}, "wikidata_id": "Q1553390"},
+		{"id": "person-1", "name": "Michael Jordan", "aliases": []interface{}{}, "wikidata_id": "Q41421"},
+	}}
+
+	// Any type is matched by a shared authority ID, whatever it is called
+	agency := testutil.MockEntity("government", "DOJ")
+	agency.Properties["wikidata_id"] = "Q1553390"
+	_, err := resolveEntity(tx, agency)
+	require.NoError(t, err)
+	assert.Equal(t, "org-1", agency.ID)
+	assert.Equal(t, "Department of Justice", agency.Name)
+	assert.Equal(t, []string{"DOJ"}, agency.Aliases)
+
+	// A namesake linked to another item stays apart
+	scientist := testutil.MockEntity("person", "Michael Jordan")
+	scientist.Properties["wikidata_id"] = "Q3308285"
+	id := scientist.ID
+	_, err = resolveEntity(tx, scientist)
+	require.NoError(t, err)
+	assert.NotEqual(t, "person-1", scientist.ID)
+	assert.Equal(t, id, scientist.ID)
+}
+
+// confidenceTx stores propertyConfidence maps per entity ID in memory
+type confidenceTx struct {
+	neo4j.Transaction
+	stored map[string]map[string]interface{}
+}
+
+func (tx *confidenceTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	id := params["id"].(string)
+	if confidence, ok := params["propertyConfidence"].(map[string]float64); ok {
+		stored := make(map[string]interface{}, len(confidence))
+		for k, v := range confidence {
+			stored[k] = v
+		}
+		tx.stored[id] = stored
+		return &rowsResult{index: -1}, nil
+	}
+
+	result := &rowsResult{index: -1}
+	if stored, ok := tx.stored[id]; ok {
+		result.records = append(result.records, &neo4j.Record{Values: []interface{}{stored}})
+	}
+	return result, nil
+}
+
+func TestSavePropertyConfidence_MergesMaxAcrossIntegrations(t *testing.T) {
+	tx := &confidenceTx{stored: make(map[string]map[string]interface{})}
+
+	first := testutil.MockEntity("person", "Jane Smith")
+	first.PropertyConfidence = map[string]float64{"role": 0.9, "netWorth": 0.2}
+	require.NoError(t, savePropertyConfidence(tx, first))
+	assert.Equal(t, map[string]interface{}{"role": 0.9, "netWorth": 0.2}, tx.stored[first.ID])
+
+	// A later article is less sure of her role but better sourced on her net worth
+	second := testutil.MockEntity("person", "Jane Smith")
+	second.ID = first.ID
+	second.PropertyConfidence = map[string]float64{"role": 0.5, "netWorth": 0.7, "party": 0.6}
+	require.NoError(t, savePropertyConfidence(tx, second))
+	assert.Equal(t, map[string]interface{}{"role": 0.9, "netWorth": 0.7, "party": 0.6}, tx.stored[first.ID])
+
+	// Entities without per-property confidence leave the stored values alone
+	third := testutil.MockEntity("person", "Jane Smith")
+	third.ID = first.ID
+	require.NoError(t, savePropertyConfidence(tx, third))
+	assert.Equal(t, map[string]interface{}{"role": 0.9, "netWorth": 0.7, "party": 0.6}, tx.stored[first.ID])
+}
+
+// hashTx answers content hash lookups from in-memory articles keyed by hash
+type hashTx struct {
+	neo4j.Transaction
+	articles map[string]string // contentHash -> article ID
+}
+
+func (tx *hashTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	result := &rowsResult{index: -1}
+	if id, ok := tx.articles[params["contentHash"].(string)]; ok && id != params["id"] {
+		result.records = append(result.records, &neo4j.Record{Values: []interface{}{id}})
+	}
+	return result, nil
+}
+
+func TestCheckDuplicateContent(t *testing.T) {
+	tx := &hashTx{articles: map[string]string{"hash-1": "article-1"}}
+
+	duplicate := &models.Article{ID: "article-2", ContentHash: "hash-1"}
+	err := checkDuplicateContent(tx, duplicate)
+	assert.ErrorIs(t, err, ErrDuplicateContent)
+	assert.Contains(t, err.Error(), "article-1")
+
+	// Re-saving the stored article itself is not a duplicate
+	assert.NoError(t, checkDuplicateContent(tx, &models.Article{ID: "article-1", ContentHash: "hash-1"}))
+	assert.NoError(t, checkDuplicateContent(tx, &models.Article{ID: "article-3", ContentHash: "hash-2"}))
+	assert.NoError(t, checkDuplicateContent(tx, &models.Article{ID: "article-4"}))
+}
+
+// dedupTx answers the duplicate-relationship query with its canned groups, each
+// a list of {id, props} rows, and records the merge statements it is sent
+type dedupTx struct {
+	neo4j.Transaction
+	groups      [][]interface{}
+	queryParams map[string]interface{}
+	merges      []map[string]interface{}
+}
+
+func (tx *dedupTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	result := &rowsResult{index: -1}
+	if strings.Contains(cypher, "SET keep += $updates") {
+		tx.merges = append(tx.merges, params)
+		return result, nil
+	}
+	tx.queryParams = params
+	for _, group := range tx.groups {
+		result.records = append(result.records, &neo4j.Record{Values: []interface{}{group}})
+	}
+	return result, nil
+}
+
+func TestDeduplicateRelationshipBatch_MergesParallelEdges(t *testing.T) {
+	edge := func(id int64, props map[string]interface{}) interface{} {
+		return map[string]interface{}{"id": id, "props": props}
+	}
+	tx := &dedupTx{groups: [][]interface{}{{
+		edge(2, map[string]interface{}{
+			"type": "payment", "confidence": 0.9, "evidence": []interface{}{"bank transfer"}, "source_urls": []interface{}{"https://a.example", "https://b.example"},
+		}),
+		edge(1, map[string]interface{}{
+			"type": "payment", "confidence": 0.6, "evidence": []interface{}{"invoice 17"}, "source_urls": []interface{}{"https://a.example"},
+		}),
+		edge(3, map[string]interface{}{
+			"type": "payment", "confidence": 0.7, "evidence": []interface{}{"invoice 17"}, "amount": "$50,000",
+		}),
+	}}}
+
+	report, err := deduplicateRelationshipBatch(tx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, DedupReport{Groups: 1, Removed: 2}, report)
+	assert.Equal(t, map[string]interface{}{"batchSize": 10}, tx.queryParams)
+
+	require.Len(t, tx.merges, 1)
+	merge := tx.merges[0]
+	assert.Equal(t, int64(1), merge["keepId"], "the oldest edge survives")
+	assert.Equal(t, []int64{2, 3}, merge["duplicateIds"])
+	updates := merge["updates"].(map[string]interface{})
+	assert.Equal(t, 0.9, updates["confidence"])
+	assert.Equal(t, []interface{}{"invoice 17", "bank transfer"}, updates["evidence"])
+	assert.Equal(t, []interface{}{"https://a.example", "https://b.example"}, updates["source_urls"])
+	assert.Equal(t, "$50,000", updates["amount"], "properties only a duplicate had are kept")
+}
+
+func TestDeduplicateRelationshipBatch_NothingToMerge(t *testing.T) {
+	tx := &dedupTx{}
+
+	report, err := deduplicateRelationshipBatch(tx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, DedupReport{}, report)
+	assert.Empty(t, tx.merges)
+}
+
+func TestArticleFromNode(t *testing.T) {
+	published := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	t.Run("partial node", func(t *testing.T) {
+		node := neo4j.Node{Props: map[string]interface{}{
+			"id":    "a1",
+			"url":   "https://example.com/story",
+			"title": nil,
+		}}
+
+		var article *models.Article
+		require.NotPanics(t, func() { article = articleFromNode(node) })
+		assert.Equal(t, "a1", article.ID)
+		assert.Equal(t, "https://example.com/story", article.URL)
+		assert.Empty(t, article.Title)
+		assert.Empty(t, article.Content)
+		assert.Nil(t, article.Metadata)
+		assert.True(t, article.PublishDate.IsZero())
+		assert.True(t, article.ExtractedAt.IsZero())
+	})
+
+	t.Run("varied property shapes", func(t *testing.T) {
+		node := neo4j.Node{Props: map[string]interface{}{
+			"title":       42,
+			"publishDate": published,
+			"extractedAt": published.Format(time.RFC3339),
+			"metadata":    `{"sessionId":"s1"}`,
+		}}
+
+		article := articleFromNode(node)
+		assert.Empty(t, article.Title)
+		assert.Equal(t, published, article.PublishDate)
+		assert.True(t, published.Equal(article.ExtractedAt))
+		assert.Equal(t, map[string]interface{}{"sessionId": "s1"}, article.Metadata)
+	})
+
+	t.Run("unparseable values", func(t *testing.T) {
+		node := neo4j.Node{Props: map[string]interface{}{
+			"publishDate": "last Tuesday",
+			"metadata":    []interface{}{"not", "a", "map"},
+		}}
+
+		article := articleFromNode(node)
+		assert.True(t, article.PublishDate.IsZero())
+		assert.Nil(t, article.Metadata)
+	})
+
+	t.Run("missing node", func(t *testing.T) {
+		require.NotPanics(t, func() { articleFromNode(neo4j.Node{}) })
+	})
+}
+
+// revisionTx holds one entity and its revisions, answering the entity state,
+// revision and history queries
+type revisionTx struct {
+	neo4j.Transaction
+	entity    map[string]interface{}
+	revisions []neo4j.Node
+}
+
+func (tx *revisionTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	result := &rowsResult{index: -1}
+	switch {
+	case strings.Contains(cypher, "CREATE (rev:EntityRevision"):
+		createdAt, err := time.Parse(time.RFC3339Nano, params["createdAt"].(string))
+		if err != nil {
+			return nil, err
+		}
+		tx.revisions = append(tx.revisions, neo4j.Node{Labels: []string{"EntityRevision"}, Props: map[string]interface{}{
+			"entityId":  params["entityId"],
+			"revision":  int64(len(tx.revisions) + 1),
+			"articleId": params["articleId"],
+			"sourceUrl": params["sourceUrl"],
+			"changes":   params["changes"],
+			"createdAt": createdAt,
+		}})
+	case strings.Contains(cypher, "MATCH (rev:EntityRevision"):
+		for _, rev := range tx.revisions {
+			result.records = append(result.records, &neo4j.Record{Values: []interface{}{rev}})
+		}
+	case strings.Contains(cypher, "RETURN e"):
+		if tx.entity != nil {
+			result.records = append(result.records, &neo4j.Record{Values: []interface{}{neo4j.Node{Props: tx.entity}}})
+		}
+	}
+	return result, nil
+}
+
+// save mirrors SaveArticle: read the stored state, write the entity, record the revision
+func (tx *revisionTx) save(t *testing.T, article *models.Article, entity *models.ExtractedEntity) {
+	t.Helper()
+	before, err := entityState(tx, entity.ID)
+	require.NoError(t, err)
+	tx.entity = map[string]interface{}{
+		"id":         entity.ID,
+		"type":       entity.Type,
+		"name":       entity.Name,
+		"aliases":    entity.Aliases,
+		"confidence": entity.Confidence,
+		"properties": entity.Properties,
+	}
+	require.NoError(t, saveEntityRevision(tx, entity.ID, article, entityChanges(before, entity)))
+}
+
+func TestEntityRevisions_OrderedAcrossArticles(t *testing.T) {
+	tx := &revisionTx{}
+	first := &models.Article{ID: "a1", URL: "https://news.example.com/first"}
+	second := &models.Article{ID: "a2", URL: "https://other.example.org/second"}
+
+	tx.save(t, first, &models.ExtractedEntity{
+		ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.7,
+		Properties: map[string]interface{}{"role": "councillor"},
+	})
+	tx.save(t, second, &models.ExtractedEntity{
+		ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9, Aliases: []string{"Mayor Doe"},
+		Properties: map[string]interface{}{"role": "mayor"},
+	})
+	// Re-reading the second article changes nothing and adds no revision
+	tx.save(t, second, &models.ExtractedEntity{
+		ID: "e1", Type: "person", Name: "Jane Doe", Confidence: 0.9, Aliases: []string{"Mayor Doe"},
+		Properties: map[string]interface{}{"role": "mayor"},
+	})
+
+	history, err := entityHistory(tx, "e1")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	assert.Equal(t, 1, history[0].Revision)
+	assert.Equal(t, "a1", history[0].ArticleID)
+	assert.Equal(t, "https://news.example.com/first", history[0].SourceURL)
+	assert.Equal(t, PropertyChange{To: "Jane Doe"}, history[0].Changes["name"])
+	assert.Equal(t, PropertyChange{To: "councillor"}, history[0].Changes["properties.role"])
+
+	assert.Equal(t, 2, history[1].Revision)
+	assert.Equal(t, "a2", history[1].ArticleID)
+	assert.Equal(t, "https://other.example.org/second", history[1].SourceURL)
+	assert.False(t, history[1].CreatedAt.Before(history[0].CreatedAt))
+	assert.Equal(t, PropertyChange{From: "councillor", To: "mayor"}, history[1].Changes["properties.role"])
+	assert.Equal(t, PropertyChange{From: 0.7, To: 0.9}, history[1].Changes["confidence"])
+	assert.Equal(t, PropertyChange{To: []interface{}{"Mayor Doe"}}, history[1].Changes["aliases"])
+	assert.NotContains(t, history[1].Changes, "name")
+}
+
+// importTx records the articles and entities written by saveArticle. Person
+// lookups match the entities written earlier in the same transaction by name;
+// every other read has no rows. Writes are kept only when the transaction commits.
+type importTx struct {
+	neo4j.Transaction
+	failArticle string // Run fails when writing this article
+	articles    []string
+	entities    []string
+	names       map[string]string // lowercased name to entity ID
+}
+
+func (tx *importTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	switch {
+	case strings.Contains(cypher, "MERGE (a:Article {id: $id})"):
+		if params["id"] == tx.failArticle {
+			return nil, fmt.Errorf("constraint violation")
+		}
+		tx.articles = append(tx.articles, params["id"].(string))
+	case strings.Contains(cypher, "MERGE (e:Entity {id: $id})"):
+		id := params["id"].(string)
+		tx.entities = append(tx.entities, id)
+		if tx.names == nil {
+			tx.names = make(map[string]string)
+		}
+		tx.names[strings.ToLower(params["name"].(string))] = id
+	case strings.Contains(cypher, "toLower(e.type) = 'person'"):
+		for _, key := range params["keys"].([]string) {
+			if id, ok := tx.names[key]; ok {
+				record := &neo4j.Record{Values: []interface{}{id, key, []interface{}{}}}
+				return &rowsResult{records: []*neo4j.Record{record}, index: -1}, nil
+			}
+		}
+	}
+	return &rowsResult{index: -1}, nil
+}
+
+// importWriter runs each transaction against a fresh importTx and collects the
+// writes of those that commit
+func importWriter(failArticle string, committed *importTx, transactions *int) writeFunc {
+	return func(work func(tx neo4j.Transaction) (interface{}, error)) (interface{}, error) {
+		*transactions++
+		tx := &importTx{failArticle: failArticle}
+		result, err := work(tx)
+		if err == nil {
+			committed.articles = append(committed.articles, tx.articles...)
+			committed.entities = append(committed.entities, tx.entities...)
+		}
+		return result, err
+	}
+}
+
+func importTestDocuments() []ImportDocument {
+	return []ImportDocument{
+		{
+			Article: models.Article{ID: "a1", URL: "https://example.com/1", Content: "First article"},
+			Entities: []models.ExtractedEntity{
+				{ID: "p1", Type: "person", Name: "Jane Smith", Confidence: 0.9},
+				{ID: "c1", Type: "firm", Name: "Acme Construction", Confidence: 0.8},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{Type: "ownership", FromID: "p1", ToID: "c1", Confidence: 0.8},
+			},
+		},
+		{
+			Article:  models.Article{ID: "a2", URL: "https://example.com/2", Content: "Second article"},
+			Entities: []models.ExtractedEntity{{ID: "p2", Type: "person", Name: "John Doe"}},
+			Relationships: []models.ExtractedRelationship{
+				{Type: "payment", FromID: "p2", ToID: "missing"},
+			},
+		},
+	}
+}
+
+func TestImportDocuments_BestEffortPartialSuccess(t *testing.T) {
+	committed := &importTx{}
+	transactions := 0
+	var progress []int
+	report := importDocuments(importTestDocuments(), ImportOptions{
+		Progress: func(done, total int) { progress = append(progress, done) },
+	}, importWriter("", committed, &transactions))
+
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, 1, report.Failed)
+	require.Len(t, report.Results, 2)
+	assert.True(t, report.Results[0].Imported)
+	assert.Equal(t, "a1", report.Results[0].ArticleID)
+	assert.False(t, report.Results[1].Imported)
+	assert.Contains(t, report.Results[1].Error, `unknown entity "missing"`)
+
+	assert.Equal(t, []string{"a1"}, committed.articles)
+	assert.Equal(t, []string{"p1", "c1"}, committed.entities)
+	assert.Equal(t, 1, transactions)
+	assert.Equal(t, []int{2}, progress)
+}
+
+func TestImportDocuments_FailedBatchRetriedPerDocument(t *testing.T) {
+	docs := importTestDocuments()
+	docs[1].Relationships = nil
+	docs = append(docs, ImportDocument{Article: models.Article{ID: "a3", URL: "https://example.com/3", Content: "Third article"}})
+
+	committed := &importTx{}
+	transactions := 0
+	report := importDocuments(docs, ImportOptions{BatchSize: 3}, importWriter("a2", committed, &transactions))
+
+	assert.Equal(t, 2, report.Imported)
+	assert.Contains(t, report.Results[1].Error, "constraint violation")
+	assert.Equal(t, []string{"a1", "a3"}, committed.articles)
+	assert.Equal(t, 4, transactions) // the batch, then each document alone
+}
+
+func TestImportDocuments_RetryIgnoresRolledBackResolution(t *testing.T) {
+	// In the batch, a3's Jane Smith resolves onto a1's before a2 fails and the
+	// batch is rolled back; retried alone, a3 must save its own entity again
+	// rather than the ID it was resolved to
+	docs := importTestDocuments()
+	docs[1].Relationships = nil
+	docs = append(docs, ImportDocument{
+		Article:  models.Article{ID: "a3", URL: "https://example.com/3", Content: "Third article"},
+		Entities: []models.ExtractedEntity{{ID: "p3", Type: "person", Name: "Jane Smith"}},
+	})
+	docs[1], docs[2] = docs[2], docs[1]
+
+	committed := &importTx{}
+	transactions := 0
+	report := importDocuments(docs, ImportOptions{BatchSize: 3}, importWriter("a2", committed, &transactions))
+
+	assert.Equal(t, 2, report.Imported)
+	assert.Contains(t, report.Results[2].Error, "constraint violation")
+	assert.Equal(t, []string{"a1", "a3"}, committed.articles)
+	assert.Equal(t, []string{"p1", "c1", "p3"}, committed.entities)
+}
+
+func TestImportDocuments_TransactionalAllOrNothing(t *testing.T) {
+	committed := &importTx{}
+	transactions := 0
+	report := importDocuments(importTestDocuments(), ImportOptions{Transactional: true}, importWriter("", committed, &transactions))
+
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 2, report.Failed)
+	assert.Contains(t, report.Results[0].Error, "not imported: 1 of 2 documents are invalid")
+	assert.Empty(t, committed.articles)
+	assert.Equal(t, 0, transactions)
+
+	docs := importTestDocuments()[:1]
+	report = importDocuments(docs, ImportOptions{Transactional: true}, importWriter("", committed, &transactions))
+	assert.Equal(t, 1, report.Imported)
+	assert.Equal(t, []string{"a1"}, committed.articles)
+}
+
+// fakeDriver answers connectivity checks with err and opens session
+type fakeDriver struct {
+	neo4j.Driver
+	err     error
+	checks  int
+	session neo4j.Session
+}
+
+func (d *fakeDriver) NewSession(config neo4j.SessionConfig) neo4j.Session { return d.session }
+
+func (d *fakeDriver) VerifyConnectivity() error {
+	d.checks++
+	return d.err
+}
+
+func (d *fakeDriver) Close() error { return nil }
+
+// useFakeDriver makes InitDB create fake, recording the driver config it is given
+func useFakeDriver(t *testing.T, fake *fakeDriver) *neo4j.Config {
+	t.Helper()
+	applied := &neo4j.Config{}
+	oldNewDriver, oldDelay, oldDriver, oldAvailable := newDriver, connectRetryDelay, driver, available
+	newDriver = func(target string, auth neo4j.AuthToken, configurers ...func(*neo4j.Config)) (neo4j.Driver, error) {
+		for _, configure := range configurers {
+			configure(applied)
+		}
+		return fake, nil
+	}
+	connectRetryDelay = 0
+	t.Cleanup(func() {
+		newDriver, connectRetryDelay, driver, available = oldNewDriver, oldDelay, oldDriver, oldAvailable
+	})
+	return applied
+}
+
+func TestInitDB_PassesPoolConfig(t *testing.T) {
+	applied := useFakeDriver(t, &fakeDriver{err: errors.New("connection refused")})
+
+	InitDB(config.Neo4jConfig{
+		URI:                          "bolt://localhost:7687",
+		MaxConnectionPoolSize:        120,
+		ConnectionAcquisitionTimeout: 15 * time.Second,
+		MaxConnectionLifetime:        time.Hour,
+	})
+
+	assert.Equal(t, 120, applied.MaxConnectionPoolSize)
+	assert.Equal(t, 15*time.Second, applied.ConnectionAcquisitionTimeout)
+	assert.Equal(t, time.Hour, applied.MaxConnectionLifetime)
+}
+
+func TestInitDB_PoolConfigDefaults(t *testing.T) {
+	applied := useFakeDriver(t, &fakeDriver{err: errors.New("connection refused")})
+
+	InitDB(config.Neo4jConfig{URI: "bolt://localhost:7687"})
+
+	assert.Equal(t, defaultMaxConnectionPoolSize, applied.MaxConnectionPoolSize)
+	assert.Equal(t, defaultConnectionAcquisitionTimeout, applied.ConnectionAcquisitionTimeout)
+	assert.Equal(t, defaultMaxConnectionLifetime, applied.MaxConnectionLifetime)
+}
+
+func TestDriverConfig_DisablesDriverRetries(t *testing.T) {
+	applied := &neo4j.Config{MaxTransactionRetryTime: 30 * time.Second}
+	driverConfig(config.Neo4jConfig{})(applied)
+	assert.Zero(t, applied.MaxTransactionRetryTime, "transactions are only retried by executeWithRetry")
+}
+
+func TestInitDB_FailedVerifySurfacesError(t *testing.T) {
+	refused := errors.New("connection refused")
+	fake := &fakeDriver{err: refused}
+	useFakeDriver(t, fake)
+
+	err := InitDB(config.Neo4jConfig{URI: "bolt://localhost:7687"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, refused)
+	assert.Contains(t, err.Error(), "failed to connect to Neo4j after 3 attempts")
+	assert.Equal(t, 3, fake.checks)
+	assert.False(t, isConnected())
+}
+
+func TestCheckConnectivity_FeedsAvailability(t *testing.T) {
+	now := time.Now()
+	useTestBreaker(t, &now)
+	fake := &fakeDriver{}
+	useFakeDriver(t, fake)
+	driver, available = fake, true
+
+	fake.err = errors.New("connection reset")
+	checkConnectivity(context.Background())
+	assert.False(t, IsAvailable())
+
+	fake.err = nil
+	checkConnectivity(context.Background())
+	assert.True(t, IsAvailable())
+}
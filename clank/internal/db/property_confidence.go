@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+
+	"clank/internal/models"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// savePropertyConfidence merges an entity's per-property confidence with the
+// stored values, keeping the higher confidence for each property, and writes
+// the result back. Entities without per-property confidence leave it unchanged.
+func savePropertyConfidence(tx neo4j.Transaction, entity *models.ExtractedEntity) error {
+	if len(entity.PropertyConfidence) == 0 {
+		return nil
+	}
+
+	result, err := tx.Run(`
+		MATCH (e:Entity {id: $id})
+		RETURN e.propertyConfidence
+	`, map[string]interface{}{"id": entity.ID})
+	if err != nil {
+		return fmt.Errorf("failed to read property confidence: %w", err)
+	}
+	if result.Next() {
+		if stored, ok := result.Record().Values[0].(map[string]interface{}); ok {
+			entity.MergePropertyConfidence(storedPropertyConfidence(stored))
+		}
+	} else if err := result.Err(); err != nil {
+		return fmt.Errorf("failed to read property confidence: %w", err)
+	}
+
+	_, err = tx.Run(`
+		MATCH (e:Entity {id: $id})
+		SET e.propertyConfidence = $propertyConfidence
+	`, map[string]interface{}{"id": entity.ID, "propertyConfidence": entity.PropertyConfidence})
+	if err != nil {
+		return fmt.Errorf("failed to store property confidence: %w", err)
+	}
+	return nil
+}
+
+// storedPropertyConfidence converts a stored confidence map, skipping non-numeric values
+func storedPropertyConfidence(stored map[string]interface{}) map[string]float64 {
+	confidence := make(map[string]float64, len(stored))
+	for property, value := range stored {
+		switch v := value.(type) {
+		case float64:
+			confidence[property] = v
+		case int64:
+			confidence[property] = float64(v)
+		}
+	}
+	return confidence
+}
@@ -91,9 +91,18 @@ func CreateEntityParams(entity *models.ExtractedEntity) map[string]interface{} {
 	}
 }
 
-// GetCreateEntityQuery returns the query for creating an entity of a specific type
-func GetCreateEntityQuery(entityType string) string {
-	return fmt.Sprintf(CreateEntityQuery, entityType)
+// GetCreateEntityQuery returns the query for creating an entity of a specific type.
+// The type becomes the node label, so it must be in the entity taxonomy.
+func GetCreateEntityQuery(entityType string) (string, error) {
+	entityType, err := models.NormalizeEntityType(entityType)
+	if err != nil {
+		return "", err
+	}
+	label, err := models.SanitizeLabel(entityType)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(CreateEntityQuery, label), nil
 }
 
 // CreateRelationshipParams returns params for creating a relationship
@@ -109,7 +118,12 @@ func CreateRelationshipParams(rel *models.ExtractedRelationship) map[string]inte
 	}
 }
 
-// GetCreateRelationshipQuery returns the query for creating a relationship of a specific type
-func GetCreateRelationshipQuery(relType string) string {
-	return fmt.Sprintf(CreateRelationshipQuery, relType)
+// GetCreateRelationshipQuery returns the query for creating a relationship of a
+// specific type. The type is interpolated into the query, so it must be allowlisted.
+func GetCreateRelationshipQuery(relType string) (string, error) {
+	relType, err := models.NormalizeRelationshipType(relType)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(CreateRelationshipQuery, relType), nil
 }
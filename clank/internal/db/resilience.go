@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"clank/internal/logging"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+var (
+	// maxTransactionAttempts bounds how often a transaction is tried on transient errors
+	maxTransactionAttempts = 4
+	// retryBaseDelay is the backoff before the first retry; it doubles on each attempt
+	retryBaseDelay = 100 * time.Millisecond
+	// retryMaxDelay caps the backoff between attempts
+	retryMaxDelay = 2 * time.Second
+	// retrySleep waits between attempts (replaced in tests)
+	retrySleep = time.Sleep
+
+	// breaker fast-fails transactions once the database has been unreachable
+	// for breakerThreshold consecutive transactions
+	breaker = newCircuitBreaker(breakerThreshold, breakerCooldown)
+)
+
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned without contacting the database while the circuit breaker is open
+var ErrCircuitOpen error = NewError(ErrUnavailable, "database circuit breaker is open - too many recent connection failures")
+
+// isTransientError reports whether err is worth retrying: Neo4j transient errors
+// such as deadlocks, cluster leader switches and dropped connections
+func isTransientError(err error) bool {
+	var neoErr *neo4j.Neo4jError
+	if errors.As(err, &neoErr) {
+		return neoErr.IsRetriableTransient() || neoErr.IsRetriableCluster()
+	}
+	return isConnectionError(err)
+}
+
+// isConnectionError reports whether err means the database could not be reached
+func isConnectionError(err error) bool {
+	var connErr *neo4j.ConnectivityError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	// The driver gives up with TransactionExecutionLimit after its own retries
+	var limitErr *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limitErr) {
+		for _, cause := range limitErr.Errors {
+			if isConnectionError(cause) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// executeWithRetry runs f, retrying transient errors with exponential backoff,
+// and records the outcome with the circuit breaker. The driver doesn't retry
+// transactions itself (see driverConfig), so this is the only retry layer.
+func executeWithRetry(f func() (interface{}, error)) (interface{}, error) {
+	if !breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	var result interface{}
+	var err error
+	delay := retryBaseDelay
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		result, err = f()
+		if err == nil || !isTransientError(err) {
+			break
+		}
+		if attempt < maxTransactionAttempts {
+			logging.For(context.Background(), "db").Warn("Transient Neo4j error, retrying",
+				"attempt", attempt, "max_attempts", maxTransactionAttempts, "delay", delay, "error", err)
+			retrySleep(delay)
+			delay = min(delay*2, retryMaxDelay)
+		}
+	}
+
+	breaker.record(err)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// circuitBreaker opens after threshold consecutive connection failures and stays
+// open for cooldown, after which transactions are let through again to probe the
+// database. A success closes it; another failure reopens it.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// allow reports whether a transaction may be attempted
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures < b.threshold || b.now().Sub(b.openedAt) >= b.cooldown
+}
+
+// record updates the breaker with the outcome of a transaction. Errors that do
+// not indicate an unreachable database leave it unchanged.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.failures >= b.threshold {
+			logging.For(context.Background(), "db").Info("Neo4j database is reachable again, closing circuit breaker")
+		}
+		b.failures = 0
+		return
+	}
+	if !isConnectionError(err) {
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			logging.For(context.Background(), "db").Error("Neo4j unreachable, opening circuit breaker",
+				"consecutive_failures", b.failures, "cooldown", b.cooldown)
+		}
+		b.openedAt = b.now()
+	}
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestBreaker replaces the package breaker and retry sleep for the duration of a test
+func useTestBreaker(t *testing.T, clock *time.Time) *[]time.Duration {
+	t.Helper()
+	sleeps := &[]time.Duration{}
+
+	oldBreaker, oldSleep := breaker, retrySleep
+	breaker = newCircuitBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return *clock }
+	retrySleep = func(d time.Duration) { *sleeps = append(*sleeps, d) }
+	t.Cleanup(func() { breaker, retrySleep = oldBreaker, oldSleep })
+
+	return sleeps
+}
+
+func TestExecuteWithRetry_TransientDeadlock(t *testing.T) {
+	now := time.Now()
+	sleeps := useTestBreaker(t, &now)
+
+	deadlock := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected", Msg: "deadlock"}
+	attempts := 0
+	result, err := executeWithRetry(func() (interface{}, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.Join(errors.New("write transaction failed"), deadlock)
+		}
+		return "saved", nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "saved", result)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []time.Duration{retryBaseDelay, 2 * retryBaseDelay}, *sleeps)
+	assert.True(t, breaker.allow())
+}
+
+func TestExecuteWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	now := time.Now()
+	useTestBreaker(t, &now)
+
+	syntax := &neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "bad query"}
+	attempts := 0
+	_, err := executeWithRetry(func() (interface{}, error) {
+		attempts++
+		return nil, syntax
+	})
+
+	assert.ErrorIs(t, err, syntax)
+	assert.Equal(t, 1, attempts)
+}
+
+// onceSession hands out tx as its explicit transaction
+type onceSession struct {
+	neo4j.Session
+	tx *onceTx
+}
+
+func (s *onceSession) BeginTransaction(configurers ...func(*neo4j.TransactionConfig)) (neo4j.Transaction, error) {
+	return s.tx, nil
+}
+
+func (s *onceSession) Close() error { return nil }
+
+// onceTx records whether it was committed
+type onceTx struct {
+	neo4j.Transaction
+	committed bool
+}
+
+func (tx *onceTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *onceTx) Close() error { return nil }
+
+func TestExecuteReadOnce_DoesNotRetry(t *testing.T) {
+	now := time.Now()
+	sleeps := useTestBreaker(t, &now)
+	session := &onceSession{tx: &onceTx{}}
+	useFakeDriver(t, &fakeDriver{})
+	driver, available = &fakeDriver{session: session}, true
+
+	deadlock := &neo4j.Neo4jError{Code: "Neo.TransientError.Transaction.DeadlockDetected", Msg: "deadlock"}
+	attempts := 0
+	err := ExecuteReadOnce(func(tx neo4j.Transaction) error {
+		attempts++
+		return deadlock
+	})
+
+	assert.ErrorIs(t, err, deadlock)
+	assert.Equal(t, 1, attempts, "work that already handed out records isn't repeated")
+	assert.Empty(t, *sleeps)
+	assert.False(t, session.tx.committed)
+
+	require.NoError(t, ExecuteReadOnce(func(tx neo4j.Transaction) error { return nil }))
+	assert.True(t, session.tx.committed)
+}
+
+func TestExecuteWithRetry_OpensBreakerWhenDatabaseDown(t *testing.T) {
+	now := time.Now()
+	useTestBreaker(t, &now)
+
+	down := &neo4j.ConnectivityError{}
+	attempts := 0
+	failing := func() (interface{}, error) {
+		attempts++
+		return nil, down
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := executeWithRetry(failing)
+		assert.True(t, isConnectionError(err))
+	}
+	assert.Equal(t, 3*maxTransactionAttempts, attempts)
+	assert.False(t, breaker.allow())
+
+	// While open, requests fail fast without touching the database
+	_, err := executeWithRetry(failing)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3*maxTransactionAttempts, attempts)
+
+	// After the cooldown a successful probe closes the breaker
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.allow())
+	_, err = executeWithRetry(func() (interface{}, error) { return nil, nil })
+	require.NoError(t, err)
+	assert.True(t, breaker.allow())
+}
+
+func TestIsAvailable_ReflectsBreaker(t *testing.T) {
+	now := time.Now()
+	useTestBreaker(t, &now)
+
+	testDriver, err := neo4j.NewDriver("bolt://localhost:7687", neo4j.NoAuth())
+	require.NoError(t, err)
+
+	oldDriver, oldAvailable := driver, available
+	driver, available = testDriver, true
+	t.Cleanup(func() {
+		testDriver.Close()
+		driver, available = oldDriver, oldAvailable
+	})
+
+	assert.True(t, IsAvailable())
+	for i := 0; i < 3; i++ {
+		breaker.record(&neo4j.ConnectivityError{})
+	}
+	assert.False(t, IsAvailable())
+}
+
+func TestIsFullTextUnavailable(t *testing.T) {
+	missing := &neo4j.Neo4jError{Code: "Neo.ClientError.Procedure.ProcedureCallFailed", Msg: "There is no such fulltext schema index: article_entity_text"}
+	assert.True(t, IsFullTextUnavailable(fmt.Errorf("query failed: %w", missing)))
+	assert.True(t, IsFullTextUnavailable(&neo4j.Neo4jError{Code: "Neo.ClientError.Procedure.ProcedureNotFound"}))
+	assert.False(t, IsFullTextUnavailable(&neo4j.Neo4jError{Code: "Neo.ClientError.Statement.SyntaxError", Msg: "bad query"}))
+	assert.False(t, IsFullTextUnavailable(errors.New("connection refused")))
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clank/internal/logging"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// schemaElement is a constraint or index the graph relies on, created with
+// IF NOT EXISTS so it can be run on every start
+type schemaElement struct {
+	name   string
+	cypher string
+}
+
+// schema lists the graph's constraints and indexes. Uniqueness constraints come
+// with an index on their property, so id lookups need no separate index.
+var schema = []schemaElement{
+	// MERGE on ids runs concurrently during integration; the constraints stop two
+	// requests from both creating the node
+	{"entity_id", `CREATE CONSTRAINT entity_id IF NOT EXISTS FOR (e:Entity) REQUIRE e.id IS UNIQUE`},
+	{"article_id", `CREATE CONSTRAINT article_id IF NOT EXISTS FOR (a:Article) REQUIRE a.id IS UNIQUE`},
+	{"article_content_hash", `CREATE CONSTRAINT article_content_hash IF NOT EXISTS FOR (a:Article) REQUIRE a.contentHash IS UNIQUE`},
+
+	{"entity_name", `CREATE INDEX entity_name IF NOT EXISTS FOR (e:Entity) ON (e.name)`},
+	{"entity_type", `CREATE INDEX entity_type IF NOT EXISTS FOR (e:Entity) ON (e.type)`},
+	{"article_publish_date", `CREATE INDEX article_publish_date IF NOT EXISTS FOR (a:Article) ON (a.publishDate)`},
+	{"entity_wikidata_id", `CREATE INDEX entity_wikidata_id IF NOT EXISTS FOR (e:Entity) ON (e.wikidata_id)`},
+	{"entity_revision_entity", `CREATE INDEX entity_revision_entity IF NOT EXISTS FOR (r:EntityRevision) ON (r.entityId)`},
+	{"mention_article", `CREATE INDEX mention_article IF NOT EXISTS FOR (m:Mention) ON (m.articleId)`},
+
+	// Searched by /api/search
+	{FullTextIndex, `CREATE FULLTEXT INDEX ` + FullTextIndex + ` IF NOT EXISTS
+		FOR (n:Article|Entity) ON EACH [n.title, n.content, n.name]`},
+}
+
+// EnsureSchema creates the graph's constraints and indexes, skipping those that
+// already exist, and logs each one it creates. Every element is attempted even
+// when an earlier one fails, e.g. a uniqueness constraint over existing duplicates.
+func EnsureSchema() error {
+	var errs []error
+	for _, element := range schema {
+		created, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+			return ensureSchemaElement(tx, element)
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create %s: %w", element.name, err))
+			continue
+		}
+		if created.(bool) {
+			logging.For(context.Background(), "db").Info("Neo4j schema element created", "element", element.name)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ensureSchemaElement runs the element's statement, reporting whether it created
+// anything rather than finding it already there
+func ensureSchemaElement(tx neo4j.Transaction, element schemaElement) (bool, error) {
+	result, err := tx.Run(element.cypher, nil)
+	if err != nil {
+		return false, err
+	}
+	summary, err := result.Consume()
+	if err != nil {
+		return false, err
+	}
+	counters := summary.Counters()
+	return counters.ConstraintsAdded()+counters.IndexesAdded() > 0, nil
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaTx records schema statements and reports each as created the first time
+type schemaTx struct {
+	neo4j.Transaction
+	existing   map[string]bool
+	statements []string
+}
+
+func (tx *schemaTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	tx.statements = append(tx.statements, cypher)
+	created := !tx.existing[cypher]
+	tx.existing[cypher] = true
+
+	counters := schemaCounters{}
+	if created && strings.Contains(cypher, "CONSTRAINT") {
+		counters.constraints = 1
+	} else if created {
+		counters.indexes = 1
+	}
+	return &schemaResult{summary: schemaSummary{counters: counters}}, nil
+}
+
+type schemaResult struct {
+	neo4j.Result
+	summary schemaSummary
+}
+
+func (r *schemaResult) Consume() (neo4j.ResultSummary, error) { return r.summary, nil }
+
+type schemaSummary struct {
+	neo4j.ResultSummary
+	counters schemaCounters
+}
+
+func (s schemaSummary) Counters() neo4j.Counters { return s.counters }
+
+type schemaCounters struct {
+	neo4j.Counters
+	constraints, indexes int
+}
+
+func (c schemaCounters) ConstraintsAdded() int { return c.constraints }
+func (c schemaCounters) IndexesAdded() int     { return c.indexes }
+
+func TestEnsureSchemaElements(t *testing.T) {
+	tx := &schemaTx{existing: make(map[string]bool)}
+
+	var created []string
+	for _, element := range schema {
+		ok, err := ensureSchemaElement(tx, element)
+		require.NoError(t, err)
+		if ok {
+			created = append(created, element.name)
+		}
+	}
+
+	statements := strings.Join(tx.statements, "\n")
+	for _, expected := range []string{
+		"FOR (e:Entity) REQUIRE e.id IS UNIQUE",
+		"FOR (a:Article) REQUIRE a.id IS UNIQUE",
+		"FOR (a:Article) REQUIRE a.contentHash IS UNIQUE",
+		"FOR (e:Entity) ON (e.name)",
+		"FOR (e:Entity) ON (e.type)",
+		"FOR (a:Article) ON (a.publishDate)",
+		"FOR (e:Entity) ON (e.wikidata_id)",
+		"CREATE FULLTEXT INDEX " + FullTextIndex,
+	} {
+		assert.Contains(t, statements, expected)
+	}
+	for _, statement := range tx.statements {
+		assert.Contains(t, statement, "IF NOT EXISTS", "schema statements must be safe to rerun")
+	}
+	assert.Len(t, created, len(schema))
+
+	// A second start finds everything in place
+	for _, element := range schema {
+		ok, err := ensureSchemaElement(tx, element)
+		require.NoError(t, err)
+		assert.False(t, ok, element.name)
+	}
+}
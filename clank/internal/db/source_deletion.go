@@ -0,0 +1,201 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+)
+
+// SourceDeletionReport summarizes what removing a source URL deleted or kept
+type SourceDeletionReport struct {
+	Articles             int `json:"articles"`             // Articles stored under the URL, deleted
+	EntitiesDeleted      int `json:"entitiesDeleted"`      // Entities no other source reports
+	EntitiesKept         int `json:"entitiesKept"`         // Entities other sources corroborate, with the URL removed
+	RelationshipsDeleted int `json:"relationshipsDeleted"` // Edges no other source reports
+	RelationshipsKept    int `json:"relationshipsKept"`    // Edges other sources corroborate, with the URL removed
+	Revisions            int `json:"revisions"`            // Entity revisions made by the URL's articles
+}
+
+// sourcedItem is an entity or edge and the URLs of the sources reporting it
+type sourcedItem struct {
+	id      interface{} // Entity id, or the internal ID of an edge
+	sources []string
+}
+
+// sourceURLsSet adds $sourceUrl to a node or edge's source_urls list once; an
+// empty URL leaves the list as it is
+func sourceURLsSet(variable string) string {
+	return fmt.Sprintf(`%[1]s.source_urls = CASE
+		WHEN $sourceUrl = '' OR $sourceUrl IN coalesce(%[1]s.source_urls, []) THEN %[1]s.source_urls
+		ELSE coalesce(%[1]s.source_urls, []) + $sourceUrl
+	END`, variable)
+}
+
+// DeleteSourceURL removes everything reported only by url in one write
+// transaction: the articles stored under it, entities and edges (events
+// included) whose sources come down to it, and the entity revisions its articles
+// made. Entities and edges other sources corroborate are kept with url dropped
+// from their source_urls. Data written before source_urls was recorded counts
+// the URLs of the articles mentioning an entity, and an event edge's article, as
+// its sources.
+func DeleteSourceURL(url string) (SourceDeletionReport, error) {
+	result, err := ExecuteWrite(func(tx neo4j.Transaction) (interface{}, error) {
+		return deleteSourceURL(tx, url)
+	})
+	if err != nil {
+		return SourceDeletionReport{}, fmt.Errorf("failed to delete source %s: %w", url, err)
+	}
+	return result.(SourceDeletionReport), nil
+}
+
+// deleteSourceURL does DeleteSourceURL's work in tx
+func deleteSourceURL(tx neo4j.Transaction, url string) (SourceDeletionReport, error) {
+	var report SourceDeletionReport
+	params := map[string]interface{}{"url": url}
+
+	result, err := tx.Run(`
+		MATCH (a:Article {url: $url})
+		RETURN a.id
+	`, params)
+	if err != nil {
+		return report, fmt.Errorf("failed to find articles: %w", err)
+	}
+	articleIDs := []string{}
+	for result.Next() {
+		if id, ok := result.Record().Values[0].(string); ok {
+			articleIDs = append(articleIDs, id)
+		}
+	}
+	if err := result.Err(); err != nil {
+		return report, err
+	}
+	params["articleIds"] = articleIDs
+
+	edges, err := sourcedItems(tx, `
+		MATCH (:Entity)-[r]->(:Entity)
+		WHERE $url IN r.source_urls OR (r.source_urls IS NULL AND r.articleId IN $articleIds)
+		RETURN ID(r), coalesce(r.source_urls, [$url])
+	`, params)
+	if err != nil {
+		return report, fmt.Errorf("failed to find relationships: %w", err)
+	}
+	entities, err := sourcedItems(tx, `
+		MATCH (e:Entity)
+		WHERE $url IN e.source_urls OR (e.source_urls IS NULL AND (:Article {url: $url})-[:MENTIONS]->(e))
+		OPTIONAL MATCH (a:Article)-[:MENTIONS]->(e)
+		WITH e, collect(DISTINCT a.url) AS articleUrls
+		RETURN e.id, coalesce(e.source_urls, articleUrls)
+	`, params)
+	if err != nil {
+		return report, fmt.Errorf("failed to find entities: %w", err)
+	}
+
+	keptEdges, deletedEdges := withoutSource(edges, url)
+	if err := writePrunedSources(tx, `
+		UNWIND $kept AS kept
+		MATCH ()-[r]->() WHERE ID(r) = kept.id
+		SET r.source_urls = kept.sources
+		WITH count(*) AS updated
+		MATCH ()-[r]->() WHERE ID(r) IN $deleted
+		DELETE r
+	`, keptEdges, deletedEdges); err != nil {
+		return report, fmt.Errorf("failed to remove source from relationships: %w", err)
+	}
+	report.RelationshipsKept, report.RelationshipsDeleted = len(keptEdges), len(deletedEdges)
+
+	keptEntities, deletedEntities := withoutSource(entities, url)
+	if err := writePrunedSources(tx, `
+		UNWIND $kept AS kept
+		MATCH (e:Entity {id: kept.id})
+		SET e.source_urls = kept.sources
+		WITH count(*) AS updated
+		MATCH (e:Entity) WHERE e.id IN $deleted
+		OPTIONAL MATCH (m:Mention)-[:IN]->(e)
+		OPTIONAL MATCH (rev:EntityRevision)-[:REVISION_OF]->(e)
+		DETACH DELETE m, rev, e
+	`, keptEntities, deletedEntities); err != nil {
+		return report, fmt.Errorf("failed to remove source from entities: %w", err)
+	}
+	report.EntitiesKept, report.EntitiesDeleted = len(keptEntities), len(deletedEntities)
+
+	if report.Revisions, err = deleteCount(tx, `
+		MATCH (rev:EntityRevision {sourceUrl: $url})
+		DETACH DELETE rev
+		RETURN count(rev)
+	`, params); err != nil {
+		return report, fmt.Errorf("failed to delete entity revisions: %w", err)
+	}
+	if report.Articles, err = deleteCount(tx, `
+		MATCH (a:Article {url: $url})
+		DETACH DELETE a
+		RETURN count(a)
+	`, params); err != nil {
+		return report, fmt.Errorf("failed to delete articles: %w", err)
+	}
+	return report, nil
+}
+
+// sourcedItems reads (id, sources) rows
+func sourcedItems(tx neo4j.Transaction, cypher string, params map[string]interface{}) ([]sourcedItem, error) {
+	result, err := tx.Run(cypher, params)
+	if err != nil {
+		return nil, err
+	}
+	var items []sourcedItem
+	for result.Next() {
+		values := result.Record().Values
+		item := sourcedItem{id: values[0]}
+		sources, _ := values[1].([]interface{})
+		for _, source := range sources {
+			if s, ok := source.(string); ok {
+				item.sources = append(item.sources, s)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, result.Err()
+}
+
+// withoutSource drops url from each item's sources. Items left with other
+// sources are returned as kept, as {id, sources} rows for the update; the ids of
+// items left with none are returned as deleted.
+func withoutSource(items []sourcedItem, url string) (kept []map[string]interface{}, deleted []interface{}) {
+	kept, deleted = []map[string]interface{}{}, []interface{}{}
+	for _, item := range items {
+		remaining := []string{}
+		for _, source := range item.sources {
+			if source != url {
+				remaining = append(remaining, source)
+			}
+		}
+		if len(remaining) == 0 {
+			deleted = append(deleted, item.id)
+			continue
+		}
+		kept = append(kept, map[string]interface{}{"id": item.id, "sources": remaining})
+	}
+	return kept, deleted
+}
+
+// writePrunedSources runs cypher, which updates the $kept rows and deletes the
+// $deleted ids, unless there is nothing to write
+func writePrunedSources(tx neo4j.Transaction, cypher string, kept []map[string]interface{}, deleted []interface{}) error {
+	if len(kept) == 0 && len(deleted) == 0 {
+		return nil
+	}
+	_, err := tx.Run(cypher, map[string]interface{}{"kept": kept, "deleted": deleted})
+	return err
+}
+
+// deleteCount runs a deletion returning the number of nodes removed
+func deleteCount(tx neo4j.Transaction, cypher string, params map[string]interface{}) (int, error) {
+	result, err := tx.Run(cypher, params)
+	if err != nil {
+		return 0, err
+	}
+	if !result.Next() {
+		return 0, result.Err()
+	}
+	count, _ := result.Record().Values[0].(int64)
+	return int(count), nil
+}
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/neo4j/neo4j-go-driver/v4/neo4j"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sourceGraphTx is an in-memory graph answering the source deletion queries.
+// Sources are stored as the driver returns them; nil means no source_urls.
+type sourceGraphTx struct {
+	neo4j.Transaction
+	articles  map[string]string                // article ID -> URL
+	mentions  map[string][]string              // entity ID -> IDs of articles mentioning it
+	entities  map[string][]interface{}         // entity ID -> source_urls
+	edges     map[int64]map[string]interface{} // edge ID -> properties
+	revisions map[string]int                   // sourceUrl -> entity revisions
+}
+
+func (tx *sourceGraphTx) Run(cypher string, params map[string]interface{}) (neo4j.Result, error) {
+	result := &rowsResult{index: -1}
+	row := func(values ...interface{}) {
+		result.records = append(result.records, &neo4j.Record{Values: values})
+	}
+	url, _ := params["url"].(string)
+
+	switch {
+	case strings.Contains(cypher, "RETURN a.id"):
+		for id, articleURL := range tx.articles {
+			if articleURL == url {
+				row(id)
+			}
+		}
+	case strings.Contains(cypher, "RETURN ID(r)"):
+		for id, props := range tx.edges {
+			sources, ok := props["source_urls"].([]interface{})
+			switch {
+			case ok && slices.Contains(sources, interface{}(url)):
+				row(id, sources)
+			case !ok && slices.Contains(params["articleIds"].([]string), props["articleId"].(string)):
+				row(id, []interface{}{url})
+			}
+		}
+	case strings.Contains(cypher, "RETURN e.id"):
+		for id, sources := range tx.entities {
+			var articleURLs []interface{}
+			for _, articleID := range tx.mentions[id] {
+				articleURLs = append(articleURLs, tx.articles[articleID])
+			}
+			switch {
+			case sources != nil && slices.Contains(sources, interface{}(url)):
+				row(id, sources)
+			case sources == nil && slices.Contains(articleURLs, interface{}(url)):
+				row(id, articleURLs)
+			}
+		}
+	case strings.Contains(cypher, "SET r.source_urls = kept.sources"):
+		for _, kept := range params["kept"].([]map[string]interface{}) {
+			tx.edges[kept["id"].(int64)]["source_urls"] = kept["sources"]
+		}
+		for _, id := range params["deleted"].([]interface{}) {
+			delete(tx.edges, id.(int64))
+		}
+	case strings.Contains(cypher, "SET e.source_urls = kept.sources"):
+		for _, kept := range params["kept"].([]map[string]interface{}) {
+			sources := kept["sources"].([]string)
+			tx.entities[kept["id"].(string)] = make([]interface{}, len(sources))
+			for i, source := range sources {
+				tx.entities[kept["id"].(string)][i] = source
+			}
+		}
+		for _, id := range params["deleted"].([]interface{}) {
+			delete(tx.entities, id.(string))
+			delete(tx.mentions, id.(string))
+		}
+	case strings.Contains(cypher, "EntityRevision {sourceUrl: $url}"):
+		row(int64(tx.revisions[url]))
+		delete(tx.revisions, url)
+	case strings.Contains(cypher, "DETACH DELETE a"):
+		deleted := 0
+		for id, articleURL := range tx.articles {
+			if articleURL == url {
+				delete(tx.articles, id)
+				deleted++
+			}
+		}
+		row(int64(deleted))
+	}
+	return result, nil
+}
+
+func newSourceGraphTx() *sourceGraphTx {
+	return &sourceGraphTx{
+		articles: map[string]string{"a1": "https://a.example/story", "a2": "https://b.example/story"},
+		mentions: map[string][]string{
+			"acme": {"a1", "a2"}, "jane": {"a1", "a2"}, "shell": {"a1"}, "legacy": {"a1"}, "shared-legacy": {"a1", "a2"},
+		},
+		entities: map[string][]interface{}{
+			"acme":          {"https://a.example/story", "https://b.example/story"},
+			"jane":          {"https://a.example/story", "https://b.example/story"},
+			"shell":         {"https://a.example/story"},
+			"legacy":        nil,
+			"shared-legacy": nil,
+		},
+		edges: map[int64]map[string]interface{}{
+			// Reported by both articles
+			1: {"type": "payment", "source_urls": []interface{}{"https://a.example/story", "https://b.example/story"}},
+			// Reported only by the deleted URL
+			2: {"type": "ownership", "source_urls": []interface{}{"https://a.example/story"}},
+			// An event edge written before source_urls was recorded
+			3: {"articleId": "a1"},
+			// Another source's event edge
+			4: {"articleId": "a2"},
+		},
+		revisions: map[string]int{"https://a.example/story": 3, "https://b.example/story": 2},
+	}
+}
+
+func TestDeleteSourceURL_RelationshipWithOtherSourceIsKept(t *testing.T) {
+	tx := newSourceGraphTx()
+
+	report, err := deleteSourceURL(tx, "https://a.example/story")
+	require.NoError(t, err)
+
+	require.Contains(t, tx.edges, int64(1))
+	assert.Equal(t, []string{"https://b.example/story"}, tx.edges[1]["source_urls"], "only the deleted URL is removed")
+	assert.Equal(t, 1, report.RelationshipsKept)
+}
+
+func TestDeleteSourceURL_RelationshipWithOnlyThatSourceIsDeleted(t *testing.T) {
+	tx := newSourceGraphTx()
+
+	report, err := deleteSourceURL(tx, "https://a.example/story")
+	require.NoError(t, err)
+
+	assert.NotContains(t, tx.edges, int64(2))
+	assert.NotContains(t, tx.edges, int64(3), "event edges from the deleted article go too")
+	assert.Contains(t, tx.edges, int64(4))
+	assert.Equal(t, 2, report.RelationshipsDeleted)
+}
+
+func TestDeleteSourceURL_Entities(t *testing.T) {
+	tx := newSourceGraphTx()
+
+	report, err := deleteSourceURL(tx, "https://a.example/story")
+	require.NoError(t, err)
+
+	assert.Equal(t, []interface{}{"https://b.example/story"}, tx.entities["acme"])
+	assert.Equal(t, []interface{}{"https://b.example/story"}, tx.entities["shared-legacy"], "legacy entities count the articles mentioning them")
+	assert.NotContains(t, tx.entities, "shell")
+	assert.NotContains(t, tx.entities, "legacy")
+	assert.Equal(t, SourceDeletionReport{
+		Articles:             1,
+		EntitiesDeleted:      2,
+		EntitiesKept:         3,
+		RelationshipsDeleted: 2,
+		RelationshipsKept:    1,
+		Revisions:            3,
+	}, report)
+	assert.Equal(t, map[string]string{"a2": "https://b.example/story"}, tx.articles)
+	assert.Equal(t, map[string]int{"https://b.example/story": 2}, tx.revisions)
+}
+
+func TestDeleteSourceURL_UnknownURL(t *testing.T) {
+	tx := newSourceGraphTx()
+
+	report, err := deleteSourceURL(tx, "https://c.example/story")
+	require.NoError(t, err)
+	assert.Equal(t, SourceDeletionReport{}, report)
+	assert.Len(t, tx.edges, 4)
+	assert.Len(t, tx.entities, 5)
+}
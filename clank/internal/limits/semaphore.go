@@ -0,0 +1,88 @@
+// Package limits bounds how many expensive operations (browser scrapes, LLM calls)
+// run at once across the server.
+package limits
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// ErrBusy is returned when too many callers are already waiting for a slot
+var ErrBusy = errors.New("server busy")
+
+// Semaphore admits at most size holders at once. Callers beyond that wait in a
+// queue of at most maxQueue; further callers are turned away with ErrBusy. A nil
+// Semaphore admits everyone.
+type Semaphore struct {
+	name     string
+	slots    chan struct{}
+	maxQueue int
+	waiting  atomic.Int64
+}
+
+// NewSemaphore creates a semaphore with size slots and a queue of maxQueue waiters
+// (0 leaves the queue unbounded). It returns nil, meaning no limit, when size is not positive.
+func NewSemaphore(name string, size, maxQueue int) *Semaphore {
+	if size <= 0 {
+		return nil
+	}
+	return &Semaphore{name: name, slots: make(chan struct{}, size), maxQueue: maxQueue}
+}
+
+// Acquire takes a slot, waiting until one is released or ctx is done. Every
+// successful Acquire must be paired with a Release.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if waiting := s.waiting.Add(1); s.maxQueue > 0 && waiting > int64(s.maxQueue) {
+		s.waiting.Add(-1)
+		return fmt.Errorf("%w: %d %s operations already waiting", ErrBusy, s.maxQueue, s.name)
+	}
+	defer s.waiting.Add(-1)
+
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for a %s slot: %w", s.name, ctx.Err())
+	}
+}
+
+// Release gives back a slot taken by Acquire
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
+
+var (
+	browsers atomic.Pointer[Semaphore]
+	llmCalls atomic.Pointer[Semaphore]
+)
+
+// Configure sets the server-wide limits on concurrent browser scrapes and LLM
+// calls. Non-positive sizes leave that operation unlimited.
+func Configure(maxBrowsers, maxLLM, maxQueue int) {
+	browsers.Store(NewSemaphore("browser", maxBrowsers, maxQueue))
+	llmCalls.Store(NewSemaphore("LLM", maxLLM, maxQueue))
+}
+
+// Browsers is the semaphore guarding browser scrapes (nil when unlimited)
+func Browsers() *Semaphore {
+	return browsers.Load()
+}
+
+// LLM is the semaphore guarding LLM calls (nil when unlimited)
+func LLM() *Semaphore {
+	return llmCalls.Load()
+}
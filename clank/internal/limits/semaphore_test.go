@@ -0,0 +1,69 @@
+package limits
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemaphore_BlocksBeyondSize(t *testing.T) {
+	sem := NewSemaphore("test", 2, 0)
+	ctx := context.Background()
+	require.NoError(t, sem.Acquire(ctx))
+	require.NoError(t, sem.Acquire(ctx))
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- sem.Acquire(ctx) }()
+
+	select {
+	case <-acquired:
+		t.Fatal("third acquisition did not wait for a release")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	sem.Release()
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("third acquisition was not admitted after a release")
+	}
+}
+
+func TestSemaphore_WaitCancelled(t *testing.T) {
+	sem := NewSemaphore("test", 1, 0)
+	require.NoError(t, sem.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := sem.Acquire(ctx)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, sem.waiting.Load(), "a cancelled waiter leaves the queue")
+}
+
+func TestSemaphore_QueueFull(t *testing.T) {
+	sem := NewSemaphore("test", 1, 1)
+	require.NoError(t, sem.Acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sem.Acquire(ctx)
+	require.Eventually(t, func() bool { return sem.waiting.Load() == 1 }, time.Second, time.Millisecond)
+
+	err := sem.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrBusy)
+}
+
+func TestSemaphore_NilIsUnlimited(t *testing.T) {
+	sem := NewSemaphore("test", 0, 0)
+	assert.Nil(t, sem)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, sem.Acquire(context.Background()))
+	}
+	sem.Release()
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clank/internal/interfaces"
+)
+
+// TextAdapter exposes an LLMProvider through the string-based interfaces.LLMProvider
+// used by the prompt packages
+type TextAdapter struct {
+	provider LLMProvider
+}
+
+// Ensure TextAdapter implements interfaces.LLMProvider
+var _ interfaces.LLMProvider = (*TextAdapter)(nil)
+
+// NewTextAdapter wraps an LLMProvider so it returns plain text completions
+func NewTextAdapter(provider LLMProvider) *TextAdapter {
+	return &TextAdapter{provider: provider}
+}
+
+// Generate performs a completion and returns the content of the first choice
+func (a *TextAdapter) Generate(ctx context.Context, messages []interfaces.Message) (string, error) {
+	resp, err := a.provider.Generate(ctx, convertMessages(messages))
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("LLM error: %s", resp.Error)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+
+	if resp.Choices[0].Content != "" {
+		return resp.Choices[0].Content, nil
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// GenerateStream streams a completion into respChan
+func (a *TextAdapter) GenerateStream(ctx context.Context, messages []interfaces.Message, respChan chan<- string) error {
+	return a.provider.GenerateStream(ctx, convertMessages(messages), respChan)
+}
+
+// convertMessages converts interface messages to llm.Message
+func convertMessages(messages []interfaces.Message) []Message {
+	llmMessages := make([]Message, len(messages))
+	for i, msg := range messages {
+		llmMessages[i] = Message{
+			Role:      msg.Role,
+			Content:   msg.Content,
+			CreatedAt: time.Now(),
+		}
+	}
+	return llmMessages
+}
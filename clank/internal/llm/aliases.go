@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"strings"
+
+	"clank/internal/models"
+)
+
+// aliasInstructions asks the model to record the other names people are referred to by
+const aliasInstructions = `
+
+Also capture how people are referred to:
+- For each entity with type "person", add an "aliases" array listing every other name, title or nickname the article uses for them (e.g. "Senator Smith", "Bob Smith", "the minister")
+- Use the person's fullest name as "name" and do not repeat it in "aliases"
+- Only include aliases that clearly refer to that person; omit "aliases" when there are none`
+
+// AliasInstructions returns the person alias instructions to append to extraction
+// prompts, or "" when alias extraction is disabled
+func (c *Client) AliasInstructions() string {
+	if !c.personAliases {
+		return ""
+	}
+	return aliasInstructions
+}
+
+// cleanAliases normalizes the aliases returned by the model and drops them from
+// entities that are not people
+func cleanAliases(entity *models.ExtractedEntity) {
+	aliases := entity.Aliases
+	entity.Aliases = nil
+	if !strings.EqualFold(entity.Type, "person") {
+		return
+	}
+	entity.AddAliases(aliases...)
+}
@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ProcessArticle_PersonAliases(t *testing.T) {
+	response := `{
+		"entities": [
+			{"id": "p1", "type": "PERSON", "name": "Robert Smith", "aliases": ["Senator Smith", "Robert  Smith", "senator smith", "Bob"]},
+			{"id": "o1", "type": "organization", "name": "Acme Corp", "aliases": ["Acme"]}
+		],
+		"relationships": []
+	}`
+
+	for _, enabled := range []bool{true, false} {
+		var prompt string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req GenerateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			prompt = req.Messages[len(req.Messages)-1].Content
+			json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: response}}})
+		}))
+
+		cfg := &config.Config{}
+		cfg.LLM.URL = server.URL
+		cfg.Extraction.PersonAliases = enabled
+		result, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{Content: "Senator Smith, known as Bob, denied the claims."})
+		server.Close()
+		require.NoError(t, err)
+
+		if enabled {
+			assert.Contains(t, prompt, `"aliases"`)
+		} else {
+			assert.NotContains(t, prompt, `"aliases"`)
+		}
+
+		require.Len(t, result.Entities, 2)
+		assert.Equal(t, []string{"Senator Smith", "Bob"}, result.Entities[0].Aliases)
+		assert.Empty(t, result.Entities[1].Aliases)
+	}
+}
@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"strings"
+
+	"clank/internal/models"
+	"clank/pkg/extraction"
+)
+
+// normalizeAmounts stores the parsed value and currency of money amounts next to the
+// raw text the model returned: "amount" properties of relationships, and money
+// entities by their "amount" property or their name. Amounts that can't be read
+// unambiguously are left as text only.
+func normalizeAmounts(result *models.ExtractionResult) {
+	for i := range result.Relationships {
+		rel := &result.Relationships[i]
+		if raw, ok := rel.Properties["amount"]; ok {
+			rel.Properties = withNormalizedAmount(rel.Properties, raw)
+		}
+	}
+
+	for i := range result.Entities {
+		entity := &result.Entities[i]
+		if !strings.EqualFold(entity.Type, "money") {
+			continue
+		}
+		raw, ok := entity.Properties["amount"]
+		if !ok {
+			raw = entity.Name
+		}
+		entity.Properties = withNormalizedAmount(entity.Properties, raw)
+	}
+}
+
+// withNormalizedAmount adds amount_value and currency for raw to props. A currency
+// the model already gave is kept.
+func withNormalizedAmount(props map[string]interface{}, raw interface{}) map[string]interface{} {
+	var value float64
+	var currency string
+	switch raw := raw.(type) {
+	case float64:
+		value = raw
+	case string:
+		var ok bool
+		if value, currency, ok = extraction.NormalizeAmount(raw); !ok {
+			return props
+		}
+	default:
+		return props
+	}
+
+	if props == nil {
+		props = make(map[string]interface{})
+	}
+	props["amount_value"] = value
+	if existing, _ := props["currency"].(string); currency != "" && existing == "" {
+		props["currency"] = currency
+	}
+	return props
+}
@@ -0,0 +1,38 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAmounts(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "money", Name: "€500k"},
+			{ID: "e2", Type: "money", Name: "the bribe", Properties: map[string]interface{}{"amount": "$1.2M"}},
+			{ID: "e3", Type: "money", Name: "an undisclosed sum"},
+			{ID: "e4", Type: "organization", Name: "3M"},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "payment", Properties: map[string]interface{}{"amount": "1,000,000 USD"}},
+			{ID: "r2", Type: "payment", Properties: map[string]interface{}{"amount": "2.5 million", "currency": "GBP"}},
+			{ID: "r3", Type: "payment", Properties: map[string]interface{}{"amount": "between $1M and $2M"}},
+			{ID: "r4", Type: "employment"},
+		},
+	}
+
+	normalizeAmounts(result)
+
+	assert.Equal(t, map[string]interface{}{"amount_value": 500e3, "currency": "EUR"}, result.Entities[0].Properties)
+	assert.Equal(t, map[string]interface{}{"amount": "$1.2M", "amount_value": 1.2e6, "currency": "USD"}, result.Entities[1].Properties)
+	assert.Nil(t, result.Entities[2].Properties)
+	assert.Nil(t, result.Entities[3].Properties)
+
+	assert.Equal(t, map[string]interface{}{"amount": "1,000,000 USD", "amount_value": 1e6, "currency": "USD"}, result.Relationships[0].Properties)
+	assert.Equal(t, map[string]interface{}{"amount": "2.5 million", "amount_value": 2.5e6, "currency": "GBP"}, result.Relationships[1].Properties)
+	assert.Equal(t, map[string]interface{}{"amount": "between $1M and $2M"}, result.Relationships[2].Properties)
+	assert.Nil(t, result.Relationships[3].Properties)
+}
@@ -0,0 +1,197 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"clank/internal/models"
+)
+
+// acronymStopwords are connecting words an acronym may leave out
+var acronymStopwords = map[string]bool{
+	"of": true, "the": true, "and": true, "for": true, "on": true, "in": true, "to": true, "&": true,
+}
+
+// parentheticalAcronym matches a name followed by its acronym, e.g. "Department of Justice (DOJ)"
+var parentheticalAcronym = regexp.MustCompile(`^(.+?)\s*\(([^()]+)\)$`)
+
+// canonicalizeEntities merges entities of the same type that name the same thing
+// within one extraction, such as "the Department of Justice" and "DOJ". Entities are
+// clustered when their names match ignoring case and a leading "the", when one name is
+// the acronym of the other, or when one entity's mention or alias is the other's name.
+// The highest-confidence member of each cluster keeps its name and ID; the other names
+// become its aliases and relationships are pointed at it.
+func canonicalizeEntities(result *models.ExtractionResult) {
+	if result == nil || len(result.Entities) < 2 {
+		return
+	}
+
+	entities := result.Entities
+	parent := make([]int, len(entities))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	terms := make([][]string, len(entities))
+	for i := range entities {
+		terms[i] = entityTerms(entities[i])
+	}
+	for i := range entities {
+		for j := i + 1; j < len(entities); j++ {
+			if !strings.EqualFold(strings.TrimSpace(entities[i].Type), strings.TrimSpace(entities[j].Type)) {
+				continue
+			}
+			if sameEntity(entities[i].Name, terms[i], entities[j].Name, terms[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	// The most confident member of each cluster is canonical; ties keep the earlier entity
+	canonical := make(map[int]int)
+	for i := range entities {
+		root := find(i)
+		if best, ok := canonical[root]; !ok || entities[i].Confidence > entities[best].Confidence {
+			canonical[root] = i
+		}
+	}
+
+	idMap := make(map[string]string)
+	merged := make([]models.ExtractedEntity, 0, len(entities))
+	index := make(map[int]int) // canonical entity -> index in merged
+	for i := range entities {
+		keep := canonical[find(i)]
+		if keep == i {
+			index[i] = len(merged)
+			merged = append(merged, entities[i])
+		}
+	}
+	for i := range entities {
+		keep := canonical[find(i)]
+		if keep == i {
+			continue
+		}
+		target := &merged[index[keep]]
+		mergeEntity(target, entities[i])
+		target.AddAliases(entities[i].Name)
+		if entities[i].ID != "" {
+			idMap[entities[i].ID] = target.ID
+		}
+	}
+	if len(merged) == len(entities) {
+		return
+	}
+	result.Entities = merged
+
+	relationshipIndex := make(map[string]int)
+	relationships := make([]models.ExtractedRelationship, 0, len(result.Relationships))
+	for _, rel := range result.Relationships {
+		distinct := rel.FromID != rel.ToID
+		if id, ok := idMap[rel.FromID]; ok {
+			rel.FromID = id
+		}
+		if id, ok := idMap[rel.ToID]; ok {
+			rel.ToID = id
+		}
+		// A link between two names of the same entity says nothing
+		if distinct && rel.FromID == rel.ToID {
+			continue
+		}
+
+		key := strings.ToUpper(rel.Type) + "|" + rel.FromID + "|" + rel.ToID
+		if idx, ok := relationshipIndex[key]; ok {
+			if rel.Confidence > relationships[idx].Confidence {
+				relationships[idx].Confidence = rel.Confidence
+			}
+			continue
+		}
+		relationshipIndex[key] = len(relationships)
+		relationships = append(relationships, rel)
+	}
+	result.Relationships = relationships
+}
+
+// entityTerms are the normalized names an entity is known by in the article: its
+// name, aliases and mention texts, with "Name (ACRONYM)" mentions split in two
+func entityTerms(entity models.ExtractedEntity) []string {
+	names := append([]string{entity.Name}, entity.Aliases...)
+	for _, mention := range entity.Mentions {
+		names = append(names, mention.Text)
+	}
+
+	var terms []string
+	for _, name := range names {
+		if m := parentheticalAcronym.FindStringSubmatch(strings.TrimSpace(name)); m != nil {
+			terms = append(terms, normalizeEntityName(m[1]), normalizeEntityName(m[2]))
+			continue
+		}
+		if term := normalizeEntityName(name); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// sameEntity reports whether two entities' names and terms identify the same thing
+func sameEntity(nameA string, termsA []string, nameB string, termsB []string) bool {
+	a, b := normalizeEntityName(nameA), normalizeEntityName(nameB)
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b || isAcronymOf(nameA, b) || isAcronymOf(nameB, a) {
+		return true
+	}
+	return containsTerm(termsA, b) || containsTerm(termsB, a)
+}
+
+// normalizeEntityName lowercases a name, collapses whitespace and drops a leading "the"
+func normalizeEntityName(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	return strings.TrimPrefix(name, "the ")
+}
+
+// isAcronymOf reports whether acronym (as written, e.g. "DOJ" or "D.O.J.") is made of
+// the initials of the words in the normalized name, with or without its short
+// connecting words: both DOJ and FBI are accepted for "... of ..." names
+func isAcronymOf(acronym, name string) bool {
+	letters := strings.ReplaceAll(strings.TrimSpace(acronym), ".", "")
+	if len(letters) < 2 || strings.ContainsAny(letters, " ") {
+		return false
+	}
+	for _, r := range letters {
+		if !unicode.IsUpper(r) {
+			return false
+		}
+	}
+
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return false
+	}
+	var all, significant strings.Builder
+	for _, word := range words {
+		initial := []rune(word)[0]
+		all.WriteRune(initial)
+		if !acronymStopwords[word] {
+			significant.WriteRune(initial)
+		}
+	}
+	return strings.EqualFold(all.String(), letters) || strings.EqualFold(significant.String(), letters)
+}
+
+func containsTerm(terms []string, term string) bool {
+	for _, t := range terms {
+		if t == term {
+			return true
+		}
+	}
+	return false
+}
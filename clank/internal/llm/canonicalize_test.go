@@ -0,0 +1,107 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalizeEntities_Acronym(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "organization", Name: "DOJ", Confidence: 0.7},
+			{ID: "e2", Type: "organization", Name: "the Department of Justice", Confidence: 0.9},
+			{ID: "e3", Type: "person", Name: "Jane Smith", Confidence: 0.8},
+			{ID: "e4", Type: "organization", Name: "Acme Construction", Confidence: 0.8},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "investigation", FromID: "e1", ToID: "e4", Confidence: 0.6},
+			{ID: "r2", Type: "investigation", FromID: "e2", ToID: "e4", Confidence: 0.8},
+			{ID: "r3", Type: "employment", FromID: "e3", ToID: "e1", Confidence: 0.7},
+			{ID: "r4", Type: "part_of", FromID: "e1", ToID: "e2", Confidence: 0.5},
+		},
+	}
+
+	canonicalizeEntities(result)
+
+	require.Len(t, result.Entities, 3)
+	doj := result.Entities[0]
+	assert.Equal(t, "e2", doj.ID, "the higher-confidence entity is canonical")
+	assert.Equal(t, "the Department of Justice", doj.Name)
+	assert.Equal(t, []string{"DOJ"}, doj.Aliases)
+	assert.Equal(t, 0.9, doj.Confidence)
+	assert.Equal(t, "Jane Smith", result.Entities[1].Name)
+	assert.Equal(t, "Acme Construction", result.Entities[2].Name)
+
+	require.Len(t, result.Relationships, 2, "duplicate and self relationships are dropped")
+	assert.Equal(t, "e2", result.Relationships[0].FromID)
+	assert.Equal(t, 0.8, result.Relationships[0].Confidence)
+	assert.Equal(t, "e3", result.Relationships[1].FromID)
+	assert.Equal(t, "e2", result.Relationships[1].ToID)
+}
+
+func TestCanonicalizeEntities_Mentions(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "organization", Name: "Federal Bureau of Investigation", Confidence: 0.9,
+				Mentions: []models.EntityMention{{Text: "Federal Bureau of Investigation (FBI)"}}},
+			{ID: "e2", Type: "organization", Name: "the bureau", Confidence: 0.6},
+			{ID: "e3", Type: "organization", Name: "Bureau", Confidence: 0.5,
+				Mentions: []models.EntityMention{{Text: "the Bureau"}}},
+			{ID: "e4", Type: "organization", Name: "FBI", Confidence: 0.8},
+		},
+	}
+
+	canonicalizeEntities(result)
+
+	require.Len(t, result.Entities, 2)
+	assert.Equal(t, "Federal Bureau of Investigation", result.Entities[0].Name)
+	assert.Equal(t, []string{"FBI"}, result.Entities[0].Aliases)
+	assert.Equal(t, "the bureau", result.Entities[1].Name, "\"the bureau\" and \"Bureau\" match once the article is dropped")
+	assert.Equal(t, []string{"Bureau"}, result.Entities[1].Aliases)
+}
+
+func TestCanonicalizeEntities_KeepsUnrelatedNames(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "organization", Name: "Department of Justice", Confidence: 0.9},
+			{ID: "e2", Type: "organization", Name: "Department of Energy", Confidence: 0.9},
+			{ID: "e3", Type: "organization", Name: "DOE", Confidence: 0.8},
+			{ID: "e4", Type: "person", Name: "DJ", Confidence: 0.8},
+			{ID: "e5", Type: "location", Name: "Justice", Confidence: 0.6},
+		},
+	}
+
+	canonicalizeEntities(result)
+
+	names := make([]string, 0, len(result.Entities))
+	for _, entity := range result.Entities {
+		names = append(names, entity.Name)
+	}
+	assert.ElementsMatch(t, []string{"Department of Justice", "Department of Energy", "DJ", "Justice"}, names,
+		"DOE joins the Department of Energy only; other types and names stay separate")
+	assert.Equal(t, []string{"DOE"}, result.Entities[1].Aliases)
+	assert.Empty(t, result.Entities[0].Aliases)
+}
+
+func TestIsAcronymOf(t *testing.T) {
+	tests := []struct {
+		acronym string
+		name    string
+		want    bool
+	}{
+		{"DOJ", "department of justice", true},
+		{"D.O.J.", "department of justice", true},
+		{"SEC", "securities and exchange commission", true},
+		{"FBI", "federal bureau of investigation", true},
+		{"doj", "department of justice", false},
+		{"DOE", "department of justice", false},
+		{"J", "justice", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isAcronymOf(tt.acronym, tt.name), "%s / %s", tt.acronym, tt.name)
+	}
+}
@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"clank/internal/models"
+)
+
+// processArticleChunks extracts each overlapping window of the article and merges the results
+func (c *Client) processArticleChunks(ctx context.Context, article *models.Article) (*models.ExtractionResult, error) {
+	chunks := splitIntoChunks(article.Content, c.chunkSize, c.chunkOverlap)
+
+	results := make([]*models.ExtractionResult, 0, len(chunks))
+	for i, chunk := range chunks {
+		chunkArticle := *article
+		chunkArticle.Content = chunk
+
+		result, err := c.extractArticle(ctx, &chunkArticle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		results = append(results, result)
+	}
+
+	return mergeExtractionResults(results), nil
+}
+
+// splitIntoChunks splits content into windows of at most size characters, with
+// consecutive windows sharing overlap characters. Windows end on a paragraph or
+// sentence boundary when one is available in the second half of the window.
+func splitIntoChunks(content string, size, overlap int) []string {
+	runes := []rune(content)
+	if size <= 0 || len(runes) <= size {
+		return []string{content}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	start := 0
+	for start < len(runes) {
+		end := start + size
+		if end >= len(runes) {
+			chunks = append(chunks, string(runes[start:]))
+			break
+		}
+		end = chunkBoundary(runes, start, end)
+		chunks = append(chunks, string(runes[start:end]))
+
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// chunkBoundary finds a natural break before end, preferring paragraphs over sentences
+func chunkBoundary(runes []rune, start, end int) int {
+	floor := start + (end-start)/2
+	for i := end - 1; i > floor; i-- {
+		if runes[i] == '\n' {
+			return i + 1
+		}
+	}
+	for i := end - 1; i > floor; i-- {
+		if (runes[i-1] == '.' || runes[i-1] == '!' || runes[i-1] == '?') && runes[i] == ' ' {
+			return i + 1
+		}
+	}
+	return end
+}
+
+// mergeExtractionResults combines per-chunk results, deduplicating entities by type and
+// name and relationships by type and endpoints. Relationship endpoints are remapped to
+// the merged entity IDs. IDs a chunk reuses for a different entity or relationship get
+// the chunk number as a suffix.
+func mergeExtractionResults(results []*models.ExtractionResult) *models.ExtractionResult {
+	merged := &models.ExtractionResult{}
+	entityIndex := make(map[string]int)       // entity key -> index in merged.Entities
+	usedIDs := make(map[string]string)        // merged entity ID -> entity key
+	relationshipIndex := make(map[string]int) // relationship key -> index in merged.Relationships
+	usedRelIDs := make(map[string]bool)       // merged relationship IDs
+
+	var confidenceSum float64
+	for i, result := range results {
+		if result == nil {
+			continue
+		}
+		confidenceSum += result.Confidence
+
+		// Map this chunk's entity IDs to merged entity IDs
+		idMap := make(map[string]string)
+		for _, entity := range result.Entities {
+			key := entityKey(entity.Type, entity.Name)
+			if idx, ok := entityIndex[key]; ok {
+				existing := &merged.Entities[idx]
+				mergeEntity(existing, entity)
+				if entity.ID != "" {
+					idMap[entity.ID] = existing.ID
+				}
+				continue
+			}
+
+			originalID := entity.ID
+			if owner, taken := usedIDs[entity.ID]; taken && owner != key {
+				entity.ID = fmt.Sprintf("%s-%d", entity.ID, i+1)
+			}
+			if entity.ID != "" {
+				usedIDs[entity.ID] = key
+			}
+			if originalID != "" {
+				idMap[originalID] = entity.ID
+			}
+			entityIndex[key] = len(merged.Entities)
+			merged.Entities = append(merged.Entities, entity)
+		}
+
+		for _, rel := range result.Relationships {
+			if id, ok := idMap[rel.FromID]; ok {
+				rel.FromID = id
+			}
+			if id, ok := idMap[rel.ToID]; ok {
+				rel.ToID = id
+			}
+
+			key := strings.ToUpper(rel.Type) + "|" + rel.FromID + "|" + rel.ToID
+			if idx, ok := relationshipIndex[key]; ok {
+				if rel.Confidence > merged.Relationships[idx].Confidence {
+					merged.Relationships[idx].Confidence = rel.Confidence
+				}
+				continue
+			}
+			if usedRelIDs[rel.ID] {
+				rel.ID = fmt.Sprintf("%s-%d", rel.ID, i+1)
+			}
+			if rel.ID != "" {
+				usedRelIDs[rel.ID] = true
+			}
+			relationshipIndex[key] = len(merged.Relationships)
+			merged.Relationships = append(merged.Relationships, rel)
+		}
+	}
+
+	if len(results) > 0 {
+		merged.Confidence = confidenceSum / float64(len(results))
+	}
+	return merged
+}
+
+// mergeEntity folds a duplicate entity into an existing one
+func mergeEntity(existing *models.ExtractedEntity, duplicate models.ExtractedEntity) {
+	if duplicate.Confidence > existing.Confidence {
+		existing.Confidence = duplicate.Confidence
+	}
+	for k, v := range duplicate.Properties {
+		if existing.Properties == nil {
+			existing.Properties = make(map[string]interface{})
+		}
+		if _, ok := existing.Properties[k]; !ok {
+			existing.Properties[k] = v
+		}
+	}
+
+	existing.AddAliases(duplicate.Aliases...)
+	existing.MergePropertyConfidence(duplicate.PropertyConfidence)
+
+	seen := make(map[string]bool, len(existing.Mentions))
+	for _, m := range existing.Mentions {
+		seen[m.Text+"|"+m.Context] = true
+	}
+	for _, m := range duplicate.Mentions {
+		if !seen[m.Text+"|"+m.Context] {
+			existing.Mentions = append(existing.Mentions, m)
+			seen[m.Text+"|"+m.Context] = true
+		}
+	}
+}
+
+// entityKey normalizes an entity's type and name for deduplication
+func entityKey(entityType, name string) string {
+	return strings.ToLower(strings.TrimSpace(entityType)) + "|" + strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
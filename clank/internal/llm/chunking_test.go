@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// longArticle builds a multi-section article where each section names a different official
+func longArticle(sections int) string {
+	var b strings.Builder
+	for i := 1; i <= sections; i++ {
+		fmt.Fprintf(&b, "Section %d. Official Person%d met with John Smith to discuss the contract. ", i, i)
+		b.WriteString(strings.Repeat("The investigation continued with further interviews and document reviews. ", 5))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// newChunkExtractionServer returns entities for every section found in the prompt
+func newChunkExtractionServer(t *testing.T, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+
+		var req GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		prompt := req.Messages[len(req.Messages)-1].Content
+
+		result := models.ExtractionResult{Confidence: 0.8}
+		result.Entities = append(result.Entities, models.ExtractedEntity{
+			ID: "e0", Type: "person", Name: "John Smith", Confidence: 0.7,
+		})
+		for i := 1; i <= 20; i++ {
+			if strings.Contains(prompt, fmt.Sprintf("Official Person%d ", i)) {
+				id := fmt.Sprintf("e%d", len(result.Entities))
+				result.Entities = append(result.Entities, models.ExtractedEntity{
+					ID: id, Type: "person", Name: fmt.Sprintf("Person%d", i), Confidence: 0.9,
+				})
+				result.Relationships = append(result.Relationships, models.ExtractedRelationship{
+					ID: "r" + id, Type: "MET_WITH", FromID: id, ToID: "e0", Confidence: 0.8,
+				})
+			}
+		}
+
+		content, err := json.Marshal(result)
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: string(content)}}})
+	}))
+}
+
+func TestClient_ProcessArticle_Chunked(t *testing.T) {
+	var calls int32
+	server := newChunkExtractionServer(t, &calls)
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.Extraction.ChunkSize = 1000
+	cfg.Extraction.ChunkOverlap = 200
+	client := NewClient(cfg)
+
+	article := &models.Article{ID: "article-1", Title: "Contract scandal", Content: longArticle(6)}
+	result, err := client.ProcessArticle(t.Context(), article)
+	require.NoError(t, err)
+
+	assert.Greater(t, atomic.LoadInt32(&calls), int32(1), "long article should be split into several chunks")
+
+	names := make(map[string]int)
+	for _, e := range result.Entities {
+		names[e.Name]++
+	}
+	assert.Equal(t, 1, names["John Smith"], "entity repeated across chunks should be merged")
+	assert.Equal(t, 1, names["Person6"], "entity from the final section should be present")
+	for i := 1; i <= 6; i++ {
+		assert.Contains(t, names, fmt.Sprintf("Person%d", i))
+	}
+
+	// Relationships should point at merged entity IDs
+	ids := make(map[string]bool)
+	for _, e := range result.Entities {
+		ids[e.ID] = true
+	}
+	seen := make(map[string]bool)
+	relIDs := make(map[string]bool)
+	for _, r := range result.Relationships {
+		assert.False(t, relIDs[r.ID], "duplicate relationship id %s", r.ID)
+		relIDs[r.ID] = true
+		assert.True(t, ids[r.FromID], "unknown from id %s", r.FromID)
+		assert.True(t, ids[r.ToID], "unknown to id %s", r.ToID)
+		key := r.FromID + "->" + r.ToID
+		assert.False(t, seen[key], "duplicate relationship %s", key)
+		seen[key] = true
+	}
+	assert.Len(t, result.Relationships, 6)
+}
+
+func TestMergeExtractionResults_OverlappingRelationshipIDs(t *testing.T) {
+	chunk := func(official string) *models.ExtractionResult {
+		return &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "person", Name: official},
+				{ID: "e2", Type: "organization", Name: "Acme Builders"},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{ID: "r1", Type: "PAYMENT", FromID: "e2", ToID: "e1"},
+			},
+		}
+	}
+
+	merged := mergeExtractionResults([]*models.ExtractionResult{chunk("Mayor Smith"), chunk("Judge Jones")})
+
+	require.Len(t, merged.Relationships, 2)
+	assert.Equal(t, "r1", merged.Relationships[0].ID)
+	assert.Equal(t, "e1", merged.Relationships[0].ToID)
+	assert.Equal(t, "r1-2", merged.Relationships[1].ID, "the second chunk's r1 is another payment")
+	assert.Equal(t, "e1-2", merged.Relationships[1].ToID)
+}
+
+func TestClient_ProcessArticle_ChunkingDisabled(t *testing.T) {
+	var calls int32
+	server := newChunkExtractionServer(t, &calls)
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	client := NewClient(cfg)
+
+	_, err := client.ProcessArticle(t.Context(), &models.Article{Content: longArticle(6)})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestSplitIntoChunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		size    int
+		overlap int
+		check   func(*testing.T, []string)
+	}{
+		{
+			name:    "short content is a single chunk",
+			content: "Short article.",
+			size:    100,
+			overlap: 10,
+			check: func(t *testing.T, chunks []string) {
+				assert.Equal(t, []string{"Short article."}, chunks)
+			},
+		},
+		{
+			name:    "chunks overlap and cover the end",
+			content: longArticle(4),
+			size:    500,
+			overlap: 100,
+			check: func(t *testing.T, chunks []string) {
+				require.Greater(t, len(chunks), 1)
+				for _, c := range chunks {
+					assert.LessOrEqual(t, len([]rune(c)), 500)
+				}
+				assert.True(t, strings.HasSuffix(longArticle(4), chunks[len(chunks)-1]))
+			},
+		},
+		{
+			name:    "overlap larger than size is ignored",
+			content: strings.Repeat("a", 250),
+			size:    100,
+			overlap: 200,
+			check: func(t *testing.T, chunks []string) {
+				assert.Len(t, chunks, 3)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.check(t, splitIntoChunks(tt.content, tt.size, tt.overlap))
+		})
+	}
+}
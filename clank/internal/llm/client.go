@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,27 +13,111 @@ import (
 	"time"
 
 	"clank/config"
+	"clank/internal/limits"
+	"clank/internal/logging"
+	"clank/internal/metrics"
 )
 
+// DefaultMaxResponseBytes caps LLM responses when no limit is configured
+const DefaultMaxResponseBytes = 4 * 1024 * 1024
+
+// maxErrorBodyBytes caps how much of an error response is quoted in the error
+const maxErrorBodyBytes = 4 * 1024
+
+// ErrResponseTooLarge is returned when a generation exceeds the configured maximum size
+var ErrResponseTooLarge = errors.New("LLM response exceeded maximum size")
+
 // Client represents an LLM client that implements the LLMProvider interface
 type Client struct {
-	url     string
-	model   string
-	timeout time.Duration
-	http    *http.Client
+	url                string
+	model              string
+	timeout            time.Duration
+	http               *http.Client
+	chunkSize          int
+	chunkOverlap       int
+	maxResponseBytes   int
+	temporalSequences  bool
+	personAliases      bool
+	propertyConfidence bool
+	danglingReferences string
+	systemPreamble     string
+	autoRepairJSON     bool
+	prompts            PromptRenderer // Renders the two-phase events prompt; nil uses the built-in one
 }
 
 // Ensure Client implements LLMProvider
 var _ LLMProvider = (*Client)(nil)
 
 func NewClient(cfg *config.Config) *Client {
+	maxResponseBytes := cfg.LLM.MaxResponseBytes
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = DefaultMaxResponseBytes
+	}
+
+	// No Timeout here so streaming isn't cut off; rely on ctx for cancellation.
+	httpClient := &http.Client{}
+	if cfg.LLM.DebugLog {
+		httpClient.Transport = newDebugTransport(http.DefaultTransport, cfg.LLM.DebugRedact, cfg.LLM.DebugMaxBytes)
+	}
+
 	return &Client{
-		url:     cfg.LLM.URL,
-		model:   cfg.LLM.Model,
-		timeout: cfg.LLM.Timeout,
-		// No Timeout here so streaming isn't cut off; rely on ctx for cancellation.
-		http: &http.Client{},
+		url:                cfg.LLM.URL,
+		model:              cfg.LLM.Model,
+		timeout:            cfg.LLM.Timeout,
+		http:               httpClient,
+		chunkSize:          cfg.Extraction.ChunkSize,
+		chunkOverlap:       cfg.Extraction.ChunkOverlap,
+		maxResponseBytes:   maxResponseBytes,
+		temporalSequences:  cfg.Extraction.TemporalSequences,
+		personAliases:      cfg.Extraction.PersonAliases,
+		propertyConfidence: cfg.Extraction.PropertyConfidence,
+		danglingReferences: cfg.Extraction.DanglingReferences,
+		systemPreamble:     strings.TrimSpace(cfg.LLM.SystemPreamble),
+		autoRepairJSON:     cfg.LLM.AutoRepairJSON,
+	}
+}
+
+// withSystemPreamble returns messages with the configured preamble merged into the
+// first system message, or prepended as one when there is none. messages itself
+// is left untouched.
+func (c *Client) withSystemPreamble(messages []Message) []Message {
+	if c.systemPreamble == "" {
+		return messages
+	}
+	merged := make([]Message, 0, len(messages)+1)
+	for i, msg := range messages {
+		if msg.Role == "system" {
+			merged = append(merged, messages[:i]...)
+			msg.Content = c.systemPreamble + "\n\n" + msg.Content
+			merged = append(merged, msg)
+			return append(merged, messages[i+1:]...)
+		}
 	}
+	merged = append(merged, Message{Role: "system", Content: c.systemPreamble, CreatedAt: time.Now()})
+	return append(merged, messages...)
+}
+
+// tooLargeError describes a response that exceeded the size limit
+func (c *Client) tooLargeError() error {
+	return fmt.Errorf("%w (limit %d bytes)", ErrResponseTooLarge, c.maxResponseBytes)
+}
+
+// readLimited reads at most maxResponseBytes from r, failing if the body is larger
+func (c *Client) readLimited(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, int64(c.maxResponseBytes)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > c.maxResponseBytes {
+		return nil, c.tooLargeError()
+	}
+	return body, nil
+}
+
+// errorBody reads the start of an error response for its message, ignoring the rest
+func errorBody(r io.Reader) string {
+	body, _ := io.ReadAll(io.LimitReader(r, maxErrorBodyBytes))
+	return string(body)
 }
 
 type GenerateRequest struct {
@@ -47,6 +132,7 @@ type GenerateResponse struct {
 	Created int64    `json:"created"`
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
 }
 
 type SSEResponse struct {
@@ -65,10 +151,27 @@ type SSEResponse struct {
 
 // GenerateStream sends a request to llama.cpp and streams chunks into responseChan.
 // IMPORTANT: this function **does not** close responseChan. The caller owns closing it.
-func (c *Client) GenerateStream(ctx context.Context, messages []Message, responseChan chan<- string) error {
+func (c *Client) GenerateStream(ctx context.Context, messages []Message, responseChan chan<- string) (err error) {
+	stage := stageLabel(ctx)
+	slots := limits.LLM()
+	if err := slots.Acquire(ctx); err != nil {
+		metrics.LLMDuration.Observe(0, stage, metrics.Status(err))
+		return err
+	}
+	defer slots.Release()
+	start := time.Now()
+	defer func() {
+		metrics.LLMDuration.ObserveSince(start, stage, metrics.Status(err))
+		if err != nil {
+			logging.For(ctx, "llm").Warn("LLM stream failed", "stage", stage, "duration", time.Since(start), "error", err)
+			return
+		}
+		logging.For(ctx, "llm").Info("LLM stream", "stage", stage, "duration", time.Since(start))
+	}()
+
 	llmReq := GenerateRequest{
-		Model:    c.model,
-		Messages: messages,
+		Model:    c.modelFor(ctx),
+		Messages: c.withSystemPreamble(withExtraInstructions(ctx, messages)),
 		Stream:   true,
 	}
 
@@ -91,8 +194,7 @@ func (c *Client) GenerateStream(ctx context.Context, messages []Message, respons
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("llama.cpp returned status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("llama.cpp returned status %d: %s", resp.StatusCode, errorBody(resp.Body))
 	}
 
 	// Safe send helper: avoids panic if caller closed the channel.
@@ -108,6 +210,16 @@ func (c *Client) GenerateStream(ctx context.Context, messages []Message, respons
 		return nil
 	}
 
+	// Track streamed content so a runaway generation can't grow without bound
+	received := 0
+	sendLimited := func(s string) error {
+		received += len(s)
+		if received > c.maxResponseBytes {
+			return c.tooLargeError()
+		}
+		return trySend(s)
+	}
+
 	scanner := bufio.NewScanner(resp.Body)
 	// Increase max token size to handle larger SSE lines safely.
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
@@ -140,7 +252,7 @@ func (c *Client) GenerateStream(ctx context.Context, messages []Message, respons
 		if err := json.Unmarshal([]byte(data), &sse); err != nil {
 			// Fallback: forward raw data
 			if data != "" {
-				if err := trySend(data); err != nil {
+				if err := sendLimited(data); err != nil {
 					return err
 				}
 			}
@@ -150,7 +262,7 @@ func (c *Client) GenerateStream(ctx context.Context, messages []Message, respons
 		if len(sse.Choices) > 0 {
 			ch := sse.Choices[0]
 			if ch.Delta.Content != "" {
-				if err := trySend(ch.Delta.Content); err != nil {
+				if err := sendLimited(ch.Delta.Content); err != nil {
 					return err
 				}
 			}
@@ -171,7 +283,15 @@ func (c *Client) GenerateStream(ctx context.Context, messages []Message, respons
 
 // sendRequest sends a request to the LLM and decodes the response
 func (c *Client) sendRequest(ctx context.Context, req *GenerateRequest, resp interface{}) error {
-	jsonBody, err := json.Marshal(req)
+	slots := limits.LLM()
+	if err := slots.Acquire(ctx); err != nil {
+		return err
+	}
+	defer slots.Release()
+
+	withPreamble := *req
+	withPreamble.Messages = c.withSystemPreamble(req.Messages)
+	jsonBody, err := json.Marshal(&withPreamble)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
@@ -189,22 +309,64 @@ func (c *Client) sendRequest(ctx context.Context, req *GenerateRequest, resp int
 	defer httpResp.Body.Close()
 
 	if httpResp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(httpResp.Body)
-		return fmt.Errorf("LLM returned status %d: %s", httpResp.StatusCode, string(body))
+		return fmt.Errorf("LLM returned status %d: %s", httpResp.StatusCode, errorBody(httpResp.Body))
 	}
 
-	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+	body, err := c.readLimited(httpResp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, resp); err != nil {
 		return fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	return nil
 }
 
-// Generate performs a standard (non-streaming) completion.
+// Generate performs a standard (non-streaming) completion. Token usage reported by
+// the backend is added to the context's UsageTracker, if any. With a PromptCache
+// in the context, a prompt it already holds is answered from it without a request.
 func (c *Client) Generate(ctx context.Context, messages []Message) (*Response, error) {
+	stage := stageLabel(ctx)
+	cache := promptCacheFrom(ctx)
+	var key string
+	if cache != nil {
+		key = promptHash(c.modelFor(ctx), messages)
+		if cached, ok := cache.get(key); ok {
+			logging.For(ctx, "llm").Debug("LLM completion served from prompt cache", "stage", stage)
+			return cached, nil
+		}
+	}
+	slots := limits.LLM()
+	if err := slots.Acquire(ctx); err != nil {
+		metrics.LLMDuration.Observe(0, stage, metrics.Status(err))
+		return NewErrorResponse(err.Error()), err
+	}
+	defer slots.Release()
+
+	start := time.Now()
+	result, err := c.generate(ctx, messages)
+	metrics.LLMDuration.ObserveSince(start, stage, metrics.Status(err))
+	logger := logging.For(ctx, "llm")
+	if err != nil {
+		logger.Warn("LLM completion failed", "stage", stage, "duration", time.Since(start), "error", err)
+		return result, err
+	}
+	if result.Usage != nil {
+		metrics.LLMTokens.Add(float64(result.Usage.PromptTokens), stage, "prompt")
+		metrics.LLMTokens.Add(float64(result.Usage.CompletionTokens), stage, "completion")
+		logger = logger.With("prompt_tokens", result.Usage.PromptTokens, "completion_tokens", result.Usage.CompletionTokens)
+	}
+	logger.Info("LLM completion", "stage", stage, "duration", time.Since(start))
+	cache.put(key, result)
+	return result, nil
+}
+
+func (c *Client) generate(ctx context.Context, messages []Message) (*Response, error) {
 	reqBody := GenerateRequest{
-		Model:    c.model,
-		Messages: messages,
+		Model:    c.modelFor(ctx),
+		Messages: c.withSystemPreamble(withExtraInstructions(ctx, messages)),
 		Stream:   false,
 	}
 
@@ -226,14 +388,19 @@ func (c *Client) Generate(ctx context.Context, messages []Message) (*Response, e
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return NewErrorResponse(fmt.Sprintf("llama.cpp returned status %d: %s", resp.StatusCode, string(body))), fmt.Errorf("HTTP %d", resp.StatusCode)
+		return NewErrorResponse(fmt.Sprintf("llama.cpp returned status %d: %s", resp.StatusCode, errorBody(resp.Body))), fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := c.readLimited(resp.Body)
+	if err != nil {
+		return NewErrorResponse(fmt.Sprintf("error reading llama.cpp response: %v", err)), err
 	}
 
 	var result Response
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return NewErrorResponse(fmt.Sprintf("error decoding llama.cpp response: %v", err)), err
 	}
+	recordUsage(ctx, result.Usage)
 
 	if len(result.Choices) == 0 {
 		return NewErrorResponse("no choices in response"), fmt.Errorf("no choices in response")
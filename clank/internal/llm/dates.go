@@ -0,0 +1,33 @@
+package llm
+
+import (
+	"time"
+
+	"clank/internal/models"
+	"clank/pkg/extraction"
+)
+
+// normalizeDates stores the parsed date and its precision next to the raw "date"
+// property of entities (events) and relationships, so the timeline can sort them.
+// Dates that can't be placed on a calendar, such as "last Tuesday", stay text only.
+func normalizeDates(result *models.ExtractionResult) {
+	for i := range result.Entities {
+		normalizeDate(result.Entities[i].Properties)
+	}
+	for i := range result.Relationships {
+		normalizeDate(result.Relationships[i].Properties)
+	}
+}
+
+func normalizeDate(props map[string]interface{}) {
+	raw, _ := props["date"].(string)
+	if raw == "" {
+		return
+	}
+	date, precision, ok := extraction.NormalizeDate(raw)
+	if !ok {
+		return
+	}
+	props["date_rfc3339"] = date.Format(time.RFC3339)
+	props["date_precision"] = precision
+}
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeDates(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "event", Name: "contract award", Properties: map[string]interface{}{"date": "March 2021"}},
+			{ID: "e2", Type: "event", Name: "meeting", Properties: map[string]interface{}{"date": "last Tuesday"}},
+			{ID: "e3", Type: "person", Name: "Jane Smith"},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "payment", Properties: map[string]interface{}{"date": "2021-03-04"}},
+		},
+	}
+
+	normalizeDates(result)
+
+	assert.Equal(t, map[string]interface{}{
+		"date":           "March 2021",
+		"date_rfc3339":   "2021-03-01T00:00:00Z",
+		"date_precision": "month",
+	}, result.Entities[0].Properties)
+	assert.Equal(t, map[string]interface{}{"date": "last Tuesday"}, result.Entities[1].Properties)
+	assert.Nil(t, result.Entities[2].Properties)
+	assert.Equal(t, "2021-03-04T00:00:00Z", result.Relationships[0].Properties["date_rfc3339"])
+	assert.Equal(t, "day", result.Relationships[0].Properties["date_precision"])
+}
@@ -0,0 +1,182 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDebugLogMaxBytes caps each logged message and response body when no limit is configured
+const DefaultDebugLogMaxBytes = 8 * 1024
+
+// redactedText replaces sensitive substrings in debug logs
+const redactedText = "[REDACTED]"
+
+// debugTransport logs every LLM request and raw response body as a JSON line.
+// Configured sensitive substrings are redacted and long bodies truncated before
+// anything is written.
+type debugTransport struct {
+	next     http.RoundTripper
+	out      io.Writer
+	redactor *strings.Replacer
+	maxBytes int
+	capture  int // Response bytes kept: enough to redact a secret straddling maxBytes
+	mu       sync.Mutex
+}
+
+// debugEntry is one logged request/response exchange
+type debugEntry struct {
+	Time             time.Time      `json:"time"`
+	URL              string         `json:"url"`
+	Model            string         `json:"model,omitempty"`
+	Stream           bool           `json:"stream,omitempty"`
+	Messages         []debugMessage `json:"messages,omitempty"`
+	Request          string         `json:"request,omitempty"` // Raw body when it isn't a chat completion request
+	Status           int            `json:"status,omitempty"`
+	Response         string         `json:"response,omitempty"`
+	DurationMs       int64          `json:"durationMs"`
+	PromptTokens     int            `json:"promptTokens,omitempty"`
+	CompletionTokens int            `json:"completionTokens,omitempty"`
+	TotalTokens      int            `json:"totalTokens,omitempty"`
+	Error            string         `json:"error,omitempty"`
+}
+
+type debugMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// newDebugTransport wraps next, writing to the standard logger's output.
+// Empty redaction strings are ignored.
+func newDebugTransport(next http.RoundTripper, redact []string, maxBytes int) *debugTransport {
+	if maxBytes <= 0 {
+		maxBytes = DefaultDebugLogMaxBytes
+	}
+	var pairs []string
+	longest := 0
+	for _, s := range redact {
+		if s != "" {
+			pairs = append(pairs, s, redactedText)
+			longest = max(longest, len(s))
+		}
+	}
+	t := &debugTransport{next: next, out: log.Writer(), maxBytes: maxBytes, capture: maxBytes + longest + 1}
+	if len(pairs) > 0 {
+		t.redactor = strings.NewReplacer(pairs...)
+	}
+	return t
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	entry := &debugEntry{Time: start, URL: req.URL.String()}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			raw, _ := io.ReadAll(body)
+			body.Close()
+			t.describeRequest(entry, raw)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		entry.Error = err.Error()
+		entry.DurationMs = time.Since(start).Milliseconds()
+		t.write(entry)
+		return nil, err
+	}
+	entry.Status = resp.StatusCode
+	resp.Body = &debugBody{ReadCloser: resp.Body, transport: t, entry: entry, start: start}
+	return resp, nil
+}
+
+// describeRequest records the model and messages of a chat completion request,
+// or the raw body of anything else
+func (t *debugTransport) describeRequest(entry *debugEntry, raw []byte) {
+	var req GenerateRequest
+	if err := json.Unmarshal(raw, &req); err != nil || len(req.Messages) == 0 {
+		entry.Request = string(raw)
+		return
+	}
+	entry.Model = req.Model
+	entry.Stream = req.Stream
+	for _, msg := range req.Messages {
+		entry.Messages = append(entry.Messages, debugMessage{Role: msg.Role, Content: msg.Content})
+	}
+}
+
+// write redacts and truncates entry, then logs it as one JSON line
+func (t *debugTransport) write(entry *debugEntry) {
+	entry.Request = t.clean(entry.Request)
+	entry.Response = t.clean(entry.Response)
+	entry.Error = t.clean(entry.Error)
+	for i := range entry.Messages {
+		entry.Messages[i].Content = t.clean(entry.Messages[i].Content)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(entry); err != nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.out.Write(buf.Bytes())
+}
+
+// clean redacts s and then truncates it, so a truncated secret can't leak a prefix
+func (t *debugTransport) clean(s string) string {
+	if t.redactor != nil {
+		s = t.redactor.Replace(s)
+	}
+	if len(s) > t.maxBytes {
+		s = s[:t.maxBytes] + "...[truncated]"
+	}
+	return s
+}
+
+// debugBody captures the start of a response body and logs the exchange on Close
+type debugBody struct {
+	io.ReadCloser
+	transport *debugTransport
+	entry     *debugEntry
+	start     time.Time
+	captured  bytes.Buffer
+	once      sync.Once
+}
+
+func (b *debugBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := b.transport.capture - b.captured.Len(); room > 0 {
+		b.captured.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+func (b *debugBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.entry.Response = b.captured.String()
+		b.entry.DurationMs = time.Since(b.start).Milliseconds()
+		var usage struct {
+			Usage struct {
+				PromptTokens     int `json:"prompt_tokens"`
+				CompletionTokens int `json:"completion_tokens"`
+				TotalTokens      int `json:"total_tokens"`
+			} `json:"usage"`
+		}
+		if json.Unmarshal(b.captured.Bytes(), &usage) == nil {
+			b.entry.PromptTokens = usage.Usage.PromptTokens
+			b.entry.CompletionTokens = usage.Usage.CompletionTokens
+			b.entry.TotalTokens = usage.Usage.TotalTokens
+		}
+		b.transport.write(b.entry)
+	})
+	return err
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clank/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDebugClient returns a client whose debug log is captured in the returned buffer
+func newDebugClient(url string, redact []string, maxBytes int) (*Client, *bytes.Buffer) {
+	cfg := &config.Config{}
+	cfg.LLM.URL = url
+	cfg.LLM.DebugLog = true
+	cfg.LLM.DebugRedact = redact
+	cfg.LLM.DebugMaxBytes = maxBytes
+	client := NewClient(cfg)
+
+	var buf bytes.Buffer
+	client.http.Transport.(*debugTransport).out = &buf
+	return client, &buf
+}
+
+func TestClient_DebugLog_Redacts(t *testing.T) {
+	secrets := []string{"sk-live-12345", "Jane Roe"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices": [{"content": "Jane Roe paid sk-live-12345"}], "usage": {"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17}}`))
+	}))
+	defer server.Close()
+
+	client, buf := newDebugClient(server.URL, secrets, 0)
+	_, err := client.Generate(t.Context(), []Message{
+		{Role: "system", Content: "key sk-live-12345"},
+		{Role: "user", Content: "What did Jane Roe pay?"},
+	})
+	require.NoError(t, err)
+
+	logged := buf.String()
+	for _, secret := range secrets {
+		assert.NotContains(t, logged, secret)
+	}
+
+	var entry debugEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Len(t, entry.Messages, 2)
+	assert.Equal(t, "key [REDACTED]", entry.Messages[0].Content)
+	assert.Equal(t, "What did [REDACTED] pay?", entry.Messages[1].Content)
+	assert.Contains(t, entry.Response, "[REDACTED] paid [REDACTED]")
+	assert.Equal(t, http.StatusOK, entry.Status)
+	assert.Equal(t, 12, entry.PromptTokens)
+	assert.Equal(t, 5, entry.CompletionTokens)
+	assert.Equal(t, 17, entry.TotalTokens)
+}
+
+func TestClient_DebugLog_TruncatesWithoutLeakingSecrets(t *testing.T) {
+	// The secret starts just before the truncation point
+	body := `{"choices": [{"content": "` + strings.Repeat("x", 30) + `sk-live-12345` + strings.Repeat("y", 4096) + `"}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, buf := newDebugClient(server.URL, []string{"sk-live-12345"}, 64)
+	_, err := client.Generate(t.Context(), []Message{{Role: "user", Content: strings.Repeat("z", 1000)}})
+	require.NoError(t, err)
+
+	assert.NotContains(t, buf.String(), "sk-live")
+	var entry debugEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.True(t, strings.HasSuffix(entry.Response, "...[truncated]"))
+	assert.LessOrEqual(t, len(entry.Messages[0].Content), 64+len("...[truncated]"))
+}
+
+func TestClient_DebugLog_OffByDefault(t *testing.T) {
+	client := NewClient(&config.Config{})
+	assert.Nil(t, client.http.Transport)
+}
@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"clank/internal/models"
+	"clank/internal/prompts"
+)
+
+const (
+	// eventExtractionPrompt names the template of the two-phase events pass
+	eventExtractionPrompt = "event_extraction"
+	// eventsStage labels metrics of the two-phase events pass
+	eventsStage = "events"
+	// eventEntityType is the entity type events are extracted as
+	eventEntityType = "event"
+)
+
+// eventRelationshipTypes are the relationship types the events pass asks for
+var eventRelationshipTypes = map[string]bool{"INVOLVEMENT": true, "BEFORE": true, "AFTER": true, "CAUSED": true}
+
+// PromptRenderer renders a named prompt template, e.g. a prompts.PromptLoader
+type PromptRenderer interface {
+	RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error)
+}
+
+// SetPromptRenderer makes the two-phase events pass render its prompt from the
+// event_extraction template of renderer instead of the built-in text. A nil
+// renderer restores the built-in prompt.
+func (c *Client) SetPromptRenderer(renderer PromptRenderer) {
+	c.prompts = renderer
+}
+
+type twoPhaseEventsKey struct{}
+
+// WithTwoPhaseEvents returns a context whose article extractions leave events out
+// of the entity pass and extract them in a second call that is given the
+// entities already found, so events link to those entities by ID
+func WithTwoPhaseEvents(ctx context.Context) context.Context {
+	return context.WithValue(ctx, twoPhaseEventsKey{}, true)
+}
+
+// twoPhaseEvents reports whether WithTwoPhaseEvents was set on ctx
+func twoPhaseEvents(ctx context.Context) bool {
+	enabled, _ := ctx.Value(twoPhaseEventsKey{}).(bool)
+	return enabled
+}
+
+// extractEvents runs the events pass over the article and adds the events it
+// finds, their ordering and the involvement of already extracted entities to
+// result
+func (c *Client) extractEvents(ctx context.Context, article *models.Article, result *models.ExtractionResult) error {
+	prompt, err := c.eventsPrompt(ctx, article, result.Entities)
+	if err != nil {
+		return err
+	}
+	messages := []Message{
+		{Role: "system", Content: extractionSystemPrompt(article.Language), CreatedAt: time.Now()},
+		{Role: "user", Content: prompt, CreatedAt: time.Now()},
+	}
+
+	resp, err := c.Generate(WithStage(ctx, eventsStage), messages)
+	if err != nil {
+		return fmt.Errorf("failed to extract events: %w", err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("LLM error: %s", resp.Error)
+	}
+	if len(resp.Choices) == 0 {
+		return fmt.Errorf("no response from LLM")
+	}
+	content := resp.Choices[0].Content
+	if content == "" {
+		content = resp.Choices[0].Message.Content
+	}
+
+	var events models.ExtractionResult
+	if err := c.DecodeJSON(ctx, content, &events); err != nil {
+		return fmt.Errorf("failed to parse events response: %w", err)
+	}
+
+	now := time.Now()
+	for i := range events.Entities {
+		events.Entities[i].ArticleID = article.ID
+		events.Entities[i].ExtractedAt = now
+	}
+	for i := range events.Relationships {
+		events.Relationships[i].ArticleID = article.ID
+		events.Relationships[i].ExtractedAt = now
+	}
+	mergeEvents(result, &events)
+	return nil
+}
+
+// eventsPrompt renders the events pass prompt, in the version pinned on ctx if
+// any, listing the entities events may refer to
+func (c *Client) eventsPrompt(ctx context.Context, article *models.Article, entities []models.ExtractedEntity) (string, error) {
+	var list strings.Builder
+	for _, entity := range entities {
+		fmt.Fprintf(&list, "- %s (%s): %s\n", entity.ID, entity.Type, entity.Name)
+	}
+	if list.Len() == 0 {
+		list.WriteString("(none)\n")
+	}
+	date := article.PublishDate.Format("2006-01-02")
+
+	if c.prompts != nil {
+		rendered, err := prompts.RenderPinned(ctx, c.prompts, eventExtractionPrompt, &models.PromptContext{
+			Arguments: map[string]any{
+				"title":    article.Title,
+				"source":   article.Source,
+				"date":     date,
+				"content":  article.Content,
+				"entities": list.String(),
+			},
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to render prompt %q: %w", eventExtractionPrompt, err)
+		}
+		return rendered.RenderedText + LanguageInstructions(article.Language), nil
+	}
+
+	return fmt.Sprintf(`Extract the events described in the following article: meetings, payments, contract awards, votes, arrests, resignations and the like.
+
+Title: %s
+Source: %s
+Date: %s
+Content:
+%s
+
+These entities have already been extracted from the article. Refer to them by id and don't extract them again:
+%s
+For each event:
+- Add an entity with type "event", a short name and a "date" property when the article gives one
+- Link every listed entity that took part in it with an "involvement" relationship from the entity's id to the event's id, with the entity's part (e.g. payer, recipient, awarding body) as the "role" property
+- Link events whose order or causation is stated or clearly implied with "BEFORE" (fromId happened before toId), "AFTER" or "CAUSED" (fromId led to toId) relationships
+
+Format your response as a valid JSON object with the following structure:
+{
+  "entities": [
+    {
+      "id": "event_1",
+      "type": "event",
+      "name": "string",
+      "properties": {"date": "YYYY-MM-DD"},
+      "confidence": 0.0-1.0,
+      "mentions": [
+        {
+          "text": "exact text from article",
+          "context": "surrounding sentence"
+        }
+      ]
+    }
+  ],
+  "relationships": [
+    {
+      "id": "string",
+      "type": "involvement|BEFORE|AFTER|CAUSED",
+      "fromId": "listed entity id or event id",
+      "toId": "event id",
+      "properties": {"role": "string"},
+      "confidence": 0.0-1.0,
+      "context": "relevant quote from article"
+    }
+  ]
+}`,
+		article.Title,
+		article.Source,
+		date,
+		article.Content,
+		list.String(),
+	) + LanguageInstructions(article.Language), nil
+}
+
+// mergeEvents adds the event entities of events to result, renaming IDs that
+// clash with an entity already in result, and the involvement and ordering
+// relationships touching them. Anything else the events pass returned repeats
+// the entity pass and is dropped.
+func mergeEvents(result, events *models.ExtractionResult) {
+	taken := make(map[string]bool, len(result.Entities))
+	for _, entity := range result.Entities {
+		taken[entity.ID] = true
+	}
+
+	eventIDs := make(map[string]string)
+	for _, event := range events.Entities {
+		if !strings.EqualFold(event.Type, eventEntityType) || event.ID == "" {
+			continue
+		}
+		id := event.ID
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%s_%d", event.ID, n)
+		}
+		taken[id] = true
+		eventIDs[event.ID] = id
+		event.ID = id
+		result.Entities = append(result.Entities, event)
+	}
+
+	for _, rel := range events.Relationships {
+		from, fromEvent := eventIDs[rel.FromID]
+		to, toEvent := eventIDs[rel.ToID]
+		if !fromEvent && !toEvent || !eventRelationshipTypes[strings.ToUpper(rel.Type)] {
+			continue
+		}
+		if fromEvent {
+			rel.FromID = from
+		}
+		if toEvent {
+			rel.ToID = to
+		}
+		result.Relationships = append(result.Relationships, rel)
+	}
+}
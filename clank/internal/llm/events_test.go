@@ -0,0 +1,138 @@
+package llm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+	"clank/internal/prompts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const eventsArticle = "City councillor Jane Doe received a payment of $50,000 from Acme Construction " +
+	"on 3 March 2024, a week before the council awarded Acme the bridge contract."
+
+// In a single pass the model extracts the event but links it to an entity ID it
+// never extracted instead of the company's
+const singlePhaseExtraction = `{"entities": [
+	{"id": "e1", "type": "person", "name": "Jane Doe", "confidence": 0.9},
+	{"id": "e2", "type": "organization", "name": "Acme Construction", "confidence": 0.9},
+	{"id": "ev1", "type": "event", "name": "Contract award", "confidence": 0.8}],
+	"relationships": [{"id": "r1", "type": "involvement", "fromId": "org_3", "toId": "ev1", "confidence": 0.8}],
+	"confidence": 0.8}`
+
+const entityExtraction = `{"entities": [
+	{"id": "e1", "type": "person", "name": "Jane Doe", "confidence": 0.9},
+	{"id": "e2", "type": "organization", "name": "Acme Construction", "confidence": 0.9}],
+	"relationships": [{"id": "r1", "type": "payment", "fromId": "e2", "toId": "e1", "confidence": 0.9}],
+	"confidence": 0.8}`
+
+const eventExtraction = `{"entities": [
+	{"id": "e1", "type": "event", "name": "Contract award", "properties": {"date": "2024-03-10"}, "confidence": 0.8},
+	{"id": "e2", "type": "organization", "name": "Acme Construction", "confidence": 0.9}],
+	"relationships": [
+	{"id": "r10", "type": "involvement", "fromId": "e2", "toId": "e1", "properties": {"role": "contractor"}, "confidence": 0.8},
+	{"id": "r11", "type": "payment", "fromId": "e2", "toId": "e1", "confidence": 0.9}]}`
+
+// eventLinks returns the IDs of the entities linked to an event by involvement
+func eventLinks(result *models.ExtractionResult) map[string][]string {
+	events := make(map[string]bool)
+	for _, entity := range result.Entities {
+		if entity.Type == "event" {
+			events[entity.ID] = true
+		}
+	}
+	links := make(map[string][]string)
+	for _, rel := range result.Relationships {
+		if rel.Type == "involvement" && events[rel.ToID] {
+			links[rel.ToID] = append(links[rel.ToID], rel.FromID)
+		}
+	}
+	return links
+}
+
+func TestClient_ProcessArticle_SinglePhaseEvents(t *testing.T) {
+	server, requests := sequenceServer(t, singlePhaseExtraction)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	result, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{ID: "a1", Content: eventsArticle})
+	require.NoError(t, err)
+
+	assert.Len(t, *requests, 1)
+	assert.Empty(t, eventLinks(result)["ev1"], "the event isn't linked to Acme Construction")
+}
+
+func TestClient_ProcessArticle_TwoPhaseEvents(t *testing.T) {
+	server, requests := sequenceServer(t, entityExtraction, eventExtraction)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.Extraction.TemporalSequences = true
+	ctx := WithTwoPhaseEvents(t.Context())
+	result, err := NewClient(cfg).ProcessArticle(ctx, &models.Article{ID: "a1", Content: eventsArticle})
+	require.NoError(t, err)
+
+	require.Len(t, *requests, 2)
+	entityPrompt := (*requests)[0].Messages[1].Content
+	assert.NotContains(t, entityPrompt, "capture the order of events", "events are left to the second call")
+	eventsPrompt := (*requests)[1].Messages[1].Content
+	assert.Contains(t, eventsPrompt, "- e1 (person): Jane Doe")
+	assert.Contains(t, eventsPrompt, "- e2 (organization): Acme Construction")
+
+	require.Len(t, result.Entities, 3, "the repeated organization is dropped")
+	event := result.Entities[2]
+	assert.Equal(t, "event", event.Type)
+	assert.Equal(t, "e1_2", event.ID, "the event ID is renamed off the person's")
+	assert.Equal(t, "a1", event.ArticleID)
+	assert.Equal(t, map[string][]string{"e1_2": {"e2"}}, eventLinks(result), "the event is linked to the extracted company")
+
+	require.Len(t, result.Relationships, 2, "the repeated payment is dropped")
+	assert.Equal(t, "contractor", result.Relationships[1].Properties["role"])
+}
+
+func TestClient_ProcessArticle_TwoPhaseEventsPromptRenderer(t *testing.T) {
+	server, requests := sequenceServer(t, entityExtraction, eventExtraction)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	client := NewClient(cfg)
+	renderer := &recordingRenderer{}
+	client.SetPromptRenderer(renderer)
+	_, err := client.ProcessArticle(WithTwoPhaseEvents(context.Background()), &models.Article{ID: "a1", Content: eventsArticle})
+	require.NoError(t, err)
+
+	assert.Equal(t, eventExtractionPrompt, renderer.name)
+	assert.Equal(t, eventsArticle, renderer.args["content"])
+	assert.Contains(t, renderer.args["entities"], "- e2 (organization): Acme Construction")
+	assert.Equal(t, "rendered events prompt", (*requests)[1].Messages[1].Content)
+}
+
+func TestEventsPrompt_DefaultFileMatchesBuiltin(t *testing.T) {
+	article := &models.Article{Title: "Bridge contract", Source: "example.com", Content: eventsArticle}
+	entities := []models.ExtractedEntity{{ID: "e2", Type: "organization", Name: "Acme Construction"}}
+	client := NewClient(&config.Config{})
+	builtin, err := client.eventsPrompt(context.Background(), article, entities)
+	require.NoError(t, err)
+
+	loader := prompts.NewPromptLoader(filepath.Join("..", "..", "prompts"))
+	require.NoError(t, loader.LoadPrompts())
+	client.SetPromptRenderer(loader)
+	fromFile, err := client.eventsPrompt(context.Background(), article, entities)
+	require.NoError(t, err)
+	assert.Equal(t, builtin, fromFile)
+}
+
+type recordingRenderer struct {
+	name string
+	args map[string]any
+}
+
+func (r *recordingRenderer) RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error) {
+	r.name, r.args = name, context.Arguments
+	return &models.PromptResult{RenderedText: "rendered events prompt"}, nil
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"strings"
+	"unicode"
+
+	"clank/internal/models"
+)
+
+const (
+	// unverifiedEvidenceWeight scales the confidence of relationships whose
+	// evidence quote can't be found in the article
+	unverifiedEvidenceWeight = 0.5
+	// minFuzzyQuoteWords is the shortest quote matched fuzzily; shorter quotes
+	// must appear exactly
+	minFuzzyQuoteWords = 4
+	// minFuzzyQuoteMatch is the share of a quote's words that must match a
+	// passage of the article word for word, allowing for small edits
+	minFuzzyQuoteMatch = 0.8
+)
+
+// ellipsisReplacer splits quotes the model shortened into their fragments
+var ellipsisReplacer = strings.NewReplacer("...", "\x00", "…", "\x00", "[...]", "\x00")
+
+// verifyEvidence checks that the evidence quote of each relationship, its
+// context or else its "evidence" property, appears in content and records the
+// outcome as the evidence_verified property. Quotes are compared ignoring case,
+// punctuation and small edits, and shortened quotes fragment by fragment.
+// Relationships whose quote can't be located have their confidence halved;
+// those without a quote are left alone.
+func verifyEvidence(result *models.ExtractionResult, content string) {
+	article := quoteWords(content)
+	for i := range result.Relationships {
+		rel := &result.Relationships[i]
+		quote := strings.TrimSpace(rel.Context)
+		if quote == "" {
+			quote, _ = rel.Properties["evidence"].(string)
+		}
+		if strings.TrimSpace(quote) == "" {
+			continue
+		}
+
+		verified := quoteInArticle(quote, article)
+		if rel.Properties == nil {
+			rel.Properties = make(map[string]interface{})
+		}
+		rel.Properties["evidence_verified"] = verified
+		if !verified {
+			rel.Confidence *= unverifiedEvidenceWeight
+		}
+	}
+}
+
+// quoteInArticle reports whether every fragment of quote is a passage of the
+// article, given as its words
+func quoteInArticle(quote string, article []string) bool {
+	found := false
+	for _, fragment := range strings.Split(ellipsisReplacer.Replace(quote), "\x00") {
+		words := quoteWords(fragment)
+		if len(words) == 0 {
+			continue
+		}
+		if !passageIn(words, article) {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// passageIn reports whether words occur in article in a row, exactly or, for
+// quotes of minFuzzyQuoteWords or more, with at least minFuzzyQuoteMatch of
+// them in place
+func passageIn(words, article []string) bool {
+	need := len(words)
+	if len(words) >= minFuzzyQuoteWords {
+		need = int(float64(len(words))*minFuzzyQuoteMatch + 0.999)
+	}
+	for start := 0; start+len(words) <= len(article); start++ {
+		matched := 0
+		for i, word := range words {
+			if article[start+i] == word {
+				matched++
+			} else if len(words)-i+matched-1 < need {
+				break
+			}
+		}
+		if matched >= need {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteWords splits text into lower case words, dropping punctuation so curly
+// and straight quotes, dashes and spacing don't matter
+func quoteWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const evidenceArticle = `The city awarded a $2.4 million paving contract to Roe Construction in March.
+Records show the firm is owned by Dana Whitfield's brother-in-law, who donated
+to her campaign. "I had no role in the decision," Whitfield said on Tuesday.`
+
+func TestVerifyEvidence(t *testing.T) {
+	result := &models.ExtractionResult{
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "ownership", Confidence: 0.9,
+				Context: "records show the firm is owned by Dana Whitfield’s brother-in-law"},
+			{ID: "r2", Type: "payment", Confidence: 0.8,
+				Context: "Whitfield received a $50,000 payment from Roe Construction"},
+			{ID: "r3", Type: "affiliation", Confidence: 0.7},
+		},
+	}
+
+	verifyEvidence(result, evidenceArticle)
+
+	verified := result.Relationships[0]
+	assert.Equal(t, true, verified.Properties["evidence_verified"])
+	assert.Equal(t, 0.9, verified.Confidence)
+
+	fabricated := result.Relationships[1]
+	assert.Equal(t, false, fabricated.Properties["evidence_verified"])
+	assert.InDelta(t, 0.4, fabricated.Confidence, 1e-9)
+
+	// Without a quote there is nothing to verify
+	assert.NotContains(t, result.Relationships[2].Properties, "evidence_verified")
+	assert.Equal(t, 0.7, result.Relationships[2].Confidence)
+}
+
+func TestQuoteInArticle(t *testing.T) {
+	article := quoteWords(evidenceArticle)
+	tests := []struct {
+		name  string
+		quote string
+		want  bool
+	}{
+		{"exact", "awarded a $2.4 million paving contract to Roe Construction", true},
+		{"across a line break", "donated\nto her campaign", true},
+		{"punctuation and case", `"i had no role in the decision", Whitfield said`, true},
+		{"one word changed", "The city awarded a $2.4 million road contract to Roe Construction", true},
+		{"shortened with an ellipsis", "The city awarded ... paving contract to Roe Construction in March", true},
+		{"fragment not in the article", "The city awarded … a no-bid deal", false},
+		{"fabricated", "Whitfield admitted steering the contract", false},
+		{"short quotes must match exactly", "Roe Holdings", false},
+		{"only punctuation", "...", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, quoteInArticle(tt.quote, article))
+		})
+	}
+}
+
+func TestVerifyEvidence_EvidenceProperty(t *testing.T) {
+	result := &models.ExtractionResult{
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Confidence: 0.6, Properties: map[string]interface{}{"evidence": "Whitfield said on Tuesday"}},
+		},
+	}
+
+	verifyEvidence(result, evidenceArticle)
+
+	require.Contains(t, result.Relationships[0].Properties, "evidence_verified")
+	assert.Equal(t, true, result.Relationships[0].Properties["evidence_verified"])
+	assert.Equal(t, 0.6, result.Relationships[0].Confidence)
+}
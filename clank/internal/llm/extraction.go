@@ -2,7 +2,6 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -10,8 +9,44 @@ import (
 	"clank/internal/models"
 )
 
-// ProcessArticle sends an article to the LLM for entity and relationship extraction
+// ProcessArticle sends an article to the LLM for entity and relationship extraction.
+// When chunking is configured and the content is longer than the chunk size, the
+// article is extracted in overlapping windows and the results are merged. Entity
+// types are mapped onto the taxonomy, entities naming the same thing are merged
+// into one with aliases, relationship endpoints naming no entity ID are repaired
+// per the dangling references policy, money amounts and dates are parsed into a
+// value and currency or a date and precision, and relationships whose evidence
+// quote isn't in the article are flagged and down-weighted.
 func (c *Client) ProcessArticle(ctx context.Context, article *models.Article) (*models.ExtractionResult, error) {
+	ctx = WithStage(ctx, extractionStage)
+	var result *models.ExtractionResult
+	var err error
+	if c.chunkSize > 0 && len([]rune(article.Content)) > c.chunkSize {
+		result, err = c.processArticleChunks(ctx, article)
+	} else {
+		result, err = c.extractArticle(ctx, article)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if twoPhaseEvents(ctx) {
+		if err := c.extractEvents(ctx, article, result); err != nil {
+			return nil, err
+		}
+	}
+
+	normalizeEntityTypes(result)
+	canonicalizeEntities(result)
+	repairReferences(result, c.danglingReferences)
+	normalizeAmounts(result)
+	normalizeDates(result)
+	locateMentions(result, article.Content)
+	verifyEvidence(result, article.Content)
+	return result, nil
+}
+
+// extractArticle performs a single extraction pass over the article content
+func (c *Client) extractArticle(ctx context.Context, article *models.Article) (*models.ExtractionResult, error) {
 	prompt := fmt.Sprintf(`Analyze the following article and extract entities and relationships related to corruption:
 
 Title: %s
@@ -63,12 +98,19 @@ Format your response as a valid JSON object with the following structure:
 		article.PublishDate.Format("2006-01-02"),
 		article.Content,
 	)
+	// The two-phase events pass extracts events on its own
+	if !twoPhaseEvents(ctx) {
+		prompt += c.TemporalInstructions()
+	}
+	prompt += c.AliasInstructions()
+	prompt += c.PropertyConfidenceInstructions()
+	prompt += LanguageInstructions(article.Language)
 
 	// Create completion request
 	messages := []interfaces.Message{
 		{
 			Role:    "system",
-			Content: "You are a precise entity extraction system specializing in analyzing corruption-related news articles.",
+			Content: extractionSystemPrompt(article.Language),
 		},
 		{
 			Role:    "user",
@@ -109,7 +151,7 @@ Format your response as a valid JSON object with the following structure:
 
 	// Parse LLM response
 	var result models.ExtractionResult
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
+	if err := c.DecodeJSON(ctx, content, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse LLM response: %w", err)
 	}
 
@@ -118,6 +160,8 @@ Format your response as a valid JSON object with the following structure:
 	for i := range result.Entities {
 		result.Entities[i].ArticleID = article.ID
 		result.Entities[i].ExtractedAt = now
+		cleanAliases(&result.Entities[i])
+		cleanPropertyConfidence(&result.Entities[i])
 	}
 
 	for i := range result.Relationships {
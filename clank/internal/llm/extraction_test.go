@@ -2,110 +2,77 @@ package llm
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"clank/config"
 	"clank/internal/models"
-	"clank/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestExtractEntities(t *testing.T) {
+func TestClient_ProcessArticle(t *testing.T) {
+	const article = "John Smith from Acme Corp paid $500,000 to Official Jane"
 	tests := []struct {
-		name        string
-		text        string
-		setupMocks  func(*testutil.MockLLMClient)
-		expectError bool
-		expected    []*models.ExtractedEntity
+		name          string
+		response      string
+		status        int
+		expectError   bool
+		entities      []models.ExtractedEntity
+		relationships []models.ExtractedRelationship
 	}{
 		{
-			name: "successful entity extraction",
-			text: "John Smith from Acme Corp paid $500,000 to Official Jane",
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{
-					"entities": [
-						{
-							"name": "John Smith",
-							"type": "person",
-							"properties": {
-								"role": "executive",
-								"company": "Acme Corp"
-							}
-						},
-						{
-							"name": "Acme Corp",
-							"type": "company",
-							"properties": {
-								"industry": "technology"
-							}
-						},
-						{
-							"name": "Official Jane",
-							"type": "person",
-							"properties": {
-								"role": "government official"
-							}
-						}
-					]
-				}`
-			},
-			expectError: false,
-			expected: []*models.Entity{
-				{
-					Name: "John Smith",
-					Type: "person",
-					Properties: map[string]interface{}{
-						"role":    "executive",
-						"company": "Acme Corp",
-					},
-				},
-				{
-					Name: "Acme Corp",
-					Type: "company",
-					Properties: map[string]interface{}{
-						"industry": "technology",
-					},
-				},
-				{
-					Name: "Official Jane",
-					Type: "person",
-					Properties: map[string]interface{}{
-						"role": "government official",
-					},
-				},
-			},
-		},
-		{
-			name:        "empty text",
-			text:        "",
-			setupMocks:  func(mock *testutil.MockLLMClient) {},
+			name: "successful extraction",
+			response: `{
+				"entities": [
+					{"id": "1", "name": "John Smith", "type": "person", "properties": {"role": "executive", "company": "Acme Corp"}},
+					{"id": "2", "name": "Acme Corp", "type": "organization", "properties": {"industry": "technology"}},
+					{"id": "3", "name": "Official Jane", "type": "person", "properties": {"role": "government official"}}
+				],
+				"relationships": [
+					{"id": "r1", "fromId": "1", "toId": "2", "type": "affiliation", "properties": {}},
+					{"id": "r2", "fromId": "1", "toId": "3", "type": "payment", "properties": {"currency": "USD", "date": "2025-08-17"}}
+				]
+			}`,
+			entities: []models.ExtractedEntity{
+				{Name: "John Smith", Type: "person", Properties: map[string]interface{}{"role": "executive", "company": "Acme Corp"}},
+				{Name: "Acme Corp", Type: "organization", Properties: map[string]interface{}{"industry": "technology"}},
+				{Name: "Official Jane", Type: "person", Properties: map[string]interface{}{"role": "government official"}},
+			},
+			relationships: []models.ExtractedRelationship{
+				{FromID: "1", ToID: "2", Type: "affiliation"},
+				{FromID: "1", ToID: "3", Type: "payment"},
+			},
+		},
+		{
+			name:        "llm error",
+			status:      http.StatusInternalServerError,
 			expectError: true,
 		},
 		{
-			name: "llm error",
-			text: "Valid text",
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateError = assert.AnError
-			},
-			expectError: true,
-		},
-		{
-			name: "invalid response format",
-			text: "Valid text",
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{"invalid": "format"}`
-			},
+			name:        "invalid response format",
+			response:    `not an extraction`,
 			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockLLM := testutil.NewMockLLMClient()
-			tt.setupMocks(mockLLM)
-
-			entities, err := ExtractEntities(context.Background(), tt.text, mockLLM)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					http.Error(w, "model not loaded", tt.status)
+					return
+				}
+				json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: tt.response}}})
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{}
+			cfg.LLM.URL = server.URL
+			result, err := NewClient(cfg).ProcessArticle(context.Background(), &models.Article{ID: "a1", Content: article})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -113,116 +80,17 @@ func TestExtractEntities(t *testing.T) {
 			}
 
 			require.NoError(t, err)
-			require.Equal(t, len(tt.expected), len(entities))
-
-			for i, expected := range tt.expected {
-				assert.Equal(t, expected.Name, entities[i].Name)
-				assert.Equal(t, expected.Type, entities[i].Type)
-				assert.Equal(t, expected.Properties, entities[i].Properties)
+			require.Len(t, result.Entities, len(tt.entities))
+			for i, expected := range tt.entities {
+				assert.Equal(t, expected.Name, result.Entities[i].Name)
+				assert.Equal(t, expected.Type, result.Entities[i].Type)
+				assert.Equal(t, expected.Properties, result.Entities[i].Properties)
 			}
-		})
-	}
-}
-
-func TestExtractRelationships(t *testing.T) {
-	tests := []struct {
-		name        string
-		text        string
-		entities    []*models.Entity
-		setupMocks  func(*testutil.MockLLMClient)
-		expectError bool
-		expected    []*models.Relationship
-	}{
-		{
-			name: "successful relationship extraction",
-			text: "John Smith from Acme Corp paid $500,000 to Official Jane",
-			entities: []*models.Entity{
-				{ID: "1", Name: "John Smith", Type: "person"},
-				{ID: "2", Name: "Acme Corp", Type: "company"},
-				{ID: "3", Name: "Official Jane", Type: "person"},
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{
-					"relationships": [
-						{
-							"from": "1",
-							"to": "2",
-							"type": "WORKS_FOR",
-							"properties": {}
-						},
-						{
-							"from": "1",
-							"to": "3",
-							"type": "PAID",
-							"properties": {
-								"amount": "500000",
-								"currency": "USD",
-								"date": "2025-08-17"
-							}
-						}
-					]
-				}`
-			},
-			expectError: false,
-			expected: []*models.Relationship{
-				{
-					FromID:     "1",
-					ToID:       "2",
-					Type:       "WORKS_FOR",
-					Properties: map[string]interface{}{},
-				},
-				{
-					FromID: "1",
-					ToID:   "3",
-					Type:   "PAID",
-					Properties: map[string]interface{}{
-						"amount":   "500000",
-						"currency": "USD",
-						"date":     "2025-08-17",
-					},
-				},
-			},
-		},
-		{
-			name:        "no entities provided",
-			text:        "Valid text",
-			entities:    []*models.Entity{},
-			setupMocks:  func(mock *testutil.MockLLMClient) {},
-			expectError: true,
-		},
-		{
-			name: "llm error",
-			text: "Valid text",
-			entities: []*models.Entity{
-				{ID: "1", Name: "Entity", Type: "person"},
-			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateError = assert.AnError
-			},
-			expectError: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			mockLLM := testutil.NewMockLLMClient()
-			tt.setupMocks(mockLLM)
-
-			relationships, err := ExtractRelationships(context.Background(), tt.text, tt.entities, mockLLM)
-
-			if tt.expectError {
-				assert.Error(t, err)
-				return
-			}
-
-			require.NoError(t, err)
-			require.Equal(t, len(tt.expected), len(relationships))
-
-			for i, expected := range tt.expected {
-				assert.Equal(t, expected.FromID, relationships[i].FromID)
-				assert.Equal(t, expected.ToID, relationships[i].ToID)
-				assert.Equal(t, expected.Type, relationships[i].Type)
-				assert.Equal(t, expected.Properties, relationships[i].Properties)
+			require.Len(t, result.Relationships, len(tt.relationships))
+			for i, expected := range tt.relationships {
+				assert.Equal(t, expected.FromID, result.Relationships[i].FromID)
+				assert.Equal(t, expected.ToID, result.Relationships[i].ToID)
+				assert.Equal(t, expected.Type, result.Relationships[i].Type)
 			}
 		})
 	}
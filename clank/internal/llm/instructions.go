@@ -0,0 +1,30 @@
+package llm
+
+import "context"
+
+type extraInstructionsKey struct{}
+
+// WithExtraInstructions returns a context whose completions append instructions
+// to their last user message, e.g. to try a prompt change on stored articles
+// without editing the prompt files
+func WithExtraInstructions(ctx context.Context, instructions string) context.Context {
+	return context.WithValue(ctx, extraInstructionsKey{}, instructions)
+}
+
+// withExtraInstructions returns messages with the instructions set by
+// WithExtraInstructions appended to the last user message. messages itself is
+// left untouched.
+func withExtraInstructions(ctx context.Context, messages []Message) []Message {
+	instructions, _ := ctx.Value(extraInstructionsKey{}).(string)
+	if instructions == "" {
+		return messages
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			extended := append([]Message(nil), messages...)
+			extended[i].Content += "\n\nAdditional instructions:\n" + instructions
+			return extended
+		}
+	}
+	return messages
+}
@@ -0,0 +1,24 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithExtraInstructions(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "Extract entities."},
+		{Role: "user", Content: "Article text"},
+		{Role: "assistant", Content: "{}"},
+	}
+
+	assert.Equal(t, messages, withExtraInstructions(context.Background(), messages))
+
+	ctx := WithExtraInstructions(context.Background(), "Only extract people.")
+	got := withExtraInstructions(ctx, messages)
+	assert.Equal(t, "Article text\n\nAdditional instructions:\nOnly extract people.", got[1].Content)
+	assert.Equal(t, "Extract entities.", got[0].Content)
+	assert.Equal(t, "Article text", messages[1].Content, "the caller's messages are left untouched")
+}
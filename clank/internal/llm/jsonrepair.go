@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jsonFixupSystemPrompt instructs the repair pass sent malformed output
+const jsonFixupSystemPrompt = "You repair malformed JSON. Reply with the corrected JSON only: no explanation, no markdown fences, no comments."
+
+// DecodeJSON parses an LLM response into v. Output that isn't valid JSON is
+// repaired heuristically first: markdown fences, prose around the JSON value,
+// comments and trailing commas are removed. When that still doesn't parse and
+// auto_repair_json is set, the output is sent back to the LLM once, with
+// instructions to return it as valid JSON, and the reply is parsed instead.
+// Only syntax errors are repaired; JSON of the wrong shape fails as is.
+func (c *Client) DecodeJSON(ctx context.Context, content string, v any) error {
+	err := decodeRepairedJSON(content, v)
+	var syntaxErr *json.SyntaxError
+	if err == nil || !c.autoRepairJSON || !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	fixed, fixupErr := c.fixupJSON(ctx, content, err)
+	if fixupErr != nil {
+		return fmt.Errorf("%w (repair pass failed: %v)", err, fixupErr)
+	}
+	if err := decodeRepairedJSON(fixed, v); err != nil {
+		return fmt.Errorf("repair pass returned invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// fixupJSON asks the LLM to rewrite content, which failed to parse with
+// parseErr, as valid JSON
+func (c *Client) fixupJSON(ctx context.Context, content string, parseErr error) (string, error) {
+	prompt := fmt.Sprintf(`The following output should be a single valid JSON value, but it can't be parsed: %v
+
+Return the same data as valid JSON. Keep every key and value; don't add, drop or change any.
+
+%s`, parseErr, content)
+	messages := []Message{
+		{Role: "system", Content: jsonFixupSystemPrompt, CreatedAt: time.Now()},
+		{Role: "user", Content: prompt, CreatedAt: time.Now()},
+	}
+
+	resp, err := c.Generate(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("LLM error: %s", resp.Error)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+	if resp.Choices[0].Content != "" {
+		return resp.Choices[0].Content, nil
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// decodeRepairedJSON unmarshals content into v, retrying with repairJSON's
+// rewrite when content has a syntax error. The original error is returned when
+// the rewrite doesn't parse either. Syntax errors leave v untouched.
+func decodeRepairedJSON(content string, v any) error {
+	err := json.Unmarshal([]byte(content), v)
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+	repaired := repairJSON(content)
+	if repaired == content {
+		return err
+	}
+	if repairedErr := json.Unmarshal([]byte(repaired), v); repairedErr == nil || !errors.As(repairedErr, &syntaxErr) {
+		return repairedErr
+	}
+	return err
+}
+
+// repairJSON fixes the usual ways models wrap or decorate JSON: a markdown code
+// fence, prose before or after the value, // and /* */ comments (the prompts'
+// own examples have them) and trailing commas
+func repairJSON(content string) string {
+	s := strings.TrimSpace(content)
+	if fenced, ok := strings.CutPrefix(s, "```"); ok {
+		// Drop the fence's info string, e.g. json
+		if newline := strings.IndexByte(fenced, '\n'); newline >= 0 {
+			fenced = fenced[newline+1:]
+		}
+		if end := strings.LastIndex(fenced, "```"); end >= 0 {
+			fenced = fenced[:end]
+		}
+		s = strings.TrimSpace(fenced)
+	}
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return content
+	}
+	closing := "}"
+	if s[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(s, closing)
+	if end < start {
+		return content
+	}
+	return stripCommentsAndTrailingCommas(s[start : end+1])
+}
+
+// stripCommentsAndTrailingCommas removes comments, and commas directly before a
+// closing brace or bracket, outside of string literals
+func stripCommentsAndTrailingCommas(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	inString, escaped := false, false
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if inString {
+			b.WriteByte(ch)
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case ch == '"':
+			inString = true
+			b.WriteByte(ch)
+		case ch == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+		case ch == '/' && i+1 < len(s) && s[i+1] == '*':
+			if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(s)
+			}
+		case ch == ',':
+			if next := nextToken(s, i+1); next < len(s) && (s[next] == '}' || s[next] == ']') {
+				continue
+			}
+			b.WriteByte(ch)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// nextToken returns the index of the first byte at or after i that isn't
+// whitespace or part of a comment
+func nextToken(s string, i int) int {
+	for i < len(s) {
+		switch {
+		case strings.IndexByte(" \t\r\n", s[i]) >= 0:
+			i++
+		case strings.HasPrefix(s[i:], "//"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 1
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return len(s)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"markdown fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"surrounding prose", "Here is the analysis:\n{\"a\": [1, 2]}\nLet me know if you need more.", `{"a": [1, 2]}`},
+		{"trailing commas", `{"a": [1, 2,], "b": {"c": 3,},}`, `{"a": [1, 2], "b": {"c": 3}}`},
+		{"comments", "{\"a\": [], // most confident entities\n\"b\": 1 /* inline */}", "{\"a\": [], \n\"b\": 1 }"},
+		{"comma before a comment", "{\"a\": 1, // last\n}", "{\"a\": 1 \n}"},
+		{"strings are left alone", `{"url": "https://example.com/a,}", "q": "say \"//\",]"}`, `{"url": "https://example.com/a,}", "q": "say \"//\",]"}`},
+		{"top-level array", "Result: [{\"a\": 1},]", `[{"a": 1}]`},
+		{"no JSON", "I can't help with that.", "I can't help with that."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repairJSON(tt.content))
+		})
+	}
+}
+
+// sequenceServer answers successive completions with responses, repeating the
+// last one, and records the requests
+func sequenceServer(t *testing.T, responses ...string) (*httptest.Server, *[]GenerateRequest) {
+	t.Helper()
+	var requests []GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		content := responses[min(len(requests), len(responses)-1)]
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: content}}})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+const brokenExtraction = `{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe", "confidence": 0.9}` +
+	` {"id": "e2", "type": "company", "name": "Acme Construction"}], "relationships": [], "confidence": 0.8}`
+
+func TestClient_ProcessArticle_AutoRepairJSON(t *testing.T) {
+	repaired := `{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe", "confidence": 0.9},` +
+		` {"id": "e2", "type": "company", "name": "Acme Construction"}], "relationships": [], "confidence": 0.8}`
+	server, requests := sequenceServer(t, brokenExtraction, repaired)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.AutoRepairJSON = true
+	result, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{ID: "a1", Content: "Jane Doe runs Acme Construction."})
+	require.NoError(t, err)
+
+	require.Len(t, result.Entities, 2)
+	assert.Equal(t, "Jane Doe", result.Entities[0].Name)
+	assert.Equal(t, "a1", result.Entities[1].ArticleID, "the repaired result is post-processed as usual")
+	assert.Equal(t, 0.8, result.Confidence)
+
+	require.Len(t, *requests, 2)
+	fixup := (*requests)[1].Messages
+	require.Len(t, fixup, 2)
+	assert.Equal(t, jsonFixupSystemPrompt, fixup[0].Content)
+	assert.Contains(t, fixup[1].Content, brokenExtraction, "the malformed output is sent back")
+}
+
+func TestClient_ProcessArticle_AutoRepairJSONDisabled(t *testing.T) {
+	server, requests := sequenceServer(t, brokenExtraction)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	_, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{Content: "..."})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse LLM response")
+	assert.Len(t, *requests, 1)
+}
+
+func TestClient_DecodeJSON(t *testing.T) {
+	t.Run("heuristic repair needs no extra call", func(t *testing.T) {
+		server, requests := sequenceServer(t, `{}`)
+		cfg := &config.Config{}
+		cfg.LLM.URL = server.URL
+		cfg.LLM.AutoRepairJSON = true
+
+		var v struct{ A []int }
+		require.NoError(t, NewClient(cfg).DecodeJSON(t.Context(), "```json\n{\"A\": [1, 2,],}\n```", &v))
+		assert.Equal(t, []int{1, 2}, v.A)
+		assert.Empty(t, *requests)
+	})
+
+	t.Run("one repair pass at most", func(t *testing.T) {
+		server, requests := sequenceServer(t, `still {"A": [1 2]}`)
+		cfg := &config.Config{}
+		cfg.LLM.URL = server.URL
+		cfg.LLM.AutoRepairJSON = true
+
+		var v struct{ A []int }
+		err := NewClient(cfg).DecodeJSON(t.Context(), `{"A": [1 2]}`, &v)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "repair pass returned invalid JSON")
+		assert.Len(t, *requests, 1)
+	})
+
+	t.Run("wrong shape isn't sent for repair", func(t *testing.T) {
+		server, requests := sequenceServer(t, `{}`)
+		cfg := &config.Config{}
+		cfg.LLM.URL = server.URL
+		cfg.LLM.AutoRepairJSON = true
+
+		var v struct{ A []int }
+		err := NewClient(cfg).DecodeJSON(t.Context(), `{"A": "one"}`, &v)
+		require.Error(t, err)
+		assert.Empty(t, *requests)
+	})
+}
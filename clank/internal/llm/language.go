@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"fmt"
+)
+
+// extractionSystemPrompts are the extraction system prompts by article language
+var extractionSystemPrompts = map[string]string{
+	"en": "You are a precise entity extraction system specializing in analyzing corruption-related news articles.",
+	"es": "Eres un sistema preciso de extracción de entidades especializado en analizar noticias sobre corrupción. Responde solo con JSON; las claves y los valores de \"type\" deben permanecer en inglés.",
+	"fr": "Tu es un système précis d'extraction d'entités spécialisé dans l'analyse d'articles de presse sur la corruption. Réponds uniquement en JSON ; les clés et les valeurs de \"type\" restent en anglais.",
+}
+
+// languageNames are the English names of the supported article languages
+var languageNames = map[string]string{
+	"es": "Spanish",
+	"fr": "French",
+}
+
+// extractionSystemPrompt returns the system prompt for an article language,
+// falling back to English for unknown or undetected languages
+func extractionSystemPrompt(language string) string {
+	if prompt, ok := extractionSystemPrompts[language]; ok {
+		return prompt
+	}
+	return extractionSystemPrompts["en"]
+}
+
+// LanguageInstructions returns instructions for extracting from a non-English
+// article, or "" for English and undetected languages
+func LanguageInstructions(language string) string {
+	name, ok := languageNames[language]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(`
+
+The article is written in %s:
+- Keep every JSON key and every "type" value exactly as specified above, in English
+- Copy names, mention text and context quotes verbatim in %s; do not translate them`, name, name)
+}
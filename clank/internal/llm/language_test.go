@@ -0,0 +1,51 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ProcessArticle_LanguagePrompts(t *testing.T) {
+	tests := []struct {
+		language     string
+		systemPrompt string
+		instructions string
+	}{
+		{language: "en", systemPrompt: extractionSystemPrompts["en"]},
+		{language: "", systemPrompt: extractionSystemPrompts["en"]},
+		{language: "es", systemPrompt: extractionSystemPrompts["es"], instructions: "The article is written in Spanish"},
+		{language: "fr", systemPrompt: extractionSystemPrompts["fr"], instructions: "The article is written in French"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.language, func(t *testing.T) {
+			var req GenerateRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: `{"entities": [], "relationships": []}`}}})
+			}))
+			defer server.Close()
+
+			cfg := &config.Config{}
+			cfg.LLM.URL = server.URL
+			_, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{Content: "...", Language: tt.language})
+			require.NoError(t, err)
+
+			require.Len(t, req.Messages, 2)
+			assert.Equal(t, tt.systemPrompt, req.Messages[0].Content)
+			if tt.instructions != "" {
+				assert.Contains(t, req.Messages[1].Content, tt.instructions)
+			} else {
+				assert.NotContains(t, req.Messages[1].Content, "The article is written in")
+			}
+		})
+	}
+}
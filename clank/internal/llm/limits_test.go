@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clank/config"
+	"clank/internal/limits"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Generate_ConcurrencyLimit(t *testing.T) {
+	limits.Configure(0, 1, 0)
+	t.Cleanup(func() { limits.Configure(0, 0, 0) })
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: "ok"}}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	client := NewClient(cfg)
+	messages := []Message{{Role: "user", Content: "hi"}}
+
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := client.Generate(context.Background(), messages)
+			done <- err
+		}()
+	}
+
+	<-started
+	select {
+	case <-started:
+		t.Fatal("second call reached the LLM while the first held the only slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Releasing the first call admits the second
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+	assert.Len(t, started, 1)
+}
+
+func TestClient_Generate_WaitCancelled(t *testing.T) {
+	limits.Configure(0, 1, 0)
+	t.Cleanup(func() { limits.Configure(0, 0, 0) })
+
+	slots := limits.LLM()
+	require.NoError(t, slots.Acquire(context.Background()))
+	defer slots.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := NewClient(&config.Config{}).Generate(ctx, []Message{{Role: "user", Content: "hi"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"clank/internal/models"
+)
+
+// locateMentions sets the character offsets of every entity mention within content
+// so clients can highlight the source text. When a mention's text occurs several
+// times, the occurrence inside its context sentence wins, and repeated mentions of
+// the same text take successive occurrences. Mentions whose text isn't in the
+// content are flagged NotFound and keep no position.
+func locateMentions(result *models.ExtractionResult, content string) {
+	locator := &mentionLocator{content: content, used: make(map[int]bool)}
+	for i := range result.Entities {
+		mentions := result.Entities[i].Mentions
+		for j := range mentions {
+			locator.locate(&mentions[j])
+		}
+	}
+}
+
+// mentionLocator finds mention texts in one article's content
+type mentionLocator struct {
+	content string
+	used    map[int]bool // Byte offsets already given to a mention
+}
+
+func (l *mentionLocator) locate(mention *models.EntityMention) {
+	mention.Position.Start, mention.Position.End = 0, 0
+	mention.NotFound = false
+
+	span, ok := l.find(strings.TrimSpace(mention.Text), strings.TrimSpace(mention.Context))
+	if !ok {
+		mention.NotFound = true
+		return
+	}
+	l.used[span[0]] = true
+	mention.Position.Start = utf8.RuneCountInString(l.content[:span[0]])
+	mention.Position.End = mention.Position.Start + utf8.RuneCountInString(l.content[span[0]:span[1]])
+}
+
+// find returns the byte span of the occurrence of text best matching the mention
+func (l *mentionLocator) find(text, context string) ([2]int, bool) {
+	occurrences := l.occurrences(text)
+	if len(occurrences) == 0 {
+		return [2]int{}, false
+	}
+
+	if context != "" {
+		if contextStart := strings.Index(l.content, context); contextStart >= 0 {
+			contextEnd := contextStart + len(context)
+			for _, span := range occurrences {
+				if span[0] >= contextStart && span[1] <= contextEnd && !l.used[span[0]] {
+					return span, true
+				}
+			}
+		}
+	}
+	for _, span := range occurrences {
+		if !l.used[span[0]] {
+			return span, true
+		}
+	}
+	// Every occurrence is taken, so the mention repeats one of them
+	return occurrences[0], true
+}
+
+// occurrences returns the byte spans of text in the content, falling back to a
+// case-insensitive search when the model changed the capitalization
+func (l *mentionLocator) occurrences(text string) [][2]int {
+	if text == "" {
+		return nil
+	}
+
+	var spans [][2]int
+	for offset := 0; ; {
+		i := strings.Index(l.content[offset:], text)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		spans = append(spans, [2]int{start, start + len(text)})
+		offset = start + len(text)
+	}
+	if len(spans) > 0 {
+		return spans
+	}
+
+	for _, match := range regexp.MustCompile(`(?i)`+regexp.QuoteMeta(text)).FindAllStringIndex(l.content, -1) {
+		spans = append(spans, [2]int{match[0], match[1]})
+	}
+	return spans
+}
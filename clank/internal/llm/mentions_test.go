@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mention(text, context string) models.EntityMention {
+	return models.EntityMention{Text: text, Context: context}
+}
+
+func TestLocateMentions(t *testing.T) {
+	content := "Mayor Smith signed the deal. Acme Corp paid €2m to Smith's campaign. Later, Smith denied it."
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Name: "Mayor Smith", Mentions: []models.EntityMention{
+				mention("Smith", "Later, Smith denied it."),
+				mention("Mayor Smith", ""),
+				mention("Smith", ""),
+			}},
+			{ID: "e2", Name: "Acme Corp", Mentions: []models.EntityMention{
+				mention("acme corp", "Acme Corp paid €2m"),
+				mention("Acme Holdings", ""),
+			}},
+			{ID: "e3", Name: "campaign", Mentions: []models.EntityMention{
+				mention("campaign", ""),
+			}},
+		},
+	}
+
+	locateMentions(result, content)
+
+	runes := []rune(content)
+	span := func(m models.EntityMention) string {
+		return string(runes[m.Position.Start:m.Position.End])
+	}
+
+	smith := result.Entities[0].Mentions
+	// The context picks the third "Smith"; later mentions take the remaining ones in order
+	assert.Equal(t, 76, smith[0].Position.Start)
+	assert.Equal(t, "Smith", span(smith[0]))
+	assert.Equal(t, 0, smith[1].Position.Start)
+	assert.Equal(t, "Mayor Smith", span(smith[1]))
+	assert.Equal(t, 6, smith[2].Position.Start, "the first Smith inside Mayor Smith is still unused")
+
+	acme := result.Entities[1].Mentions
+	assert.False(t, acme[0].NotFound)
+	assert.Equal(t, "Acme Corp", span(acme[0]), "matched ignoring case")
+	assert.True(t, acme[1].NotFound)
+	assert.Zero(t, acme[1].Position.Start)
+	assert.Zero(t, acme[1].Position.End)
+
+	// Offsets count characters, so the € before "campaign" counts once
+	campaign := result.Entities[2].Mentions[0]
+	require.False(t, campaign.NotFound)
+	assert.Equal(t, "campaign", span(campaign))
+}
+
+func TestLocateMentions_EmptyText(t *testing.T) {
+	result := &models.ExtractionResult{Entities: []models.ExtractedEntity{
+		{ID: "e1", Mentions: []models.EntityMention{mention("  ", "context")}},
+	}}
+
+	locateMentions(result, "Some article text")
+
+	assert.True(t, result.Entities[0].Mentions[0].NotFound)
+}
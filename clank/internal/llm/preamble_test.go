@@ -0,0 +1,62 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPreamble = "Be conservative, avoid unfounded accusations and cite the article."
+
+func TestClient_ProcessArticle_SystemPreamble(t *testing.T) {
+	var req GenerateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: `{"entities": [], "relationships": []}`}}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.SystemPreamble = testPreamble
+	_, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{Content: "..."})
+	require.NoError(t, err)
+
+	// The preamble is merged into the stage's system message rather than added beside it
+	require.Len(t, req.Messages, 2)
+	assert.Equal(t, "system", req.Messages[0].Role)
+	assert.Equal(t, testPreamble+"\n\n"+extractionSystemPrompts["en"], req.Messages[0].Content)
+	assert.Equal(t, "user", req.Messages[1].Role)
+}
+
+func TestClient_WithSystemPreamble(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.LLM.SystemPreamble = testPreamble
+	client := NewClient(cfg)
+
+	t.Run("no system message", func(t *testing.T) {
+		messages := client.withSystemPreamble([]Message{{Role: "user", Content: "hi"}})
+		require.Len(t, messages, 2)
+		assert.Equal(t, Message{Role: "system", Content: testPreamble}, Message{Role: messages[0].Role, Content: messages[0].Content})
+		assert.Equal(t, "hi", messages[1].Content)
+	})
+
+	t.Run("caller's messages are not modified", func(t *testing.T) {
+		original := []Message{{Role: "system", Content: "stage"}, {Role: "user", Content: "hi"}}
+		messages := client.withSystemPreamble(original)
+		assert.Equal(t, testPreamble+"\n\nstage", messages[0].Content)
+		assert.Equal(t, "stage", original[0].Content)
+	})
+
+	t.Run("no preamble configured", func(t *testing.T) {
+		original := []Message{{Role: "system", Content: "stage"}}
+		assert.Equal(t, original, NewClient(&config.Config{}).withSystemPreamble(original))
+	})
+}
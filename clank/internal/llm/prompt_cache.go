@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// PromptCache holds the completions of the prompts sent with a context returned
+// by WithPromptCache, so a prompt repeated exactly (same model and messages) is
+// answered without another request. A nil cache caches nothing.
+//
+// Completions aren't deterministic at non-zero temperatures, so a cache should
+// be scoped to one piece of work, such as an analysis session.
+type PromptCache struct {
+	mu        sync.Mutex
+	responses map[string]*Response
+}
+
+// NewPromptCache creates an empty prompt cache
+func NewPromptCache() *PromptCache {
+	return &PromptCache{responses: make(map[string]*Response)}
+}
+
+func (pc *PromptCache) get(key string) (*Response, bool) {
+	if pc == nil {
+		return nil, false
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	response, ok := pc.responses[key]
+	return response, ok
+}
+
+func (pc *PromptCache) put(key string, response *Response) {
+	if pc == nil {
+		return
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.responses[key] = response
+}
+
+type promptCacheKey struct{}
+
+// WithPromptCache returns a context whose completions are looked up in and
+// added to cache
+func WithPromptCache(ctx context.Context, cache *PromptCache) context.Context {
+	return context.WithValue(ctx, promptCacheKey{}, cache)
+}
+
+// promptCacheFrom returns the cache set by WithPromptCache, or nil
+func promptCacheFrom(ctx context.Context) *PromptCache {
+	cache, _ := ctx.Value(promptCacheKey{}).(*PromptCache)
+	return cache
+}
+
+// promptHash identifies a prompt by the model it is sent to and the role, name
+// and content of its messages; IDs and timestamps don't change the completion
+func promptHash(model string, messages []Message) string {
+	type promptMessage struct {
+		Role    string `json:"role"`
+		Name    string `json:"name,omitempty"`
+		Content string `json:"content"`
+	}
+	prompt := make([]promptMessage, len(messages))
+	for i, message := range messages {
+		prompt[i] = promptMessage{Role: message.Role, Name: message.Name, Content: message.Content}
+	}
+	data, _ := json.Marshal(struct {
+		Model    string          `json:"model"`
+		Messages []promptMessage `json:"messages"`
+	}{model, prompt})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
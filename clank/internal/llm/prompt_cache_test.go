@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clank/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer answers every completion with "ok", counting the requests
+func countingServer(t *testing.T) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		json.NewEncoder(w).Encode(Response{
+			Choices: []Choice{{Message: Message{Role: "assistant", Content: "ok"}}},
+			Usage:   &Usage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.Model = "test-model"
+	return NewClient(cfg), &calls
+}
+
+func TestClient_Generate_PromptCache(t *testing.T) {
+	client, calls := countingServer(t)
+	tracker := &UsageTracker{}
+	ctx := WithUsageTracker(WithPromptCache(context.Background(), NewPromptCache()), tracker)
+	prompt := []Message{{Role: "system", Content: "Extract entities"}, {Role: "user", Content: "Article text"}}
+
+	first, err := client.Generate(ctx, prompt)
+	require.NoError(t, err)
+	// The same prompt built again, with a new timestamp, is served from the cache
+	repeated := []Message{{Role: "system", Content: "Extract entities"}, {Role: "user", Content: "Article text", CreatedAt: time.Now()}}
+	second, err := client.Generate(ctx, repeated)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, first, second)
+	assert.Equal(t, 12, tracker.Usage().TotalTokens, "a cached completion spends no tokens")
+
+	// Other prompts and other models are requested
+	_, err = client.Generate(ctx, []Message{{Role: "user", Content: "Another article"}})
+	require.NoError(t, err)
+	_, err = client.Generate(WithModel(ctx, "large-model"), prompt)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_Generate_WithoutPromptCache(t *testing.T) {
+	client, calls := countingServer(t)
+	prompt := []Message{{Role: "user", Content: "Article text"}}
+
+	for range 2 {
+		_, err := client.Generate(context.Background(), prompt)
+		require.NoError(t, err)
+	}
+	// A nil cache caches nothing
+	_, err := client.Generate(WithPromptCache(context.Background(), nil), prompt)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -4,18 +4,33 @@ import (
 	"context"
 	"testing"
 
+	"clank/internal/interfaces"
 	"clank/internal/models"
-	"clank/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fakeProvider is an interfaces.LLMProvider answering every prompt with
+// GenerateResponse, or failing with GenerateError
+type fakeProvider struct {
+	GenerateResponse string
+	GenerateError    error
+}
+
+func (f *fakeProvider) Generate(ctx context.Context, messages []interfaces.Message) (string, error) {
+	return f.GenerateResponse, f.GenerateError
+}
+
+func (f *fakeProvider) GenerateStream(ctx context.Context, messages []interfaces.Message, respChan chan<- string) error {
+	return f.GenerateError
+}
+
 func TestArticleExtractionPrompt_Generate(t *testing.T) {
 	tests := []struct {
 		name        string
 		article     *models.Article
-		setupMocks  func(*testutil.MockLLMClient)
+		setupMocks  func(*fakeProvider)
 		expectError bool
 		checkResult func(*testing.T, []models.ExtractedEntity, []models.ExtractedRelationship)
 	}{
@@ -26,7 +41,7 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 				Title:   "Company A in Corruption Scandal",
 				Content: "Company A CEO John Smith allegedly paid $1M to Official Jane Doe for contract approval.",
 			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
+			setupMocks: func(mock *fakeProvider) {
 				mock.GenerateResponse = `{
 					"entities": [
 						{
@@ -47,14 +62,14 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 					],
 					"relationships": [
 						{
-							"from": "John Smith",
-							"to": "Company A",
+							"fromId": "John Smith",
+							"toId": "Company A",
 							"type": "WORKS_FOR",
 							"properties": {"position": "CEO"}
 						},
 						{
-							"from": "Company A",
-							"to": "Jane Doe",
+							"fromId": "Company A",
+							"toId": "Jane Doe",
 							"type": "PAID",
 							"properties": {"amount": "1000000", "currency": "USD"}
 						}
@@ -92,7 +107,7 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 				Title:   "Empty Article",
 				Content: "",
 			},
-			setupMocks:  func(mock *testutil.MockLLMClient) {},
+			setupMocks:  func(mock *fakeProvider) {},
 			expectError: true,
 		},
 		{
@@ -102,7 +117,7 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 				Title:   "Test Article",
 				Content: "Valid content",
 			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
+			setupMocks: func(mock *fakeProvider) {
 				mock.GenerateError = assert.AnError
 			},
 			expectError: true,
@@ -114,8 +129,8 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 				Title:   "Test Article",
 				Content: "Valid content",
 			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
-				mock.GenerateResponse = `{"invalid": "format"}`
+			setupMocks: func(mock *fakeProvider) {
+				mock.GenerateResponse = `Sorry, I cannot help with that.`
 			},
 			expectError: true,
 		},
@@ -123,7 +138,7 @@ func TestArticleExtractionPrompt_Generate(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockLLM := testutil.NewMockLLMClient()
+			mockLLM := &fakeProvider{}
 			tt.setupMocks(mockLLM)
 
 			prompt := NewArticleExtractionPrompt()
@@ -181,34 +196,33 @@ func TestArticleExtractionPrompt_ValidateResults(t *testing.T) {
 		},
 		{
 			name: "missing entity type",
-			entities: []models.Entity{
+			entities: []models.ExtractedEntity{
 				{
 					Name: "Invalid Entity",
 					Type: "",
 				},
 			},
-			relationships: []models.Relationship{},
+			relationships: []models.ExtractedRelationship{},
 			expectError:   true,
 		},
 		{
-			name: "invalid relationship type",
-			entities: []models.Entity{
+			name: "relationship missing endpoint",
+			entities: []models.ExtractedEntity{
 				{Name: "Entity A", Type: "person"},
 				{Name: "Entity B", Type: "person"},
 			},
-			relationships: []models.Relationship{
+			relationships: []models.ExtractedRelationship{
 				{
 					FromID: "1",
-					ToID:   "2",
-					Type:   "INVALID_TYPE",
+					Type:   "WORKS_FOR",
 				},
 			},
 			expectError: true,
 		},
 		{
 			name:          "empty results",
-			entities:      []models.Entity{},
-			relationships: []models.Relationship{},
+			entities:      []models.ExtractedEntity{},
+			relationships: []models.ExtractedRelationship{},
 			expectError:   true,
 		},
 	}
@@ -231,7 +245,7 @@ func TestArticleExtractionPrompt_Enrichment(t *testing.T) {
 	tests := []struct {
 		name        string
 		article     *models.Article
-		setupMocks  func(*testutil.MockLLMClient)
+		setupMocks  func(*fakeProvider)
 		expectError bool
 		checkResult func(*testing.T, *models.Article)
 	}{
@@ -242,7 +256,7 @@ func TestArticleExtractionPrompt_Enrichment(t *testing.T) {
 				Title:   "Test Article",
 				Content: "Basic content",
 			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
+			setupMocks: func(mock *fakeProvider) {
 				mock.GenerateResponse = `{
 					"summary": "Enriched summary",
 					"topics": ["corruption", "government"],
@@ -252,12 +266,12 @@ func TestArticleExtractionPrompt_Enrichment(t *testing.T) {
 			},
 			expectError: false,
 			checkResult: func(t *testing.T, article *models.Article) {
-				assert.Contains(t, article.Properties, "summary")
-				assert.Contains(t, article.Properties, "topics")
-				assert.Contains(t, article.Properties, "sentiment")
-				assert.Contains(t, article.Properties, "risk_score")
+				assert.Contains(t, article.Metadata, "summary")
+				assert.Contains(t, article.Metadata, "topics")
+				assert.Contains(t, article.Metadata, "sentiment")
+				assert.Contains(t, article.Metadata, "risk_score")
 
-				topics, ok := article.Properties["topics"].([]interface{})
+				topics, ok := article.Metadata["topics"].([]interface{})
 				require.True(t, ok)
 				assert.Contains(t, topics, "corruption")
 			},
@@ -269,7 +283,7 @@ func TestArticleExtractionPrompt_Enrichment(t *testing.T) {
 				Title:   "Test Article",
 				Content: "Basic content",
 			},
-			setupMocks: func(mock *testutil.MockLLMClient) {
+			setupMocks: func(mock *fakeProvider) {
 				mock.GenerateError = assert.AnError
 			},
 			expectError: true,
@@ -278,7 +292,7 @@ func TestArticleExtractionPrompt_Enrichment(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockLLM := testutil.NewMockLLMClient()
+			mockLLM := &fakeProvider{}
 			tt.setupMocks(mockLLM)
 
 			prompt := NewArticleExtractionPrompt()
@@ -0,0 +1,34 @@
+package llm
+
+import (
+	"clank/internal/models"
+)
+
+// propertyConfidenceInstructions asks the model to rate its support for each property
+const propertyConfidenceInstructions = `
+
+Also rate each entity property separately:
+- For each entity with properties, add a "propertyConfidence" object mapping every property key to a confidence between 0.0 and 1.0
+- Rate how directly the article supports that value: a role stated outright scores high, an inferred or estimated value (e.g. net worth) scores low`
+
+// PropertyConfidenceInstructions returns the per-property confidence instructions to
+// append to extraction prompts, or "" when per-property confidence is disabled
+func (c *Client) PropertyConfidenceInstructions() string {
+	if !c.propertyConfidence {
+		return ""
+	}
+	return propertyConfidenceInstructions
+}
+
+// cleanPropertyConfidence drops confidences for properties the entity does not have
+// and clamps the rest to 0.0-1.0
+func cleanPropertyConfidence(entity *models.ExtractedEntity) {
+	confidence := entity.PropertyConfidence
+	entity.PropertyConfidence = nil
+	for property, value := range confidence {
+		if _, ok := entity.Properties[property]; !ok {
+			continue
+		}
+		entity.MergePropertyConfidence(map[string]float64{property: min(max(value, 0), 1)})
+	}
+}
@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanPropertyConfidence(t *testing.T) {
+	entity := &models.ExtractedEntity{
+		Properties: map[string]interface{}{"role": "Mayor", "netWorth": "$2M"},
+		PropertyConfidence: map[string]float64{
+			"role":     0.95,
+			"netWorth": 1.4,
+			"age":      0.8, // no such property
+		},
+	}
+
+	cleanPropertyConfidence(entity)
+
+	assert.Equal(t, map[string]float64{"role": 0.95, "netWorth": 1}, entity.PropertyConfidence)
+}
+
+func TestMergeEntity_PropertyConfidenceMax(t *testing.T) {
+	existing := &models.ExtractedEntity{
+		Name:               "Jane Smith",
+		Properties:         map[string]interface{}{"role": "Mayor", "netWorth": "$2M"},
+		PropertyConfidence: map[string]float64{"role": 0.6, "netWorth": 0.3},
+	}
+	duplicate := models.ExtractedEntity{
+		Name:               "Jane Smith",
+		Properties:         map[string]interface{}{"role": "Mayor", "party": "Independent"},
+		PropertyConfidence: map[string]float64{"role": 0.9, "netWorth": 0.1, "party": 0.7},
+	}
+
+	mergeEntity(existing, duplicate)
+
+	assert.Equal(t, map[string]float64{"role": 0.9, "netWorth": 0.3, "party": 0.7}, existing.PropertyConfidence)
+}
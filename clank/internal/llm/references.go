@@ -0,0 +1,295 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"clank/internal/models"
+)
+
+// How relationship endpoints that match no extracted entity ID are handled. Any
+// other policy, including none, repairs.
+const (
+	DanglingRepair     = "repair"     // Point them at the closest extracted entity, else drop the relationship
+	DanglingSynthesize = "synthesize" // As repair, but create an entity named after the reference instead of dropping
+	DanglingDrop       = "drop"       // Drop the relationship
+)
+
+const (
+	// minReferenceSimilarity is how alike a reference and an entity name must be,
+	// by edit distance relative to the longer, to count as the same entity
+	minReferenceSimilarity = 0.8
+	// minFuzzyReferenceLength is the shortest reference matched by edit distance;
+	// shorter ones, like initials, only match exactly
+	minFuzzyReferenceLength = 4
+	// synthesizedEntityType is given to synthesized entities when the taxonomy
+	// has no type for unknown entities
+	synthesizedEntityType = "organization"
+)
+
+// idLikeReference matches references that are entity IDs rather than names, e.g.
+// e7 or person_2, which can't name a synthesized entity
+var idLikeReference = regexp.MustCompile(`^[\pL]*[_-]?\d+$|_`)
+
+// repairReferences resolves relationship endpoints that aren't the ID of an
+// extracted entity. The LLM often gives a name instead of an ID, or misspells one;
+// such references are pointed at the entity whose ID, name, alias or mention text
+// is the same or closely matches. The rest are handled per mode, and the counts
+// recorded in result.References.
+func repairReferences(result *models.ExtractionResult, mode string) {
+	if result == nil || len(result.Relationships) == 0 {
+		return
+	}
+	resolver := newReferenceResolver(result.Entities)
+	report := &models.ReferenceReport{}
+	synthesized := make(map[string]string) // normalized reference -> synthesized entity ID
+
+	relationships := result.Relationships[:0]
+	for _, rel := range result.Relationships {
+		from, fromAction := resolver.endpoint(rel.FromID, mode)
+		to, toAction := resolver.endpoint(rel.ToID, mode)
+		if fromAction == referenceDropped || toAction == referenceDropped {
+			ref := rel.FromID
+			if fromAction != referenceDropped {
+				ref = rel.ToID
+			}
+			report.Dropped++
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"skipped %s relationship %s -> %s: %q matches no extracted entity", rel.Type, rel.FromID, rel.ToID, ref))
+			continue
+		}
+		// A repair that points both ends at one entity leaves nothing to link
+		bothSynthesized := fromAction == referenceSynthesized && toAction == referenceSynthesized
+		sameEnd := from == to && (fromAction == referenceSynthesized) == (toAction == referenceSynthesized)
+		if bothSynthesized {
+			sameEnd = normalizeEntityName(from) == normalizeEntityName(to)
+		}
+		if sameEnd && rel.FromID != rel.ToID {
+			report.Dropped++
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"skipped %s relationship %s -> %s: both ends are %s", rel.Type, rel.FromID, rel.ToID, from))
+			continue
+		}
+
+		for _, end := range []struct {
+			id     *string
+			ref    string
+			action referenceAction
+		}{{&rel.FromID, from, fromAction}, {&rel.ToID, to, toAction}} {
+			switch end.action {
+			case referenceRepaired:
+				*end.id = end.ref
+				report.Repaired++
+			case referenceSynthesized:
+				key := normalizeEntityName(end.ref)
+				id, exists := synthesized[key]
+				if !exists {
+					entity := synthesizeEntity(end.ref, rel, resolver.newID())
+					result.Entities = append(result.Entities, entity)
+					id = entity.ID
+					synthesized[key] = id
+				}
+				*end.id = id
+				report.Synthesized++
+			}
+		}
+		relationships = append(relationships, rel)
+	}
+	result.Relationships = relationships
+
+	if report.Repaired+report.Synthesized+report.Dropped > 0 {
+		result.References = report
+	}
+}
+
+// referenceResolver matches references against one extraction's entities
+type referenceResolver struct {
+	ids      map[string]bool
+	entities []models.ExtractedEntity
+	terms    [][]string // Normalized ID, name, aliases and mention texts per entity
+}
+
+func newReferenceResolver(entities []models.ExtractedEntity) *referenceResolver {
+	r := &referenceResolver{ids: make(map[string]bool), entities: entities, terms: make([][]string, len(entities))}
+	for i, entity := range entities {
+		r.ids[entity.ID] = true
+		r.terms[i] = append(entityTerms(entity), normalizeEntityName(entity.ID))
+	}
+	return r
+}
+
+// referenceAction is what is done with one relationship endpoint
+type referenceAction int
+
+const (
+	referenceKept referenceAction = iota
+	referenceRepaired
+	referenceSynthesized
+	referenceDropped
+)
+
+// endpoint decides what to do with the reference ref under mode, returning the
+// entity ID it resolves to when repaired
+func (r *referenceResolver) endpoint(ref, mode string) (string, referenceAction) {
+	if r.ids[ref] {
+		return ref, referenceKept
+	}
+	if mode != DanglingDrop {
+		if id, ok := r.resolve(ref); ok {
+			return id, referenceRepaired
+		}
+	}
+	if mode == DanglingSynthesize && isNameReference(ref) {
+		return ref, referenceSynthesized
+	}
+	return "", referenceDropped
+}
+
+// newID returns an entity ID not used in the extraction
+func (r *referenceResolver) newID() string {
+	for n := len(r.ids) + 1; ; n++ {
+		id := fmt.Sprintf("synthesized_%d", n)
+		if !r.ids[id] {
+			r.ids[id] = true
+			return id
+		}
+	}
+}
+
+// resolve returns the ID of the entity ref names: one with the same ID or term
+// ignoring case, else the single one with a term at least minReferenceSimilarity
+// alike, else the single one whose name contains every word of ref ("Smith" for
+// "John Smith")
+func (r *referenceResolver) resolve(ref string) (string, bool) {
+	term := normalizeEntityName(ref)
+	if term == "" {
+		return "", false
+	}
+	if i, ok := r.unique(func(candidate string) bool { return candidate == term }); ok {
+		return r.entities[i].ID, true
+	}
+
+	if len([]rune(term)) >= minFuzzyReferenceLength {
+		best, bestScore, tied := -1, 0.0, false
+		for i, terms := range r.terms {
+			score := 0.0
+			for _, candidate := range terms {
+				if s := similarity(term, candidate); s > score {
+					score = s
+				}
+			}
+			switch {
+			case score > bestScore:
+				best, bestScore, tied = i, score, false
+			case score == bestScore && best >= 0:
+				tied = true
+			}
+		}
+		if best >= 0 && bestScore >= minReferenceSimilarity && !tied {
+			return r.entities[best].ID, true
+		}
+	}
+
+	words := strings.Fields(term)
+	if i, ok := r.unique(func(candidate string) bool { return containsWords(candidate, words) }); ok {
+		return r.entities[i].ID, true
+	}
+	return "", false
+}
+
+// unique returns the only entity with a term satisfying match
+func (r *referenceResolver) unique(match func(string) bool) (int, bool) {
+	found := -1
+	for i, terms := range r.terms {
+		for _, candidate := range terms {
+			if match(candidate) {
+				if found >= 0 && found != i {
+					return -1, false
+				}
+				found = i
+				break
+			}
+		}
+	}
+	return found, found >= 0
+}
+
+// containsWords reports whether every word appears as a whole word in term
+func containsWords(term string, words []string) bool {
+	have := make(map[string]bool)
+	for _, word := range strings.Fields(term) {
+		have[word] = true
+	}
+	for _, word := range words {
+		if !have[word] {
+			return false
+		}
+	}
+	return len(words) > 0
+}
+
+// similarity is 1 minus the edit distance between a and b relative to the longer
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// isNameReference reports whether ref reads as a name an entity can be created for
+func isNameReference(ref string) bool {
+	ref = strings.TrimSpace(ref)
+	if !strings.ContainsFunc(ref, unicode.IsLetter) {
+		return false
+	}
+	return strings.Contains(ref, " ") || !idLikeReference.MatchString(ref)
+}
+
+// synthesizeEntity creates a minimal entity for a reference the LLM used in rel
+// but never extracted, marked synthesized and as confident as the relationship.
+// The mention is taken from the relationship's quote when the name appears in it.
+func synthesizeEntity(ref string, rel models.ExtractedRelationship, id string) models.ExtractedEntity {
+	name := strings.Join(strings.Fields(ref), " ")
+	entityType, err := models.NormalizeEntityType("unknown")
+	if err != nil {
+		entityType = synthesizedEntityType
+	}
+	entity := models.ExtractedEntity{
+		ID:          id,
+		Type:        entityType,
+		Name:        name,
+		Properties:  map[string]interface{}{"synthesized": true},
+		Confidence:  rel.Confidence,
+		ArticleID:   rel.ArticleID,
+		ExtractedAt: rel.ExtractedAt,
+	}
+	if strings.Contains(strings.ToLower(rel.Context), strings.ToLower(name)) {
+		entity.Mentions = []models.EntityMention{{Text: name, Context: rel.Context}}
+	}
+	return entity
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func danglingResult() *models.ExtractionResult {
+	return &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "person", Name: "Jane Kowalczyk", Confidence: 0.9},
+			{ID: "e2", Type: "company", Name: "Acme Construction", Confidence: 0.9},
+			{ID: "e3", Type: "government", Name: "Ministry of Transport", Confidence: 0.8},
+		},
+		Relationships: []models.ExtractedRelationship{
+			{ID: "r1", Type: "payment", FromID: "e2", ToID: "e1", Confidence: 0.8},
+			// Misspelled name instead of an ID
+			{ID: "r2", Type: "employment", FromID: "Jane Kowalczik", ToID: "e3", Confidence: 0.7},
+			// Name by case, and a surname alone
+			{ID: "r3", Type: "involvement", FromID: "acme construction", ToID: "Kowalczyk", Confidence: 0.6},
+			// An entity the LLM never extracted
+			{ID: "r4", Type: "ownership", FromID: "Harbor Holdings", ToID: "e2", Confidence: 0.75,
+				Context: "Acme Construction is owned by Harbor Holdings, a Cyprus firm."},
+		},
+	}
+}
+
+func TestRepairReferences_Repair(t *testing.T) {
+	result := danglingResult()
+
+	repairReferences(result, DanglingRepair)
+
+	require.Len(t, result.Relationships, 3)
+	assert.Equal(t, "e1", result.Relationships[1].FromID, "the misspelled name is repaired")
+	assert.Equal(t, "e3", result.Relationships[1].ToID)
+	assert.Equal(t, "e2", result.Relationships[2].FromID)
+	assert.Equal(t, "e1", result.Relationships[2].ToID)
+	assert.Len(t, result.Entities, 3)
+
+	require.NotNil(t, result.References)
+	assert.Equal(t, models.ReferenceReport{Repaired: 3, Dropped: 1}, *result.References)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "Harbor Holdings")
+}
+
+func TestRepairReferences_Synthesize(t *testing.T) {
+	result := danglingResult()
+	result.Relationships = append(result.Relationships,
+		models.ExtractedRelationship{ID: "r5", Type: "payment", FromID: "harbor holdings", ToID: "e3", Confidence: 0.5},
+		models.ExtractedRelationship{ID: "r6", Type: "payment", FromID: "e9", ToID: "e3", Confidence: 0.5},
+	)
+
+	repairReferences(result, DanglingSynthesize)
+
+	require.Len(t, result.Entities, 4)
+	harbor := result.Entities[3]
+	assert.Equal(t, "Harbor Holdings", harbor.Name)
+	assert.Equal(t, synthesizedEntityType, harbor.Type)
+	assert.Equal(t, true, harbor.Properties["synthesized"])
+	assert.Equal(t, 0.75, harbor.Confidence)
+	require.Len(t, harbor.Mentions, 1)
+	assert.Equal(t, "Acme Construction is owned by Harbor Holdings, a Cyprus firm.", harbor.Mentions[0].Context)
+
+	require.Len(t, result.Relationships, 5, "the ID-like reference e9 is still dropped")
+	assert.Equal(t, harbor.ID, result.Relationships[3].FromID)
+	assert.Equal(t, harbor.ID, result.Relationships[4].FromID, "one entity per name")
+	assert.Equal(t, models.ReferenceReport{Repaired: 3, Synthesized: 2, Dropped: 1}, *result.References)
+}
+
+func TestRepairReferences_Drop(t *testing.T) {
+	result := danglingResult()
+
+	repairReferences(result, DanglingDrop)
+
+	require.Len(t, result.Relationships, 1)
+	assert.Equal(t, "r1", result.Relationships[0].ID)
+	assert.Equal(t, models.ReferenceReport{Dropped: 3}, *result.References)
+	assert.Len(t, result.Warnings, 3)
+}
+
+func TestRepairReferences_Unrepairable(t *testing.T) {
+	result := &models.ExtractionResult{
+		Entities: []models.ExtractedEntity{
+			{ID: "e1", Type: "person", Name: "John Smith"},
+			{ID: "e2", Type: "person", Name: "Mary Smith"},
+			{ID: "e3", Type: "company", Name: "Smith Holdings"},
+		},
+		Relationships: []models.ExtractedRelationship{
+			// "Smith" could be any of them
+			{ID: "r1", Type: "family_of", FromID: "Smith", ToID: "e2"},
+			// Both ends resolve to John Smith
+			{ID: "r2", Type: "associated_with", FromID: "Jon Smith", ToID: "john smith"},
+			// Too short to match by edit distance
+			{ID: "r3", Type: "owns", FromID: "JS", ToID: "e3"},
+		},
+	}
+
+	repairReferences(result, DanglingRepair)
+
+	assert.Empty(t, result.Relationships)
+	assert.Equal(t, models.ReferenceReport{Dropped: 3}, *result.References)
+}
+
+func TestRepairReferences_NothingDangling(t *testing.T) {
+	result := danglingResult()
+	result.Relationships = result.Relationships[:1]
+
+	repairReferences(result, DanglingRepair)
+
+	assert.Len(t, result.Relationships, 1)
+	assert.Nil(t, result.References, "no report when every reference is an entity ID")
+	assert.Empty(t, result.Warnings)
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clank/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLimitedClient(url string, limit int) *Client {
+	cfg := &config.Config{}
+	cfg.LLM.URL = url
+	cfg.LLM.MaxResponseBytes = limit
+	return NewClient(cfg)
+}
+
+func TestClient_Generate_MaxResponseBytes(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		limit       int
+		expectError bool
+	}{
+		{name: "within limit", content: "short answer", limit: 1024},
+		{name: "oversized response", content: strings.Repeat("x", 4096), limit: 1024, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: tt.content}}})
+			}))
+			defer server.Close()
+
+			resp, err := newLimitedClient(server.URL, tt.limit).Generate(t.Context(), []Message{{Role: "user", Content: "hi"}})
+			if tt.expectError {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrResponseTooLarge)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.content, resp.Choices[0].Content)
+		})
+	}
+}
+
+func TestClient_GenerateStream_MaxResponseBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Emit far more content than the limit allows
+		for i := 0; i < 1000; i++ {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":\"%s\"}}]}\n\n", strings.Repeat("y", 100))
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	respChan := make(chan string, 2000)
+	err := newLimitedClient(server.URL, 1000).GenerateStream(t.Context(), []Message{{Role: "user", Content: "hi"}}, respChan)
+	close(respChan)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrResponseTooLarge)
+
+	total := 0
+	for chunk := range respChan {
+		total += len(chunk)
+	}
+	assert.LessOrEqual(t, total, 1000)
+}
+
+func TestNewClient_DefaultMaxResponseBytes(t *testing.T) {
+	client := NewClient(&config.Config{})
+	assert.Equal(t, DefaultMaxResponseBytes, client.maxResponseBytes)
+}
+
+func TestClient_ErrorBodyIsTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, strings.Repeat("z", 1<<20), http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := newLimitedClient(server.URL, 1024)
+
+	_, err := client.Generate(t.Context(), []Message{{Role: "user", Content: "hi"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+	assert.Less(t, len(err.Error()), 2*maxErrorBodyBytes)
+
+	err = client.GenerateStream(t.Context(), []Message{{Role: "user", Content: "hi"}}, make(chan string, 1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "500")
+	assert.Less(t, len(err.Error()), 2*maxErrorBodyBytes)
+}
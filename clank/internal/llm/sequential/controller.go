@@ -2,22 +2,33 @@ package sequential
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
 	"clank/internal/llm"
+	"clank/internal/logging"
+	"clank/internal/metrics"
 	"clank/internal/models"
 
 	"github.com/google/uuid"
 )
 
+// ErrInvalidResume is returned by StartAnalysis when a config's resume point can't be used
+var ErrInvalidResume = errors.New("invalid resume point")
+
+// ErrSessionNotFound is returned for a session ID the controller doesn't know
+var ErrSessionNotFound = errors.New("session not found")
+
 // AnalysisController manages sequential analysis sessions
 type AnalysisController struct {
 	llmClient *llm.Client
 	sessions  map[string]*AnalysisSession
 	mu        sync.RWMutex
-	stages    []AnalysisStageProcessor
+	stages    map[string]AnalysisStageProcessor
+	webhook   *Webhook
 }
 
 // NewAnalysisController creates a new analysis controller
@@ -25,41 +36,50 @@ func NewAnalysisController(llmClient *llm.Client) *AnalysisController {
 	controller := &AnalysisController{
 		llmClient: llmClient,
 		sessions:  make(map[string]*AnalysisSession),
-		stages: []AnalysisStageProcessor{
-			NewSurfaceExtractionStage().WithLLMClient(llmClient),
-			NewDeepAnalysisStage().WithLLMClient(llmClient),
-			NewCrossReferenceStage().WithLLMClient(llmClient),
-			NewHypothesisGenerationStage().WithLLMClient(llmClient),
-			NewRecursiveRefinementStage().WithLLMClient(llmClient),
-		},
+		stages:    newStageRegistry(llmClient),
 	}
 	return controller
 }
 
+// SetWebhook sets how sessions with a callback URL report their outcome. Without a
+// webhook, callback URLs are ignored.
+func (c *AnalysisController) SetWebhook(webhook *Webhook) {
+	c.webhook = webhook
+}
+
 // StartAnalysis starts a new sequential analysis session
 func (c *AnalysisController) StartAnalysis(ctx context.Context, article *models.Article, config *AnalysisConfig) (*AnalysisSession, error) {
+	stageNames, err := c.pipeline(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid analysis pipeline: %w", err)
+	}
+	if !ValidLowConfidencePolicy(config.LowConfidencePolicy) {
+		return nil, fmt.Errorf("unknown low confidence policy %q", config.LowConfidencePolicy)
+	}
+	if err := ValidateStageModels(config.StageModels); err != nil {
+		return nil, err
+	}
+
 	sessionID := uuid.New().String()
 
 	session := &AnalysisSession{
-		ID:         sessionID,
-		ArticleID:  article.ID,
-		Config:     config,
-		Status:     "running",
-		StartedAt:  time.Now(),
-		Evidence:   make([]Evidence, 0),
-		Hypotheses: make([]Hypothesis, 0),
-		Results:    make([]*models.ExtractionResult, 0),
-		Stages:     make([]*AnalysisStage, 0),
-	}
-
-	// Initialize stages based on depth
-	maxStages := config.MaxStages
-	if config.Depth < maxStages {
-		maxStages = config.Depth
+		ID:             sessionID,
+		ArticleID:      article.ID,
+		Config:         config,
+		Status:         "running",
+		StartedAt:      time.Now(),
+		Evidence:       make([]Evidence, 0),
+		EvidenceChains: make([]*EvidenceChain, 0),
+		Hypotheses:     make([]Hypothesis, 0),
+		Results:        make([]*models.ExtractionResult, 0),
+		Stages:         make([]*AnalysisStage, 0),
 	}
 
-	for i := 0; i < maxStages && i < len(c.stages); i++ {
-		processor := c.stages[i]
+	processors := make([]AnalysisStageProcessor, len(stageNames))
+	passes := make(map[string]int)
+	for i, name := range stageNames {
+		processor := c.stages[name]
+		processors[i] = processor
 		stage := &AnalysisStage{
 			Stage:       i + 1,
 			Name:        processor.GetName(),
@@ -68,19 +88,110 @@ func (c *AnalysisController) StartAnalysis(ctx context.Context, article *models.
 			Confidence:  0.0,
 			Insights:    make([]string, 0),
 		}
+		// Refinement passes beyond the first refine the previous pass's results
+		passes[name]++
+		if pass := passes[name]; pass > 1 {
+			stage.Name = fmt.Sprintf("%s (pass %d)", stage.Name, pass)
+		}
 		session.Stages = append(session.Stages, stage)
 	}
 
+	if config.ResumeSessionID != "" || config.ResumeFrom != "" {
+		if err := c.resume(session, stageNames); err != nil {
+			return nil, err
+		}
+	}
+
 	c.mu.Lock()
 	c.sessions[sessionID] = session
 	c.mu.Unlock()
 
 	// Start processing in background
-	go c.processSession(ctx, session, article)
+	go c.processSession(ctx, session, article, stageNames, processors)
 
 	return session, nil
 }
 
+// resume seeds session with the stages before Config.ResumeFrom, copied from the
+// completed session Config.ResumeSessionID of the same article, so they don't run
+// again. Every stage before the resume point, which includes the stages it depends
+// on, must have completed in that session.
+func (c *AnalysisController) resume(session *AnalysisSession, stageNames []string) error {
+	config := session.Config
+	if config.ResumeSessionID == "" || config.ResumeFrom == "" {
+		return fmt.Errorf("%w: resumeSessionId and resumeFrom must be set together", ErrInvalidResume)
+	}
+	from := slices.Index(stageNames, config.ResumeFrom)
+	if from < 0 {
+		return fmt.Errorf("%w: stage %q is not in the pipeline", ErrInvalidResume, config.ResumeFrom)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prior, exists := c.sessions[config.ResumeSessionID]
+	if !exists {
+		return fmt.Errorf("%w: session %s not found", ErrInvalidResume, config.ResumeSessionID)
+	}
+	// A session cut short by its total timeout can be resumed from where it stopped
+	if prior.Status != "completed" && prior.Status != "completed_partial" {
+		return fmt.Errorf("%w: session %s is %s, not completed", ErrInvalidResume, prior.ID, prior.Status)
+	}
+	if prior.ArticleID != session.ArticleID {
+		return fmt.Errorf("%w: session %s analyzed another article", ErrInvalidResume, prior.ID)
+	}
+	priorNames, err := c.pipeline(prior.Config)
+	if err != nil {
+		return fmt.Errorf("%w: session %s: %v", ErrInvalidResume, prior.ID, err)
+	}
+
+	// Stage numbers in the prior session mapped to their number in this one, for
+	// carrying over the evidence and hypotheses the reused stages produced
+	renumbered := make(map[int]int, from)
+	for i, name := range stageNames[:from] {
+		index := slices.Index(priorNames, name)
+		if index < 0 || index >= len(prior.Stages) || prior.Stages[index].Status != "completed" {
+			return fmt.Errorf("%w: session %s has no completed %q stage for %q to build on", ErrInvalidResume, prior.ID, name, config.ResumeFrom)
+		}
+
+		reused := *prior.Stages[index]
+		reused.Stage = i + 1
+		reused.Insights = append(slices.Clone(reused.Insights), fmt.Sprintf("Reused from session %s", prior.ID))
+		reused.Usage = nil // No tokens were spent on it in this session
+		session.Stages[i] = &reused
+		if reused.Results != nil {
+			session.Results = append(session.Results, reused.Results)
+		}
+		renumbered[prior.Stages[index].Stage] = reused.Stage
+	}
+
+	kept := make(map[string]bool)
+	for _, evidence := range prior.Evidence {
+		if stage, ok := renumbered[evidence.Stage]; ok {
+			evidence.Stage = stage
+			session.Evidence = append(session.Evidence, evidence)
+			kept[evidence.ID] = true
+		}
+	}
+	for _, chain := range prior.EvidenceChains {
+		if slices.ContainsFunc(chain.Evidence, func(id string) bool { return !kept[id] }) {
+			continue
+		}
+		// Later stages extend chains in place, so the prior session keeps its own
+		copied := *chain
+		copied.Evidence = slices.Clone(chain.Evidence)
+		copied.SourceURLs = slices.Clone(chain.SourceURLs)
+		session.EvidenceChains = append(session.EvidenceChains, &copied)
+	}
+	for _, hypothesis := range prior.Hypotheses {
+		if stage, ok := renumbered[hypothesis.Stage]; ok {
+			hypothesis.Stage = stage
+			session.Hypotheses = append(session.Hypotheses, hypothesis)
+		}
+	}
+	return nil
+}
+
 // GetSession retrieves a session by ID
 func (c *AnalysisController) GetSession(sessionID string) (*AnalysisSession, error) {
 	c.mu.RLock()
@@ -88,7 +199,7 @@ func (c *AnalysisController) GetSession(sessionID string) (*AnalysisSession, err
 
 	session, exists := c.sessions[sessionID]
 	if !exists {
-		return nil, fmt.Errorf("session not found")
+		return nil, ErrSessionNotFound
 	}
 
 	return session, nil
@@ -101,11 +212,12 @@ func (c *AnalysisController) TerminateSession(sessionID string) error {
 
 	session, exists := c.sessions[sessionID]
 	if !exists {
-		return fmt.Errorf("session not found")
+		return ErrSessionNotFound
 	}
 
 	if session.Status == "running" {
 		session.Status = "terminated"
+		session.EndReason = EndReasonTerminated
 		now := time.Now()
 		session.CompletedAt = &now
 	}
@@ -113,16 +225,39 @@ func (c *AnalysisController) TerminateSession(sessionID string) error {
 	return nil
 }
 
-// processSession processes all stages of an analysis session
-func (c *AnalysisController) processSession(ctx context.Context, session *AnalysisSession, article *models.Article) {
+// processSession processes all stages of an analysis session. stageNames are the
+// pipeline names of session.Stages, which carry their processors' display names.
+func (c *AnalysisController) processSession(ctx context.Context, session *AnalysisSession, article *models.Article, stageNames []string, processors []AnalysisStageProcessor) {
 	defer func() {
 		if session.Status == "running" {
 			session.Status = "completed"
+			session.EndReason = EndReasonCompleted
 			now := time.Now()
 			session.CompletedAt = &now
 		}
+		c.notify(ctx, session)
 	}()
 
+	// Stage contexts derive from the total budget, so running out of it also
+	// cancels the stage in flight
+	budgetCtx, cancelBudget := ctx, context.CancelFunc(func() {})
+	if session.Config.TotalTimeout > 0 {
+		budgetCtx, cancelBudget = context.WithDeadline(ctx, session.StartedAt.Add(session.Config.TotalTimeout))
+	}
+	defer cancelBudget()
+	if session.Config.PromptCache {
+		budgetCtx = llm.WithPromptCache(budgetCtx, llm.NewPromptCache())
+	}
+	budgetSpent := func() bool { return budgetCtx.Err() != nil && ctx.Err() == nil }
+	timedOut := func() {
+		c.endPartial(session, EndReasonTotalTimeout, fmt.Sprintf("total timeout of %s exceeded after %d of %d stages",
+			session.Config.TotalTimeout, completedStages(session), len(session.Stages)))
+	}
+
+	// Stages that fell below the threshold under LowConfidenceSkipDependents, and
+	// the stages skipped because of them
+	uncertain := make(map[string]bool)
+
 	for i, stage := range session.Stages {
 		// Check if session was terminated
 		c.mu.RLock()
@@ -132,26 +267,56 @@ func (c *AnalysisController) processSession(ctx context.Context, session *Analys
 		}
 		c.mu.RUnlock()
 
-		// Process stage with timeout
-		stageCtx, cancel := context.WithTimeout(ctx, session.Config.TimeoutPerStage)
+		// Stages reused from a resumed session already have their results
+		if stage.Status == "completed" {
+			continue
+		}
+		if budgetSpent() {
+			timedOut()
+			return
+		}
+		if dependency, ok := uncertainDependency(stageNames[i], uncertain); ok {
+			stage.Status = "skipped"
+			stage.Insights = append(stage.Insights,
+				fmt.Sprintf("Skipped: depends on %s, which fell below the confidence threshold", dependency))
+			uncertain[stageNames[i]] = true
+			continue
+		}
+
+		// Process stage with timeout, counting the tokens it uses
+		stageCtx, cancel := context.WithTimeout(budgetCtx, session.Config.TimeoutPerStage)
+		tracker := &llm.UsageTracker{}
+		stageCtx = llm.WithStage(llm.WithUsageTracker(stageCtx, tracker), stage.Name)
+		stageCtx = llm.WithModel(stageCtx, session.Config.StageModels[stageNames[i]])
 
 		now := time.Now()
 		stage.StartedAt = &now
 		stage.Status = "running"
 
-		processor := c.stages[i]
+		processor := processors[i]
 		err := processor.Process(stageCtx, session, stage, article, session.Results)
+		metrics.StageDuration.ObserveSince(now, stage.Name, metrics.Status(err))
 
 		cancel()
 
 		completedAt := time.Now()
 		stage.CompletedAt = &completedAt
+		usage := tracker.Usage()
+		stage.Usage = &usage
+		session.Usage.Add(usage)
 
+		if err != nil && budgetSpent() {
+			stage.Status = "cancelled"
+			stage.Error = err.Error()
+			timedOut()
+			return
+		}
 		if err != nil {
 			stage.Status = "failed"
 			stage.Error = err.Error()
 			session.Status = "failed"
 			session.Error = fmt.Sprintf("Stage %d failed: %v", stage.Stage, err)
+			session.EndReason = EndReasonStageFailed
 			return
 		}
 
@@ -164,13 +329,77 @@ func (c *AnalysisController) processSession(ctx context.Context, session *Analys
 
 		// Check confidence threshold
 		if stage.Confidence < session.Config.ConfidenceThreshold {
-			// Could continue or stop based on policy
-			// For now, continue but log low confidence
 			stage.Insights = append(stage.Insights,
 				fmt.Sprintf("Low confidence: %.2f (threshold: %.2f)",
 					stage.Confidence, session.Config.ConfidenceThreshold))
+
+			switch session.Config.LowConfidencePolicy {
+			case LowConfidenceStop:
+				c.endPartial(session, EndReasonLowConfidence, fmt.Sprintf("stopped after stage %d (%s): confidence %.2f below threshold %.2f",
+					stage.Stage, stageNames[i], stage.Confidence, session.Config.ConfidenceThreshold))
+				return
+			case LowConfidenceSkipDependents:
+				uncertain[stageNames[i]] = true
+			}
+		}
+	}
+}
+
+// uncertainDependency returns the first stage name depends on that is uncertain
+func uncertainDependency(name string, uncertain map[string]bool) (string, bool) {
+	for _, dependency := range stageDependencies[name] {
+		if uncertain[dependency] {
+			return dependency, true
+		}
+	}
+	return "", false
+}
+
+// completedStages counts the session's completed stages
+func completedStages(session *AnalysisSession) int {
+	completed := 0
+	for _, stage := range session.Stages {
+		if stage.Status == "completed" {
+			completed++
 		}
 	}
+	return completed
+}
+
+// endPartial finishes a session before all its stages ran, keeping the stages
+// that completed and marking those that never ran as skipped
+func (c *AnalysisController) endPartial(session *AnalysisSession, reason, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if session.Status != "running" {
+		return
+	}
+
+	for _, stage := range session.Stages {
+		if stage.Status == "pending" {
+			stage.Status = "skipped"
+		}
+	}
+	session.Status = "completed_partial"
+	session.Error = message
+	session.EndReason = reason
+	now := time.Now()
+	session.CompletedAt = &now
+}
+
+// notify posts the summary of a finished session to its callback URL. Delivery
+// failures are logged; they don't change the session.
+func (c *AnalysisController) notify(ctx context.Context, session *AnalysisSession) {
+	if c.webhook == nil || session.Config == nil || session.Config.CallbackURL == "" {
+		return
+	}
+	c.mu.RLock()
+	summary := Summarize(session)
+	c.mu.RUnlock()
+
+	if err := c.webhook.Deliver(ctx, session.Config.CallbackURL, summary); err != nil {
+		logging.For(ctx, "analysis").Warn("Webhook delivery failed", "session_id", session.ID, "error", err)
+	}
 }
 
 // ListSessions returns all sessions (you might want to add pagination)
@@ -1,7 +1,14 @@
 package sequential
 
 import (
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+
+	"clank/internal/models"
+
+	"github.com/google/uuid"
 )
 
 // Evidence represents a piece of evidence from the analysis
@@ -34,9 +41,11 @@ type Hypothesis struct {
 // EvidenceChain represents a sequence of connected evidence
 type EvidenceChain struct {
 	ID          string    `json:"id"`
+	Claim       string    `json:"claim"` // The claim the evidence supports
 	Description string    `json:"description"`
-	Evidence    []string  `json:"evidence"` // Ordered list of evidence IDs
-	Strength    float64   `json:"strength"` // Overall chain strength
+	Evidence    []string  `json:"evidence"`   // Ordered list of evidence IDs
+	SourceURLs  []string  `json:"sourceUrls"` // Articles the supporting quotes come from
+	Strength    float64   `json:"strength"`   // Overall chain strength
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 }
@@ -51,3 +60,110 @@ type CrossReference struct {
 	Strength    float64   `json:"strength"`
 	CreatedAt   time.Time `json:"createdAt"`
 }
+
+// defaultEvidenceClaimConfidence is the confidence a claim needs to get an evidence
+// chain when the session sets no confidence threshold
+const defaultEvidenceClaimConfidence = 0.7
+
+// SupportedClaim is a claim returned by a stage together with the article quotes supporting it
+type SupportedClaim struct {
+	Claim      string   `json:"claim"`
+	Confidence float64  `json:"confidence"`
+	Quotes     []string `json:"quotes"`
+}
+
+// addEvidenceChains records each high-confidence claim's quotes as evidence and
+// links them in one chain per claim. A claim seen again, in a later stage or from
+// another article, extends its existing chain: new quotes and source URLs are
+// added and the chain strength combines the confidences (1 - Π(1 - c)). Evidence
+// confidences are weighted by the session's source reliability.
+func addEvidenceChains(session *AnalysisSession, stage *AnalysisStage, article *models.Article, claims []SupportedClaim) {
+	threshold := defaultEvidenceClaimConfidence
+	if session.Config != nil && session.Config.ConfidenceThreshold > 0 {
+		threshold = session.Config.ConfidenceThreshold
+	}
+
+	chains := make(map[string]*EvidenceChain, len(session.EvidenceChains))
+	for _, chain := range session.EvidenceChains {
+		chains[claimKey(chain.Claim)] = chain
+	}
+	evidenceIDs := make(map[string]string, len(session.Evidence))
+	for _, evidence := range session.Evidence {
+		evidenceIDs[evidence.Source+"|"+evidence.Text] = evidence.ID
+	}
+
+	// Claims are kept by their own confidence; the evidence for them counts for
+	// as much as the article's source is reliable
+	reliability := 1.0
+	if session.Config != nil && session.Config.SourceReliability > 0 {
+		reliability = session.Config.SourceReliability
+	}
+
+	now := time.Now()
+	for _, claim := range claims {
+		claimText := strings.Join(strings.Fields(claim.Claim), " ")
+		if claimText == "" || claim.Confidence < threshold {
+			continue
+		}
+
+		var supporting []string
+		for _, quote := range claim.Quotes {
+			quote = strings.TrimSpace(quote)
+			if quote == "" {
+				continue
+			}
+			key := article.URL + "|" + quote
+			id, exists := evidenceIDs[key]
+			if !exists {
+				id = uuid.New().String()
+				evidenceIDs[key] = id
+				session.Evidence = append(session.Evidence, Evidence{
+					ID:         id,
+					Type:       "quote",
+					Stage:      stage.Stage,
+					Text:       quote,
+					Context:    claimText,
+					Source:     article.URL,
+					Confidence: claim.Confidence * reliability,
+					CreatedAt:  now,
+				})
+			}
+			supporting = append(supporting, id)
+		}
+		if len(supporting) == 0 {
+			continue
+		}
+
+		chain, exists := chains[claimKey(claimText)]
+		if !exists {
+			chain = &EvidenceChain{
+				ID:          uuid.New().String(),
+				Claim:       claimText,
+				Description: fmt.Sprintf("Evidence supporting: %s", claimText),
+				CreatedAt:   now,
+			}
+			chains[claimKey(claimText)] = chain
+			session.EvidenceChains = append(session.EvidenceChains, chain)
+		}
+		chain.Evidence = appendUnique(chain.Evidence, supporting...)
+		if article.URL != "" {
+			chain.SourceURLs = appendUnique(chain.SourceURLs, article.URL)
+		}
+		chain.Strength = 1 - (1-chain.Strength)*(1-min(max(claim.Confidence*reliability, 0), 1))
+		chain.UpdatedAt = now
+	}
+}
+
+// claimKey normalizes a claim for deduplication
+func claimKey(claim string) string {
+	return strings.ToLower(strings.Join(strings.Fields(claim), " "))
+}
+
+func appendUnique(values []string, additions ...string) []string {
+	for _, addition := range additions {
+		if !slices.Contains(values, addition) {
+			values = append(values, addition)
+		}
+	}
+	return values
+}
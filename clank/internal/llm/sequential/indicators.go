@@ -0,0 +1,89 @@
+package sequential
+
+import (
+	"strings"
+
+	"clank/internal/models"
+)
+
+// indicatorCategories maps the corruption_indicators keys returned by the
+// refinement stage to indicator flags
+var indicatorCategories = map[string]string{
+	"financial_irregularities": models.IndicatorFinancialIrregularity,
+	"financial_irregularity":   models.IndicatorFinancialIrregularity,
+	"conflict_of_interest":     models.IndicatorConflictOfInterest,
+	"conflicts_of_interest":    models.IndicatorConflictOfInterest,
+	"abuse_of_power":           models.IndicatorAbuseOfPower,
+	"lack_of_transparency":     models.IndicatorLackOfTransparency,
+}
+
+// applyIndicatorFlags turns the refinement stage's free-text corruption indicators
+// into flags on the entities and relationships they describe. An entity is flagged
+// when an indicator mentions its name, alias or ID; a relationship when an indicator
+// mentions its ID or both of its endpoints. Only flags listed in enabled are
+// recorded; an empty list enables them all.
+func applyIndicatorFlags(result *models.ExtractionResult, indicators map[string][]string, enabled []string) {
+	allowed := make(map[string]bool)
+	for _, flag := range enabled {
+		allowed[flag] = true
+	}
+
+	entityTerms := make(map[string][]string, len(result.Entities))
+	for _, entity := range result.Entities {
+		entityTerms[entity.ID] = indicatorTerms(entity)
+	}
+
+	for category, descriptions := range indicators {
+		flag, ok := indicatorCategories[strings.ToLower(category)]
+		if !ok || (len(allowed) > 0 && !allowed[flag]) {
+			continue
+		}
+
+		for _, description := range descriptions {
+			text := strings.ToLower(description)
+			mentioned := make(map[string]bool)
+			for i := range result.Entities {
+				entity := &result.Entities[i]
+				if mentionsAny(text, entityTerms[entity.ID]) {
+					mentioned[entity.ID] = true
+					entity.Indicators = addIndicator(entity.Indicators, flag)
+				}
+			}
+			for i := range result.Relationships {
+				rel := &result.Relationships[i]
+				if (rel.ID != "" && strings.Contains(text, strings.ToLower(rel.ID))) || (mentioned[rel.FromID] && mentioned[rel.ToID]) {
+					rel.Indicators = addIndicator(rel.Indicators, flag)
+				}
+			}
+		}
+	}
+}
+
+// indicatorTerms are the lowercase strings that identify an entity in indicator text
+func indicatorTerms(entity models.ExtractedEntity) []string {
+	var terms []string
+	for _, term := range append([]string{entity.Name, entity.ID}, entity.Aliases...) {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func mentionsAny(text string, terms []string) bool {
+	for _, term := range terms {
+		if strings.Contains(text, term) {
+			return true
+		}
+	}
+	return false
+}
+
+func addIndicator(indicators []string, flag string) []string {
+	for _, existing := range indicators {
+		if existing == flag {
+			return indicators
+		}
+	}
+	return append(indicators, flag)
+}
@@ -0,0 +1,120 @@
+package sequential
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"clank/internal/llm"
+)
+
+// Stage names accepted in AnalysisConfig.Stages
+const (
+	StageSurfaceExtraction    = "surface_extraction"
+	StageDeepAnalysis         = "deep_analysis"
+	StageCrossReference       = "cross_reference"
+	StageHypothesisGeneration = "hypothesis_generation"
+	StageRecursiveRefinement  = "recursive_refinement"
+)
+
+// DefaultPipeline is the stage order used when a config names no stages. A Depth
+// below its length truncates it from the end; a Depth above it adds recursive
+// refinement passes.
+var DefaultPipeline = []string{
+	StageSurfaceExtraction,
+	StageDeepAnalysis,
+	StageCrossReference,
+	StageHypothesisGeneration,
+	StageRecursiveRefinement,
+}
+
+// stageDependencies lists the stages that must run earlier in a pipeline. Every
+// later stage builds on the entities found by surface extraction.
+var stageDependencies = map[string][]string{
+	StageSurfaceExtraction:    nil,
+	StageDeepAnalysis:         {StageSurfaceExtraction},
+	StageCrossReference:       {StageSurfaceExtraction},
+	StageHypothesisGeneration: {StageSurfaceExtraction},
+	StageRecursiveRefinement:  {StageSurfaceExtraction},
+}
+
+// newStageRegistry creates the built-in stage processors keyed by stage name
+func newStageRegistry(llmClient *llm.Client) map[string]AnalysisStageProcessor {
+	return map[string]AnalysisStageProcessor{
+		StageSurfaceExtraction:    NewSurfaceExtractionStage().WithLLMClient(llmClient),
+		StageDeepAnalysis:         NewDeepAnalysisStage().WithLLMClient(llmClient),
+		StageCrossReference:       NewCrossReferenceStage().WithLLMClient(llmClient),
+		StageHypothesisGeneration: NewHypothesisGenerationStage().WithLLMClient(llmClient),
+		StageRecursiveRefinement:  NewRecursiveRefinementStage().WithLLMClient(llmClient),
+	}
+}
+
+// ValidatePipeline checks that every stage name is known, listed once, and runs
+// after the stages it depends on. An empty list selects DefaultPipeline.
+func ValidatePipeline(stages []string) error {
+	seen := make(map[string]bool, len(stages))
+	for _, name := range stages {
+		dependencies, known := stageDependencies[name]
+		if !known {
+			return fmt.Errorf("unknown analysis stage %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("analysis stage %q is listed more than once", name)
+		}
+		for _, dependency := range dependencies {
+			if !seen[dependency] {
+				return fmt.Errorf("analysis stage %q requires %q to run before it", name, dependency)
+			}
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// ValidateStageModels checks that every stage given a model override is a known
+// stage and the model is named
+func ValidateStageModels(models map[string]string) error {
+	for name, model := range models {
+		if _, known := stageDependencies[name]; !known {
+			return fmt.Errorf("model override for unknown analysis stage %q", name)
+		}
+		if strings.TrimSpace(model) == "" {
+			return fmt.Errorf("empty model override for analysis stage %q", name)
+		}
+	}
+	return nil
+}
+
+// MaxDepth is the largest Depth honored; deeper configs run this many stages
+const MaxDepth = 10
+
+// pipeline resolves the names of the stages a session runs, in order. An explicit
+// Stages list runs as given. Otherwise Depth is the number of stages run:
+// DefaultPipeline, truncated by Depth and MaxStages, followed when it runs in full
+// by one more recursive refinement pass per level of Depth beyond it, up to MaxDepth.
+func (c *AnalysisController) pipeline(config *AnalysisConfig) ([]string, error) {
+	if len(config.Stages) > 0 {
+		if err := ValidatePipeline(config.Stages); err != nil {
+			return nil, err
+		}
+		return config.Stages, nil
+	}
+
+	maxStages := config.MaxStages
+	if config.Depth < maxStages {
+		maxStages = config.Depth
+	}
+	if maxStages > len(DefaultPipeline) {
+		maxStages = len(DefaultPipeline)
+	}
+	if maxStages < 0 {
+		maxStages = 0
+	}
+	stages := slices.Clone(DefaultPipeline[:maxStages])
+	if maxStages == len(DefaultPipeline) {
+		for range min(config.Depth, MaxDepth) - maxStages {
+			stages = append(stages, StageRecursiveRefinement)
+		}
+	}
+	return stages, nil
+}
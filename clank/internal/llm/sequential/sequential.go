@@ -13,6 +13,7 @@ import (
 // SurfaceExtractionStage handles initial entity and relationship extraction
 type SurfaceExtractionStage struct {
 	llmClient *llm.Client
+	prompts   PromptRenderer
 }
 
 func NewSurfaceExtractionStage() *SurfaceExtractionStage {
@@ -26,6 +27,10 @@ func (s *SurfaceExtractionStage) WithLLMClient(client *llm.Client) AnalysisStage
 	return s
 }
 
+func (s *SurfaceExtractionStage) setPrompts(renderer PromptRenderer) {
+	s.prompts = renderer
+}
+
 func (s *SurfaceExtractionStage) GetName() string {
 	return "Surface Extraction"
 }
@@ -40,7 +45,12 @@ func (s *SurfaceExtractionStage) Process(ctx context.Context, session *AnalysisS
 		return fmt.Errorf("LLM client not initialized")
 	}
 
-	prompt := fmt.Sprintf(`Perform initial entity extraction from this article. Focus on identifying:
+	prompt, err := renderStagePrompt(ctx, s.prompts, surfaceExtractionPrompt, map[string]any{
+		"url":     article.URL,
+		"title":   article.Title,
+		"content": article.Content,
+	}, func() string {
+		return fmt.Sprintf(`Perform initial entity extraction from this article. Focus on identifying:
 
 1. PEOPLE: Names, roles, positions, organizations they're affiliated with
 2. ORGANIZATIONS: Companies, government agencies, institutions
@@ -90,6 +100,11 @@ Extract entities and relationships in this JSON format:
   ],
   "confidence": 0.0-1.0
 }`, article.URL, article.Title, article.Content)
+	})
+	if err != nil {
+		return err
+	}
+	prompt += s.llmClient.TemporalInstructions()
 
 	// Use LLM to extract entities
 	messages := []llm.Message{
@@ -127,7 +142,7 @@ Extract entities and relationships in this JSON format:
 
 	// Parse the response
 	var result models.ExtractionResult
-	if err := json.Unmarshal([]byte(content), &result); err != nil {
+	if err := s.llmClient.DecodeJSON(ctx, content, &result); err != nil {
 		return fmt.Errorf("failed to parse LLM response: %w", err)
 	}
 
@@ -156,6 +171,7 @@ Extract entities and relationships in this JSON format:
 // DeepAnalysisStage performs deeper analysis of extracted entities
 type DeepAnalysisStage struct {
 	llmClient *llm.Client
+	prompts   PromptRenderer
 }
 
 func NewDeepAnalysisStage() *DeepAnalysisStage {
@@ -169,6 +185,10 @@ func (s *DeepAnalysisStage) WithLLMClient(client *llm.Client) AnalysisStageProce
 	return s
 }
 
+func (s *DeepAnalysisStage) setPrompts(renderer PromptRenderer) {
+	s.prompts = renderer
+}
+
 func (s *DeepAnalysisStage) GetName() string {
 	return "Deep Analysis"
 }
@@ -187,9 +207,13 @@ func (s *DeepAnalysisStage) Process(ctx context.Context, session *AnalysisSessio
 	// Serialize previous results for analysis
 	prevData, _ := json.Marshal(lastResult)
 
-	prompt := fmt.Sprintf(`Perform deep analysis of the extracted entities and relationships. Focus on:
+	prompt, err := renderStagePrompt(ctx, s.prompts, deepAnalysisPrompt, map[string]any{
+		"previous_results": string(prevData),
+		"content":          article.Content,
+	}, func() string {
+		return fmt.Sprintf(`Perform deep analysis of the extracted entities and relationships. Focus on:
 
-1. ROLE ANALYSIS: What roles do people play? Are they victims, perpetrators, investigators, witnesses?
+1. ROLE ANALYSIS: What roles do people play? Are they victims, perpetrators, investigators, witnesses? Record the role each entity played in an event as the "role" of its involvement relationship to that event.
 2. RELATIONSHIP STRENGTH: How strong/direct are the connections? What's the evidence quality?
 3. MOTIVATION ANALYSIS: What might motivate the relationships and actions described?
 4. PATTERN RECOGNITION: Do you see corruption patterns, conflict of interest, quid pro quo?
@@ -230,7 +254,8 @@ Provide enhanced analysis in this JSON format:
         "corruption_indicators": ["red", "flags", "identified"],
         "pattern_match": "type of corruption pattern if any",
         "evidence_quality": "high|medium|low",
-        "timeline_importance": "critical|important|minor"
+        "timeline_importance": "critical|important|minor",
+        "role": "for involvement in an event: perpetrator|victim|investigator|witness|beneficiary"
       },
       "confidence": 0.0-1.0,
       "context": "same_or_enhanced"
@@ -240,6 +265,10 @@ Provide enhanced analysis in this JSON format:
   "patterns": ["corruption patterns identified"],
   "confidence": 0.0-1.0
 }`, string(prevData), article.Content)
+	})
+	if err != nil {
+		return err
+	}
 
 	messages := []llm.Message{
 		{Role: "system", Content: "You are an expert corruption analyst with deep knowledge of corruption patterns, power dynamics, and investigative techniques."},
@@ -260,7 +289,7 @@ Provide enhanced analysis in this JSON format:
 		Confidence    float64                        `json:"confidence"`
 	}
 
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &analysisResult); err != nil {
+	if err := s.llmClient.DecodeJSON(ctx, resp.Choices[0].Message.Content, &analysisResult); err != nil {
 		return fmt.Errorf("failed to parse analysis response: %w", err)
 	}
 
@@ -281,6 +310,7 @@ Provide enhanced analysis in this JSON format:
 // CrossReferenceStage validates consistency and identifies conflicts
 type CrossReferenceStage struct {
 	llmClient *llm.Client
+	prompts   PromptRenderer
 }
 
 func NewCrossReferenceStage() *CrossReferenceStage {
@@ -294,6 +324,10 @@ func (s *CrossReferenceStage) WithLLMClient(client *llm.Client) AnalysisStagePro
 	return s
 }
 
+func (s *CrossReferenceStage) setPrompts(renderer PromptRenderer) {
+	s.prompts = renderer
+}
+
 func (s *CrossReferenceStage) GetName() string {
 	return "Cross-Reference Validation"
 }
@@ -314,7 +348,11 @@ func (s *CrossReferenceStage) Process(ctx context.Context, session *AnalysisSess
 	// Compare the last two results
 	prevData, _ := json.Marshal(previousResults)
 
-	prompt := fmt.Sprintf(`Cross-reference and validate the analysis results for consistency and accuracy:
+	prompt, err := renderStagePrompt(ctx, s.prompts, crossReferencePrompt, map[string]any{
+		"previous_results": string(prevData),
+		"content":          article.Content,
+	}, func() string {
+		return fmt.Sprintf(`Cross-reference and validate the analysis results for consistency and accuracy:
 
 Previous analysis stages:
 %s
@@ -349,8 +387,19 @@ Return validation results in JSON format:
   ],
   "validated_entities": [], // corrected entities
   "validated_relationships": [], // corrected relationships
+  "supported_claims": [
+    {
+      "claim": "a claim the article supports",
+      "confidence": 0.0-1.0,
+      "quotes": ["exact quote from the article supporting the claim"]
+    }
+  ],
   "confidence": 0.0-1.0
 }`, string(prevData), article.Content)
+	})
+	if err != nil {
+		return err
+	}
 
 	messages := []llm.Message{
 		{Role: "system", Content: "You are a meticulous fact-checker and validation expert specializing in corruption investigations."},
@@ -374,10 +423,11 @@ Return validation results in JSON format:
 		IssuesFound            []map[string]interface{}       `json:"issues_found"`
 		ValidatedEntities      []models.ExtractedEntity       `json:"validated_entities"`
 		ValidatedRelationships []models.ExtractedRelationship `json:"validated_relationships"`
+		SupportedClaims        []SupportedClaim               `json:"supported_claims"`
 		Confidence             float64                        `json:"confidence"`
 	}
 
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &validation); err != nil {
+	if err := s.llmClient.DecodeJSON(ctx, resp.Choices[0].Message.Content, &validation); err != nil {
 		return fmt.Errorf("failed to parse validation response: %w", err)
 	}
 
@@ -387,6 +437,8 @@ Return validation results in JSON format:
 		Confidence:    validation.Confidence,
 	}
 
+	addEvidenceChains(session, stage, article, validation.SupportedClaims)
+
 	stage.Results = result
 	stage.Confidence = result.Confidence
 	stage.Insights = []string{
@@ -401,6 +453,7 @@ Return validation results in JSON format:
 // HypothesisGenerationStage generates possible explanations and theories
 type HypothesisGenerationStage struct {
 	llmClient *llm.Client
+	prompts   PromptRenderer
 }
 
 func NewHypothesisGenerationStage() *HypothesisGenerationStage {
@@ -414,6 +467,10 @@ func (s *HypothesisGenerationStage) WithLLMClient(client *llm.Client) AnalysisSt
 	return s
 }
 
+func (s *HypothesisGenerationStage) setPrompts(renderer PromptRenderer) {
+	s.prompts = renderer
+}
+
 func (s *HypothesisGenerationStage) GetName() string {
 	return "Hypothesis Generation"
 }
@@ -425,7 +482,11 @@ func (s *HypothesisGenerationStage) GetDescription() string {
 func (s *HypothesisGenerationStage) Process(ctx context.Context, session *AnalysisSession, stage *AnalysisStage, article *models.Article, previousResults []*models.ExtractionResult) error {
 	allResults, _ := json.Marshal(previousResults)
 
-	prompt := fmt.Sprintf(`Based on the analysis results, generate hypotheses and identify gaps in information:
+	prompt, err := renderStagePrompt(ctx, s.prompts, hypothesisGenerationPrompt, map[string]any{
+		"previous_results": string(allResults),
+		"content":          article.Content,
+	}, func() string {
+		return fmt.Sprintf(`Based on the analysis results, generate hypotheses and identify gaps in information:
 
 Analysis results so far:
 %s
@@ -458,6 +519,10 @@ Generate hypotheses and analysis in JSON format:
   "follow_up_questions": ["Questions that should be investigated further"],
   "confidence": 0.0-1.0
 }`, string(allResults), article.Content)
+	})
+	if err != nil {
+		return err
+	}
 
 	messages := []llm.Message{
 		{Role: "system", Content: "You are an investigative analyst expert at generating theories and identifying information gaps in corruption cases."},
@@ -476,7 +541,7 @@ Generate hypotheses and analysis in JSON format:
 		Confidence         float64                  `json:"confidence"`
 	}
 
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &hypothesesResult); err != nil {
+	if err := s.llmClient.DecodeJSON(ctx, resp.Choices[0].Message.Content, &hypothesesResult); err != nil {
 		return fmt.Errorf("failed to parse hypotheses response: %w", err)
 	}
 
@@ -499,6 +564,7 @@ Generate hypotheses and analysis in JSON format:
 // RecursiveRefinementStage performs additional refinement passes
 type RecursiveRefinementStage struct {
 	llmClient *llm.Client
+	prompts   PromptRenderer
 }
 
 func NewRecursiveRefinementStage() *RecursiveRefinementStage {
@@ -512,6 +578,10 @@ func (s *RecursiveRefinementStage) WithLLMClient(client *llm.Client) AnalysisSta
 	return s
 }
 
+func (s *RecursiveRefinementStage) setPrompts(renderer PromptRenderer) {
+	s.prompts = renderer
+}
+
 func (s *RecursiveRefinementStage) GetName() string {
 	return "Recursive Refinement"
 }
@@ -528,7 +598,11 @@ func (s *RecursiveRefinementStage) Process(ctx context.Context, session *Analysi
 		"evidence":         session.Evidence,
 	})
 
-	prompt := fmt.Sprintf(`Perform final synthesis and refinement of the complete analysis:
+	prompt, err := renderStagePrompt(ctx, s.prompts, recursiveRefinementPrompt, map[string]any{
+		"analysis_data": string(allData),
+		"content":       article.Content,
+	}, func() string {
+		return fmt.Sprintf(`Perform final synthesis and refinement of the complete analysis:
 
 Complete analysis data:
 %s
@@ -560,8 +634,19 @@ JSON format:
     "evidence_strength": "strong|moderate|weak"
   },
   "next_steps": ["Recommended follow-up actions"],
+  "supported_claims": [
+    {
+      "claim": "a high-confidence conclusion",
+      "confidence": 0.0-1.0,
+      "quotes": ["exact quote from the article supporting the claim"]
+    }
+  ],
   "confidence": 0.0-1.0
 }`, string(allData), article.Content)
+	})
+	if err != nil {
+		return err
+	}
 
 	messages := []llm.Message{
 		{Role: "system", Content: "You are a senior investigative analyst providing final synthesis of a complex corruption analysis."},
@@ -580,10 +665,11 @@ JSON format:
 		CorruptionIndicators map[string][]string            `json:"corruption_indicators"`
 		ConfidenceAssessment map[string]interface{}         `json:"confidence_assessment"`
 		NextSteps            []string                       `json:"next_steps"`
+		SupportedClaims      []SupportedClaim               `json:"supported_claims"`
 		Confidence           float64                        `json:"confidence"`
 	}
 
-	if err := json.Unmarshal([]byte(resp.Choices[0].Message.Content), &finalResult); err != nil {
+	if err := s.llmClient.DecodeJSON(ctx, resp.Choices[0].Message.Content, &finalResult); err != nil {
 		return fmt.Errorf("failed to parse final refinement response: %w", err)
 	}
 
@@ -593,6 +679,14 @@ JSON format:
 		Confidence:    finalResult.Confidence,
 	}
 
+	// Record the indicators as flags on the entities and relationships they name
+	var enabledFlags []string
+	if session.Config != nil {
+		enabledFlags = session.Config.IndicatorFlags
+	}
+	applyIndicatorFlags(result, finalResult.CorruptionIndicators, enabledFlags)
+	addEvidenceChains(session, stage, article, finalResult.SupportedClaims)
+
 	stage.Results = result
 	stage.Confidence = result.Confidence
 	stage.Insights = append(finalResult.KeyInsights, finalResult.NextSteps...)
@@ -2,11 +2,22 @@ package sequential
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"clank/config"
 	"clank/internal/llm"
 	"clank/internal/models"
+	"clank/internal/prompts"
 	"clank/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -18,7 +29,6 @@ func TestAnalysisController_StartAnalysis(t *testing.T) {
 		name           string
 		config         *AnalysisConfig
 		mockResponses  []string
-		mockErrors     []error
 		expectedStages int
 		expectError    bool
 	}{
@@ -40,32 +50,23 @@ func TestAnalysisController_StartAnalysis(t *testing.T) {
 			expectedStages: 3,
 		},
 		{
-			name: "analysis with error",
+			// Stages run in the background, so only config errors are returned
+			name: "analysis with invalid config",
 			config: &AnalysisConfig{
 				Depth:               2,
 				MaxStages:           3,
 				ConfidenceThreshold: 0.7,
 				TimeoutPerStage:     time.Second,
+				LowConfidencePolicy: "retry-forever",
 			},
-			mockResponses: []string{`{"result": {"entities": []}}`},
-			mockErrors:    []error{assert.AnError},
+			mockResponses: []string{`{"entities": []}`},
 			expectError:   true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create mocks
-			mockLLM := testutil.NewMockLLMClient()
-
-			// Setup mock responses
-			mockLLM.Responses = tt.mockResponses
-			if len(tt.mockErrors) > 0 {
-				mockLLM.GenerateError = tt.mockErrors[0]
-			}
-
-			// Create controller with mock
-			controller := NewAnalysisController(&llm.Client{})
+			controller := NewAnalysisController(stubLLMClient(t, tt.mockResponses...))
 
 			// Create test article
 			article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
@@ -89,19 +90,17 @@ func TestAnalysisStages_Process(t *testing.T) {
 	tests := []struct {
 		name         string
 		mockResponse string
-		mockError    error
+		mockStatus   int
 		expectError  bool
 		checkStage   func(*testing.T, *AnalysisStage)
 	}{
 		{
 			name: "surface extraction stage",
 			mockResponse: `{
-				"result": {
-					"entities": [
-						{"type": "person", "name": "John Doe"},
-						{"type": "company", "name": "Acme Corp"}
-					]
-				}
+				"entities": [
+					{"type": "person", "name": "John Doe"},
+					{"type": "company", "name": "Acme Corp"}
+				]
 			}`,
 			checkStage: func(t *testing.T, stage *AnalysisStage) {
 				require.NotNil(t, stage.Results)
@@ -113,11 +112,9 @@ func TestAnalysisStages_Process(t *testing.T) {
 		{
 			name: "deep analysis stage",
 			mockResponse: `{
-				"result": {
-					"relationships": [
-						{"type": "owns", "from": "John Doe", "to": "Acme Corp"}
-					]
-				}
+				"relationships": [
+					{"type": "owns", "fromId": "John Doe", "toId": "Acme Corp"}
+				]
 			}`,
 			checkStage: func(t *testing.T, stage *AnalysisStage) {
 				require.NotNil(t, stage.Results)
@@ -127,17 +124,17 @@ func TestAnalysisStages_Process(t *testing.T) {
 		},
 		{
 			name:        "stage with error",
-			mockError:   assert.AnError,
+			mockStatus:  http.StatusInternalServerError,
 			expectError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Create mock LLM client
-			mockLLM := testutil.NewMockLLMClient()
-			mockLLM.GenerateResponse = tt.mockResponse
-			mockLLM.GenerateError = tt.mockError
+			client := stubLLMClient(t, tt.mockResponse)
+			if tt.mockStatus != 0 {
+				client = failingLLMClient(t, tt.mockStatus)
+			}
 
 			// Create test article
 			article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
@@ -160,7 +157,7 @@ func TestAnalysisStages_Process(t *testing.T) {
 			}
 
 			// Create processor
-			processor := NewSurfaceExtractionStage().WithLLMClient(&llm.Client{})
+			processor := NewSurfaceExtractionStage().WithLLMClient(client)
 
 			// Process stage
 			err := processor.Process(context.Background(), session, stage, article, nil)
@@ -177,3 +174,797 @@ func TestAnalysisStages_Process(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyIndicatorFlags(t *testing.T) {
+	newResult := func() *models.ExtractionResult {
+		return &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Name: "Jane Smith", Aliases: []string{"Councillor Smith"}},
+				{ID: "e2", Name: "Acme Construction"},
+				{ID: "e3", Name: "City Council"},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{ID: "r1", FromID: "e1", ToID: "e2"},
+				{ID: "r2", FromID: "e3", ToID: "e2"},
+			},
+		}
+	}
+	indicators := map[string][]string{
+		"conflict_of_interest":     {"Councillor Smith voted on a contract for Acme Construction, her brother's firm"},
+		"financial_irregularities": {"City Council paid invoices without receipts"},
+		"lack_of_transparency":     {"Relationship r2 was never disclosed"},
+		"unrelated_category":       {"Jane Smith"},
+	}
+
+	t.Run("all flags enabled", func(t *testing.T) {
+		result := newResult()
+		applyIndicatorFlags(result, indicators, nil)
+
+		assert.Equal(t, []string{models.IndicatorConflictOfInterest}, result.Entities[0].Indicators)
+		assert.Equal(t, []string{models.IndicatorConflictOfInterest}, result.Entities[1].Indicators)
+		assert.Equal(t, []string{models.IndicatorFinancialIrregularity}, result.Entities[2].Indicators)
+		assert.Equal(t, []string{models.IndicatorConflictOfInterest}, result.Relationships[0].Indicators)
+		assert.Equal(t, []string{models.IndicatorLackOfTransparency}, result.Relationships[1].Indicators)
+	})
+
+	t.Run("only configured flags", func(t *testing.T) {
+		result := newResult()
+		applyIndicatorFlags(result, indicators, []string{models.IndicatorConflictOfInterest})
+
+		assert.Equal(t, []string{models.IndicatorConflictOfInterest}, result.Entities[0].Indicators)
+		assert.Empty(t, result.Entities[2].Indicators)
+		assert.Empty(t, result.Relationships[1].Indicators)
+	})
+}
+
+// stubLLMClient returns a client whose completions are the given responses, in order
+func stubLLMClient(t *testing.T, responses ...string) *llm.Client {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := responses[min(calls, len(responses)-1)]
+		calls++
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}}}})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	return llm.NewClient(cfg)
+}
+
+// failingLLMClient returns a client whose completions all fail with status
+func failingLLMClient(t *testing.T, status int) *llm.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", status)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	return llm.NewClient(cfg)
+}
+
+func TestEvidenceChains_CrossReferenceAndRefinement(t *testing.T) {
+	crossReference := `{
+		"validation_results": {"consistency_score": 0.9},
+		"supported_claims": [
+			{"claim": "The mayor awarded the contract to his brother's firm", "confidence": 0.9,
+			 "quotes": ["Mayor Doe signed the contract with Doe Construction", "Doe Construction is owned by the mayor's brother"]},
+			{"claim": "The council was unaware", "confidence": 0.4, "quotes": ["Council members said they were not told"]},
+			{"claim": "Unsupported claim", "confidence": 0.95, "quotes": []}
+		],
+		"confidence": 0.8
+	}`
+	refinement := `{
+		"key_insights": [],
+		"supported_claims": [
+			{"claim": "the mayor  awarded the contract to his brother's firm", "confidence": 0.8,
+			 "quotes": ["Doe Construction is owned by the mayor's brother", "The bid was never advertised"]}
+		],
+		"confidence": 0.85
+	}`
+
+	session := &AnalysisSession{ID: "s1", Config: &AnalysisConfig{ConfidenceThreshold: 0.7}}
+	article := &models.Article{ID: "a1", URL: "https://example.com/contract", Content: "..."}
+	previous := []*models.ExtractionResult{{}, {}}
+
+	crossStage := &AnalysisStage{Stage: 3}
+	require.NoError(t, NewCrossReferenceStage().WithLLMClient(stubLLMClient(t, crossReference)).
+		Process(context.Background(), session, crossStage, article, previous))
+
+	require.Len(t, session.EvidenceChains, 1, "low-confidence and unquoted claims get no chain")
+	chain := session.EvidenceChains[0]
+	assert.Equal(t, "The mayor awarded the contract to his brother's firm", chain.Claim)
+	assert.Len(t, chain.Evidence, 2)
+	assert.Equal(t, []string{"https://example.com/contract"}, chain.SourceURLs)
+	assert.InDelta(t, 0.9, chain.Strength, 1e-9)
+	require.Len(t, session.Evidence, 2)
+	assert.Equal(t, "quote", session.Evidence[0].Type)
+	assert.Equal(t, 3, session.Evidence[0].Stage)
+	assert.Equal(t, article.URL, session.Evidence[0].Source)
+
+	refineStage := &AnalysisStage{Stage: 5}
+	require.NoError(t, NewRecursiveRefinementStage().WithLLMClient(stubLLMClient(t, refinement)).
+		Process(context.Background(), session, refineStage, article, previous))
+
+	// The same claim extends its chain instead of adding a second one
+	require.Len(t, session.EvidenceChains, 1)
+	assert.Len(t, chain.Evidence, 3, "the repeated quote is not added twice")
+	assert.Len(t, session.Evidence, 3)
+	assert.InDelta(t, 0.98, chain.Strength, 1e-9)
+
+	evidenceByID := make(map[string]Evidence)
+	for _, evidence := range session.Evidence {
+		evidenceByID[evidence.ID] = evidence
+	}
+	for _, id := range chain.Evidence {
+		assert.Contains(t, evidenceByID, id, "chains only reference recorded evidence")
+	}
+}
+
+func TestEvidenceChains_SourceReliability(t *testing.T) {
+	claims := []SupportedClaim{{Claim: "The mayor awarded the contract to his brother's firm", Confidence: 0.9,
+		Quotes: []string{"Mayor Doe signed the contract with Doe Construction"}}}
+	article := &models.Article{ID: "a1", URL: "https://tabloid.example/contract"}
+
+	session := &AnalysisSession{Config: &AnalysisConfig{ConfidenceThreshold: 0.7, SourceReliability: 0.5}}
+	addEvidenceChains(session, &AnalysisStage{Stage: 3}, article, claims)
+
+	require.Len(t, session.EvidenceChains, 1, "the claim is kept by its own confidence")
+	assert.InDelta(t, 0.45, session.EvidenceChains[0].Strength, 1e-9)
+	require.Len(t, session.Evidence, 1)
+	assert.InDelta(t, 0.45, session.Evidence[0].Confidence, 1e-9)
+
+	unweighted := &AnalysisSession{Config: &AnalysisConfig{ConfidenceThreshold: 0.7}}
+	addEvidenceChains(unweighted, &AnalysisStage{Stage: 3}, article, claims)
+	assert.InDelta(t, 0.9, unweighted.EvidenceChains[0].Strength, 1e-9)
+}
+
+// capturingLLMClient returns a client answering every request with response and
+// records the user message of each request
+func capturingLLMClient(t *testing.T, response string) (*llm.Client, *[]string) {
+	t.Helper()
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, msg := range req.Messages {
+			if msg.Role == "user" {
+				prompts = append(prompts, msg.Content)
+			}
+		}
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: response}}}})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	return llm.NewClient(cfg), &prompts
+}
+
+func writePromptFile(t *testing.T, dir string, prompt models.Prompt) {
+	t.Helper()
+	data, err := json.Marshal(prompt)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, prompt.Name+".json"), data, 0o644))
+}
+
+func processAllStages(t *testing.T, controller *AnalysisController) {
+	t.Helper()
+	article := testutil.MockArticle("https://example.com/contract", "Council contract", "The mayor signed the contract.")
+	session := &AnalysisSession{ID: "s1", Config: &AnalysisConfig{}}
+	previous := []*models.ExtractionResult{{Confidence: 0.5}, {Confidence: 0.6}}
+	for _, name := range DefaultPipeline {
+		processor := controller.stages[name]
+		err := processor.Process(context.Background(), session, &AnalysisStage{}, article, previous)
+		require.NoError(t, err, processor.GetName())
+	}
+}
+
+func TestStagePrompts_DefaultFilesMatchBuiltin(t *testing.T) {
+	builtinClient, builtinPrompts := capturingLLMClient(t, `{"confidence": 0.8}`)
+	processAllStages(t, NewAnalysisController(builtinClient))
+
+	loader := prompts.NewPromptLoader(filepath.Join("..", "..", "..", "prompts"))
+	require.NoError(t, loader.LoadPrompts())
+	fileClient, filePrompts := capturingLLMClient(t, `{"confidence": 0.8}`)
+	controller := NewAnalysisController(fileClient)
+	controller.SetPromptRenderer(loader)
+	processAllStages(t, controller)
+
+	require.Len(t, *builtinPrompts, 5)
+	assert.Equal(t, *builtinPrompts, *filePrompts)
+}
+
+func TestStagePrompts_RenderFromLoader(t *testing.T) {
+	dir := t.TempDir()
+	writePromptFile(t, dir, models.Prompt{
+		Name: surfaceExtractionPrompt,
+		Arguments: []models.PromptArgument{
+			{Name: "url", Type: "string"}, {Name: "title", Type: "string"}, {Name: "content", Type: "string"},
+		},
+		Template: "Extract entities from {{{title}}} ({{{url}}}):\n{{{content}}}",
+	})
+	loader := prompts.NewPromptLoader(dir)
+	require.NoError(t, loader.LoadPrompts())
+
+	client, captured := capturingLLMClient(t, `{"entities": [], "confidence": 0.8}`)
+	stage := NewSurfaceExtractionStage()
+	stage.WithLLMClient(client)
+	stage.setPrompts(loader)
+
+	article := testutil.MockArticle("https://example.com/a", "Contract vote", "The council voted.")
+	require.NoError(t, stage.Process(context.Background(), &AnalysisSession{}, &AnalysisStage{}, article, nil))
+
+	require.Len(t, *captured, 1)
+	assert.Equal(t, "Extract entities from Contract vote (https://example.com/a):\nThe council voted.", (*captured)[0])
+}
+
+func TestSurfaceExtractionStage_AutoRepairJSON(t *testing.T) {
+	responses := []string{
+		`{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe"}] "confidence": 0.7}`,
+		`{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe"}], "confidence": 0.7}`,
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := responses[min(calls, len(responses)-1)]
+		calls++
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}}}})
+	}))
+	defer server.Close()
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.AutoRepairJSON = true
+
+	stage := &AnalysisStage{}
+	article := testutil.MockArticle("https://example.com/a", "Contract vote", "Jane Doe voted.")
+	err := NewSurfaceExtractionStage().WithLLMClient(llm.NewClient(cfg)).Process(context.Background(), &AnalysisSession{}, stage, article, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "one extraction and one repair pass")
+	require.NotNil(t, stage.Results)
+	require.Len(t, stage.Results.Entities, 1)
+	assert.Equal(t, "Jane Doe", stage.Results.Entities[0].Name)
+	assert.Equal(t, 0.7, stage.Confidence)
+}
+
+func TestStagePrompts_MissingTemplate(t *testing.T) {
+	loader := prompts.NewPromptLoader(t.TempDir())
+	require.NoError(t, loader.LoadPrompts())
+
+	client, captured := capturingLLMClient(t, `{}`)
+	stage := NewSurfaceExtractionStage()
+	stage.WithLLMClient(client)
+	stage.setPrompts(loader)
+
+	article := testutil.MockArticle("https://example.com/a", "Contract vote", "The council voted.")
+	err := stage.Process(context.Background(), &AnalysisSession{}, &AnalysisStage{}, article, nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `stage prompt "surface_extraction"`)
+	assert.Contains(t, err.Error(), "prompt not found")
+	assert.Empty(t, *captured)
+}
+
+func TestAnalysisController_CustomPipeline(t *testing.T) {
+	client := stubLLMClient(t, `{"entities": [], "hypotheses": [], "confidence": 0.8}`)
+	controller := NewAnalysisController(client)
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+	session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+		Depth:           5,
+		MaxStages:       5,
+		TimeoutPerStage: time.Second,
+		Stages:          []string{StageSurfaceExtraction, StageHypothesisGeneration},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, session.Stages, 2)
+	assert.Equal(t, "Surface Extraction", session.Stages[0].Name)
+	assert.Equal(t, NewHypothesisGenerationStage().GetName(), session.Stages[1].Name)
+	require.Eventually(t, func() bool {
+		controller.mu.RLock()
+		defer controller.mu.RUnlock()
+		return session.Status != "running"
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "completed", session.Status, session.Error)
+}
+
+func TestAnalysisController_StageModels(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req llm.GenerateRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requested = append(requested, req.Model)
+		mu.Unlock()
+		json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: `{"entities": [], "hypotheses": [], "confidence": 0.8}`}}}})
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	cfg.LLM.Model = "default-model"
+	controller := NewAnalysisController(llm.NewClient(cfg))
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+	session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+		TimeoutPerStage: time.Second,
+		Stages:          []string{StageSurfaceExtraction, StageDeepAnalysis, StageHypothesisGeneration},
+		StageModels:     map[string]string{StageDeepAnalysis: "large-model"},
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		controller.mu.RLock()
+		defer controller.mu.RUnlock()
+		return session.Status != "running"
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, "completed", session.Status, session.Error)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"default-model", "large-model", "default-model"}, requested)
+
+	_, err = controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+		StageModels: map[string]string{"quick_scan": "large-model"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown analysis stage "quick_scan"`)
+}
+
+// promptStage is a stage processor that sends the same prompt on every run
+type promptStage struct {
+	name   string
+	client *llm.Client
+}
+
+func (s *promptStage) GetName() string        { return s.name }
+func (s *promptStage) GetDescription() string { return s.name }
+
+func (s *promptStage) WithLLMClient(client *llm.Client) AnalysisStageProcessor { return s }
+
+func (s *promptStage) Process(ctx context.Context, session *AnalysisSession, stage *AnalysisStage, article *models.Article, previousResults []*models.ExtractionResult) error {
+	if _, err := s.client.Generate(ctx, []llm.Message{{Role: "user", Content: "Summarize: " + article.Content}}); err != nil {
+		return err
+	}
+	stage.Results = &models.ExtractionResult{}
+	stage.Confidence = 0.9
+	return nil
+}
+
+func TestAnalysisController_PromptCache(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		cache bool
+		calls int32
+	}{
+		{"cached session", true, 1},
+		{"uncached session", false, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				json.NewEncoder(w).Encode(llm.Response{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "{}"}}}})
+			}))
+			defer server.Close()
+			cfg := &config.Config{}
+			cfg.LLM.URL = server.URL
+			client := llm.NewClient(cfg)
+
+			controller := NewAnalysisController(client)
+			names := []string{StageSurfaceExtraction, StageDeepAnalysis}
+			session := &AnalysisSession{
+				ID:     "s1",
+				Config: &AnalysisConfig{TimeoutPerStage: time.Second, PromptCache: tt.cache},
+				Status: "running",
+				Stages: []*AnalysisStage{{Stage: 1, Name: names[0]}, {Stage: 2, Name: names[1]}},
+			}
+			article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+			controller.processSession(context.Background(), session, article, names, []AnalysisStageProcessor{
+				&promptStage{name: names[0], client: client},
+				&promptStage{name: names[1], client: client},
+			})
+
+			require.Equal(t, "completed", session.Status, session.Error)
+			assert.Equal(t, tt.calls, calls.Load())
+		})
+	}
+}
+
+func TestAnalysisController_DefaultPipelineTruncatedByDepth(t *testing.T) {
+	controller := NewAnalysisController(stubLLMClient(t, `{"confidence": 0.8}`))
+	names, err := controller.pipeline(&AnalysisConfig{Depth: 2, MaxStages: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []string{StageSurfaceExtraction, StageDeepAnalysis}, names)
+}
+
+func TestAnalysisController_DepthAddsRefinementPasses(t *testing.T) {
+	controller := NewAnalysisController(stubLLMClient(t, `{"confidence": 0.8}`))
+	refinements := func(n int) []string {
+		return append(slices.Clone(DefaultPipeline), slices.Repeat([]string{StageRecursiveRefinement}, n)...)
+	}
+
+	tests := []struct {
+		name   string
+		config AnalysisConfig
+		want   []string
+	}{
+		{"depth matches the pipeline", AnalysisConfig{Depth: 5, MaxStages: 5}, refinements(0)},
+		{"deeper than the pipeline", AnalysisConfig{Depth: 7, MaxStages: 5}, refinements(2)},
+		{"capped at MaxDepth", AnalysisConfig{Depth: 12, MaxStages: 5}, refinements(MaxDepth - len(DefaultPipeline))},
+		{"truncated by MaxStages", AnalysisConfig{Depth: 7, MaxStages: 3}, DefaultPipeline[:3]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names, err := controller.pipeline(&tt.config)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	t.Run("session runs every pass", func(t *testing.T) {
+		controller := NewAnalysisController(&llm.Client{})
+		stages := make(map[string]*confidenceStage, len(DefaultPipeline))
+		for _, name := range DefaultPipeline {
+			stages[name] = &confidenceStage{name: name, confidence: 0.9}
+			controller.stages[name] = stages[name]
+		}
+		article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+		session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+			Depth:           7,
+			MaxStages:       5,
+			TimeoutPerStage: 5 * time.Second,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			controller.mu.RLock()
+			defer controller.mu.RUnlock()
+			return session.Status != "running"
+		}, 2*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, "completed", session.Status, session.Error)
+		require.Len(t, session.Stages, 7)
+		assert.Equal(t, "recursive refinement", session.Stages[4].Name)
+		assert.Equal(t, "recursive refinement (pass 2)", session.Stages[5].Name)
+		assert.Equal(t, "recursive refinement (pass 3)", session.Stages[6].Name)
+		assert.Equal(t, 7, session.Stages[6].Stage)
+		assert.Equal(t, 3, stages[StageRecursiveRefinement].calls, "two passes beyond the pipeline's own")
+		assert.Equal(t, 1, stages[StageHypothesisGeneration].calls)
+		assert.Len(t, session.Results, 7)
+	})
+}
+
+func TestValidatePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  []string
+		wantErr string
+	}{
+		{name: "default", stages: nil},
+		{name: "reordered", stages: []string{StageSurfaceExtraction, StageHypothesisGeneration, StageDeepAnalysis}},
+		{name: "unknown stage", stages: []string{StageSurfaceExtraction, "sentiment"}, wantErr: `unknown analysis stage "sentiment"`},
+		{name: "missing dependency", stages: []string{StageDeepAnalysis}, wantErr: `requires "surface_extraction"`},
+		{name: "dependency after dependent", stages: []string{StageCrossReference, StageSurfaceExtraction}, wantErr: `requires "surface_extraction"`},
+		{name: "duplicate stage", stages: []string{StageSurfaceExtraction, StageSurfaceExtraction}, wantErr: "more than once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipeline(tt.stages)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestAnalysisController_RejectsInvalidPipeline(t *testing.T) {
+	controller := NewAnalysisController(stubLLMClient(t, `{}`))
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+	_, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{Stages: []string{"quick_scan"}})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown analysis stage "quick_scan"`)
+	assert.Empty(t, controller.ListSessions())
+}
+
+// countingStage is a stage processor that records how often it runs and how many
+// previous results it was given
+type countingStage struct {
+	name     string
+	calls    int
+	previous int
+}
+
+func (s *countingStage) GetName() string        { return s.name }
+func (s *countingStage) GetDescription() string { return s.name }
+
+func (s *countingStage) WithLLMClient(client *llm.Client) AnalysisStageProcessor { return s }
+
+func (s *countingStage) Process(ctx context.Context, session *AnalysisSession, stage *AnalysisStage, article *models.Article, previousResults []*models.ExtractionResult) error {
+	s.calls++
+	s.previous = len(previousResults)
+	stage.Results = &models.ExtractionResult{Entities: []models.ExtractedEntity{{Name: s.name}}}
+	stage.Confidence = 0.9
+	session.Evidence = append(session.Evidence, Evidence{ID: s.name, Stage: stage.Stage})
+	return nil
+}
+
+func TestAnalysisController_ResumeFrom(t *testing.T) {
+	controller := NewAnalysisController(&llm.Client{})
+	counters := make(map[string]*countingStage, len(DefaultPipeline))
+	for _, name := range DefaultPipeline {
+		counters[name] = &countingStage{name: name}
+		controller.stages[name] = counters[name]
+	}
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+	finished := func(session *AnalysisSession) func() bool {
+		return func() bool {
+			controller.mu.RLock()
+			defer controller.mu.RUnlock()
+			return session.Status != "running"
+		}
+	}
+
+	shallow, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{Depth: 2, MaxStages: 5, TimeoutPerStage: time.Second})
+	require.NoError(t, err)
+	require.Eventually(t, finished(shallow), 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, "completed", shallow.Status, shallow.Error)
+
+	deeper, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+		Depth:           4,
+		MaxStages:       5,
+		TimeoutPerStage: time.Second,
+		ResumeSessionID: shallow.ID,
+		ResumeFrom:      StageCrossReference,
+	})
+	require.NoError(t, err)
+	require.Eventually(t, finished(deeper), 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, "completed", deeper.Status, deeper.Error)
+
+	assert.Equal(t, 1, counters[StageSurfaceExtraction].calls)
+	assert.Equal(t, 1, counters[StageDeepAnalysis].calls)
+	assert.Equal(t, 1, counters[StageCrossReference].calls)
+	assert.Equal(t, 1, counters[StageHypothesisGeneration].calls)
+	assert.Equal(t, 2, counters[StageCrossReference].previous, "reused results seed previousResults")
+
+	require.Len(t, deeper.Stages, 4)
+	require.Len(t, deeper.Results, 4)
+	assert.Equal(t, StageSurfaceExtraction, deeper.Results[0].Entities[0].Name)
+	assert.Nil(t, deeper.Stages[0].Usage)
+	assert.Contains(t, deeper.Stages[0].Insights, "Reused from session "+shallow.ID)
+	assert.NotContains(t, shallow.Stages[0].Insights, "Reused from session "+shallow.ID)
+	assert.Len(t, deeper.Evidence, 4)
+	assert.Len(t, shallow.Evidence, 2)
+
+	t.Run("invalid resume points", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			config  AnalysisConfig
+			wantErr string
+		}{
+			{name: "unknown session", config: AnalysisConfig{Depth: 4, MaxStages: 5, ResumeSessionID: "missing", ResumeFrom: StageCrossReference}, wantErr: "not found"},
+			{name: "stage outside pipeline", config: AnalysisConfig{Depth: 2, MaxStages: 5, ResumeSessionID: shallow.ID, ResumeFrom: StageCrossReference}, wantErr: "not in the pipeline"},
+			{name: "earlier stage never ran", config: AnalysisConfig{Depth: 4, MaxStages: 5, ResumeSessionID: shallow.ID, ResumeFrom: StageHypothesisGeneration}, wantErr: `no completed "cross_reference" stage`},
+			{name: "stage without session", config: AnalysisConfig{Depth: 4, MaxStages: 5, ResumeFrom: StageCrossReference}, wantErr: "must be set together"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := controller.StartAnalysis(context.Background(), article, &tt.config)
+				require.ErrorIs(t, err, ErrInvalidResume)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			})
+		}
+
+		other := testutil.MockArticle("https://example.com/other", "Other", "Other content")
+		_, err := controller.StartAnalysis(context.Background(), other, &AnalysisConfig{Depth: 4, MaxStages: 5, ResumeSessionID: shallow.ID, ResumeFrom: StageCrossReference})
+		require.ErrorIs(t, err, ErrInvalidResume)
+	})
+}
+
+// slowStage is a stage processor that takes delay to finish, or until its context
+// ends when it honors cancellation
+type slowStage struct {
+	name        string
+	delay       time.Duration
+	cancellable bool
+	calls       int
+}
+
+func (s *slowStage) GetName() string        { return s.name }
+func (s *slowStage) GetDescription() string { return s.name }
+
+func (s *slowStage) WithLLMClient(client *llm.Client) AnalysisStageProcessor { return s }
+
+func (s *slowStage) Process(ctx context.Context, session *AnalysisSession, stage *AnalysisStage, article *models.Article, previousResults []*models.ExtractionResult) error {
+	s.calls++
+	if s.cancellable {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.delay):
+		}
+	} else {
+		time.Sleep(s.delay)
+	}
+	stage.Results = &models.ExtractionResult{Entities: []models.ExtractedEntity{{Name: s.name}}}
+	stage.Confidence = 0.8
+	return nil
+}
+
+func TestAnalysisController_TotalTimeout(t *testing.T) {
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+	run := func(t *testing.T, stages map[string]AnalysisStageProcessor) *AnalysisSession {
+		t.Helper()
+		controller := NewAnalysisController(&llm.Client{})
+		for name, stage := range stages {
+			controller.stages[name] = stage
+		}
+		session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+			Depth:           3,
+			MaxStages:       5,
+			TimeoutPerStage: 5 * time.Second,
+			TotalTimeout:    100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			controller.mu.RLock()
+			defer controller.mu.RUnlock()
+			return session.Status != "running"
+		}, 2*time.Second, 10*time.Millisecond)
+		return session
+	}
+
+	t.Run("cancels the stage in flight", func(t *testing.T) {
+		last := &countingStage{name: StageCrossReference}
+		session := run(t, map[string]AnalysisStageProcessor{
+			StageSurfaceExtraction: &countingStage{name: StageSurfaceExtraction},
+			StageDeepAnalysis:      &slowStage{name: StageDeepAnalysis, delay: time.Minute, cancellable: true},
+			StageCrossReference:    last,
+		})
+
+		assert.Equal(t, "completed_partial", session.Status)
+		assert.Contains(t, session.Error, "1 of 3 stages")
+		assert.NotNil(t, session.CompletedAt)
+		assert.Equal(t, "completed", session.Stages[0].Status)
+		assert.Equal(t, "cancelled", session.Stages[1].Status)
+		assert.Equal(t, "skipped", session.Stages[2].Status)
+		assert.Zero(t, last.calls)
+
+		require.Len(t, session.Results, 1, "results of completed stages are kept")
+		assert.Equal(t, StageSurfaceExtraction, session.Results[0].Entities[0].Name)
+		assert.Len(t, session.Evidence, 1)
+	})
+
+	t.Run("stops between stages", func(t *testing.T) {
+		next := &countingStage{name: StageDeepAnalysis}
+		session := run(t, map[string]AnalysisStageProcessor{
+			// Finishes past the deadline without looking at its context
+			StageSurfaceExtraction: &slowStage{name: StageSurfaceExtraction, delay: 150 * time.Millisecond},
+			StageDeepAnalysis:      next,
+		})
+
+		assert.Equal(t, "completed_partial", session.Status)
+		assert.Equal(t, "completed", session.Stages[0].Status)
+		assert.Equal(t, "skipped", session.Stages[1].Status)
+		assert.Equal(t, "skipped", session.Stages[2].Status)
+		assert.Zero(t, next.calls)
+		assert.Len(t, session.Results, 1)
+	})
+}
+
+// confidenceStage is a stage processor that completes with a fixed confidence
+type confidenceStage struct {
+	name       string
+	confidence float64
+	calls      int
+}
+
+// GetName returns a display name unlike the pipeline name, as the built-in stages do
+func (s *confidenceStage) GetName() string        { return strings.ReplaceAll(s.name, "_", " ") }
+func (s *confidenceStage) GetDescription() string { return s.name }
+
+func (s *confidenceStage) WithLLMClient(client *llm.Client) AnalysisStageProcessor { return s }
+
+func (s *confidenceStage) Process(ctx context.Context, session *AnalysisSession, stage *AnalysisStage, article *models.Article, previousResults []*models.ExtractionResult) error {
+	s.calls++
+	stage.Results = &models.ExtractionResult{Entities: []models.ExtractedEntity{{Name: s.name}}}
+	stage.Confidence = s.confidence
+	return nil
+}
+
+func TestAnalysisController_LowConfidencePolicy(t *testing.T) {
+	// Hypothesis generation builds on deep analysis for this test, so the
+	// uncertain middle stage has a dependent and an independent stage after it
+	saved := stageDependencies[StageHypothesisGeneration]
+	stageDependencies[StageHypothesisGeneration] = []string{StageSurfaceExtraction, StageDeepAnalysis}
+	defer func() { stageDependencies[StageHypothesisGeneration] = saved }()
+
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+	pipeline := []string{StageSurfaceExtraction, StageDeepAnalysis, StageCrossReference, StageHypothesisGeneration, StageRecursiveRefinement}
+
+	run := func(t *testing.T, policy string) (*AnalysisSession, map[string]*confidenceStage) {
+		t.Helper()
+		controller := NewAnalysisController(&llm.Client{})
+		stages := make(map[string]*confidenceStage, len(pipeline))
+		for _, name := range pipeline {
+			stages[name] = &confidenceStage{name: name, confidence: 0.9}
+			controller.stages[name] = stages[name]
+		}
+		stages[StageDeepAnalysis].confidence = 0.3
+
+		session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+			Stages:              pipeline,
+			ConfidenceThreshold: 0.6,
+			TimeoutPerStage:     5 * time.Second,
+			LowConfidencePolicy: policy,
+		})
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			controller.mu.RLock()
+			defer controller.mu.RUnlock()
+			return session.Status != "running"
+		}, 2*time.Second, 10*time.Millisecond)
+		return session, stages
+	}
+	statuses := func(session *AnalysisSession) []string {
+		var out []string
+		for _, stage := range session.Stages {
+			out = append(out, stage.Status)
+		}
+		return out
+	}
+
+	t.Run("continue", func(t *testing.T) {
+		for _, policy := range []string{"", LowConfidenceContinue} {
+			session, stages := run(t, policy)
+
+			assert.Equal(t, "completed", session.Status)
+			assert.Equal(t, EndReasonCompleted, session.EndReason)
+			assert.Equal(t, []string{"completed", "completed", "completed", "completed", "completed"}, statuses(session))
+			assert.Contains(t, session.Stages[1].Insights, "Low confidence: 0.30 (threshold: 0.60)")
+			assert.Equal(t, 1, stages[StageRecursiveRefinement].calls)
+			assert.Len(t, session.Results, 5)
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		session, stages := run(t, LowConfidenceStop)
+
+		assert.Equal(t, "completed_partial", session.Status)
+		assert.Equal(t, EndReasonLowConfidence, session.EndReason)
+		assert.Equal(t, "stopped after stage 2 (deep_analysis): confidence 0.30 below threshold 0.60", session.Error)
+		assert.Equal(t, []string{"completed", "completed", "skipped", "skipped", "skipped"}, statuses(session))
+		assert.Zero(t, stages[StageCrossReference].calls)
+		assert.Len(t, session.Results, 2, "the uncertain stage's results are kept")
+		assert.NotNil(t, session.CompletedAt)
+	})
+
+	t.Run("skip-dependents", func(t *testing.T) {
+		session, stages := run(t, LowConfidenceSkipDependents)
+
+		assert.Equal(t, "completed", session.Status)
+		assert.Equal(t, EndReasonCompleted, session.EndReason)
+		assert.Equal(t, []string{"completed", "completed", "completed", "skipped", "completed"}, statuses(session))
+		assert.Zero(t, stages[StageHypothesisGeneration].calls)
+		assert.Equal(t, 1, stages[StageRecursiveRefinement].calls, "stages not depending on it still run")
+		assert.Contains(t, session.Stages[3].Insights[0], "depends on deep_analysis")
+	})
+
+	t.Run("unknown policy", func(t *testing.T) {
+		controller := NewAnalysisController(&llm.Client{})
+		_, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{LowConfidencePolicy: "retry"})
+		assert.Error(t, err)
+	})
+}
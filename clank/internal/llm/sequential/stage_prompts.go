@@ -0,0 +1,59 @@
+package sequential
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clank/internal/models"
+	"clank/internal/prompts"
+)
+
+// Prompt names each stage renders when the controller has a PromptRenderer.
+// The default templates live in prompts/<name>.json.
+const (
+	surfaceExtractionPrompt    = StageSurfaceExtraction
+	deepAnalysisPrompt         = StageDeepAnalysis
+	crossReferencePrompt       = StageCrossReference
+	hypothesisGenerationPrompt = StageHypothesisGeneration
+	recursiveRefinementPrompt  = StageRecursiveRefinement
+)
+
+// PromptRenderer renders named prompt templates; *prompts.PromptLoader implements it
+type PromptRenderer interface {
+	RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error)
+}
+
+// stagePrompter is implemented by stages whose prompt can come from a PromptRenderer
+type stagePrompter interface {
+	setPrompts(renderer PromptRenderer)
+}
+
+// SetPromptRenderer makes every stage render its prompt from renderer instead of
+// the built-in text. A nil renderer restores the built-in prompts.
+func (c *AnalysisController) SetPromptRenderer(renderer PromptRenderer) {
+	for _, stage := range c.stages {
+		if prompter, ok := stage.(stagePrompter); ok {
+			prompter.setPrompts(renderer)
+		}
+	}
+}
+
+// renderStagePrompt renders the named template with arguments, in the version
+// pinned on ctx by prompts.WithVersions if any, or returns builtin() when no
+// renderer is configured. A renderer without the template is an error rather
+// than a silent fallback, so a missing or misnamed prompt file is noticed.
+func renderStagePrompt(ctx context.Context, renderer PromptRenderer, name string, arguments map[string]any, builtin func() string) (string, error) {
+	if renderer == nil {
+		return builtin(), nil
+	}
+
+	result, err := prompts.RenderPinned(ctx, renderer, name, &models.PromptContext{
+		Arguments: arguments,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render stage prompt %q: %w", name, err)
+	}
+	return result.RenderedText, nil
+}
@@ -10,27 +10,65 @@ import (
 
 // AnalysisConfig configures the sequential analysis process
 type AnalysisConfig struct {
-	Depth                int           `json:"depth"`
-	MaxStages            int           `json:"maxStages"`
-	ConfidenceThreshold  float64       `json:"confidenceThreshold"`
-	TimeoutPerStage      time.Duration `json:"timeoutPerStage"`
-	EnableCrossReference bool          `json:"enableCrossReference"`
-	EnableHypotheses     bool          `json:"enableHypotheses"`
+	Depth                int               `json:"depth"`     // Stages run; beyond DefaultPipeline, each level adds a recursive refinement pass (up to MaxDepth)
+	MaxStages            int               `json:"maxStages"` // Caps the DefaultPipeline stages run; refinement passes are only added when it runs in full
+	ConfidenceThreshold  float64           `json:"confidenceThreshold"`
+	TimeoutPerStage      time.Duration     `json:"timeoutPerStage"`
+	EnableCrossReference bool              `json:"enableCrossReference"`
+	EnableHypotheses     bool              `json:"enableHypotheses"`
+	IndicatorFlags       []string          `json:"indicatorFlags,omitempty"`      // Corruption indicator flags to record (empty records all)
+	Stages               []string          `json:"stages,omitempty"`              // Ordered stage names to run (empty runs DefaultPipeline shaped by Depth)
+	CallbackURL          string            `json:"callbackUrl,omitempty"`         // Receives the session summary when the session ends
+	ResumeSessionID      string            `json:"resumeSessionId,omitempty"`     // Completed session whose earlier stage results are reused
+	ResumeFrom           string            `json:"resumeFrom,omitempty"`          // First stage to run; the stages before it are taken from ResumeSessionID
+	TotalTimeout         time.Duration     `json:"totalTimeout,omitempty"`        // Budget for the whole session; when spent it ends completed_partial (0 is unlimited)
+	LowConfidencePolicy  string            `json:"lowConfidencePolicy,omitempty"` // What a stage below ConfidenceThreshold does to the rest: LowConfidenceContinue (default), Stop or SkipDependents
+	SourceReliability    float64           `json:"sourceReliability,omitempty"`   // Weight of the article's source (0..1) scaling evidence confidence (0 is unweighted)
+	StageModels          map[string]string `json:"stageModels,omitempty"`         // LLM model per stage name; stages not listed use the client's configured model
+	PromptCache          bool              `json:"promptCache,omitempty"`         // Answer prompts repeated exactly within the session from the session's first completion
 }
 
+// Policies for a stage that completes below ConfidenceThreshold
+const (
+	LowConfidenceContinue       = "continue"        // Note it in the stage's insights and run the remaining stages
+	LowConfidenceStop           = "stop"            // End the session completed_partial, skipping the remaining stages
+	LowConfidenceSkipDependents = "skip-dependents" // Skip the stages that depend on it, directly or not, and run the rest
+)
+
+// ValidLowConfidencePolicy reports whether policy is one of the low confidence policies or empty
+func ValidLowConfidencePolicy(policy string) bool {
+	switch policy {
+	case "", LowConfidenceContinue, LowConfidenceStop, LowConfidenceSkipDependents:
+		return true
+	}
+	return false
+}
+
+// Reasons a session ended, recorded in AnalysisSession.EndReason
+const (
+	EndReasonCompleted     = "completed"      // Every stage ran, or was skipped as a dependent of an uncertain stage
+	EndReasonLowConfidence = "low_confidence" // A stage fell below the threshold under LowConfidenceStop
+	EndReasonTotalTimeout  = "total_timeout"  // The TotalTimeout budget ran out
+	EndReasonStageFailed   = "stage_failed"   // A stage returned an error
+	EndReasonTerminated    = "terminated"     // TerminateSession was called
+)
+
 // AnalysisSession represents a sequential analysis session
 type AnalysisSession struct {
-	ID          string                     `json:"id"`
-	ArticleID   string                     `json:"articleId"`
-	Config      *AnalysisConfig            `json:"config"`
-	Stages      []*AnalysisStage           `json:"stages"`
-	Status      string                     `json:"status"` // "running", "completed", "failed", "terminated"
-	StartedAt   time.Time                  `json:"startedAt"`
-	CompletedAt *time.Time                 `json:"completedAt,omitempty"`
-	Error       string                     `json:"error,omitempty"`
-	Evidence    []Evidence                 `json:"evidence"`
-	Hypotheses  []Hypothesis               `json:"hypotheses"`
-	Results     []*models.ExtractionResult `json:"results"`
+	ID             string                     `json:"id"`
+	ArticleID      string                     `json:"articleId"`
+	Config         *AnalysisConfig            `json:"config"`
+	Stages         []*AnalysisStage           `json:"stages"`
+	Status         string                     `json:"status"` // "running", "completed", "completed_partial", "failed", "terminated"
+	StartedAt      time.Time                  `json:"startedAt"`
+	CompletedAt    *time.Time                 `json:"completedAt,omitempty"`
+	Error          string                     `json:"error,omitempty"`
+	EndReason      string                     `json:"endReason,omitempty"` // Why the pipeline ended, once it has (see EndReasonCompleted)
+	Evidence       []Evidence                 `json:"evidence"`
+	EvidenceChains []*EvidenceChain           `json:"evidenceChains"`
+	Hypotheses     []Hypothesis               `json:"hypotheses"`
+	Results        []*models.ExtractionResult `json:"results"`
+	Usage          llm.Usage                  `json:"usage"` // Tokens used by all stages so far
 }
 
 // AnalysisStage represents a single stage in the sequential analysis
@@ -38,7 +76,7 @@ type AnalysisStage struct {
 	Stage       int                      `json:"stage"`
 	Name        string                   `json:"name"`
 	Description string                   `json:"description"`
-	Status      string                   `json:"status"` // "pending", "running", "completed", "failed"
+	Status      string                   `json:"status"` // "pending", "running", "completed", "failed", "cancelled", "skipped"
 	StartedAt   *time.Time               `json:"startedAt,omitempty"`
 	CompletedAt *time.Time               `json:"completedAt,omitempty"`
 	Results     *models.ExtractionResult `json:"results,omitempty"`
@@ -46,6 +84,7 @@ type AnalysisStage struct {
 	Insights    []string                 `json:"insights"`
 	Questions   []string                 `json:"questions,omitempty"`
 	Error       string                   `json:"error,omitempty"`
+	Usage       *llm.Usage               `json:"usage,omitempty"` // Tokens used by the stage, once it has run
 }
 
 // Evidence and Hypothesis types are defined in evidence.go
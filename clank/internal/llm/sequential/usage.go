@@ -0,0 +1,64 @@
+package sequential
+
+import "clank/internal/llm"
+
+// Pricing estimates what tokens cost, per 1000 tokens
+type Pricing struct {
+	PromptPer1K     float64 `json:"promptPer1k"`
+	CompletionPer1K float64 `json:"completionPer1k"`
+}
+
+// Enabled reports whether any price is configured
+func (p Pricing) Enabled() bool {
+	return p.PromptPer1K > 0 || p.CompletionPer1K > 0
+}
+
+// Cost estimates the cost of usage
+func (p Pricing) Cost(usage llm.Usage) float64 {
+	return float64(usage.PromptTokens)/1000*p.PromptPer1K + float64(usage.CompletionTokens)/1000*p.CompletionPer1K
+}
+
+// UsageSummary is the token usage of a session, per stage and in total
+type UsageSummary struct {
+	SessionID     string       `json:"sessionId"`
+	Status        string       `json:"status"`
+	Stages        []StageUsage `json:"stages"`
+	Total         llm.Usage    `json:"total"`
+	EstimatedCost *float64     `json:"estimatedCost,omitempty"` // Only when a price is configured
+	Pricing       *Pricing     `json:"pricing,omitempty"`
+}
+
+// StageUsage is the token usage of one stage in a UsageSummary. Stages that
+// haven't run yet have no usage.
+type StageUsage struct {
+	Stage         int        `json:"stage"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"`
+	Usage         *llm.Usage `json:"usage,omitempty"`
+	EstimatedCost *float64   `json:"estimatedCost,omitempty"`
+}
+
+// SummarizeUsage builds the usage summary of a session, estimating costs when
+// pricing has a price set
+func SummarizeUsage(session *AnalysisSession, pricing Pricing) UsageSummary {
+	summary := UsageSummary{
+		SessionID: session.ID,
+		Status:    session.Status,
+		Stages:    make([]StageUsage, 0, len(session.Stages)),
+		Total:     session.Usage,
+	}
+	for _, stage := range session.Stages {
+		stageUsage := StageUsage{Stage: stage.Stage, Name: stage.Name, Status: stage.Status, Usage: stage.Usage}
+		if stage.Usage != nil && pricing.Enabled() {
+			cost := pricing.Cost(*stage.Usage)
+			stageUsage.EstimatedCost = &cost
+		}
+		summary.Stages = append(summary.Stages, stageUsage)
+	}
+	if pricing.Enabled() {
+		cost := pricing.Cost(session.Usage)
+		summary.EstimatedCost = &cost
+		summary.Pricing = &pricing
+	}
+	return summary
+}
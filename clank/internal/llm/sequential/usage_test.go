@@ -0,0 +1,93 @@
+package sequential
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clank/config"
+	"clank/internal/llm"
+	"clank/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usageLLMClient returns a client answering every request with content and the
+// given usages, in order
+func usageLLMClient(t *testing.T, content string, usages ...llm.Usage) *llm.Client {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		usage := usages[min(calls, len(usages)-1)]
+		calls++
+		json.NewEncoder(w).Encode(llm.Response{
+			Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: content}}},
+			Usage:   &usage,
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.LLM.URL = server.URL
+	return llm.NewClient(cfg)
+}
+
+func TestAnalysisController_AccumulatesUsage(t *testing.T) {
+	client := usageLLMClient(t, `{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe"}], "confidence": 0.8}`,
+		llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120},
+		// A backend that omits the total still counts towards it
+		llm.Usage{PromptTokens: 300, CompletionTokens: 50},
+	)
+	controller := NewAnalysisController(client)
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+	session := &AnalysisSession{
+		ID:     "s1",
+		Config: &AnalysisConfig{TimeoutPerStage: time.Second},
+		Status: "running",
+		Stages: []*AnalysisStage{{Stage: 1, Name: StageSurfaceExtraction}, {Stage: 2, Name: StageDeepAnalysis}},
+	}
+	controller.processSession(context.Background(), session, article, []string{StageSurfaceExtraction, StageDeepAnalysis}, []AnalysisStageProcessor{
+		controller.stages[StageSurfaceExtraction],
+		controller.stages[StageDeepAnalysis],
+	})
+	require.Equal(t, "completed", session.Status, session.Error)
+
+	require.NotNil(t, session.Stages[0].Usage)
+	assert.Equal(t, llm.Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120}, *session.Stages[0].Usage)
+	require.NotNil(t, session.Stages[1].Usage)
+	assert.Equal(t, llm.Usage{PromptTokens: 300, CompletionTokens: 50, TotalTokens: 350}, *session.Stages[1].Usage)
+	assert.Equal(t, llm.Usage{PromptTokens: 400, CompletionTokens: 70, TotalTokens: 470}, session.Usage)
+
+	summary := SummarizeUsage(session, Pricing{PromptPer1K: 0.5, CompletionPer1K: 1.5})
+	assert.Equal(t, session.Usage, summary.Total)
+	require.Len(t, summary.Stages, 2)
+	require.NotNil(t, summary.Stages[0].EstimatedCost)
+	assert.InDelta(t, 0.05+0.03, *summary.Stages[0].EstimatedCost, 1e-9)
+	require.NotNil(t, summary.EstimatedCost)
+	assert.InDelta(t, 0.2+0.105, *summary.EstimatedCost, 1e-9)
+}
+
+func TestSummarizeUsage_WithoutPricing(t *testing.T) {
+	session := &AnalysisSession{
+		ID:     "s1",
+		Status: "running",
+		Usage:  llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		Stages: []*AnalysisStage{
+			{Stage: 1, Name: StageSurfaceExtraction, Status: "completed", Usage: &llm.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}},
+			{Stage: 2, Name: StageDeepAnalysis, Status: "pending"},
+		},
+	}
+
+	summary := SummarizeUsage(session, Pricing{})
+
+	assert.Nil(t, summary.EstimatedCost)
+	assert.Nil(t, summary.Pricing)
+	assert.Equal(t, 15, summary.Total.TotalTokens)
+	assert.Nil(t, summary.Stages[0].EstimatedCost)
+	assert.Nil(t, summary.Stages[1].Usage, "stages that haven't run have no usage")
+}
@@ -0,0 +1,256 @@
+package sequential
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"clank/internal/logging"
+)
+
+const (
+	// SignatureHeader carries the hex HMAC-SHA256 of the callback body, prefixed "sha256="
+	SignatureHeader = "X-Clank-Signature"
+
+	defaultWebhookAttempts = 3
+	defaultWebhookTimeout  = 10 * time.Second
+	webhookBaseDelay       = time.Second
+	webhookMaxDelay        = 30 * time.Second
+)
+
+// ErrPrivateAddress is returned for callbacks to hosts that resolve to loopback,
+// private, link-local, carrier-grade NAT, NAT64 or other internal addresses and
+// aren't allowed hosts
+var ErrPrivateAddress = errors.New("callback host resolves to an internal address")
+
+// SessionSummary is the payload posted to a session's callback URL once it ends
+type SessionSummary struct {
+	SessionID      string         `json:"sessionId"`
+	ArticleID      string         `json:"articleId"`
+	Status         string         `json:"status"` // "completed", "completed_partial", "failed" or "terminated"
+	Error          string         `json:"error,omitempty"`
+	EndReason      string         `json:"endReason,omitempty"` // Why the pipeline ended (see EndReasonCompleted)
+	StartedAt      time.Time      `json:"startedAt"`
+	CompletedAt    *time.Time     `json:"completedAt,omitempty"`
+	Stages         []StageSummary `json:"stages"`
+	Entities       int            `json:"entities"`
+	Relationships  int            `json:"relationships"`
+	Hypotheses     int            `json:"hypotheses"`
+	EvidenceChains int            `json:"evidenceChains"`
+}
+
+// StageSummary is the outcome of one stage in a SessionSummary
+type StageSummary struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Summarize builds the callback payload for a session
+func Summarize(session *AnalysisSession) SessionSummary {
+	summary := SessionSummary{
+		SessionID:      session.ID,
+		ArticleID:      session.ArticleID,
+		Status:         session.Status,
+		Error:          session.Error,
+		EndReason:      session.EndReason,
+		StartedAt:      session.StartedAt,
+		CompletedAt:    session.CompletedAt,
+		Stages:         make([]StageSummary, 0, len(session.Stages)),
+		Hypotheses:     len(session.Hypotheses),
+		EvidenceChains: len(session.EvidenceChains),
+	}
+	for _, stage := range session.Stages {
+		summary.Stages = append(summary.Stages, StageSummary{Name: stage.Name, Status: stage.Status, Confidence: stage.Confidence})
+	}
+	for _, result := range session.Results {
+		if result != nil {
+			summary.Entities += len(result.Entities)
+			summary.Relationships += len(result.Relationships)
+		}
+	}
+	return summary
+}
+
+// Webhook posts session summaries to callback URLs, signing each body with a
+// shared secret so receivers can verify it came from this server
+type Webhook struct {
+	client       *http.Client
+	secret       []byte
+	maxAttempts  int
+	baseDelay    time.Duration
+	allowedHosts map[string]bool                            // Hosts reachable even on internal addresses
+	sleep        func(context.Context, time.Duration) error // replaced in tests
+}
+
+// NewWebhook creates a webhook sender. An empty secret sends unsigned callbacks;
+// zero attempts or timeout use the defaults. Callback URLs are supplied by API
+// clients, so only allowedHosts may resolve to internal addresses, and
+// redirects are never followed.
+func NewWebhook(secret string, maxAttempts int, timeout time.Duration, allowedHosts []string) *Webhook {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookAttempts
+	}
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	w := &Webhook{
+		secret:       []byte(secret),
+		maxAttempts:  maxAttempts,
+		baseDelay:    webhookBaseDelay,
+		allowedHosts: make(map[string]bool, len(allowedHosts)),
+		sleep:        sleepContext,
+	}
+	for _, host := range allowedHosts {
+		w.allowedHosts[strings.ToLower(host)] = true
+	}
+
+	// A proxy would be dialed instead of the receiver, bypassing the address check
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = w.dial
+	w.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return w
+}
+
+// dial connects to addr unless its host resolves to an internal address. The
+// address checked is the one dialed, so the host can't be re-resolved to
+// another one in between.
+func (w *Webhook) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	if w.allowedHosts[strings.ToLower(host)] {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		if !publicIP(ip.IP) {
+			return nil, fmt.Errorf("%w: %s is %s", ErrPrivateAddress, host, ip.IP)
+		}
+	}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].IP.String(), port))
+}
+
+// nonPublicNets are ranges IsGlobalUnicast and IsPrivate accept that still
+// reach internal hosts: carrier-grade NAT and the NAT64 translation prefixes,
+// whose embedded IPv4 address could be anything
+var nonPublicNets = parseCIDRs("100.64.0.0/10", "64:ff9b::/96", "64:ff9b:1::/48")
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// publicIP reports whether ip is a globally routable unicast address. An
+// IPv4-mapped IPv6 address is judged by the IPv4 address it maps.
+func publicIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	if ip.IsUnspecified() || !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return false
+	}
+	for _, ipNet := range nonPublicNets {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// sleepContext waits for d, or until ctx ends with ctx's error
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Sign returns the signature header value for body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver posts the summary to url, retrying network errors, 429s and 5xx
+// responses with exponential backoff until ctx ends
+func (w *Webhook) Deliver(ctx context.Context, url string, summary SessionSummary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode session summary: %w", err)
+	}
+
+	delay := w.baseDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == w.maxAttempts {
+			return fmt.Errorf("callback to %s failed after %d attempt(s): %w", url, attempt, err)
+		}
+		logging.For(ctx, "analysis").Warn("Webhook callback failed, retrying", "attempt", attempt, "max_attempts", w.maxAttempts, "delay", delay, "error", err)
+		if err := w.sleep(ctx, delay); err != nil {
+			return fmt.Errorf("callback to %s abandoned after %d attempt(s): %w", url, attempt, err)
+		}
+		delay = min(delay*2, webhookMaxDelay)
+	}
+}
+
+// post sends one callback, reporting whether a failure is worth retrying
+func (w *Webhook) post(ctx context.Context, url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrPrivateAddress), err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("receiver returned %s", resp.Status)
+}
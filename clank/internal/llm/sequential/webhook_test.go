@@ -0,0 +1,197 @@
+package sequential
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clank/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testReceiverHosts allows callbacks to httptest servers, which listen on loopback
+var testReceiverHosts = []string{"127.0.0.1"}
+
+type capturedCallback struct {
+	body      []byte
+	signature string
+}
+
+// callbackReceiver records each callback and answers with the given statuses, in order
+func callbackReceiver(t *testing.T, statuses ...int) (*httptest.Server, chan capturedCallback) {
+	t.Helper()
+	received := make(chan capturedCallback, 10)
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- capturedCallback{body: body, signature: r.Header.Get(SignatureHeader)}
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[min(calls, len(statuses)-1)])
+		}
+		calls++
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func TestAnalysisController_CallbackOnCompletion(t *testing.T) {
+	receiver, received := callbackReceiver(t)
+
+	controller := NewAnalysisController(stubLLMClient(t, `{"entities": [{"id": "e1", "type": "person", "name": "Jane Doe"}], "confidence": 0.8}`))
+	controller.SetWebhook(NewWebhook("s3cret", 3, time.Second, testReceiverHosts))
+	article := testutil.MockArticle("https://example.com", "Test Article", "Test content")
+
+	session, err := controller.StartAnalysis(context.Background(), article, &AnalysisConfig{
+		Depth:           2,
+		MaxStages:       5,
+		TimeoutPerStage: time.Second,
+		Stages:          []string{StageSurfaceExtraction},
+		CallbackURL:     receiver.URL,
+	})
+	require.NoError(t, err)
+
+	var callback capturedCallback
+	select {
+	case callback = <-received:
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback was not delivered")
+	}
+
+	expected := Sign([]byte("s3cret"), callback.body)
+	assert.True(t, hmac.Equal([]byte(expected), []byte(callback.signature)), "signature %q does not match body", callback.signature)
+
+	var summary SessionSummary
+	require.NoError(t, json.Unmarshal(callback.body, &summary))
+	assert.Equal(t, session.ID, summary.SessionID)
+	assert.Equal(t, article.ID, summary.ArticleID)
+	assert.Equal(t, "completed", summary.Status)
+	assert.NotNil(t, summary.CompletedAt)
+	require.Len(t, summary.Stages, 1)
+	assert.Equal(t, "completed", summary.Stages[0].Status)
+}
+
+func TestWebhook_RetriesWithBackoff(t *testing.T) {
+	receiver, received := callbackReceiver(t, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+
+	webhook := NewWebhook("", 3, time.Second, testReceiverHosts)
+	var delays []time.Duration
+	webhook.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	err := webhook.Deliver(context.Background(), receiver.URL, SessionSummary{SessionID: "s1", Status: "failed"})
+	require.NoError(t, err)
+
+	assert.Len(t, received, 3)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	assert.Empty(t, (<-received).signature, "callbacks without a secret are unsigned")
+}
+
+func TestWebhook_GivesUp(t *testing.T) {
+	t.Run("client error is not retried", func(t *testing.T) {
+		receiver, received := callbackReceiver(t, http.StatusBadRequest)
+		webhook := NewWebhook("s3cret", 3, time.Second, testReceiverHosts)
+		webhook.sleep = func(context.Context, time.Duration) error { return nil }
+
+		err := webhook.Deliver(context.Background(), receiver.URL, SessionSummary{SessionID: "s1"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "400")
+		assert.Len(t, received, 1)
+	})
+
+	t.Run("attempts are bounded", func(t *testing.T) {
+		receiver, received := callbackReceiver(t, http.StatusInternalServerError)
+		webhook := NewWebhook("s3cret", 2, time.Second, testReceiverHosts)
+		webhook.sleep = func(context.Context, time.Duration) error { return nil }
+
+		err := webhook.Deliver(context.Background(), receiver.URL, SessionSummary{SessionID: "s1"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "after 2 attempt(s)")
+		assert.Len(t, received, 2)
+	})
+}
+
+func TestWebhook_RefusesInternalAddresses(t *testing.T) {
+	receiver, received := callbackReceiver(t)
+	webhook := NewWebhook("s3cret", 3, time.Second, nil)
+	webhook.sleep = func(context.Context, time.Duration) error {
+		t.Fatal("refused callbacks are not retried")
+		return nil
+	}
+
+	for _, url := range []string{receiver.URL, "http://localhost:9/", "http://169.254.169.254/latest/meta-data/", "http://10.0.0.1/"} {
+		err := webhook.Deliver(context.Background(), url, SessionSummary{SessionID: "s1"})
+		require.ErrorIs(t, err, ErrPrivateAddress, url)
+	}
+	assert.Empty(t, received)
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"::ffff:93.184.216.34": true,
+		"0.0.0.0":              false,
+		"::":                   false,
+		"127.0.0.1":            false,
+		"10.0.0.1":             false,
+		"169.254.169.254":      false,
+		"100.64.0.1":           false,
+		"100.127.255.254":      false,
+		"::ffff:127.0.0.1":     false,
+		"::ffff:10.0.0.1":      false,
+		"::ffff:100.64.0.1":    false,
+		"64:ff9b::a9fe:a9fe":   false,
+		"64:ff9b::5db8:d822":   false,
+		"64:ff9b:1::a00:1":     false,
+		"fd00::1":              false,
+		"fe80::1":              false,
+	}
+	for addr, want := range tests {
+		ip := net.ParseIP(addr)
+		require.NotNil(t, ip, addr)
+		assert.Equal(t, want, publicIP(ip), addr)
+	}
+}
+
+func TestWebhook_DoesNotFollowRedirects(t *testing.T) {
+	internal, received := callbackReceiver(t)
+	redirecting := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusTemporaryRedirect))
+	t.Cleanup(redirecting.Close)
+
+	webhook := NewWebhook("s3cret", 3, time.Second, testReceiverHosts)
+	err := webhook.Deliver(context.Background(), redirecting.URL, SessionSummary{SessionID: "s1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "307")
+	assert.Empty(t, received)
+}
+
+func TestWebhook_BackoffEndsWithContext(t *testing.T) {
+	receiver, received := callbackReceiver(t, http.StatusServiceUnavailable)
+	webhook := NewWebhook("s3cret", 3, time.Second, testReceiverHosts)
+	webhook.baseDelay = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() { done <- webhook.Deliver(ctx, receiver.URL, SessionSummary{SessionID: "s1"}) }()
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Deliver kept waiting after the context ended")
+	}
+}
@@ -32,16 +32,20 @@ func (r *SSEReader) Read() (*SSEEvent, error) {
 
 	for {
 		line, err := r.reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				// If we have data in the buffer, process it before returning EOF
-				if buffer.Len() > 0 {
-					event.Data = strings.TrimSpace(buffer.String())
-					return event, nil
-				}
-			}
+		if err != nil && err != io.EOF {
 			return nil, err
 		}
+		if err == io.EOF {
+			// The last line may lack its newline; keep its data before ending
+			if data, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:")); ok {
+				buffer.Write(bytes.TrimSpace(data))
+			}
+			if buffer.Len() > 0 {
+				event.Data = strings.TrimSpace(buffer.String())
+				return event, nil
+			}
+			return nil, io.EOF
+		}
 
 		line = bytes.TrimSpace(line)
 		if len(line) == 0 {
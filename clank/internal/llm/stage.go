@@ -0,0 +1,42 @@
+package llm
+
+import "context"
+
+const (
+	// extractionStage labels metrics of single-pass extraction
+	extractionStage = "extraction"
+	// unstagedLabel labels metrics of completions made outside any stage
+	unstagedLabel = "none"
+)
+
+type stageKey struct{}
+
+// WithStage returns a context whose completions are attributed to the named
+// analysis stage in metrics
+func WithStage(ctx context.Context, stage string) context.Context {
+	return context.WithValue(ctx, stageKey{}, stage)
+}
+
+// stageLabel returns the stage set by WithStage, or unstagedLabel
+func stageLabel(ctx context.Context) string {
+	if stage, ok := ctx.Value(stageKey{}).(string); ok && stage != "" {
+		return stage
+	}
+	return unstagedLabel
+}
+
+type modelKey struct{}
+
+// WithModel returns a context whose completions request model instead of the
+// client's configured one; an empty model keeps the configured one
+func WithModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// modelFor returns the model set by WithModel, or the client's configured model
+func (c *Client) modelFor(ctx context.Context) string {
+	if model, ok := ctx.Value(modelKey{}).(string); ok && model != "" {
+		return model
+	}
+	return c.model
+}
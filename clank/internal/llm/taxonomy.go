@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"fmt"
+
+	"clank/internal/models"
+)
+
+// ValidateEntityType normalizes the entity's type against the entity taxonomy,
+// returning an error for types that are neither allowed nor aliases of one
+func ValidateEntityType(entity *models.Entity) error {
+	entityType, err := models.NormalizeEntityType(entity.Type)
+	if err != nil {
+		return err
+	}
+	entity.Type = entityType
+	return nil
+}
+
+// ValidateRelationshipType normalizes the relationship's type to one of
+// models.RelationshipTypes, returning an error for anything else
+func ValidateRelationshipType(rel *models.Relationship) error {
+	relType, err := models.NormalizeRelationshipType(rel.Type)
+	if err != nil {
+		return err
+	}
+	rel.Type = relType
+	return nil
+}
+
+// normalizeEntityTypes maps extracted entity types onto the taxonomy. Entities of
+// unknown types are dropped with a warning, along with their relationships.
+func normalizeEntityTypes(result *models.ExtractionResult) {
+	entities := result.Entities[:0]
+	dropped := make(map[string]bool)
+	for _, entity := range result.Entities {
+		entityType, err := models.NormalizeEntityType(entity.Type)
+		if err != nil {
+			dropped[entity.ID] = true
+			result.Warnings = append(result.Warnings, fmt.Sprintf("skipped %q: %v", entity.Name, err))
+			continue
+		}
+		entity.Type = entityType
+		entities = append(entities, entity)
+	}
+	result.Entities = entities
+
+	if len(dropped) == 0 {
+		return
+	}
+	relationships := result.Relationships[:0]
+	for _, rel := range result.Relationships {
+		if dropped[rel.FromID] || dropped[rel.ToID] {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"skipped %s relationship %s -> %s: it references a skipped entity", rel.Type, rel.FromID, rel.ToID))
+			continue
+		}
+		relationships = append(relationships, rel)
+	}
+	result.Relationships = relationships
+}
@@ -0,0 +1,108 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateEntityType_Taxonomy(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "valid type", input: "person", expected: "person"},
+		{name: "case and spacing", input: " Organization ", expected: "organization"},
+		{name: "alias", input: "Government Agency", expected: "government"},
+		{name: "typo", input: "Persom", wantErr: true},
+		{name: "injection", input: "person) DETACH DELETE n //", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entity := &models.Entity{Name: "Test", Type: tt.input}
+			err := ValidateEntityType(entity)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, models.ErrUnknownEntityType), "got %v", err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, entity.Type)
+		})
+	}
+}
+
+func TestNormalizeEntityTypes(t *testing.T) {
+	newResult := func() *models.ExtractionResult {
+		return &models.ExtractionResult{
+			Entities: []models.ExtractedEntity{
+				{ID: "e1", Type: "Individual", Name: "Jane Smith"},
+				{ID: "e2", Type: "vessel", Name: "MV Fortune"},
+				{ID: "e3", Type: "firm", Name: "Acme Construction"},
+			},
+			Relationships: []models.ExtractedRelationship{
+				{ID: "r1", Type: "ownership", FromID: "e3", ToID: "e2"},
+				{ID: "r2", Type: "employment", FromID: "e1", ToID: "e3"},
+			},
+		}
+	}
+
+	t.Run("unknown types are dropped", func(t *testing.T) {
+		result := newResult()
+		normalizeEntityTypes(result)
+
+		require.Len(t, result.Entities, 2)
+		assert.Equal(t, "person", result.Entities[0].Type)
+		assert.Equal(t, "company", result.Entities[1].Type)
+		require.Len(t, result.Relationships, 1)
+		assert.Equal(t, "r2", result.Relationships[0].ID)
+		assert.Len(t, result.Warnings, 2)
+	})
+
+	t.Run("unknown types are mapped to the fallback", func(t *testing.T) {
+		models.SetEntityTaxonomy(models.NewEntityTaxonomy(models.DefaultEntityTypes, "other"))
+		t.Cleanup(func() { models.SetEntityTaxonomy(nil) })
+
+		result := newResult()
+		normalizeEntityTypes(result)
+
+		require.Len(t, result.Entities, 3)
+		assert.Equal(t, "other", result.Entities[1].Type)
+		assert.Len(t, result.Relationships, 2)
+		assert.Empty(t, result.Warnings)
+	})
+}
+
+func TestValidateRelationshipType_Allowlist(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "valid type", input: "PAID", expected: "PAID"},
+		{name: "case and spacing", input: "works for", expected: "WORKS_FOR"},
+		{name: "extraction type", input: "payment", expected: "PAYMENT"},
+		{name: "injection", input: "X]->(to) DETACH DELETE to //", wantErr: true},
+		{name: "unknown", input: "INVALID_TYPE", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := &models.Relationship{Type: tt.input}
+			err := ValidateRelationshipType(rel)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, models.ErrUnknownRelationshipType)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rel.Type)
+		})
+	}
+}
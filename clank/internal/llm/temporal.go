@@ -0,0 +1,19 @@
+package llm
+
+// temporalInstructions asks the model to capture the ordering between events
+const temporalInstructions = `
+
+Also capture the order of events:
+- Extract each distinct event (a meeting, payment, contract award, arrest, resignation, etc.) as an entity with type "event", adding a "date" property when the article gives one
+- Link events with relationships of type "BEFORE" (fromId happened before toId), "AFTER" (fromId happened after toId) or "CAUSED" (fromId led to toId)
+- Only link events whose order or causation is stated or clearly implied by the article
+- Connect the people and organizations involved to each event with "involvement" relationships`
+
+// TemporalInstructions returns the event ordering instructions to append to
+// extraction prompts, or "" when temporal sequence extraction is disabled
+func (c *Client) TemporalInstructions() string {
+	if !c.temporalSequences {
+		return ""
+	}
+	return temporalInstructions
+}
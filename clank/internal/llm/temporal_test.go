@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clank/config"
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ProcessArticle_TemporalInstructions(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		var prompt string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req GenerateRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			prompt = req.Messages[len(req.Messages)-1].Content
+			json.NewEncoder(w).Encode(Response{Choices: []Choice{{Content: `{"entities": [], "relationships": []}`}}})
+		}))
+
+		cfg := &config.Config{}
+		cfg.LLM.URL = server.URL
+		cfg.Extraction.TemporalSequences = enabled
+		_, err := NewClient(cfg).ProcessArticle(t.Context(), &models.Article{Content: "The meeting happened before the contract was awarded."})
+		server.Close()
+		require.NoError(t, err)
+
+		if enabled {
+			assert.Contains(t, prompt, `"BEFORE"`)
+			assert.Contains(t, prompt, `"CAUSED"`)
+		} else {
+			assert.NotContains(t, prompt, `"BEFORE"`)
+		}
+	}
+}
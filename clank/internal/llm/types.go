@@ -23,6 +23,7 @@ type Response struct {
 	Created int64    `json:"created"`
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"` // Token usage, when the backend reports it
 	Error   string   `json:"error,omitempty"`
 }
 
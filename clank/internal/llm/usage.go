@@ -0,0 +1,60 @@
+package llm
+
+import (
+	"context"
+	"sync"
+)
+
+// Usage is the token usage reported by an OpenAI-compatible backend
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add adds other to u. A missing total is taken to be prompt plus completion tokens.
+func (u *Usage) Add(other Usage) {
+	if other.TotalTokens == 0 {
+		other.TotalTokens = other.PromptTokens + other.CompletionTokens
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// UsageTracker accumulates the usage of every completion made with a context
+// returned by WithUsageTracker
+type UsageTracker struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+// Usage returns the usage accumulated so far
+func (t *UsageTracker) Usage() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+func (t *UsageTracker) add(usage Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.usage.Add(usage)
+}
+
+type usageTrackerKey struct{}
+
+// WithUsageTracker returns a context whose completions are counted by tracker
+func WithUsageTracker(ctx context.Context, tracker *UsageTracker) context.Context {
+	return context.WithValue(ctx, usageTrackerKey{}, tracker)
+}
+
+// recordUsage adds usage to the context's tracker, if it has one
+func recordUsage(ctx context.Context, usage *Usage) {
+	if usage == nil {
+		return
+	}
+	if tracker, ok := ctx.Value(usageTrackerKey{}).(*UsageTracker); ok {
+		tracker.add(*usage)
+	}
+}
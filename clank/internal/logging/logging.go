@@ -0,0 +1,73 @@
+// Package logging sets up structured logging (log/slog) and carries a per-request
+// correlation ID through contexts, so one request can be followed from the handler
+// through the scraper, the LLM client and the graph.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries a request's correlation ID in and out of the server
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds inbound IDs, which end up on every log line
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines carry id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the correlation ID set by WithRequestID, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns id if it is usable as a correlation ID and a fresh one
+// otherwise. Inbound IDs are trusted as given, but must be short and printable.
+func NewRequestID(id string) string {
+	id = strings.TrimSpace(id)
+	if id == "" || len(id) > maxRequestIDLength || strings.IndexFunc(id, func(r rune) bool { return r < 0x21 || r > 0x7e }) >= 0 {
+		return uuid.New().String()
+	}
+	return id
+}
+
+// For returns the logger for a component, e.g. "scraper" or "llm", whose lines
+// carry ctx's correlation ID
+func For(ctx context.Context, component string) *slog.Logger {
+	logger := slog.Default().With("component", component)
+	if id := RequestID(ctx); id != "" {
+		logger = logger.With("request_id", id)
+	}
+	return logger
+}
+
+// Setup makes a logger writing to w the default for slog and the log package.
+// format is "json" or "text" (the default); level is "debug", "info" (the
+// default), "warn" or "error".
+func Setup(w io.Writer, format, level string) {
+	opts := &slog.HandlerOptions{Level: parseLevel(level)}
+	var handler slog.Handler = slog.NewTextHandler(w, opts)
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// parseLevel maps a configured level name to a slog level, defaulting to info
+func parseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestID(t *testing.T) {
+	assert.Equal(t, "abc-123", NewRequestID(" abc-123 "))
+
+	for _, inbound := range []string{"", "has space", "line\nbreak", strings.Repeat("x", maxRequestIDLength+1)} {
+		generated := NewRequestID(inbound)
+		assert.NotEqual(t, inbound, generated)
+		assert.Len(t, generated, 36, "a UUID replaces %q", inbound)
+	}
+	assert.NotEqual(t, NewRequestID(""), NewRequestID(""))
+}
+
+func TestFor(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	Setup(&buf, "json", "debug")
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	For(WithRequestID(context.Background(), "req-1"), "scraper").Debug("Scraping article", "url", "https://example.com")
+	For(context.Background(), "graph").Info("Startup")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var first, second map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "req-1", first["request_id"])
+	assert.Equal(t, "scraper", first["component"])
+	assert.Equal(t, "https://example.com", first["url"])
+	assert.NotContains(t, second, "request_id", "lines outside a request carry no ID")
+}
+
+func TestParseLevel(t *testing.T) {
+	assert.Equal(t, slog.LevelDebug, parseLevel("debug"))
+	assert.Equal(t, slog.LevelWarn, parseLevel("WARN"))
+	assert.Equal(t, slog.LevelInfo, parseLevel(""))
+	assert.Equal(t, slog.LevelInfo, parseLevel("verbose"))
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+
+	"clank/internal/limits"
+)
+
+// Default is the registry served at /metrics
+var Default = NewRegistry()
+
+// Status label values
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+	StatusBusy    = "busy"     // Refused by a concurrency limit
+	StatusInvalid = "rejected" // Refused as a bad request
+)
+
+var (
+	// ScrapeDuration times article scrapes by status
+	ScrapeDuration = Default.NewHistogramVec("clank_scrape_duration_seconds",
+		"Time spent scraping article pages.", nil, "status")
+
+	// LLMDuration times LLM completions by analysis stage and status
+	LLMDuration = Default.NewHistogramVec("clank_llm_request_duration_seconds",
+		"Time spent waiting for LLM completions.", nil, "stage", "status")
+
+	// LLMTokens counts tokens reported by the LLM backend by stage and kind (prompt or completion)
+	LLMTokens = Default.NewCounterVec("clank_llm_tokens_total",
+		"Tokens used by LLM completions.", "stage", "kind")
+
+	// Extractions counts extraction requests by mode and status
+	Extractions = Default.NewCounterVec("clank_extractions_total",
+		"Extraction requests handled.", "mode", "status")
+
+	// StageDuration times sequential analysis stages by stage and status
+	StageDuration = Default.NewHistogramVec("clank_analysis_stage_duration_seconds",
+		"Time spent in sequential analysis stages.", nil, "stage", "status")
+
+	// Neo4jTransactionDuration times Neo4j transactions by access mode (read or write) and status
+	Neo4jTransactionDuration = Default.NewHistogramVec("clank_neo4j_transaction_duration_seconds",
+		"Time spent in Neo4j transactions.", nil, "mode", "status")
+)
+
+// Handler serves the Default registry
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Status returns the status label for the outcome err
+func Status(err error) string {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case errors.Is(err, limits.ErrBusy):
+		return StatusBusy
+	}
+	return StatusError
+}
+
+// HTTPStatus returns the status label for an HTTP response code
+func HTTPStatus(code int) string {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return StatusBusy
+	case code >= 500:
+		return StatusError
+	case code >= 400:
+		return StatusInvalid
+	}
+	return StatusSuccess
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(r *Registry) string {
+	rr := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	return rr.Body.String()
+}
+
+func TestCounterVec_Exposition(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Requests handled.", "mode", "status")
+	c.Inc("fast", "success")
+	c.Inc("fast", "success")
+	c.Add(3, "deep", `quote"d`)
+	c.Add(-1, "fast", "success") // Counters never go down
+
+	assert.Equal(t, 2.0, c.Value("fast", "success"))
+	assert.Equal(t, `# HELP test_requests_total Requests handled.
+# TYPE test_requests_total counter
+test_requests_total{mode="deep",status="quote\"d"} 3
+test_requests_total{mode="fast",status="success"} 2
+`, scrape(r))
+}
+
+func TestHistogramVec_Exposition(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "stage")
+	h.Observe(0.05, "surface")
+	h.Observe(0.5, "surface")
+	h.Observe(2, "surface")
+
+	assert.Equal(t, uint64(3), h.Count("surface"))
+	assert.Equal(t, `# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{stage="surface",le="0.1"} 1
+test_duration_seconds_bucket{stage="surface",le="1"} 2
+test_duration_seconds_bucket{stage="surface",le="+Inf"} 3
+test_duration_seconds_sum{stage="surface"} 2.55
+test_duration_seconds_count{stage="surface"} 3
+`, scrape(r))
+}
+
+func TestRegistry_DuplicateName(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "")
+	assert.Panics(t, func() { r.NewCounterVec("test_total", "") })
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,8 @@ type Article struct {
 	Content     string                   `json:"content"`
 	Source      string                   `json:"source"`
 	Author      string                   `json:"author,omitempty"`
+	Language    string                   `json:"language,omitempty"`    // ISO 639-1 code detected from the content
+	ContentHash string                   `json:"contentHash,omitempty"` // SHA-256 of the normalized content, unique per stored article
 	PublishDate time.Time                `json:"publishDate"`
 	ExtractedAt time.Time                `json:"extractedAt"`
 	Entities    []*ExtractedEntity       `json:"entities,omitempty"`
@@ -29,8 +32,43 @@ type ExtractedEntity struct {
 	Properties  map[string]interface{} `json:"properties"`
 	Confidence  float64                `json:"confidence"`
 	Mentions    []EntityMention        `json:"mentions"`
+	Aliases     []string               `json:"aliases,omitempty"`    // Other names, titles, nicknames and acronyms
+	Indicators  []string               `json:"indicators,omitempty"` // Corruption indicator flags, e.g. conflict_of_interest
 	ArticleID   string                 `json:"articleId"`
 	ExtractedAt time.Time              `json:"extractedAt"`
+
+	// PropertyConfidence holds a confidence per key in Properties, so a
+	// well-supported role can be trusted more than a guessed net worth
+	PropertyConfidence map[string]float64 `json:"propertyConfidence,omitempty"`
+}
+
+// MergePropertyConfidence keeps the higher confidence for each property
+func (e *ExtractedEntity) MergePropertyConfidence(confidence map[string]float64) {
+	for property, value := range confidence {
+		if e.PropertyConfidence == nil {
+			e.PropertyConfidence = make(map[string]float64)
+		}
+		if current, ok := e.PropertyConfidence[property]; !ok || value > current {
+			e.PropertyConfidence[property] = value
+		}
+	}
+}
+
+// AddAliases appends aliases with whitespace collapsed, skipping blanks, the
+// entity's own name and case-insensitive duplicates
+func (e *ExtractedEntity) AddAliases(aliases ...string) {
+	seen := map[string]bool{strings.ToLower(strings.Join(strings.Fields(e.Name), " ")): true}
+	for _, alias := range e.Aliases {
+		seen[strings.ToLower(alias)] = true
+	}
+	for _, alias := range aliases {
+		alias = strings.Join(strings.Fields(alias), " ")
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		e.Aliases = append(e.Aliases, alias)
+	}
 }
 
 // EntityMention represents a specific mention of an entity in the text
@@ -38,9 +76,10 @@ type EntityMention struct {
 	Text     string `json:"text"`
 	Context  string `json:"context"`
 	Position struct {
-		Start int `json:"start"`
-		End   int `json:"end"`
+		Start int `json:"start"` // Character offset of Text in the article content
+		End   int `json:"end"`   // Character offset just past Text
 	} `json:"position"`
+	NotFound bool `json:"notFound,omitempty"` // Text isn't in the article content, so Position is unset
 }
 
 // ExtractedRelationship represents a relationship between entities found in an article
@@ -52,10 +91,27 @@ type ExtractedRelationship struct {
 	Properties  map[string]interface{} `json:"properties"`
 	Confidence  float64                `json:"confidence"`
 	Context     string                 `json:"context"`
+	Indicators  []string               `json:"indicators,omitempty"` // Corruption indicator flags, e.g. conflict_of_interest
 	ArticleID   string                 `json:"articleId"`
 	ExtractedAt time.Time              `json:"extractedAt"`
 }
 
+// Corruption indicator flags recorded on entities and relationships
+const (
+	IndicatorFinancialIrregularity = "financial_irregularity"
+	IndicatorConflictOfInterest    = "conflict_of_interest"
+	IndicatorAbuseOfPower          = "abuse_of_power"
+	IndicatorLackOfTransparency    = "lack_of_transparency"
+)
+
+// CorruptionIndicators lists every indicator flag
+var CorruptionIndicators = []string{
+	IndicatorFinancialIrregularity,
+	IndicatorConflictOfInterest,
+	IndicatorAbuseOfPower,
+	IndicatorLackOfTransparency,
+}
+
 // ExtractionResult contains all information extracted from an article
 type ExtractionResult struct {
 	Article        *Article                `json:"article,omitempty"`
@@ -64,4 +120,16 @@ type ExtractionResult struct {
 	Confidence     float64                 `json:"confidence"`
 	ProcessingTime time.Duration           `json:"processingTime,omitempty"`
 	Error          string                  `json:"error,omitempty"`
+	Warnings       []string                `json:"warnings,omitempty"` // Items left out of the graph, e.g. below a confidence threshold
+
+	// References counts relationship endpoints that named no extracted entity
+	References *ReferenceReport `json:"references,omitempty"`
+}
+
+// ReferenceReport counts relationship endpoints that didn't match an extracted
+// entity ID and what was done with them
+type ReferenceReport struct {
+	Repaired    int `json:"repaired"`    // Pointed at the extracted entity they closely match
+	Synthesized int `json:"synthesized"` // Given a new entity named after the reference
+	Dropped     int `json:"dropped"`     // Relationships left out because an endpoint matched nothing
 }
@@ -10,4 +10,5 @@ type ProcessingResult struct {
 	SourceURL   string                 `json:"sourceUrl"`
 	PublishedAt string                 `json:"publishedAt,omitempty"`
 	WordCount   int                    `json:"wordCount"`
+	Language    string                 `json:"language,omitempty"` // ISO 639-1 code detected from the content
 }
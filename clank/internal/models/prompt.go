@@ -38,6 +38,15 @@ type Prompt struct {
 	CompiledAt time.Time `json:"-"`
 }
 
+// PromptVersion records one loaded revision of a prompt
+type PromptVersion struct {
+	Version  int       `json:"version"`
+	Hash     string    `json:"hash"`
+	Template string    `json:"template"`
+	LoadedAt time.Time `json:"loaded_at"`
+	Active   bool      `json:"active"`
+}
+
 // PromptContext represents the context data passed to a prompt
 type PromptContext struct {
 	Arguments map[string]any `json:"arguments"`
@@ -58,11 +67,13 @@ type PromptResult struct {
 	Timestamp    time.Time      `json:"timestamp"`
 }
 
-// ValidationError represents a prompt validation error
+// ValidationError represents a prompt validation error. When several
+// arguments are invalid at once, each one is listed in Errors.
 type ValidationError struct {
-	Field   string `json:"field"`
-	Message string `json:"message"`
-	Value   any    `json:"value,omitempty"`
+	Field   string            `json:"field"`
+	Message string            `json:"message"`
+	Value   any               `json:"value,omitempty"`
+	Errors  []ValidationError `json:"errors,omitempty"`
 }
 
 func (ve ValidationError) Error() string {
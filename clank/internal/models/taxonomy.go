@@ -0,0 +1,124 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultEntityTypes are the allowed entity types and the aliases mapped onto them
+var DefaultEntityTypes = map[string][]string{
+	"person":       {"people", "individual", "official", "politician"},
+	"organization": {"org", "organisation", "ngo", "party", "group"},
+	"company":      {"corporation", "corp", "firm", "business", "contractor"},
+	"government":   {"government_agency", "agency", "ministry", "department"},
+	"location":     {"place", "country", "city", "region"},
+	"money":        {"amount", "funds"},
+	"time":         {"date", "period"},
+	"event":        {"incident"},
+}
+
+// RelationshipTypes are the relationship types that may be stored as Neo4j
+// relationship types: those the extraction prompts ask for, and the typed edges
+// written for ownership, payment, employment and event ordering
+var RelationshipTypes = []string{
+	"PAYMENT", "EMPLOYMENT", "OWNERSHIP", "INVESTIGATION", "ACCUSATION", "AFFILIATION", "INVOLVEMENT",
+	"PAID", "OWNS", "WORKS_FOR", "INVOLVED_IN", "PART_OF", "RELATED_TO", "FAMILY_OF", "ASSOCIATED_WITH",
+	"BEFORE", "AFTER", "CAUSED", "CAUSED_BY",
+}
+
+var (
+	// ErrUnknownEntityType is returned for entity types outside the taxonomy
+	ErrUnknownEntityType = errors.New("unknown entity type")
+	// ErrUnknownRelationshipType is returned for relationship types outside RelationshipTypes
+	ErrUnknownRelationshipType = errors.New("unknown relationship type")
+	// ErrInvalidLabel is returned for strings that can't be used as a Neo4j label
+	ErrInvalidLabel = errors.New("invalid label")
+
+	// labelPattern is what may be interpolated into Cypher as a label or relationship type
+	labelPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+	entityTaxonomy atomic.Pointer[EntityTaxonomy]
+)
+
+// EntityTaxonomy maps entity types and their aliases onto the allowed types
+type EntityTaxonomy struct {
+	canonical map[string]string // normalized type or alias -> allowed type
+	fallback  string
+}
+
+// NewEntityTaxonomy creates a taxonomy of the given types (each with its aliases).
+// Unknown types are mapped to fallback when it is set and rejected otherwise.
+func NewEntityTaxonomy(types map[string][]string, fallback string) *EntityTaxonomy {
+	t := &EntityTaxonomy{canonical: make(map[string]string), fallback: entityTypeKey(fallback)}
+	for entityType, aliases := range types {
+		key := entityTypeKey(entityType)
+		t.canonical[key] = key
+		for _, alias := range aliases {
+			t.canonical[entityTypeKey(alias)] = key
+		}
+	}
+	if t.fallback != "" {
+		t.canonical[t.fallback] = t.fallback
+	}
+	return t
+}
+
+// Normalize returns the allowed type for entityType, matching case-insensitively
+// and treating spaces and hyphens as underscores ("Government Agency" matches the
+// alias "government_agency")
+func (t *EntityTaxonomy) Normalize(entityType string) (string, error) {
+	key := entityTypeKey(entityType)
+	if canonical, ok := t.canonical[key]; ok {
+		return canonical, nil
+	}
+	if t.fallback != "" && key != "" {
+		return t.fallback, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownEntityType, entityType)
+}
+
+// SetEntityTaxonomy replaces the taxonomy used by NormalizeEntityType
+func SetEntityTaxonomy(t *EntityTaxonomy) {
+	entityTaxonomy.Store(t)
+}
+
+// NormalizeEntityType normalizes entityType against the configured taxonomy, or
+// DefaultEntityTypes when none was set
+func NormalizeEntityType(entityType string) (string, error) {
+	t := entityTaxonomy.Load()
+	if t == nil {
+		t = NewEntityTaxonomy(DefaultEntityTypes, "")
+		entityTaxonomy.CompareAndSwap(nil, t)
+	}
+	return t.Normalize(entityType)
+}
+
+// NormalizeRelationshipType returns relType as one of RelationshipTypes, matching
+// case-insensitively and treating spaces and hyphens as underscores ("works for"
+// is WORKS_FOR)
+func NormalizeRelationshipType(relType string) (string, error) {
+	key := strings.ToUpper(entityTypeKey(relType))
+	for _, allowed := range RelationshipTypes {
+		if key == allowed {
+			return allowed, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownRelationshipType, relType)
+}
+
+// SanitizeLabel returns label if it is safe to interpolate into Cypher as a node
+// label or relationship type: letters, digits and underscores, not starting with a digit
+func SanitizeLabel(label string) (string, error) {
+	if !labelPattern.MatchString(label) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLabel, label)
+	}
+	return label, nil
+}
+
+func entityTypeKey(entityType string) string {
+	key := strings.ToLower(strings.TrimSpace(entityType))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+}
@@ -0,0 +1,81 @@
+package prompts
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// validArgumentTypes are the argument types a prompt may declare ("" accepts anything)
+var validArgumentTypes = map[string]bool{
+	"":        true,
+	"any":     true,
+	"string":  true,
+	"number":  true,
+	"boolean": true,
+	"bool":    true,
+	"array":   true,
+	"object":  true,
+}
+
+// coerceArgument converts value to the declared argument type, or returns an
+// error describing the mismatch
+func coerceArgument(argType string, value any) (any, error) {
+	switch argType {
+	case "", "any":
+		return value, nil
+
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case bool, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return fmt.Sprint(v), nil
+		}
+
+	case "number":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int, int8, int16, int32, int64:
+			return float64(reflect.ValueOf(v).Int()), nil
+		case uint, uint8, uint16, uint32, uint64:
+			return float64(reflect.ValueOf(v).Uint()), nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+
+	case "boolean", "bool":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+
+	case "array":
+		if value != nil {
+			kind := reflect.TypeOf(value).Kind()
+			if kind == reflect.Slice || kind == reflect.Array {
+				return value, nil
+			}
+		}
+
+	case "object":
+		if value != nil && reflect.TypeOf(value).Kind() == reflect.Map {
+			return value, nil
+		}
+
+	default:
+		return nil, fmt.Errorf("has unsupported type '%s'", argType)
+	}
+
+	return nil, fmt.Errorf("must be of type %s, got %T", argType, value)
+}
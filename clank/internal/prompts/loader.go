@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"text/template"
@@ -23,6 +24,10 @@ type PromptLoader struct {
 	promptsDir   string
 	watchEnabled bool
 	lastModified map[string]time.Time
+	versions     map[string][]*promptVersion
+	nextVersion  map[string]int
+	maxVersions  int
+	archiveDir   string
 }
 
 // NewPromptLoader creates a new prompt loader
@@ -32,6 +37,9 @@ func NewPromptLoader(promptsDir string) *PromptLoader {
 		templates:    make(map[string]*template.Template),
 		promptsDir:   promptsDir,
 		lastModified: make(map[string]time.Time),
+		versions:     make(map[string][]*promptVersion),
+		nextVersion:  make(map[string]int),
+		maxVersions:  defaultMaxVersions,
 	}
 }
 
@@ -95,6 +103,7 @@ func (pl *PromptLoader) LoadPromptFile(filePath string) error {
 	pl.prompts[prompt.Name] = &prompt
 	pl.templates[prompt.Name] = tmpl
 	pl.lastModified[filePath] = fileInfo.ModTime()
+	pl.recordVersion(&prompt, tmpl, data)
 
 	fmt.Printf("Loaded prompt: %s from %s\n", prompt.Name, filePath)
 	return nil
@@ -118,6 +127,22 @@ func (pl *PromptLoader) validatePrompt(prompt *models.Prompt) error {
 				Message: "argument name is required",
 			}
 		}
+		if !validArgumentTypes[arg.Type] {
+			return &models.ValidationError{
+				Field:   fmt.Sprintf("arguments[%d].type", i),
+				Message: fmt.Sprintf("unsupported argument type '%s'", arg.Type),
+				Value:   arg.Type,
+			}
+		}
+		if arg.Default != nil {
+			if _, err := coerceArgument(arg.Type, arg.Default); err != nil {
+				return &models.ValidationError{
+					Field:   fmt.Sprintf("arguments[%d].default", i),
+					Message: fmt.Sprintf("default for '%s' %v", arg.Name, err),
+					Value:   arg.Default,
+				}
+			}
+		}
 	}
 
 	return nil
@@ -155,7 +180,10 @@ func (pl *PromptLoader) compileTemplate(prompt *models.Prompt) (*template.Templa
 	}
 
 	// Convert Handlebars syntax to Go template syntax
-	templateText := pl.convertHandlebarsToGo(prompt.Template)
+	templateText, err := pl.convertHandlebarsToGo(prompt.Template)
+	if err != nil {
+		return nil, fmt.Errorf("template conversion error: %w", err)
+	}
 
 	tmpl, err := template.New(prompt.Name).Funcs(funcMap).Parse(templateText)
 	if err != nil {
@@ -165,20 +193,143 @@ func (pl *PromptLoader) compileTemplate(prompt *models.Prompt) (*template.Templa
 	return tmpl, nil
 }
 
-// convertHandlebarsToGo converts Handlebars syntax to Go template syntax
-func (pl *PromptLoader) convertHandlebarsToGo(text string) string {
-	// Convert {{#if condition}} to {{if condition}}
-	text = strings.ReplaceAll(text, "{{#if ", "{{if ")
+// convertHandlebarsToGo converts Handlebars syntax to Go template syntax.
+// The template is tokenized into text and {{...}} tags so block helpers can be
+// matched up: {{#if}}, {{#unless}}, {{#each}} and {{#with}} become if/range/with
+// actions, {{else}} is kept, closing tags become {{end}}, and bare paths such as
+// {{name}}, {{this}} and {{../name}} become .name, . and $.name. Tags that are
+// already Go template syntax are passed through unchanged.
+func (pl *PromptLoader) convertHandlebarsToGo(text string) (string, error) {
+	var out strings.Builder
+	var blocks []string
+	offset := 0
+
+	for {
+		start := strings.Index(text, "{{")
+		if start < 0 {
+			out.WriteString(text)
+			break
+		}
+		out.WriteString(text[:start])
 
-	// Convert {{/if}} to {{end}}
-	text = strings.ReplaceAll(text, "{{/if}}", "{{end}}")
+		// {{{variable}}} (unescaped) is treated like {{variable}}
+		open, closing := "{{", "}}"
+		if strings.HasPrefix(text[start:], "{{{") {
+			open, closing = "{{{", "}}}"
+		}
+
+		exprStart := start + len(open)
+		length := strings.Index(text[exprStart:], closing)
+		if length < 0 {
+			return "", fmt.Errorf("unclosed tag at offset %d", offset+start)
+		}
 
-	// Convert {{{variable}}} (unescaped) to {{.variable}}
-	text = strings.ReplaceAll(text, "{{{", "{{")
-	text = strings.ReplaceAll(text, "}}}", "}}")
+		tag, err := convertHandlebarsTag(strings.TrimSpace(text[exprStart:exprStart+length]), &blocks)
+		if err != nil {
+			return "", fmt.Errorf("%w at offset %d", err, offset+start)
+		}
+		out.WriteString("{{" + tag + "}}")
+
+		consumed := exprStart + length + len(closing)
+		text = text[consumed:]
+		offset += consumed
+	}
+
+	if len(blocks) > 0 {
+		return "", fmt.Errorf("unclosed {{#%s}} block", blocks[len(blocks)-1])
+	}
+
+	return out.String(), nil
+}
+
+// handlebarsPath matches a bare Handlebars path expression such as name, this.name or ../name
+var handlebarsPath = regexp.MustCompile(`^(\.\./)*[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// goTemplateKeywords are bare words that already mean something in Go templates
+var goTemplateKeywords = map[string]bool{
+	"end": true, "else": true, "nil": true, "true": true, "false": true, "break": true, "continue": true,
+}
+
+// convertHandlebarsTag converts the expression inside a single tag, tracking open blocks
+func convertHandlebarsTag(expr string, blocks *[]string) (string, error) {
+	// Go template comments and trim markers are already Go syntax
+	if strings.HasPrefix(expr, "/*") || strings.HasPrefix(expr, "-") || strings.HasSuffix(expr, "-") {
+		return expr, nil
+	}
+
+	switch {
+	case strings.HasPrefix(expr, "!"):
+		comment := strings.TrimSpace(strings.Trim(strings.TrimPrefix(expr, "!"), "-"))
+		return "/* " + comment + " */", nil
+
+	case strings.HasPrefix(expr, "#"):
+		name, arg, _ := strings.Cut(strings.TrimPrefix(expr, "#"), " ")
+		arg = strings.TrimSpace(arg)
+		if arg == "" {
+			return "", fmt.Errorf("{{#%s}} requires an argument", name)
+		}
+
+		var action string
+		switch name {
+		case "if":
+			action = "if " + convertHandlebarsExpr(arg)
+		case "unless":
+			action = "if not " + convertHandlebarsExpr(arg)
+		case "each":
+			action = "range " + convertHandlebarsExpr(arg)
+		case "with":
+			action = "with " + convertHandlebarsExpr(arg)
+		default:
+			return "", fmt.Errorf("unsupported block helper {{#%s}}", name)
+		}
+		*blocks = append(*blocks, name)
+		return action, nil
+
+	case strings.HasPrefix(expr, "/"):
+		name := strings.TrimSpace(strings.TrimPrefix(expr, "/"))
+		if len(*blocks) == 0 {
+			return "", fmt.Errorf("unexpected {{/%s}}", name)
+		}
+		open := (*blocks)[len(*blocks)-1]
+		if open != name {
+			return "", fmt.Errorf("{{/%s}} does not close {{#%s}}", name, open)
+		}
+		*blocks = (*blocks)[:len(*blocks)-1]
+		return "end", nil
+
+	case expr == "else" || strings.HasPrefix(expr, "else "):
+		if cond, ok := strings.CutPrefix(expr, "else if "); ok {
+			return "else if " + convertHandlebarsExpr(strings.TrimSpace(cond)), nil
+		}
+		return "else", nil
+	}
 
-	// For now, leave variable resolution as-is
-	return text
+	return convertHandlebarsExpr(expr), nil
+}
+
+// convertHandlebarsExpr converts a bare Handlebars path to a Go template field reference.
+// Anything that isn't a bare path (function calls, pipelines, .Fields) is returned as-is.
+func convertHandlebarsExpr(expr string) string {
+	if goTemplateKeywords[expr] || !handlebarsPath.MatchString(expr) {
+		return expr
+	}
+
+	root := "."
+	if strings.HasPrefix(expr, "../") {
+		root = "$."
+		for strings.HasPrefix(expr, "../") {
+			expr = strings.TrimPrefix(expr, "../")
+		}
+	}
+
+	if expr == "this" {
+		if root == "$." {
+			return "$"
+		}
+		return "."
+	}
+	expr = strings.TrimPrefix(expr, "this.")
+	return root + expr
 }
 
 // GetPrompt returns a prompt by name
@@ -208,8 +359,6 @@ func (pl *PromptLoader) ListPrompts() map[string]*models.Prompt {
 
 // RenderPrompt renders a prompt with the given context
 func (pl *PromptLoader) RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error) {
-	startTime := time.Now()
-
 	pl.mu.RLock()
 	prompt, exists := pl.prompts[name]
 	tmpl, tmplExists := pl.templates[name]
@@ -218,15 +367,22 @@ func (pl *PromptLoader) RenderPrompt(name string, context *models.PromptContext)
 	if !exists || !tmplExists {
 		return nil, fmt.Errorf("prompt not found: %s", name)
 	}
+	return pl.render(name, prompt, tmpl, context)
+}
+
+// render executes a prompt's compiled template with the given context
+func (pl *PromptLoader) render(name string, prompt *models.Prompt, tmpl *template.Template, context *models.PromptContext) (*models.PromptResult, error) {
+	startTime := time.Now()
 
-	// Validate required arguments
-	if err := pl.validateContext(prompt, context); err != nil {
+	// Validate arguments, applying defaults and type coercion
+	arguments, err := pl.validateContext(prompt, context.Arguments)
+	if err != nil {
 		return nil, fmt.Errorf("context validation failed: %w", err)
 	}
 
 	// Flatten context for {{context}} helper
 	flatCtx := map[string]interface{}{
-		"Arguments": context.Arguments,
+		"Arguments": arguments,
 		"Metadata":  context.Metadata,
 		"NodeData":  context.NodeData,
 		"UserID":    context.UserID,
@@ -236,7 +392,7 @@ func (pl *PromptLoader) RenderPrompt(name string, context *models.PromptContext)
 
 	// Prepare template data
 	data := map[string]interface{}{
-		"Arguments": context.Arguments,
+		"Arguments": arguments,
 		"Metadata":  context.Metadata,
 		"NodeData":  context.NodeData,
 		"UserID":    context.UserID,
@@ -245,6 +401,13 @@ func (pl *PromptLoader) RenderPrompt(name string, context *models.PromptContext)
 		"Context":   flatCtx, // 🔑 needed for {{context "UserID" .Context}}
 	}
 
+	// Expose arguments at the top level so Handlebars paths like {{name}} resolve
+	for k, v := range arguments {
+		if _, reserved := data[k]; !reserved {
+			data[k] = v
+		}
+	}
+
 	// Render template
 	var buf bytes.Buffer
 	if err := tmpl.Execute(&buf, data); err != nil {
@@ -254,26 +417,78 @@ func (pl *PromptLoader) RenderPrompt(name string, context *models.PromptContext)
 	return &models.PromptResult{
 		PromptName:   name,
 		RenderedText: buf.String(),
-		Arguments:    context.Arguments,
+		Arguments:    arguments,
 		Metadata:     context.Metadata,
 		RenderTime:   time.Since(startTime),
 		Timestamp:    time.Now(),
 	}, nil
 }
 
-// validateContext validates that required arguments are provided
-func (pl *PromptLoader) validateContext(prompt *models.Prompt, context *models.PromptContext) error {
+// validateContext checks the provided arguments against the prompt's declared
+// arguments. Missing optional arguments are filled from their defaults and values
+// are coerced to their declared type. Every invalid argument is reported in a
+// single ValidationError.
+func (pl *PromptLoader) validateContext(prompt *models.Prompt, arguments map[string]any) (map[string]any, error) {
+	resolved := make(map[string]any, len(arguments))
+	for k, v := range arguments {
+		resolved[k] = v
+	}
+
+	var errs []models.ValidationError
 	for _, arg := range prompt.Arguments {
-		if arg.Required {
-			if _, exists := context.Arguments[arg.Name]; !exists {
-				return &models.ValidationError{
+		value, exists := resolved[arg.Name]
+		if !exists || value == nil {
+			if arg.Default != nil {
+				value = arg.Default
+			} else if arg.Required {
+				errs = append(errs, models.ValidationError{
 					Field:   arg.Name,
 					Message: fmt.Sprintf("required argument '%s' is missing", arg.Name),
-				}
+				})
+				continue
+			} else {
+				continue
 			}
 		}
+
+		coerced, err := coerceArgument(arg.Type, value)
+		if err != nil {
+			errs = append(errs, models.ValidationError{
+				Field:   arg.Name,
+				Message: fmt.Sprintf("argument '%s' %v", arg.Name, err),
+				Value:   value,
+			})
+			continue
+		}
+		resolved[arg.Name] = coerced
 	}
-	return nil
+
+	switch len(errs) {
+	case 0:
+		return resolved, nil
+	case 1:
+		return nil, &errs[0]
+	}
+
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+	}
+	return nil, &models.ValidationError{
+		Field:   "arguments",
+		Message: fmt.Sprintf("%d invalid arguments: %s", len(errs), strings.Join(messages, "; ")),
+		Errors:  errs,
+	}
+}
+
+// ValidateArguments validates arguments for a prompt and returns them with
+// defaults applied and values coerced to their declared types
+func (pl *PromptLoader) ValidateArguments(name string, arguments map[string]any) (map[string]any, error) {
+	prompt, err := pl.GetPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+	return pl.validateContext(prompt, arguments)
 }
 
 // ReloadPrompt reloads a specific prompt file
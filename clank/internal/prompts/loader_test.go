@@ -0,0 +1,286 @@
+package prompts
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLoader returns a loader with the given prompts compiled in memory
+func newTestLoader(t *testing.T, prompts ...*models.Prompt) *PromptLoader {
+	t.Helper()
+	loader := NewPromptLoader(t.TempDir())
+	for _, prompt := range prompts {
+		tmpl, err := loader.compileTemplate(prompt)
+		require.NoError(t, err)
+		loader.prompts[prompt.Name] = prompt
+		loader.templates[prompt.Name] = tmpl
+	}
+	return loader
+}
+
+func renderTestPrompt(t *testing.T, loader *PromptLoader, name string, args map[string]any) (string, error) {
+	t.Helper()
+	result, err := loader.RenderPrompt(name, &models.PromptContext{Arguments: args, Timestamp: time.Now()})
+	if err != nil {
+		return "", err
+	}
+	return result.RenderedText, nil
+}
+
+func TestConvertHandlebarsToGo(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{
+			name:     "if block",
+			input:    "{{#if context}}Context: {{{context}}}{{/if}}",
+			expected: "{{if .context}}Context: {{.context}}{{end}}",
+		},
+		{
+			name:     "if else",
+			input:    "{{#if a}}yes{{else}}no{{/if}}",
+			expected: "{{if .a}}yes{{else}}no{{end}}",
+		},
+		{
+			name:     "unless",
+			input:    "{{#unless a}}missing{{/unless}}",
+			expected: "{{if not .a}}missing{{end}}",
+		},
+		{
+			name:     "each with this and parent path",
+			input:    "{{#each items}}{{this}} of {{../owner}}{{/each}}",
+			expected: "{{range .items}}{{.}} of {{$.owner}}{{end}}",
+		},
+		{
+			name:     "each over objects",
+			input:    "{{#each people}}{{name}} ({{this.role}}){{/each}}",
+			expected: "{{range .people}}{{.name}} ({{.role}}){{end}}",
+		},
+		{
+			name:     "go template syntax passes through",
+			input:    `{{if .Arguments.x}}{{json .Arguments.x}}{{else}}{{context "UserID" .Context}}{{end}}`,
+			expected: `{{if .Arguments.x}}{{json .Arguments.x}}{{else}}{{context "UserID" .Context}}{{end}}`,
+		},
+		{
+			name:     "comment",
+			input:    "{{! internal note }}text",
+			expected: "{{/* internal note */}}text",
+		},
+		{
+			name:        "mismatched close",
+			input:       "{{#each items}}{{/if}}",
+			expectError: true,
+		},
+		{
+			name:        "unclosed block",
+			input:       "{{#if a}}text",
+			expectError: true,
+		},
+		{
+			name:        "unclosed tag",
+			input:       "{{#if a",
+			expectError: true,
+		},
+	}
+
+	loader := NewPromptLoader(t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := loader.convertHandlebarsToGo(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRenderPrompt_HandlebarsBlocks(t *testing.T) {
+	loader := newTestLoader(t, &models.Prompt{
+		Name:     "report",
+		Template: "Entities:{{#each entities}} {{this}};{{/each}}\n{{#if urgent}}URGENT{{else}}routine{{/if}}",
+	})
+
+	text, err := renderTestPrompt(t, loader, "report", map[string]any{
+		"entities": []string{"Acme Corp", "Jane Doe"},
+		"urgent":   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Entities: Acme Corp; Jane Doe;\nURGENT", text)
+
+	text, err = renderTestPrompt(t, loader, "report", map[string]any{
+		"entities": []string{},
+		"urgent":   false,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Entities:\nroutine", text)
+}
+
+func TestRenderPrompt_ArgumentValidation(t *testing.T) {
+	loader := newTestLoader(t, &models.Prompt{
+		Name: "analysis",
+		Arguments: []models.PromptArgument{
+			{Name: "entity", Type: "string", Required: true},
+			{Name: "depth", Type: "number", Default: 2},
+			{Name: "verbose", Type: "boolean", Default: false},
+			{Name: "topics", Type: "array"},
+		},
+		Template: "{{entity}} depth={{depth}} verbose={{verbose}}",
+	})
+
+	t.Run("fills defaults", func(t *testing.T) {
+		text, err := renderTestPrompt(t, loader, "analysis", map[string]any{"entity": "Acme Corp"})
+		require.NoError(t, err)
+		assert.Equal(t, "Acme Corp depth=2 verbose=false", text)
+	})
+
+	t.Run("coerces compatible values", func(t *testing.T) {
+		text, err := renderTestPrompt(t, loader, "analysis", map[string]any{
+			"entity":  "Acme Corp",
+			"depth":   "4",
+			"verbose": "true",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Acme Corp depth=4 verbose=true", text)
+	})
+
+	t.Run("type mismatch", func(t *testing.T) {
+		_, err := renderTestPrompt(t, loader, "analysis", map[string]any{
+			"entity": "Acme Corp",
+			"depth":  "deep",
+		})
+		var ve *models.ValidationError
+		require.ErrorAs(t, err, &ve)
+		assert.Equal(t, "depth", ve.Field)
+		assert.Equal(t, "deep", ve.Value)
+		assert.Empty(t, ve.Errors)
+	})
+
+	t.Run("reports every invalid argument", func(t *testing.T) {
+		_, err := loader.ValidateArguments("analysis", map[string]any{
+			"depth":   map[string]any{"levels": 3},
+			"verbose": "sometimes",
+			"topics":  "corruption",
+		})
+		var ve *models.ValidationError
+		require.ErrorAs(t, err, &ve)
+		require.Len(t, ve.Errors, 4)
+
+		fields := make([]string, len(ve.Errors))
+		for i, e := range ve.Errors {
+			fields[i] = e.Field
+		}
+		assert.Equal(t, []string{"entity", "depth", "verbose", "topics"}, fields)
+		assert.Contains(t, ve.Message, "4 invalid arguments")
+	})
+}
+
+func TestValidatePrompt_ArgumentTypes(t *testing.T) {
+	loader := NewPromptLoader(t.TempDir())
+
+	err := loader.validatePrompt(&models.Prompt{
+		Name:      "bad-type",
+		Template:  "x",
+		Arguments: []models.PromptArgument{{Name: "a", Type: "date"}},
+	})
+	assert.Error(t, err)
+
+	err = loader.validatePrompt(&models.Prompt{
+		Name:      "bad-default",
+		Template:  "x",
+		Arguments: []models.PromptArgument{{Name: "a", Type: "number", Default: "many"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestPromptLoader_VersionRollback(t *testing.T) {
+	dir := t.TempDir()
+	archive := t.TempDir()
+	loader := NewPromptLoader(dir)
+	loader.SetVersionHistory(defaultMaxVersions, archive)
+
+	var path string
+	for _, tmpl := range []string{"first", "second", "third"} {
+		path = writePromptFile(t, dir, "greeting.json", "greeting", tmpl)
+		require.NoError(t, loader.LoadPromptFile(path))
+	}
+
+	// Reloading unchanged contents does not add a version
+	require.NoError(t, loader.LoadPromptFile(path))
+
+	versions, err := loader.GetPromptVersions("greeting")
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, []int{1, 2, 3}, []int{versions[0].Version, versions[1].Version, versions[2].Version})
+	assert.True(t, versions[2].Active)
+	assert.NotEqual(t, versions[0].Hash, versions[2].Hash)
+	assert.FileExists(t, filepath.Join(archive, "greeting", "v3.json"))
+
+	require.NoError(t, loader.RollbackPrompt("greeting", 1))
+
+	text, err := renderTestPrompt(t, loader, "greeting", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first", text)
+
+	versions, err = loader.GetPromptVersions("greeting")
+	require.NoError(t, err)
+	assert.True(t, versions[0].Active)
+	assert.False(t, versions[2].Active)
+
+	assert.Error(t, loader.RollbackPrompt("greeting", 7))
+	assert.Error(t, loader.RollbackPrompt("missing", 1))
+}
+
+// activeOnlyRenderer renders prompts without keeping earlier versions
+type activeOnlyRenderer struct{}
+
+func (activeOnlyRenderer) RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error) {
+	return &models.PromptResult{PromptName: name, RenderedText: "active"}, nil
+}
+
+func TestRenderPinned(t *testing.T) {
+	dir := t.TempDir()
+	loader := NewPromptLoader(dir)
+	for _, tmpl := range []string{"first", "second"} {
+		require.NoError(t, loader.LoadPromptFile(writePromptFile(t, dir, "greeting.json", "greeting", tmpl)))
+	}
+	render := func(ctx context.Context, renderer Renderer) (string, error) {
+		result, err := RenderPinned(ctx, renderer, "greeting", &models.PromptContext{Timestamp: time.Now()})
+		if err != nil {
+			return "", err
+		}
+		return result.RenderedText, nil
+	}
+
+	text, err := render(context.Background(), loader)
+	require.NoError(t, err)
+	assert.Equal(t, "second", text)
+
+	pinned := WithVersions(context.Background(), map[string]int{"greeting": 1})
+	text, err = render(pinned, loader)
+	require.NoError(t, err)
+	assert.Equal(t, "first", text)
+	text, err = renderTestPrompt(t, loader, "greeting", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "second", text, "pinning leaves the active version as it is")
+
+	_, err = render(WithVersions(context.Background(), map[string]int{"greeting": 7}), loader)
+	assert.Error(t, err)
+	_, err = render(pinned, activeOnlyRenderer{})
+	assert.Error(t, err, "a renderer without versions doesn't fall back to the active one")
+	text, err = render(WithVersions(context.Background(), map[string]int{"other": 1}), activeOnlyRenderer{})
+	require.NoError(t, err)
+	assert.Equal(t, "active", text)
+}
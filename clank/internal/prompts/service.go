@@ -27,6 +27,14 @@ func NewService(promptsDir string) (*Service, error) {
 	}, nil
 }
 
+// NewServiceForLoader creates a prompt service over an already loaded loader, so
+// the service's versions and rollbacks apply to whatever else renders from it
+func NewServiceForLoader(loader *PromptLoader) *Service {
+	return &Service{
+		loader: loader,
+	}
+}
+
 // GetSystemPrompt renders the system prompt with context
 func (s *Service) GetSystemPrompt(ctx context.Context, graphContext string, userPreferences string) (string, error) {
 	promptCtx := &models.PromptContext{
@@ -118,26 +126,20 @@ func (s *Service) ReloadSpecificPrompt(name string) error {
 	return s.loader.ReloadPrompt(name)
 }
 
-// ValidatePromptArguments checks if the provided arguments are valid for a prompt
-func (s *Service) ValidatePromptArguments(name string, arguments map[string]any) error {
-	prompt, err := s.loader.GetPrompt(name)
-	if err != nil {
-		return err
-	}
+// GetPromptVersions returns the retained version history of a prompt
+func (s *Service) GetPromptVersions(name string) ([]models.PromptVersion, error) {
+	return s.loader.GetPromptVersions(name)
+}
 
-	// Check required arguments
-	for _, arg := range prompt.Arguments {
-		if arg.Required {
-			if _, exists := arguments[arg.Name]; !exists {
-				return &models.ValidationError{
-					Field:   arg.Name,
-					Message: fmt.Sprintf("required argument '%s' is missing", arg.Name),
-				}
-			}
-		}
-	}
+// RollbackPrompt makes a previous version of a prompt active
+func (s *Service) RollbackPrompt(name string, version int) error {
+	return s.loader.RollbackPrompt(name, version)
+}
 
-	return nil
+// ValidatePromptArguments checks if the provided arguments are valid for a prompt
+func (s *Service) ValidatePromptArguments(name string, arguments map[string]any) error {
+	_, err := s.loader.ValidateArguments(name, arguments)
+	return err
 }
 
 // CreatePromptContext creates a prompt context with additional metadata
@@ -0,0 +1,188 @@
+package prompts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"clank/internal/models"
+)
+
+// defaultMaxVersions is how many versions of each prompt are kept in memory
+const defaultMaxVersions = 10
+
+// promptVersion is a loaded revision of a prompt together with its compiled template
+type promptVersion struct {
+	version  int
+	hash     string
+	prompt   *models.Prompt
+	template *template.Template
+}
+
+// SetVersionHistory configures how many versions of each prompt are kept and,
+// when archiveDir is not empty, where each new version's file contents are archived
+func (pl *PromptLoader) SetVersionHistory(maxVersions int, archiveDir string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if maxVersions < 1 {
+		maxVersions = defaultMaxVersions
+	}
+	pl.maxVersions = maxVersions
+	pl.archiveDir = archiveDir
+}
+
+// recordVersion adds a loaded prompt to its version history. Reloading unchanged
+// contents does not create a new version. Callers must hold pl.mu.
+func (pl *PromptLoader) recordVersion(prompt *models.Prompt, tmpl *template.Template, data []byte) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	history := pl.versions[prompt.Name]
+	if n := len(history); n > 0 && history[n-1].hash == hash {
+		history[n-1].prompt = prompt
+		history[n-1].template = tmpl
+		return
+	}
+
+	pl.nextVersion[prompt.Name]++
+	version := &promptVersion{
+		version:  pl.nextVersion[prompt.Name],
+		hash:     hash,
+		prompt:   prompt,
+		template: tmpl,
+	}
+
+	history = append(history, version)
+	if len(history) > pl.maxVersions {
+		history = history[len(history)-pl.maxVersions:]
+	}
+	pl.versions[prompt.Name] = history
+
+	if pl.archiveDir != "" {
+		if err := archivePromptVersion(pl.archiveDir, prompt.Name, version.version, data); err != nil {
+			fmt.Printf("Error archiving prompt %s version %d: %v\n", prompt.Name, version.version, err)
+		}
+	}
+}
+
+// archivePromptVersion writes a version's file contents to archiveDir/<name>/v<version>.json
+func archivePromptVersion(archiveDir, name string, version int, data []byte) error {
+	dir := filepath.Join(archiveDir, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fmt.Sprintf("v%d.json", version)), data, 0o644)
+}
+
+// GetPromptVersions returns the retained versions of a prompt, oldest first
+func (pl *PromptLoader) GetPromptVersions(name string) ([]models.PromptVersion, error) {
+	pl.mu.RLock()
+	defer pl.mu.RUnlock()
+
+	history, exists := pl.versions[name]
+	if !exists {
+		return nil, fmt.Errorf("prompt not found: %s", name)
+	}
+
+	active := pl.prompts[name]
+	versions := make([]models.PromptVersion, len(history))
+	for i, v := range history {
+		versions[i] = models.PromptVersion{
+			Version:  v.version,
+			Hash:     v.hash,
+			Template: v.prompt.Template,
+			LoadedAt: v.prompt.LoadedAt,
+			Active:   v.prompt == active,
+		}
+	}
+	return versions, nil
+}
+
+// RollbackPrompt makes a previously loaded version the active one. The rollback
+// lasts until the prompt file changes again and is reloaded.
+func (pl *PromptLoader) RollbackPrompt(name string, version int) error {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	history, exists := pl.versions[name]
+	if !exists {
+		return fmt.Errorf("prompt not found: %s", name)
+	}
+
+	for _, v := range history {
+		if v.version == version {
+			pl.prompts[name] = v.prompt
+			pl.templates[name] = v.template
+			fmt.Printf("Rolled back prompt %s to version %d\n", name, version)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("version %d of prompt %s not found", version, name)
+}
+
+// RenderPromptVersion renders a retained version of a prompt with the given
+// context, leaving the active version as it is. Version 0 renders the active one.
+func (pl *PromptLoader) RenderPromptVersion(name string, version int, context *models.PromptContext) (*models.PromptResult, error) {
+	if version == 0 {
+		return pl.RenderPrompt(name, context)
+	}
+
+	pl.mu.RLock()
+	var found *promptVersion
+	for _, v := range pl.versions[name] {
+		if v.version == version {
+			found = v
+		}
+	}
+	pl.mu.RUnlock()
+
+	if found == nil {
+		return nil, fmt.Errorf("version %d of prompt %s not found", version, name)
+	}
+	return pl.render(name, found.prompt, found.template, context)
+}
+
+type versionsKey struct{}
+
+// WithVersions returns a context whose prompts, rendered with RenderPinned, use
+// the given versions by prompt name instead of the active ones, e.g. to compare
+// prompt versions on the same article
+func WithVersions(ctx context.Context, versions map[string]int) context.Context {
+	return context.WithValue(ctx, versionsKey{}, versions)
+}
+
+// PinnedVersion returns the version of the named prompt set by WithVersions, or
+// 0 when the active version is to be used
+func PinnedVersion(ctx context.Context, name string) int {
+	versions, _ := ctx.Value(versionsKey{}).(map[string]int)
+	return versions[name]
+}
+
+// Renderer renders the active version of a named prompt
+type Renderer interface {
+	RenderPrompt(name string, context *models.PromptContext) (*models.PromptResult, error)
+}
+
+// RenderPinned renders the named prompt with renderer, in the version pinned on
+// ctx by WithVersions if any. Only renderers keeping earlier versions, like a
+// PromptLoader, can render a pinned version; others fail rather than silently
+// rendering the active one.
+func RenderPinned(ctx context.Context, renderer Renderer, name string, promptContext *models.PromptContext) (*models.PromptResult, error) {
+	version := PinnedVersion(ctx, name)
+	if version == 0 {
+		return renderer.RenderPrompt(name, promptContext)
+	}
+	versioned, ok := renderer.(interface {
+		RenderPromptVersion(name string, version int, context *models.PromptContext) (*models.PromptResult, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("version %d of prompt %s requested, but its renderer keeps no versions", version, name)
+	}
+	return versioned.RenderPromptVersion(name, version, promptContext)
+}
@@ -0,0 +1,147 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long a prompt file must be quiet before it is reloaded
+const watchDebounce = 200 * time.Millisecond
+
+// Watch watches the prompts directory and reloads prompt files as they change.
+// Deleted files have their prompts removed. Watching stops when ctx is canceled.
+func (pl *PromptLoader) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create prompt watcher: %w", err)
+	}
+
+	// fsnotify is not recursive, so watch every directory LoadPrompts would walk
+	err = filepath.WalkDir(pl.promptsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch prompts directory %s: %w", pl.promptsDir, err)
+	}
+
+	pl.mu.Lock()
+	pl.watchEnabled = true
+	pl.mu.Unlock()
+
+	go pl.watchLoop(ctx, watcher)
+	return nil
+}
+
+// watchLoop debounces file events and applies them until ctx is done
+func (pl *PromptLoader) watchLoop(ctx context.Context, watcher *fsnotify.Watcher) {
+	var timersMu sync.Mutex
+	timers := make(map[string]*time.Timer)
+
+	defer func() {
+		watcher.Close()
+		timersMu.Lock()
+		for _, t := range timers {
+			t.Stop()
+		}
+		timersMu.Unlock()
+
+		pl.mu.Lock()
+		pl.watchEnabled = false
+		pl.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+
+			// Start watching directories created after Watch was called
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watcher.Add(event.Name); err != nil {
+						fmt.Printf("Error watching prompt directory %s: %v\n", event.Name, err)
+					}
+					continue
+				}
+			}
+
+			if !strings.HasSuffix(event.Name, ".json") {
+				continue
+			}
+
+			path := event.Name
+			timersMu.Lock()
+			if t, exists := timers[path]; exists {
+				t.Stop()
+			}
+			timers[path] = time.AfterFunc(watchDebounce, func() {
+				timersMu.Lock()
+				delete(timers, path)
+				timersMu.Unlock()
+
+				if ctx.Err() == nil {
+					pl.applyFileChange(path)
+				}
+			})
+			timersMu.Unlock()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("Prompt watcher error: %v\n", err)
+		}
+	}
+}
+
+// applyFileChange reloads a prompt file, or removes its prompts if it no longer exists
+func (pl *PromptLoader) applyFileChange(filePath string) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		removed := pl.removePromptFile(filePath)
+		for _, name := range removed {
+			fmt.Printf("Removed prompt: %s (file %s deleted)\n", name, filePath)
+		}
+		return
+	}
+
+	if err := pl.LoadPromptFile(filePath); err != nil {
+		fmt.Printf("Error reloading prompt file %s: %v\n", filePath, err)
+	}
+}
+
+// removePromptFile removes all prompts loaded from filePath and returns their names
+func (pl *PromptLoader) removePromptFile(filePath string) []string {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	var removed []string
+	for name, prompt := range pl.prompts {
+		if prompt.FilePath == filePath {
+			delete(pl.prompts, name)
+			delete(pl.templates, name)
+			removed = append(removed, name)
+		}
+	}
+	delete(pl.lastModified, filePath)
+	return removed
+}
@@ -0,0 +1,64 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePromptFile writes a minimal prompt definition to dir
+func writePromptFile(t *testing.T, dir, file, name, tmpl string) string {
+	t.Helper()
+	path := filepath.Join(dir, file)
+	content := `{"name": "` + name + `", "description": "test prompt", "template": "` + tmpl + `"}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestPromptLoader_Watch(t *testing.T) {
+	dir := t.TempDir()
+	writePromptFile(t, dir, "existing.json", "existing", "Hello")
+
+	loader := NewPromptLoader(dir)
+	require.NoError(t, loader.LoadPrompts())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, loader.Watch(ctx))
+
+	// New files become available without a manual reload
+	path := writePromptFile(t, dir, "added.json", "added", "Added prompt")
+	assert.Eventually(t, func() bool {
+		_, err := loader.GetPrompt("added")
+		return err == nil
+	}, 3*time.Second, 20*time.Millisecond)
+
+	// Rapid successive writes settle on the final contents
+	for _, tmpl := range []string{"v1", "v2", "v3"} {
+		writePromptFile(t, dir, "added.json", "added", tmpl)
+	}
+	assert.Eventually(t, func() bool {
+		prompt, err := loader.GetPrompt("added")
+		return err == nil && prompt.Template == "v3"
+	}, 3*time.Second, 20*time.Millisecond)
+
+	// Deleted files have their prompts removed
+	require.NoError(t, os.Remove(path))
+	assert.Eventually(t, func() bool {
+		_, err := loader.GetPrompt("added")
+		return err != nil
+	}, 3*time.Second, 20*time.Millisecond)
+
+	_, err := loader.GetPrompt("existing")
+	assert.NoError(t, err)
+}
+
+func TestPromptLoader_WatchMissingDir(t *testing.T) {
+	loader := NewPromptLoader(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, loader.Watch(context.Background()))
+}
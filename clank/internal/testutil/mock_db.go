@@ -66,21 +66,6 @@ func (m *MockDB) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models
 	return articles, nil
 }
 
-// GetArticlesByTimeRange retrieves articles within a time range
-func (m *MockDB) GetArticlesByTimeRange(startTime, endTime time.Time) ([]*models.Article, error) {
-	if m.RetrieveError != nil {
-		return nil, m.RetrieveError
-	}
-
-	var articles []*models.Article
-	for _, article := range m.Articles {
-		if article.CreatedAt.After(startTime) && article.CreatedAt.Before(endTime) {
-			articles = append(articles, article)
-		}
-	}
-	return articles, nil
-}
-
 // SaveEntity mocks saving an entity
 func (m *MockDB) SaveEntity(ctx context.Context, entity *models.ExtractedEntity) error {
 	if m.SaveError != nil {
@@ -2,6 +2,7 @@ package testutil
 
 import (
 	"clank/internal/models"
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -70,7 +71,7 @@ func (m *MockBrowserAutomation) Initialize() error {
 }
 
 // ScrapeArticle implements browser.Browser
-func (m *MockBrowserAutomation) ScrapeArticle(url string) (*models.Article, error) {
+func (m *MockBrowserAutomation) ScrapeArticle(ctx context.Context, url string) (*models.Article, error) {
 	if m.ScrapeErr != nil {
 		return nil, m.ScrapeErr
 	}
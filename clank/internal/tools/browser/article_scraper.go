@@ -2,15 +2,22 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"clank/internal/limits"
+	"clank/internal/logging"
+	"clank/internal/metrics"
 	"clank/internal/models"
+	"clank/pkg/extraction"
 
 	"github.com/google/uuid"
+	"golang.org/x/net/html"
 )
 
 // ArticleScraper extends BrowserAutomation for article-specific scraping
@@ -21,9 +28,16 @@ var (
 	multipleNewlinesRegex  = regexp.MustCompile(`\n\s*\n`)
 )
 
+// minReadableContentLength is the shortest readability result trusted over the selector fallback
+const minReadableContentLength = 200
+
 type ArticleScraper struct {
 	*BrowserAutomation
+	initMu      sync.Mutex
 	initialized bool
+	siteRules   extraction.SiteRules // Per-domain selectors tried before the readability heuristic
+	jsonFields  JSONFields           // Where the article fields are in JSON documents
+	sessions    *SessionStore        // Saved login sessions (nil disables them)
 }
 
 // NewArticleScraper creates a new ArticleScraper instance
@@ -35,8 +49,30 @@ func NewArticleScraper() *ArticleScraper {
 	}
 }
 
+// SetSiteSelectors sets the per-domain content and skip selectors consulted before
+// the readability heuristic. Invalid selectors are rejected and the previous rules kept.
+func (as *ArticleScraper) SetSiteSelectors(rules extraction.SiteRules) error {
+	if err := rules.Validate(); err != nil {
+		return fmt.Errorf("invalid site selectors: %w", err)
+	}
+	as.siteRules = rules
+	return nil
+}
+
+// SetJSONFields sets the paths to the article fields of scraped JSON documents
+func (as *ArticleScraper) SetJSONFields(fields JSONFields) {
+	as.jsonFields = fields
+}
+
+// SetSessionStore sets where session profiles are saved and loaded; nil disables them
+func (as *ArticleScraper) SetSessionStore(store *SessionStore) {
+	as.sessions = store
+}
+
 // Initialize prepares the scraper for use
 func (as *ArticleScraper) Initialize() error {
+	as.initMu.Lock()
+	defer as.initMu.Unlock()
 	if as.initialized {
 		return nil
 	}
@@ -62,30 +98,112 @@ func (as *ArticleScraper) cleanContent(content string) string {
 	return strings.TrimSpace(content)
 }
 
-// ScrapeArticle scrapes content and metadata from the given URL
-func (as *ArticleScraper) ScrapeArticle(urlStr string) (*models.Article, error) {
-	if !as.initialized {
-		if err := as.Initialize(); err != nil {
-			return nil, fmt.Errorf("failed to initialize scraper: %w", err)
-		}
+// ScrapeArticle scrapes content and metadata from the given URL. Scrapes share the
+// server-wide browser limit; waiting for a slot ends with ctx. The response's
+// content type picks the parser: pages are extracted in the browser, JSON
+// documents through the configured field paths, and RSS or Atom feeds fail with
+// a *FeedError listing their items. Pages without article text, such as consent
+// walls, fail with an *UnavailableError. A session attached with WithSession logs
+// in or loads the profile's cookies before the article is loaded. Each scrape
+// runs in a tab of its own, so cookies don't carry over between scrapes.
+func (as *ArticleScraper) ScrapeArticle(ctx context.Context, urlStr string) (*models.Article, error) {
+	slots := limits.Browsers()
+	if err := slots.Acquire(ctx); err != nil {
+		metrics.ScrapeDuration.Observe(0, metrics.Status(err))
+		return nil, err
+	}
+	defer slots.Release()
+
+	logger := logging.For(ctx, "scraper")
+	logger.Info("Scraping article", "url", urlStr)
+	start := time.Now()
+	article, err := as.scrapeArticle(ctx, urlStr)
+	metrics.ScrapeDuration.ObserveSince(start, metrics.Status(err))
+	if err != nil {
+		logger.Warn("Article scraping failed", "url", urlStr, "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+	logger.Info("Scraped article", "url", urlStr, "duration", time.Since(start), "characters", len(article.Content))
+	return article, nil
+}
+
+func (as *ArticleScraper) scrapeArticle(ctx context.Context, urlStr string) (*models.Article, error) {
+	if err := as.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize scraper: %w", err)
 	}
 
-	ctx := context.Background()
 	parsed, err := url.Parse(urlStr)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
 	}
 
-	// Navigate to the URL
-	if err := as.navigateWithRetry(ctx, urlStr); err != nil {
+	tab, err := as.openTab(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := tab.closeTab(); err != nil {
+			logging.For(ctx, "scraper").Warn("Failed to close browser tab", "url", urlStr, "error", err)
+		}
+	}()
+	scrape := as.inTab(tab)
+
+	if session, ok := RequestSession(ctx); ok {
+		if err := startSession(ctx, scrape.BrowserAutomation, as.sessions, session); err != nil {
+			return nil, err
+		}
+	}
+
+	return escalateWaits(ctx, as.strategies(), func(waitUntil string) (*models.Article, error) {
+		return scrape.loadArticle(ctx, parsed, urlStr, waitUntil)
+	})
+}
+
+// inTab returns a scraper with the same settings driving tab
+func (as *ArticleScraper) inTab(tab *BrowserAutomation) *ArticleScraper {
+	return &ArticleScraper{
+		BrowserAutomation: tab,
+		initialized:       true,
+		siteRules:         as.siteRules,
+		jsonFields:        as.jsonFields,
+		sessions:          as.sessions,
+	}
+}
+
+// loadArticle loads the page at urlStr, waiting by the waitUntil strategy, and
+// extracts its article
+func (as *ArticleScraper) loadArticle(ctx context.Context, parsed *url.URL, urlStr, waitUntil string) (*models.Article, error) {
+	if err := as.navigateWithRetry(ctx, urlStr, waitUntil); err != nil {
 		return nil, fmt.Errorf("failed to navigate to URL: %w", err)
 	}
 
+	// Feeds and JSON APIs are parsed from the response body; only pages are rendered
+	if mediaType := as.ContentType(); documentKindOf(mediaType) != htmlDocument {
+		body, err := as.ResponseBody(ctx)
+		if err != nil {
+			return nil, err
+		}
+		article, err := parseDocument(urlStr, parsed.Host, mediaType, body, as.jsonFields)
+		if err != nil {
+			return nil, err
+		}
+		return article, checkContent(urlStr, article.Content)
+	}
+
+	// Pages without a Content-Length are only caught once rendered
+	pageHTML, htmlErr := as.GetPageHTML(ctx)
+	if errors.Is(htmlErr, ErrPageTooLarge) {
+		return nil, htmlErr
+	}
+
 	title, author, pubDate := as.extractMetadata(ctx)
-	content, err := as.extractMainContent(ctx)
+	content, err := as.extractMainContent(ctx, parsed.Hostname())
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract content: %w", err)
 	}
+	if err := checkContent(urlStr, content); err != nil {
+		return nil, err
+	}
 
 	article := &models.Article{
 		ID:          uuid.New().String(),
@@ -101,9 +219,37 @@ func (as *ArticleScraper) ScrapeArticle(urlStr string) (*models.Article, error)
 		Metadata:    make(map[string]interface{}),
 	}
 
+	// Structured data (JSON-LD, Open Graph) is more reliable than the selectors above
+	if htmlErr == nil {
+		applyArticleMetadata(article, ParseArticleMetadata(pageHTML))
+	}
+
 	return article, nil
 }
 
+// applyArticleMetadata overrides scraped fields with the page's structured metadata
+// where it is present and stores the extra fields in the article metadata
+func applyArticleMetadata(article *models.Article, meta ArticleMetadata) {
+	if meta.Title != "" {
+		article.Title = meta.Title
+	}
+	if meta.Author != "" {
+		article.Author = meta.Author
+	}
+	if !meta.PublishDate.IsZero() {
+		article.PublishDate = meta.PublishDate
+	}
+	if meta.Section != "" {
+		article.Metadata["section"] = meta.Section
+	}
+	if meta.Description != "" {
+		article.Metadata["description"] = meta.Description
+	}
+	if meta.CanonicalURL != "" {
+		article.Metadata["canonicalUrl"] = meta.CanonicalURL
+	}
+}
+
 // extractMetadata extracts title, author, and publication date from HTML
 func (as *ArticleScraper) extractMetadata(ctx context.Context) (string, string, time.Time) {
 	var title, author string
@@ -170,10 +316,13 @@ func (as *ArticleScraper) extractMetadata(ctx context.Context) (string, string,
 }
 
 // navigateWithRetry attempts to navigate to a URL with retries
-func (as *ArticleScraper) navigateWithRetry(ctx context.Context, urlStr string) error {
+func (as *ArticleScraper) navigateWithRetry(ctx context.Context, urlStr, waitUntil string) error {
 	var lastErr error
 	for i := 0; i < 3; i++ {
-		if err := as.BrowserAutomation.NavigateTo(ctx, urlStr); err != nil {
+		if err := as.BrowserAutomation.navigate(ctx, urlStr, waitUntil); err != nil {
+			if errors.Is(err, ErrPageTooLarge) {
+				return err
+			}
 			lastErr = err
 			time.Sleep(time.Second * time.Duration(i+1))
 			continue
@@ -183,8 +332,17 @@ func (as *ArticleScraper) navigateWithRetry(ctx context.Context, urlStr string)
 	return fmt.Errorf("failed to navigate after retries: %w", lastErr)
 }
 
-// extractMainContent extracts the main content from the current page
-func (as *ArticleScraper) extractMainContent(ctx context.Context) (string, error) {
+// extractMainContent extracts the main content from the current page of host
+func (as *ArticleScraper) extractMainContent(ctx context.Context, host string) (string, error) {
+	// Score the page DOM in Go first; the selectors below are only a fallback
+	if pageHTML, err := as.GetPageHTML(ctx); err == nil {
+		if doc, err := html.Parse(strings.NewReader(pageHTML)); err == nil {
+			if content, ok := pageContent(doc, as.siteRules, host); ok {
+				return content, nil
+			}
+		}
+	}
+
 	// Find main article content using common selectors
 	selectors := []string{
 		"article",
@@ -211,6 +369,22 @@ func (as *ArticleScraper) extractMainContent(ctx context.Context) (string, error
 	return as.cleanContent(content), nil
 }
 
+// pageContent extracts the article text of a parsed page. A site override whose
+// content selector matches is trusted as is; otherwise the readability result must
+// be long enough to beat the generic selectors.
+func pageContent(doc *html.Node, rules extraction.SiteRules, host string) (string, bool) {
+	if selectors, ok := rules.Lookup(host); ok {
+		content, matched := extraction.ExtractSiteContent(doc, selectors)
+		if matched || len(content) >= minReadableContentLength {
+			return content, true
+		}
+		return "", false
+	}
+
+	content := extraction.ExtractReadableContent(doc)
+	return content, len(content) >= minReadableContentLength
+}
+
 // extractSourceFromURL extracts the source name from the URL
 func (as *ArticleScraper) extractSourceFromURL(urlStr string) string {
 	if u, err := url.Parse(urlStr); err == nil {
@@ -2,25 +2,41 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"mime"
+	"strconv"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-// BrowserAutomation handles browser automation tasks using Playwright
+// BrowserAutomation handles browser automation tasks using Playwright. The
+// page methods work on a tab opened with openTab.
 type BrowserAutomation struct {
 	pw      *playwright.Playwright
 	browser playwright.Browser
-	context playwright.BrowserContext
+	context playwright.BrowserContext // The tab's own context: cookie jar and headers
 	page    playwright.Page
+	resp    playwright.Response // Response to the last navigation
+
+	maxPageBytes   int            // Largest page HTML accepted (0 uses DefaultMaxPageBytes)
+	network        NetworkOptions // Proxy, headers and cookies for every page load
+	waitStrategies []string       // Wait strategies page loads escalate through (empty uses DefaultWaitStrategies)
 }
 
+// DefaultMaxPageBytes caps the page HTML read when no limit is configured
+const DefaultMaxPageBytes = 10 << 20
+
+// ErrPageTooLarge is returned when a page's HTML exceeds the configured size limit
+var ErrPageTooLarge = errors.New("page exceeds maximum size")
+
 // NewBrowserAutomation creates a new browser automation instance
 func NewBrowserAutomation() (*BrowserAutomation, error) {
 	return &BrowserAutomation{}, nil
 }
 
 // Initialize sets up the Playwright environment and launches the browser
+// that tabs are opened in
 func (ba *BrowserAutomation) Initialize(ctx context.Context) error {
 	var err error
 
@@ -36,40 +52,148 @@ func (ba *BrowserAutomation) Initialize(ctx context.Context) error {
 		return fmt.Errorf("could not start playwright: %v", err)
 	}
 
-	// Launch the browser in headless mode for production
-	ba.browser, err = ba.pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
-		Headless: playwright.Bool(true), // Headless for production
-	})
+	// Launch the browser in headless mode, through the proxy if one is configured
+	ba.browser, err = ba.pw.Chromium.Launch(ba.network.launchOptions())
 	if err != nil {
 		return fmt.Errorf("could not launch browser: %v", err)
 	}
 
-	// Create a new context
-	ba.context, err = ba.browser.NewContext()
+	return nil
+}
+
+// openTab returns a copy of ba with a browser context and page of its own, so
+// concurrent scrapes share the browser but neither a page nor a cookie jar.
+// The configured cookies are seeded into it, and its requests send the
+// configured headers plus those attached to ctx with WithRequestHeaders.
+// closeTab discards it.
+func (ba *BrowserAutomation) openTab(ctx context.Context) (*BrowserAutomation, error) {
+	if ba.browser == nil {
+		return nil, fmt.Errorf("browser not initialized")
+	}
+	browserContext, err := ba.browser.NewContext(ba.network.withHeaders(RequestHeaders(ctx)).contextOptions())
 	if err != nil {
-		return fmt.Errorf("could not create browser context: %v", err)
+		return nil, fmt.Errorf("could not create browser context: %v", err)
 	}
-
-	// Create a new page
-	ba.page, err = ba.context.NewPage()
+	if cookies := ba.network.cookies(); len(cookies) > 0 {
+		if err := browserContext.AddCookies(cookies); err != nil {
+			browserContext.Close()
+			return nil, fmt.Errorf("could not add cookies: %v", err)
+		}
+	}
+	page, err := browserContext.NewPage()
 	if err != nil {
-		return fmt.Errorf("could not create page: %v", err)
+		browserContext.Close()
+		return nil, fmt.Errorf("could not create page: %v", err)
 	}
 
+	tab := *ba
+	tab.context, tab.page, tab.resp = browserContext, page, nil
+	return &tab, nil
+}
+
+// closeTab closes a tab opened with openTab, along with its page and cookies
+func (ba *BrowserAutomation) closeTab() error {
+	if ba.context == nil {
+		return nil
+	}
+	if err := ba.context.Close(); err != nil {
+		return fmt.Errorf("failed to close browser context: %v", err)
+	}
 	return nil
 }
 
-// NavigateTo navigates to the specified URL
+// NavigateTo navigates to the specified URL, waiting by the first wait strategy
 func (ba *BrowserAutomation) NavigateTo(ctx context.Context, url string) error {
+	return ba.navigate(ctx, url, ba.strategies()[0])
+}
+
+// navigate navigates to url, waiting until the page load counts as finished by
+// the waitUntil strategy
+func (ba *BrowserAutomation) navigate(ctx context.Context, url, waitUntil string) error {
 	if ba.page == nil {
 		return fmt.Errorf("browser not initialized")
 	}
 
-	_, err := ba.page.Goto(url)
+	resp, err := ba.page.Goto(url, playwright.PageGotoOptions{WaitUntil: waitUntilStates[waitUntil]})
 	if err != nil {
 		return fmt.Errorf("failed to navigate to %s: %v", url, err)
 	}
+	ba.resp = resp
+
+	// Reject oversized documents up front when the server declares their size
+	if resp != nil {
+		if err := checkContentLength(resp.Headers()["content-length"], ba.pageLimit()); err != nil {
+			return fmt.Errorf("%s: %w", url, err)
+		}
+	}
+
+	return nil
+}
+
+// ContentType returns the media type of the last navigation's response, e.g.
+// "application/rss+xml", without parameters; "" when it is unknown
+func (ba *BrowserAutomation) ContentType() string {
+	if ba.resp == nil {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(ba.resp.Headers()["content-type"])
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// ResponseBody returns the raw body of the last navigation's response, which
+// for documents the browser doesn't render as HTML (feeds, JSON) is the source
+// to parse rather than the page content
+func (ba *BrowserAutomation) ResponseBody(ctx context.Context) ([]byte, error) {
+	if ba.resp == nil {
+		return nil, fmt.Errorf("no page loaded")
+	}
+	body, err := ba.resp.Body()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %v", err)
+	}
+	if err := checkPageSize(len(body), ba.pageLimit()); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// SetMaxPageBytes sets the largest page HTML accepted; 0 restores DefaultMaxPageBytes
+func (ba *BrowserAutomation) SetMaxPageBytes(maxBytes int) {
+	ba.maxPageBytes = maxBytes
+}
+
+func (ba *BrowserAutomation) pageLimit() int {
+	if ba.maxPageBytes > 0 {
+		return ba.maxPageBytes
+	}
+	return DefaultMaxPageBytes
+}
+
+// checkContentLength rejects a response whose Content-Length header exceeds limit.
+// A missing or malformed header (e.g. chunked responses) passes; the rendered
+// content is checked again by checkPageSize.
+func checkContentLength(header string, limit int) error {
+	if header == "" {
+		return nil
+	}
+	length, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		return nil
+	}
+	if length > int64(limit) {
+		return fmt.Errorf("%w: Content-Length %d > %d bytes", ErrPageTooLarge, length, limit)
+	}
+	return nil
+}
 
+// checkPageSize rejects page content larger than limit
+func checkPageSize(size, limit int) error {
+	if size > limit {
+		return fmt.Errorf("%w: %d > %d bytes", ErrPageTooLarge, size, limit)
+	}
 	return nil
 }
 
@@ -117,6 +241,23 @@ func (ba *BrowserAutomation) GetElementAttributeBySelector(ctx context.Context,
 	return value, nil
 }
 
+// GetPageHTML returns the full HTML of the current page
+func (ba *BrowserAutomation) GetPageHTML(ctx context.Context) (string, error) {
+	if ba.page == nil {
+		return "", fmt.Errorf("browser not initialized")
+	}
+
+	content, err := ba.page.Content()
+	if err != nil {
+		return "", fmt.Errorf("failed to get page content: %v", err)
+	}
+	if err := checkPageSize(len(content), ba.pageLimit()); err != nil {
+		return "", err
+	}
+
+	return content, nil
+}
+
 // Close cleans up browser resources
 func (ba *BrowserAutomation) Close() error {
 	if ba.page != nil {
@@ -2,6 +2,7 @@ package browser
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -16,21 +17,26 @@ func TestBrowserAutomation(t *testing.T) {
 
 	err = browser.Initialize(ctx)
 	if err != nil {
-		t.Fatalf("Failed to initialize browser: %v", err)
+		t.Skipf("browser not available: %v", err)
 	}
 	defer browser.Close()
+	tab, err := browser.openTab(ctx)
+	if err != nil {
+		t.Fatalf("Failed to open a tab: %v", err)
+	}
+	defer tab.closeTab()
 
 	t.Log("Starting navigation test...")
 
 	// Test navigation to a simple local test page
-	err = browser.NavigateTo(ctx, "about:blank")
+	err = tab.NavigateTo(ctx, "about:blank")
 	if err != nil {
 		t.Fatalf("Failed to navigate to blank page: %v", err)
 	}
 	t.Log("Navigation to blank page successful")
 
 	// Now try Google
-	err = browser.NavigateTo(ctx, "https://www.google.com")
+	err = tab.NavigateTo(ctx, "https://www.google.com")
 	if err != nil {
 		t.Fatalf("Failed to navigate to Google: %v", err)
 	}
@@ -42,9 +48,52 @@ func TestBrowserAutomation(t *testing.T) {
 
 	// Test getting text from Google's search input
 	t.Log("Checking for search input...")
-	_, err = browser.GetElementTextBySelector(ctx, "textarea[name=q]") // Google sometimes uses textarea instead of input
+	_, err = tab.GetElementTextBySelector(ctx, "textarea[name=q]") // Google sometimes uses textarea instead of input
 	if err != nil {
 		t.Fatalf("Failed to get search input: %v", err)
 	}
 	t.Log("Found search input")
 }
+
+func TestPageSizeLimit(t *testing.T) {
+	const limit = 1024
+
+	tests := []struct {
+		name          string
+		contentLength string
+		size          int
+		wantErr       bool
+	}{
+		{name: "At limit", contentLength: "1024", size: limit},
+		{name: "Declared over limit", contentLength: "1025", size: 0, wantErr: true},
+		{name: "Chunked under limit", contentLength: "", size: limit - 1},
+		{name: "Chunked over limit", contentLength: "", size: limit + 1, wantErr: true},
+		{name: "Malformed length falls back to size", contentLength: "lots", size: limit + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkContentLength(tt.contentLength, limit)
+			if err == nil {
+				err = checkPageSize(tt.size, limit)
+			}
+			if tt.wantErr && !errors.Is(err, ErrPageTooLarge) {
+				t.Fatalf("expected ErrPageTooLarge, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestBrowserAutomation_PageLimit(t *testing.T) {
+	ba, _ := NewBrowserAutomation()
+	if ba.pageLimit() != DefaultMaxPageBytes {
+		t.Errorf("expected default limit %d, got %d", DefaultMaxPageBytes, ba.pageLimit())
+	}
+	ba.SetMaxPageBytes(2048)
+	if ba.pageLimit() != 2048 {
+		t.Errorf("expected configured limit 2048, got %d", ba.pageLimit())
+	}
+}
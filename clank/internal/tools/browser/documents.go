@@ -0,0 +1,149 @@
+package browser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedContent is returned for responses that are neither a web page,
+// a feed nor a JSON document, e.g. images or downloads
+var ErrUnsupportedContent = errors.New("unsupported content type")
+
+// documentKind is how a response is scraped, chosen by its content type
+type documentKind int
+
+const (
+	htmlDocument documentKind = iota // rendered and extracted in the browser
+	feedDocument                     // RSS or Atom feed
+	xmlDocument                      // generic XML, scraped if it turns out to be a feed
+	jsonDocument                     // JSON, read through the configured field paths
+	unsupportedDocument
+)
+
+// documentKindOf maps a media type to how it is scraped. Unknown and text types
+// go through the browser as before.
+func documentKindOf(mediaType string) documentKind {
+	switch {
+	case mediaType == "", mediaType == "text/html", mediaType == "application/xhtml+xml", mediaType == "text/plain":
+		return htmlDocument
+	case mediaType == "application/rss+xml", mediaType == "application/atom+xml", mediaType == "application/rdf+xml":
+		return feedDocument
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return xmlDocument
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return jsonDocument
+	case strings.HasPrefix(mediaType, "text/"):
+		return htmlDocument
+	}
+	return unsupportedDocument
+}
+
+// JSONFields are the dot-separated paths, e.g. "data.story.body", to the article
+// fields of a JSON document; array elements are addressed by index ("items.0.text").
+// Empty paths use the field of the same name at the top level.
+type JSONFields struct {
+	Title       string
+	Content     string
+	Author      string
+	PublishDate string
+}
+
+// withDefaults fills the empty paths
+func (f JSONFields) withDefaults() JSONFields {
+	if f.Title == "" {
+		f.Title = "title"
+	}
+	if f.Content == "" {
+		f.Content = "content"
+	}
+	if f.Author == "" {
+		f.Author = "author"
+	}
+	if f.PublishDate == "" {
+		f.PublishDate = "publishDate"
+	}
+	return f
+}
+
+// parseDocument scrapes a response the browser doesn't render as a page. Feeds
+// fail with a *FeedError listing their items; JSON documents become an article
+// through fields.
+func parseDocument(urlStr, host, mediaType string, body []byte, fields JSONFields) (*models.Article, error) {
+	switch documentKindOf(mediaType) {
+	case feedDocument, xmlDocument:
+		feed, err := ParseFeed(body, urlStr)
+		if errors.Is(err, ErrNotFeed) {
+			return nil, fmt.Errorf("%w: %s is XML but not a feed", ErrUnsupportedContent, mediaType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, &FeedError{URL: urlStr, Feed: feed}
+
+	case jsonDocument:
+		article, err := parseJSONArticle(body, fields.withDefaults())
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		article.ID = uuid.New().String()
+		article.URL = urlStr
+		article.Source = host
+		article.ExtractedAt = now
+		article.CreatedAt = now
+		article.UpdatedAt = now
+		article.Metadata = map[string]interface{}{"contentType": mediaType}
+		return article, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedContent, mediaType)
+}
+
+// parseJSONArticle reads the article fields of a JSON document. The content is required.
+func parseJSONArticle(body []byte, fields JSONFields) (*models.Article, error) {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid JSON document: %w", err)
+	}
+
+	content := jsonLDText(jsonPath(doc, fields.Content))
+	if content == "" {
+		return nil, fmt.Errorf("%w: JSON document has no text at %q", ErrUnsupportedContent, fields.Content)
+	}
+	article := &models.Article{
+		Title:   jsonLDText(jsonPath(doc, fields.Title)),
+		Content: content,
+		Author:  jsonLDText(jsonPath(doc, fields.Author)),
+	}
+	if t, ok := parseMetadataDate(jsonLDText(jsonPath(doc, fields.PublishDate))); ok {
+		article.PublishDate = t
+	}
+	return article, nil
+}
+
+// jsonPath returns the value at a dot-separated path in a decoded JSON document, or nil
+func jsonPath(doc interface{}, path string) interface{} {
+	value := doc
+	for _, key := range strings.Split(path, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			value = node[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
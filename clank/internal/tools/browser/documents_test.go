@@ -0,0 +1,118 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentKindOf(t *testing.T) {
+	tests := map[string]documentKind{
+		"":                      htmlDocument,
+		"text/html":             htmlDocument,
+		"application/xhtml+xml": htmlDocument,
+		"text/plain":            htmlDocument,
+		"application/rss+xml":   feedDocument,
+		"application/atom+xml":  feedDocument,
+		"application/xml":       xmlDocument,
+		"text/xml":              xmlDocument,
+		"application/json":      jsonDocument,
+		"application/ld+json":   jsonDocument,
+		"image/png":             unsupportedDocument,
+		"application/pdf":       unsupportedDocument,
+	}
+	for mediaType, want := range tests {
+		assert.Equal(t, want, documentKindOf(mediaType), mediaType)
+	}
+}
+
+func TestParseDocument_RSSFeed(t *testing.T) {
+	body, err := os.ReadFile("testdata/feed.rss")
+	require.NoError(t, err)
+
+	// Feeds are often served as generic XML
+	for _, mediaType := range []string{"application/rss+xml", "text/xml"} {
+		article, err := parseDocument("https://news.example.com/feed.rss", "news.example.com", mediaType, body, JSONFields{})
+		assert.Nil(t, article)
+
+		var feedErr *FeedError
+		require.True(t, errors.As(err, &feedErr), "%s: %v", mediaType, err)
+		assert.Equal(t, "Example News – Investigations", feedErr.Feed.Title)
+		require.Len(t, feedErr.Feed.Items, 2)
+
+		first := feedErr.Feed.Items[0]
+		assert.Equal(t, "Mayor Charged in Contract Scheme", first.Title)
+		assert.Equal(t, "https://news.example.com/mayor-charged", first.URL)
+		assert.Equal(t, "Prosecutors allege <b>kickbacks</b> on the bridge contract.", first.Summary)
+		assert.True(t, first.PublishDate.Equal(time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)), first.PublishDate)
+
+		second := feedErr.Feed.Items[1]
+		assert.Equal(t, "Council Audit Finds Missing Funds", second.Title)
+		assert.Equal(t, "https://news.example.com/council-audit", second.URL, "relative links are resolved")
+		assert.Empty(t, second.Summary)
+		assert.True(t, second.PublishDate.Equal(time.Date(2024, 5, 1, 17, 0, 0, 0, time.UTC)), second.PublishDate)
+	}
+}
+
+func TestParseFeed_Atom(t *testing.T) {
+	feed, err := ParseFeed([]byte(`<?xml version="1.0" encoding="utf-8"?>
+		<feed xmlns="http://www.w3.org/2005/Atom">
+			<title>Court Filings</title>
+			<entry>
+				<title>Indictment unsealed</title>
+				<link rel="alternate" href="https://courts.example.com/filings/77"/>
+				<link rel="enclosure" href="https://courts.example.com/filings/77.pdf"/>
+				<summary>The indictment names three officials.</summary>
+				<published>2024-06-01T10:00:00Z</published>
+			</entry>
+		</feed>`), "https://courts.example.com/atom")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Court Filings", feed.Title)
+	require.Len(t, feed.Items, 1)
+	assert.Equal(t, "https://courts.example.com/filings/77", feed.Items[0].URL)
+	assert.Equal(t, "The indictment names three officials.", feed.Items[0].Summary)
+	assert.True(t, feed.Items[0].PublishDate.Equal(time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)), feed.Items[0].PublishDate)
+}
+
+func TestParseDocument_XMLThatIsNotAFeed(t *testing.T) {
+	_, err := parseDocument("https://example.com/sitemap.xml", "example.com", "application/xml",
+		[]byte(`<urlset><url><loc>https://example.com/a</loc></url></urlset>`), JSONFields{})
+	assert.ErrorIs(t, err, ErrUnsupportedContent)
+}
+
+func TestParseDocument_JSON(t *testing.T) {
+	body := []byte(`{"data": {"story": {
+		"headline": "Mayor Charged",
+		"body": "Prosecutors allege kickbacks.",
+		"authors": [{"name": "Jane Reporter"}, {"name": "John Editor"}],
+		"published": "2024-05-02T08:30:00Z"
+	}}}`)
+	fields := JSONFields{
+		Title:       "data.story.headline",
+		Content:     "data.story.body",
+		Author:      "data.story.authors",
+		PublishDate: "data.story.published",
+	}
+
+	article, err := parseDocument("https://api.example.com/stories/1", "api.example.com", "application/json", body, fields)
+	require.NoError(t, err)
+	assert.Equal(t, "Mayor Charged", article.Title)
+	assert.Equal(t, "Prosecutors allege kickbacks.", article.Content)
+	assert.Equal(t, "Jane Reporter, John Editor", article.Author)
+	assert.True(t, article.PublishDate.Equal(time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)), article.PublishDate)
+	assert.Equal(t, "api.example.com", article.Source)
+	assert.NotEmpty(t, article.ID)
+
+	_, err = parseDocument("https://api.example.com/stories/1", "api.example.com", "application/json", body, JSONFields{})
+	assert.ErrorIs(t, err, ErrUnsupportedContent, "the default content path doesn't match")
+}
+
+func TestParseDocument_Unsupported(t *testing.T) {
+	_, err := parseDocument("https://example.com/logo.png", "example.com", "image/png", []byte{0x89, 'P', 'N', 'G'}, JSONFields{})
+	assert.ErrorIs(t, err, ErrUnsupportedContent)
+}
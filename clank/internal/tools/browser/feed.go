@@ -0,0 +1,167 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFeed is returned by ParseFeed for XML that is not an RSS or Atom feed
+var ErrNotFeed = errors.New("not an RSS or Atom feed")
+
+// Feed is an RSS or Atom feed: a list of links to articles rather than an article
+type Feed struct {
+	Title string     `json:"title,omitempty"`
+	Items []FeedItem `json:"items"`
+}
+
+// FeedItem is one entry of a feed
+type FeedItem struct {
+	Title       string    `json:"title,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	PublishDate time.Time `json:"publishDate,omitempty"`
+}
+
+// FeedError is returned when a scraped URL is a feed. It carries the feed's
+// items, each of which can be scraped as an article.
+type FeedError struct {
+	URL  string
+	Feed *Feed
+}
+
+func (e *FeedError) Error() string {
+	return fmt.Sprintf("%s is a feed of %d items, not an article", e.URL, len(e.Feed.Items))
+}
+
+// feedDateLayouts are the date formats of RSS (RFC 822) feeds; Atom dates are
+// parsed like page metadata
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+}
+
+// xmlFeed decodes RSS 2.0 (<rss><channel><item>), RSS 1.0 (<rdf:RDF> with
+// <item> beside <channel>) and Atom (<feed><entry>) documents
+type xmlFeed struct {
+	XMLName xml.Name
+	Title   string `xml:"title"`
+	Channel struct {
+		Title string    `xml:"title"`
+		Items []xmlItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []xmlItem `xml:"item"`
+	Entries []xmlItem `xml:"entry"`
+}
+
+// xmlItem holds the fields of an RSS item or Atom entry
+type xmlItem struct {
+	Title string `xml:"title"`
+	Link  []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description"`
+	Summary     string `xml:"summary"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"` // Dublin Core, used by RSS 1.0
+	Published   string `xml:"published"`
+	Updated     string `xml:"updated"`
+}
+
+// ParseFeed parses an RSS or Atom feed. Relative item links are resolved
+// against base, the feed's URL.
+func ParseFeed(data []byte, base string) (*Feed, error) {
+	var doc xmlFeed
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotFeed, err)
+	}
+
+	feed := &Feed{Items: []FeedItem{}}
+	var items []xmlItem
+	switch strings.ToLower(doc.XMLName.Local) {
+	case "rss":
+		feed.Title, items = doc.Channel.Title, doc.Channel.Items
+	case "rdf":
+		feed.Title, items = doc.Channel.Title, doc.Items
+	case "feed":
+		feed.Title, items = doc.Title, doc.Entries
+	default:
+		return nil, fmt.Errorf("%w: root element <%s>", ErrNotFeed, doc.XMLName.Local)
+	}
+	feed.Title = strings.TrimSpace(feed.Title)
+
+	baseURL, _ := url.Parse(base)
+	for _, item := range items {
+		feed.Items = append(feed.Items, FeedItem{
+			Title:       strings.TrimSpace(item.Title),
+			URL:         resolveLink(baseURL, item.link()),
+			Summary:     strings.TrimSpace(firstNonEmpty(item.Description, item.Summary)),
+			PublishDate: parseFeedDate(firstNonEmpty(item.PubDate, item.Published, item.Date, item.Updated)),
+		})
+	}
+	return feed, nil
+}
+
+// link returns the item's article link: the RSS <link> text, else the Atom
+// alternate link, else a permalink GUID
+func (item xmlItem) link() string {
+	for _, link := range item.Link {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			return text
+		}
+	}
+	for _, link := range item.Link {
+		if link.Href != "" && (link.Rel == "" || link.Rel == "alternate") {
+			return link.Href
+		}
+	}
+	if guid := strings.TrimSpace(item.GUID); strings.HasPrefix(guid, "http://") || strings.HasPrefix(guid, "https://") {
+		return guid
+	}
+	return ""
+}
+
+// resolveLink makes link absolute against base when it is relative
+func resolveLink(base *url.URL, link string) string {
+	if link == "" || base == nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	t, _ := parseMetadataDate(value)
+	return t
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,215 @@
+package browser
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ArticleMetadata is the structured metadata a page publishes about its article
+type ArticleMetadata struct {
+	Title        string    `json:"title,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	PublishDate  time.Time `json:"publishDate,omitempty"`
+	Section      string    `json:"section,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	CanonicalURL string    `json:"canonicalUrl,omitempty"`
+}
+
+// articleTypes are the JSON-LD @type values that describe an article
+var articleTypes = map[string]bool{
+	"Article":              true,
+	"NewsArticle":          true,
+	"ReportageNewsArticle": true,
+	"AnalysisNewsArticle":  true,
+	"BlogPosting":          true,
+}
+
+// metadataDateLayouts are the date formats accepted in meta tags and JSON-LD
+var metadataDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// ParseArticleMetadata reads JSON-LD article data, Open Graph and standard meta
+// tags from page HTML. JSON-LD takes precedence; meta tags fill the gaps.
+func ParseArticleMetadata(pageHTML string) ArticleMetadata {
+	doc, err := html.Parse(strings.NewReader(pageHTML))
+	if err != nil {
+		return ArticleMetadata{}
+	}
+
+	var meta ArticleMetadata
+	tags := make(map[string]string)
+	var jsonLD []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "meta":
+				key := strings.ToLower(attr(n, "property"))
+				if key == "" {
+					key = strings.ToLower(attr(n, "name"))
+				}
+				if key == "" {
+					key = strings.ToLower(attr(n, "itemprop"))
+				}
+				if content := strings.TrimSpace(attr(n, "content")); key != "" && content != "" {
+					if _, exists := tags[key]; !exists {
+						tags[key] = content
+					}
+				}
+			case "link":
+				if strings.EqualFold(attr(n, "rel"), "canonical") && meta.CanonicalURL == "" {
+					meta.CanonicalURL = strings.TrimSpace(attr(n, "href"))
+				}
+			case "script":
+				if strings.EqualFold(attr(n, "type"), "application/ld+json") && n.FirstChild != nil {
+					jsonLD = append(jsonLD, n.FirstChild.Data)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	for _, block := range jsonLD {
+		if article := findJSONLDArticle(block); article != nil {
+			applyJSONLD(&meta, article)
+			break
+		}
+	}
+
+	fill := func(field *string, keys ...string) {
+		for _, key := range keys {
+			if *field == "" && tags[key] != "" {
+				*field = tags[key]
+			}
+		}
+	}
+	fill(&meta.Title, "og:title", "twitter:title", "headline")
+	fill(&meta.Author, "author", "article:author", "parsely-author")
+	fill(&meta.Section, "article:section", "parsely-section")
+	fill(&meta.Description, "og:description", "description", "twitter:description")
+	fill(&meta.CanonicalURL, "og:url")
+	if meta.PublishDate.IsZero() {
+		for _, key := range []string{"article:published_time", "datepublished", "pubdate", "date"} {
+			if t, ok := parseMetadataDate(tags[key]); ok {
+				meta.PublishDate = t
+				break
+			}
+		}
+	}
+
+	return meta
+}
+
+// findJSONLDArticle returns the first article object in a JSON-LD block, looking
+// inside arrays and @graph containers
+func findJSONLDArticle(block string) map[string]interface{} {
+	var data interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(block)), &data); err != nil {
+		return nil
+	}
+
+	var search func(v interface{}) map[string]interface{}
+	search = func(v interface{}) map[string]interface{} {
+		switch node := v.(type) {
+		case []interface{}:
+			for _, item := range node {
+				if found := search(item); found != nil {
+					return found
+				}
+			}
+		case map[string]interface{}:
+			if isArticleType(node["@type"]) {
+				return node
+			}
+			if graph, ok := node["@graph"]; ok {
+				return search(graph)
+			}
+		}
+		return nil
+	}
+	return search(data)
+}
+
+// isArticleType reports whether a JSON-LD @type (a string or list of strings) is an article
+func isArticleType(v interface{}) bool {
+	switch t := v.(type) {
+	case string:
+		return articleTypes[t]
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok && articleTypes[s] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func applyJSONLD(meta *ArticleMetadata, article map[string]interface{}) {
+	meta.Title = jsonLDText(article["headline"])
+	if meta.Title == "" {
+		meta.Title = jsonLDText(article["name"])
+	}
+	meta.Author = jsonLDText(article["author"])
+	meta.Section = jsonLDText(article["articleSection"])
+	meta.Description = jsonLDText(article["description"])
+	if meta.CanonicalURL == "" {
+		meta.CanonicalURL = jsonLDText(article["url"])
+	}
+	if t, ok := parseMetadataDate(jsonLDText(article["datePublished"])); ok {
+		meta.PublishDate = t
+	}
+}
+
+// jsonLDText flattens a JSON-LD value to text: strings as is, objects by their
+// name, and lists joined with ", " (e.g. multiple authors)
+func jsonLDText(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case map[string]interface{}:
+		return jsonLDText(value["name"])
+	case []interface{}:
+		var parts []string
+		for _, item := range value {
+			if text := jsonLDText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	return ""
+}
+
+func parseMetadataDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range metadataDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
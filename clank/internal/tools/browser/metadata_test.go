@@ -0,0 +1,106 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArticleMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected ArticleMetadata
+	}{
+		{
+			name: "open graph and meta tags",
+			html: `<html><head>
+				<meta property="og:title" content="Mayor Charged in Contract Scheme">
+				<meta name="author" content="Jane Reporter">
+				<meta property="article:published_time" content="2024-05-02T08:30:00Z">
+				<meta property="article:section" content="Politics">
+				<meta name="description" content="Prosecutors allege kickbacks.">
+				<link rel="canonical" href="https://news.example.com/mayor-charged">
+			</head><body><h1>Ignored</h1></body></html>`,
+			expected: ArticleMetadata{
+				Title:        "Mayor Charged in Contract Scheme",
+				Author:       "Jane Reporter",
+				PublishDate:  time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC),
+				Section:      "Politics",
+				Description:  "Prosecutors allege kickbacks.",
+				CanonicalURL: "https://news.example.com/mayor-charged",
+			},
+		},
+		{
+			name: "json-ld nested in @graph takes precedence",
+			html: `<html><head>
+				<meta property="og:title" content="OG title">
+				<meta property="og:description" content="OG description">
+				<script type="application/ld+json">{"@context": "https://schema.org", "@type": "Organization", "name": "Example News"}</script>
+				<script type="application/ld+json">
+				{
+					"@context": "https://schema.org",
+					"@graph": [
+						{"@type": "WebSite", "name": "Example News"},
+						{
+							"@type": ["NewsArticle"],
+							"headline": "Minister Resigns Over Procurement Deal",
+							"author": [{"@type": "Person", "name": "Ana Silva"}, {"@type": "Person", "name": "Tom Park"}],
+							"datePublished": "2024-03-10",
+							"articleSection": "World",
+							"url": "https://news.example.com/minister-resigns"
+						}
+					]
+				}
+				</script>
+			</head><body></body></html>`,
+			expected: ArticleMetadata{
+				Title:        "Minister Resigns Over Procurement Deal",
+				Author:       "Ana Silva, Tom Park",
+				PublishDate:  time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+				Section:      "World",
+				Description:  "OG description",
+				CanonicalURL: "https://news.example.com/minister-resigns",
+			},
+		},
+		{
+			name: "malformed json-ld falls back to meta tags",
+			html: `<html><head>
+				<script type="application/ld+json">{"@type": "NewsArticle", "headline": </script>
+				<meta name="twitter:title" content="Fallback title">
+			</head></html>`,
+			expected: ArticleMetadata{Title: "Fallback title"},
+		},
+		{
+			name:     "no metadata",
+			html:     `<html><body><p>Just text</p></body></html>`,
+			expected: ArticleMetadata{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseArticleMetadata(tt.html))
+		})
+	}
+}
+
+func TestApplyArticleMetadata(t *testing.T) {
+	scrapedAt := time.Now()
+	article := &models.Article{Title: "Scraped title", Author: "Scraped author", PublishDate: scrapedAt, Metadata: map[string]interface{}{}}
+
+	applyArticleMetadata(article, ArticleMetadata{
+		Title:       "Structured title",
+		PublishDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		Section:     "World",
+	})
+
+	assert.Equal(t, "Structured title", article.Title)
+	assert.Equal(t, "Scraped author", article.Author)
+	assert.Equal(t, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), article.PublishDate)
+	assert.Equal(t, "World", article.Metadata["section"])
+	assert.NotContains(t, article.Metadata, "description")
+}
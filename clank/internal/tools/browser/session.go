@@ -0,0 +1,380 @@
+package browser
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+var (
+	// ErrSessionsDisabled is returned when a scrape asks for a session profile
+	// and no session directory is configured
+	ErrSessionsDisabled = errors.New("session profiles are disabled")
+	// ErrSessionNotFound is returned when loading a session profile that was never saved
+	ErrSessionNotFound = errors.New("session profile not found")
+	// ErrLoginFailed is returned when a login step can't be carried out
+	ErrLoginFailed = errors.New("login failed")
+)
+
+// Login step actions
+const (
+	LoginNavigate = "navigate" // Load URL
+	LoginFill     = "fill"     // Type Value into the field matching Selector
+	LoginClick    = "click"    // Click the element matching Selector
+	LoginWaitFor  = "wait_for" // Wait until an element matches Selector, e.g. the logged-in page's menu
+)
+
+// LoginStep is one action of a login flow
+type LoginStep struct {
+	Action   string `json:"action"`
+	URL      string `json:"url,omitempty"`
+	Selector string `json:"selector,omitempty"`
+	Value    string `json:"value,omitempty"`
+}
+
+// Session selects the session profile a scrape runs in. With login steps, the
+// steps are carried out first and the cookies they leave for the hosts the
+// steps navigate to are saved as Profile; without, the cookies saved as Profile
+// earlier are loaded. Either way they only live in the scrape's own tab.
+type Session struct {
+	Profile string
+	Steps   []LoginStep
+}
+
+// profileNameRegex restricts profile names to what is safe as a file name
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,63}$`)
+
+// Validate rejects unknown actions, steps missing their URL or selector, login
+// flows that never navigate and profile names that aren't safe file names
+func (s Session) Validate() error {
+	if !profileNameRegex.MatchString(s.Profile) {
+		return fmt.Errorf("invalid session profile name %q: use letters, digits, '.', '_' and '-'", s.Profile)
+	}
+	if len(s.Steps) > 0 && len(s.loginHosts()) == 0 {
+		return fmt.Errorf("login steps need a navigate step to the login page")
+	}
+	for i, step := range s.Steps {
+		switch step.Action {
+		case LoginNavigate:
+			if u, err := url.Parse(step.URL); err != nil || u.Hostname() == "" {
+				return fmt.Errorf("login step %d: navigate needs an absolute url", i+1)
+			}
+		case LoginFill, LoginClick, LoginWaitFor:
+			if step.Selector == "" {
+				return fmt.Errorf("login step %d: %s needs a selector", i+1, step.Action)
+			}
+		default:
+			return fmt.Errorf("login step %d: unknown action %q", i+1, step.Action)
+		}
+	}
+	return nil
+}
+
+// loginHosts returns the hosts the login steps navigate to
+func (s Session) loginHosts() []string {
+	var hosts []string
+	for _, step := range s.Steps {
+		if step.Action != LoginNavigate {
+			continue
+		}
+		if u, err := url.Parse(step.URL); err == nil && u.Hostname() != "" {
+			hosts = append(hosts, strings.ToLower(u.Hostname()))
+		}
+	}
+	return hosts
+}
+
+// cookiesFor returns the cookies sent to any of hosts: those set for the host
+// itself or for one of its parent domains
+func cookiesFor(cookies []Cookie, hosts []string) []Cookie {
+	kept := make([]Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), ".")
+		for _, host := range hosts {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				kept = append(kept, cookie)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+type sessionKey struct{}
+
+// WithSession returns a context whose scrapes run in session
+func WithSession(ctx context.Context, session Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// RequestSession returns the session attached to ctx with WithSession, if any
+func RequestSession(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionKey{}).(Session)
+	return session, ok
+}
+
+// sessionDriver is the part of the browser a login flow drives
+type sessionDriver interface {
+	NavigateTo(ctx context.Context, url string) error
+	fill(selector, value string) error
+	click(selector string) error
+	waitFor(selector string) error
+	sessionCookies() ([]Cookie, error)
+	addCookies(cookies []Cookie) error
+}
+
+// startSession prepares the browser for a scrape in session: it logs in and
+// saves the resulting cookies of the login hosts, or loads the cookies saved
+// earlier
+func startSession(ctx context.Context, driver sessionDriver, store *SessionStore, session Session) error {
+	if store == nil {
+		return ErrSessionsDisabled
+	}
+	if len(session.Steps) == 0 {
+		cookies, err := store.Load(session.Profile)
+		if err != nil {
+			return err
+		}
+		return driver.addCookies(cookies)
+	}
+
+	for i, step := range session.Steps {
+		var err error
+		switch step.Action {
+		case LoginNavigate:
+			err = driver.NavigateTo(ctx, step.URL)
+		case LoginFill:
+			err = driver.fill(step.Selector, step.Value)
+		case LoginClick:
+			err = driver.click(step.Selector)
+		case LoginWaitFor:
+			err = driver.waitFor(step.Selector)
+		default:
+			err = fmt.Errorf("unknown action %q", step.Action)
+		}
+		// Errors name the step but never its value, which may be a password
+		if err != nil {
+			return fmt.Errorf("%w: step %d (%s %s%s): %v", ErrLoginFailed, i+1, step.Action, step.URL, step.Selector, err)
+		}
+	}
+
+	// The jar also holds configured cookies and those of third parties the login
+	// pages loaded; only the login site's belong to the profile
+	cookies, err := driver.sessionCookies()
+	if err != nil {
+		return err
+	}
+	return store.Save(session.Profile, cookiesFor(cookies, session.loginHosts()))
+}
+
+// SessionStore keeps the cookies of session profiles on disk, one file per
+// profile readable only by the server's user, encrypted when a key is set
+type SessionStore struct {
+	dir string
+	key []byte // AES-256 key derived from the configured secret; nil stores plain JSON
+}
+
+// NewSessionStore returns a store keeping profiles in dir, or nil (sessions
+// disabled) when dir is empty. A non-empty secret encrypts the profiles.
+func NewSessionStore(dir, secret string) (*SessionStore, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	store := &SessionStore{dir: dir}
+	if secret != "" {
+		key := sha256.Sum256([]byte(secret))
+		store.key = key[:]
+	}
+	return store, nil
+}
+
+func (s *SessionStore) path(profile string) string {
+	return filepath.Join(s.dir, profile+".session")
+}
+
+// Save replaces the cookies of a profile
+func (s *SessionStore) Save(profile string, cookies []Cookie) error {
+	if !profileNameRegex.MatchString(profile) {
+		return fmt.Errorf("invalid session profile name %q", profile)
+	}
+	data, err := json.Marshal(cookies)
+	if err != nil {
+		return fmt.Errorf("failed to encode session cookies: %w", err)
+	}
+	if data, err = s.seal(data); err != nil {
+		return err
+	}
+
+	tmp := s.path(profile) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session profile: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(profile)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write session profile: %w", err)
+	}
+	return nil
+}
+
+// Load returns the cookies saved for a profile
+func (s *SessionStore) Load(profile string) ([]Cookie, error) {
+	if !profileNameRegex.MatchString(profile) {
+		return nil, fmt.Errorf("invalid session profile name %q", profile)
+	}
+	data, err := os.ReadFile(s.path(profile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session profile: %w", err)
+	}
+	if data, err = s.open(data); err != nil {
+		return nil, fmt.Errorf("session profile %s: %w", profile, err)
+	}
+	var cookies []Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return nil, fmt.Errorf("invalid session profile %s: %w", profile, err)
+	}
+	return cookies, nil
+}
+
+// seal encrypts data with AES-GCM, prefixing the nonce, when the store has a key
+func (s *SessionStore) seal(data []byte) ([]byte, error) {
+	if s.key == nil {
+		return data, nil
+	}
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts data written by seal
+func (s *SessionStore) open(data []byte) ([]byte, error) {
+	if s.key == nil {
+		return data, nil
+	}
+	gcm, err := s.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("encrypted profile is truncated")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt; was it saved with another key?")
+	}
+	return plain, nil
+}
+
+func (s *SessionStore) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// fill types value into the field matching selector
+func (ba *BrowserAutomation) fill(selector, value string) error {
+	if ba.page == nil {
+		return fmt.Errorf("browser not initialized")
+	}
+	return ba.page.Fill(selector, value)
+}
+
+// click clicks the element matching selector and waits for any navigation it starts
+func (ba *BrowserAutomation) click(selector string) error {
+	if ba.page == nil {
+		return fmt.Errorf("browser not initialized")
+	}
+	if err := ba.page.Click(selector); err != nil {
+		return err
+	}
+	return ba.page.WaitForLoadState()
+}
+
+// waitFor waits until an element matches selector
+func (ba *BrowserAutomation) waitFor(selector string) error {
+	if ba.page == nil {
+		return fmt.Errorf("browser not initialized")
+	}
+	_, err := ba.page.WaitForSelector(selector)
+	return err
+}
+
+// sessionCookies returns every cookie in the browser's cookie jar
+func (ba *BrowserAutomation) sessionCookies() ([]Cookie, error) {
+	if ba.context == nil {
+		return nil, fmt.Errorf("browser not initialized")
+	}
+	jar, err := ba.context.Cookies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %v", err)
+	}
+	cookies := make([]Cookie, 0, len(jar))
+	for _, c := range jar {
+		cookie := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if c.SameSite != nil {
+			cookie.SameSite = string(*c.SameSite)
+		}
+		cookies = append(cookies, cookie)
+	}
+	return cookies, nil
+}
+
+// addCookies adds cookies to the browser's cookie jar
+func (ba *BrowserAutomation) addCookies(cookies []Cookie) error {
+	if ba.context == nil {
+		return fmt.Errorf("browser not initialized")
+	}
+	if len(cookies) == 0 {
+		return nil
+	}
+	if err := ba.context.AddCookies(NetworkOptions{Cookies: cookies}.cookies()); err != nil {
+		return fmt.Errorf("could not add cookies: %v", err)
+	}
+	return nil
+}
+
+// sameSite converts a SameSite name to Playwright's attribute, nil when unset
+func sameSite(value string) *playwright.SameSiteAttribute {
+	switch strings.ToLower(value) {
+	case "strict":
+		return playwright.SameSiteAttributeStrict
+	case "lax":
+		return playwright.SameSiteAttributeLax
+	case "none":
+		return playwright.SameSiteAttributeNone
+	}
+	return nil
+}
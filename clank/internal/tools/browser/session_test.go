@@ -0,0 +1,209 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubLoginSite is a browser on a site with a login form at /login: submitting
+// the right credentials sets a session cookie, and /account shows a menu only
+// with that cookie
+type stubLoginSite struct {
+	url    string
+	fields map[string]string
+	jar    []Cookie
+}
+
+func newStubLoginSite() *stubLoginSite {
+	return &stubLoginSite{fields: make(map[string]string)}
+}
+
+func (s *stubLoginSite) loggedIn() bool {
+	for _, cookie := range s.jar {
+		if cookie.Name == "portal_session" && cookie.Value == "s3cr3t-token" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *stubLoginSite) NavigateTo(ctx context.Context, url string) error {
+	s.url = url
+	return nil
+}
+
+func (s *stubLoginSite) fill(selector, value string) error {
+	if s.url != "https://courts.example.com/login" {
+		return fmt.Errorf("no element found for selector %s", selector)
+	}
+	s.fields[selector] = value
+	return nil
+}
+
+func (s *stubLoginSite) click(selector string) error {
+	if selector != "button[type=submit]" {
+		return fmt.Errorf("no element found for selector %s", selector)
+	}
+	if s.fields["#username"] == "clerk" && s.fields["#password"] == "hunter2" {
+		s.jar = append(s.jar,
+			Cookie{Name: "portal_session", Value: "s3cr3t-token", Domain: "courts.example.com", Path: "/", HTTPOnly: true, Secure: true, SameSite: "Lax"},
+			Cookie{Name: "tracker", Value: "t1", Domain: ".ads.example.net", Path: "/"},
+		)
+		s.url = "https://courts.example.com/account"
+	}
+	return nil
+}
+
+func (s *stubLoginSite) waitFor(selector string) error {
+	if selector == "nav.account" && s.url == "https://courts.example.com/account" && s.loggedIn() {
+		return nil
+	}
+	return fmt.Errorf("timeout waiting for selector %s", selector)
+}
+
+func (s *stubLoginSite) sessionCookies() ([]Cookie, error) { return s.jar, nil }
+
+func (s *stubLoginSite) addCookies(cookies []Cookie) error {
+	s.jar = append(s.jar, cookies...)
+	return nil
+}
+
+func loginSteps(password string) []LoginStep {
+	return []LoginStep{
+		{Action: LoginNavigate, URL: "https://courts.example.com/login"},
+		{Action: LoginFill, Selector: "#username", Value: "clerk"},
+		{Action: LoginFill, Selector: "#password", Value: password},
+		{Action: LoginClick, Selector: "button[type=submit]"},
+		{Action: LoginWaitFor, Selector: "nav.account"},
+	}
+}
+
+func TestSession_LoginSavesAndReloadsCookies(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSessionStore(dir, "profile-secret")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	site := newStubLoginSite()
+	site.jar = []Cookie{{Name: "consent", Value: "yes", Domain: ".news.example.org"}} // seeded from the configured cookies
+	session := Session{Profile: "court-portal", Steps: loginSteps("hunter2")}
+	require.NoError(t, session.Validate())
+	require.NoError(t, startSession(ctx, site, store, session))
+
+	// Saved encrypted and private
+	path := filepath.Join(dir, "court-portal.session")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "s3cr3t-token")
+
+	// A later scrape in a fresh browser loads the profile and is logged in
+	fresh := newStubLoginSite()
+	require.False(t, fresh.loggedIn())
+	require.NoError(t, startSession(ctx, fresh, store, Session{Profile: "court-portal"}))
+	assert.True(t, fresh.loggedIn())
+	assert.Equal(t, []Cookie{site.jar[1]}, fresh.jar, "only the login site's cookies are saved")
+
+	// The key is needed to read it back
+	other, err := NewSessionStore(dir, "another-secret")
+	require.NoError(t, err)
+	_, err = other.Load("court-portal")
+	assert.Error(t, err)
+}
+
+func TestSession_FailedLoginSavesNothing(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSessionStore(dir, "")
+	require.NoError(t, err)
+
+	err = startSession(context.Background(), newStubLoginSite(), store, Session{Profile: "court-portal", Steps: loginSteps("wrong")})
+	assert.ErrorIs(t, err, ErrLoginFailed)
+	assert.Contains(t, err.Error(), "step 5 (wait_for nav.account)")
+	assert.NotContains(t, err.Error(), "wrong", "step values may be passwords")
+
+	_, err = store.Load("court-portal")
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestSession_UnencryptedStore(t *testing.T) {
+	store, err := NewSessionStore(t.TempDir(), "")
+	require.NoError(t, err)
+
+	cookies := []Cookie{{Name: "sid", Value: "abc", Domain: ".example.com", Expires: 1767225600}}
+	require.NoError(t, store.Save("news", cookies))
+	loaded, err := store.Load("news")
+	require.NoError(t, err)
+	assert.Equal(t, cookies, loaded)
+}
+
+func TestSession_Disabled(t *testing.T) {
+	store, err := NewSessionStore("", "secret")
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	err = startSession(context.Background(), newStubLoginSite(), store, Session{Profile: "court-portal"})
+	assert.ErrorIs(t, err, ErrSessionsDisabled)
+}
+
+func TestSession_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		session Session
+		wantErr bool
+	}{
+		{"load only", Session{Profile: "court-portal"}, false},
+		{"login", Session{Profile: "court_portal.v2", Steps: loginSteps("pw")}, false},
+		{"path in name", Session{Profile: "../etc/passwd"}, true},
+		{"empty name", Session{Steps: loginSteps("pw")}, true},
+		{"unknown action", Session{Profile: "p", Steps: []LoginStep{{Action: "hover", Selector: "a"}}}, true},
+		{"navigate without url", Session{Profile: "p", Steps: []LoginStep{{Action: LoginNavigate}}}, true},
+		{"fill without selector", Session{Profile: "p", Steps: []LoginStep{{Action: LoginNavigate, URL: "https://example.com/"}, {Action: LoginFill, Value: "x"}}}, true},
+		{"navigate to relative url", Session{Profile: "p", Steps: []LoginStep{{Action: LoginNavigate, URL: "/login"}}}, true},
+		{"login without navigating", Session{Profile: "p", Steps: []LoginStep{{Action: LoginFill, Selector: "#user", Value: "x"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.session.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCookiesFor(t *testing.T) {
+	cookies := []Cookie{
+		{Name: "host", Domain: "courts.example.com"},
+		{Name: "parent", Domain: ".example.com"},
+		{Name: "sibling", Domain: "news.example.com"},
+		{Name: "suffix", Domain: "ample.com"},
+		{Name: "other", Domain: ".example.net"},
+	}
+
+	var names []string
+	for _, cookie := range cookiesFor(cookies, []string{"courts.example.com"}) {
+		names = append(names, cookie.Name)
+	}
+	assert.Equal(t, []string{"host", "parent"}, names)
+	assert.Empty(t, cookiesFor(cookies, nil))
+}
+
+func TestNetworkOptions_SavedCookieAttributes(t *testing.T) {
+	cookies := NetworkOptions{Cookies: []Cookie{{Name: "sid", Value: "abc", Domain: "example.com", Expires: 1767225600, HTTPOnly: true, Secure: true, SameSite: "Strict"}}}.cookies()
+
+	require.Len(t, cookies, 1)
+	assert.Equal(t, 1767225600.0, *cookies[0].Expires)
+	assert.True(t, *cookies[0].HttpOnly)
+	assert.True(t, *cookies[0].Secure)
+	assert.Equal(t, "Strict", string(*cookies[0].SameSite))
+}
@@ -0,0 +1,77 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrContentUnavailable is returned when a scraped page holds no article text,
+// e.g. it is a cookie consent wall or its body is empty. Extracting from it
+// would spend an LLM call producing nothing.
+var ErrContentUnavailable = errors.New("article content unavailable")
+
+// Reasons a scrape has no article content
+const (
+	UnavailableEmpty       = "empty"        // No text at all
+	UnavailableTooShort    = "too_short"    // Fewer than minArticleWords words
+	UnavailableConsentWall = "consent_wall" // Mostly cookie or privacy consent text
+)
+
+// UnavailableError is returned when a scraped URL has no article content. It
+// matches ErrContentUnavailable and carries the reason it was detected.
+type UnavailableError struct {
+	URL    string
+	Reason string // UnavailableEmpty, UnavailableTooShort or UnavailableConsentWall
+	Words  int    // Words in the scraped text
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%s: %v (%s, %d words)", e.URL, ErrContentUnavailable, e.Reason, e.Words)
+}
+
+func (e *UnavailableError) Unwrap() error { return ErrContentUnavailable }
+
+const (
+	// minArticleWords is the fewest words accepted as an article; short news
+	// briefs run to a few dozen
+	minArticleWords = 25
+	// maxConsentShare is the share of a page's words in consent sentences above
+	// which it is taken for a consent wall
+	maxConsentShare = 0.5
+)
+
+var (
+	// sentenceRegex splits text into sentences and lines
+	sentenceRegex = regexp.MustCompile(`[^.!?\n]+[.!?]*`)
+	// consentRegex matches the phrases of cookie and privacy consent banners
+	consentRegex = regexp.MustCompile(`(?i)\b(cookies?|consent|accept all|reject all|manage (?:your )?(?:preferences|options|choices)|privacy (?:policy|settings)|our partners|personali[sz]ed (?:ads|advertising|content)|legitimate interest)\b`)
+)
+
+// checkContent returns an *UnavailableError when content scraped from url is
+// empty, too short to be an article or mostly consent banner text
+func checkContent(url, content string) error {
+	words := len(strings.Fields(content))
+	switch {
+	case words == 0:
+		return &UnavailableError{URL: url, Reason: UnavailableEmpty}
+	case consentShare(content, words) > maxConsentShare:
+		return &UnavailableError{URL: url, Reason: UnavailableConsentWall, Words: words}
+	case words < minArticleWords:
+		return &UnavailableError{URL: url, Reason: UnavailableTooShort, Words: words}
+	}
+	return nil
+}
+
+// consentShare returns the share of the words of content in sentences that
+// mention cookies or consent
+func consentShare(content string, words int) float64 {
+	consentWords := 0
+	for _, sentence := range sentenceRegex.FindAllString(content, -1) {
+		if consentRegex.MatchString(sentence) {
+			consentWords += len(strings.Fields(sentence))
+		}
+	}
+	return float64(consentWords) / float64(words)
+}
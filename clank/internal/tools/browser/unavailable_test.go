@@ -0,0 +1,83 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"clank/pkg/extraction"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// fixtureContent returns the content a scrape extracts from a testdata page: the
+// readable content, or, like the scraper's last fallback, the text of the body
+func fixtureContent(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	doc, err := html.Parse(strings.NewReader(string(data)))
+	require.NoError(t, err)
+
+	if content, ok := pageContent(doc, extraction.SiteRules{}, "metro.example.com"); ok {
+		return content
+	}
+	var text []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "head" {
+			return
+		}
+		if n.Type == html.TextNode && strings.TrimSpace(n.Data) != "" {
+			text = append(text, strings.TrimSpace(n.Data))
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return strings.Join(text, "\n")
+}
+
+func TestCheckContent_ConsentWall(t *testing.T) {
+	content := fixtureContent(t, "consent_wall.html")
+	require.NotEmpty(t, content)
+
+	err := checkContent("https://metro.example.com/politics/contract", content)
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrContentUnavailable))
+	var unavailable *UnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, UnavailableConsentWall, unavailable.Reason)
+	assert.Contains(t, err.Error(), "consent_wall")
+}
+
+func TestCheckContent_ShortArticle(t *testing.T) {
+	content := fixtureContent(t, "short_article.html")
+
+	// A brief mentioning cookies in its footer is still an article
+	assert.NoError(t, checkContent("https://metro.example.com/politics/resigns", content+" We use cookies to improve your experience."))
+}
+
+func TestCheckContent_Reasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		reason  string
+	}{
+		{"empty", "  \n ", UnavailableEmpty},
+		{"too short", "Subscribe to read the full story.", UnavailableTooShort},
+		{"cookie banner", "This site uses cookies. Accept all or manage your preferences.", UnavailableConsentWall},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unavailable *UnavailableError
+			require.True(t, errors.As(checkContent("https://example.com/a", tt.content), &unavailable))
+			assert.Equal(t, tt.reason, unavailable.Reason)
+		})
+	}
+}
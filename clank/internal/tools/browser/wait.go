@@ -0,0 +1,71 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clank/internal/logging"
+	"clank/internal/models"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Navigation wait strategies: when a page load counts as finished
+const (
+	WaitDOMContentLoaded = "domcontentloaded" // The HTML is parsed; scripts may not have run
+	WaitLoad             = "load"             // The page and its resources are loaded
+	WaitNetworkIdle      = "networkidle"      // No requests for 500ms, so content loaded by XHR after load is in
+)
+
+// DefaultWaitStrategies load pages on the load event, retrying a page that had
+// no article content once its network is idle, as single-page news sites fill
+// their content in after load
+var DefaultWaitStrategies = []string{WaitLoad, WaitNetworkIdle}
+
+// waitUntilStates maps the wait strategies to Playwright's wait states
+var waitUntilStates = map[string]*playwright.WaitUntilState{
+	WaitDOMContentLoaded: playwright.WaitUntilStateDomcontentloaded,
+	WaitLoad:             playwright.WaitUntilStateLoad,
+	WaitNetworkIdle:      playwright.WaitUntilStateNetworkidle,
+}
+
+// SetWaitStrategies sets the wait strategies a page load escalates through: each
+// scrape loads the page with the first, and loads it again with the next while
+// it finds no article content. Empty restores DefaultWaitStrategies.
+func (ba *BrowserAutomation) SetWaitStrategies(strategies []string) error {
+	for _, strategy := range strategies {
+		if _, ok := waitUntilStates[strategy]; !ok {
+			return fmt.Errorf("unknown wait strategy %q: use %s, %s or %s", strategy, WaitDOMContentLoaded, WaitLoad, WaitNetworkIdle)
+		}
+	}
+	ba.waitStrategies = strategies
+	return nil
+}
+
+func (ba *BrowserAutomation) strategies() []string {
+	if len(ba.waitStrategies) > 0 {
+		return ba.waitStrategies
+	}
+	return DefaultWaitStrategies
+}
+
+// escalateWaits runs load with each wait strategy in turn until it finds article
+// content. Only pages found empty or too short are loaded again; consent walls
+// and other errors are returned as they are.
+func escalateWaits(ctx context.Context, strategies []string, load func(waitUntil string) (*models.Article, error)) (*models.Article, error) {
+	var err error
+	for i, strategy := range strategies {
+		var article *models.Article
+		article, err = load(strategy)
+		var unavailable *UnavailableError
+		if !errors.As(err, &unavailable) || unavailable.Reason == UnavailableConsentWall {
+			return article, err
+		}
+		if i+1 < len(strategies) {
+			logging.For(ctx, "scraper").Info("No article content, loading again",
+				"url", unavailable.URL, "reason", unavailable.Reason, "wait_until", strategies[i+1])
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,88 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"clank/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPage is a single-page news site: its article is filled in by XHR after the
+// load event, so only a load waiting for the network to go idle finds it
+type stubPage struct {
+	loads []string
+}
+
+func (p *stubPage) load(waitUntil string) (*models.Article, error) {
+	p.loads = append(p.loads, waitUntil)
+	content := "Loading..."
+	if waitUntil == WaitNetworkIdle {
+		content = strings.Repeat("The ministry awarded the contract without a tender. ", 10)
+	}
+	if err := checkContent("https://spa.example.com/story", content); err != nil {
+		return nil, err
+	}
+	return &models.Article{URL: "https://spa.example.com/story", Content: content}, nil
+}
+
+func TestEscalateWaits_EmptyPageEscalatesToNetworkIdle(t *testing.T) {
+	page := &stubPage{}
+
+	article, err := escalateWaits(context.Background(), DefaultWaitStrategies, page.load)
+
+	require.NoError(t, err)
+	assert.Contains(t, article.Content, "without a tender")
+	assert.Equal(t, []string{WaitLoad, WaitNetworkIdle}, page.loads)
+}
+
+func TestEscalateWaits_StopsAtFirstContent(t *testing.T) {
+	page := &stubPage{}
+
+	_, err := escalateWaits(context.Background(), []string{WaitNetworkIdle, WaitLoad}, page.load)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{WaitNetworkIdle}, page.loads)
+}
+
+func TestEscalateWaits_GivesUp(t *testing.T) {
+	page := &stubPage{}
+
+	_, err := escalateWaits(context.Background(), []string{WaitDOMContentLoaded, WaitLoad}, page.load)
+
+	assert.ErrorIs(t, err, ErrContentUnavailable)
+	assert.Equal(t, []string{WaitDOMContentLoaded, WaitLoad}, page.loads)
+}
+
+func TestEscalateWaits_OtherErrorsAreNotRetried(t *testing.T) {
+	for name, loadErr := range map[string]error{
+		"consent wall": &UnavailableError{URL: "https://example.com/a", Reason: UnavailableConsentWall},
+		"too large":    ErrPageTooLarge,
+	} {
+		t.Run(name, func(t *testing.T) {
+			loads := 0
+			_, err := escalateWaits(context.Background(), DefaultWaitStrategies, func(string) (*models.Article, error) {
+				loads++
+				return nil, loadErr
+			})
+			assert.True(t, errors.Is(err, loadErr))
+			assert.Equal(t, 1, loads)
+		})
+	}
+}
+
+func TestSetWaitStrategies(t *testing.T) {
+	ba, err := NewBrowserAutomation()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultWaitStrategies, ba.strategies())
+
+	require.NoError(t, ba.SetWaitStrategies([]string{WaitDOMContentLoaded, WaitNetworkIdle}))
+	assert.Equal(t, []string{WaitDOMContentLoaded, WaitNetworkIdle}, ba.strategies())
+
+	assert.Error(t, ba.SetWaitStrategies([]string{"commit"}))
+	assert.Equal(t, []string{WaitDOMContentLoaded, WaitNetworkIdle}, ba.strategies(), "an invalid list keeps the previous one")
+}
@@ -0,0 +1,166 @@
+package extraction
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// amountNumber matches the digits of an amount with any grouping and decimal separators
+	amountNumber = regexp.MustCompile(`\d[\d.,]*`)
+	// prefixedDollar matches dollar signs qualified by country, e.g. US$ or C$
+	prefixedDollar = regexp.MustCompile(`(?i)\b(us|ca|c|au|a|nz|hk|s|r)\$`)
+)
+
+// currencySymbols are the ISO 4217 codes of currency symbols; a bare "$" is taken as USD
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₹": "INR", "₽": "RUB", "₩": "KRW",
+}
+
+// dollarPrefixes are the countries written before "$"
+var dollarPrefixes = map[string]string{
+	"us": "USD", "c": "CAD", "ca": "CAD", "a": "AUD", "au": "AUD", "nz": "NZD", "hk": "HKD", "s": "SGD", "r": "BRL",
+}
+
+// currencyWords are currency codes and names as written after an amount
+var currencyWords = map[string]string{
+	"usd": "USD", "dollar": "USD", "dollars": "USD",
+	"eur": "EUR", "euro": "EUR", "euros": "EUR",
+	"gbp": "GBP", "pound": "GBP", "pounds": "GBP", "sterling": "GBP",
+	"jpy": "JPY", "yen": "JPY",
+	"cny": "CNY", "rmb": "CNY", "yuan": "CNY",
+	"inr": "INR", "rupee": "INR", "rupees": "INR",
+	"chf": "CHF", "franc": "CHF", "francs": "CHF",
+	"rub": "RUB", "ruble": "RUB", "rubles": "RUB", "rouble": "RUB", "roubles": "RUB",
+	"cad": "CAD", "aud": "AUD", "nzd": "NZD", "hkd": "HKD", "sgd": "SGD",
+	"brl": "BRL", "krw": "KRW", "mxn": "MXN", "zar": "ZAR",
+}
+
+// amountMultipliers are the scale suffixes and words following a number
+var amountMultipliers = map[string]float64{
+	"k": 1e3, "thousand": 1e3,
+	"m": 1e6, "mn": 1e6, "mm": 1e6, "mln": 1e6, "million": 1e6,
+	"b": 1e9, "bn": 1e9, "billion": 1e9,
+	"t": 1e12, "tn": 1e12, "trillion": 1e12,
+}
+
+// amountQualifiers are words that may surround an amount without changing it
+var amountQualifiers = map[string]bool{
+	"about": true, "approximately": true, "around": true, "roughly": true, "nearly": true,
+	"almost": true, "some": true, "over": true, "under": true, "more": true, "less": true,
+	"than": true, "at": true, "least": true, "up": true, "to": true, "estimated": true,
+	"total": true, "worth": true, "of": true, "a": true, "an": true,
+}
+
+// NormalizeAmount parses a free-text money amount such as "$1M", "1,000,000 USD",
+// "€500k" or "£2.5 billion" into its value and ISO 4217 currency code. currency is
+// "" when the text names none. ok is false for text that isn't a single amount:
+// no number, several numbers (ranges), unknown words, conflicting currencies, or
+// digit grouping that can't be read unambiguously. A single "." is a decimal point;
+// a single "," is a thousands separator only when three digits follow it.
+func NormalizeAmount(s string) (value float64, currency string, ok bool) {
+	numbers := amountNumber.FindAllStringIndex(s, -1)
+	if len(numbers) != 1 {
+		return 0, "", false
+	}
+	start, end := numbers[0][0], numbers[0][1]
+	number := strings.TrimRight(s[start:end], ".,")
+	end = start + len(number)
+
+	value, ok = parseAmountNumber(number)
+	if !ok {
+		return 0, "", false
+	}
+
+	prefix, suffix := amountTokens(s[:start]), amountTokens(s[end:])
+	if len(suffix) > 0 {
+		if multiplier, ok := amountMultipliers[suffix[0]]; ok {
+			value *= multiplier
+			suffix = suffix[1:]
+		}
+	}
+
+	for _, token := range append(prefix, suffix...) {
+		if code, ok := currencyWords[token]; ok {
+			if currency != "" && currency != code {
+				return 0, "", false
+			}
+			currency = code
+			continue
+		}
+		if !amountQualifiers[token] {
+			return 0, "", false
+		}
+	}
+	return value, currency, true
+}
+
+// amountTokens splits the text around an amount into lowercase words, turning
+// currency symbols into their codes
+func amountTokens(s string) []string {
+	s = prefixedDollar.ReplaceAllStringFunc(s, func(m string) string {
+		return " " + strings.ToLower(dollarPrefixes[strings.ToLower(strings.TrimSuffix(m, "$"))]) + " "
+	})
+	for symbol, code := range currencySymbols {
+		s = strings.ReplaceAll(s, symbol, " "+strings.ToLower(code)+" ")
+	}
+
+	var tokens []string
+	for _, field := range strings.Fields(strings.ToLower(s)) {
+		if field = strings.Trim(field, ".,;:()~-"); field != "" {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// parseAmountNumber reads digits grouped by "," or "." in either convention:
+// 1,000,000.50 and 1.000.000,50 are both accepted, 1,00,000 is not
+func parseAmountNumber(number string) (float64, bool) {
+	commas, dots := strings.Count(number, ","), strings.Count(number, ".")
+
+	var decimal, grouping string
+	switch {
+	case commas > 0 && dots > 0:
+		decimal, grouping = ".", ","
+		if strings.LastIndex(number, ",") > strings.LastIndex(number, ".") {
+			decimal, grouping = ",", "."
+		}
+	case dots == 1:
+		decimal = "."
+	case dots > 1:
+		grouping = "."
+	case commas == 1 && len(number)-strings.Index(number, ",")-1 != 3:
+		decimal = ","
+	case commas > 0:
+		grouping = ","
+	}
+
+	integer, fraction := number, ""
+	if decimal != "" {
+		i := strings.LastIndex(number, decimal)
+		integer, fraction = number[:i], number[i+1:]
+		if strings.ContainsAny(fraction, ".,") {
+			return 0, false
+		}
+	}
+	if grouping != "" {
+		groups := strings.Split(integer, grouping)
+		for i, group := range groups {
+			if group == "" || len(group) > 3 || (i > 0 && len(group) != 3) {
+				return 0, false
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+	if strings.ContainsAny(integer, ".,") {
+		return 0, false
+	}
+
+	if fraction != "" {
+		integer += "." + fraction
+	}
+	value, err := strconv.ParseFloat(integer, 64)
+	return value, err == nil
+}
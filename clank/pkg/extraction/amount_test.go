@@ -0,0 +1,42 @@
+package extraction
+
+import "testing"
+
+func TestNormalizeAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		value    float64
+		currency string
+		ok       bool
+	}{
+		{"$1M", 1e6, "USD", true},
+		{"1,000,000 USD", 1e6, "USD", true},
+		{"€500k", 500e3, "EUR", true},
+		{"£2.5 billion", 2.5e9, "GBP", true},
+		{"US$ 3.2bn", 3.2e9, "USD", true},
+		{"C$750,000", 750e3, "CAD", true},
+		{"1.000.000,50 €", 1000000.5, "EUR", true},
+		{"12,5 mln euros", 12.5e6, "EUR", true},
+		{"approximately 40 million dollars", 40e6, "USD", true},
+		{"250000", 250e3, "", true},
+		{"¥ 1,200", 1200, "JPY", true},
+
+		// Unparseable or ambiguous
+		{"", 0, "", false},
+		{"several million dollars", 0, "", false},
+		{"$1-2 million", 0, "", false},
+		{"between $5M and $10M", 0, "", false},
+		{"1,00,000 INR", 0, "", false},
+		{"$500 in EUR", 0, "", false},
+		{"5 houses", 0, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			value, currency, ok := NormalizeAmount(tt.input)
+			if ok != tt.ok || value != tt.value || currency != tt.currency {
+				t.Errorf("NormalizeAmount(%q) = %v, %q, %v; want %v, %q, %v",
+					tt.input, value, currency, ok, tt.value, tt.currency, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package extraction
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Boilerplate is noise CleanContent removes on top of the built-in ad and
+// navigation patterns
+type Boilerplate struct {
+	Phrases    []string            // Removed wherever they appear, case-insensitively, e.g. "Lesen Sie auch"
+	Sites      map[string][]string // Extra phrases per domain; a domain also covers its subdomains
+	MinRepeats int                 // Short lines appearing this many times are removed, e.g. photo credits or "Read more" blocks (0 keeps them)
+}
+
+// maxRepeatedLineLength is the longest line counted as repeated boilerplate;
+// paragraphs of the article aren't repeated word for word
+const maxRepeatedLineLength = 200
+
+// SetBoilerplate sets the phrases and repeated lines removed on top of the
+// built-in patterns
+func (cp *ContentProcessor) SetBoilerplate(boilerplate Boilerplate) {
+	cp.boilerplate = phrasePattern(boilerplate.Phrases)
+	cp.siteBoilerplate = make(map[string]*regexp.Regexp, len(boilerplate.Sites))
+	for domain, phrases := range boilerplate.Sites {
+		if pattern := phrasePattern(phrases); pattern != nil {
+			cp.siteBoilerplate[strings.ToLower(domain)] = pattern
+		}
+	}
+	cp.minRepeats = boilerplate.MinRepeats
+}
+
+// phrasePattern compiles phrases into one case-insensitive pattern matching any
+// of them literally, or nil when there are none
+func phrasePattern(phrases []string) *regexp.Regexp {
+	var quoted []string
+	for _, phrase := range phrases {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			quoted = append(quoted, regexp.QuoteMeta(phrase))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(` + strings.Join(quoted, "|") + `)`)
+}
+
+// noisePatterns returns the patterns removed from content of host: the built-in
+// ones, the configured phrases and those of host's domain
+func (cp *ContentProcessor) noisePatterns(host string) []*regexp.Regexp {
+	patterns := cp.adPatterns
+	if cp.boilerplate != nil {
+		patterns = append(patterns[:len(patterns):len(patterns)], cp.boilerplate)
+	}
+	if site, ok := lookupDomain(cp.siteBoilerplate, host); ok {
+		patterns = append(patterns[:len(patterns):len(patterns)], site)
+	}
+	return patterns
+}
+
+// removeRepeatedLines drops the short lines of content that appear at least
+// minRepeats times, comparing them case- and whitespace-insensitively
+func removeRepeatedLines(content string, minRepeats int) string {
+	if minRepeats < 2 {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	counts := make(map[string]int)
+	for _, line := range lines {
+		if key := lineKey(line); key != "" && len(key) <= maxRepeatedLineLength {
+			counts[key]++
+		}
+	}
+
+	kept := lines[:0]
+	for _, line := range lines {
+		if counts[lineKey(line)] < minRepeats {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func lineKey(line string) string {
+	return strings.ToLower(strings.Join(strings.Fields(line), " "))
+}
+
+// lookupDomain returns the value for host in a map keyed by domain, preferring
+// the most specific domain: "news.example.com" uses "news.example.com" before
+// "example.com"
+func lookupDomain[T any](byDomain map[string]T, host string) (T, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for {
+		if value, ok := byDomain[host]; ok {
+			return value, true
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			var zero T
+			return zero, false
+		}
+		host = host[dot+1:]
+	}
+}
@@ -0,0 +1,62 @@
+package extraction
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentProcessor_CustomBoilerplatePhrase(t *testing.T) {
+	cp := NewContentProcessor()
+	cp.SetBoilerplate(Boilerplate{
+		Phrases: []string{"Lesen Sie auch:"},
+		Sites:   map[string][]string{"metro.example.com": {"Sign up for Morning Brief (free)"}},
+	})
+	input := "Der Bürgermeister vergab den Auftrag. Lesen Sie auch: Die Stadt prüft Verträge. " +
+		"Sign up for Morning Brief (free) The audit continues."
+
+	got := cp.CleanSiteContent("news.metro.example.com", input)
+	if strings.Contains(got, "Lesen Sie auch") || strings.Contains(got, "Morning Brief") {
+		t.Errorf("CleanSiteContent() kept boilerplate: %q", got)
+	}
+	if !strings.Contains(got, "Der Bürgermeister vergab den Auftrag.") || !strings.Contains(got, "The audit continues.") {
+		t.Errorf("CleanSiteContent() removed article text: %q", got)
+	}
+
+	// Site phrases only apply to their publisher; the built-in ones still apply everywhere
+	got = cp.CleanSiteContent("other.example.org", input+" Advertisement")
+	if !strings.Contains(got, "Morning Brief") {
+		t.Errorf("CleanSiteContent() applied another site's boilerplate: %q", got)
+	}
+	if strings.Contains(got, "Lesen Sie auch") || strings.Contains(got, "Advertisement") {
+		t.Errorf("CleanSiteContent() kept global boilerplate: %q", got)
+	}
+}
+
+func TestContentProcessor_RepeatedCaptionLine(t *testing.T) {
+	input := strings.Join([]string{
+		"The council approved the paving contract on Monday.",
+		"Photo: Jane Roe / Metro Daily",
+		"The winning bid came from a firm owned by the mayor's brother.",
+		"Photo: Jane Roe / Metro Daily",
+		"Auditors said the tender was advertised for only two days.",
+		"  photo:  Jane Roe / Metro Daily ",
+		"The mayor denied any involvement.",
+	}, "\n")
+
+	cp := NewContentProcessor()
+	cp.SetBoilerplate(Boilerplate{MinRepeats: 3})
+	got := cp.CleanContent(input)
+	if strings.Contains(strings.ToLower(got), "photo: jane roe") {
+		t.Errorf("CleanContent() kept the repeated caption: %q", got)
+	}
+	for _, sentence := range []string{"paving contract", "mayor's brother", "only two days", "denied any involvement"} {
+		if !strings.Contains(got, sentence) {
+			t.Errorf("CleanContent() removed %q: %q", sentence, got)
+		}
+	}
+
+	// Without the setting, repeated lines are kept
+	if got := NewContentProcessor().CleanContent(input); !strings.Contains(got, "Photo: Jane Roe") {
+		t.Errorf("CleanContent() removed a caption without boilerplate_repeats: %q", got)
+	}
+}
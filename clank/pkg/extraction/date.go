@@ -0,0 +1,81 @@
+package extraction
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Date precisions reported by NormalizeDate
+const (
+	DatePrecisionDay   = "day"
+	DatePrecisionMonth = "month"
+	DatePrecisionYear  = "year"
+)
+
+// dateLayouts are tried in order against the cleaned-up date text
+var dateLayouts = []struct {
+	layout    string
+	precision string
+}{
+	{"2006-01-02", DatePrecisionDay},
+	{"2006/01/02", DatePrecisionDay},
+	{"January 2 2006", DatePrecisionDay},
+	{"Jan 2 2006", DatePrecisionDay},
+	{"2 January 2006", DatePrecisionDay},
+	{"2 Jan 2006", DatePrecisionDay},
+	{"2006-01", DatePrecisionMonth},
+	{"2006/01", DatePrecisionMonth},
+	{"January 2006", DatePrecisionMonth},
+	{"Jan 2006", DatePrecisionMonth},
+	{"2006", DatePrecisionYear},
+}
+
+// ordinalDay matches a day of the month written as an ordinal, e.g. "3rd"
+var ordinalDay = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+
+// dateFillers are words that may surround a date without changing it
+var dateFillers = map[string]bool{"on": true, "in": true, "of": true, "the": true}
+
+// NormalizeDate parses a free-text date such as "2021-03-04", "2021-03",
+// "March 2021" or "4th of March, 2021" and reports how precise it is: day, month
+// or year. The time is the start of that period in UTC. ok is false for relative
+// dates ("last Tuesday") and anything else that can't be placed on a calendar,
+// including numeric day/month orders like "04/03/2021".
+func NormalizeDate(s string) (t time.Time, precision string, ok bool) {
+	s = strings.TrimSpace(s)
+	if parsed, err := time.Parse(time.RFC3339, s); err == nil {
+		return parsed.UTC(), DatePrecisionDay, true
+	}
+
+	cleaned := cleanDate(s)
+	for _, candidate := range dateLayouts {
+		if parsed, err := time.Parse(candidate.layout, cleaned); err == nil {
+			return parsed, candidate.precision, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// cleanDate reduces date text to the shapes in dateLayouts: punctuation, ordinal
+// suffixes and filler words are dropped and month names are capitalized
+func cleanDate(s string) string {
+	var words []string
+	for _, field := range strings.Fields(strings.ToLower(s)) {
+		field = strings.Trim(field, ".,")
+		if field == "" || dateFillers[field] {
+			continue
+		}
+		if m := ordinalDay.FindStringSubmatch(field); m != nil {
+			field = m[1]
+		}
+		if field == "sept" {
+			field = "sep"
+		}
+		if field[0] >= 'a' && field[0] <= 'z' {
+			field = strings.ToUpper(field[:1]) + field[1:]
+		}
+		words = append(words, field)
+	}
+	return strings.Join(words, " ")
+}
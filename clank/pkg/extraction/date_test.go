@@ -0,0 +1,50 @@
+package extraction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeDate(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		input     string
+		want      time.Time
+		precision string
+		ok        bool
+	}{
+		// ISO
+		{"2021-03-04", day(2021, time.March, 4), DatePrecisionDay, true},
+		{"2021-03-04T10:30:00Z", time.Date(2021, time.March, 4, 10, 30, 0, 0, time.UTC), DatePrecisionDay, true},
+		{"2021-03", day(2021, time.March, 1), DatePrecisionMonth, true},
+		{"2021", day(2021, time.January, 1), DatePrecisionYear, true},
+
+		// Month and year
+		{"March 2021", day(2021, time.March, 1), DatePrecisionMonth, true},
+		{"in Sept. 2019", day(2019, time.September, 1), DatePrecisionMonth, true},
+		{"dec 2020", day(2020, time.December, 1), DatePrecisionMonth, true},
+
+		// Full month names
+		{"March 4, 2021", day(2021, time.March, 4), DatePrecisionDay, true},
+		{"4 March 2021", day(2021, time.March, 4), DatePrecisionDay, true},
+		{"on the 4th of March, 2021", day(2021, time.March, 4), DatePrecisionDay, true},
+		{"Jan. 21st 2022", day(2022, time.January, 21), DatePrecisionDay, true},
+
+		// Relative or ambiguous
+		{"last Tuesday", time.Time{}, "", false},
+		{"earlier this year", time.Time{}, "", false},
+		{"04/03/2021", time.Time{}, "", false},
+		{"February 30, 2021", time.Time{}, "", false},
+		{"", time.Time{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, precision, ok := NormalizeDate(tt.input)
+			if ok != tt.ok || !got.Equal(tt.want) || precision != tt.precision {
+				t.Errorf("NormalizeDate(%q) = %v, %q, %v; want %v, %q, %v",
+					tt.input, got, precision, ok, tt.want, tt.precision, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package extraction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// ContentFingerprint returns the SHA-256 of an article's normalized text, so
+// copies that differ only in case, punctuation or whitespace share a fingerprint.
+// Empty content has no fingerprint.
+func ContentFingerprint(content string) string {
+	words := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,29 @@
+package extraction
+
+import "testing"
+
+func TestContentFingerprint(t *testing.T) {
+	base := ContentFingerprint("The mayor awarded the contract to his brother's firm.")
+
+	tests := []struct {
+		name    string
+		content string
+		same    bool
+	}{
+		{name: "Whitespace and case", content: "THE MAYOR  awarded the contract\nto his brother's firm.", same: true},
+		{name: "Punctuation", content: "The mayor awarded the contract to his brother’s firm!", same: true},
+		{name: "Different wording", content: "The mayor awarded the contract to his sister's firm.", same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentFingerprint(tt.content) == base; got != tt.same {
+				t.Errorf("fingerprint match = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	if ContentFingerprint("  ...  ") != "" {
+		t.Error("expected no fingerprint for content without words")
+	}
+}
@@ -0,0 +1,68 @@
+package extraction
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageStopwords are frequent function words that identify each supported language
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "was", "with", "his", "her", "said", "by", "from", "has", "have", "were", "which", "this"},
+	"es": {"el", "la", "los", "las", "de", "del", "que", "y", "en", "por", "con", "para", "una", "un", "es", "fue", "su", "al", "se", "según"},
+	"fr": {"le", "la", "les", "de", "des", "du", "et", "en", "est", "que", "qui", "une", "un", "pour", "dans", "par", "sur", "au", "selon", "été"},
+}
+
+// languageTrigrams are character trigrams that separate languages sharing stopwords
+var languageTrigrams = map[string][]string{
+	"en": {"the", "ing", "tio", "and", "ed ", "ly "},
+	"es": {"ión", "ció", "ado", "os ", "as ", "ñ"},
+	"fr": {"ion", "eau", "ent", "que", "é", "è", "ç"},
+}
+
+const (
+	// minLanguageWords is the fewest words needed to attempt detection
+	minLanguageWords = 5
+	// minLanguageHits is the fewest stopword matches the winning language needs
+	minLanguageHits = 2
+)
+
+// DetectLanguage returns the ISO 639-1 code (en, es, fr) of text, or "" when it is
+// too short or matches no supported language. Languages are scored by stopword
+// frequency, with character trigrams breaking close calls.
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	if len(words) < minLanguageWords {
+		return ""
+	}
+
+	counts := make(map[string]int, len(words))
+	for _, word := range words {
+		counts[word]++
+	}
+
+	lower := strings.ToLower(text)
+	best, bestScore, bestHits := "", 0.0, 0
+	for _, language := range []string{"en", "es", "fr"} {
+		hits := 0
+		for _, stopword := range languageStopwords[language] {
+			hits += counts[stopword]
+		}
+
+		trigramHits := 0
+		for _, trigram := range languageTrigrams[language] {
+			trigramHits += strings.Count(lower, trigram)
+		}
+
+		score := float64(hits)/float64(len(words)) + 0.1*float64(trigramHits)/float64(len(words))
+		if score > bestScore {
+			best, bestScore, bestHits = language, score, hits
+		}
+	}
+
+	if bestHits < minLanguageHits {
+		return ""
+	}
+	return best
+}
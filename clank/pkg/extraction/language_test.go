@@ -0,0 +1,45 @@
+package extraction
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "English",
+			text:     "The mayor was accused of steering the contract to a company owned by his brother, according to records obtained by the newspaper.",
+			expected: "en",
+		},
+		{
+			name:     "Spanish",
+			text:     "El alcalde fue acusado de adjudicar el contrato a una empresa de su hermano, según los documentos obtenidos por el periódico.",
+			expected: "es",
+		},
+		{
+			name:     "French",
+			text:     "Le maire est accusé d'avoir attribué le contrat à une entreprise de son frère, selon les documents obtenus par le journal.",
+			expected: "fr",
+		},
+		{
+			name:     "Too short",
+			text:     "Breaking news",
+			expected: "",
+		},
+		{
+			name:     "No stopwords",
+			text:     "Acme Corp Q3 revenue EBITDA guidance 2024 2025",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.expected {
+				t.Errorf("DetectLanguage() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
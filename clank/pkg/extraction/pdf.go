@@ -0,0 +1,496 @@
+package extraction
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// PDFDocument is the text of a PDF, page streams in file order
+type PDFDocument struct {
+	Text  string
+	Pages int
+	Title string // From the document information dictionary, if set
+}
+
+var (
+	// ErrPDFInvalid is returned for data that isn't a PDF
+	ErrPDFInvalid = errors.New("not a PDF document")
+	// ErrPDFEncrypted is returned for encrypted PDFs, whose streams can't be read
+	ErrPDFEncrypted = errors.New("PDF is encrypted")
+	// ErrPDFNoText is returned when a PDF has (almost) no text, e.g. scanned pages
+	ErrPDFNoText = errors.New("PDF has no extractable text")
+)
+
+const (
+	// minPDFLettersPerPage is the fewest letters a page holds on average for the
+	// PDF to count as text-based; scans only carry stray text like page numbers
+	minPDFLettersPerPage = 25
+	// maxPDFStreamBytes caps a decompressed stream
+	maxPDFStreamBytes = 64 << 20
+)
+
+var (
+	pdfStreamStart = regexp.MustCompile(`stream\r?\n`)
+	pdfStreamLen   = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	pdfPageType    = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfInfoTitle   = regexp.MustCompile(`/Title\s*([(<])`)
+)
+
+// ExtractPDFText reads the text of a text-based PDF. It understands unencrypted
+// files with uncompressed or Flate-compressed content streams and single-byte
+// (WinAnsi) fonts, which covers PDFs exported by word processors and browsers.
+// PDFs whose pages are images return ErrPDFNoText.
+func ExtractPDFText(data []byte) (*PDFDocument, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, ErrPDFInvalid
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, ErrPDFEncrypted
+	}
+
+	doc := &PDFDocument{
+		Pages: len(pdfPageType.FindAllIndex(data, -1)),
+		Title: pdfTitle(data),
+	}
+	var text strings.Builder
+	for _, stream := range pdfStreams(data) {
+		if bytes.Contains(stream.dict, []byte("/ObjStm")) {
+			// Compressed object streams can hold the page objects
+			doc.Pages += len(pdfPageType.FindAllIndex(stream.data, -1))
+			continue
+		}
+		if !bytes.Contains(stream.data, []byte("BT")) {
+			continue
+		}
+		if pageText := strings.TrimSpace(contentStreamText(stream.data)); pageText != "" {
+			if text.Len() > 0 {
+				text.WriteString("\n\n")
+			}
+			text.WriteString(pageText)
+		}
+	}
+	doc.Text = text.String()
+
+	letters := 0
+	for _, r := range doc.Text {
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if letters < minPDFLettersPerPage*max(doc.Pages, 1) {
+		return doc, ErrPDFNoText
+	}
+	return doc, nil
+}
+
+// pdfStream is a decoded stream with the dictionary that introduced it
+type pdfStream struct {
+	dict []byte
+	data []byte
+}
+
+// pdfStreams returns the decodable streams of the file, skipping images, fonts
+// and streams in filters other than Flate
+func pdfStreams(data []byte) []pdfStream {
+	var streams []pdfStream
+	for _, loc := range pdfStreamStart.FindAllIndex(data, -1) {
+		if loc[0] > 0 && bytes.HasSuffix(data[:loc[0]], []byte("end")) {
+			continue // "endstream"
+		}
+		dictStart := bytes.LastIndex(data[:loc[0]], []byte("obj"))
+		if dictStart < 0 {
+			continue
+		}
+		dict := data[dictStart:loc[0]]
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/Length1")) ||
+			bytes.Contains(dict, []byte("/Length2")) || bytes.Contains(dict, []byte("/Subtype/Type1C")) ||
+			bytes.Contains(dict, []byte("/Subtype /Type1C")) {
+			continue
+		}
+
+		raw, ok := pdfStreamData(data[loc[1]:], dict)
+		if !ok {
+			continue
+		}
+		decoded, ok := decodePDFStream(raw, dict)
+		if !ok {
+			continue
+		}
+		streams = append(streams, pdfStream{dict: dict, data: decoded})
+	}
+	return streams
+}
+
+// pdfStreamData returns the bytes of a stream starting at rest, using a direct
+// /Length when it is right and the endstream keyword otherwise
+func pdfStreamData(rest, dict []byte) ([]byte, bool) {
+	if m := pdfStreamLen.FindSubmatch(dict); m != nil && len(m[2]) == 0 {
+		if n, err := strconv.Atoi(string(m[1])); err == nil && n <= len(rest) &&
+			bytes.HasPrefix(bytes.TrimLeft(rest[n:], "\r\n \t"), []byte("endstream")) {
+			return rest[:n], true
+		}
+	}
+	end := bytes.Index(rest, []byte("endstream"))
+	if end < 0 {
+		return nil, false
+	}
+	return bytes.TrimRight(rest[:end], "\r\n"), true
+}
+
+func decodePDFStream(raw, dict []byte) ([]byte, bool) {
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return raw, true
+	}
+	// Only a lone FlateDecode filter is supported
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Count(dict, []byte("Decode")) > 1 {
+		return nil, false
+	}
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+	decoded, err := io.ReadAll(io.LimitReader(r, maxPDFStreamBytes))
+	if err != nil && len(decoded) == 0 {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// pdfTitle returns the /Title of an uncompressed document information dictionary
+func pdfTitle(data []byte) string {
+	loc := pdfInfoTitle.FindSubmatchIndex(data)
+	if loc == nil {
+		return ""
+	}
+	lex := &pdfLexer{data: data, pos: loc[2]}
+	token, ok := lex.next()
+	if !ok || token.kind != pdfString {
+		return ""
+	}
+	return strings.TrimSpace(token.text)
+}
+
+// contentStreamText returns the text shown by a page content stream, one line per
+// text object or line move
+func contentStreamText(stream []byte) string {
+	var out strings.Builder
+	newline := func() {
+		if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+
+	lex := &pdfLexer{data: stream}
+	var operands []pdfToken
+	for {
+		token, ok := lex.next()
+		if !ok {
+			break
+		}
+		if token.kind != pdfOperator {
+			operands = append(operands, token)
+			continue
+		}
+
+		switch token.text {
+		case "Tj":
+			writeLastString(&out, operands)
+		case "'", `"`:
+			newline()
+			writeLastString(&out, operands)
+		case "TJ":
+			for _, item := range lastArray(operands) {
+				switch {
+				case item.kind == pdfString:
+					out.WriteString(item.text)
+				case item.kind == pdfNumber && item.number < -250:
+					// A large negative adjustment is a word gap
+					out.WriteByte(' ')
+				}
+			}
+		case "T*", "ET":
+			newline()
+		case "Td", "TD":
+			if len(operands) >= 2 && operands[len(operands)-1].number != 0 {
+				newline()
+			}
+		case "Tm":
+			newline()
+		case "ID":
+			lex.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return out.String()
+}
+
+func writeLastString(out *strings.Builder, operands []pdfToken) {
+	for i := len(operands) - 1; i >= 0; i-- {
+		if operands[i].kind == pdfString {
+			out.WriteString(operands[i].text)
+			return
+		}
+	}
+}
+
+func lastArray(operands []pdfToken) []pdfToken {
+	for i := len(operands) - 1; i >= 0; i-- {
+		if operands[i].kind == pdfArray {
+			return operands[i].items
+		}
+	}
+	return nil
+}
+
+type pdfTokenKind int
+
+const (
+	pdfOperator pdfTokenKind = iota
+	pdfString
+	pdfNumber
+	pdfName
+	pdfArray
+	pdfOther
+)
+
+type pdfToken struct {
+	kind   pdfTokenKind
+	text   string // Decoded string, name or operator
+	number float64
+	items  []pdfToken // Array elements
+}
+
+// pdfLexer tokenizes PDF content streams
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func (l *pdfLexer) next() (pdfToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return pdfToken{}, false
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '(':
+		return pdfToken{kind: pdfString, text: decodePDFText(l.literal())}, true
+	case c == '<' && l.peek(1) == '<', c == '>' && l.peek(1) == '>':
+		l.pos += 2
+		return pdfToken{kind: pdfOther}, true
+	case c == '<':
+		return pdfToken{kind: pdfString, text: decodePDFText(l.hex())}, true
+	case c == '[':
+		l.pos++
+		var items []pdfToken
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				break
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				break
+			}
+			item, ok := l.next()
+			if !ok {
+				break
+			}
+			items = append(items, item)
+		}
+		return pdfToken{kind: pdfArray, items: items}, true
+	case c == '/':
+		l.pos++
+		return pdfToken{kind: pdfName, text: l.word()}, true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		word := l.word()
+		n, err := strconv.ParseFloat(word, 64)
+		if err != nil {
+			return pdfToken{kind: pdfOther}, true
+		}
+		return pdfToken{kind: pdfNumber, number: n}, true
+	case isPDFDelimiter(c):
+		l.pos++ // Stray ), ], > or {}
+		return pdfToken{kind: pdfOther}, true
+	}
+	return pdfToken{kind: pdfOperator, text: l.word()}, true
+}
+
+func (l *pdfLexer) peek(offset int) byte {
+	if l.pos+offset < len(l.data) {
+		return l.data[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// word reads up to the next whitespace or delimiter
+func (l *pdfLexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++ // Never stall on an unexpected byte
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literal reads a (string) with balanced parentheses and escapes
+func (l *pdfLexer) literal() []byte {
+	l.pos++ // (
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue // Line continuation
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(n)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hex reads a <hex string>; an odd final digit is padded with 0
+func (l *pdfLexer) hex() []byte {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; isHexDigit(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		n, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(n)
+	}
+	return out
+}
+
+// skipInlineImage moves past the data of an inline image, up to its EI operator
+func (l *pdfLexer) skipInlineImage() {
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' && isPDFSpace(l.data[l.pos-1]) &&
+			(isPDFSpace(l.data[l.pos+2]) || isPDFDelimiter(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// winAnsiHigh maps the WinAnsi bytes 0x80-0x9F that differ from Latin-1
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+	0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘',
+	0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—', 0x98: '˜',
+	0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+// decodePDFText decodes a string as UTF-16 when it has a byte order mark and as
+// WinAnsi otherwise
+func decodePDFText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+
+	var out strings.Builder
+	for _, c := range b {
+		if r, ok := winAnsiHigh[c]; ok {
+			out.WriteRune(r)
+		} else {
+			out.WriteRune(rune(c))
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,113 @@
+package extraction
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// buildPDF renders a PDF with one page per entry of pages, each line a cell
+func buildPDF(t *testing.T, title string, pages [][]string) []byte {
+	t.Helper()
+	pdf := fpdf.New("P", "mm", "A4", "")
+	if title != "" {
+		pdf.SetTitle(title, true)
+	}
+	pdf.SetFont("Helvetica", "", 12)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	for _, lines := range pages {
+		pdf.AddPage()
+		if len(lines) == 0 {
+			pdf.Rect(20, 20, 100, 150, "F") // A stand-in for a scanned page image
+		}
+		for _, line := range lines {
+			pdf.Cell(0, 8, tr(line))
+			pdf.Ln(8)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		t.Fatalf("failed to render PDF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	data := buildPDF(t, "Audit Report 2024", [][]string{
+		{
+			"The city council awarded the contract to Acme Corp.",
+			"Mayor John Smith signed the agreement in March.",
+		},
+		{
+			"The auditor found payments of €2 million to a shell company.",
+		},
+	})
+
+	doc, err := ExtractPDFText(data)
+	if err != nil {
+		t.Fatalf("ExtractPDFText failed: %v", err)
+	}
+	if doc.Pages != 2 {
+		t.Errorf("expected 2 pages, got %d", doc.Pages)
+	}
+	if doc.Title != "Audit Report 2024" {
+		t.Errorf("expected title from the document info, got %q", doc.Title)
+	}
+
+	want := "The city council awarded the contract to Acme Corp.\n" +
+		"Mayor John Smith signed the agreement in March.\n\n" +
+		"The auditor found payments of €2 million to a shell company."
+	if doc.Text != want {
+		t.Errorf("unexpected text:\n%q\nwant:\n%q", doc.Text, want)
+	}
+}
+
+func TestExtractPDFText_ImageOnly(t *testing.T) {
+	data := buildPDF(t, "", [][]string{{}, {"2"}})
+
+	doc, err := ExtractPDFText(data)
+	if !errors.Is(err, ErrPDFNoText) {
+		t.Fatalf("expected ErrPDFNoText, got %v", err)
+	}
+	if doc == nil || doc.Pages != 2 {
+		t.Errorf("expected the page count alongside the error, got %+v", doc)
+	}
+}
+
+func TestExtractPDFText_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{name: "Empty", data: nil, want: ErrPDFInvalid},
+		{name: "HTML", data: []byte("<html><body>Not a PDF</body></html>"), want: ErrPDFInvalid},
+		{name: "Encrypted", data: []byte("%PDF-1.7\n1 0 obj\n<< /Root 2 0 R /Encrypt 3 0 R >>\nendobj\n"), want: ErrPDFEncrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ExtractPDFText(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestContentStreamText(t *testing.T) {
+	stream := []byte(`BT /F1 12 Tf 72 720 Td (Hello \(world\)) Tj 0 -14 Td [(Sp) 20 (lit) -600 (words)] TJ T* (Caf\351) Tj ET
+BI /W 1 /H 1 /BPC 8 /CS /G ID ` + "\x00\xff" + ` EI
+BT <FEFF004F006B> Tj ET`)
+
+	got := contentStreamText(stream)
+	want := "Hello (world)\nSplit words\nCafé\nOk\n"
+	if got != want {
+		t.Errorf("contentStreamText = %q, want %q", got, want)
+	}
+	if strings.Contains(got, "\xff") {
+		t.Error("expected inline image data to be skipped")
+	}
+}
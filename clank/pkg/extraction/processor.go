@@ -18,10 +18,21 @@ type ContentProcessor struct {
 	whitespaceRegex *regexp.Regexp
 	// Ad/navigation text patterns
 	adPatterns []*regexp.Regexp
+	// Configured boilerplate phrases, everywhere and per domain (see SetBoilerplate)
+	boilerplate     *regexp.Regexp
+	siteBoilerplate map[string]*regexp.Regexp
+	// Short lines repeated this often are removed (0 keeps them)
+	minRepeats int
 }
 
 // ProcessArticle processes raw article content into a structured result
 func (cp *ContentProcessor) ProcessArticle(content string) (*models.ProcessingResult, error) {
+	return cp.ProcessSiteArticle("", content)
+}
+
+// ProcessSiteArticle processes raw article content published on host, also
+// removing the boilerplate configured for host's domain
+func (cp *ContentProcessor) ProcessSiteArticle(host, content string) (*models.ProcessingResult, error) {
 	// Split into title and content
 	parts := strings.SplitN(content, "\n", 2)
 	if len(parts) < 2 {
@@ -32,13 +43,14 @@ func (cp *ContentProcessor) ProcessArticle(content string) (*models.ProcessingRe
 	mainContent := strings.TrimSpace(parts[1])
 
 	// Clean content
-	cleanedContent := cp.CleanContent(mainContent)
+	cleanedContent := cp.CleanSiteContent(host, mainContent)
 	relevantParagraphs := cp.ExtractRelevantParagraphs(cleanedContent)
 
 	result := &models.ProcessingResult{
 		Title:     strings.TrimSpace(title),
 		Content:   strings.Join(relevantParagraphs, "\n\n"),
 		WordCount: len(strings.Fields(cleanedContent)),
+		Language:  DetectLanguage(cleanedContent),
 		Metadata:  make(map[string]interface{}),
 	}
 
@@ -101,14 +113,23 @@ func NewContentProcessor() *ContentProcessor {
 
 // CleanContent removes HTML tags, ads, and cleans up article content
 func (cp *ContentProcessor) CleanContent(rawContent string) string {
+	return cp.CleanSiteContent("", rawContent)
+}
+
+// CleanSiteContent cleans up article content published on host, also removing
+// the boilerplate configured for host's domain
+func (cp *ContentProcessor) CleanSiteContent(host, rawContent string) string {
 	// Remove HTML tags
 	content := cp.htmlTagRegex.ReplaceAllString(rawContent, " ")
 
 	// Decode HTML entities
 	content = html.UnescapeString(content)
 
-	// Remove common ad/navigation text
-	for _, pattern := range cp.adPatterns {
+	// Remove lines repeated across the page, e.g. photo captions
+	content = removeRepeatedLines(content, cp.minRepeats)
+
+	// Remove common ad/navigation text and configured boilerplate
+	for _, pattern := range cp.noisePatterns(host) {
 		content = pattern.ReplaceAllString(content, "")
 	}
 
@@ -0,0 +1,217 @@
+package extraction
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	// unlikelyCandidateRegex matches class/id values of page chrome rather than article text
+	unlikelyCandidateRegex = regexp.MustCompile(`(?i)comment|sidebar|footer|header|menu|nav|share|social|related|promo|advert|sponsor|popup|newsletter|cookie|banner|breadcrumb|subscribe`)
+	// maybeCandidateRegex rescues elements that also look like content
+	maybeCandidateRegex = regexp.MustCompile(`(?i)article|body|content|main|story|post|text`)
+	// positiveClassRegex and negativeClassRegex adjust a candidate's starting score
+	positiveClassRegex = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text`)
+	negativeClassRegex = regexp.MustCompile(`(?i)comment|footer|sidebar|widget|share|social|related|promo|advert|sponsor|meta|tags`)
+)
+
+// readabilitySkipTags are never part of the readable content
+var readabilitySkipTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "iframe": true, "svg": true,
+	"nav": true, "header": true, "footer": true, "aside": true, "form": true, "button": true,
+}
+
+// readableBlockTags are the elements whose text makes up the extracted content
+var readableBlockTags = map[string]bool{
+	"p": true, "pre": true, "blockquote": true, "li": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+const (
+	// minParagraphLength is the shortest text that counts as a paragraph when scoring
+	minParagraphLength = 25
+)
+
+// ExtractReadableContent returns the main article text of a parsed HTML document,
+// or "" when no element scores as article content. It ports the readability
+// heuristic: paragraphs score their parent and grandparent by length and comma
+// count, candidates are weighted by class/id and penalized by link density, and
+// the text of the best candidate's paragraphs is returned.
+func ExtractReadableContent(doc *html.Node) string {
+	best := topCandidate(doc)
+	if best == nil {
+		return ""
+	}
+
+	var blocks []string
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if readabilitySkipTags[n.Data] || isUnlikelyCandidate(n) {
+				return
+			}
+			if readableBlockTags[n.Data] {
+				if text := nodeText(n); text != "" {
+					blocks = append(blocks, text)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(best)
+
+	if len(blocks) == 0 {
+		return nodeText(best)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// topCandidate scores every paragraph's ancestors and returns the highest scoring element
+func topCandidate(doc *html.Node) *html.Node {
+	scores := scoreCandidates(doc)
+
+	var best *html.Node
+	bestScore := 0.0
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		if best == nil || score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+	return best
+}
+
+// scoreCandidates returns the content score of every element containing paragraphs
+func scoreCandidates(doc *html.Node) map[*html.Node]float64 {
+	scores := make(map[*html.Node]float64)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = initialScore(n)
+		}
+		scores[n] += score
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if readabilitySkipTags[n.Data] || isUnlikelyCandidate(n) {
+				return
+			}
+			if n.Data == "p" || n.Data == "pre" || n.Data == "td" {
+				text := nodeText(n)
+				if len(text) >= minParagraphLength {
+					score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+					addScore(n.Parent, score)
+					if n.Parent != nil {
+						addScore(n.Parent.Parent, score/2)
+					}
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return scores
+}
+
+// initialScore weights a candidate by its tag and class/id
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "article":
+		score += 10
+	case "div", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	classAndID := attrValue(n, "class") + " " + attrValue(n, "id")
+	if negativeClassRegex.MatchString(classAndID) {
+		score -= 25
+	}
+	if positiveClassRegex.MatchString(classAndID) {
+		score += 25
+	}
+	return score
+}
+
+// isUnlikelyCandidate reports whether an element's class/id marks it as page chrome
+func isUnlikelyCandidate(n *html.Node) bool {
+	if n.Data == "body" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+	classAndID := attrValue(n, "class") + " " + attrValue(n, "id")
+	return unlikelyCandidateRegex.MatchString(classAndID) && !maybeCandidateRegex.MatchString(classAndID)
+}
+
+// linkDensity is the share of an element's text that is inside links
+func linkDensity(n *html.Node) float64 {
+	textLength := len(nodeText(n))
+	if textLength == 0 {
+		return 0
+	}
+
+	linkLength := 0
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linkLength += len(nodeText(n))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+
+	return float64(linkLength) / float64(textLength)
+}
+
+// nodeText returns the whitespace-normalized text of an element, skipping scripts and styles
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		case html.ElementNode:
+			if n.Data == "script" || n.Data == "style" || n.Data == "noscript" {
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func attrValue(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
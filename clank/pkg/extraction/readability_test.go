@@ -0,0 +1,126 @@
+package extraction
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const articleParagraphs = `
+	<p>The city council approved the contract on Tuesday, despite objections from two members, who said the bidding process had been rushed.</p>
+	<p>Records show the winning firm, Acme Construction, donated to the mayor's campaign, the local party and a related charity in the months before the vote.</p>
+	<p>A spokesperson for the mayor said the donations had no bearing on the decision, and that the contract was awarded on merit.</p>`
+
+const sidebarLinks = `
+	<ul>
+		<li><a href="/a">Most read: Council budget hearing runs late into the night</a></li>
+		<li><a href="/b">Most read: New park opens downtown after years of delays</a></li>
+		<li><a href="/c">Most read: Police chief announces retirement, successor named</a></li>
+	</ul>`
+
+func TestExtractReadableContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		page     string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Article beats sidebar",
+			page:     `<html><body><div id="sidebar">` + sidebarLinks + `</div><article>` + articleParagraphs + `</article></body></html>`,
+			contains: []string{"approved the contract", "Acme Construction"},
+			excludes: []string{"Most read"},
+		},
+		{
+			name:     "Content class beats link-heavy div",
+			page:     `<html><body><div class="links"><p>` + strings.Repeat(`<a href="/x">Related coverage of the council, the mayor and the contract</a> `, 4) + `</p></div><div class="story-body">` + articleParagraphs + `</div></body></html>`,
+			contains: []string{"spokesperson for the mayor"},
+			excludes: []string{"Related coverage"},
+		},
+		{
+			name:     "Navigation and footer are skipped",
+			page:     `<html><body><nav><p>Home, News, Politics, Business, Sport, Culture and Opinion sections</p></nav><div>` + articleParagraphs + `</div><footer><p>Copyright, all rights reserved, terms of use and privacy policy</p></footer></body></html>`,
+			contains: []string{"approved the contract"},
+			excludes: []string{"Politics", "Copyright"},
+		},
+		{
+			name:     "Scripts are ignored",
+			page:     `<html><body><div class="content">` + articleParagraphs + `<script>var tracking = "approved";</script></div></body></html>`,
+			contains: []string{"awarded on merit"},
+			excludes: []string{"tracking"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			result := ExtractReadableContent(doc)
+			for _, want := range tt.contains {
+				if !strings.Contains(result, want) {
+					t.Errorf("expected %q in result, got %q", want, result)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(result, unwanted) {
+					t.Errorf("did not expect %q in result, got %q", unwanted, result)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractReadableContent_NoParagraphs(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body><div id="sidebar">` + sidebarLinks + `</div></body></html>`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if result := ExtractReadableContent(doc); result != "" {
+		t.Errorf("expected no readable content, got %q", result)
+	}
+}
+
+func TestScoreCandidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		page   string
+		winner string
+		loser  string
+	}{
+		{
+			name:   "Article-like over sidebar-like",
+			page:   `<html><body><div id="sidebar"><p>` + strings.Repeat(`<a href="/x">Trending story about the council</a>, `, 3) + `</p></div><div id="article">` + articleParagraphs + `</div></body></html>`,
+			winner: "article",
+			loser:  "sidebar",
+		},
+		{
+			name:   "Positive class over negative class",
+			page:   `<html><body><div id="comments">` + articleParagraphs + `</div><div id="main-content">` + articleParagraphs + `</div></body></html>`,
+			winner: "main-content",
+			loser:  "comments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			best := topCandidate(doc)
+			if best == nil || attrValue(best, "id") != tt.winner {
+				t.Fatalf("expected #%s to be the top candidate, got %v", tt.winner, best)
+			}
+
+			scores := scoreCandidates(doc)
+			for node, score := range scores {
+				if attrValue(node, "id") == tt.loser && score*(1-linkDensity(node)) >= scores[best]*(1-linkDensity(best)) {
+					t.Errorf("expected #%s to score below #%s", tt.loser, tt.winner)
+				}
+			}
+		})
+	}
+}
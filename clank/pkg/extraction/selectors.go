@@ -0,0 +1,299 @@
+package extraction
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// SiteSelectors are per-publisher overrides of the readability heuristic
+type SiteSelectors struct {
+	Content []string // Elements holding the article text, e.g. "div.story-text"
+	Skip    []string // Regions removed before extraction, e.g. ".promo" or "#comments"
+}
+
+// SiteRules maps a domain to its selectors. A domain also covers its subdomains.
+type SiteRules map[string]SiteSelectors
+
+// Lookup returns the selectors for host, preferring the most specific domain:
+// "news.example.com" uses "news.example.com" before "example.com"
+func (r SiteRules) Lookup(host string) (SiteSelectors, bool) {
+	return lookupDomain(r, host)
+}
+
+// Validate reports the first selector that can't be parsed
+func (r SiteRules) Validate() error {
+	for domain, selectors := range r {
+		for _, selector := range append(append([]string(nil), selectors.Content...), selectors.Skip...) {
+			if _, err := parseSelector(selector); err != nil {
+				return fmt.Errorf("site %s: %w", domain, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ExtractSiteContent extracts the article text of doc using a site's selectors.
+// Skip regions are removed from doc first. When a content selector matches, the
+// text of the matched elements is returned with matched true; otherwise the
+// readability heuristic runs on what is left of the page.
+func ExtractSiteContent(doc *html.Node, selectors SiteSelectors) (content string, matched bool) {
+	for _, selector := range selectors.Skip {
+		compiled, err := parseSelector(selector)
+		if err != nil {
+			continue
+		}
+		for _, n := range compiled.findAll(doc) {
+			if n.Parent != nil {
+				n.Parent.RemoveChild(n)
+			}
+		}
+	}
+
+	for _, selector := range selectors.Content {
+		compiled, err := parseSelector(selector)
+		if err != nil {
+			continue
+		}
+		var parts []string
+		for _, n := range compiled.findAll(doc) {
+			if text := selectedText(n); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, "\n\n"), true
+		}
+	}
+
+	return ExtractReadableContent(doc), false
+}
+
+// selectedText is the paragraph text of an element chosen by a selector. Unlike
+// readability, nothing inside it is dropped for looking like page chrome.
+func selectedText(n *html.Node) string {
+	var blocks []string
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if n.Data == "script" || n.Data == "style" || n.Data == "noscript" {
+				return
+			}
+			if readableBlockTags[n.Data] {
+				if text := nodeText(n); text != "" {
+					blocks = append(blocks, text)
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+
+	if len(blocks) == 0 {
+		return nodeText(n)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// selector is a parsed CSS selector: comma-separated alternatives, each a chain of
+// compound selectors joined by the descendant combinator
+type selector [][]compoundSelector
+
+// compoundSelector matches one element by tag, id, classes and attributes
+type compoundSelector struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+}
+
+type attrSelector struct {
+	name     string
+	operator string // "" (present), "=", "*=", "^=" or "$="
+	value    string
+}
+
+// parseSelector parses the subset of CSS used for site overrides: type, #id, .class
+// and [attr], [attr=v], [attr*=v], [attr^=v], [attr$=v] selectors, descendant
+// combinators and comma-separated groups
+func parseSelector(s string) (selector, error) {
+	var sel selector
+	for _, group := range strings.Split(s, ",") {
+		var chain []compoundSelector
+		for _, part := range strings.Fields(group) {
+			compound, err := parseCompound(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+			}
+			chain = append(chain, compound)
+		}
+		if len(chain) == 0 {
+			return nil, fmt.Errorf("invalid selector %q: empty selector", s)
+		}
+		sel = append(sel, chain)
+	}
+	return sel, nil
+}
+
+func parseCompound(s string) (compoundSelector, error) {
+	var c compoundSelector
+	name := func(i int) int {
+		j := i
+		for j < len(s) && isNameByte(s[j]) {
+			j++
+		}
+		return j
+	}
+
+	i := name(0)
+	c.tag = strings.ToLower(s[:i])
+	if i == 0 && strings.HasPrefix(s, "*") {
+		i = 1
+	}
+	for i < len(s) {
+		switch s[i] {
+		case '.', '#':
+			end := name(i + 1)
+			if end == i+1 {
+				return c, fmt.Errorf("missing name after %q", s[i])
+			}
+			if s[i] == '.' {
+				c.classes = append(c.classes, s[i+1:end])
+			} else {
+				c.id = s[i+1 : end]
+			}
+			i = end
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return c, fmt.Errorf("unterminated attribute selector")
+			}
+			attr, err := parseAttr(s[i+1 : i+end])
+			if err != nil {
+				return c, err
+			}
+			c.attrs = append(c.attrs, attr)
+			i += end + 1
+		default:
+			return c, fmt.Errorf("unsupported syntax at %q", s[i:])
+		}
+	}
+	return c, nil
+}
+
+func parseAttr(s string) (attrSelector, error) {
+	for _, operator := range []string{"*=", "^=", "$=", "="} {
+		if i := strings.Index(s, operator); i > 0 {
+			value := strings.Trim(s[i+len(operator):], `"'`)
+			return attrSelector{name: strings.ToLower(s[:i]), operator: operator, value: value}, nil
+		}
+	}
+	if s == "" {
+		return attrSelector{}, fmt.Errorf("empty attribute selector")
+	}
+	return attrSelector{name: strings.ToLower(s)}, nil
+}
+
+func isNameByte(b byte) bool {
+	return b == '-' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// findAll returns the outermost elements matching the selector, in document order
+func (sel selector) findAll(doc *html.Node) []*html.Node {
+	var matches []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && sel.matches(n) {
+			matches = append(matches, n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return matches
+}
+
+func (sel selector) matches(n *html.Node) bool {
+	for _, chain := range sel {
+		if matchesChain(n, chain) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesChain matches the last compound against n and the rest against its ancestors
+func matchesChain(n *html.Node, chain []compoundSelector) bool {
+	last := len(chain) - 1
+	if !chain[last].matches(n) {
+		return false
+	}
+	i := last - 1
+	for p := n.Parent; p != nil && i >= 0; p = p.Parent {
+		if p.Type == html.ElementNode && chain[i].matches(p) {
+			i--
+		}
+	}
+	return i < 0
+}
+
+func (c compoundSelector) matches(n *html.Node) bool {
+	if c.tag != "" && n.Data != c.tag {
+		return false
+	}
+	if c.id != "" && attrValue(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		classes := strings.Fields(attrValue(n, "class"))
+		for _, want := range c.classes {
+			if !containsString(classes, want) {
+				return false
+			}
+		}
+	}
+	for _, attr := range c.attrs {
+		value, ok := attribute(n, attr.name)
+		if !ok {
+			return false
+		}
+		switch attr.operator {
+		case "=":
+			ok = value == attr.value
+		case "*=":
+			ok = strings.Contains(value, attr.value)
+		case "^=":
+			ok = strings.HasPrefix(value, attr.value)
+		case "$=":
+			ok = strings.HasSuffix(value, attr.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func attribute(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package extraction
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parseFixture(t *testing.T, name string) *html.Node {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return doc
+}
+
+func TestExtractSiteContent_CustomDomainOverride(t *testing.T) {
+	// The heuristic alone picks the readers' letters, not the story
+	if heuristic := ExtractReadableContent(parseFixture(t, "custom_gazette.html")); strings.Contains(heuristic, "harbour board resigned") {
+		t.Fatalf("expected the heuristic to miss the story, got %q", heuristic)
+	}
+
+	rules := SiteRules{"customgazette.test": {
+		Content: []string{"div.gz-copy[data-region=story]"},
+		Skip:    []string{".gz-ad"},
+	}}
+	selectors, ok := rules.Lookup("www.customgazette.test")
+	if !ok {
+		t.Fatal("expected rules for a subdomain of customgazette.test")
+	}
+
+	content, matched := ExtractSiteContent(parseFixture(t, "custom_gazette.html"), selectors)
+	if !matched {
+		t.Error("expected the content selector to match")
+	}
+	for _, want := range []string{"The chair of the harbour board resigned on Friday", "owned by her former business partner"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("expected %q in result, got %q", want, content)
+		}
+	}
+	for _, unwanted := range []string{"Advertisement", "Letters to the editor"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("did not expect %q in result, got %q", unwanted, content)
+		}
+	}
+}
+
+func TestExtractSiteContent_FallsBackToHeuristic(t *testing.T) {
+	page := `<html><body><div id="comments">` + strings.Repeat(`<p>A reader comment that is long enough to count as a paragraph, with commas, too.</p>`, 5) +
+		`</div><article>` + articleParagraphs + `</article></body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	// No content selector matches; the skip region is still removed before scoring
+	content, matched := ExtractSiteContent(doc, SiteSelectors{Content: []string{".missing"}, Skip: []string{"#comments"}})
+	if matched {
+		t.Error("expected no content selector match")
+	}
+	if !strings.Contains(content, "approved the contract") {
+		t.Errorf("expected the article in result, got %q", content)
+	}
+	if strings.Contains(content, "reader comment") {
+		t.Errorf("expected the skipped comments to be removed, got %q", content)
+	}
+}
+
+func TestSiteRules_Lookup(t *testing.T) {
+	rules := SiteRules{
+		"example.com":      {Content: []string{".generic"}},
+		"news.example.com": {Content: []string{".news"}},
+	}
+
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{host: "news.example.com", want: []string{".news"}},
+		{host: "WWW.Example.com", want: []string{".generic"}},
+		{host: "example.org"},
+		{host: "notexample.com"},
+	}
+	for _, tt := range tests {
+		selectors, ok := rules.Lookup(tt.host)
+		if ok != (tt.want != nil) || !reflect.DeepEqual(selectors.Content, tt.want) {
+			t.Errorf("Lookup(%q) = %v, %v; want %v", tt.host, selectors.Content, ok, tt.want)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<main id="story"><div class="a b" data-kind="story-body"><p>one</p></div></main>
+		<div class="a"><p>two</p></div>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"div.a.b", []string{"one"}},
+		{"#story div", []string{"one"}},
+		{"main .a", []string{"one"}},
+		{"[data-kind^=story]", []string{"one"}},
+		{`[data-kind="story-body"]`, []string{"one"}},
+		{"div.a", []string{"one", "two"}},
+		{"section, div.a", []string{"one", "two"}},
+		{"*.b", []string{"one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := parseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("parseSelector: %v", err)
+			}
+			var got []string
+			for _, n := range sel.findAll(doc) {
+				got = append(got, nodeText(n))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"div > p", "a:hover", "[href", "", "div,"} {
+		if _, err := parseSelector(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+	if err := (SiteRules{"example.com": {Skip: []string{"ul > li"}}}).Validate(); err == nil {
+		t.Error("expected Validate to reject an unsupported selector")
+	}
+}